@@ -20,6 +20,7 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers"
 	"github.com/aws/karpenter-provider-aws/pkg/operator"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/webhooks"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/metrics"
@@ -34,7 +35,7 @@ func main() {
 
 	awsCloudProvider := cloudprovider.New(
 		op.InstanceTypesProvider,
-		op.InstanceProvider,
+		op.AccountProvider,
 		op.EventRecorder,
 		op.GetClient(),
 		op.AMIProvider,
@@ -42,6 +43,9 @@ func main() {
 		op.SubnetProvider,
 	)
 	lo.Must0(op.AddHealthzCheck("cloud-provider", awsCloudProvider.LivenessProbe))
+	if options.FromContext(ctx).LaunchTemplateDebugEndpoint {
+		lo.Must0(op.AddMetricsServerExtraHandler("/debug/launchtemplates", awsCloudProvider.LaunchTemplatesHandler(ctx)))
+	}
 	cloudProvider := metrics.Decorate(awsCloudProvider)
 
 	op.
@@ -56,19 +60,15 @@ func main() {
 		WithControllers(ctx, controllers.NewControllers(
 			ctx,
 			op.Session,
+			op.EC2API,
 			op.Clock,
 			op.GetClient(),
 			op.EventRecorder,
-			op.UnavailableOfferingsCache,
 			cloudProvider,
-			op.SubnetProvider,
-			op.SecurityGroupProvider,
-			op.InstanceProfileProvider,
-			op.InstanceProvider,
+			op.AccountProvider,
 			op.PricingProvider,
-			op.AMIProvider,
 			op.LaunchTemplateProvider,
-			op.InstanceTypesProvider,
+			op.PlacementScoreProvider,
 		)...).
 		WithWebhooks(ctx, webhooks.NewWebhooks()...).
 		Start(ctx)
@@ -118,6 +118,7 @@ func getInstanceTypeInfo(info *ec2.InstanceTypeInfo) string {
 	fmt.Fprintf(src, "BurstablePerformanceSupported: aws.Bool(%t),\n", lo.FromPtr(info.BurstablePerformanceSupported))
 	fmt.Fprintf(src, "BareMetal: aws.Bool(%t),\n", lo.FromPtr(info.BareMetal))
 	fmt.Fprintf(src, "Hypervisor: aws.String(\"%s\"),\n", lo.FromPtr(info.Hypervisor))
+	fmt.Fprintf(src, "NitroEnclavesSupport: aws.String(\"%s\"),\n", lo.FromPtr(info.NitroEnclavesSupport))
 	fmt.Fprintf(src, "ProcessorInfo: &ec2.ProcessorInfo{\n")
 	fmt.Fprintf(src, "Manufacturer: aws.String(\"%s\"),\n", lo.FromPtr(info.ProcessorInfo.Manufacturer))
 	fmt.Fprintf(src, "SupportedArchitectures: aws.StringSlice([]string{%s}),\n", getStringSliceData(info.ProcessorInfo.SupportedArchitectures))
@@ -125,6 +126,15 @@ func getInstanceTypeInfo(info *ec2.InstanceTypeInfo) string {
 	fmt.Fprintf(src, "VCpuInfo: &ec2.VCpuInfo{\n")
 	fmt.Fprintf(src, "DefaultCores: aws.Int64(%d),\n", lo.FromPtr(info.VCpuInfo.DefaultCores))
 	fmt.Fprintf(src, "DefaultVCpus: aws.Int64(%d),\n", lo.FromPtr(info.VCpuInfo.DefaultVCpus))
+	if info.VCpuInfo.DefaultThreadsPerCore != nil {
+		fmt.Fprintf(src, "DefaultThreadsPerCore: aws.Int64(%d),\n", lo.FromPtr(info.VCpuInfo.DefaultThreadsPerCore))
+	}
+	if len(info.VCpuInfo.ValidCores) > 0 {
+		fmt.Fprintf(src, "ValidCores: aws.Int64Slice(%#v),\n", aws.Int64ValueSlice(info.VCpuInfo.ValidCores))
+	}
+	if len(info.VCpuInfo.ValidThreadsPerCore) > 0 {
+		fmt.Fprintf(src, "ValidThreadsPerCore: aws.Int64Slice(%#v),\n", aws.Int64ValueSlice(info.VCpuInfo.ValidThreadsPerCore))
+	}
 	fmt.Fprintf(src, "},\n")
 	fmt.Fprintf(src, "MemoryInfo: &ec2.MemoryInfo{\n")
 	fmt.Fprintf(src, "SizeInMiB: aws.Int64(%d),\n", lo.FromPtr(info.MemoryInfo.SizeInMiB))
@@ -187,6 +197,11 @@ func getInstanceTypeInfo(info *ec2.InstanceTypeInfo) string {
 	}
 	fmt.Fprintf(src, "},\n")
 	fmt.Fprintf(src, "},\n")
+	if info.PlacementGroupInfo != nil {
+		fmt.Fprintf(src, "PlacementGroupInfo: &ec2.PlacementGroupInfo{\n")
+		fmt.Fprintf(src, "SupportedStrategies: aws.StringSlice([]string{%s}),\n", getStringSliceData(info.PlacementGroupInfo.SupportedStrategies))
+		fmt.Fprintf(src, "},\n")
+	}
 	return src.String()
 }
 
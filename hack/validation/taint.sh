@@ -0,0 +1,9 @@
+# Taints Validation 
+
+# Adding validation for ec2nodeclass
+
+## Node-Taint
+yq eval '.spec.versions[0].schema.openAPIV3Schema.properties.spec.properties.nodeTaints.items.properties.key.minLength = 1' -i pkg/apis/crds/karpenter.k8s.aws_ec2nodeclasses.yaml
+yq eval '.spec.versions[0].schema.openAPIV3Schema.properties.spec.properties.nodeTaints.items.properties.key.pattern = "^([a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*(\/))?([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]$"' -i pkg/apis/crds/karpenter.k8s.aws_ec2nodeclasses.yaml
+yq eval '.spec.versions[0].schema.openAPIV3Schema.properties.spec.properties.nodeTaints.items.properties.value.pattern = "^([a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*(\/))?([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]$"' -i pkg/apis/crds/karpenter.k8s.aws_ec2nodeclasses.yaml
+yq eval '.spec.versions[0].schema.openAPIV3Schema.properties.spec.properties.nodeTaints.items.properties.effect.enum += ["NoSchedule","PreferNoSchedule","NoExecute"]' -i pkg/apis/crds/karpenter.k8s.aws_ec2nodeclasses.yaml
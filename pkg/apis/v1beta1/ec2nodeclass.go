@@ -16,9 +16,11 @@ package v1beta1
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
@@ -36,21 +38,41 @@ type EC2NodeClassSpec struct {
 	SubnetSelectorTerms []SubnetSelectorTerm `json:"subnetSelectorTerms" hash:"ignore"`
 	// SecurityGroupSelectorTerms is a list of or security group selector terms. The terms are ORed.
 	// +kubebuilder:validation:XValidation:message="securityGroupSelectorTerms cannot be empty",rule="self.size() != 0"
-	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id', 'name']",rule="self.all(x, has(x.tags) || has(x.id) || has(x.name))"
+	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id', 'name', 'clusterPrimarySecurityGroup']",rule="self.all(x, has(x.tags) || has(x.id) || has(x.name) || has(x.clusterPrimarySecurityGroup))"
 	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in securityGroupSelectorTerms",rule="!self.all(x, has(x.id) && (has(x.tags) || has(x.name)))"
 	// +kubebuilder:validation:XValidation:message="'name' is mutually exclusive, cannot be set with a combination of other fields in securityGroupSelectorTerms",rule="!self.all(x, has(x.name) && (has(x.tags) || has(x.id)))"
+	// +kubebuilder:validation:XValidation:message="'clusterPrimarySecurityGroup' is mutually exclusive, cannot be set with a combination of other fields in securityGroupSelectorTerms",rule="!self.exists(x, has(x.clusterPrimarySecurityGroup) && (has(x.tags) || has(x.id) || has(x.name)))"
 	// +kubebuilder:validation:MaxItems:=30
 	// +required
 	SecurityGroupSelectorTerms []SecurityGroupSelectorTerm `json:"securityGroupSelectorTerms" hash:"ignore"`
+	// SecurityGroupSelectorStrategy controls how the results of the securityGroupSelectorTerms are combined.
+	// Union selects the security groups that match any term, and Intersection selects the security groups
+	// that match every term. Defaults to Union.
+	// +optional
+	SecurityGroupSelectorStrategy *SecurityGroupSelectorStrategy `json:"securityGroupSelectorStrategy,omitempty" hash:"ignore"`
 	// AssociatePublicIPAddress controls if public IP addresses are assigned to instances that are launched with the nodeclass.
 	// +optional
 	AssociatePublicIPAddress *bool `json:"associatePublicIPAddress,omitempty"`
+	// NetworkMode declares whether instances that are launched with the nodeclass are expected to have public IP
+	// addresses. It doesn't change how instances are launched, but the resolved subnets are checked against it so that
+	// subnets that assign public IP addresses contrary to it are surfaced.
+	// +optional
+	NetworkMode *NetworkMode `json:"networkMode,omitempty" hash:"ignore"`
 	// AMISelectorTerms is a list of or ami selector terms. The terms are ORed.
-	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id', 'name']",rule="self.all(x, has(x.tags) || has(x.id) || has(x.name))"
+	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id', 'name', 'imagePipelineARN', 'ssmParameter']",rule="self.all(x, has(x.tags) || has(x.id) || has(x.name) || has(x.imagePipelineARN) || has(x.ssmParameter))"
 	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in amiSelectorTerms",rule="!self.all(x, has(x.id) && (has(x.tags) || has(x.name) || has(x.owner)))"
+	// +kubebuilder:validation:XValidation:message="'imagePipelineARN' is mutually exclusive, cannot be set with a combination of other fields in amiSelectorTerms",rule="!self.all(x, has(x.imagePipelineARN) && (has(x.tags) || has(x.id) || has(x.name) || has(x.owner)))"
+	// +kubebuilder:validation:XValidation:message="'ssmParameter' is mutually exclusive, cannot be set with a combination of other fields in amiSelectorTerms",rule="!self.all(x, has(x.ssmParameter) && (has(x.tags) || has(x.id) || has(x.name) || has(x.owner) || has(x.imagePipelineARN)))"
 	// +kubebuilder:validation:MaxItems:=30
 	// +optional
 	AMISelectorTerms []AMISelectorTerm `json:"amiSelectorTerms,omitempty" hash:"ignore"`
+	// CapacityReservationSelectorTerms is a list of or capacity reservation selector terms. The terms are ORed.
+	// On-demand nodes are launched into the best-matching active capacity reservation when one is available.
+	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id']",rule="self.all(x, has(x.tags) || has(x.id))"
+	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in capacityReservationSelectorTerms",rule="!self.all(x, has(x.id) && has(x.tags))"
+	// +kubebuilder:validation:MaxItems:=30
+	// +optional
+	CapacityReservationSelectorTerms []CapacityReservationSelectorTerm `json:"capacityReservationSelectorTerms,omitempty" hash:"ignore"`
 	// AMIFamily is the AMI family that instances use.
 	// +kubebuilder:validation:Enum:={AL2,AL2023,Bottlerocket,Ubuntu,Custom,Windows2019,Windows2022}
 	// +required
@@ -60,6 +82,22 @@ type EC2NodeClassSpec struct {
 	// this UserData to ensure nodes are being provisioned with the correct configuration.
 	// +optional
 	UserData *string `json:"userData,omitempty"`
+	// UserDataFragments are appended to the UserData of instances whose instance family or instance type matches the
+	// fragment. Fragments must be in the same format as UserData and are applied in the order they're specified.
+	// +kubebuilder:validation:MaxItems:=20
+	// +optional
+	UserDataFragments []UserDataFragment `json:"userDataFragments,omitempty"`
+	// TrustedCABundle is one or more PEM encoded CA certificates that are added to the system trust store of the
+	// provisioned nodes, e.g. to pull images from a private registry that's signed by an internal CA. It's applied by the
+	// generated UserData of every AMIFamily except Custom.
+	// +optional
+	TrustedCABundle *string `json:"trustedCABundle,omitempty"`
+	// RegistryMirrors are the mirrors, e.g. a pull-through cache, that containerd pulls the images of a registry through
+	// on the provisioned nodes. They're applied by the generated UserData of the AL2023 and Bottlerocket AMIFamilies.
+	// +kubebuilder:validation:XValidation:message="registry mirrors must have unique registries",rule="self.all(x, self.exists_one(y, x.registry == y.registry))"
+	// +kubebuilder:validation:MaxItems:=20
+	// +optional
+	RegistryMirrors []RegistryMirror `json:"registryMirrors,omitempty"`
 	// Role is the AWS identity that nodes use. This field is immutable.
 	// This field is mutually exclusive from instanceProfile.
 	// Marking this field as immutable avoids concerns around terminating managed instance profiles from running instances.
@@ -76,15 +114,37 @@ type EC2NodeClassSpec struct {
 	// +kubebuilder:validation:XValidation:rule="self != ''",message="instanceProfile cannot be empty"
 	// +optional
 	InstanceProfile *string `json:"instanceProfile,omitempty"`
+	// AssumeRoleARN is the ARN of an IAM role that Karpenter assumes to manage the instances of the EC2NodeClass. When
+	// it's set, the subnets, security groups, AMIs, instance profile, launch templates and instances of the EC2NodeClass
+	// are discovered and managed in the account of the role rather than in the account that Karpenter runs in.
+	// This field is immutable.
+	// +kubebuilder:validation:Pattern:="^arn:aws[a-z-]*:iam::[0-9]{12}:role/.+$"
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="immutable field changed"
+	// +optional
+	AssumeRoleARN *string `json:"assumeRoleARN,omitempty"`
 	// Tags to be applied on ec2 resources like instances and launch templates.
 	// +kubebuilder:validation:XValidation:message="empty tag keys aren't supported",rule="self.all(k, k != '')"
 	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching kubernetes.io/cluster/",rule="self.all(k, !k.startsWith('kubernetes.io/cluster') )"
 	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.sh/nodepool",rule="self.all(k, k != 'karpenter.sh/nodepool')"
 	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.sh/managed-by",rule="self.all(k, k !='karpenter.sh/managed-by')"
 	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.sh/nodeclaim",rule="self.all(k, k !='karpenter.sh/nodeclaim')"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.k8s.aws/nodeclaim-uid",rule="self.all(k, k !='karpenter.k8s.aws/nodeclaim-uid')"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.k8s.aws/stopped",rule="self.all(k, k !='karpenter.k8s.aws/stopped')"
 	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.k8s.aws/ec2nodeclass",rule="self.all(k, k !='karpenter.k8s.aws/ec2nodeclass')"
 	// +optional
 	Tags map[string]string `json:"tags,omitempty"`
+	// LabelTags maps NodeClaim label keys to the tag keys that their values are copied to when instances are launched.
+	// NodeClaims carry the labels of their NodePool's template, so this can be used to propagate ownership metadata such
+	// as a team or cost center to the instance. Tags in the tags field take precedence, values are sanitized to the
+	// characters that EC2 allows and label tags that would exceed the EC2 limit of 50 tags per resource are skipped.
+	// +kubebuilder:validation:XValidation:message="empty label keys aren't supported",rule="self.all(k, k != '')"
+	// +kubebuilder:validation:XValidation:message="empty tag keys aren't supported",rule="self.all(k, self[k] != '')"
+	// +kubebuilder:validation:XValidation:message="tag keys can't be longer than 128 characters",rule="self.all(k, self[k].size() <= 128)"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching kubernetes.io/cluster/",rule="self.all(k, !self[k].startsWith('kubernetes.io/cluster'))"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag domain karpenter.sh or karpenter.k8s.aws",rule="self.all(k, !self[k].startsWith('karpenter.sh/') && !self[k].startsWith('karpenter.k8s.aws/'))"
+	// +kubebuilder:validation:MaxProperties:=50
+	// +optional
+	LabelTags map[string]string `json:"labelTags,omitempty" hash:"ignore"`
 	// BlockDeviceMappings to be applied to provisioned nodes.
 	// +kubebuilder:validation:XValidation:message="must have only one blockDeviceMappings with rootVolume",rule="self.filter(x, has(x.rootVolume)?x.rootVolume==true:false).size() <= 1"
 	// +kubebuilder:validation:MaxItems:=50
@@ -93,9 +153,100 @@ type EC2NodeClassSpec struct {
 	// InstanceStorePolicy specifies how to handle instance-store disks.
 	// +optional
 	InstanceStorePolicy *InstanceStorePolicy `json:"instanceStorePolicy,omitempty"`
+	// Kubelet defines args to be used when configuring kubelet on nodes launched with this nodeclass.
+	// Values set here take precedence over the kubelet configuration on the NodePool.
+	// +kubebuilder:validation:XValidation:message="imageGCHighThresholdPercent must be greater than imageGCLowThresholdPercent",rule="has(self.imageGCHighThresholdPercent) && has(self.imageGCLowThresholdPercent) ?  self.imageGCHighThresholdPercent > self.imageGCLowThresholdPercent  : true"
+	// +kubebuilder:validation:XValidation:message="evictionSoft OwnerKey does not have a matching evictionSoftGracePeriod",rule="has(self.evictionSoft) ? self.evictionSoft.all(e, (e in self.evictionSoftGracePeriod)):true"
+	// +kubebuilder:validation:XValidation:message="evictionSoftGracePeriod OwnerKey does not have a matching evictionSoft",rule="has(self.evictionSoftGracePeriod) ? self.evictionSoftGracePeriod.all(e, (e in self.evictionSoft)):true"
+	// +optional
+	Kubelet *KubeletConfiguration `json:"kubelet,omitempty"`
+	// NodeLabels are additional labels that the kubelet applies to nodes when they register with the cluster.
+	// Unlike NodePool labels, these labels are present on the node before any pod can be scheduled to it.
+	// Labels that are managed by Karpenter or that use a restricted label domain can't be specified.
+	// +kubebuilder:validation:XValidation:message="label is managed by Karpenter",rule="self.all(x, !(x in ['karpenter.sh/nodepool','karpenter.sh/capacity-type','topology.kubernetes.io/zone','topology.kubernetes.io/region','node.kubernetes.io/instance-type','kubernetes.io/arch','kubernetes.io/os','node.kubernetes.io/windows-build']))"
+	// +kubebuilder:validation:XValidation:message="label domain \"kubernetes.io\" is restricted",rule="self.all(x, !x.find('^([^/]+)').endsWith('kubernetes.io') || (x.find('^([^/]+)').endsWith('node.kubernetes.io') && !x.find('^([^/]+)').endsWith('node-restriction.kubernetes.io')))"
+	// +kubebuilder:validation:XValidation:message="label domain \"k8s.io\" is restricted",rule="self.all(x, !x.find('^([^/]+)').endsWith('k8s.io'))"
+	// +kubebuilder:validation:XValidation:message="label domain \"karpenter.sh\" is restricted",rule="self.all(x, !x.find('^([^/]+)').endsWith('karpenter.sh'))"
+	// +kubebuilder:validation:XValidation:message="label domain \"karpenter.k8s.aws\" is restricted",rule="self.all(x, !x.find('^([^/]+)').endsWith('karpenter.k8s.aws'))"
+	// +kubebuilder:validation:MaxProperties:=100
+	// +optional
+	NodeLabels map[string]string `json:"nodeLabels,omitempty"`
+	// DisabledLabels are provider-generated well-known labels, e.g. karpenter.k8s.aws/instance-cpu, that aren't applied
+	// to nodes launched with the EC2NodeClass. Labels that are required for scheduling, e.g. the instance type and zone
+	// labels, can't be disabled. Disabled labels are still applied when the NodePool or the pods that a node is launched
+	// for have a requirement on them.
+	// +kubebuilder:validation:XValidation:message="only labels in the karpenter.k8s.aws domain can be disabled",rule="self.all(x, x.startsWith('karpenter.k8s.aws/instance-'))"
+	// +kubebuilder:validation:MaxItems:=50
+	// +optional
+	DisabledLabels []string `json:"disabledLabels,omitempty" hash:"ignore"`
+	// NodeTaints are additional taints that the kubelet applies to nodes when they register with the cluster.
+	// Unlike NodePool taints, these taints are present on the node before any pod can be scheduled to it.
+	// They aren't considered when Karpenter simulates scheduling, so they should be removed by another
+	// controller (e.g. a DaemonSet once it's ready), similar to NodePool startupTaints.
+	// +kubebuilder:validation:MaxItems:=50
+	// +optional
+	NodeTaints []v1.Taint `json:"nodeTaints,omitempty"`
+	// ExtendedResources are static extended resources, e.g. FPGAs or other devices that aren't advertised by a device
+	// plugin, that nodes launched with the EC2NodeClass advertise. They're added to the capacity of every instance type
+	// when scheduling, and to the capacity of the node once it registers with the cluster.
+	// +kubebuilder:validation:XValidation:message="extended resource names must be fully qualified, e.g. example.com/device",rule="self.all(x, x.contains('/'))"
+	// +kubebuilder:validation:XValidation:message="resource domain \"kubernetes.io\" is restricted",rule="self.all(x, !x.find('^([^/]+)').endsWith('kubernetes.io'))"
+	// +kubebuilder:validation:XValidation:message="extended resource names can't be prefixed with requests.",rule="self.all(x, !x.startsWith('requests.'))"
+	// +kubebuilder:validation:XValidation:message="resource is managed by Karpenter",rule="self.all(x, !(x in ['nvidia.com/gpu','amd.com/gpu','aws.amazon.com/neuron','habana.ai/gaudi','vpc.amazonaws.com/pod-eni','vpc.amazonaws.com/PrivateIPv4Address','vpc.amazonaws.com/efa']))"
+	// +kubebuilder:validation:XValidation:message="extended resource quantities must be positive",rule="self.all(x, self[x] > 0)"
+	// +kubebuilder:validation:MaxProperties:=20
+	// +optional
+	ExtendedResources map[v1.ResourceName]int64 `json:"extendedResources,omitempty"`
 	// DetailedMonitoring controls if detailed monitoring is enabled for instances that are launched
 	// +optional
 	DetailedMonitoring *bool `json:"detailedMonitoring,omitempty"`
+	// CPUCredits is the credit option for CPU usage of burstable performance instances, e.g. T instance types, that are
+	// launched with the EC2NodeClass. It isn't applied to instance types that aren't burstable.
+	// +kubebuilder:validation:Enum:={standard,unlimited}
+	// +optional
+	CPUCredits *string `json:"cpuCredits,omitempty"`
+	// NitroEnclaves enables AWS Nitro Enclaves on instances that are launched with the EC2NodeClass. When it's enabled,
+	// only instance types that support Nitro Enclaves are launched.
+	// +optional
+	NitroEnclaves *bool `json:"nitroEnclaves,omitempty"`
+	// NetworkCardIndex is the index of the network card that an additional network interface is attached to on instances
+	// that are launched with the EC2NodeClass. The primary network interface always stays on network card 0. When it's set,
+	// only instance types with more network cards than the index are launched.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	NetworkCardIndex *int64 `json:"networkCardIndex,omitempty"`
+	// DisableHyperthreading launches instances with one thread per CPU core, so that each vCPU of the node is a physical
+	// core. When it's enabled, only instance types that support a single thread per core are launched, and the vCPUs of
+	// instance types are their number of cores.
+	// +optional
+	DisableHyperthreading *bool `json:"disableHyperthreading,omitempty"`
+	// CPUOptions customizes the number of CPU cores and threads per core of instances that are launched with the
+	// EC2NodeClass. When it's set, only instance types that support the combination are launched.
+	// +optional
+	CPUOptions *CPUOptions `json:"cpuOptions,omitempty"`
+	// MaxHourlyPrice is the maximum hourly price, in USD, of instances that are launched with the EC2NodeClass.
+	// On-demand and spot offerings with a higher price aren't launched, regardless of the NodePool requirements.
+	// +kubebuilder:validation:Pattern:="^[0-9]+([.][0-9]+)?$"
+	// +kubebuilder:validation:XValidation:message="maxHourlyPrice must be greater than 0",rule="double(self) > 0.0"
+	// +optional
+	MaxHourlyPrice *string `json:"maxHourlyPrice,omitempty" hash:"ignore"`
+	// SpotMaxPrice caps the price that is bid for spot instances launched with the EC2NodeClass. When it isn't set,
+	// spot instances are bid at the on-demand price.
+	// +optional
+	SpotMaxPrice *SpotMaxPrice `json:"spotMaxPrice,omitempty" hash:"ignore"`
+	// OnDemandBaseCapacity is the number of instances in each CreateFleet request that are launched as on-demand, with
+	// the rest of the request launched as spot. It only applies to launches whose NodePool allows both spot and
+	// on-demand capacity. Launches for NodeClaims that are created together are batched into a single request.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	OnDemandBaseCapacity *int64 `json:"onDemandBaseCapacity,omitempty" hash:"ignore"`
+	// OnDemandPercentageAboveBaseCapacity is the percentage of the instances in each CreateFleet request above
+	// OnDemandBaseCapacity that are launched as on-demand, rounded up, with the rest of the request launched as spot.
+	// Like OnDemandBaseCapacity, it only applies to launches whose NodePool allows both spot and on-demand capacity.
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=100
+	// +optional
+	OnDemandPercentageAboveBaseCapacity *int64 `json:"onDemandPercentageAboveBaseCapacity,omitempty" hash:"ignore"`
 	// MetadataOptions for the generated launch template of provisioned nodes.
 	//
 	// This specifies the exposure of the Instance Metadata Service to
@@ -113,12 +264,72 @@ type EC2NodeClassSpec struct {
 	// +kubebuilder:default={"httpEndpoint":"enabled","httpProtocolIPv6":"disabled","httpPutResponseHopLimit":2,"httpTokens":"required"}
 	// +optional
 	MetadataOptions *MetadataOptions `json:"metadataOptions,omitempty"`
+	// Hardened launches instances without an EC2 key pair and with a hardened Instance Metadata Service. It requires
+	// tokens for metadata requests, sets the HTTP PUT response hop limit to 1 and doesn't expose instance tags in
+	// instance metadata. These values take precedence over httpTokens and httpPutResponseHopLimit in MetadataOptions.
+	// +optional
+	Hardened *bool `json:"hardened,omitempty"`
 	// Context is a Reserved field in EC2 APIs
 	// https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_CreateFleet.html
 	// +optional
 	Context *string `json:"context,omitempty"`
 }
 
+// KubeletConfiguration defines args to be used when configuring kubelet on provisioned nodes.
+// They are a subset of the upstream types, recognizing not all options may be supported.
+// Wherever possible, the types and names should reflect the upstream kubelet types.
+// https://pkg.go.dev/k8s.io/kubelet/config/v1beta1#KubeletConfiguration
+type KubeletConfiguration struct {
+	// clusterDNS is a list of IP addresses for the cluster DNS server.
+	// Note that not all providers may use all addresses.
+	// +optional
+	ClusterDNS []string `json:"clusterDNS,omitempty"`
+	// MaxPods is an override for the maximum number of pods that can run on
+	// a worker node instance.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	MaxPods *int32 `json:"maxPods,omitempty"`
+	// SystemReserved contains resources reserved for OS system daemons and kernel memory.
+	// +kubebuilder:validation:XValidation:message="valid keys for systemReserved are ['cpu','memory','ephemeral-storage','pid']",rule="self.all(x, x=='cpu' || x=='memory' || x=='ephemeral-storage' || x=='pid')"
+	// +kubebuilder:validation:XValidation:message="systemReserved value cannot be a negative resource quantity",rule="self.all(x, !self[x].startsWith('-'))"
+	// +optional
+	SystemReserved map[string]string `json:"systemReserved,omitempty"`
+	// KubeReserved contains resources reserved for Kubernetes system components.
+	// +kubebuilder:validation:XValidation:message="valid keys for kubeReserved are ['cpu','memory','ephemeral-storage','pid']",rule="self.all(x, x=='cpu' || x=='memory' || x=='ephemeral-storage' || x=='pid')"
+	// +kubebuilder:validation:XValidation:message="kubeReserved value cannot be a negative resource quantity",rule="self.all(x, !self[x].startsWith('-'))"
+	// +optional
+	KubeReserved map[string]string `json:"kubeReserved,omitempty"`
+	// EvictionHard is the map of signal names to quantities that define hard eviction thresholds
+	// +kubebuilder:validation:XValidation:message="valid keys for evictionHard are ['memory.available','nodefs.available','nodefs.inodesFree','imagefs.available','imagefs.inodesFree','pid.available']",rule="self.all(x, x in ['memory.available','nodefs.available','nodefs.inodesFree','imagefs.available','imagefs.inodesFree','pid.available'])"
+	// +optional
+	EvictionHard map[string]string `json:"evictionHard,omitempty"`
+	// EvictionSoft is the map of signal names to quantities that define soft eviction thresholds
+	// +kubebuilder:validation:XValidation:message="valid keys for evictionSoft are ['memory.available','nodefs.available','nodefs.inodesFree','imagefs.available','imagefs.inodesFree','pid.available']",rule="self.all(x, x in ['memory.available','nodefs.available','nodefs.inodesFree','imagefs.available','imagefs.inodesFree','pid.available'])"
+	// +optional
+	EvictionSoft map[string]string `json:"evictionSoft,omitempty"`
+	// EvictionSoftGracePeriod is the map of signal names to quantities that define grace periods for each eviction signal
+	// +kubebuilder:validation:XValidation:message="valid keys for evictionSoftGracePeriod are ['memory.available','nodefs.available','nodefs.inodesFree','imagefs.available','imagefs.inodesFree','pid.available']",rule="self.all(x, x in ['memory.available','nodefs.available','nodefs.inodesFree','imagefs.available','imagefs.inodesFree','pid.available'])"
+	// +optional
+	EvictionSoftGracePeriod map[string]metav1.Duration `json:"evictionSoftGracePeriod,omitempty"`
+	// ImageGCHighThresholdPercent is the percent of disk usage after which image
+	// garbage collection is always run. The percent is calculated by dividing this
+	// field value by 100, so this field must be between 0 and 100, inclusive.
+	// When specified, the value must be greater than ImageGCLowThresholdPercent.
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=100
+	// +optional
+	ImageGCHighThresholdPercent *int32 `json:"imageGCHighThresholdPercent,omitempty"`
+	// ImageGCLowThresholdPercent is the percent of disk usage before which image
+	// garbage collection is never run. Lowest disk usage to garbage collect to.
+	// The percent is calculated by dividing this field value by 100,
+	// so the field value must be between 0 and 100, inclusive.
+	// When specified, the value must be less than imageGCHighThresholdPercent
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=100
+	// +optional
+	ImageGCLowThresholdPercent *int32 `json:"imageGCLowThresholdPercent,omitempty"`
+}
+
 // SubnetSelectorTerm defines selection logic for a subnet used by Karpenter to launch nodes.
 // If multiple fields are used for selection, the requirements are ANDed.
 type SubnetSelectorTerm struct {
@@ -134,6 +345,84 @@ type SubnetSelectorTerm struct {
 	ID string `json:"id,omitempty"`
 }
 
+// CapacityReservationSelectorTerm defines selection logic for a capacity reservation used by Karpenter to launch nodes.
+// If multiple fields are used for selection, the requirements are ANDed.
+type CapacityReservationSelectorTerm struct {
+	// Tags is a map of key/value tags used to select capacity reservations
+	// Specifying '*' for a value selects all values for a given tag key.
+	// +kubebuilder:validation:XValidation:message="empty tag keys or values aren't supported",rule="self.all(k, k != '' && self[k] != '')"
+	// +kubebuilder:validation:MaxProperties:=20
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+	// ID is the capacity reservation id in EC2
+	// +kubebuilder:validation:Pattern="cr-[0-9a-z]+"
+	// +optional
+	ID string `json:"id,omitempty"`
+}
+
+// UserDataFragment is UserData that's only applied to instances of the matching instance families or instance types.
+// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['instanceFamilies', 'instanceTypes']",rule="has(self.instanceFamilies) || has(self.instanceTypes)"
+type UserDataFragment struct {
+	// InstanceFamilies are the instance families, e.g. "g5" or "p4d", that the fragment is applied to
+	// +kubebuilder:validation:MaxItems:=50
+	// +optional
+	InstanceFamilies []string `json:"instanceFamilies,omitempty"`
+	// InstanceTypes are the instance types, e.g. "g5.xlarge", that the fragment is applied to
+	// +kubebuilder:validation:MaxItems:=50
+	// +optional
+	InstanceTypes []string `json:"instanceTypes,omitempty"`
+	// UserData is appended to the UserData of matching instances
+	// +kubebuilder:validation:MinLength:=1
+	// +required
+	UserData string `json:"userData"`
+}
+
+// RegistryMirror is the mirrors that containerd pulls the images of a registry through.
+type RegistryMirror struct {
+	// Registry is the host, and optionally the port, of the registry whose images are pulled through the mirrors, e.g.
+	// "docker.io" or "registry.example.com:5000". "*" applies the mirrors to every registry.
+	// +kubebuilder:validation:Pattern:=`^(\*|[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?(:[0-9]+)?)$`
+	// +required
+	Registry string `json:"registry"`
+	// Endpoints are the http or https URLs of the mirrors, which are tried in order before the registry itself
+	// +kubebuilder:validation:XValidation:message="endpoints must be http or https URLs",rule="self.all(x, isURL(x) && (x.startsWith('http://') || x.startsWith('https://')))"
+	// +kubebuilder:validation:MinItems:=1
+	// +kubebuilder:validation:MaxItems:=10
+	// +required
+	Endpoints []string `json:"endpoints"`
+}
+
+// CPUOptions are the CPU options of instances, which customize the number of CPU cores and threads per core
+// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['coreCount', 'threadsPerCore']",rule="has(self.coreCount) || has(self.threadsPerCore)"
+type CPUOptions struct {
+	// CoreCount is the number of CPU cores of instances
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	CoreCount *int64 `json:"coreCount,omitempty"`
+	// ThreadsPerCore is the number of threads per CPU core of instances
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=2
+	// +optional
+	ThreadsPerCore *int64 `json:"threadsPerCore,omitempty"`
+}
+
+// SpotMaxPrice is the maximum price that is bid for spot instances, either as an absolute price or as a percentage of
+// the on-demand price of the instance type.
+// +kubebuilder:validation:XValidation:message="expected exactly one, got both or none, ['price', 'onDemandPercentage']",rule="has(self.price) != has(self.onDemandPercentage)"
+type SpotMaxPrice struct {
+	// Price is the maximum hourly price, in USD, that is bid for spot instances
+	// +kubebuilder:validation:Pattern:="^[0-9]+([.][0-9]+)?$"
+	// +kubebuilder:validation:XValidation:message="price must be greater than 0",rule="double(self) > 0.0"
+	// +optional
+	Price *string `json:"price,omitempty"`
+	// OnDemandPercentage is the maximum price that is bid for spot instances, as a percentage of the on-demand price
+	// of the instance type
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=100
+	// +optional
+	OnDemandPercentage *int64 `json:"onDemandPercentage,omitempty"`
+}
+
 // SecurityGroupSelectorTerm defines selection logic for a security group used by Karpenter to launch nodes.
 // If multiple fields are used for selection, the requirements are ANDed.
 type SecurityGroupSelectorTerm struct {
@@ -150,6 +439,10 @@ type SecurityGroupSelectorTerm struct {
 	// Name is the security group name in EC2.
 	// This value is the name field, which is different from the name tag.
 	Name string `json:"name,omitempty"`
+	// ClusterPrimarySecurityGroup selects the cluster security group that EKS created for the cluster, which is
+	// discovered through the DescribeCluster API for the configured cluster name.
+	// +optional
+	ClusterPrimarySecurityGroup bool `json:"clusterPrimarySecurityGroup,omitempty"`
 }
 
 // AMISelectorTerm defines selection logic for an ami used by Karpenter to launch nodes.
@@ -173,6 +466,26 @@ type AMISelectorTerm struct {
 	// You can specify a combination of AWS account IDs, "self", "amazon", and "aws-marketplace"
 	// +optional
 	Owner string `json:"owner,omitempty"`
+	// ImagePipelineARN is the ARN of an EC2 Image Builder image pipeline.
+	// The AMIs distributed to the region by the latest successful build of the pipeline are selected.
+	// +kubebuilder:validation:Pattern:="^arn:aws[a-z-]*:imagebuilder:[a-z0-9-]+:[0-9]{12}:image-pipeline/.+$"
+	// +optional
+	ImagePipelineARN string `json:"imagePipelineARN,omitempty"`
+	// SSMParameter is the name of an SSM parameter whose value is an AMI ID. The name can be suffixed with a version,
+	// e.g. /my/ami/parameter:3, to pin the selected AMI to that version of the parameter. The latest version of the
+	// parameter is selected otherwise.
+	// +kubebuilder:validation:Pattern:="^[a-zA-Z0-9_./-]+(:[0-9]+)?$"
+	// +optional
+	SSMParameter string `json:"ssmParameter,omitempty"`
+	// IncludeDeprecated selects the deprecated AMIs that match the term. EC2 only selects deprecated AMIs that are
+	// owned by the account or selected by ID otherwise.
+	// +optional
+	IncludeDeprecated bool `json:"includeDeprecated,omitempty"`
+	// Architecture constrains the term to the AMIs of the architecture, e.g. when a name pattern matches AMIs of both
+	// architectures. AMIs are matched to the architecture of instance types regardless of this field.
+	// +kubebuilder:validation:Enum:={amd64,arm64}
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
 }
 
 // MetadataOptions contains parameters for specifying the exposure of the
@@ -316,6 +629,28 @@ const (
 	InstanceStorePolicyRAID0 InstanceStorePolicy = "RAID0"
 )
 
+// SecurityGroupSelectorStrategy enumerates the ways the results of security group selector terms are combined.
+// +kubebuilder:validation:Enum={Union,Intersection}
+type SecurityGroupSelectorStrategy string
+
+const (
+	// SecurityGroupSelectorStrategyUnion selects the security groups that match any of the selector terms.
+	SecurityGroupSelectorStrategyUnion SecurityGroupSelectorStrategy = "Union"
+	// SecurityGroupSelectorStrategyIntersection selects the security groups that match all of the selector terms.
+	SecurityGroupSelectorStrategyIntersection SecurityGroupSelectorStrategy = "Intersection"
+)
+
+// NetworkMode enumerates the expected networking posture of instances.
+// +kubebuilder:validation:Enum={Private,Public}
+type NetworkMode string
+
+const (
+	// NetworkModePrivate expects instances to only have private IP addresses.
+	NetworkModePrivate NetworkMode = "Private"
+	// NetworkModePublic expects instances to be assigned public IP addresses.
+	NetworkModePublic NetworkMode = "Public"
+)
+
 // EC2NodeClass is the Schema for the EC2NodeClass API
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:path=ec2nodeclasses,scope=Cluster,categories=karpenter,shortName={ec2nc,ec2ncs}
@@ -327,6 +662,9 @@ type EC2NodeClass struct {
 	// +kubebuilder:validation:XValidation:message="amiSelectorTerms is required when amiFamily == 'Custom'",rule="self.amiFamily == 'Custom' ? self.amiSelectorTerms.size() != 0 : true"
 	// +kubebuilder:validation:XValidation:message="must specify exactly one of ['role', 'instanceProfile']",rule="(has(self.role) && !has(self.instanceProfile)) || (!has(self.role) && has(self.instanceProfile))"
 	// +kubebuilder:validation:XValidation:message="changing from 'instanceProfile' to 'role' is not supported. You must delete and recreate this node class if you want to change this.",rule="(has(oldSelf.role) && has(self.role)) || (has(oldSelf.instanceProfile) && has(self.instanceProfile))"
+	// +kubebuilder:validation:XValidation:message="adding or removing 'assumeRoleARN' is not supported. You must delete and recreate this node class if you want to change this.",rule="has(oldSelf.assumeRoleARN) == has(self.assumeRoleARN)"
+	// +kubebuilder:validation:XValidation:message="associatePublicIPAddress must match networkMode",rule="has(self.networkMode) && has(self.associatePublicIPAddress) ? self.associatePublicIPAddress == (self.networkMode == 'Public') : true"
+	// +kubebuilder:validation:XValidation:message="cpuOptions.threadsPerCore must be 1 when disableHyperthreading is enabled",rule="has(self.disableHyperthreading) && self.disableHyperthreading && has(self.cpuOptions) && has(self.cpuOptions.threadsPerCore) ? self.cpuOptions.threadsPerCore == 1 : true"
 	Spec   EC2NodeClassSpec   `json:"spec,omitempty"`
 	Status EC2NodeClassStatus `json:"status,omitempty"`
 }
@@ -345,7 +683,12 @@ func (in *EC2NodeClass) Hash() string {
 	})))
 }
 
+// InstanceProfileName returns the name of the instance profile of the nodeclass. This is the name of the instance
+// profile that Karpenter manages for the role, unless an instance profile is specified.
 func (in *EC2NodeClass) InstanceProfileName(clusterName, region string) string {
+	if in.Spec.InstanceProfile != nil {
+		return *in.Spec.InstanceProfile
+	}
 	return fmt.Sprintf("%s_%d", clusterName, lo.Must(hashstructure.Hash(fmt.Sprintf("%s%s", region, in.Name), hashstructure.FormatV2, nil)))
 }
 
@@ -361,6 +704,98 @@ func (in *EC2NodeClass) InstanceProfileTags(clusterName string) map[string]strin
 	})
 }
 
+// MaxHourlyPrice returns the maximum hourly price of instances launched with this nodeclass, or false if the nodeclass
+// doesn't set a maximum price or it can't be parsed.
+func (in *EC2NodeClass) MaxHourlyPrice() (float64, bool) {
+	if in.Spec.MaxHourlyPrice == nil {
+		return 0, false
+	}
+	price, err := strconv.ParseFloat(*in.Spec.MaxHourlyPrice, 64)
+	if err != nil {
+		return 0, false
+	}
+	return price, true
+}
+
+// CPUOptions returns the CPU options that instances launched with this nodeclass use, combining cpuOptions with
+// disableHyperthreading, or false if the nodeclass doesn't customize them.
+func (in *EC2NodeClass) CPUOptions() (CPUOptions, bool) {
+	var options CPUOptions
+	if in.Spec.CPUOptions != nil {
+		options = *in.Spec.CPUOptions
+	}
+	if lo.FromPtr(in.Spec.DisableHyperthreading) {
+		options.ThreadsPerCore = lo.ToPtr[int64](1)
+	}
+	return options, options.CoreCount != nil || options.ThreadsPerCore != nil
+}
+
+// SpotMaxPrice returns the maximum price to bid for spot instances of an instance type with the passed on-demand
+// price, or false if the nodeclass doesn't cap the spot price or the cap can't be resolved.
+func (in *EC2NodeClass) SpotMaxPrice(onDemandPrice float64, hasOnDemandPrice bool) (float64, bool) {
+	switch {
+	case in.Spec.SpotMaxPrice == nil:
+		return 0, false
+	case in.Spec.SpotMaxPrice.Price != nil:
+		price, err := strconv.ParseFloat(*in.Spec.SpotMaxPrice.Price, 64)
+		if err != nil {
+			return 0, false
+		}
+		return price, true
+	case in.Spec.SpotMaxPrice.OnDemandPercentage != nil && hasOnDemandPrice:
+		return onDemandPrice * float64(*in.Spec.SpotMaxPrice.OnDemandPercentage) / 100, true
+	}
+	return 0, false
+}
+
+// KubeletConfiguration returns the kubelet configuration for nodes launched with this nodeclass. Values set on the
+// nodeclass take precedence over the passed kubelet configuration, which is typically sourced from the NodePool.
+func (in *EC2NodeClass) KubeletConfiguration(base *corev1beta1.KubeletConfiguration) *corev1beta1.KubeletConfiguration {
+	if in.Spec.Kubelet == nil {
+		return base
+	}
+	kc := lo.FromPtr(base.DeepCopy())
+	if len(in.Spec.Kubelet.ClusterDNS) != 0 {
+		kc.ClusterDNS = in.Spec.Kubelet.ClusterDNS
+	}
+	if in.Spec.Kubelet.MaxPods != nil {
+		kc.MaxPods = in.Spec.Kubelet.MaxPods
+	}
+	if in.Spec.Kubelet.SystemReserved != nil {
+		kc.SystemReserved = in.Spec.Kubelet.SystemReserved
+	}
+	if in.Spec.Kubelet.KubeReserved != nil {
+		kc.KubeReserved = in.Spec.Kubelet.KubeReserved
+	}
+	if in.Spec.Kubelet.EvictionHard != nil {
+		kc.EvictionHard = in.Spec.Kubelet.EvictionHard
+	}
+	// Soft eviction thresholds and their grace periods must be specified together
+	if in.Spec.Kubelet.EvictionSoft != nil {
+		kc.EvictionSoft = in.Spec.Kubelet.EvictionSoft
+		kc.EvictionSoftGracePeriod = in.Spec.Kubelet.EvictionSoftGracePeriod
+	}
+	// Image GC thresholds are validated against each other, so they're overridden together to avoid mixing a high
+	// threshold from one object with a low threshold from the other
+	if in.Spec.Kubelet.ImageGCHighThresholdPercent != nil || in.Spec.Kubelet.ImageGCLowThresholdPercent != nil {
+		kc.ImageGCHighThresholdPercent = in.Spec.Kubelet.ImageGCHighThresholdPercent
+		kc.ImageGCLowThresholdPercent = in.Spec.Kubelet.ImageGCLowThresholdPercent
+	}
+	return &kc
+}
+
+// Taints returns the taints that nodes launched with this EC2NodeClass register with. The taints passed in take
+// precedence over NodeTaints that have the same key and effect.
+func (in *EC2NodeClass) Taints(base []v1.Taint) []v1.Taint {
+	taints := append([]v1.Taint{}, base...)
+	for _, taint := range in.Spec.NodeTaints {
+		if !lo.ContainsBy(taints, func(t v1.Taint) bool { return t.MatchTaint(&taint) }) {
+			taints = append(taints, taint)
+		}
+	}
+	return taints
+}
+
 // EC2NodeClassList contains a list of EC2NodeClass
 // +kubebuilder:object:root=true
 type EC2NodeClassList struct {
@@ -18,6 +18,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/imdario/mergo"
 	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
@@ -99,11 +100,15 @@ var _ = Describe("Hash", func() {
 		Entry("BlockDeviceMapping SnapshotID", "5250341140179985875", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{BlockDeviceMappings: []*v1beta1.BlockDeviceMapping{{EBS: &v1beta1.BlockDevice{SnapshotID: lo.ToPtr("test")}}}}}),
 		Entry("BlockDeviceMapping Throughput", "16711481758638864953", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{BlockDeviceMappings: []*v1beta1.BlockDeviceMapping{{EBS: &v1beta1.BlockDevice{Throughput: lo.ToPtr(int64(10))}}}}}),
 		Entry("BlockDeviceMapping VolumeType", "488614640133725370", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{BlockDeviceMappings: []*v1beta1.BlockDeviceMapping{{EBS: &v1beta1.BlockDevice{VolumeType: lo.ToPtr("io1")}}}}}),
+		Entry("Kubelet MaxPods", "11002903269337859368", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Kubelet: &v1beta1.KubeletConfiguration{MaxPods: lo.ToPtr(int32(10))}}}),
+		Entry("Kubelet EvictionHard", "4743363192657911442", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Kubelet: &v1beta1.KubeletConfiguration{EvictionHard: map[string]string{"memory.available": "5%"}}}}),
+		Entry("NodeLabels", "14726649025523946948", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{NodeLabels: map[string]string{"example.com/team": "a"}}}),
 
 		// Behavior / Dynamic fields, expect same hash as base
 		Entry("Modified AMISelector", staticHash, v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AMISelectorTerms: []v1beta1.AMISelectorTerm{{Tags: map[string]string{"ami-test-key": "ami-test-value"}}}}}),
 		Entry("Modified SubnetSelector", staticHash, v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{SubnetSelectorTerms: []v1beta1.SubnetSelectorTerm{{Tags: map[string]string{"subnet-test-key": "subnet-test-value"}}}}}),
 		Entry("Modified SecurityGroupSelector", staticHash, v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{SecurityGroupSelectorTerms: []v1beta1.SecurityGroupSelectorTerm{{Tags: map[string]string{"security-group-test-key": "security-group-test-value"}}}}}),
+		Entry("Modified NetworkMode", staticHash, v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{NetworkMode: lo.ToPtr(v1beta1.NetworkModePrivate)}}),
 	)
 	// We create a separate test for updating blockDeviceMapping volumeSize, since resource.Quantity is a struct, and mergo.WithSliceDeepCopy
 	// doesn't work well with unexported fields, like the ones that are present in resource.Quantity
@@ -116,6 +121,10 @@ var _ = Describe("Hash", func() {
 		nodeClass.Spec.InstanceProfile = lo.ToPtr("test-instance-profile")
 		Expect(nodeClass.Hash()).To(Equal("7914642030762404205"))
 	})
+	It("should match static hash for nodeTaints", func() {
+		nodeClass.Spec.NodeTaints = []v1.Taint{{Key: "example.com/not-ready", Effect: v1.TaintEffectNoSchedule}}
+		Expect(nodeClass.Hash()).To(Equal("18104867787685261556"))
+	})
 	It("should match static hash when reordering tags", func() {
 		nodeClass.Spec.Tags = map[string]string{"keyTag-2": "valueTag-2", "keyTag-1": "valueTag-1"}
 		Expect(nodeClass.Hash()).To(Equal(staticHash))
@@ -134,6 +143,15 @@ var _ = Describe("Hash", func() {
 		Entry("Tags", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Tags: map[string]string{"keyTag-test-3": "valueTag-test-3"}}}),
 		Entry("Context", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Context: aws.String("context-2")}}),
 		Entry("DetailedMonitoring", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{DetailedMonitoring: aws.Bool(true)}}),
+		Entry("CPUCredits", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{CPUCredits: aws.String("unlimited")}}),
+		Entry("NitroEnclaves", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{NitroEnclaves: aws.Bool(true)}}),
+		Entry("Hardened", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Hardened: aws.Bool(true)}}),
+		Entry("NetworkCardIndex", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{NetworkCardIndex: aws.Int64(1)}}),
+		Entry("DisableHyperthreading", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{DisableHyperthreading: aws.Bool(true)}}),
+		Entry("CPUOptions", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{CPUOptions: &v1beta1.CPUOptions{CoreCount: aws.Int64(2), ThreadsPerCore: aws.Int64(1)}}}),
+		Entry("ExtendedResources", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{ExtendedResources: map[v1.ResourceName]int64{"example.com/fpga": 1}}}),
+		Entry("TrustedCABundle", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{TrustedCABundle: aws.String("-----BEGIN CERTIFICATE-----")}}),
+		Entry("RegistryMirrors", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{RegistryMirrors: []v1beta1.RegistryMirror{{Registry: "docker.io", Endpoints: []string{"https://mirror.example.com"}}}}}),
 		Entry("AMIFamily", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AMIFamily: aws.String(v1beta1.AMIFamilyBottlerocket)}}),
 		Entry("InstanceStorePolicy", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{InstanceStorePolicy: lo.ToPtr(v1beta1.InstanceStorePolicyRAID0)}}),
 		Entry("AssociatePublicIPAddress", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AssociatePublicIPAddress: lo.ToPtr(true)}}),
@@ -198,6 +216,7 @@ var _ = Describe("Hash", func() {
 				Tags: map[string]string{"ami-test-key": "ami-test-value"},
 			},
 		}
+		nodeClass.Spec.DisabledLabels = []string{v1beta1.LabelInstanceCPU}
 		updatedHash := nodeClass.Hash()
 		Expect(hash).To(Equal(updatedHash))
 	})
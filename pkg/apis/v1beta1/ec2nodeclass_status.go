@@ -17,6 +17,7 @@ package v1beta1
 import (
 	"github.com/awslabs/operatorpkg/status"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // Subnet contains resolved Subnet selector values utilized for node launch
@@ -27,6 +28,12 @@ type Subnet struct {
 	// The associated availability zone
 	// +required
 	Zone string `json:"zone"`
+	// The ID of the associated availability zone
+	// +optional
+	ZoneID string `json:"zoneID,omitempty"`
+	// The ID of the VPC of the subnet
+	// +optional
+	VPCID string `json:"vpcID,omitempty"`
 }
 
 // SecurityGroup contains resolved SecurityGroup selector values utilized for node launch
@@ -37,6 +44,25 @@ type SecurityGroup struct {
 	// Name of the security group
 	// +optional
 	Name string `json:"name,omitempty"`
+	// The ID of the VPC of the security group
+	// +optional
+	VPCID string `json:"vpcID,omitempty"`
+}
+
+// CapacityReservation contains resolved CapacityReservation selector values utilized for node launch
+type CapacityReservation struct {
+	// ID of the capacity reservation
+	// +required
+	ID string `json:"id"`
+	// InstanceType of the capacity reservation
+	// +required
+	InstanceType string `json:"instanceType"`
+	// The associated availability zone
+	// +required
+	Zone string `json:"zone"`
+	// AvailableInstanceCount is the number of instances that can still be launched into the capacity reservation
+	// +optional
+	AvailableInstanceCount int64 `json:"availableInstanceCount,omitempty"`
 }
 
 // AMI contains resolved AMI selector values utilized for node launch
@@ -50,6 +76,16 @@ type AMI struct {
 	// Requirements of the AMI to be utilized on an instance type
 	// +required
 	Requirements []v1.NodeSelectorRequirement `json:"requirements"`
+	// RootDeviceName is the device name of the root volume of the AMI
+	// +optional
+	RootDeviceName string `json:"rootDeviceName,omitempty"`
+	// RootSnapshotSize is the size of the snapshot of the root volume of the AMI, which is the smallest size that the
+	// root volume can be launched with
+	// +optional
+	RootSnapshotSize *resource.Quantity `json:"rootSnapshotSize,omitempty"`
+	// Deprecated is true when the deprecation time of the AMI has passed
+	// +optional
+	Deprecated bool `json:"deprecated,omitempty"`
 }
 
 // EC2NodeClassStatus contains the resolved state of the EC2NodeClass
@@ -66,14 +102,63 @@ type EC2NodeClassStatus struct {
 	// cluster under the AMI selectors.
 	// +optional
 	AMIs []AMI `json:"amis,omitempty"`
+	// BlockDeviceMappings contains the block device mappings that are applied to instances launched with the
+	// EC2NodeClass, after the defaults of the AMI family are applied.
+	// +optional
+	BlockDeviceMappings []*BlockDeviceMapping `json:"blockDeviceMappings,omitempty"`
+	// CapacityReservations contains the current active capacity reservations that are available to the
+	// cluster under the capacity reservation selectors.
+	// +optional
+	CapacityReservations []CapacityReservation `json:"capacityReservations,omitempty"`
 	// InstanceProfile contains the resolved instance profile for the role
 	// +optional
 	InstanceProfile string `json:"instanceProfile,omitempty"`
+	// Role contains the role attached to the resolved instance profile
+	// +optional
+	Role string `json:"role,omitempty"`
 	// Conditions contains signals for health and readiness
 	// +optional
 	Conditions []status.Condition `json:"conditions,omitempty"`
 }
 
+const (
+	// ConditionTypeCapacityReservationsReady is false when capacityReservationSelectorTerms are set but don't match any
+	// active capacity reservations. It doesn't affect the readiness of the EC2NodeClass, since nodes can still be
+	// launched without a capacity reservation.
+	ConditionTypeCapacityReservationsReady = "CapacityReservationsReady"
+	// ConditionTypeOfferingsWithinMaxHourlyPrice is false when maxHourlyPrice is set and every available offering is
+	// priced above it, which prevents any instance from being launched with the EC2NodeClass.
+	ConditionTypeOfferingsWithinMaxHourlyPrice = "OfferingsWithinMaxHourlyPrice"
+	// ConditionTypeImagePipelineBuildsSucceeded is false when amiSelectorTerms select an EC2 Image Builder image pipeline
+	// whose latest build failed. AMIs from the last successful build of the pipeline continue to be used.
+	ConditionTypeImagePipelineBuildsSucceeded = "ImagePipelineBuildsSucceeded"
+	// ConditionTypeRootVolumeSizeSufficient is false when blockDeviceMappings request a root volume that is smaller than
+	// the root snapshot of a resolved AMI. The root volume is launched with the size of the snapshot instead.
+	ConditionTypeRootVolumeSizeSufficient = "RootVolumeSizeSufficient"
+	// ConditionTypeAMIsNotDeprecated is false when any of the resolved AMIs is deprecated. It doesn't affect the
+	// readiness of the EC2NodeClass, since deprecated AMIs can still be launched.
+	ConditionTypeAMIsNotDeprecated = "AMIsNotDeprecated"
+	// ConditionTypeTrustedCABundleValid is false when trustedCABundle is set but isn't a valid bundle of PEM encoded
+	// certificates. The EC2NodeClass isn't ready until it's fixed, since nodes wouldn't trust the intended CAs.
+	ConditionTypeTrustedCABundleValid = "TrustedCABundleValid"
+	// ConditionTypeInstanceProfileNotDrifted is false when the instance profile that Karpenter manages for the role has
+	// a different role assigned out-of-band, and Karpenter fails to assign the role back to it.
+	ConditionTypeInstanceProfileNotDrifted = "InstanceProfileNotDrifted"
+	// ConditionTypeAMISelectorTermsResolved is false when amiSelectorTerms don't match any AMIs. When the default AMIs
+	// of the amiFamily are used as a fallback, nodes are launched with them but existing nodes aren't drifted to them.
+	ConditionTypeAMISelectorTermsResolved = "AMISelectorTermsResolved"
+	// ConditionTypeCPUOptionsSupported is false when cpuOptions or disableHyperthreading request a combination of cores
+	// and threads per core that none of the instance types support, which prevents any instance from being launched.
+	ConditionTypeCPUOptionsSupported = "CPUOptionsSupported"
+	// ConditionTypeSubnetsMatchNetworkMode is false when networkMode is set and any of the resolved subnets would assign
+	// public IP addresses contrary to it. It doesn't affect the readiness of the EC2NodeClass.
+	ConditionTypeSubnetsMatchNetworkMode = "SubnetsMatchNetworkMode"
+	// ConditionTypeSubnetVPCsHaveSecurityGroups is false when resolved subnets are in a VPC that none of the resolved
+	// security groups are in. Instances are only launched into subnets with security groups from the same VPC, so those
+	// subnets aren't launched into.
+	ConditionTypeSubnetVPCsHaveSecurityGroups = "SubnetVPCsHaveSecurityGroups"
+)
+
 func (in *EC2NodeClass) StatusConditions() status.ConditionSet {
 	return status.NewReadyConditions().For(in)
 }
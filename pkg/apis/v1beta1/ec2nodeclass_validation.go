@@ -17,29 +17,57 @@ package v1beta1
 import (
 	"context"
 	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/samber/lo"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"knative.dev/pkg/apis"
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 )
 
 const (
-	subnetSelectorTermsPath        = "subnetSelectorTerms"
-	securityGroupSelectorTermsPath = "securityGroupSelectorTerms"
-	amiSelectorTermsPath           = "amiSelectorTerms"
-	amiFamilyPath                  = "amiFamily"
-	tagsPath                       = "tags"
-	metadataOptionsPath            = "metadataOptions"
-	blockDeviceMappingsPath        = "blockDeviceMappings"
-	rolePath                       = "role"
-	instanceProfilePath            = "instanceProfile"
+	subnetSelectorTermsPath              = "subnetSelectorTerms"
+	securityGroupSelectorTermsPath       = "securityGroupSelectorTerms"
+	amiSelectorTermsPath                 = "amiSelectorTerms"
+	capacityReservationSelectorTermsPath = "capacityReservationSelectorTerms"
+	amiFamilyPath                        = "amiFamily"
+	tagsPath                             = "tags"
+	metadataOptionsPath                  = "metadataOptions"
+	maxHourlyPricePath                   = "maxHourlyPrice"
+	spotMaxPricePath                     = "spotMaxPrice"
+	cpuOptionsPath                       = "cpuOptions"
+	networkModePath                      = "networkMode"
+	associatePublicIPAddressPath         = "associatePublicIPAddress"
+	userDataFragmentsPath                = "userDataFragments"
+	blockDeviceMappingsPath              = "blockDeviceMappings"
+	rolePath                             = "role"
+	instanceProfilePath                  = "instanceProfile"
+	kubeletPath                          = "kubelet"
+	nodeLabelsPath                       = "nodeLabels"
+	disabledLabelsPath                   = "disabledLabels"
+	nodeTaintsPath                       = "nodeTaints"
+	extendedResourcesPath                = "extendedResources"
+	registryMirrorsPath                  = "registryMirrors"
 )
 
 var (
-	minVolumeSize = *resource.NewScaledQuantity(1, resource.Giga)
-	maxVolumeSize = *resource.NewScaledQuantity(64, resource.Tera)
+	evictionSignals = []string{"memory.available", "nodefs.available", "nodefs.inodesFree", "imagefs.available", "imagefs.inodesFree", "pid.available"}
+	minVolumeSize   = *resource.NewScaledQuantity(1, resource.Giga)
+	maxVolumeSize   = *resource.NewScaledQuantity(64, resource.Tera)
+	// ssmParameterRegex matches SSM parameter names that are optionally suffixed with a version
+	ssmParameterRegex = regexp.MustCompile(`^[a-zA-Z0-9_./-]+(:[0-9]+)?$`)
+	// karpenterManagedResources are the resources that Karpenter computes the capacity of from the instance type
+	karpenterManagedResources = []v1.ResourceName{ResourceNVIDIAGPU, ResourceAMDGPU, ResourceAWSNeuron, ResourceHabanaGaudi,
+		ResourceAWSPodENI, ResourcePrivateIPv4Address, ResourceEFA}
 )
 
 func (in *EC2NodeClass) SupportedVerbs() []admissionregistrationv1.OperationType {
@@ -77,10 +105,22 @@ func (in *EC2NodeClassSpec) validate(_ context.Context) (errs *apis.FieldError)
 		in.validateSubnetSelectorTerms().ViaField(subnetSelectorTermsPath),
 		in.validateSecurityGroupSelectorTerms().ViaField(securityGroupSelectorTermsPath),
 		in.validateAMISelectorTerms().ViaField(amiSelectorTermsPath),
+		in.validateCapacityReservationSelectorTerms().ViaField(capacityReservationSelectorTermsPath),
 		in.validateMetadataOptions().ViaField(metadataOptionsPath),
+		in.validateMaxHourlyPrice(),
+		in.validateSpotMaxPrice().ViaField(spotMaxPricePath),
+		in.validateCPUOptions().ViaField(cpuOptionsPath),
+		in.validateNetworkMode(),
+		in.validateUserDataFragments().ViaField(userDataFragmentsPath),
 		in.validateAMIFamily().ViaField(amiFamilyPath),
 		in.validateBlockDeviceMappings().ViaField(blockDeviceMappingsPath),
 		in.validateTags().ViaField(tagsPath),
+		in.validateKubelet().ViaField(kubeletPath),
+		in.validateNodeLabels().ViaField(nodeLabelsPath),
+		in.validateDisabledLabels(),
+		in.validateNodeTaints(),
+		in.validateExtendedResources().ViaField(extendedResourcesPath),
+		in.validateRegistryMirrors(),
 	)
 }
 
@@ -117,8 +157,10 @@ func (in *EC2NodeClassSpec) validateSecurityGroupSelectorTerms() (errs *apis.Fie
 //nolint:gocyclo
 func (in *SecurityGroupSelectorTerm) validate() (errs *apis.FieldError) {
 	errs = errs.Also(validateTags(in.Tags).ViaField("tags"))
-	if len(in.Tags) == 0 && in.ID == "" && in.Name == "" {
-		errs = errs.Also(apis.ErrGeneric("expect at least one, got none", "tags", "id", "name"))
+	if len(in.Tags) == 0 && in.ID == "" && in.Name == "" && !in.ClusterPrimarySecurityGroup {
+		errs = errs.Also(apis.ErrGeneric("expect at least one, got none", "tags", "id", "name", "clusterPrimarySecurityGroup"))
+	} else if in.ClusterPrimarySecurityGroup && (len(in.Tags) > 0 || in.ID != "" || in.Name != "") {
+		errs = errs.Also(apis.ErrGeneric(`"clusterPrimarySecurityGroup" is mutually exclusive, cannot be set with a combination of other fields in`))
 	} else if in.ID != "" && (len(in.Tags) > 0 || in.Name != "") {
 		errs = errs.Also(apis.ErrGeneric(`"id" is mutually exclusive, cannot be set with a combination of other fields in`))
 	} else if in.Name != "" && (len(in.Tags) > 0 || in.ID != "") {
@@ -137,10 +179,36 @@ func (in *EC2NodeClassSpec) validateAMISelectorTerms() (errs *apis.FieldError) {
 //nolint:gocyclo
 func (in *AMISelectorTerm) validate() (errs *apis.FieldError) {
 	errs = errs.Also(validateTags(in.Tags).ViaField("tags"))
-	if len(in.Tags) == 0 && in.ID == "" && in.Name == "" {
-		errs = errs.Also(apis.ErrGeneric("expect at least one, got none", "tags", "id", "name"))
+	if len(in.Tags) == 0 && in.ID == "" && in.Name == "" && in.ImagePipelineARN == "" && in.SSMParameter == "" {
+		errs = errs.Also(apis.ErrGeneric("expect at least one, got none", "tags", "id", "name", "imagePipelineARN", "ssmParameter"))
 	} else if in.ID != "" && (len(in.Tags) > 0 || in.Name != "" || in.Owner != "") {
 		errs = errs.Also(apis.ErrGeneric(`"id" is mutually exclusive, cannot be set with a combination of other fields in`))
+	} else if in.ImagePipelineARN != "" && (len(in.Tags) > 0 || in.ID != "" || in.Name != "" || in.Owner != "") {
+		errs = errs.Also(apis.ErrGeneric(`"imagePipelineARN" is mutually exclusive, cannot be set with a combination of other fields in`))
+	} else if in.SSMParameter != "" && (len(in.Tags) > 0 || in.ID != "" || in.Name != "" || in.Owner != "" || in.ImagePipelineARN != "") {
+		errs = errs.Also(apis.ErrGeneric(`"ssmParameter" is mutually exclusive, cannot be set with a combination of other fields in`))
+	} else if in.SSMParameter != "" && !ssmParameterRegex.MatchString(in.SSMParameter) {
+		errs = errs.Also(apis.ErrInvalidValue(in.SSMParameter, "ssmParameter", "must be an SSM parameter name, optionally suffixed with a version"))
+	}
+	if in.Architecture != "" && !WellKnownArchitectures.Has(in.Architecture) {
+		errs = errs.Also(apis.ErrInvalidValue(in.Architecture, "architecture", fmt.Sprintf("must be one of %v", WellKnownArchitectures.List())))
+	}
+	return errs
+}
+
+func (in *EC2NodeClassSpec) validateCapacityReservationSelectorTerms() (errs *apis.FieldError) {
+	for i, term := range in.CapacityReservationSelectorTerms {
+		errs = errs.Also(term.validate()).ViaIndex(i)
+	}
+	return errs
+}
+
+func (in *CapacityReservationSelectorTerm) validate() (errs *apis.FieldError) {
+	errs = errs.Also(validateTags(in.Tags).ViaField("tags"))
+	if len(in.Tags) == 0 && in.ID == "" {
+		errs = errs.Also(apis.ErrGeneric("expected at least one, got none", "tags", "id"))
+	} else if in.ID != "" && len(in.Tags) > 0 {
+		errs = errs.Also(apis.ErrGeneric(`"id" is mutually exclusive, cannot be set with a combination of other fields in`))
 	}
 	return errs
 }
@@ -157,6 +225,87 @@ func validateTags(m map[string]string) (errs *apis.FieldError) {
 	return errs
 }
 
+func (in *EC2NodeClassSpec) validateMaxHourlyPrice() *apis.FieldError {
+	if in.MaxHourlyPrice == nil {
+		return nil
+	}
+	if price, err := strconv.ParseFloat(*in.MaxHourlyPrice, 64); err != nil || math.IsNaN(price) || math.IsInf(price, 0) || price <= 0 {
+		return apis.ErrInvalidValue(*in.MaxHourlyPrice, maxHourlyPricePath, "must be a price greater than 0")
+	}
+	return nil
+}
+
+func (in *EC2NodeClassSpec) validateSpotMaxPrice() *apis.FieldError {
+	if in.SpotMaxPrice == nil {
+		return nil
+	}
+	switch {
+	case in.SpotMaxPrice.Price != nil && in.SpotMaxPrice.OnDemandPercentage != nil:
+		return apis.ErrMultipleOneOf("price", "onDemandPercentage")
+	case in.SpotMaxPrice.Price != nil:
+		if price, err := strconv.ParseFloat(*in.SpotMaxPrice.Price, 64); err != nil || math.IsNaN(price) || math.IsInf(price, 0) || price <= 0 {
+			return apis.ErrInvalidValue(*in.SpotMaxPrice.Price, "price", "must be a price greater than 0")
+		}
+	case in.SpotMaxPrice.OnDemandPercentage != nil:
+		if percentage := *in.SpotMaxPrice.OnDemandPercentage; percentage < 1 || percentage > 100 {
+			return apis.ErrOutOfBoundsValue(percentage, 1, 100, "onDemandPercentage")
+		}
+	default:
+		return apis.ErrMissingOneOf("price", "onDemandPercentage")
+	}
+	return nil
+}
+
+func (in *EC2NodeClassSpec) validateCPUOptions() (errs *apis.FieldError) {
+	if in.CPUOptions == nil {
+		return nil
+	}
+	if in.CPUOptions.CoreCount == nil && in.CPUOptions.ThreadsPerCore == nil {
+		return apis.ErrMissingOneOf("coreCount", "threadsPerCore")
+	}
+	if in.CPUOptions.CoreCount != nil && *in.CPUOptions.CoreCount < 1 {
+		errs = errs.Also(apis.ErrInvalidValue(*in.CPUOptions.CoreCount, "coreCount", "must be at least 1"))
+	}
+	if threads := in.CPUOptions.ThreadsPerCore; threads != nil {
+		if *threads < 1 || *threads > 2 {
+			errs = errs.Also(apis.ErrOutOfBoundsValue(*threads, 1, 2, "threadsPerCore"))
+		} else if lo.FromPtr(in.DisableHyperthreading) && *threads != 1 {
+			errs = errs.Also(apis.ErrInvalidValue(*threads, "threadsPerCore", "must be 1 when disableHyperthreading is enabled"))
+		}
+	}
+	return errs
+}
+
+func (in *EC2NodeClassSpec) validateNetworkMode() *apis.FieldError {
+	if in.NetworkMode == nil {
+		return nil
+	}
+	if !lo.Contains([]NetworkMode{NetworkModePrivate, NetworkModePublic}, *in.NetworkMode) {
+		return apis.ErrInvalidValue(*in.NetworkMode, networkModePath, fmt.Sprintf("must be one of %s or %s", NetworkModePrivate, NetworkModePublic))
+	}
+	if in.AssociatePublicIPAddress != nil && *in.AssociatePublicIPAddress != (*in.NetworkMode == NetworkModePublic) {
+		return apis.ErrGeneric(fmt.Sprintf("%s must match %s", associatePublicIPAddressPath, networkModePath), associatePublicIPAddressPath, networkModePath)
+	}
+	return nil
+}
+
+func (in *EC2NodeClassSpec) validateUserDataFragments() (errs *apis.FieldError) {
+	for i, fragment := range in.UserDataFragments {
+		errs = errs.Also(fragment.validate().ViaIndex(i))
+	}
+	return errs
+}
+
+func (in *UserDataFragment) validate() (errs *apis.FieldError) {
+	if len(in.InstanceFamilies) == 0 && len(in.InstanceTypes) == 0 {
+		errs = errs.Also(apis.ErrGeneric("expected at least one, got none", "instanceFamilies", "instanceTypes"))
+	}
+	if in.UserData == "" {
+		errs = errs.Also(apis.ErrMissingField("userData"))
+	}
+	return errs
+}
+
 func (in *EC2NodeClassSpec) validateMetadataOptions() (errs *apis.FieldError) {
 	if in.MetadataOptions == nil {
 		return nil
@@ -296,6 +445,177 @@ func (in *EC2NodeClassSpec) validateTags() (errs *apis.FieldError) {
 	return errs
 }
 
+func (in *EC2NodeClassSpec) validateKubelet() (errs *apis.FieldError) {
+	if in.Kubelet == nil {
+		return nil
+	}
+	for i, ip := range in.Kubelet.ClusterDNS {
+		if net.ParseIP(ip) == nil {
+			errs = errs.Also(apis.ErrInvalidArrayValue(ip, "clusterDNS", i))
+		}
+	}
+	if in.Kubelet.MaxPods != nil && *in.Kubelet.MaxPods < 0 {
+		errs = errs.Also(apis.ErrInvalidValue(*in.Kubelet.MaxPods, "maxPods", "must be non-negative"))
+	}
+	for _, field := range []struct {
+		name  string
+		value *int32
+	}{
+		{name: "imageGCHighThresholdPercent", value: in.Kubelet.ImageGCHighThresholdPercent},
+		{name: "imageGCLowThresholdPercent", value: in.Kubelet.ImageGCLowThresholdPercent},
+	} {
+		if field.value != nil && (*field.value < 0 || *field.value > 100) {
+			errs = errs.Also(apis.ErrOutOfBoundsValue(*field.value, 0, 100, field.name))
+		}
+	}
+	if in.Kubelet.ImageGCHighThresholdPercent != nil && in.Kubelet.ImageGCLowThresholdPercent != nil &&
+		*in.Kubelet.ImageGCHighThresholdPercent <= *in.Kubelet.ImageGCLowThresholdPercent {
+		errs = errs.Also(apis.ErrInvalidValue(*in.Kubelet.ImageGCHighThresholdPercent, "imageGCHighThresholdPercent", "must be greater than imageGCLowThresholdPercent"))
+	}
+	errs = errs.Also(
+		validateReservedResources(in.Kubelet.SystemReserved).ViaField("systemReserved"),
+		validateReservedResources(in.Kubelet.KubeReserved).ViaField("kubeReserved"),
+		validateEvictionThresholds(in.Kubelet.EvictionHard).ViaField("evictionHard"),
+		validateEvictionThresholds(in.Kubelet.EvictionSoft).ViaField("evictionSoft"),
+	)
+	for k := range in.Kubelet.EvictionSoft {
+		if _, ok := in.Kubelet.EvictionSoftGracePeriod[k]; !ok {
+			errs = errs.Also(apis.ErrInvalidKeyName(k, "evictionSoft", "does not have a matching evictionSoftGracePeriod"))
+		}
+	}
+	for k := range in.Kubelet.EvictionSoftGracePeriod {
+		if _, ok := in.Kubelet.EvictionSoft[k]; !ok {
+			errs = errs.Also(apis.ErrInvalidKeyName(k, "evictionSoftGracePeriod", "does not have a matching evictionSoft"))
+		}
+	}
+	return errs
+}
+
+func validateReservedResources(m map[string]string) (errs *apis.FieldError) {
+	for k, v := range m {
+		if !lo.Contains([]string{"cpu", "memory", "ephemeral-storage", "pid"}, k) {
+			errs = errs.Also(apis.ErrInvalidKeyName(k, "", "valid keys are ['cpu','memory','ephemeral-storage','pid']"))
+			continue
+		}
+		if q, err := resource.ParseQuantity(v); err != nil || q.Sign() < 0 {
+			errs = errs.Also(apis.ErrInvalidValue(v, k, "must be a non-negative resource quantity"))
+		}
+	}
+	return errs
+}
+
+func validateEvictionThresholds(m map[string]string) (errs *apis.FieldError) {
+	for k, v := range m {
+		if !lo.Contains(evictionSignals, k) {
+			errs = errs.Also(apis.ErrInvalidKeyName(k, "", fmt.Sprintf("valid keys are %v", evictionSignals)))
+			continue
+		}
+		if strings.HasSuffix(v, "%") {
+			if p, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64); err != nil || p < 0 || p > 100 {
+				errs = errs.Also(apis.ErrInvalidValue(v, k, "must be a percentage between 0% and 100%"))
+			}
+			continue
+		}
+		if q, err := resource.ParseQuantity(v); err != nil || q.Sign() < 0 {
+			errs = errs.Also(apis.ErrInvalidValue(v, k, "must be a percentage or a non-negative resource quantity"))
+		}
+	}
+	return errs
+}
+
+func (in *EC2NodeClassSpec) validateNodeLabels() (errs *apis.FieldError) {
+	for k, v := range in.NodeLabels {
+		for _, err := range validation.IsQualifiedName(k) {
+			errs = errs.Also(apis.ErrInvalidKeyName(k, "", err))
+		}
+		for _, err := range validation.IsValidLabelValue(v) {
+			errs = errs.Also(apis.ErrInvalidValue(v, k, err))
+		}
+		// Well-known labels are managed by Karpenter and would collide with the values set from the NodeClaim. Unlike
+		// NodePool labels, the node-restriction and kops label domains aren't allowed since the kubelet can't set them.
+		domain := corev1beta1.GetLabelDomain(k)
+		if corev1beta1.IsRestrictedNodeLabel(k) || strings.HasSuffix(domain, "node-restriction.kubernetes.io") || strings.HasSuffix(domain, "kops.k8s.io") {
+			errs = errs.Also(apis.ErrInvalidKeyName(k, "", "label is restricted"))
+		}
+	}
+	return errs
+}
+
+func (in *EC2NodeClassSpec) validateExtendedResources() (errs *apis.FieldError) {
+	for name, quantity := range in.ExtendedResources {
+		for _, err := range validation.IsQualifiedName(string(name)) {
+			errs = errs.Also(apis.ErrInvalidKeyName(string(name), "", err))
+		}
+		// Resources without a domain or in the kubernetes.io domain are native resources, which can't be advertised
+		if !strings.Contains(string(name), "/") || strings.HasSuffix(corev1beta1.GetLabelDomain(string(name)), "kubernetes.io") ||
+			strings.HasPrefix(string(name), v1.DefaultResourceRequestsPrefix) {
+			errs = errs.Also(apis.ErrInvalidKeyName(string(name), "", "not an extended resource name"))
+		}
+		if lo.Contains(karpenterManagedResources, name) {
+			errs = errs.Also(apis.ErrInvalidKeyName(string(name), "", "resource is managed by Karpenter"))
+		}
+		if quantity <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(quantity, string(name), "quantity must be positive"))
+		}
+	}
+	return errs
+}
+
+func (in *EC2NodeClassSpec) validateDisabledLabels() (errs *apis.FieldError) {
+	for i, label := range in.DisabledLabels {
+		if !DisableableLabels.Has(label) {
+			errs = errs.Also(apis.ErrInvalidArrayValue(fmt.Sprintf("%s is not a provider-generated label that can be disabled", label), disabledLabelsPath, i))
+		}
+	}
+	return errs
+}
+
+func (in *EC2NodeClassSpec) validateNodeTaints() (errs *apis.FieldError) {
+	existing := map[string]struct{}{}
+	for i, taint := range in.NodeTaints {
+		for _, err := range validation.IsQualifiedName(taint.Key) {
+			errs = errs.Also(apis.ErrInvalidArrayValue(err, nodeTaintsPath, i))
+		}
+		if len(taint.Value) != 0 {
+			for _, err := range validation.IsQualifiedName(taint.Value) {
+				errs = errs.Also(apis.ErrInvalidArrayValue(err, nodeTaintsPath, i))
+			}
+		}
+		switch taint.Effect {
+		case v1.TaintEffectNoSchedule, v1.TaintEffectPreferNoSchedule, v1.TaintEffectNoExecute:
+		default:
+			errs = errs.Also(apis.ErrInvalidArrayValue(taint.Effect, nodeTaintsPath, i))
+		}
+		key := fmt.Sprintf("%s:%s", taint.Key, taint.Effect)
+		if _, ok := existing[key]; ok {
+			errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("duplicate taint Key/Effect pair %s=%s", taint.Key, taint.Effect), apis.CurrentField).
+				ViaFieldIndex(nodeTaintsPath, i))
+		}
+		existing[key] = struct{}{}
+	}
+	return errs
+}
+
+func (in *EC2NodeClassSpec) validateRegistryMirrors() (errs *apis.FieldError) {
+	existing := map[string]struct{}{}
+	for i, mirror := range in.RegistryMirrors {
+		if _, ok := existing[mirror.Registry]; ok {
+			errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("duplicate registry %s", mirror.Registry), "registry").
+				ViaFieldIndex(registryMirrorsPath, i))
+		}
+		existing[mirror.Registry] = struct{}{}
+		if len(mirror.Endpoints) == 0 {
+			errs = errs.Also(apis.ErrMissingField("endpoints").ViaFieldIndex(registryMirrorsPath, i))
+		}
+		for j, endpoint := range mirror.Endpoints {
+			if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = errs.Also(apis.ErrInvalidArrayValue(endpoint, "endpoints", j).ViaFieldIndex(registryMirrorsPath, i))
+			}
+		}
+	}
+	return errs
+}
+
 func (in *EC2NodeClassSpec) validateRoleImmutability(originalSpec *EC2NodeClassSpec) *apis.FieldError {
 	if in.Role != originalSpec.Role {
 		return &apis.FieldError{
@@ -15,9 +15,14 @@ limitations under the License.
 package v1beta1_test
 
 import (
+	"strings"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
@@ -91,6 +96,14 @@ var _ = Describe("CEL/Validation", func() {
 				"karpenter.sh/nodeclaim": "test",
 			}
 			Expect(env.Client.Create(ctx, nc)).To(Not(Succeed()))
+			nc.Spec.Tags = map[string]string{
+				v1beta1.TagNodeClaimUID: "test",
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Not(Succeed()))
+			nc.Spec.Tags = map[string]string{
+				v1beta1.TagInstanceStopped: "test",
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Not(Succeed()))
 		})
 	})
 	Context("SubnetSelectorTerms", func() {
@@ -191,6 +204,57 @@ var _ = Describe("CEL/Validation", func() {
 			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
 		})
 	})
+	Context("CapacityReservationSelectorTerms", func() {
+		It("should succeed when capacity reservation selector terms are omitted", func() {
+			nc.Spec.CapacityReservationSelectorTerms = nil
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed with a valid capacity reservation selector on tags", func() {
+			nc.Spec.CapacityReservationSelectorTerms = []v1beta1.CapacityReservationSelectorTerm{
+				{
+					Tags: map[string]string{
+						"test": "testvalue",
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed with a valid capacity reservation selector on id", func() {
+			nc.Spec.CapacityReservationSelectorTerms = []v1beta1.CapacityReservationSelectorTerm{
+				{
+					ID: "cr-12345749",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when a capacity reservation selector term has no values", func() {
+			nc.Spec.CapacityReservationSelectorTerms = []v1beta1.CapacityReservationSelectorTerm{
+				{},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when a capacity reservation selector term has a tag map value that is empty", func() {
+			nc.Spec.CapacityReservationSelectorTerms = []v1beta1.CapacityReservationSelectorTerm{
+				{
+					Tags: map[string]string{
+						"test": "",
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when specifying id with tags", func() {
+			nc.Spec.CapacityReservationSelectorTerms = []v1beta1.CapacityReservationSelectorTerm{
+				{
+					ID: "cr-12345749",
+					Tags: map[string]string{
+						"test": "testvalue",
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
 	Context("SecurityGroupSelectorTerms", func() {
 		It("should succeed with a valid security group selector on tags", func() {
 			nc.Spec.SecurityGroupSelectorTerms = []v1beta1.SecurityGroupSelectorTerm{
@@ -218,6 +282,39 @@ var _ = Describe("CEL/Validation", func() {
 			}
 			Expect(env.Client.Create(ctx, nc)).To(Succeed())
 		})
+		It("should succeed with a valid security group selector on the cluster primary security group", func() {
+			nc.Spec.SecurityGroupSelectorTerms = []v1beta1.SecurityGroupSelectorTerm{
+				{
+					ClusterPrimarySecurityGroup: true,
+				},
+				{
+					Tags: map[string]string{
+						"test": "testvalue",
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when specifying the cluster primary security group with tags", func() {
+			nc.Spec.SecurityGroupSelectorTerms = []v1beta1.SecurityGroupSelectorTerm{
+				{
+					ClusterPrimarySecurityGroup: true,
+					Tags: map[string]string{
+						"test": "testvalue",
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when specifying the cluster primary security group with id", func() {
+			nc.Spec.SecurityGroupSelectorTerms = []v1beta1.SecurityGroupSelectorTerm{
+				{
+					ClusterPrimarySecurityGroup: true,
+					ID:                          "sg-12345749",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
 		It("should fail when security group selector terms is set to nil", func() {
 			nc.Spec.SecurityGroupSelectorTerms = nil
 			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
@@ -317,7 +414,90 @@ var _ = Describe("CEL/Validation", func() {
 			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
 		})
 	})
+	Context("SecurityGroupSelectorStrategy", func() {
+		DescribeTable("should succeed with a valid strategy", func(strategy v1beta1.SecurityGroupSelectorStrategy) {
+			nc.Spec.SecurityGroupSelectorStrategy = lo.ToPtr(strategy)
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		},
+			Entry("Union", v1beta1.SecurityGroupSelectorStrategyUnion),
+			Entry("Intersection", v1beta1.SecurityGroupSelectorStrategyIntersection),
+		)
+		It("should fail with an invalid strategy", func() {
+			nc.Spec.SecurityGroupSelectorStrategy = lo.ToPtr(v1beta1.SecurityGroupSelectorStrategy("Difference"))
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("CPUCredits", func() {
+		DescribeTable("should succeed with a valid credit option", func(cpuCredits string) {
+			nc.Spec.CPUCredits = lo.ToPtr(cpuCredits)
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		},
+			Entry("standard", "standard"),
+			Entry("unlimited", "unlimited"),
+		)
+		It("should fail with an invalid credit option", func() {
+			nc.Spec.CPUCredits = lo.ToPtr("burst")
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("NetworkCardIndex", func() {
+		It("should succeed with a network card index other than the primary network card", func() {
+			nc.Spec.NetworkCardIndex = lo.ToPtr[int64](1)
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail with the primary network card index", func() {
+			nc.Spec.NetworkCardIndex = lo.ToPtr[int64](0)
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("RegistryMirrors", func() {
+		It("should succeed with valid registry mirrors", func() {
+			nc.Spec.RegistryMirrors = []v1beta1.RegistryMirror{
+				{Registry: "docker.io", Endpoints: []string{"https://mirror.example.com", "http://10.0.0.10:5000"}},
+				{Registry: "registry.example.com:5000", Endpoints: []string{"https://mirror.example.com"}},
+				{Registry: "*", Endpoints: []string{"https://default-mirror.example.com"}},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail with duplicate registries", func() {
+			nc.Spec.RegistryMirrors = []v1beta1.RegistryMirror{
+				{Registry: "docker.io", Endpoints: []string{"https://mirror.example.com"}},
+				{Registry: "docker.io", Endpoints: []string{"https://other-mirror.example.com"}},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail without endpoints", func() {
+			nc.Spec.RegistryMirrors = []v1beta1.RegistryMirror{{Registry: "docker.io", Endpoints: []string{}}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail with an endpoint that isn't an http or https URL", func() {
+			nc.Spec.RegistryMirrors = []v1beta1.RegistryMirror{{Registry: "docker.io", Endpoints: []string{"ftp://mirror.example.com"}}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail with an invalid registry", func() {
+			nc.Spec.RegistryMirrors = []v1beta1.RegistryMirror{{Registry: "https://docker.io", Endpoints: []string{"https://mirror.example.com"}}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
 	Context("AMISelectorTerms", func() {
+		It("should succeed with an architecture in an ami selector", func() {
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
+				{
+					Name:         "testname",
+					Architecture: "arm64",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail with an unknown architecture in an ami selector", func() {
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
+				{
+					Name:         "testname",
+					Architecture: "x86_64",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
 		It("should succeed with a valid ami selector on tags", func() {
 			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
 				{
@@ -344,6 +524,59 @@ var _ = Describe("CEL/Validation", func() {
 			}
 			Expect(env.Client.Create(ctx, nc)).To(Succeed())
 		})
+		It("should succeed with a valid ami selector on imagePipelineARN", func() {
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
+				{
+					ImagePipelineARN: "arn:aws:imagebuilder:us-west-2:123456789012:image-pipeline/test-pipeline",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when specifying imagePipelineARN with other fields", func() {
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
+				{
+					ImagePipelineARN: "arn:aws:imagebuilder:us-west-2:123456789012:image-pipeline/test-pipeline",
+					Name:             "testname",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail with an imagePipelineARN that isn't an image pipeline", func() {
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
+				{
+					ImagePipelineARN: "arn:aws:imagebuilder:us-west-2:123456789012:image-recipe/test-recipe/1.0.0",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		DescribeTable("should succeed with a valid ami selector on ssmParameter", func(parameter string) {
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
+				{
+					SSMParameter: parameter,
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		},
+			Entry("unversioned", "/test/ami/parameter"),
+			Entry("versioned", "/test/ami/parameter:3"),
+		)
+		It("should fail when specifying ssmParameter with other fields", func() {
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
+				{
+					SSMParameter: "/test/ami/parameter",
+					Owner:        "testowner",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail with an ssmParameter that has an invalid version", func() {
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
+				{
+					SSMParameter: "/test/ami/parameter:latest",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
 		It("should succeed with a valid ami selector on name and owner", func() {
 			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
 				{
@@ -457,6 +690,286 @@ var _ = Describe("CEL/Validation", func() {
 			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
 		})
 	})
+	Context("Kubelet", func() {
+		It("should succeed with valid kubelet configuration", func() {
+			nc.Spec.Kubelet = &v1beta1.KubeletConfiguration{
+				MaxPods:                     lo.ToPtr(int32(110)),
+				SystemReserved:              map[string]string{"cpu": "100m", "memory": "100Mi"},
+				EvictionHard:                map[string]string{"memory.available": "5%"},
+				EvictionSoft:                map[string]string{"memory.available": "10%"},
+				EvictionSoftGracePeriod:     map[string]metav1.Duration{"memory.available": {Duration: time.Minute}},
+				ImageGCHighThresholdPercent: lo.ToPtr(int32(80)),
+				ImageGCLowThresholdPercent:  lo.ToPtr(int32(60)),
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when maxPods is negative", func() {
+			nc.Spec.Kubelet = &v1beta1.KubeletConfiguration{MaxPods: lo.ToPtr(int32(-1))}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when imageGCHighThresholdPercent is not greater than imageGCLowThresholdPercent", func() {
+			nc.Spec.Kubelet = &v1beta1.KubeletConfiguration{
+				ImageGCHighThresholdPercent: lo.ToPtr(int32(50)),
+				ImageGCLowThresholdPercent:  lo.ToPtr(int32(60)),
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when kubeReserved contains an invalid resource", func() {
+			nc.Spec.Kubelet = &v1beta1.KubeletConfiguration{KubeReserved: map[string]string{"gpu": "1"}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when evictionSoft doesn't have a matching evictionSoftGracePeriod", func() {
+			nc.Spec.Kubelet = &v1beta1.KubeletConfiguration{EvictionSoft: map[string]string{"memory.available": "10%"}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("LabelTags", func() {
+		It("should succeed with labels mapped to custom tag keys", func() {
+			nc.Spec.LabelTags = map[string]string{"example.com/team": "team", corev1beta1.NodePoolLabelKey: "nodepool"}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		DescribeTable("should fail with invalid mappings", func(labelKey, tagKey string) {
+			nc.Spec.LabelTags = map[string]string{labelKey: tagKey}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		},
+			Entry("empty label key", "", "team"),
+			Entry("empty tag key", "example.com/team", ""),
+			Entry("tag key that's too long", "example.com/team", strings.Repeat("a", 129)),
+			Entry("cluster tag key", "example.com/team", "kubernetes.io/cluster/test"),
+			Entry("karpenter.sh tag key", "example.com/team", corev1beta1.NodePoolLabelKey),
+			Entry("karpenter.k8s.aws tag key", "example.com/team", v1beta1.LabelNodeClass),
+		)
+	})
+	Context("NodeLabels", func() {
+		It("should succeed with custom labels", func() {
+			nc.Spec.NodeLabels = map[string]string{"example.com/team": "team-a", "node.kubernetes.io/role": "worker"}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		DescribeTable("should fail with restricted labels", func(key string) {
+			nc.Spec.NodeLabels = map[string]string{key: "value"}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		},
+			Entry("well-known label", v1.LabelTopologyZone),
+			Entry("instance type label", v1.LabelInstanceTypeStable),
+			Entry("nodepool label", corev1beta1.NodePoolLabelKey),
+			Entry("capacity type label", corev1beta1.CapacityTypeLabelKey),
+			Entry("kubernetes.io domain", "kubernetes.io/custom"),
+			Entry("node-restriction.kubernetes.io domain", "node-restriction.kubernetes.io/team"),
+			Entry("k8s.io domain", "k8s.io/custom"),
+			Entry("kops.k8s.io domain", "kops.k8s.io/instancegroup"),
+			Entry("karpenter.sh domain", "karpenter.sh/custom"),
+			Entry("karpenter.k8s.aws domain", v1beta1.LabelInstanceFamily),
+		)
+	})
+	Context("DisabledLabels", func() {
+		It("should succeed with provider-generated labels", func() {
+			nc.Spec.DisabledLabels = []string{v1beta1.LabelInstanceCPU, v1beta1.LabelInstanceNetworkBandwidth}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		DescribeTable("should fail with labels that are required for scheduling", func(key string) {
+			nc.Spec.DisabledLabels = []string{key}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		},
+			Entry("zone label", v1.LabelTopologyZone),
+			Entry("instance type label", v1.LabelInstanceTypeStable),
+			Entry("architecture label", v1.LabelArchStable),
+			Entry("capacity type label", corev1beta1.CapacityTypeLabelKey),
+			Entry("zone id label", v1beta1.LabelTopologyZoneID),
+		)
+	})
+	Context("NodeTaints", func() {
+		It("should succeed with valid taints", func() {
+			nc.Spec.NodeTaints = []v1.Taint{
+				{Key: "example.com/not-ready", Effect: v1.TaintEffectNoSchedule},
+				{Key: "example.com/dedicated", Value: "team-a", Effect: v1.TaintEffectNoExecute},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail with an invalid taint key", func() {
+			nc.Spec.NodeTaints = []v1.Taint{{Key: "???", Effect: v1.TaintEffectNoSchedule}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail with an invalid taint value", func() {
+			nc.Spec.NodeTaints = []v1.Taint{{Key: "example.com/dedicated", Value: "team a", Effect: v1.TaintEffectNoSchedule}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail with an invalid taint effect", func() {
+			nc.Spec.NodeTaints = []v1.Taint{{Key: "example.com/not-ready", Effect: "Invalid"}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail without a taint effect", func() {
+			nc.Spec.NodeTaints = []v1.Taint{{Key: "example.com/not-ready"}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("ExtendedResources", func() {
+		It("should succeed with extended resources", func() {
+			nc.Spec.ExtendedResources = map[v1.ResourceName]int64{"example.com/fpga": 2, "devices.example.com/accelerator": 1}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		DescribeTable("should fail with invalid extended resources", func(name v1.ResourceName, quantity int64) {
+			nc.Spec.ExtendedResources = map[v1.ResourceName]int64{name: quantity}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		},
+			Entry("resource without a domain", v1.ResourceName("fpga"), int64(1)),
+			Entry("kubernetes.io domain", v1.ResourceName("kubernetes.io/fpga"), int64(1)),
+			Entry("requests. prefix", v1.ResourceName("requests.example.com/fpga"), int64(1)),
+			Entry("resource managed by Karpenter", v1beta1.ResourceNVIDIAGPU, int64(1)),
+			Entry("zero quantity", v1.ResourceName("example.com/fpga"), int64(0)),
+			Entry("negative quantity", v1.ResourceName("example.com/fpga"), int64(-1)),
+		)
+	})
+	Context("MaxHourlyPrice", func() {
+		It("should succeed with a valid price", func() {
+			nc.Spec.MaxHourlyPrice = aws.String("0.5")
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed with a whole number price", func() {
+			nc.Spec.MaxHourlyPrice = aws.String("2")
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail with a price that isn't a number", func() {
+			nc.Spec.MaxHourlyPrice = aws.String("one")
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail with a price of 0", func() {
+			nc.Spec.MaxHourlyPrice = aws.String("0.0")
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail with a negative price", func() {
+			nc.Spec.MaxHourlyPrice = aws.String("-1")
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("SpotMaxPrice", func() {
+		It("should succeed with a valid price", func() {
+			nc.Spec.SpotMaxPrice = &v1beta1.SpotMaxPrice{Price: aws.String("0.05")}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed with a valid on-demand percentage", func() {
+			nc.Spec.SpotMaxPrice = &v1beta1.SpotMaxPrice{OnDemandPercentage: aws.Int64(60)}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail with a price of 0", func() {
+			nc.Spec.SpotMaxPrice = &v1beta1.SpotMaxPrice{Price: aws.String("0")}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail with a price that isn't a number", func() {
+			nc.Spec.SpotMaxPrice = &v1beta1.SpotMaxPrice{Price: aws.String("one")}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		DescribeTable("should fail with an out of bounds on-demand percentage", func(percentage int64) {
+			nc.Spec.SpotMaxPrice = &v1beta1.SpotMaxPrice{OnDemandPercentage: aws.Int64(percentage)}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		},
+			Entry("0", int64(0)),
+			Entry("101", int64(101)),
+		)
+		It("should fail when both price and on-demand percentage are set", func() {
+			nc.Spec.SpotMaxPrice = &v1beta1.SpotMaxPrice{Price: aws.String("0.05"), OnDemandPercentage: aws.Int64(60)}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when neither price nor on-demand percentage are set", func() {
+			nc.Spec.SpotMaxPrice = &v1beta1.SpotMaxPrice{}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("OnDemandPercentageAboveBaseCapacity", func() {
+		DescribeTable("should succeed with a percentage within bounds", func(percentage int64) {
+			nc.Spec.OnDemandPercentageAboveBaseCapacity = aws.Int64(percentage)
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		},
+			Entry("0", int64(0)),
+			Entry("50", int64(50)),
+			Entry("100", int64(100)),
+		)
+		DescribeTable("should fail with an out of bounds percentage", func(percentage int64) {
+			nc.Spec.OnDemandPercentageAboveBaseCapacity = aws.Int64(percentage)
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		},
+			Entry("-1", int64(-1)),
+			Entry("101", int64(101)),
+		)
+	})
+	Context("NetworkMode", func() {
+		DescribeTable("should succeed with a valid network mode", func(mode v1beta1.NetworkMode) {
+			nc.Spec.NetworkMode = lo.ToPtr(mode)
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		},
+			Entry("Private", v1beta1.NetworkModePrivate),
+			Entry("Public", v1beta1.NetworkModePublic),
+		)
+		It("should fail with an invalid network mode", func() {
+			nc.Spec.NetworkMode = lo.ToPtr(v1beta1.NetworkMode("Hybrid"))
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should succeed when associatePublicIPAddress matches the network mode", func() {
+			nc.Spec.NetworkMode = lo.ToPtr(v1beta1.NetworkModePrivate)
+			nc.Spec.AssociatePublicIPAddress = aws.Bool(false)
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when associatePublicIPAddress contradicts the network mode", func() {
+			nc.Spec.NetworkMode = lo.ToPtr(v1beta1.NetworkModePrivate)
+			nc.Spec.AssociatePublicIPAddress = aws.Bool(true)
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("CPUOptions", func() {
+		It("should succeed with a core count and threads per core", func() {
+			nc.Spec.CPUOptions = &v1beta1.CPUOptions{CoreCount: aws.Int64(4), ThreadsPerCore: aws.Int64(1)}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed with only a core count", func() {
+			nc.Spec.CPUOptions = &v1beta1.CPUOptions{CoreCount: aws.Int64(4)}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when neither core count nor threads per core are set", func() {
+			nc.Spec.CPUOptions = &v1beta1.CPUOptions{}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail with a core count of 0", func() {
+			nc.Spec.CPUOptions = &v1beta1.CPUOptions{CoreCount: aws.Int64(0)}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		DescribeTable("should fail with an out of bounds threads per core", func(threads int64) {
+			nc.Spec.CPUOptions = &v1beta1.CPUOptions{ThreadsPerCore: aws.Int64(threads)}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		},
+			Entry("0", int64(0)),
+			Entry("3", int64(3)),
+		)
+		It("should succeed with a single thread per core when hyperthreading is disabled", func() {
+			nc.Spec.DisableHyperthreading = aws.Bool(true)
+			nc.Spec.CPUOptions = &v1beta1.CPUOptions{ThreadsPerCore: aws.Int64(1)}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail with two threads per core when hyperthreading is disabled", func() {
+			nc.Spec.DisableHyperthreading = aws.Bool(true)
+			nc.Spec.CPUOptions = &v1beta1.CPUOptions{ThreadsPerCore: aws.Int64(2)}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("UserDataFragments", func() {
+		It("should succeed with fragments for instance families and instance types", func() {
+			nc.Spec.UserDataFragments = []v1beta1.UserDataFragment{
+				{InstanceFamilies: []string{"p4d", "g5"}, UserData: "echo gpu"},
+				{InstanceTypes: []string{"m5.large"}, UserData: "echo m5"},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when a fragment doesn't specify instance families or instance types", func() {
+			nc.Spec.UserDataFragments = []v1beta1.UserDataFragment{
+				{UserData: "echo all"},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when a fragment has empty user data", func() {
+			nc.Spec.UserDataFragments = []v1beta1.UserDataFragment{
+				{InstanceFamilies: []string{"p4d"}},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
 	Context("MetadataOptions", func() {
 		It("should succeed for valid inputs", func() {
 			nc.Spec.MetadataOptions = &v1beta1.MetadataOptions{
@@ -653,4 +1166,42 @@ var _ = Describe("CEL/Validation", func() {
 			Expect(env.Client.Update(ctx, nc)).ToNot(Succeed())
 		})
 	})
+	Context("AssumeRoleARN", func() {
+		It("should succeed with a valid role ARN", func() {
+			nc.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::123456789012:role/karpenter-nodes")
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed with a role ARN in another partition", func() {
+			nc.Spec.AssumeRoleARN = lo.ToPtr("arn:aws-us-gov:iam::123456789012:role/path/karpenter-nodes")
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail with an ARN that isn't a role ARN", func() {
+			nc.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::123456789012:user/karpenter")
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail with an invalid account ID", func() {
+			nc.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::1234:role/karpenter-nodes")
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when updating the role ARN", func() {
+			nc.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::123456789012:role/karpenter-nodes")
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+
+			nc.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::210987654321:role/karpenter-nodes")
+			Expect(env.Client.Update(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when adding the role ARN", func() {
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+
+			nc.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::123456789012:role/karpenter-nodes")
+			Expect(env.Client.Update(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when removing the role ARN", func() {
+			nc.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::123456789012:role/karpenter-nodes")
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+
+			nc.Spec.AssumeRoleARN = nil
+			Expect(env.Client.Update(ctx, nc)).ToNot(Succeed())
+		})
+	})
 })
@@ -15,9 +15,14 @@ limitations under the License.
 package v1beta1_test
 
 import (
+	"time"
+
 	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"knative.dev/pkg/apis"
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 
 	"github.com/aws/aws-sdk-go/aws"
 
@@ -103,6 +108,14 @@ var _ = Describe("Webhook/Validation", func() {
 				"karpenter.sh/nodeclaim": "test",
 			}
 			Expect(nc.Validate(ctx)).To(Not(Succeed()))
+			nc.Spec.Tags = map[string]string{
+				v1beta1.TagNodeClaimUID: "test",
+			}
+			Expect(nc.Validate(ctx)).To(Not(Succeed()))
+			nc.Spec.Tags = map[string]string{
+				v1beta1.TagInstanceStopped: "test",
+			}
+			Expect(nc.Validate(ctx)).To(Not(Succeed()))
 		})
 	})
 	Context("SubnetSelectorTerms", func() {
@@ -203,6 +216,57 @@ var _ = Describe("Webhook/Validation", func() {
 			Expect(nc.Validate(ctx)).ToNot(Succeed())
 		})
 	})
+	Context("CapacityReservationSelectorTerms", func() {
+		It("should succeed when capacity reservation selector terms are omitted", func() {
+			nc.Spec.CapacityReservationSelectorTerms = nil
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should succeed with a valid capacity reservation selector on tags", func() {
+			nc.Spec.CapacityReservationSelectorTerms = []v1beta1.CapacityReservationSelectorTerm{
+				{
+					Tags: map[string]string{
+						"test": "testvalue",
+					},
+				},
+			}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should succeed with a valid capacity reservation selector on id", func() {
+			nc.Spec.CapacityReservationSelectorTerms = []v1beta1.CapacityReservationSelectorTerm{
+				{
+					ID: "cr-12345749",
+				},
+			}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail when a capacity reservation selector term has no values", func() {
+			nc.Spec.CapacityReservationSelectorTerms = []v1beta1.CapacityReservationSelectorTerm{
+				{},
+			}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail when a capacity reservation selector term has a tag map value that is empty", func() {
+			nc.Spec.CapacityReservationSelectorTerms = []v1beta1.CapacityReservationSelectorTerm{
+				{
+					Tags: map[string]string{
+						"test": "",
+					},
+				},
+			}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail when specifying id with tags", func() {
+			nc.Spec.CapacityReservationSelectorTerms = []v1beta1.CapacityReservationSelectorTerm{
+				{
+					ID: "cr-12345749",
+					Tags: map[string]string{
+						"test": "testvalue",
+					},
+				},
+			}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+	})
 	Context("SecurityGroupSelectorTerms", func() {
 		It("should succeed with a valid security group selector on tags", func() {
 			nc.Spec.SecurityGroupSelectorTerms = []v1beta1.SecurityGroupSelectorTerm{
@@ -230,6 +294,39 @@ var _ = Describe("Webhook/Validation", func() {
 			}
 			Expect(nc.Validate(ctx)).To(Succeed())
 		})
+		It("should succeed with a valid security group selector on the cluster primary security group", func() {
+			nc.Spec.SecurityGroupSelectorTerms = []v1beta1.SecurityGroupSelectorTerm{
+				{
+					ClusterPrimarySecurityGroup: true,
+				},
+				{
+					Tags: map[string]string{
+						"test": "testvalue",
+					},
+				},
+			}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail when specifying the cluster primary security group with tags", func() {
+			nc.Spec.SecurityGroupSelectorTerms = []v1beta1.SecurityGroupSelectorTerm{
+				{
+					ClusterPrimarySecurityGroup: true,
+					Tags: map[string]string{
+						"test": "testvalue",
+					},
+				},
+			}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail when specifying the cluster primary security group with id", func() {
+			nc.Spec.SecurityGroupSelectorTerms = []v1beta1.SecurityGroupSelectorTerm{
+				{
+					ClusterPrimarySecurityGroup: true,
+					ID:                          "sg-12345749",
+				},
+			}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
 		It("should fail when security group selector terms is set to nil", func() {
 			nc.Spec.SecurityGroupSelectorTerms = nil
 			Expect(nc.Validate(ctx)).ToNot(Succeed())
@@ -330,6 +427,24 @@ var _ = Describe("Webhook/Validation", func() {
 		})
 	})
 	Context("AMISelectorTerms", func() {
+		It("should succeed with an architecture in an ami selector", func() {
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
+				{
+					Name:         "testname",
+					Architecture: "arm64",
+				},
+			}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail with an unknown architecture in an ami selector", func() {
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
+				{
+					Name:         "testname",
+					Architecture: "x86_64",
+				},
+			}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
 		It("should succeed with a valid ami selector on tags", func() {
 			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
 				{
@@ -356,6 +471,51 @@ var _ = Describe("Webhook/Validation", func() {
 			}
 			Expect(nc.Validate(ctx)).To(Succeed())
 		})
+		It("should succeed with a valid ami selector on imagePipelineARN", func() {
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
+				{
+					ImagePipelineARN: "arn:aws:imagebuilder:us-west-2:123456789012:image-pipeline/test-pipeline",
+				},
+			}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail when specifying imagePipelineARN with other fields", func() {
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
+				{
+					ImagePipelineARN: "arn:aws:imagebuilder:us-west-2:123456789012:image-pipeline/test-pipeline",
+					Name:             "testname",
+				},
+			}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		DescribeTable("should succeed with a valid ami selector on ssmParameter", func(parameter string) {
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
+				{
+					SSMParameter: parameter,
+				},
+			}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		},
+			Entry("unversioned", "/test/ami/parameter"),
+			Entry("versioned", "/test/ami/parameter:3"),
+		)
+		It("should fail when specifying ssmParameter with other fields", func() {
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
+				{
+					SSMParameter: "/test/ami/parameter",
+					Owner:        "testowner",
+				},
+			}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail with an ssmParameter that has an invalid version", func() {
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
+				{
+					SSMParameter: "/test/ami/parameter:latest",
+				},
+			}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
 		It("should succeed with a valid ami selector on name and owner", func() {
 			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
 				{
@@ -492,6 +652,324 @@ var _ = Describe("Webhook/Validation", func() {
 			Expect(nodeClass.Validate(ctx)).To(Not(Succeed()))
 		})
 	})
+	Context("Kubelet", func() {
+		It("should succeed with valid kubelet configuration", func() {
+			nc.Spec.Kubelet = &v1beta1.KubeletConfiguration{
+				MaxPods:                     lo.ToPtr(int32(110)),
+				SystemReserved:              map[string]string{"cpu": "100m", "memory": "100Mi"},
+				KubeReserved:                map[string]string{"cpu": "200m", "ephemeral-storage": "1Gi"},
+				EvictionHard:                map[string]string{"memory.available": "5%", "nodefs.available": "1Gi"},
+				EvictionSoft:                map[string]string{"memory.available": "10%"},
+				EvictionSoftGracePeriod:     map[string]metav1.Duration{"memory.available": {Duration: time.Minute}},
+				ImageGCHighThresholdPercent: lo.ToPtr(int32(80)),
+				ImageGCLowThresholdPercent:  lo.ToPtr(int32(60)),
+			}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should succeed when clusterDNS contains IPv4 and IPv6 addresses", func() {
+			nc.Spec.Kubelet = &v1beta1.KubeletConfiguration{ClusterDNS: []string{"10.0.1.100", "fd4b:121b:812b::a"}}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail when clusterDNS contains an invalid IP address", func() {
+			nc.Spec.Kubelet = &v1beta1.KubeletConfiguration{ClusterDNS: []string{"10.0.1.100", "kube-dns.kube-system"}}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail when maxPods is negative", func() {
+			nc.Spec.Kubelet = &v1beta1.KubeletConfiguration{MaxPods: lo.ToPtr(int32(-1))}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail when imageGCHighThresholdPercent is out of range", func() {
+			nc.Spec.Kubelet = &v1beta1.KubeletConfiguration{ImageGCHighThresholdPercent: lo.ToPtr(int32(101))}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail when imageGCHighThresholdPercent is not greater than imageGCLowThresholdPercent", func() {
+			nc.Spec.Kubelet = &v1beta1.KubeletConfiguration{
+				ImageGCHighThresholdPercent: lo.ToPtr(int32(50)),
+				ImageGCLowThresholdPercent:  lo.ToPtr(int32(50)),
+			}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail when systemReserved contains an invalid resource", func() {
+			nc.Spec.Kubelet = &v1beta1.KubeletConfiguration{SystemReserved: map[string]string{"gpu": "1"}}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail when kubeReserved contains a negative quantity", func() {
+			nc.Spec.Kubelet = &v1beta1.KubeletConfiguration{KubeReserved: map[string]string{"memory": "-1Gi"}}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail when evictionHard contains an invalid signal", func() {
+			nc.Spec.Kubelet = &v1beta1.KubeletConfiguration{EvictionHard: map[string]string{"memory": "5%"}}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail when evictionHard contains a percentage above 100%", func() {
+			nc.Spec.Kubelet = &v1beta1.KubeletConfiguration{EvictionHard: map[string]string{"memory.available": "110%"}}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail when evictionSoft doesn't have a matching evictionSoftGracePeriod", func() {
+			nc.Spec.Kubelet = &v1beta1.KubeletConfiguration{EvictionSoft: map[string]string{"memory.available": "10%"}}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail when evictionSoftGracePeriod doesn't have a matching evictionSoft", func() {
+			nc.Spec.Kubelet = &v1beta1.KubeletConfiguration{EvictionSoftGracePeriod: map[string]metav1.Duration{"memory.available": {Duration: time.Minute}}}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+	})
+	Context("NodeLabels", func() {
+		It("should succeed with custom labels", func() {
+			nc.Spec.NodeLabels = map[string]string{"example.com/team": "team-a", "node.kubernetes.io/role": "worker"}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail with an invalid label key", func() {
+			nc.Spec.NodeLabels = map[string]string{"example.com/team/a": "team-a"}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail with an invalid label value", func() {
+			nc.Spec.NodeLabels = map[string]string{"example.com/team": "team a"}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		DescribeTable("should fail with restricted labels", func(key string) {
+			nc.Spec.NodeLabels = map[string]string{key: "value"}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		},
+			Entry("well-known label", v1.LabelTopologyZone),
+			Entry("instance type label", v1.LabelInstanceTypeStable),
+			Entry("nodepool label", corev1beta1.NodePoolLabelKey),
+			Entry("capacity type label", corev1beta1.CapacityTypeLabelKey),
+			Entry("kubernetes.io domain", "kubernetes.io/custom"),
+			Entry("node-restriction.kubernetes.io domain", "node-restriction.kubernetes.io/team"),
+			Entry("k8s.io domain", "k8s.io/custom"),
+			Entry("kops.k8s.io domain", "kops.k8s.io/instancegroup"),
+			Entry("karpenter.sh domain", "karpenter.sh/custom"),
+			Entry("karpenter.k8s.aws domain", v1beta1.LabelInstanceFamily),
+		)
+	})
+	Context("DisabledLabels", func() {
+		It("should succeed with provider-generated labels", func() {
+			nc.Spec.DisabledLabels = []string{v1beta1.LabelInstanceCPU, v1beta1.LabelInstanceNetworkBandwidth}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		DescribeTable("should fail with labels that can't be disabled", func(key string) {
+			nc.Spec.DisabledLabels = []string{key}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		},
+			Entry("zone label", v1.LabelTopologyZone),
+			Entry("instance type label", v1.LabelInstanceTypeStable),
+			Entry("architecture label", v1.LabelArchStable),
+			Entry("capacity type label", corev1beta1.CapacityTypeLabelKey),
+			Entry("zone id label", v1beta1.LabelTopologyZoneID),
+			Entry("unknown provider label", "karpenter.k8s.aws/instance-unknown"),
+		)
+	})
+	Context("NodeTaints", func() {
+		It("should succeed with valid taints", func() {
+			nc.Spec.NodeTaints = []v1.Taint{
+				{Key: "example.com/not-ready", Effect: v1.TaintEffectNoSchedule},
+				{Key: "example.com/not-ready", Effect: v1.TaintEffectNoExecute},
+				{Key: "example.com/dedicated", Value: "team-a", Effect: v1.TaintEffectPreferNoSchedule},
+			}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail with an invalid taint key", func() {
+			nc.Spec.NodeTaints = []v1.Taint{{Key: "???", Effect: v1.TaintEffectNoSchedule}}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail with an empty taint key", func() {
+			nc.Spec.NodeTaints = []v1.Taint{{Effect: v1.TaintEffectNoSchedule}}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail with an invalid taint value", func() {
+			nc.Spec.NodeTaints = []v1.Taint{{Key: "example.com/dedicated", Value: "team a", Effect: v1.TaintEffectNoSchedule}}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail with an invalid taint effect", func() {
+			nc.Spec.NodeTaints = []v1.Taint{{Key: "example.com/not-ready", Effect: "Invalid"}}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail without a taint effect", func() {
+			nc.Spec.NodeTaints = []v1.Taint{{Key: "example.com/not-ready"}}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail with duplicate taint key/effect pairs", func() {
+			nc.Spec.NodeTaints = []v1.Taint{
+				{Key: "example.com/not-ready", Value: "a", Effect: v1.TaintEffectNoSchedule},
+				{Key: "example.com/not-ready", Value: "b", Effect: v1.TaintEffectNoSchedule},
+			}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+	})
+	Context("RegistryMirrors", func() {
+		It("should succeed with valid registry mirrors", func() {
+			nc.Spec.RegistryMirrors = []v1beta1.RegistryMirror{
+				{Registry: "docker.io", Endpoints: []string{"https://mirror.example.com", "http://10.0.0.10:5000"}},
+				{Registry: "*", Endpoints: []string{"https://default-mirror.example.com"}},
+			}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail with duplicate registries", func() {
+			nc.Spec.RegistryMirrors = []v1beta1.RegistryMirror{
+				{Registry: "docker.io", Endpoints: []string{"https://mirror.example.com"}},
+				{Registry: "docker.io", Endpoints: []string{"https://other-mirror.example.com"}},
+			}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail without endpoints", func() {
+			nc.Spec.RegistryMirrors = []v1beta1.RegistryMirror{{Registry: "docker.io"}}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		DescribeTable("should fail with an invalid endpoint", func(endpoint string) {
+			nc.Spec.RegistryMirrors = []v1beta1.RegistryMirror{{Registry: "docker.io", Endpoints: []string{endpoint}}}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		},
+			Entry("missing scheme", "mirror.example.com"),
+			Entry("unsupported scheme", "ftp://mirror.example.com"),
+			Entry("missing host", "https://"),
+			Entry("unparsable", "https://mirror example.com:port"),
+		)
+	})
+	Context("ExtendedResources", func() {
+		It("should succeed with extended resources", func() {
+			nc.Spec.ExtendedResources = map[v1.ResourceName]int64{"example.com/fpga": 2, "devices.example.com/accelerator": 1}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		DescribeTable("should fail with invalid extended resources", func(name v1.ResourceName, quantity int64) {
+			nc.Spec.ExtendedResources = map[v1.ResourceName]int64{name: quantity}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		},
+			Entry("invalid resource name", v1.ResourceName("example.com/fpga/a"), int64(1)),
+			Entry("resource without a domain", v1.ResourceName("fpga"), int64(1)),
+			Entry("kubernetes.io domain", v1.ResourceName("kubernetes.io/fpga"), int64(1)),
+			Entry("requests. prefix", v1.ResourceName("requests.example.com/fpga"), int64(1)),
+			Entry("resource managed by Karpenter", v1beta1.ResourceNVIDIAGPU, int64(1)),
+			Entry("zero quantity", v1.ResourceName("example.com/fpga"), int64(0)),
+			Entry("negative quantity", v1.ResourceName("example.com/fpga"), int64(-1)),
+		)
+	})
+	Context("MaxHourlyPrice", func() {
+		It("should succeed with a valid price", func() {
+			nc.Spec.MaxHourlyPrice = aws.String("1.25")
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail with a price that isn't a number", func() {
+			nc.Spec.MaxHourlyPrice = aws.String("one")
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail with a price of 0", func() {
+			nc.Spec.MaxHourlyPrice = aws.String("0")
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail with a negative price", func() {
+			nc.Spec.MaxHourlyPrice = aws.String("-1")
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+	})
+	Context("SpotMaxPrice", func() {
+		It("should succeed with a valid price", func() {
+			nc.Spec.SpotMaxPrice = &v1beta1.SpotMaxPrice{Price: aws.String("0.05")}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should succeed with a valid on-demand percentage", func() {
+			nc.Spec.SpotMaxPrice = &v1beta1.SpotMaxPrice{OnDemandPercentage: aws.Int64(100)}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail with a price of 0", func() {
+			nc.Spec.SpotMaxPrice = &v1beta1.SpotMaxPrice{Price: aws.String("0")}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		DescribeTable("should fail with an out of bounds on-demand percentage", func(percentage int64) {
+			nc.Spec.SpotMaxPrice = &v1beta1.SpotMaxPrice{OnDemandPercentage: aws.Int64(percentage)}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		},
+			Entry("0", int64(0)),
+			Entry("101", int64(101)),
+		)
+		It("should fail when both price and on-demand percentage are set", func() {
+			nc.Spec.SpotMaxPrice = &v1beta1.SpotMaxPrice{Price: aws.String("0.05"), OnDemandPercentage: aws.Int64(60)}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail when neither price nor on-demand percentage are set", func() {
+			nc.Spec.SpotMaxPrice = &v1beta1.SpotMaxPrice{}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+	})
+	Context("NetworkMode", func() {
+		DescribeTable("should succeed with a valid network mode", func(mode v1beta1.NetworkMode) {
+			nc.Spec.NetworkMode = lo.ToPtr(mode)
+			Expect(nc.Validate(ctx)).To(Succeed())
+		},
+			Entry("Private", v1beta1.NetworkModePrivate),
+			Entry("Public", v1beta1.NetworkModePublic),
+		)
+		It("should fail with an invalid network mode", func() {
+			nc.Spec.NetworkMode = lo.ToPtr(v1beta1.NetworkMode("Hybrid"))
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should succeed when associatePublicIPAddress matches the network mode", func() {
+			nc.Spec.NetworkMode = lo.ToPtr(v1beta1.NetworkModePrivate)
+			nc.Spec.AssociatePublicIPAddress = aws.Bool(false)
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail when associatePublicIPAddress contradicts the network mode", func() {
+			nc.Spec.NetworkMode = lo.ToPtr(v1beta1.NetworkModePrivate)
+			nc.Spec.AssociatePublicIPAddress = aws.Bool(true)
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+	})
+	Context("CPUOptions", func() {
+		It("should succeed with a core count and threads per core", func() {
+			nc.Spec.CPUOptions = &v1beta1.CPUOptions{CoreCount: aws.Int64(4), ThreadsPerCore: aws.Int64(1)}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should succeed with only a core count", func() {
+			nc.Spec.CPUOptions = &v1beta1.CPUOptions{CoreCount: aws.Int64(4)}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail when neither core count nor threads per core are set", func() {
+			nc.Spec.CPUOptions = &v1beta1.CPUOptions{}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail with a core count of 0", func() {
+			nc.Spec.CPUOptions = &v1beta1.CPUOptions{CoreCount: aws.Int64(0)}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		DescribeTable("should fail with an out of bounds threads per core", func(threads int64) {
+			nc.Spec.CPUOptions = &v1beta1.CPUOptions{ThreadsPerCore: aws.Int64(threads)}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		},
+			Entry("0", int64(0)),
+			Entry("3", int64(3)),
+		)
+		It("should succeed with a single thread per core when hyperthreading is disabled", func() {
+			nc.Spec.DisableHyperthreading = aws.Bool(true)
+			nc.Spec.CPUOptions = &v1beta1.CPUOptions{ThreadsPerCore: aws.Int64(1)}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail with two threads per core when hyperthreading is disabled", func() {
+			nc.Spec.DisableHyperthreading = aws.Bool(true)
+			nc.Spec.CPUOptions = &v1beta1.CPUOptions{ThreadsPerCore: aws.Int64(2)}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+	})
+	Context("UserDataFragments", func() {
+		It("should succeed with fragments for instance families and instance types", func() {
+			nc.Spec.UserDataFragments = []v1beta1.UserDataFragment{
+				{InstanceFamilies: []string{"p4d", "g5"}, UserData: "echo gpu"},
+				{InstanceTypes: []string{"m5.large"}, UserData: "echo m5"},
+			}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail when a fragment doesn't specify instance families or instance types", func() {
+			nc.Spec.UserDataFragments = []v1beta1.UserDataFragment{
+				{UserData: "echo all"},
+			}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail when a fragment has empty user data", func() {
+			nc.Spec.UserDataFragments = []v1beta1.UserDataFragment{
+				{InstanceFamilies: []string{"p4d"}},
+			}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+	})
 	Context("Role Immutability", func() {
 		It("should fail when updating the role", func() {
 			nc.Spec.Role = "test-role"
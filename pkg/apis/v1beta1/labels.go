@@ -29,12 +29,15 @@ func init() {
 	v1beta1.WellKnownLabels = v1beta1.WellKnownLabels.Insert(
 		LabelInstanceHypervisor,
 		LabelInstanceEncryptionInTransitSupported,
+		LabelInstanceBurstable,
+		LabelInstanceEnclavesSupported,
 		LabelInstanceCategory,
 		LabelInstanceFamily,
 		LabelInstanceGeneration,
 		LabelInstanceSize,
 		LabelInstanceLocalNVME,
 		LabelInstanceCPU,
+		LabelInstanceCPUCores,
 		LabelInstanceCPUManufacturer,
 		LabelInstanceMemory,
 		LabelInstanceEBSBandwidth,
@@ -43,9 +46,17 @@ func init() {
 		LabelInstanceGPUManufacturer,
 		LabelInstanceGPUCount,
 		LabelInstanceGPUMemory,
+		LabelInstanceGPUMemoryTotal,
 		LabelInstanceAcceleratorName,
 		LabelInstanceAcceleratorManufacturer,
 		LabelInstanceAcceleratorCount,
+		LabelInstanceNUMANodes,
+		LabelInstanceMaxIPs,
+		LabelInstanceNetworkCards,
+		LabelInstanceMemoryPerVCPU,
+		LabelInstanceClusterPlacementSupported,
+		LabelInstanceSpotSupported,
+		LabelTopologyZoneID,
 		v1.LabelWindowsBuild,
 	)
 }
@@ -71,6 +82,8 @@ var (
 		regexp.MustCompile(fmt.Sprintf("^%s$", regexp.QuoteMeta(v1beta1.ManagedByAnnotationKey))),
 		regexp.MustCompile(fmt.Sprintf("^%s$", regexp.QuoteMeta(LabelNodeClass))),
 		regexp.MustCompile(fmt.Sprintf("^%s$", regexp.QuoteMeta(TagNodeClaim))),
+		regexp.MustCompile(fmt.Sprintf("^%s$", regexp.QuoteMeta(TagNodeClaimUID))),
+		regexp.MustCompile(fmt.Sprintf("^%s$", regexp.QuoteMeta(TagInstanceStopped))),
 	}
 	AMIFamilyBottlerocket                      = "Bottlerocket"
 	AMIFamilyAL2                               = "AL2"
@@ -94,14 +107,21 @@ var (
 
 	LabelNodeClass = Group + "/ec2nodeclass"
 
+	// LabelTopologyZoneID is the ID of the availability zone of the node. Unlike zone names, zone IDs refer to the
+	// same physical zone in every account.
+	LabelTopologyZoneID = "topology.k8s.aws/zone-id"
+
 	LabelInstanceHypervisor                   = Group + "/instance-hypervisor"
 	LabelInstanceEncryptionInTransitSupported = Group + "/instance-encryption-in-transit-supported"
+	LabelInstanceBurstable                    = Group + "/instance-burstable"
+	LabelInstanceEnclavesSupported            = Group + "/instance-enclaves-supported"
 	LabelInstanceCategory                     = Group + "/instance-category"
 	LabelInstanceFamily                       = Group + "/instance-family"
 	LabelInstanceGeneration                   = Group + "/instance-generation"
 	LabelInstanceLocalNVME                    = Group + "/instance-local-nvme"
 	LabelInstanceSize                         = Group + "/instance-size"
 	LabelInstanceCPU                          = Group + "/instance-cpu"
+	LabelInstanceCPUCores                     = Group + "/instance-cpu-cores"
 	LabelInstanceCPUManufacturer              = Group + "/instance-cpu-manufacturer"
 	LabelInstanceMemory                       = Group + "/instance-memory"
 	LabelInstanceEBSBandwidth                 = Group + "/instance-ebs-bandwidth"
@@ -110,14 +130,65 @@ var (
 	LabelInstanceGPUManufacturer              = Group + "/instance-gpu-manufacturer"
 	LabelInstanceGPUCount                     = Group + "/instance-gpu-count"
 	LabelInstanceGPUMemory                    = Group + "/instance-gpu-memory"
+	LabelInstanceGPUMemoryTotal               = Group + "/instance-gpu-memory-total"
 	LabelInstanceAcceleratorName              = Group + "/instance-accelerator-name"
 	LabelInstanceAcceleratorManufacturer      = Group + "/instance-accelerator-manufacturer"
 	LabelInstanceAcceleratorCount             = Group + "/instance-accelerator-count"
+	LabelInstanceNUMANodes                    = Group + "/instance-numa-nodes"
+	LabelInstanceMaxIPs                       = Group + "/instance-max-ips"
+	LabelInstanceNetworkCards                 = Group + "/instance-network-cards"
+	LabelInstanceMemoryPerVCPU                = Group + "/instance-memory-per-vcpu"
+	LabelInstanceClusterPlacementSupported    = Group + "/instance-cluster-placement-supported"
+	LabelInstanceSpotSupported                = Group + "/instance-spot-supported"
 	AnnotationEC2NodeClassHash                = Group + "/ec2nodeclass-hash"
 	AnnotationEC2NodeClassHashVersion         = Group + "/ec2nodeclass-hash-version"
 	AnnotationInstanceTagged                  = Group + "/tagged"
+	AnnotationLaunchToReadyRecorded           = Group + "/launch-to-ready-recorded"
+	AnnotationStopOnDelete                    = Group + "/stop-on-delete"
+	AnnotationInstanceCapabilities            = Group + "/instance-capabilities"
+	AnnotationNotReadyTimeout                 = Group + "/not-ready-timeout"
+	AnnotationRebalanceRecommended            = Group + "/rebalance-recommended"
+	AnnotationInstanceTypePriority            = Group + "/instance-type-priority"
+	AnnotationLaunchPrice                     = Group + "/launch-price"
 
 	TagNodeClaim             = v1beta1.Group + "/nodeclaim"
+	TagNodeClaimUID          = Group + "/nodeclaim-uid"
+	TagInstanceStopped       = Group + "/stopped"
 	TagManagedLaunchTemplate = Group + "/cluster"
 	TagName                  = "Name"
+
+	// DisableableLabels are the provider-generated well-known labels that can be disabled through the disabledLabels
+	// field of an EC2NodeClass. Labels that are required for scheduling, e.g. the instance type and zone labels, aren't
+	// included.
+	DisableableLabels = sets.New(
+		LabelInstanceHypervisor,
+		LabelInstanceEncryptionInTransitSupported,
+		LabelInstanceBurstable,
+		LabelInstanceEnclavesSupported,
+		LabelInstanceCategory,
+		LabelInstanceFamily,
+		LabelInstanceGeneration,
+		LabelInstanceSize,
+		LabelInstanceLocalNVME,
+		LabelInstanceCPU,
+		LabelInstanceCPUCores,
+		LabelInstanceCPUManufacturer,
+		LabelInstanceMemory,
+		LabelInstanceEBSBandwidth,
+		LabelInstanceNetworkBandwidth,
+		LabelInstanceGPUName,
+		LabelInstanceGPUManufacturer,
+		LabelInstanceGPUCount,
+		LabelInstanceGPUMemory,
+		LabelInstanceGPUMemoryTotal,
+		LabelInstanceAcceleratorName,
+		LabelInstanceAcceleratorManufacturer,
+		LabelInstanceAcceleratorCount,
+		LabelInstanceNUMANodes,
+		LabelInstanceMaxIPs,
+		LabelInstanceNetworkCards,
+		LabelInstanceMemoryPerVCPU,
+		LabelInstanceClusterPlacementSupported,
+		LabelInstanceSpotSupported,
+	)
 )
@@ -26,34 +26,60 @@ import (
 )
 
 type CreateFleetBatcher struct {
-	batcher *Batcher[ec2.CreateFleetInput, ec2.CreateFleetOutput]
+	batcher *Batcher[createFleetRequest, ec2.CreateFleetOutput]
+}
+
+// createFleetRequest is a single instance CreateFleet request. Its fields are exported so that they're hashed into the
+// bucket of the request, since requests with different on-demand percentages can't be batched together.
+type createFleetRequest struct {
+	Input *ec2.CreateFleetInput
+	// OnDemandPercentageAboveBase is the percentage of the capacity of a batch of requests that mix capacity types
+	// that is launched as on-demand once the on-demand base is launched
+	OnDemandPercentageAboveBase int64
 }
 
 func NewCreateFleetBatcher(ctx context.Context, ec2api ec2iface.EC2API) *CreateFleetBatcher {
-	options := Options[ec2.CreateFleetInput, ec2.CreateFleetOutput]{
+	options := Options[createFleetRequest, ec2.CreateFleetOutput]{
 		Name:          "create_fleet",
 		IdleTimeout:   35 * time.Millisecond,
 		MaxTimeout:    1 * time.Second,
 		MaxItems:      1_000,
-		RequestHasher: DefaultHasher[ec2.CreateFleetInput],
+		RequestHasher: DefaultHasher[createFleetRequest],
 		BatchExecutor: execCreateFleetBatch(ec2api),
 	}
 	return &CreateFleetBatcher{batcher: NewBatcher(ctx, options)}
 }
 
 func (b *CreateFleetBatcher) CreateFleet(ctx context.Context, createFleetInput *ec2.CreateFleetInput) (*ec2.CreateFleetOutput, error) {
+	return b.CreateMixedFleet(ctx, createFleetInput, 0)
+}
+
+// CreateMixedFleet launches a single instance like CreateFleet. When the request mixes capacity types, i.e. it sets an
+// on-demand target capacity as its on-demand base, the given percentage of the capacity of the batch above the base is
+// also launched as on-demand, rounded up, and the rest of the batch is launched as spot.
+func (b *CreateFleetBatcher) CreateMixedFleet(ctx context.Context, createFleetInput *ec2.CreateFleetInput, onDemandPercentageAboveBase int64) (*ec2.CreateFleetOutput, error) {
 	if createFleetInput.TargetCapacitySpecification != nil && *createFleetInput.TargetCapacitySpecification.TotalTargetCapacity != 1 {
 		return nil, fmt.Errorf("expected to receive a single instance only, found %d", *createFleetInput.TargetCapacitySpecification.TotalTargetCapacity)
 	}
-	result := b.batcher.Add(ctx, createFleetInput)
+	result := b.batcher.Add(ctx, &createFleetRequest{Input: createFleetInput, OnDemandPercentageAboveBase: onDemandPercentageAboveBase})
 	return result.Output, result.Err
 }
 
-func execCreateFleetBatch(ec2api ec2iface.EC2API) BatchExecutor[ec2.CreateFleetInput, ec2.CreateFleetOutput] {
-	return func(ctx context.Context, inputs []*ec2.CreateFleetInput) []Result[ec2.CreateFleetOutput] {
+func execCreateFleetBatch(ec2api ec2iface.EC2API) BatchExecutor[createFleetRequest, ec2.CreateFleetOutput] {
+	return func(ctx context.Context, inputs []*createFleetRequest) []Result[ec2.CreateFleetOutput] {
 		results := make([]Result[ec2.CreateFleetOutput], 0, len(inputs))
-		firstInput := inputs[0]
-		firstInput.TargetCapacitySpecification.TotalTargetCapacity = aws.Int64(int64(len(inputs)))
+		firstInput := inputs[0].Input
+		total := int64(len(inputs))
+		firstInput.TargetCapacitySpecification.TotalTargetCapacity = aws.Int64(total)
+		// Requests that mix capacity types carry their on-demand base, which is capped by the total capacity of the
+		// batch. The on-demand percentage of the rest of the batch is rounded up, so that at least that percentage is
+		// launched as on-demand, and the remainder is launched as spot.
+		if onDemandBase := firstInput.TargetCapacitySpecification.OnDemandTargetCapacity; onDemandBase != nil {
+			base := min(aws.Int64Value(onDemandBase), total)
+			onDemand := base + ((total-base)*inputs[0].OnDemandPercentageAboveBase+99)/100
+			firstInput.TargetCapacitySpecification.OnDemandTargetCapacity = aws.Int64(onDemand)
+			firstInput.TargetCapacitySpecification.SpotTargetCapacity = aws.Int64(total - onDemand)
+		}
 		output, err := ec2api.CreateFleetWithContext(ctx, firstInput)
 		if err != nil {
 			for range inputs {
@@ -249,6 +249,145 @@ var _ = Describe("CreateFleet Batching", func() {
 		Expect(receivedInstance).To(BeNumerically("==", 5))
 		Expect(numErrors).To(BeNumerically("==", 5))
 	})
+	DescribeTable("should split the capacity of batched inputs into the on-demand base and spot", func(onDemandBase, expectedOnDemand, expectedSpot int) {
+		input := &ec2.CreateFleetInput{
+			LaunchTemplateConfigs: []*ec2.FleetLaunchTemplateConfigRequest{
+				{
+					LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecificationRequest{
+						LaunchTemplateName: aws.String("my-template"),
+					},
+					Overrides: []*ec2.FleetLaunchTemplateOverridesRequest{
+						{
+							AvailabilityZone: aws.String("us-east-1"),
+						},
+					},
+				},
+			},
+			TargetCapacitySpecification: &ec2.TargetCapacitySpecificationRequest{
+				DefaultTargetCapacityType: aws.String(ec2.DefaultTargetCapacityTypeSpot),
+				OnDemandTargetCapacity:    aws.Int64(int64(onDemandBase)),
+				TotalTargetCapacity:       aws.Int64(1),
+			},
+		}
+		var wg sync.WaitGroup
+		var lifecycles sync.Map
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				rsp, err := cfb.CreateFleet(ctx, input)
+				Expect(err).To(BeNil())
+				Expect(rsp.Instances).To(HaveLen(1))
+				lifecycles.Store(*rsp.Instances[0].InstanceIds[0], *rsp.Instances[0].Lifecycle)
+			}()
+		}
+		wg.Wait()
+
+		Expect(fakeEC2API.CreateFleetBehavior.CalledWithInput.Len()).To(BeNumerically("==", 1))
+		call := fakeEC2API.CreateFleetBehavior.CalledWithInput.Pop()
+		Expect(*call.TargetCapacitySpecification.TotalTargetCapacity).To(BeNumerically("==", 5))
+		Expect(*call.TargetCapacitySpecification.OnDemandTargetCapacity).To(BeNumerically("==", expectedOnDemand))
+		Expect(*call.TargetCapacitySpecification.SpotTargetCapacity).To(BeNumerically("==", expectedSpot))
+		counts := map[string]int{}
+		lifecycles.Range(func(_, lifecycle any) bool {
+			counts[lifecycle.(string)]++
+			return true
+		})
+		Expect(counts[ec2.DefaultTargetCapacityTypeOnDemand]).To(Equal(expectedOnDemand))
+		Expect(counts[ec2.DefaultTargetCapacityTypeSpot]).To(Equal(expectedSpot))
+	},
+		Entry("with a base smaller than the batch", 2, 2, 3),
+		Entry("with a base larger than the batch", 10, 5, 0),
+	)
+	DescribeTable("should split the capacity of batched inputs above the on-demand base by the on-demand percentage", func(onDemandBase, onDemandPercentage, expectedOnDemand, expectedSpot int) {
+		input := &ec2.CreateFleetInput{
+			LaunchTemplateConfigs: []*ec2.FleetLaunchTemplateConfigRequest{
+				{
+					LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecificationRequest{
+						LaunchTemplateName: aws.String("my-template"),
+					},
+					Overrides: []*ec2.FleetLaunchTemplateOverridesRequest{
+						{
+							AvailabilityZone: aws.String("us-east-1"),
+						},
+					},
+				},
+			},
+			TargetCapacitySpecification: &ec2.TargetCapacitySpecificationRequest{
+				DefaultTargetCapacityType: aws.String(ec2.DefaultTargetCapacityTypeSpot),
+				OnDemandTargetCapacity:    aws.Int64(int64(onDemandBase)),
+				TotalTargetCapacity:       aws.Int64(1),
+			},
+		}
+		var wg sync.WaitGroup
+		var lifecycles sync.Map
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				rsp, err := cfb.CreateMixedFleet(ctx, input, int64(onDemandPercentage))
+				Expect(err).To(BeNil())
+				Expect(rsp.Instances).To(HaveLen(1))
+				lifecycles.Store(*rsp.Instances[0].InstanceIds[0], *rsp.Instances[0].Lifecycle)
+			}()
+		}
+		wg.Wait()
+
+		Expect(fakeEC2API.CreateFleetBehavior.CalledWithInput.Len()).To(BeNumerically("==", 1))
+		call := fakeEC2API.CreateFleetBehavior.CalledWithInput.Pop()
+		Expect(*call.TargetCapacitySpecification.TotalTargetCapacity).To(BeNumerically("==", 10))
+		Expect(*call.TargetCapacitySpecification.OnDemandTargetCapacity).To(BeNumerically("==", expectedOnDemand))
+		Expect(*call.TargetCapacitySpecification.SpotTargetCapacity).To(BeNumerically("==", expectedSpot))
+		counts := map[string]int{}
+		lifecycles.Range(func(_, lifecycle any) bool {
+			counts[lifecycle.(string)]++
+			return true
+		})
+		Expect(counts[ec2.DefaultTargetCapacityTypeOnDemand]).To(Equal(expectedOnDemand))
+		Expect(counts[ec2.DefaultTargetCapacityTypeSpot]).To(Equal(expectedSpot))
+	},
+		Entry("with a percentage and no base", 0, 20, 2, 8),
+		Entry("with a percentage that is rounded up", 0, 25, 3, 7),
+		Entry("with a percentage above a base", 2, 50, 6, 4),
+		Entry("with a percentage of 0 above a base", 2, 0, 2, 8),
+		Entry("with a percentage of 100", 0, 100, 10, 0),
+		Entry("with a base larger than the batch", 12, 50, 10, 0),
+	)
+	It("should not batch inputs with different on-demand percentages", func() {
+		input := &ec2.CreateFleetInput{
+			LaunchTemplateConfigs: []*ec2.FleetLaunchTemplateConfigRequest{
+				{
+					LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecificationRequest{
+						LaunchTemplateName: aws.String("my-template"),
+					},
+					Overrides: []*ec2.FleetLaunchTemplateOverridesRequest{
+						{
+							AvailabilityZone: aws.String("us-east-1"),
+						},
+					},
+				},
+			},
+			TargetCapacitySpecification: &ec2.TargetCapacitySpecificationRequest{
+				DefaultTargetCapacityType: aws.String(ec2.DefaultTargetCapacityTypeSpot),
+				OnDemandTargetCapacity:    aws.Int64(0),
+				TotalTargetCapacity:       aws.Int64(1),
+			},
+		}
+		var wg sync.WaitGroup
+		for _, percentage := range []int64{25, 50} {
+			wg.Add(1)
+			go func(percentage int64) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				_, err := cfb.CreateMixedFleet(ctx, input, percentage)
+				Expect(err).To(BeNil())
+			}(percentage)
+		}
+		wg.Wait()
+		Expect(fakeEC2API.CreateFleetBehavior.CalledWithInput.Len()).To(BeNumerically("==", 2))
+	})
 	It("should handle partial fulfillment", func() {
 		input := &ec2.CreateFleetInput{
 			LaunchTemplateConfigs: []*ec2.FleetLaunchTemplateConfigRequest{
@@ -24,6 +24,8 @@ import (
 const (
 	batcherSubsystem = "cloudprovider_batcher"
 	batcherNameLabel = "batcher"
+	operationLabel   = "operation"
+	accountLabel     = "account"
 )
 
 // SizeBuckets returns a []float64 of default threshold values for size histograms.
@@ -48,8 +50,14 @@ var (
 		Help:      "Size of the request batch per batcher",
 		Buckets:   SizeBuckets(),
 	}, []string{batcherNameLabel})
+	ec2RateLimiterSaturation = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "cloudprovider",
+		Name:      "ec2_rate_limiter_saturation",
+		Help:      "Fraction of the client-side rate limiter burst in use per account and EC2 operation. The account is empty for the account that Karpenter runs in. Values above 1 indicate that calls are waiting for the rate limiter.",
+	}, []string{accountLabel, operationLabel})
 )
 
 func init() {
-	crmetrics.Registry.MustRegister(batchWindowDuration, batchSize)
+	crmetrics.Registry.MustRegister(batchWindowDuration, batchSize, ec2RateLimiterSaturation)
 }
@@ -0,0 +1,147 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package batcher
+
+import (
+	"context"
+	"math"
+	"time"
+
+	awsrequest "github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitedEC2API wraps an EC2 client with client-side rate limiters for individual EC2 operations. Calls that exceed
+// the configured rate for their operation wait for the limiter rather than failing. The batchers are constructed on
+// top of this client, so batched calls are limited in the same way as any other call.
+type RateLimitedEC2API struct {
+	ec2iface.EC2API
+	limiters map[string]*rateLimiter
+}
+
+// NewRateLimitedEC2API returns an EC2 client that limits each operation in qps to the given number of calls per second.
+// Operations that aren't in qps, or that have a qps of 0, aren't rate limited. EC2 limits calls separately for each
+// account, so each account has its own client, and the account ID labels the saturation of its limiters. It's empty for
+// the account that Karpenter runs in.
+func NewRateLimitedEC2API(ec2api ec2iface.EC2API, accountID string, qps map[string]float64) *RateLimitedEC2API {
+	limiters := map[string]*rateLimiter{}
+	for operation, limit := range qps {
+		if limit > 0 {
+			limiters[operation] = newRateLimiter(accountID, operation, limit)
+		}
+	}
+	return &RateLimitedEC2API{EC2API: ec2api, limiters: limiters}
+}
+
+func (r *RateLimitedEC2API) CreateFleetWithContext(ctx context.Context, input *ec2.CreateFleetInput, opts ...awsrequest.Option) (*ec2.CreateFleetOutput, error) {
+	if err := r.wait(ctx, "CreateFleet"); err != nil {
+		return nil, err
+	}
+	return r.EC2API.CreateFleetWithContext(ctx, input, opts...)
+}
+
+func (r *RateLimitedEC2API) TerminateInstancesWithContext(ctx context.Context, input *ec2.TerminateInstancesInput, opts ...awsrequest.Option) (*ec2.TerminateInstancesOutput, error) {
+	if err := r.wait(ctx, "TerminateInstances"); err != nil {
+		return nil, err
+	}
+	return r.EC2API.TerminateInstancesWithContext(ctx, input, opts...)
+}
+
+func (r *RateLimitedEC2API) CreateTagsWithContext(ctx context.Context, input *ec2.CreateTagsInput, opts ...awsrequest.Option) (*ec2.CreateTagsOutput, error) {
+	if err := r.wait(ctx, "CreateTags"); err != nil {
+		return nil, err
+	}
+	return r.EC2API.CreateTagsWithContext(ctx, input, opts...)
+}
+
+func (r *RateLimitedEC2API) DescribeInstancesWithContext(ctx context.Context, input *ec2.DescribeInstancesInput, opts ...awsrequest.Option) (*ec2.DescribeInstancesOutput, error) {
+	if err := r.wait(ctx, "DescribeInstances"); err != nil {
+		return nil, err
+	}
+	return r.EC2API.DescribeInstancesWithContext(ctx, input, opts...)
+}
+
+// DescribeInstancesPagesWithContext counts every page against the DescribeInstances limit, since each page is a
+// separate call to EC2
+func (r *RateLimitedEC2API) DescribeInstancesPagesWithContext(ctx context.Context, input *ec2.DescribeInstancesInput, fn func(*ec2.DescribeInstancesOutput, bool) bool, opts ...awsrequest.Option) error {
+	if err := r.wait(ctx, "DescribeInstances"); err != nil {
+		return err
+	}
+	var waitErr error
+	if err := r.EC2API.DescribeInstancesPagesWithContext(ctx, input, func(out *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		if !fn(out, lastPage) || lastPage {
+			return false
+		}
+		waitErr = r.wait(ctx, "DescribeInstances")
+		return waitErr == nil
+	}, opts...); err != nil {
+		return err
+	}
+	return waitErr
+}
+
+func (r *RateLimitedEC2API) wait(ctx context.Context, operation string) error {
+	limiter, ok := r.limiters[operation]
+	if !ok {
+		return nil
+	}
+	return limiter.wait(ctx)
+}
+
+type rateLimiter struct {
+	*rate.Limiter
+	accountID string
+	operation string
+}
+
+func newRateLimiter(accountID, operation string, qps float64) *rateLimiter {
+	// The burst is the smallest number of calls that covers a full second at the configured rate so that limits
+	// below one call per second still allow a call through
+	return &rateLimiter{
+		Limiter:   rate.NewLimiter(rate.Limit(qps), int(math.Max(1, math.Ceil(qps)))),
+		accountID: accountID,
+		operation: operation,
+	}
+}
+
+// wait blocks until the limiter allows another call or the context is done. This is equivalent to rate.Limiter.Wait,
+// but records the saturation of the limiter once the call has reserved its token so that waiting calls are reflected.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	reservation := l.Reserve()
+	l.observe()
+	defer l.observe()
+
+	delay := reservation.Delay()
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		reservation.Cancel()
+		return ctx.Err()
+	}
+}
+
+// observe records the fraction of the limiter's burst that is in use. A value above 1 means that calls are waiting
+// for the limiter.
+func (l *rateLimiter) observe() {
+	ec2RateLimiterSaturation.WithLabelValues(l.accountID, l.operation).Set((float64(l.Burst()) - l.Tokens()) / float64(l.Burst()))
+}
@@ -0,0 +1,152 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package batcher_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"sigs.k8s.io/karpenter/pkg/test/expectations"
+
+	"github.com/aws/karpenter-provider-aws/pkg/batcher"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RateLimitedEC2API", func() {
+	var ec2api *batcher.RateLimitedEC2API
+
+	BeforeEach(func() {
+		fakeEC2API.Reset()
+		ec2api = batcher.NewRateLimitedEC2API(fakeEC2API, "", map[string]float64{
+			"TerminateInstances": 5,
+			"DescribeInstances":  2,
+			"CreateTags":         0,
+		})
+	})
+
+	It("should throttle calls over the limit without dropping them", func() {
+		start := time.Now()
+		var wg sync.WaitGroup
+		for i := 0; i < 15; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				_, err := ec2api.TerminateInstancesWithContext(ctx, &ec2.TerminateInstancesInput{InstanceIds: []*string{aws.String(fmt.Sprintf("i-%d", i))}})
+				Expect(err).ToNot(HaveOccurred())
+			}(i)
+		}
+		wg.Wait()
+
+		// The first 5 calls use the burst, the remaining 10 calls are let through at 5 calls per second
+		Expect(time.Since(start)).To(BeNumerically(">=", time.Millisecond*1800))
+		Expect(fakeEC2API.TerminateInstancesBehavior.SuccessfulCalls()).To(Equal(15))
+	})
+	It("should not throttle operations without a limit", func() {
+		fakeEC2API.CreateFleetBehavior.Output.Set(&ec2.CreateFleetOutput{})
+		fakeEC2API.CreateTagsBehavior.Output.Set(&ec2.CreateTagsOutput{})
+		start := time.Now()
+		for i := 0; i < 20; i++ {
+			_, err := ec2api.CreateFleetWithContext(ctx, &ec2.CreateFleetInput{})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = ec2api.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{})
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		Expect(fakeEC2API.CreateFleetBehavior.SuccessfulCalls()).To(Equal(20))
+		Expect(fakeEC2API.CreateTagsBehavior.SuccessfulCalls()).To(Equal(20))
+	})
+	It("should throttle calls made through the batchers", func() {
+		terminateInstancesBatcher := batcher.NewTerminateInstancesBatcher(ctx, batcher.NewRateLimitedEC2API(fakeEC2API, "", map[string]float64{
+			"TerminateInstances": 2,
+		}))
+		for _, id := range []string{"i-1", "i-2", "i-3"} {
+			fakeEC2API.Instances.Store(id, &ec2.Instance{})
+		}
+
+		start := time.Now()
+		for _, id := range []string{"i-1", "i-2", "i-3"} {
+			_, err := terminateInstancesBatcher.TerminateInstances(ctx, &ec2.TerminateInstancesInput{InstanceIds: []*string{aws.String(id)}})
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		// The third batch has to wait for the limiter, since the burst only allows 2 calls
+		Expect(time.Since(start)).To(BeNumerically(">=", time.Millisecond*400))
+		Expect(fakeEC2API.TerminateInstancesBehavior.SuccessfulCalls()).To(Equal(3))
+	})
+	It("should count paginated calls against the limit", func() {
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			Expect(ec2api.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{}, func(_ *ec2.DescribeInstancesOutput, _ bool) bool {
+				return false
+			})).To(Succeed())
+		}
+		Expect(time.Since(start)).To(BeNumerically(">=", time.Millisecond*400))
+		Expect(fakeEC2API.DescribeInstancesBehavior.SuccessfulCalls()).To(Equal(3))
+	})
+	It("should return an error without calling EC2 when the context is canceled while waiting", func() {
+		for i := 0; i < 5; i++ {
+			_, err := ec2api.TerminateInstancesWithContext(ctx, &ec2.TerminateInstancesInput{})
+			Expect(err).ToNot(HaveOccurred())
+		}
+		cancelCtx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+		defer cancel()
+		_, err := ec2api.TerminateInstancesWithContext(cancelCtx, &ec2.TerminateInstancesInput{})
+		Expect(err).To(HaveOccurred())
+		Expect(fakeEC2API.TerminateInstancesBehavior.Calls()).To(Equal(5))
+	})
+	It("should report the saturation of the limiter", func() {
+		for i := 0; i < 5; i++ {
+			_, err := ec2api.TerminateInstancesWithContext(ctx, &ec2.TerminateInstancesInput{})
+			Expect(err).ToNot(HaveOccurred())
+		}
+		metric, ok := expectations.FindMetricWithLabelValues("karpenter_cloudprovider_ec2_rate_limiter_saturation", map[string]string{
+			"account":   "",
+			"operation": "TerminateInstances",
+		})
+		Expect(ok).To(BeTrue())
+		Expect(metric.GetGauge().GetValue()).To(BeNumerically(">", 0.5))
+	})
+	It("should report the saturation of the limiters of each account separately", func() {
+		accountEC2API := batcher.NewRateLimitedEC2API(fakeEC2API, "123456789012", map[string]float64{
+			"TerminateInstances": 5,
+		})
+		for i := 0; i < 5; i++ {
+			_, err := ec2api.TerminateInstancesWithContext(ctx, &ec2.TerminateInstancesInput{})
+			Expect(err).ToNot(HaveOccurred())
+		}
+		_, err := accountEC2API.TerminateInstancesWithContext(ctx, &ec2.TerminateInstancesInput{})
+		Expect(err).ToNot(HaveOccurred())
+
+		metric, ok := expectations.FindMetricWithLabelValues("karpenter_cloudprovider_ec2_rate_limiter_saturation", map[string]string{
+			"account":   "",
+			"operation": "TerminateInstances",
+		})
+		Expect(ok).To(BeTrue())
+		Expect(metric.GetGauge().GetValue()).To(BeNumerically(">", 0.5))
+		metric, ok = expectations.FindMetricWithLabelValues("karpenter_cloudprovider_ec2_rate_limiter_saturation", map[string]string{
+			"account":   "123456789012",
+			"operation": "TerminateInstances",
+		})
+		Expect(ok).To(BeTrue())
+		Expect(metric.GetGauge().GetValue()).To(BeNumerically("<=", 0.5))
+	})
+})
@@ -27,14 +27,35 @@ const (
 	// UnavailableOfferingsTTL is the time before offerings that were marked as unavailable
 	// are removed from the cache and are available for launch again
 	UnavailableOfferingsTTL = 3 * time.Minute
+	// UnavailableZonesTTL is the time before zones that were marked as unavailable, after insufficient capacity errors
+	// for many instance types in the zone, are removed from the cache and are available for launch again
+	UnavailableZonesTTL = 5 * time.Minute
+	// ExhaustedCapacityReservationsTTL is the time before capacity reservations that failed a launch for lack of available
+	// instances are launched into again, even if the EC2NodeClass status still reports available instances for them
+	ExhaustedCapacityReservationsTTL = 3 * time.Minute
+	// StoppedInstanceClaimTTL is the time that a stopped instance that's being started for a NodeClaim is reserved for
+	// it, which covers the delay before DescribeInstances stops reporting the instance as stopped
+	StoppedInstanceClaimTTL = 5 * time.Minute
+	// LaunchLimiterTTL is the time that the launch rate limiter of an EC2NodeClass is kept after its last launch. A
+	// limiter that's idle for longer than a second has refilled, so dropping it doesn't change how launches are paced.
+	LaunchLimiterTTL = 5 * time.Minute
 	// InstanceTypesAndZonesTTL is the time before we refresh instance types and zones at EC2
 	InstanceTypesAndZonesTTL = 5 * time.Minute
 	// InstanceProfileTTL is the time before we refresh checking instance profile existence at IAM
 	InstanceProfileTTL = 15 * time.Minute
+	// InstanceProfileRoleTTL is the time before we check the role of an instance profile at IAM again, which bounds how
+	// long a role that's changed out-of-band goes unnoticed
+	InstanceProfileRoleTTL = 5 * time.Minute
 	// AvailableIPAddressTTL is time to drop AvailableIPAddress data if it is not updated within the TTL
 	AvailableIPAddressTTL = 5 * time.Minute
 	// AvailableIPAddressTTL is time to drop AssociatePublicIPAddressTTL data if it is not updated within the TTL
 	AssociatePublicIPAddressTTL = 5 * time.Minute
+	// ClusterTTL is the time before we refresh the endpoint and certificate authority of the cluster at EKS, when
+	// they're discovered for the user data of nodes
+	ClusterTTL = 5 * time.Minute
+	// ClusterDiscoveryFailureTTL is the time before we retry discovering the cluster at EKS after it failed, during which
+	// nodes bootstrap with the configured endpoint and certificate authority
+	ClusterDiscoveryFailureTTL = 30 * time.Second
 )
 
 const (
@@ -17,27 +17,37 @@ package cache
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"sync/atomic"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/patrickmn/go-cache"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 )
 
 // UnavailableOfferings stores any offerings that return ICE (insufficient capacity errors) when
 // attempting to launch the capacity. These offerings are ignored as long as they are in the cache on
-// GetInstanceTypes responses
+// GetInstanceTypes responses. A zone is also marked as unavailable for a capacity type when insufficient capacity
+// errors are returned for many instance types in the zone, since that suggests the whole zone is constrained.
 type UnavailableOfferings struct {
+	// key: <capacityType>:<instanceType>:<zone> or <capacityType>:<zone>, value: struct{}{}
+	cache *cache.Cache
 	// key: <capacityType>:<instanceType>:<zone>, value: struct{}{}
-	cache  *cache.Cache
-	SeqNum uint64
+	insufficientCapacity *cache.Cache
+	mu                   sync.Mutex
+	SeqNum               uint64
 }
 
 func NewUnavailableOfferings() *UnavailableOfferings {
 	uo := &UnavailableOfferings{
-		cache:  cache.New(UnavailableOfferingsTTL, UnavailableOfferingsCleanupInterval),
-		SeqNum: 0,
+		cache:                cache.New(UnavailableOfferingsTTL, UnavailableOfferingsCleanupInterval),
+		insufficientCapacity: cache.New(UnavailableOfferingsTTL, UnavailableOfferingsCleanupInterval),
+		SeqNum:               0,
 	}
 	uo.cache.OnEvicted(func(_ string, _ interface{}) {
 		atomic.AddUint64(&uo.SeqNum, 1)
@@ -45,9 +55,12 @@ func NewUnavailableOfferings() *UnavailableOfferings {
 	return uo
 }
 
-// IsUnavailable returns true if the offering appears in the cache
+// IsUnavailable returns true if the offering, or the zone of the offering, appears in the cache
 func (u *UnavailableOfferings) IsUnavailable(instanceType, zone, capacityType string) bool {
-	_, found := u.cache.Get(u.key(instanceType, zone, capacityType))
+	if _, found := u.cache.Get(u.key(instanceType, zone, capacityType)); found {
+		return true
+	}
+	_, found := u.cache.Get(u.zoneKey(zone, capacityType))
 	return found
 }
 
@@ -68,6 +81,38 @@ func (u *UnavailableOfferings) MarkUnavailableForFleetErr(ctx context.Context, f
 	instanceType := aws.StringValue(fleetErr.LaunchTemplateAndOverrides.Overrides.InstanceType)
 	zone := aws.StringValue(fleetErr.LaunchTemplateAndOverrides.Overrides.AvailabilityZone)
 	u.MarkUnavailable(ctx, aws.StringValue(fleetErr.ErrorCode), instanceType, zone, capacityType)
+	if aws.StringValue(fleetErr.ErrorCode) == "InsufficientInstanceCapacity" {
+		u.markInsufficientCapacity(ctx, instanceType, zone, capacityType)
+	}
+}
+
+// markInsufficientCapacity records an insufficient capacity error for the offering, and marks the zone as unavailable
+// for the capacity type once errors have been recorded for zone-capacity-cooldown-threshold distinct instance types
+func (u *UnavailableOfferings) markInsufficientCapacity(ctx context.Context, instanceType, zone, capacityType string) {
+	threshold := options.FromContext(ctx).ZoneCapacityCooldownThreshold
+	if threshold <= 0 {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.insufficientCapacity.SetDefault(u.key(instanceType, zone, capacityType), struct{}{})
+	instanceTypes := sets.New[string]()
+	for key := range u.insufficientCapacity.Items() {
+		if parts := strings.Split(key, ":"); len(parts) == 3 && parts[0] == capacityType && parts[2] == zone {
+			instanceTypes.Insert(parts[1])
+		}
+	}
+	if instanceTypes.Len() < threshold {
+		return
+	}
+	log.FromContext(ctx).WithValues(
+		"zone", zone,
+		"capacity-type", capacityType,
+		"instance-types", sets.List(instanceTypes),
+		"ttl", UnavailableZonesTTL).V(1).Info("removing zone from offerings")
+	u.cache.Set(u.zoneKey(zone, capacityType), struct{}{}, UnavailableZonesTTL)
+	atomic.AddUint64(&u.SeqNum, 1)
 }
 
 func (u *UnavailableOfferings) Delete(instanceType string, zone string, capacityType string) {
@@ -76,9 +121,15 @@ func (u *UnavailableOfferings) Delete(instanceType string, zone string, capacity
 
 func (u *UnavailableOfferings) Flush() {
 	u.cache.Flush()
+	u.insufficientCapacity.Flush()
 }
 
 // key returns the cache key for all offerings in the cache
 func (u *UnavailableOfferings) key(instanceType string, zone string, capacityType string) string {
 	return fmt.Sprintf("%s:%s:%s", capacityType, instanceType, zone)
 }
+
+// zoneKey returns the cache key for all offerings of a capacity type in a zone
+func (u *UnavailableOfferings) zoneKey(zone string, capacityType string) string {
+	return fmt.Sprintf("%s:%s", capacityType, zone)
+}
@@ -16,12 +16,15 @@ package cloudprovider
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/awslabs/operatorpkg/status"
+	"go.uber.org/multierr"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -34,15 +37,18 @@ import (
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	cloudproviderevents "github.com/aws/karpenter-provider-aws/pkg/cloudprovider/events"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
@@ -59,17 +65,17 @@ type CloudProvider struct {
 	recorder   events.Recorder
 
 	instanceTypeProvider  instancetype.Provider
-	instanceProvider      instance.Provider
+	accountProvider       account.Provider
 	amiProvider           amifamily.Provider
 	securityGroupProvider securitygroup.Provider
 	subnetProvider        subnet.Provider
 }
 
-func New(instanceTypeProvider instancetype.Provider, instanceProvider instance.Provider, recorder events.Recorder,
+func New(instanceTypeProvider instancetype.Provider, accountProvider account.Provider, recorder events.Recorder,
 	kubeClient client.Client, amiProvider amifamily.Provider, securityGroupProvider securitygroup.Provider, subnetProvider subnet.Provider) *CloudProvider {
 	return &CloudProvider{
 		instanceTypeProvider:  instanceTypeProvider,
-		instanceProvider:      instanceProvider,
+		accountProvider:       accountProvider,
 		kubeClient:            kubeClient,
 		amiProvider:           amiProvider,
 		securityGroupProvider: securityGroupProvider,
@@ -92,6 +98,7 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *corev1beta1.NodeC
 	if !nodeClassReady.IsTrue() {
 		return nil, fmt.Errorf("resolving ec2nodeclass, %s", nodeClassReady.Message)
 	}
+	nodeClaim = withZoneIDRequirement(nodeClaim, nodeClass)
 	instanceTypes, err := c.resolveInstanceTypes(ctx, nodeClaim, nodeClass)
 	if err != nil {
 		return nil, fmt.Errorf("resolving instance types, %w", err)
@@ -99,7 +106,7 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *corev1beta1.NodeC
 	if len(instanceTypes) == 0 {
 		return nil, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("all requested instance types were unavailable during launch"))
 	}
-	instance, err := c.instanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+	instance, err := c.accountProvider.Get(ctx, nodeClass).InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
 	if err != nil {
 		return nil, fmt.Errorf("creating instance, %w", err)
 	}
@@ -107,17 +114,45 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *corev1beta1.NodeC
 		return i.Name == instance.Type
 	})
 	nc := c.instanceToNodeClaim(instance, instanceType)
+	nc.Labels = withoutDisabledLabels(nc.Labels, nodeClaim, nodeClass)
+	if subnet, ok := lo.Find(nodeClass.Status.Subnets, func(s v1beta1.Subnet) bool {
+		return s.ID == instance.SubnetID || (instance.SubnetID == "" && s.Zone == instance.Zone)
+	}); ok && subnet.ZoneID != "" {
+		nc.Labels[v1beta1.LabelTopologyZoneID] = subnet.ZoneID
+	}
 	nc.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{
 		v1beta1.AnnotationEC2NodeClassHash:        nodeClass.Hash(),
 		v1beta1.AnnotationEC2NodeClassHashVersion: v1beta1.EC2NodeClassHashVersion,
 	})
+	if options.FromContext(ctx).AnnotateInstanceCapabilities && instanceType != nil {
+		nc.Annotations[v1beta1.AnnotationInstanceCapabilities] = instanceCapabilities(instanceType)
+	}
+	// The price is the hourly price of the offering that the instance was launched into, as it was known at launch
+	if instanceType != nil {
+		if offering, ok := instanceType.Offerings.Get(instance.CapacityType, instance.Zone); ok {
+			nc.Annotations[v1beta1.AnnotationLaunchPrice] = strconv.FormatFloat(offering.Price, 'f', -1, 64)
+		}
+	}
 	return nc, nil
 }
 
 func (c *CloudProvider) List(ctx context.Context) ([]*corev1beta1.NodeClaim, error) {
-	instances, err := c.instanceProvider.List(ctx)
+	accounts, err := c.accountProvider.List(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("listing instances, %w", err)
+		return nil, fmt.Errorf("listing accounts, %w", err)
+	}
+	var instances []*instance.Instance
+	for i, providers := range accounts {
+		accountInstances, err := providers.InstanceProvider.List(ctx)
+		if err != nil {
+			// A misconfigured assumed role shouldn't stop the instances of every other account from being listed
+			if i > 0 {
+				log.FromContext(ctx).Error(err, "failed listing instances of assumed role account")
+				continue
+			}
+			return nil, fmt.Errorf("listing instances, %w", err)
+		}
+		instances = append(instances, accountInstances...)
 	}
 	var nodeClaims []*corev1beta1.NodeClaim
 	for _, instance := range instances {
@@ -136,7 +171,7 @@ func (c *CloudProvider) Get(ctx context.Context, providerID string) (*corev1beta
 		return nil, fmt.Errorf("getting instance ID, %w", err)
 	}
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("id", id))
-	instance, err := c.instanceProvider.Get(ctx, id)
+	instance, err := c.getInstanceFromAccounts(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("getting instance, %w", err)
 	}
@@ -164,7 +199,7 @@ func (c *CloudProvider) GetInstanceTypes(ctx context.Context, nodePool *corev1be
 		return nil, fmt.Errorf("resolving node class, %w", err)
 	}
 	// TODO, break this coupling
-	instanceTypes, err := c.instanceTypeProvider.List(ctx, nodePool.Spec.Template.Spec.Kubelet, nodeClass)
+	instanceTypes, err := c.accountProvider.Get(ctx, nodeClass).InstanceTypeProvider.List(ctx, nodeClass.KubeletConfiguration(nodePool.Spec.Template.Spec.Kubelet), nodeClass)
 	if err != nil {
 		return nil, err
 	}
@@ -177,10 +212,42 @@ func (c *CloudProvider) Delete(ctx context.Context, nodeClaim *corev1beta1.NodeC
 		return fmt.Errorf("getting instance ID, %w", err)
 	}
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("id", id))
-	return c.instanceProvider.Delete(ctx, id)
+	accounts, err := c.accountProvider.List(ctx)
+	if err != nil {
+		return fmt.Errorf("listing accounts, %w", err)
+	}
+	// NodeClaims can opt into having their instance stopped rather than terminated, so that it's started again for a
+	// later NodeClaim
+	stop := nodeClaim.Annotations[v1beta1.AnnotationStopOnDelete] == "true"
+	// The instance is terminated in the first account that it's found in, since instance IDs are unique across accounts.
+	// Accounts that fail don't stop the instance from being terminated in the other accounts, but the instance is only
+	// reported as not found if it's not found in any account.
+	var errs []error
+	for _, providers := range accounts {
+		if stop {
+			err = providers.InstanceProvider.Stop(ctx, id)
+		} else {
+			err = providers.InstanceProvider.Delete(ctx, id)
+		}
+		if err == nil {
+			return nil
+		}
+		if !cloudprovider.IsNodeClaimNotFoundError(err) {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return multierr.Combine(errs...)
+	}
+	return err
 }
 
 func (c *CloudProvider) IsDrifted(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (cloudprovider.DriftReason, error) {
+	// NodeClaims whose spot instance received a rebalance recommendation are replaced through drift, so that the
+	// replacement is launched and the NodeClaim is drained by the core disruption controller
+	if _, ok := nodeClaim.Annotations[v1beta1.AnnotationRebalanceRecommended]; ok {
+		return RebalanceRecommendationDrift, nil
+	}
 	// Not needed when GetInstanceTypes removes nodepool dependency
 	nodePoolName, ok := nodeClaim.Labels[corev1beta1.NodePoolLabelKey]
 	if !ok {
@@ -250,7 +317,7 @@ func (c *CloudProvider) resolveNodeClassFromNodePool(ctx context.Context, nodePo
 }
 
 func (c *CloudProvider) resolveInstanceTypes(ctx context.Context, nodeClaim *corev1beta1.NodeClaim, nodeClass *v1beta1.EC2NodeClass) ([]*cloudprovider.InstanceType, error) {
-	instanceTypes, err := c.instanceTypeProvider.List(ctx, nodeClaim.Spec.Kubelet, nodeClass)
+	instanceTypes, err := c.accountProvider.Get(ctx, nodeClass).InstanceTypeProvider.List(ctx, nodeClass.KubeletConfiguration(nodeClaim.Spec.Kubelet), nodeClass)
 	if err != nil {
 		return nil, fmt.Errorf("getting instance types, %w", err)
 	}
@@ -262,6 +329,59 @@ func (c *CloudProvider) resolveInstanceTypes(ctx context.Context, nodeClaim *cor
 	}), nil
 }
 
+// withZoneIDRequirement translates a zone ID requirement on the NodeClaim into a requirement on the zone names that
+// the NodeClass' subnets map those zone IDs to, since offerings and subnets are only selected by zone name
+func withZoneIDRequirement(nodeClaim *corev1beta1.NodeClaim, nodeClass *v1beta1.EC2NodeClass) *corev1beta1.NodeClaim {
+	reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	if !reqs.Has(v1beta1.LabelTopologyZoneID) {
+		return nodeClaim
+	}
+	zoneIDs := reqs.Get(v1beta1.LabelTopologyZoneID)
+	zones := sets.New[string]()
+	for _, subnet := range nodeClass.Status.Subnets {
+		if subnet.ZoneID != "" && zoneIDs.Has(subnet.ZoneID) {
+			zones.Insert(subnet.Zone)
+		}
+	}
+	nodeClaim = nodeClaim.DeepCopy()
+	nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, corev1beta1.NodeSelectorRequirementWithMinValues{
+		NodeSelectorRequirement: v1.NodeSelectorRequirement{
+			Key:      v1.LabelTopologyZone,
+			Operator: v1.NodeSelectorOpIn,
+			Values:   sets.List(zones),
+		},
+	})
+	return nodeClaim
+}
+
+// getInstanceFromAccounts gets the instance from the first account that it's found in, since the account that an
+// instance was launched in can't be known from its ID
+func (c *CloudProvider) getInstanceFromAccounts(ctx context.Context, id string) (*instance.Instance, error) {
+	accounts, err := c.accountProvider.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing accounts, %w", err)
+	}
+	// Accounts that fail don't stop the instance from being found in the other accounts, but the instance is only
+	// reported as not found if it's not found in any account
+	var errs []error
+	for _, providers := range accounts {
+		instance, e := providers.InstanceProvider.Get(ctx, id)
+		if e == nil {
+			return instance, nil
+		}
+		if !cloudprovider.IsNodeClaimNotFoundError(e) {
+			log.FromContext(ctx).Error(e, "failed getting instance from account")
+			errs = append(errs, e)
+			continue
+		}
+		err = e
+	}
+	if len(errs) > 0 {
+		return nil, multierr.Combine(errs...)
+	}
+	return nil, err
+}
+
 func (c *CloudProvider) resolveInstanceTypeFromInstance(ctx context.Context, instance *instance.Instance) (*cloudprovider.InstanceType, error) {
 	nodePool, err := c.resolveNodePoolFromInstance(ctx, instance)
 	if err != nil {
@@ -290,6 +410,18 @@ func (c *CloudProvider) resolveNodePoolFromInstance(ctx context.Context, instanc
 	return nil, errors.NewNotFound(schema.GroupResource{Group: corev1beta1.Group, Resource: "nodepools"}, "")
 }
 
+// withoutDisabledLabels removes the labels that are disabled on the EC2NodeClass. Labels that the NodeClaim has a
+// requirement on are kept, since the pods that the NodeClaim was launched for may select on them.
+func withoutDisabledLabels(labels map[string]string, nodeClaim *corev1beta1.NodeClaim, nodeClass *v1beta1.EC2NodeClass) map[string]string {
+	if len(nodeClass.Spec.DisabledLabels) == 0 {
+		return labels
+	}
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	return lo.OmitBy(labels, func(key string, _ string) bool {
+		return lo.Contains(nodeClass.Spec.DisabledLabels, key) && !requirements.Has(key)
+	})
+}
+
 func (c *CloudProvider) instanceToNodeClaim(i *instance.Instance, instanceType *cloudprovider.InstanceType) *corev1beta1.NodeClaim {
 	nodeClaim := &corev1beta1.NodeClaim{}
 	labels := map[string]string{}
@@ -335,6 +467,44 @@ func (c *CloudProvider) instanceToNodeClaim(i *instance.Instance, instanceType *
 	return nodeClaim
 }
 
+// InstanceCapabilities are the capabilities of an instance type that Nodes are annotated with, so that agents on the
+// node can discover them without calling EC2. Only numeric fields are included so that the annotation stays small.
+type InstanceCapabilities struct {
+	VCPUs                int64 `json:"vcpus,omitempty"`
+	MemoryMiB            int64 `json:"memoryMiB,omitempty"`
+	GPUs                 int64 `json:"gpus,omitempty"`
+	GPUMemoryMiB         int64 `json:"gpuMemoryMiB,omitempty"`
+	NetworkBandwidthMbps int64 `json:"networkBandwidthMbps,omitempty"`
+	EBSBandwidthMbps     int64 `json:"ebsBandwidthMbps,omitempty"`
+	LocalStorageGB       int64 `json:"localStorageGB,omitempty"`
+}
+
+// instanceCapabilities returns the compact JSON of the capabilities that are resolved into the requirements of the
+// instance type
+func instanceCapabilities(instanceType *cloudprovider.InstanceType) string {
+	value := func(key string) int64 {
+		if !instanceType.Requirements.Has(key) || instanceType.Requirements.Get(key).Len() != 1 {
+			return 0
+		}
+		v, err := strconv.ParseInt(instanceType.Requirements.Get(key).Values()[0], 10, 64)
+		if err != nil {
+			return 0
+		}
+		return v
+	}
+	// Marshaling can't fail since the capabilities are only integers
+	raw := lo.Must(json.Marshal(InstanceCapabilities{
+		VCPUs:                value(v1beta1.LabelInstanceCPU),
+		MemoryMiB:            value(v1beta1.LabelInstanceMemory),
+		GPUs:                 value(v1beta1.LabelInstanceGPUCount),
+		GPUMemoryMiB:         value(v1beta1.LabelInstanceGPUMemory),
+		NetworkBandwidthMbps: value(v1beta1.LabelInstanceNetworkBandwidth),
+		EBSBandwidthMbps:     value(v1beta1.LabelInstanceEBSBandwidth),
+		LocalStorageGB:       value(v1beta1.LabelInstanceLocalNVME),
+	}))
+	return string(raw)
+}
+
 // newTerminatingNodeClassError returns a NotFound error for handling by
 func newTerminatingNodeClassError(name string) *errors.StatusError {
 	qualifiedResource := schema.GroupResource{Group: corev1beta1.Group, Resource: "ec2nodeclasses"}
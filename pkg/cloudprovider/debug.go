@@ -0,0 +1,96 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+)
+
+// LaunchTemplatesHandler serves the launch templates that Karpenter would create for an EC2NodeClass, without creating
+// them. The EC2NodeClass is selected with the "nodeclass" query parameter, and the labels, requirements and kubelet
+// configuration of a NodePool can be applied with the "nodepool" query parameter. The handler is passed the operator's
+// context, since requests to the metrics server don't carry the operator's options.
+func (c *CloudProvider) LaunchTemplatesHandler(ctx context.Context) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nodeClassName := r.URL.Query().Get("nodeclass")
+		if nodeClassName == "" {
+			http.Error(w, "the nodeclass query parameter is required", http.StatusBadRequest)
+			return
+		}
+		nodeClaim := &corev1beta1.NodeClaim{
+			Spec: corev1beta1.NodeClaimSpec{
+				NodeClassRef: &corev1beta1.NodeClassReference{Name: nodeClassName},
+			},
+		}
+		if nodePoolName := r.URL.Query().Get("nodepool"); nodePoolName != "" {
+			nodePool := &corev1beta1.NodePool{}
+			if err := c.kubeClient.Get(r.Context(), types.NamespacedName{Name: nodePoolName}, nodePool); err != nil {
+				writeError(w, fmt.Errorf("getting nodepool, %w", err))
+				return
+			}
+			nodeClaim.Labels = lo.Assign(nodePool.Spec.Template.Labels, map[string]string{corev1beta1.NodePoolLabelKey: nodePool.Name})
+			nodeClaim.Spec.Requirements = nodePool.Spec.Template.Spec.Requirements
+			nodeClaim.Spec.Kubelet = nodePool.Spec.Template.Spec.Kubelet
+		}
+		launchTemplates, err := c.launchTemplates(ctx, nodeClaim)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(launchTemplates); err != nil {
+			log.FromContext(ctx).Error(err, "failed writing launch templates")
+		}
+	})
+}
+
+func (c *CloudProvider) launchTemplates(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) ([]*launchtemplate.RenderedLaunchTemplate, error) {
+	nodeClass := &v1beta1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		return nil, fmt.Errorf("getting ec2nodeclass, %w", err)
+	}
+	instanceTypes, err := c.resolveInstanceTypes(ctx, nodeClaim, nodeClass)
+	if err != nil {
+		return nil, fmt.Errorf("resolving instance types, %w", err)
+	}
+	if len(instanceTypes) == 0 {
+		return nil, fmt.Errorf("no instance types are compatible with the ec2nodeclass")
+	}
+	launchTemplates, err := c.accountProvider.Get(ctx, nodeClass).InstanceProvider.LaunchTemplates(ctx, nodeClass, nodeClaim, instanceTypes)
+	if err != nil {
+		return nil, fmt.Errorf("rendering launch templates, %w", err)
+	}
+	return launchTemplates, nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	if errors.IsNotFound(err) {
+		code = http.StatusNotFound
+	}
+	http.Error(w, err.Error(), code)
+}
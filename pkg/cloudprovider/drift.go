@@ -32,10 +32,11 @@ import (
 )
 
 const (
-	AMIDrift           cloudprovider.DriftReason = "AMIDrift"
-	SubnetDrift        cloudprovider.DriftReason = "SubnetDrift"
-	SecurityGroupDrift cloudprovider.DriftReason = "SecurityGroupDrift"
-	NodeClassDrift     cloudprovider.DriftReason = "NodeClassDrift"
+	AMIDrift                     cloudprovider.DriftReason = "AMIDrift"
+	SubnetDrift                  cloudprovider.DriftReason = "SubnetDrift"
+	SecurityGroupDrift           cloudprovider.DriftReason = "SecurityGroupDrift"
+	NodeClassDrift               cloudprovider.DriftReason = "NodeClassDrift"
+	RebalanceRecommendationDrift cloudprovider.DriftReason = "RebalanceRecommendationDrift"
 )
 
 func (c *CloudProvider) isNodeClassDrifted(ctx context.Context, nodeClaim *corev1beta1.NodeClaim, nodePool *corev1beta1.NodePool, nodeClass *v1beta1.EC2NodeClass) (cloudprovider.DriftReason, error) {
@@ -43,7 +44,7 @@ func (c *CloudProvider) isNodeClassDrifted(ctx context.Context, nodeClaim *corev
 	if drifted := c.areStaticFieldsDrifted(nodeClaim, nodeClass); drifted != "" {
 		return drifted, nil
 	}
-	instance, err := c.getInstance(ctx, nodeClaim.Status.ProviderID)
+	instance, err := c.getInstance(ctx, nodeClaim.Status.ProviderID, nodeClass)
 	if err != nil {
 		return "", err
 	}
@@ -80,6 +81,10 @@ func (c *CloudProvider) isAMIDrifted(ctx context.Context, nodeClaim *corev1beta1
 	if len(nodeClass.Status.AMIs) == 0 {
 		return "", fmt.Errorf("no amis exist given constraints")
 	}
+	// The default AMIs are only a fallback while the amiSelectorTerms don't match any AMIs, so nodes aren't drifted to them
+	if condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeAMISelectorTermsResolved); condition != nil && condition.IsFalse() {
+		return "", nil
+	}
 	mappedAMIs := amifamily.MapToInstanceTypes([]*cloudprovider.InstanceType{nodeInstanceType}, nodeClass.Status.AMIs)
 	if !lo.Contains(lo.Keys(mappedAMIs), instance.ImageID) {
 		return AMIDrift, nil
@@ -106,9 +111,13 @@ func (c *CloudProvider) isSubnetDrifted(instance *instance.Instance, nodeClass *
 }
 
 // Checks if the security groups are drifted, by comparing the security groups returned from the SecurityGroupProvider
-// to the ec2 instance security groups
+// to the ec2 instance security groups. Only the security groups in the VPC of the instance's subnet are compared, since
+// instances are launched with the security groups of a single VPC.
 func (c *CloudProvider) areSecurityGroupsDrifted(ec2Instance *instance.Instance, nodeClass *v1beta1.EC2NodeClass) (cloudprovider.DriftReason, error) {
-	securityGroupIds := sets.New(lo.Map(nodeClass.Status.SecurityGroups, func(sg v1beta1.SecurityGroup, _ int) string { return sg.ID })...)
+	vpcID := lo.FindOrElse(nodeClass.Status.Subnets, v1beta1.Subnet{}, func(s v1beta1.Subnet) bool { return s.ID == ec2Instance.SubnetID }).VPCID
+	securityGroupIds := sets.New(lo.FilterMap(nodeClass.Status.SecurityGroups, func(sg v1beta1.SecurityGroup, _ int) (string, bool) {
+		return sg.ID, vpcID == "" || sg.VPCID == "" || sg.VPCID == vpcID
+	})...)
 	if len(securityGroupIds) == 0 {
 		return "", fmt.Errorf("no security groups are present in the status")
 	}
@@ -135,13 +144,13 @@ func (c *CloudProvider) areStaticFieldsDrifted(nodeClaim *corev1beta1.NodeClaim,
 	return lo.Ternary(nodeClassHash != nodeClaimHash, NodeClassDrift, "")
 }
 
-func (c *CloudProvider) getInstance(ctx context.Context, providerID string) (*instance.Instance, error) {
+func (c *CloudProvider) getInstance(ctx context.Context, providerID string, nodeClass *v1beta1.EC2NodeClass) (*instance.Instance, error) {
 	// Get InstanceID to fetch from EC2
 	instanceID, err := utils.ParseInstanceID(providerID)
 	if err != nil {
 		return nil, err
 	}
-	instance, err := c.instanceProvider.Get(ctx, instanceID)
+	instance, err := c.accountProvider.Get(ctx, nodeClass).InstanceProvider.Get(ctx, instanceID)
 	if err != nil {
 		return nil, fmt.Errorf("getting instance, %w", err)
 	}
@@ -16,8 +16,10 @@ package cloudprovider_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -30,6 +32,7 @@ import (
 	clock "k8s.io/utils/clock/testing"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	opstatus "github.com/awslabs/operatorpkg/status"
@@ -83,7 +86,7 @@ var _ = BeforeSuite(func() {
 	awsEnv = test.NewEnvironment(ctx, env)
 	fakeClock = clock.NewFakeClock(time.Now())
 	recorder = events.NewRecorder(&record.FakeRecorder{})
-	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, recorder,
+	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.AccountProvider, recorder,
 		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider, awsEnv.SubnetProvider)
 	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
 	prov = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster)
@@ -134,16 +137,19 @@ var _ = Describe("CloudProvider", func() {
 					},
 					Subnets: []v1beta1.Subnet{
 						{
-							ID:   "subnet-test1",
-							Zone: "test-zone-1a",
+							ID:     "subnet-test1",
+							Zone:   "test-zone-1a",
+							ZoneID: "testzone1a",
 						},
 						{
-							ID:   "subnet-test2",
-							Zone: "test-zone-1b",
+							ID:     "subnet-test2",
+							Zone:   "test-zone-1b",
+							ZoneID: "testzone1b",
 						},
 						{
-							ID:   "subnet-test3",
-							Zone: "test-zone-1c",
+							ID:     "subnet-test3",
+							Zone:   "test-zone-1c",
+							ZoneID: "testzone1c",
 						},
 					},
 				},
@@ -225,6 +231,68 @@ var _ = Describe("CloudProvider", func() {
 		Expect(ok).To(BeTrue())
 		Expect(v).To(Equal(v1beta1.EC2NodeClassHashVersion))
 	})
+	Context("Disabled Labels", func() {
+		It("should not label the nodeClaim with disabled labels", func() {
+			nodeClass.Spec.DisabledLabels = []string{v1beta1.LabelInstanceCPU, v1beta1.LabelInstanceNetworkBandwidth}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim.Labels).ToNot(HaveKey(v1beta1.LabelInstanceCPU))
+			Expect(cloudProviderNodeClaim.Labels).ToNot(HaveKey(v1beta1.LabelInstanceNetworkBandwidth))
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKey(v1beta1.LabelInstanceMemory))
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKey(v1.LabelInstanceTypeStable))
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKey(v1.LabelArchStable))
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKey(v1.LabelTopologyZone))
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKey(corev1beta1.CapacityTypeLabelKey))
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKey(v1beta1.LabelTopologyZoneID))
+		})
+		It("should keep disabled labels that the nodeClaim has a requirement on", func() {
+			nodeClass.Spec.DisabledLabels = []string{v1beta1.LabelInstanceCPU}
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, corev1beta1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1beta1.LabelInstanceCPU, Operator: v1.NodeSelectorOpExists},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKey(v1beta1.LabelInstanceCPU))
+		})
+	})
+	Context("Zone ID", func() {
+		It("should launch into the zone that a zone ID maps to", func() {
+			nodeClaim.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1beta1.LabelTopologyZoneID, Operator: v1.NodeSelectorOpIn, Values: []string{"testzone1c"}}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(v1.LabelTopologyZone, "test-zone-1c"))
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(v1beta1.LabelTopologyZoneID, "testzone1c"))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			for _, ltc := range createFleetInput.LaunchTemplateConfigs {
+				for _, override := range ltc.Overrides {
+					Expect(aws.StringValue(override.AvailabilityZone)).To(Equal("test-zone-1c"))
+				}
+			}
+		})
+		It("should label the nodeClaim with the zone ID of the zone that it was launched in", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			zone := cloudProviderNodeClaim.Labels[v1.LabelTopologyZone]
+			subnet, ok := lo.Find(nodeClass.Status.Subnets, func(s v1beta1.Subnet) bool { return s.Zone == zone })
+			Expect(ok).To(BeTrue())
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(v1beta1.LabelTopologyZoneID, subnet.ZoneID))
+		})
+		It("should return an ICE error when no subnet is in the zone ID", func() {
+			nodeClaim.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1beta1.LabelTopologyZoneID, Operator: v1.NodeSelectorOpIn, Values: []string{"testzone1d"}}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(corecloudproivder.IsInsufficientCapacityError(err)).To(BeTrue())
+			Expect(cloudProviderNodeClaim).To(BeNil())
+		})
+	})
 	Context("EC2 Context", func() {
 		contextID := "context-1234"
 		It("should set context on the CreateFleet request if specified on the NodePool", func() {
@@ -715,6 +783,21 @@ var _ = Describe("CloudProvider", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(isDrifted).To(Equal(cloudprovider.AMIDrift))
 		})
+		It("should not return drifted if the AMI is not valid while the default AMIs are used as a fallback", func() {
+			nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeAMISelectorTermsResolved, "DefaultAMIFallback", "amiSelectorTerms didn't match any AMIs")
+			ExpectApplied(ctx, env.Client, nodeClass)
+			// Instance is a reference to what we return in the GetInstances call
+			instance.ImageId = aws.String(fake.ImageID())
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(BeEmpty())
+		})
+		It("should return drifted if the instance received a rebalance recommendation", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1beta1.AnnotationRebalanceRecommended: time.Now().UTC().Format(time.RFC3339)})
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(Equal(cloudprovider.RebalanceRecommendationDrift))
+		})
 		It("should return drifted if there are multiple drift reasons", func() {
 			// Instance is a reference to what we return in the GetInstances call
 			instance.ImageId = aws.String(fake.ImageID())
@@ -785,6 +868,24 @@ var _ = Describe("CloudProvider", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(isDrifted).To(Equal(cloudprovider.SecurityGroupDrift))
 		})
+		It("should not return drifted if the security groups of the instance's vpc match", func() {
+			nodeClass.Status.Subnets[0].VPCID = "vpc-test1"
+			nodeClass.Status.Subnets[1].VPCID = "vpc-test2"
+			nodeClass.Status.SecurityGroups = []v1beta1.SecurityGroup{
+				{
+					ID:    validSecurityGroup,
+					VPCID: "vpc-test1",
+				},
+				{
+					ID:    fake.SecurityGroupID(),
+					VPCID: "vpc-test2",
+				},
+			}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(BeEmpty())
+		})
 		It("should not return drifted if the security groups match", func() {
 			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
 			Expect(err).ToNot(HaveOccurred())
@@ -1064,7 +1165,7 @@ var _ = Describe("CloudProvider", func() {
 				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int64(100),
 					Tags: []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
 			}})
-			controller := status.NewController(env.Client, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+			controller := status.NewController(env.Client, awsEnv.AccountProvider, awsEnv.LaunchTemplateProvider)
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
 			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1.LabelTopologyZone: "test-zone-1a"}})
@@ -1081,7 +1182,7 @@ var _ = Describe("CloudProvider", func() {
 				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int64(11),
 					Tags: []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
 			}})
-			controller := status.NewController(env.Client, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+			controller := status.NewController(env.Client, awsEnv.AccountProvider, awsEnv.LaunchTemplateProvider)
 			nodePool.Spec.Template.Spec.Kubelet = &corev1beta1.KubeletConfiguration{MaxPods: aws.Int32(1)}
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
@@ -1119,7 +1220,7 @@ var _ = Describe("CloudProvider", func() {
 			}})
 			nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{{Tags: map[string]string{"Name": "test-subnet-1"}}}
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
-			controller := status.NewController(env.Client, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+			controller := status.NewController(env.Client, awsEnv.AccountProvider, awsEnv.LaunchTemplateProvider)
 			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
 			podSubnet1 := coretest.UnschedulablePod()
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, podSubnet1)
@@ -1241,4 +1342,259 @@ var _ = Describe("CloudProvider", func() {
 			Expect(lo.Keys(cloudProviderNodeClaim.Status.Allocatable)).ToNot(ContainElement(v1beta1.ResourceEFA))
 		})
 	})
+	Context("Stop On Delete", func() {
+		BeforeEach(func() {
+			nodeClaim.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.CapacityTypeOnDemand}}},
+			}
+		})
+		It("should stop the instance of a nodeClaim that opts into stopping", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1beta1.AnnotationStopOnDelete: "true"})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			cloudProviderNodeClaim.Annotations = nodeClaim.Annotations
+
+			Expect(cloudProvider.Delete(ctx, cloudProviderNodeClaim)).To(Succeed())
+			Expect(awsEnv.EC2API.StopInstancesBehavior.Calls()).To(Equal(1))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(0))
+			// The nodeClaim is finalized once its instance is stopped
+			err = cloudProvider.Delete(ctx, cloudProviderNodeClaim)
+			Expect(corecloudproivder.IsNodeClaimNotFoundError(err)).To(BeTrue())
+		})
+		It("should terminate the instance of a nodeClaim that doesn't opt into stopping", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+
+			Expect(cloudProvider.Delete(ctx, cloudProviderNodeClaim)).To(Succeed())
+			Expect(awsEnv.EC2API.StopInstancesBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(1))
+		})
+	})
+	Context("Instance Capabilities", func() {
+		BeforeEach(func() {
+			nodeClaim.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"g4dn.8xlarge"}}},
+			}
+		})
+		It("should annotate the nodeClaim with the capabilities of its instance type", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				AnnotateInstanceCapabilities: lo.ToPtr(true),
+			}))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim.Annotations).To(HaveKey(v1beta1.AnnotationInstanceCapabilities))
+
+			capabilities := cloudprovider.InstanceCapabilities{}
+			Expect(json.Unmarshal([]byte(cloudProviderNodeClaim.Annotations[v1beta1.AnnotationInstanceCapabilities]), &capabilities)).To(Succeed())
+			Expect(capabilities).To(Equal(cloudprovider.InstanceCapabilities{
+				VCPUs:                32,
+				MemoryMiB:            131072,
+				GPUs:                 1,
+				GPUMemoryMiB:         16384,
+				NetworkBandwidthMbps: 50000,
+				EBSBandwidthMbps:     9500,
+				LocalStorageGB:       900,
+			}))
+		})
+		It("should omit the capabilities that the instance type doesn't have", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				AnnotateInstanceCapabilities: lo.ToPtr(true),
+			}))
+			nodeClaim.Spec.Requirements[0].Values = []string{"m5.large"}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim.Annotations[v1beta1.AnnotationInstanceCapabilities]).To(Equal(
+				`{"vcpus":2,"memoryMiB":8192,"networkBandwidthMbps":750,"ebsBandwidthMbps":4750}`,
+			))
+		})
+		It("should not annotate the nodeClaim when disabled", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationInstanceCapabilities))
+		})
+	})
+	Context("Launch Price", func() {
+		It("should annotate the nodeClaim with the on-demand price of its instance type", func() {
+			nodeClaim.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"m5.large"}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.CapacityTypeOnDemand}}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("m5.large")
+			Expect(ok).To(BeTrue())
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(corev1beta1.CapacityTypeLabelKey, corev1beta1.CapacityTypeOnDemand))
+			Expect(cloudProviderNodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationLaunchPrice, strconv.FormatFloat(price, 'f', -1, 64)))
+		})
+		It("should annotate the nodeClaim with the spot price of the zone that it was launched into", func() {
+			now := time.Now()
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []*ec2.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     aws.String("m5.large"),
+						SpotPrice:        aws.String("0.0123"),
+						Timestamp:        &now,
+					},
+					{
+						AvailabilityZone: aws.String("test-zone-1b"),
+						InstanceType:     aws.String("m5.large"),
+						SpotPrice:        aws.String("0.0456"),
+						Timestamp:        &now,
+					},
+				},
+			})
+			Expect(awsEnv.PricingProvider.UpdateSpotPricing(ctx)).To(Succeed())
+			nodeClaim.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"m5.large"}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.CapacityTypeSpot}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1b"}}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(corev1beta1.CapacityTypeLabelKey, corev1beta1.CapacityTypeSpot))
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(v1.LabelTopologyZone, "test-zone-1b"))
+			Expect(cloudProviderNodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationLaunchPrice, "0.0456"))
+		})
+	})
+	Context("Assume Role", func() {
+		var defaultNodeClaim *corev1beta1.NodeClaim
+		BeforeEach(func() {
+			nodeClass.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::123456789012:role/karpenter-nodes")
+			_, err := awsEnv.AccountProvider.Get(ctx, nodeClass).SubnetProvider.List(ctx, nodeClass) // Hydrate the subnet cache of the account
+			Expect(err).To(BeNil())
+
+			defaultNodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{Status: *nodeClass.Status.DeepCopy()})
+			defaultNodeClass.StatusConditions().SetTrue(opstatus.ConditionReady)
+			defaultNodeClaim = coretest.NodeClaim(corev1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{corev1beta1.NodePoolLabelKey: nodePool.Name},
+				},
+				Spec: corev1beta1.NodeClaimSpec{
+					NodeClassRef: &corev1beta1.NodeClassReference{
+						Name: defaultNodeClass.Name,
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, defaultNodeClass)
+		})
+		It("should launch instances in the account of the assumed role", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim).ToNot(BeNil())
+			Expect(awsEnv.AccountEC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(0))
+		})
+		It("should launch instances in the account that Karpenter runs in when no role is assumed", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, defaultNodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, defaultNodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim).ToNot(BeNil())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			Expect(awsEnv.AccountEC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(0))
+		})
+		It("should track unavailable offerings separately for each account", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "test", "m5.large", "test-zone-1a", corev1beta1.CapacityTypeSpot)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).To(BeNil())
+			instanceType, ok := lo.Find(instanceTypes, func(it *corecloudproivder.InstanceType) bool { return it.Name == "m5.large" })
+			Expect(ok).To(BeTrue())
+			offering, ok := instanceType.Offerings.Get(corev1beta1.CapacityTypeSpot, "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			Expect(offering.Available).To(BeTrue())
+		})
+		It("should list the instances of every account", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			newInstance := func() *ec2.Instance {
+				return &ec2.Instance{
+					State: &ec2.InstanceState{
+						Name: aws.String(ec2.InstanceStateNameRunning),
+					},
+					Tags: []*ec2.Tag{
+						{
+							Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)),
+							Value: aws.String("owned"),
+						},
+						{
+							Key:   aws.String(corev1beta1.NodePoolLabelKey),
+							Value: aws.String(nodePool.Name),
+						},
+						{
+							Key:   aws.String(v1beta1.LabelNodeClass),
+							Value: aws.String(nodeClass.Name),
+						},
+					},
+					PrivateDnsName: aws.String(fake.PrivateDNSName()),
+					Placement: &ec2.Placement{
+						AvailabilityZone: aws.String("test-zone-1a"),
+					},
+					InstanceId:   aws.String(fake.InstanceID()),
+					InstanceType: aws.String("m5.large"),
+					LaunchTime:   aws.Time(time.Now()),
+				}
+			}
+			accountInstance := newInstance()
+			awsEnv.AccountEC2API.Instances.Store(aws.StringValue(accountInstance.InstanceId), accountInstance)
+			defaultInstance := newInstance()
+			awsEnv.EC2API.Instances.Store(aws.StringValue(defaultInstance.InstanceId), defaultInstance)
+
+			nodeClaims, err := cloudProvider.List(ctx)
+			Expect(err).To(BeNil())
+			Expect(lo.Map(nodeClaims, func(nc *corev1beta1.NodeClaim, _ int) string { return nc.Status.ProviderID })).To(ConsistOf(
+				fmt.Sprintf("aws:///test-zone-1a/%s", aws.StringValue(accountInstance.InstanceId)),
+				fmt.Sprintf("aws:///test-zone-1a/%s", aws.StringValue(defaultInstance.InstanceId)),
+			))
+		})
+		It("should list the instances of the other accounts when an assumed role account fails", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, defaultNodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, defaultNodeClaim)
+			Expect(err).To(BeNil())
+			awsEnv.AccountEC2API.DescribeInstancesBehavior.Error.Set(awserr.New("UnauthorizedOperation", "not authorized", nil), fake.MaxCalls(0))
+
+			nodeClaims, err := cloudProvider.List(ctx)
+			Expect(err).To(BeNil())
+			Expect(lo.Map(nodeClaims, func(nc *corev1beta1.NodeClaim, _ int) string { return nc.Status.ProviderID })).To(ConsistOf(cloudProviderNodeClaim.Status.ProviderID))
+		})
+		It("should not return a NodeClaimNotFound error when an assumed role account fails", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			awsEnv.AccountEC2API.DescribeInstancesBehavior.Error.Set(awserr.New("UnauthorizedOperation", "not authorized", nil), fake.MaxCalls(0))
+			_, err := cloudProvider.Get(ctx, fmt.Sprintf("aws:///%s/%s", fake.DefaultRegion, fake.InstanceID()))
+			Expect(err).To(HaveOccurred())
+			Expect(corecloudproivder.IsNodeClaimNotFoundError(err)).To(BeFalse())
+		})
+		It("should get an instance from the account that it was launched in", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+
+			got, err := cloudProvider.Get(ctx, cloudProviderNodeClaim.Status.ProviderID)
+			Expect(err).To(BeNil())
+			Expect(got.Status.ProviderID).To(Equal(cloudProviderNodeClaim.Status.ProviderID))
+		})
+		It("should delete an instance in the account that it was launched in", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			awsEnv.EC2API.TerminateInstancesBehavior.Error.Set(awserr.New("InvalidInstanceID.NotFound", "not found", nil))
+
+			Expect(cloudProvider.Delete(ctx, cloudProviderNodeClaim)).To(Succeed())
+			Expect(awsEnv.AccountEC2API.TerminateInstancesBehavior.CalledWithInput.Len()).To(BeNumerically(">=", 1))
+			_, err = cloudProvider.Get(ctx, cloudProviderNodeClaim.Status.ProviderID)
+			Expect(corecloudproivder.IsNodeClaimNotFoundError(err)).To(BeTrue())
+		})
+		It("should return a NodeClaimNotFound error when the instance isn't found in any account", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			_, err := cloudProvider.Get(ctx, fmt.Sprintf("aws:///%s/%s", fake.DefaultRegion, fake.InstanceID()))
+			Expect(corecloudproivder.IsNodeClaimNotFoundError(err)).To(BeTrue())
+		})
+	})
 })
@@ -23,11 +23,14 @@ import (
 	nodeclasshash "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclass/hash"
 	nodeclassstatus "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclass/status"
 	nodeclasstermination "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclass/termination"
+	controllersami "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/ami"
 	controllersinstancetype "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/instancetype"
+	controllersplacementscore "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/placementscore"
 	controllerspricing "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/pricing"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
 
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	servicesqs "github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/samber/lo"
 	"k8s.io/utils/clock"
@@ -35,39 +38,43 @@ import (
 
 	"sigs.k8s.io/karpenter/pkg/events"
 
-	"github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption"
+	networkinterfacegarbagecollection "github.com/aws/karpenter-provider-aws/pkg/controllers/networkinterface/garbagecollection"
+	nodeclaimextendedresources "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/extendedresources"
 	nodeclaimgarbagecollection "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/garbagecollection"
+	nodeclaimnotready "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/notready"
+	nodeclaimreadiness "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/readiness"
 	nodeclaimtagging "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/tagging"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/placementscore"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/sqs"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 )
 
-func NewControllers(ctx context.Context, sess *session.Session, clk clock.Clock, kubeClient client.Client, recorder events.Recorder,
-	unavailableOfferings *cache.UnavailableOfferings, cloudProvider cloudprovider.CloudProvider, subnetProvider subnet.Provider,
-	securityGroupProvider securitygroup.Provider, instanceProfileProvider instanceprofile.Provider, instanceProvider instance.Provider,
-	pricingProvider pricing.Provider, amiProvider amifamily.Provider, launchTemplateProvider launchtemplate.Provider, instanceTypeProvider instancetype.Provider) []controller.Controller {
+func NewControllers(ctx context.Context, sess *session.Session, ec2api ec2iface.EC2API, clk clock.Clock, kubeClient client.Client, recorder events.Recorder,
+	cloudProvider cloudprovider.CloudProvider, accountProvider account.Provider,
+	pricingProvider pricing.Provider, launchTemplateProvider launchtemplate.Provider, placementScoreProvider placementscore.Provider) []controller.Controller {
 
 	controllers := []controller.Controller{
 		nodeclasshash.NewController(kubeClient),
-		nodeclassstatus.NewController(kubeClient, subnetProvider, securityGroupProvider, amiProvider, instanceProfileProvider, launchTemplateProvider),
-		nodeclasstermination.NewController(kubeClient, recorder, instanceProfileProvider, launchTemplateProvider),
-		nodeclaimgarbagecollection.NewController(kubeClient, cloudProvider),
-		nodeclaimtagging.NewController(kubeClient, instanceProvider),
+		nodeclassstatus.NewController(kubeClient, accountProvider, launchTemplateProvider),
+		nodeclasstermination.NewController(kubeClient, recorder, accountProvider),
+		nodeclaimgarbagecollection.NewController(kubeClient, recorder, cloudProvider),
+		nodeclaimtagging.NewController(kubeClient, accountProvider),
+		nodeclaimreadiness.NewController(kubeClient),
+		nodeclaimnotready.NewController(kubeClient, recorder, clk),
+		nodeclaimextendedresources.NewController(kubeClient),
+		networkinterfacegarbagecollection.NewController(accountProvider, clk),
 		controllerspricing.NewController(pricingProvider),
-		controllersinstancetype.NewController(instanceTypeProvider),
+		controllersinstancetype.NewController(accountProvider),
+		controllersplacementscore.NewController(placementScoreProvider),
+		controllersami.NewController(kubeClient, accountProvider),
 	}
 	if options.FromContext(ctx).InterruptionQueue != "" {
 		sqsapi := servicesqs.New(sess)
 		out := lo.Must(sqsapi.GetQueueUrlWithContext(ctx, &servicesqs.GetQueueUrlInput{QueueName: lo.ToPtr(options.FromContext(ctx).InterruptionQueue)}))
-		controllers = append(controllers, interruption.NewController(kubeClient, clk, recorder, lo.Must(sqs.NewDefaultProvider(sqsapi, lo.FromPtr(out.QueueUrl))), unavailableOfferings))
+		controllers = append(controllers, interruption.NewController(kubeClient, clk, recorder, lo.Must(sqs.NewDefaultProvider(sqsapi, lo.FromPtr(out.QueueUrl))), accountProvider))
 	}
 	return controllers
 }
@@ -16,7 +16,9 @@ package interruption
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	sqsapi "github.com/aws/aws-sdk-go/service/sqs"
@@ -24,6 +26,7 @@ import (
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/clock"
@@ -36,10 +39,12 @@ import (
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 
-	"github.com/aws/karpenter-provider-aws/pkg/cache"
 	interruptionevents "github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/events"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/statechange"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/sqs"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
@@ -50,34 +55,41 @@ import (
 type Action string
 
 const (
-	CordonAndDrain Action = "CordonAndDrain"
-	NoAction       Action = "NoAction"
+	CordonAndDrain  Action = "CordonAndDrain"
+	ReplaceAndDrain Action = "ReplaceAndDrain"
+	NoAction        Action = "NoAction"
 )
 
+// shortPollingInterval is how long the controller waits before polling an empty queue again when the wait time of a
+// poll rounds down to 0 seconds. Short polls return right away, so without it the singleton would poll in a tight loop.
+const shortPollingInterval = time.Second
+
 // Controller is an AWS interruption controller.
 // It continually polls an SQS queue for events from aws.ec2 and aws.health that
 // trigger node health events or node spot interruption/rebalance events.
 type Controller struct {
-	kubeClient                client.Client
-	clk                       clock.Clock
-	recorder                  events.Recorder
-	sqsProvider               sqs.Provider
-	unavailableOfferingsCache *cache.UnavailableOfferings
-	parser                    *EventParser
-	cm                        *pretty.ChangeMonitor
+	kubeClient      client.Client
+	clk             clock.Clock
+	recorder        events.Recorder
+	sqsProvider     sqs.Provider
+	accountProvider account.Provider
+	parser          *EventParser
+	cm              *pretty.ChangeMonitor
+	limiter         *disruptionLimiter
 }
 
 func NewController(kubeClient client.Client, clk clock.Clock, recorder events.Recorder,
-	sqsProvider sqs.Provider, unavailableOfferingsCache *cache.UnavailableOfferings) *Controller {
+	sqsProvider sqs.Provider, accountProvider account.Provider) *Controller {
 
 	return &Controller{
-		kubeClient:                kubeClient,
-		clk:                       clk,
-		recorder:                  recorder,
-		sqsProvider:               sqsProvider,
-		unavailableOfferingsCache: unavailableOfferingsCache,
-		parser:                    NewEventParser(DefaultParsers...),
-		cm:                        pretty.NewChangeMonitor(),
+		kubeClient:      kubeClient,
+		clk:             clk,
+		recorder:        recorder,
+		sqsProvider:     sqsProvider,
+		accountProvider: accountProvider,
+		parser:          NewEventParser(DefaultParsers...),
+		cm:              pretty.NewChangeMonitor(),
+		limiter:         newDisruptionLimiter(),
 	}
 }
 
@@ -91,6 +103,9 @@ func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconc
 		return reconcile.Result{}, fmt.Errorf("getting messages from queue, %w", err)
 	}
 	if len(sqsMessages) == 0 {
+		if options.FromContext(ctx).InterruptionWaitTime < time.Second {
+			return reconcile.Result{RequeueAfter: shortPollingInterval}, nil
+		}
 		return reconcile.Result{}, nil
 	}
 	nodeClaimInstanceIDMap, err := c.makeNodeClaimInstanceIDMap(ctx)
@@ -101,33 +116,79 @@ func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconc
 	if err != nil {
 		return reconcile.Result{}, fmt.Errorf("making node instance id map, %w", err)
 	}
+	c.limiter.Prune(sets.New(lo.MapToSlice(nodeClaimInstanceIDMap, func(_ string, nc *v1beta1.NodeClaim) string { return nc.Name })...))
+
 	errs := make([]error, len(sqsMessages))
-	workqueue.ParallelizeUntil(ctx, 10, len(sqsMessages), func(i int) {
+	handled := make([]bool, len(sqsMessages))
+	msgs := make([]messages.Message, len(sqsMessages))
+	for i := range sqsMessages {
 		msg, e := c.parseMessage(sqsMessages[i])
 		if e != nil {
 			// If we fail to parse, then we should delete the message but still log the error
-			log.FromContext(ctx).Error(err, "failed parsing interruption message")
-			errs[i] = c.deleteMessage(ctx, sqsMessages[i])
-			return
+			log.FromContext(ctx).Error(e, "failed parsing interruption message")
+			handled[i] = true
+			continue
 		}
-		if e = c.handleMessage(ctx, nodeClaimInstanceIDMap, nodeInstanceIDMap, msg); e != nil {
-			errs[i] = fmt.Errorf("handling message, %w", e)
+		msgs[i] = msg
+	}
+	// Messages with the nearest deadlines are handled first so that they count toward the disruption limit before
+	// messages that can be deferred
+	order := lo.Filter(lo.Range(len(msgs)), func(i int, _ int) bool { return msgs[i] != nil })
+	sort.SliceStable(order, func(a, b int) bool { return urgent(msgs[order[a]]) && !urgent(msgs[order[b]]) })
+	workqueue.ParallelizeUntil(ctx, options.FromContext(ctx).InterruptionWorkers, len(order), func(j int) {
+		i := order[j]
+		if e := c.handleMessage(ctx, nodeClaimInstanceIDMap, nodeInstanceIDMap, msgs[i]); e != nil {
+			if !isDeferred(e) {
+				errs[i] = fmt.Errorf("handling message, %w", e)
+			}
 			return
 		}
-		errs[i] = c.deleteMessage(ctx, sqsMessages[i])
+		handled[i] = true
 	})
+	// Only messages that were handled are deleted. Messages that failed are left on the queue and are
+	// re-delivered once their visibility timeout expires.
+	errs = append(errs, c.deleteMessages(ctx, lo.Filter(sqsMessages, func(_ *sqsapi.Message, i int) bool { return handled[i] })))
 	if err = multierr.Combine(errs...); err != nil {
 		return reconcile.Result{}, err
 	}
 	return reconcile.Result{}, nil
 }
 
-func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+func (c *Controller) Register(ctx context.Context, m manager.Manager) error {
+	if err := c.validateQueue(ctx); err != nil {
+		return err
+	}
 	return corecontroller.NewSingletonManagedBy(m).
 		Named("interruption").
 		Complete(c)
 }
 
+// validateQueue optionally tags the queue with cluster ownership and then validates that the queue's access policy allows
+// EventBridge to deliver interruption events. Misconfigured policies otherwise silently drop every interruption event.
+func (c *Controller) validateQueue(ctx context.Context) error {
+	if options.FromContext(ctx).InterruptionQueueTagging {
+		if err := c.sqsProvider.TagQueue(ctx, map[string]string{
+			fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName): "owned",
+		}); err != nil {
+			return fmt.Errorf("tagging interruption queue, %w", err)
+		}
+	}
+	if err := c.sqsProvider.ValidateQueuePolicy(ctx); err != nil {
+		// Validation requires sqs:GetQueueAttributes which older controller policies don't grant, so we don't fail startup on it
+		if awserrors.IsAccessDenied(err) {
+			log.FromContext(ctx).Error(err, "unable to validate interruption queue policy, ensure the controller has sqs:GetQueueAttributes permissions")
+			return nil
+		}
+		// The policy check is a diagnostic and can't evaluate every policy element, so we surface the result rather than
+		// blocking startup on a queue that may still be receiving events
+		log.FromContext(ctx).Error(err, "interruption queue policy may not allow EventBridge to deliver interruption events")
+		queuePolicyValid.Set(0)
+		return nil
+	}
+	queuePolicyValid.Set(1)
+	return nil
+}
+
 // parseMessage parses the passed SQS message into an internal Message interface
 func (c *Controller) parseMessage(raw *sqsapi.Message) (messages.Message, error) {
 	// No message to parse in this case
@@ -151,6 +212,11 @@ func (c *Controller) handleMessage(ctx context.Context, nodeClaimInstanceIDMap m
 	if msg.Kind() == messages.NoOpKind {
 		return nil
 	}
+	if msg.Kind() == messages.ScheduledChangeKind && !options.FromContext(ctx).InterruptionScheduledChanges {
+		log.FromContext(ctx).V(1).Info("ignoring scheduled change message since scheduled change handling is disabled")
+		return nil
+	}
+	var deferred error
 	for _, instanceID := range msg.EC2InstanceIDs() {
 		nodeClaim, ok := nodeClaimInstanceIDMap[instanceID]
 		if !ok {
@@ -158,6 +224,10 @@ func (c *Controller) handleMessage(ctx context.Context, nodeClaimInstanceIDMap m
 		}
 		node := nodeInstanceIDMap[instanceID]
 		if e := c.handleNodeClaim(ctx, msg, nodeClaim, node); e != nil {
+			if isDeferred(e) {
+				deferred = e
+				continue
+			}
 			err = multierr.Append(err, e)
 		}
 	}
@@ -165,26 +235,62 @@ func (c *Controller) handleMessage(ctx context.Context, nodeClaimInstanceIDMap m
 	if err != nil {
 		return fmt.Errorf("acting on NodeClaims, %w", err)
 	}
-	return nil
+	return deferred
 }
 
-// deleteMessage removes the passed SQS message from the queue and fires a metric for the deletion
-func (c *Controller) deleteMessage(ctx context.Context, msg *sqsapi.Message) error {
-	if err := c.sqsProvider.DeleteSQSMessage(ctx, msg); err != nil {
-		return fmt.Errorf("deleting sqs message, %w", err)
+// deleteMessages removes the passed SQS messages from the queue in batches and fires a metric for the deletions
+func (c *Controller) deleteMessages(ctx context.Context, msgs []*sqsapi.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	deleted, err := c.sqsProvider.DeleteSQSMessages(ctx, msgs)
+	deletedMessages.Add(float64(len(deleted)))
+	if err != nil {
+		return fmt.Errorf("deleting sqs messages, %w", err)
 	}
-	deletedMessages.Inc()
 	return nil
 }
 
 // handleNodeClaim retrieves the action for the message and then performs the appropriate action against the node
 func (c *Controller) handleNodeClaim(ctx context.Context, msg messages.Message, nodeClaim *v1beta1.NodeClaim, node *v1.Node) error {
-	action := actionForMessage(msg)
+	// State change notifications commonly follow a scheduled change or spot interruption that has already started
+	// disrupting the NodeClaim, so we don't notify or act on them a second time
+	if msg.Kind() == messages.StateChangeKind && !nodeClaim.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	action := actionForMessage(ctx, msg)
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("NodeClaim", klog.KRef("", nodeClaim.Name), "action", string(action)))
 	if node != nil {
 		ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("Node", klog.KRef("", node.Name)))
 	}
 
+	// Mark the offering as unavailable in the ICE cache since we got a spot interruption warning
+	if msg.Kind() == messages.SpotInterruptionKind {
+		zone := nodeClaim.Labels[v1.LabelTopologyZone]
+		instanceType := nodeClaim.Labels[v1.LabelInstanceTypeStable]
+		if zone != "" && instanceType != "" {
+			unavailableOfferings, err := c.resolveUnavailableOfferings(ctx, nodeClaim)
+			if err != nil {
+				return err
+			}
+			unavailableOfferings.MarkUnavailable(ctx, string(msg.Kind()), instanceType, zone, v1beta1.CapacityTypeSpot)
+		}
+	}
+	// Defer the disruption until in-flight interruption disruptions complete if we're at the limit. Spot interruptions and
+	// state changes can't wait for re-delivery without missing their deadline, so they're never deferred. Replacements
+	// are disrupted through drift, which is limited by the NodePool's disruption budgets instead.
+	if action == CordonAndDrain && nodeClaim.DeletionTimestamp.IsZero() {
+		if urgent(msg) {
+			c.limiter.Acquire(nodeClaim.Name)
+		} else if !c.limiter.TryAcquire(nodeClaim.Name, options.FromContext(ctx).InterruptionDisruptionLimit) {
+			log.FromContext(ctx).V(1).Info("deferring disruption from interruption message, interruption disruption limit reached")
+			return errDisruptionLimited
+		}
+	}
+	if action == ReplaceAndDrain {
+		return c.replaceNodeClaim(ctx, msg, nodeClaim, node)
+	}
+
 	// Record metric and event for this action
 	c.notifyForMessage(msg, nodeClaim, node)
 	actionsPerformed.With(
@@ -194,15 +300,15 @@ func (c *Controller) handleNodeClaim(ctx context.Context, msg messages.Message,
 		},
 	).Inc()
 
-	// Mark the offering as unavailable in the ICE cache since we got a spot interruption warning
-	if msg.Kind() == messages.SpotInterruptionKind {
-		zone := nodeClaim.Labels[v1.LabelTopologyZone]
-		instanceType := nodeClaim.Labels[v1.LabelInstanceTypeStable]
-		if zone != "" && instanceType != "" {
-			c.unavailableOfferingsCache.MarkUnavailable(ctx, string(msg.Kind()), instanceType, zone, v1beta1.CapacityTypeSpot)
-		}
-	}
 	if action != NoAction {
+		// Spot instances are reclaimed two minutes after the warning, so we optionally start evicting pods right away
+		// instead of waiting for the NodeClaim's termination to drain the node. Failing to evict doesn't stop the
+		// NodeClaim from being deleted, since its termination drains the node regardless.
+		if budget := options.FromContext(ctx).InterruptionEvictionGrace; msg.Kind() == messages.SpotInterruptionKind && budget > 0 && node != nil && nodeClaim.DeletionTimestamp.IsZero() {
+			if err := c.cordonAndEvict(ctx, node, budget); err != nil {
+				log.FromContext(ctx).Error(err, "failed evicting pods on interruption message")
+			}
+		}
 		return c.deleteNodeClaim(ctx, nodeClaim, node)
 	}
 	return nil
@@ -292,10 +398,27 @@ func (c *Controller) makeNodeInstanceIDMap(ctx context.Context) (map[string]*v1.
 	return m, nil
 }
 
-func actionForMessage(msg messages.Message) Action {
+// isDeferred returns whether handling the message was deferred rather than failed. Deferred messages are left on the
+// queue without being reported as errors.
+func isDeferred(err error) bool {
+	return errors.Is(err, errDisruptionLimited)
+}
+
+// urgent returns whether the message reports an interruption that is imminent or has already started, as opposed to
+// one that is scheduled ahead of time
+func urgent(msg messages.Message) bool {
+	return msg.Kind() == messages.SpotInterruptionKind || msg.Kind() == messages.StateChangeKind
+}
+
+func actionForMessage(ctx context.Context, msg messages.Message) Action {
 	switch msg.Kind() {
 	case messages.ScheduledChangeKind, messages.SpotInterruptionKind, messages.StateChangeKind:
 		return CordonAndDrain
+	case messages.RebalanceRecommendationKind:
+		if options.FromContext(ctx).InterruptionRebalanceReplace {
+			return ReplaceAndDrain
+		}
+		return NoAction
 	default:
 		return NoAction
 	}
@@ -0,0 +1,86 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interruption
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	nodeutil "sigs.k8s.io/karpenter/pkg/utils/node"
+	podutil "sigs.k8s.io/karpenter/pkg/utils/pod"
+)
+
+// cordonAndEvict taints the node so that no new pods schedule to it and then immediately evicts its pods, capping their
+// termination grace period at the passed budget. Spot instances are reclaimed two minutes after the interruption warning,
+// so waiting on the standard termination flow or on long grace periods risks pods being killed without a clean shutdown.
+// Evictions that are blocked by PDBs are left to the standard termination flow, which retries them.
+func (c *Controller) cordonAndEvict(ctx context.Context, node *v1.Node, budget time.Duration) error {
+	stored := node.DeepCopy()
+	if _, ok := lo.Find(node.Spec.Taints, func(t v1.Taint) bool { return v1beta1.IsDisruptingTaint(t) }); !ok {
+		node.Spec.Taints = lo.Reject(node.Spec.Taints, func(t v1.Taint, _ int) bool { return t.Key == v1beta1.DisruptionTaintKey })
+		node.Spec.Taints = append(node.Spec.Taints, v1beta1.DisruptionNoScheduleTaint)
+	}
+	if !equality.Semantic.DeepEqual(node, stored) {
+		if err := c.kubeClient.Patch(ctx, node, client.StrategicMergeFrom(stored)); err != nil {
+			return client.IgnoreNotFound(fmt.Errorf("cordoning node, %w", err))
+		}
+		log.FromContext(ctx).Info("cordoned node from interruption message")
+	}
+	pods, err := nodeutil.GetPods(ctx, c.kubeClient, node)
+	if err != nil {
+		return fmt.Errorf("listing pods on node, %w", err)
+	}
+	var errs error
+	for _, pod := range lo.Filter(pods, func(p *v1.Pod, _ int) bool { return podutil.IsEvictable(p) && !podutil.IsOwnedByDaemonSet(p) }) {
+		errs = multierr.Append(errs, c.evict(ctx, pod, budget))
+	}
+	return errs
+}
+
+// evict calls the eviction API against the pod with its termination grace period capped at the passed budget
+func (c *Controller) evict(ctx context.Context, pod *v1.Pod, budget time.Duration) error {
+	gracePeriod := int64(budget.Seconds())
+	if pod.Spec.TerminationGracePeriodSeconds != nil && *pod.Spec.TerminationGracePeriodSeconds < gracePeriod {
+		gracePeriod = *pod.Spec.TerminationGracePeriodSeconds
+	}
+	if err := c.kubeClient.SubResource("eviction").Create(ctx, pod, &policyv1.Eviction{
+		DeleteOptions: &metav1.DeleteOptions{
+			GracePeriodSeconds: lo.ToPtr(gracePeriod),
+			Preconditions:      &metav1.Preconditions{UID: lo.ToPtr(pod.UID)},
+		},
+	}); err != nil {
+		// 404 and 409 mean that the pod is already gone, and 429 means that the eviction would violate a PDB, which
+		// the standard termination flow retries
+		if apierrors.IsNotFound(err) || apierrors.IsConflict(err) || apierrors.IsTooManyRequests(err) {
+			return nil
+		}
+		return fmt.Errorf("evicting pod %s, %w", klog.KObj(pod), err)
+	}
+	log.FromContext(ctx).V(1).Info("evicted pod from interruption message", "pod", klog.KObj(pod), "gracePeriodSeconds", gracePeriod)
+	return nil
+}
@@ -0,0 +1,81 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interruption
+
+import (
+	"errors"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// errDisruptionLimited is returned when a NodeClaim can't be disrupted because the interruption disruption limit
+// has been reached. Messages that are limited are left on the queue and re-delivered once their visibility timeout expires.
+var errDisruptionLimited = errors.New("interruption disruption limit reached")
+
+// disruptionLimiter caps the number of NodeClaims that are disrupted by interruption messages at once so that a burst of
+// interruptions doesn't drain more nodes than workloads can gracefully shut down
+type disruptionLimiter struct {
+	mu       sync.Mutex
+	inflight sets.Set[string]
+	queued   sets.Set[string]
+}
+
+func newDisruptionLimiter() *disruptionLimiter {
+	return &disruptionLimiter{
+		inflight: sets.New[string](),
+		queued:   sets.New[string](),
+	}
+}
+
+// TryAcquire reserves a disruption for the NodeClaim, returning false and queueing the NodeClaim if the limit has been
+// reached. A limit of 0 disables the limiter.
+func (l *disruptionLimiter) TryAcquire(nodeClaimName string, limit int) bool {
+	if limit <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	defer func() { queuedDisruptions.Set(float64(l.queued.Len())) }()
+
+	if !l.inflight.Has(nodeClaimName) && l.inflight.Len() >= limit {
+		l.queued.Insert(nodeClaimName)
+		return false
+	}
+	l.inflight.Insert(nodeClaimName)
+	l.queued.Delete(nodeClaimName)
+	return true
+}
+
+// Acquire reserves a disruption for the NodeClaim regardless of the limit. It's used for interruptions with hard
+// deadlines, which still count toward the limit for other interruptions.
+func (l *disruptionLimiter) Acquire(nodeClaimName string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inflight.Insert(nodeClaimName)
+	l.queued.Delete(nodeClaimName)
+	queuedDisruptions.Set(float64(l.queued.Len()))
+}
+
+// Prune releases the disruptions and queued entries of NodeClaims that no longer exist
+func (l *disruptionLimiter) Prune(nodeClaimNames sets.Set[string]) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inflight = l.inflight.Intersection(nodeClaimNames)
+	l.queued = l.queued.Intersection(nodeClaimNames)
+	queuedDisruptions.Set(float64(l.queued.Len()))
+}
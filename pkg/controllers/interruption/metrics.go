@@ -55,6 +55,22 @@ var (
 			Buckets:   metrics.DurationBuckets(),
 		},
 	)
+	queuePolicyValid = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: interruptionSubsystem,
+			Name:      "queue_policy_valid",
+			Help:      "Whether the SQS queue's access policy allows EventBridge to deliver interruption events. 1 if valid, 0 otherwise.",
+		},
+	)
+	queuedDisruptions = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: interruptionSubsystem,
+			Name:      "queued_disruptions",
+			Help:      "Number of NodeClaims whose disruption from an interruption message is deferred because the interruption disruption limit has been reached.",
+		},
+	)
 	actionsPerformed = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: metrics.Namespace,
@@ -70,5 +86,5 @@ var (
 )
 
 func init() {
-	crmetrics.Registry.MustRegister(receivedMessages, deletedMessages, messageLatency, actionsPerformed)
+	crmetrics.Registry.MustRegister(receivedMessages, deletedMessages, messageLatency, queuePolicyValid, queuedDisruptions, actionsPerformed)
 }
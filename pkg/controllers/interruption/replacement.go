@@ -0,0 +1,83 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interruption
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+)
+
+// replaceNodeClaim marks a NodeClaim that received a rebalance recommendation so that it's replaced through drift. The
+// core disruption controller launches the replacement through the provisioner, within the NodePool's limits and
+// disruption budgets, and only drains the NodeClaim once the replacement is initialized. The pods of the NodeClaim are
+// nominated onto the replacement in the meantime, which keeps consolidation from removing it while it's still empty.
+// The mark is kept on the NodeClaim, so the replacement continues after the message is deleted from the queue.
+func (c *Controller) replaceNodeClaim(ctx context.Context, msg messages.Message, nodeClaim *corev1beta1.NodeClaim, node *v1.Node) error {
+	if !nodeClaim.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	if _, ok := nodeClaim.Annotations[v1beta1.AnnotationRebalanceRecommended]; ok {
+		return nil
+	}
+	// The spot capacity pool of the instance is at an elevated risk of interruption, so the replacement avoids it
+	zone := nodeClaim.Labels[v1.LabelTopologyZone]
+	instanceType := nodeClaim.Labels[v1.LabelInstanceTypeStable]
+	if zone != "" && instanceType != "" && nodeClaim.Labels[corev1beta1.CapacityTypeLabelKey] == corev1beta1.CapacityTypeSpot {
+		unavailableOfferings, err := c.resolveUnavailableOfferings(ctx, nodeClaim)
+		if err != nil {
+			return err
+		}
+		unavailableOfferings.MarkUnavailable(ctx, string(msg.Kind()), instanceType, zone, corev1beta1.CapacityTypeSpot)
+	}
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1beta1.AnnotationRebalanceRecommended: msg.StartTime().UTC().Format(time.RFC3339)})
+	if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("annotating nodeclaim with rebalance recommendation, %w", err))
+	}
+	log.FromContext(ctx).Info("marked nodeclaim for replacement from rebalance recommendation")
+	c.notifyForMessage(msg, nodeClaim, node)
+	actionsPerformed.With(prometheus.Labels{
+		actionTypeLabel:       string(ReplaceAndDrain),
+		metrics.NodePoolLabel: nodeClaim.Labels[corev1beta1.NodePoolLabelKey],
+	}).Inc()
+	return nil
+}
+
+// resolveUnavailableOfferings returns the unavailable offerings cache of the account that the NodeClaim's instance was
+// launched in, since zone names map to different zones in each account. NodeClaims whose EC2NodeClass can't be found
+// fall back to the account that Karpenter runs in.
+func (c *Controller) resolveUnavailableOfferings(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (*cache.UnavailableOfferings, error) {
+	nodeClass := &v1beta1.EC2NodeClass{}
+	if nodeClaim.Spec.NodeClassRef != nil {
+		if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Spec.NodeClassRef.Name}, nodeClass); client.IgnoreNotFound(err) != nil {
+			return nil, fmt.Errorf("resolving ec2nodeclass, %w", err)
+		}
+	}
+	return c.accountProvider.Get(ctx, nodeClass).UnavailableOfferings, nil
+}
@@ -18,11 +18,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	servicesqs "github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
@@ -40,14 +42,18 @@ import (
 	coretest "sigs.k8s.io/karpenter/pkg/test"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/rebalancerecommendation"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/scheduledchange"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/spotinterruption"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/statechange"
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/sqs"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -66,6 +72,7 @@ var ctx context.Context
 var env *coretest.Environment
 var sqsapi *fake.SQSAPI
 var sqsProvider *sqs.DefaultProvider
+var awsEnv *test.Environment
 var unavailableOfferingsCache *awscache.UnavailableOfferings
 var fakeClock *clock.FakeClock
 var controller *interruption.Controller
@@ -79,10 +86,11 @@ func TestAPIs(t *testing.T) {
 var _ = BeforeSuite(func() {
 	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
 	fakeClock = &clock.FakeClock{}
-	unavailableOfferingsCache = awscache.NewUnavailableOfferings()
+	awsEnv = test.NewEnvironment(ctx, env)
+	unavailableOfferingsCache = awsEnv.UnavailableOfferingsCache
 	sqsapi = &fake.SQSAPI{}
 	sqsProvider = lo.Must(sqs.NewDefaultProvider(sqsapi, fmt.Sprintf("https://sqs.%s.amazonaws.com/%s/test-cluster", fake.DefaultRegion, fake.DefaultAccount)))
-	controller = interruption.NewController(env.Client, fakeClock, events.NewRecorder(&record.FakeRecorder{}), sqsProvider, unavailableOfferingsCache)
+	controller = interruption.NewController(env.Client, fakeClock, events.NewRecorder(&record.FakeRecorder{}), sqsProvider, awsEnv.AccountProvider)
 })
 
 var _ = AfterSuite(func() {
@@ -91,6 +99,7 @@ var _ = AfterSuite(func() {
 
 var _ = BeforeEach(func() {
 	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
 	unavailableOfferingsCache.Flush()
 	sqsapi.Reset()
 })
@@ -114,6 +123,30 @@ var _ = Describe("InterruptionHandling", func() {
 			},
 		})
 	})
+	Context("Polling", func() {
+		It("should back off before polling an empty queue again when short polling", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionWaitTime: lo.ToPtr(time.Duration(0))}))
+			result := ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(sqsapi.ReceiveMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			Expect(result.RequeueAfter).To(Equal(time.Second))
+		})
+		It("should back off when the wait time rounds down to 0 seconds", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionWaitTime: lo.ToPtr(500 * time.Millisecond)}))
+			result := ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(result.RequeueAfter).To(Equal(time.Second))
+		})
+		It("should poll again immediately when long polling an empty queue", func() {
+			result := ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(result.RequeueAfter).To(BeZero())
+		})
+		It("should poll again immediately when short polling returned messages", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionWaitTime: lo.ToPtr(time.Duration(0))}))
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+			result := ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(result.RequeueAfter).To(BeZero())
+		})
+	})
 	Context("Processing Messages", func() {
 		It("should delete the NodeClaim when receiving a spot interruption warning", func() {
 			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
@@ -122,7 +155,7 @@ var _ = Describe("InterruptionHandling", func() {
 			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
 			Expect(sqsapi.ReceiveMessageBehavior.SuccessfulCalls()).To(Equal(1))
 			ExpectNotFound(ctx, env.Client, nodeClaim)
-			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			Expect(deletedMessageCount()).To(Equal(1))
 		})
 		It("should delete the NodeClaim when receiving a scheduled change message", func() {
 			ExpectMessagesCreated(scheduledChangeMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
@@ -131,7 +164,29 @@ var _ = Describe("InterruptionHandling", func() {
 			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
 			Expect(sqsapi.ReceiveMessageBehavior.SuccessfulCalls()).To(Equal(1))
 			ExpectNotFound(ctx, env.Client, nodeClaim)
-			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			Expect(deletedMessageCount()).To(Equal(1))
+		})
+		It("should not delete the NodeClaim when receiving a scheduled change message and scheduled change handling is disabled", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionScheduledChanges: lo.ToPtr(false)}))
+			ExpectMessagesCreated(scheduledChangeMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(sqsapi.ReceiveMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(deletedMessageCount()).To(Equal(1))
+		})
+		It("should not act on a state change message for a NodeClaim that is already being disrupted", func() {
+			nodeClaim.Finalizers = append(nodeClaim.Finalizers, corev1beta1.TerminationFinalizer)
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+			Expect(env.Client.Delete(ctx, nodeClaim)).To(Succeed())
+			before := actionsPerformed(nodeClaim.Labels[corev1beta1.NodePoolLabelKey])
+
+			ExpectMessagesCreated(stateChangeMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)), "stopping"))
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(deletedMessageCount()).To(Equal(1))
+			Expect(actionsPerformed(nodeClaim.Labels[corev1beta1.NodePoolLabelKey])).To(Equal(before))
+			ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
 		})
 		It("should delete the NodeClaim when receiving a state change message", func() {
 			var nodeClaims []*corev1beta1.NodeClaim
@@ -156,7 +211,7 @@ var _ = Describe("InterruptionHandling", func() {
 			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
 			Expect(sqsapi.ReceiveMessageBehavior.SuccessfulCalls()).To(Equal(1))
 			ExpectNotFound(ctx, env.Client, lo.Map(nodeClaims, func(nc *corev1beta1.NodeClaim, _ int) client.Object { return nc })...)
-			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(4))
+			Expect(deletedMessageCount()).To(Equal(4))
 		})
 		It("should handle multiple messages that cause nodeClaim deletion", func() {
 			var nodeClaims []*corev1beta1.NodeClaim
@@ -186,7 +241,7 @@ var _ = Describe("InterruptionHandling", func() {
 			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
 			Expect(sqsapi.ReceiveMessageBehavior.SuccessfulCalls()).To(Equal(1))
 			ExpectNotFound(ctx, env.Client, lo.Map(nodeClaims, func(nc *corev1beta1.NodeClaim, _ int) client.Object { return nc })...)
-			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(100))
+			Expect(deletedMessageCount()).To(Equal(100))
 		})
 		It("should delete a message when the message can't be parsed", func() {
 			badMessage := &servicesqs.Message{
@@ -201,7 +256,7 @@ var _ = Describe("InterruptionHandling", func() {
 
 			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
 			Expect(sqsapi.ReceiveMessageBehavior.SuccessfulCalls()).To(Equal(1))
-			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			Expect(deletedMessageCount()).To(Equal(1))
 		})
 		It("should delete a state change message when the state isn't in accepted states", func() {
 			ExpectMessagesCreated(stateChangeMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)), "creating"))
@@ -210,7 +265,7 @@ var _ = Describe("InterruptionHandling", func() {
 			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
 			Expect(sqsapi.ReceiveMessageBehavior.SuccessfulCalls()).To(Equal(1))
 			ExpectExists(ctx, env.Client, nodeClaim)
-			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			Expect(deletedMessageCount()).To(Equal(1))
 		})
 		It("should mark the ICE cache for the offering when getting a spot interruption warning", func() {
 			nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{
@@ -224,12 +279,459 @@ var _ = Describe("InterruptionHandling", func() {
 			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
 			Expect(sqsapi.ReceiveMessageBehavior.SuccessfulCalls()).To(Equal(1))
 			ExpectNotFound(ctx, env.Client, nodeClaim)
-			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			Expect(deletedMessageCount()).To(Equal(1))
 
 			// Expect a t3.large in coretest-zone-1a to be added to the ICE cache
 			Expect(unavailableOfferingsCache.IsUnavailable("t3.large", "coretest-zone-1a", corev1beta1.CapacityTypeSpot)).To(BeTrue())
 		})
 	})
+	Context("Disruption Limit", func() {
+		var nodeClaims []*corev1beta1.NodeClaim
+		var instanceIDs []string
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionDisruptionLimit: lo.ToPtr(3)}))
+			nodeClaims, instanceIDs = nil, nil
+			for i := 0; i < 10; i++ {
+				instanceID := fake.InstanceID()
+				nc, n := coretest.NodeClaimAndNode(corev1beta1.NodeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							corev1beta1.NodePoolLabelKey: "default",
+						},
+						Finalizers: []string{corev1beta1.TerminationFinalizer},
+					},
+					Status: corev1beta1.NodeClaimStatus{
+						ProviderID: fake.ProviderID(instanceID),
+					},
+				})
+				ExpectApplied(ctx, env.Client, nc, n)
+				instanceIDs = append(instanceIDs, instanceID)
+				nodeClaims = append(nodeClaims, nc)
+			}
+		})
+		AfterEach(func() {
+			for _, nc := range nodeClaims {
+				ExpectFinalizersRemoved(ctx, env.Client, nc)
+			}
+		})
+		It("should only disrupt up to the limit when receiving a burst of scheduled changes", func() {
+			ExpectMessagesCreated(lo.Map(instanceIDs, func(id string, _ int) interface{} { return scheduledChangeMessage(id) })...)
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+			Expect(disruptingCount(nodeClaims)).To(Equal(3))
+			Expect(deletedMessageCount()).To(Equal(3))
+			Expect(queuedDisruptions()).To(BeNumerically("==", 7))
+		})
+		It("should disrupt queued NodeClaims once in-flight disruptions complete", func() {
+			ExpectMessagesCreated(lo.Map(instanceIDs, func(id string, _ int) interface{} { return scheduledChangeMessage(id) })...)
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(disruptingCount(nodeClaims)).To(Equal(3))
+
+			// Re-delivery of the deferred messages while disruptions are still in-flight doesn't disrupt more NodeClaims
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(disruptingCount(nodeClaims)).To(Equal(3))
+
+			// Complete the in-flight disruptions
+			for _, nc := range nodeClaims {
+				if !ExpectExists(ctx, env.Client, nc).DeletionTimestamp.IsZero() {
+					ExpectFinalizersRemoved(ctx, env.Client, nc)
+					ExpectNotFound(ctx, env.Client, nc)
+				}
+			}
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(disruptingCount(nodeClaims)).To(Equal(3))
+			Expect(queuedDisruptions()).To(BeNumerically("==", 4))
+		})
+		It("should disrupt NodeClaims for spot interruptions before scheduled changes", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				InterruptionDisruptionLimit: lo.ToPtr(3),
+				InterruptionWorkers:         lo.ToPtr(1),
+			}))
+			var msgs []interface{}
+			for i, id := range instanceIDs {
+				if i < 5 {
+					msgs = append(msgs, scheduledChangeMessage(id))
+				} else {
+					msgs = append(msgs, spotInterruptionMessage(id))
+				}
+			}
+			ExpectMessagesCreated(msgs...)
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+			Expect(disruptingCount(nodeClaims[:5])).To(Equal(0))
+			Expect(disruptingCount(nodeClaims[5:])).To(Equal(5))
+		})
+		It("should disrupt NodeClaims for spot interruptions when the limit has been reached", func() {
+			ExpectMessagesCreated(lo.Map(instanceIDs[:5], func(id string, _ int) interface{} { return scheduledChangeMessage(id) })...)
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(disruptingCount(nodeClaims[:5])).To(Equal(3))
+
+			sqsapi.Reset()
+			ExpectMessagesCreated(spotInterruptionMessage(instanceIDs[5]))
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(ExpectExists(ctx, env.Client, nodeClaims[5]).DeletionTimestamp.IsZero()).To(BeFalse())
+			Expect(deletedMessageCount()).To(Equal(1))
+		})
+		It("should disrupt NodeClaims for state changes when the limit has been reached", func() {
+			ExpectMessagesCreated(lo.Map(instanceIDs[:5], func(id string, _ int) interface{} { return scheduledChangeMessage(id) })...)
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(disruptingCount(nodeClaims[:5])).To(Equal(3))
+
+			sqsapi.Reset()
+			ExpectMessagesCreated(stateChangeMessage(instanceIDs[5], "stopping"))
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(ExpectExists(ctx, env.Client, nodeClaims[5]).DeletionTimestamp.IsZero()).To(BeFalse())
+		})
+		It("should count disruptions for spot interruptions toward the limit", func() {
+			ExpectMessagesCreated(lo.Map(instanceIDs[:5], func(id string, _ int) interface{} { return spotInterruptionMessage(id) })...)
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(disruptingCount(nodeClaims[:5])).To(Equal(5))
+
+			sqsapi.Reset()
+			ExpectMessagesCreated(scheduledChangeMessage(instanceIDs[5]))
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(ExpectExists(ctx, env.Client, nodeClaims[5]).DeletionTimestamp.IsZero()).To(BeTrue())
+			Expect(queuedDisruptions()).To(BeNumerically("==", 1))
+		})
+		It("should mark the ICE cache for spot interruptions received while at the limit", func() {
+			for _, nc := range nodeClaims {
+				nc.Labels = lo.Assign(nc.Labels, map[string]string{
+					v1.LabelTopologyZone:             "coretest-zone-1a",
+					v1.LabelInstanceTypeStable:       "t3.large",
+					corev1beta1.CapacityTypeLabelKey: corev1beta1.CapacityTypeSpot,
+				})
+				ExpectApplied(ctx, env.Client, nc)
+			}
+			ExpectMessagesCreated(lo.Map(instanceIDs, func(id string, _ int) interface{} { return spotInterruptionMessage(id) })...)
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(disruptingCount(nodeClaims)).To(Equal(10))
+			Expect(unavailableOfferingsCache.IsUnavailable("t3.large", "coretest-zone-1a", corev1beta1.CapacityTypeSpot)).To(BeTrue())
+		})
+		It("should disrupt every NodeClaim when the limit is disabled", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionDisruptionLimit: lo.ToPtr(0)}))
+			ExpectMessagesCreated(lo.Map(instanceIDs, func(id string, _ int) interface{} { return scheduledChangeMessage(id) })...)
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+			Expect(disruptingCount(nodeClaims)).To(Equal(10))
+			Expect(deletedMessageCount()).To(Equal(10))
+		})
+	})
+	Context("Immediate Eviction", func() {
+		var pod *v1.Pod
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionEvictionGrace: lo.ToPtr(30 * time.Second)}))
+			pod = coretest.Pod(coretest.PodOptions{
+				NodeName:                      node.Name,
+				TerminationGracePeriodSeconds: lo.ToPtr[int64](300),
+			})
+		})
+		It("should cordon the node and evict pods immediately when receiving a spot interruption warning", func() {
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node, pod)
+
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Spec.Taints).To(ContainElement(corev1beta1.DisruptionNoScheduleTaint))
+			pod = ExpectExists(ctx, env.Client, pod)
+			Expect(pod.DeletionTimestamp.IsZero()).To(BeFalse())
+			Expect(pod.DeletionGracePeriodSeconds).To(Equal(lo.ToPtr[int64](30)))
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(deletedMessageCount()).To(Equal(1))
+		})
+		It("should delete the NodeClaim when evicting pods fails", func() {
+			failingController := interruption.NewController(evictionFailingClient{Client: env.Client}, fakeClock, events.NewRecorder(&record.FakeRecorder{}), sqsProvider, awsEnv.AccountProvider)
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node, pod)
+
+			ExpectReconcileSucceeded(ctx, failingController, types.NamespacedName{})
+			pod = ExpectExists(ctx, env.Client, pod)
+			Expect(pod.DeletionTimestamp.IsZero()).To(BeTrue())
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(deletedMessageCount()).To(Equal(1))
+		})
+		It("should keep termination grace periods that are shorter than the budget", func() {
+			pod.Spec.TerminationGracePeriodSeconds = lo.ToPtr[int64](10)
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node, pod)
+
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			pod = ExpectExists(ctx, env.Client, pod)
+			Expect(pod.DeletionTimestamp.IsZero()).To(BeFalse())
+			Expect(pod.DeletionGracePeriodSeconds).To(Equal(lo.ToPtr[int64](10)))
+		})
+		It("should not evict pods that tolerate the disruption taint", func() {
+			pod.Spec.Tolerations = []v1.Toleration{{Key: corev1beta1.DisruptionTaintKey, Operator: v1.TolerationOpExists}}
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node, pod)
+
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			pod = ExpectExists(ctx, env.Client, pod)
+			Expect(pod.DeletionTimestamp.IsZero()).To(BeTrue())
+		})
+		It("should not evict pods when receiving a scheduled change message", func() {
+			ExpectMessagesCreated(scheduledChangeMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node, pod)
+
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Spec.Taints).ToNot(ContainElement(corev1beta1.DisruptionNoScheduleTaint))
+			pod = ExpectExists(ctx, env.Client, pod)
+			Expect(pod.DeletionTimestamp.IsZero()).To(BeTrue())
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+		})
+		It("should not evict pods when immediate eviction is disabled", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionEvictionGrace: lo.ToPtr(time.Duration(0))}))
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node, pod)
+
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Spec.Taints).ToNot(ContainElement(corev1beta1.DisruptionNoScheduleTaint))
+			pod = ExpectExists(ctx, env.Client, pod)
+			Expect(pod.DeletionTimestamp.IsZero()).To(BeTrue())
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+		})
+	})
+	Context("Rebalance Replacement", func() {
+		var nodePool *corev1beta1.NodePool
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionRebalanceReplace: lo.ToPtr(true)}))
+			nodePool = coretest.NodePool(corev1beta1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+			nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{
+				v1.LabelTopologyZone:             "test-zone-1a",
+				v1.LabelInstanceTypeStable:       "m5.large",
+				corev1beta1.CapacityTypeLabelKey: corev1beta1.CapacityTypeSpot,
+			})
+		})
+		It("should mark the NodeClaim for replacement without draining the node when receiving a rebalance recommendation", func() {
+			ExpectMessagesCreated(rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+			Expect(nodeClaim.Annotations).To(HaveKey(v1beta1.AnnotationRebalanceRecommended))
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Spec.Taints).ToNot(ContainElement(corev1beta1.DisruptionNoScheduleTaint))
+			// The replacement is launched through drift, so no NodeClaim is created directly
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			// The mark is kept on the NodeClaim, so the message doesn't need to be re-delivered
+			Expect(deletedMessageCount()).To(Equal(1))
+		})
+		It("should not count replacements against the interruption disruption limit", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionRebalanceReplace: lo.ToPtr(true), InterruptionDisruptionLimit: lo.ToPtr(1)}))
+			otherNodeClaim, otherNode := coretest.NodeClaimAndNode(corev1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{corev1beta1.NodePoolLabelKey: nodePool.Name},
+				},
+				Status: corev1beta1.NodeClaimStatus{
+					ProviderID: fake.RandomProviderID(),
+				},
+			})
+			ExpectMessagesCreated(
+				rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))),
+				spotInterruptionMessage(lo.Must(utils.ParseInstanceID(otherNodeClaim.Status.ProviderID))),
+			)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, otherNodeClaim, otherNode)
+
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).To(HaveKey(v1beta1.AnnotationRebalanceRecommended))
+			ExpectNotFound(ctx, env.Client, otherNodeClaim)
+			Expect(deletedMessageCount()).To(Equal(2))
+		})
+		It("should mark the ICE cache for the spot offering when launching a replacement", func() {
+			ExpectMessagesCreated(rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(unavailableOfferingsCache.IsUnavailable("m5.large", "test-zone-1a", corev1beta1.CapacityTypeSpot)).To(BeTrue())
+		})
+		It("should not launch a replacement when rebalance replacement is disabled", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionRebalanceReplace: lo.ToPtr(false)}))
+			ExpectMessagesCreated(rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+			Expect(nodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationRebalanceRecommended))
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			Expect(unavailableOfferingsCache.IsUnavailable("m5.large", "test-zone-1a", corev1beta1.CapacityTypeSpot)).To(BeFalse())
+			Expect(deletedMessageCount()).To(Equal(1))
+		})
+	})
+})
+
+var _ = Describe("Batching", func() {
+	It("should receive messages using the configured batch size and visibility timeout", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			InterruptionBatchSize:         lo.ToPtr(5),
+			InterruptionVisibilityTimeout: lo.ToPtr(time.Minute),
+		}))
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(sqsapi.ReceiveMessageBehavior.CalledWithInput.Len()).To(Equal(1))
+		input := sqsapi.ReceiveMessageBehavior.CalledWithInput.Pop()
+		Expect(aws.Int64Value(input.MaxNumberOfMessages)).To(BeNumerically("==", 5))
+		Expect(aws.Int64Value(input.VisibilityTimeout)).To(BeNumerically("==", 60))
+	})
+	It("should long poll using the configured wait time", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionWaitTime: lo.ToPtr(5 * time.Second)}))
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(sqsapi.ReceiveMessageBehavior.CalledWithInput.Len()).To(Equal(1))
+		input := sqsapi.ReceiveMessageBehavior.CalledWithInput.Pop()
+		Expect(aws.Int64Value(input.WaitTimeSeconds)).To(BeNumerically("==", 5))
+	})
+	It("should long poll for 20 seconds by default", func() {
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		input := sqsapi.ReceiveMessageBehavior.CalledWithInput.Pop()
+		Expect(aws.Int64Value(input.WaitTimeSeconds)).To(BeNumerically("==", 20))
+	})
+	It("should short poll when the wait time is 0", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionWaitTime: lo.ToPtr(time.Duration(0))}))
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		input := sqsapi.ReceiveMessageBehavior.CalledWithInput.Pop()
+		Expect(aws.Int64Value(input.WaitTimeSeconds)).To(BeNumerically("==", 0))
+	})
+	It("should stop polling without an error when the context is cancelled", func() {
+		ExpectMessagesCreated(spotInterruptionMessage(fake.InstanceID()))
+		cancelledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		ExpectReconcileSucceeded(cancelledCtx, controller, types.NamespacedName{})
+		Expect(sqsapi.ReceiveMessageBehavior.Calls()).To(Equal(0))
+		Expect(sqsapi.DeleteMessageBatchBehavior.Calls()).To(Equal(0))
+	})
+	It("should return an error when the receive times out", func() {
+		sqsapi.ReceiveMessageBehavior.Error.Set(awserr.New(request.CanceledErrorCode, "request context canceled", context.DeadlineExceeded))
+		ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+	})
+	It("should delete handled messages in batches of at most 10", func() {
+		var msgs []interface{}
+		for i := 0; i < 25; i++ {
+			msgs = append(msgs, spotInterruptionMessage(fake.InstanceID()))
+		}
+		ExpectMessagesCreated(msgs...)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(sqsapi.DeleteMessageBatchBehavior.Calls()).To(Equal(3))
+		sqsapi.DeleteMessageBatchBehavior.CalledWithInput.ForEach(func(input *servicesqs.DeleteMessageBatchInput) {
+			Expect(len(input.Entries)).To(BeNumerically("<=", 10))
+		})
+		Expect(deletedMessageCount()).To(Equal(25))
+	})
+	It("should delete every received message in a single batch", func() {
+		badMessage := &servicesqs.Message{
+			Body:          aws.String("{}"),
+			MessageId:     aws.String(string(uuid.NewUUID())),
+			ReceiptHandle: aws.String(string(uuid.NewUUID())),
+		}
+		ExpectMessagesCreated(spotInterruptionMessage(fake.InstanceID()), scheduledChangeMessage(fake.InstanceID()))
+		out := sqsapi.ReceiveMessageBehavior.Output.Clone()
+		out.Messages = append(out.Messages, badMessage)
+		sqsapi.ReceiveMessageBehavior.Output.Set(out)
+
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(sqsapi.DeleteMessageBatchBehavior.Calls()).To(Equal(1))
+		input := sqsapi.DeleteMessageBatchBehavior.CalledWithInput.Pop()
+		Expect(lo.Map(input.Entries, func(e *servicesqs.DeleteMessageBatchRequestEntry, _ int) string {
+			return aws.StringValue(e.ReceiptHandle)
+		})).To(
+			ConsistOf(lo.Map(out.Messages, func(m *servicesqs.Message, _ int) string { return aws.StringValue(m.ReceiptHandle) })))
+	})
+	It("should return an error when some messages in a batch fail to be deleted", func() {
+		ExpectMessagesCreated(spotInterruptionMessage(fake.InstanceID()), spotInterruptionMessage(fake.InstanceID()))
+		sqsapi.DeleteMessageBatchBehavior.Output.Set(&servicesqs.DeleteMessageBatchOutput{
+			Successful: []*servicesqs.DeleteMessageBatchResultEntry{{Id: aws.String("0")}},
+			Failed: []*servicesqs.BatchResultErrorEntry{{
+				Id:          aws.String("1"),
+				Code:        aws.String("ReceiptHandleIsInvalid"),
+				Message:     aws.String("receipt handle is invalid"),
+				SenderFault: aws.Bool(true),
+			}},
+		})
+		ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+		Expect(deletedMessageCount()).To(Equal(2))
+	})
+	It("should only delete messages whose batch succeeded when a batch delete fails", func() {
+		var msgs []interface{}
+		for i := 0; i < 15; i++ {
+			msgs = append(msgs, spotInterruptionMessage(fake.InstanceID()))
+		}
+		ExpectMessagesCreated(msgs...)
+		sqsapi.DeleteMessageBatchBehavior.Error.Set(awsErrWithCode("AccessDenied"), fake.MaxCalls(1))
+		ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+		Expect(sqsapi.DeleteMessageBatchBehavior.FailedCalls()).To(Equal(1))
+		Expect(sqsapi.DeleteMessageBatchBehavior.SuccessfulCalls()).To(Equal(1))
+	})
+})
+
+var _ = Describe("Queue Validation", func() {
+	const queueARN = "arn:aws:sqs:us-west-2:000000000000:test-cluster"
+	setQueuePolicy := func(policy string) {
+		sqsapi.GetQueueAttributesBehavior.Output.Set(&servicesqs.GetQueueAttributesOutput{
+			Attributes: aws.StringMap(map[string]string{
+				servicesqs.QueueAttributeNamePolicy:   policy,
+				servicesqs.QueueAttributeNameQueueArn: queueARN,
+			}),
+		})
+	}
+	It("should succeed when the queue policy allows EventBridge to send messages", func() {
+		setQueuePolicy(fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":["events.amazonaws.com","sqs.amazonaws.com"]},"Action":"sqs:SendMessage","Resource":%q}]}`, queueARN))
+		Expect(sqsProvider.ValidateQueuePolicy(ctx)).To(Succeed())
+	})
+	It("should succeed when the queue policy is a single statement granting all sqs actions", func() {
+		setQueuePolicy(`{"Version":"2012-10-17","Statement":{"Effect":"Allow","Principal":{"Service":"events.amazonaws.com"},"Action":["sqs:*"],"Resource":"*"}}`)
+		Expect(sqsProvider.ValidateQueuePolicy(ctx)).To(Succeed())
+	})
+	It("should fail when the queue has no access policy", func() {
+		sqsapi.GetQueueAttributesBehavior.Output.Set(&servicesqs.GetQueueAttributesOutput{
+			Attributes: aws.StringMap(map[string]string{servicesqs.QueueAttributeNameQueueArn: queueARN}),
+		})
+		Expect(sqsProvider.ValidateQueuePolicy(ctx)).ToNot(Succeed())
+	})
+	It("should fail when the queue policy doesn't grant sqs:SendMessage to EventBridge", func() {
+		setQueuePolicy(fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"sqs.amazonaws.com"},"Action":"sqs:SendMessage","Resource":%q}]}`, queueARN))
+		Expect(sqsProvider.ValidateQueuePolicy(ctx)).ToNot(Succeed())
+	})
+	It("should fail when the queue policy grants sqs:SendMessage on a different queue", func() {
+		setQueuePolicy(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"events.amazonaws.com"},"Action":"sqs:SendMessage","Resource":"arn:aws:sqs:us-west-2:000000000000:other-queue"}]}`)
+		Expect(sqsProvider.ValidateQueuePolicy(ctx)).ToNot(Succeed())
+	})
+	It("should fail when the queue policy explicitly denies EventBridge", func() {
+		setQueuePolicy(fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"events.amazonaws.com"},"Action":"sqs:SendMessage","Resource":%[1]q},{"Effect":"Deny","Principal":"*","Action":"sqs:*","Resource":%[1]q}]}`, queueARN))
+		Expect(sqsProvider.ValidateQueuePolicy(ctx)).ToNot(Succeed())
+	})
+	It("should succeed when the queue policy has a conditional deny statement", func() {
+		setQueuePolicy(fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"events.amazonaws.com"},"Action":"sqs:SendMessage","Resource":%[1]q},{"Sid":"DenyHTTP","Effect":"Deny","Principal":"*","Action":"sqs:*","Resource":%[1]q,"Condition":{"Bool":{"aws:SecureTransport":"false"}}}]}`, queueARN))
+		Expect(sqsProvider.ValidateQueuePolicy(ctx)).To(Succeed())
+	})
+	It("should succeed when the queue policy has a conditional allow statement", func() {
+		setQueuePolicy(fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"events.amazonaws.com"},"Action":"sqs:SendMessage","Resource":%q,"Condition":{"ArnEquals":{"aws:SourceArn":"arn:aws:events:us-west-2:000000000000:rule/test-cluster"}}}]}`, queueARN))
+		Expect(sqsProvider.ValidateQueuePolicy(ctx)).To(Succeed())
+	})
+	It("should ignore deny statements that use NotPrincipal", func() {
+		setQueuePolicy(fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"events.amazonaws.com"},"Action":"sqs:SendMessage","Resource":%[1]q},{"Effect":"Deny","NotPrincipal":{"Service":"events.amazonaws.com"},"Action":"sqs:SendMessage","Resource":%[1]q}]}`, queueARN))
+		Expect(sqsProvider.ValidateQueuePolicy(ctx)).To(Succeed())
+	})
+	It("should tag the queue with the passed tags", func() {
+		Expect(sqsProvider.TagQueue(ctx, map[string]string{"kubernetes.io/cluster/test-cluster": "owned"})).To(Succeed())
+		Expect(sqsapi.TagQueueBehavior.CalledWithInput.Len()).To(Equal(1))
+		Expect(aws.StringValueMap(sqsapi.TagQueueBehavior.CalledWithInput.Pop().Tags)).To(Equal(map[string]string{"kubernetes.io/cluster/test-cluster": "owned"}))
+	})
+})
+
+var _ = Describe("Parsing", func() {
+	It("should parse an AWS Health scheduled change event for an EC2 instance", func() {
+		msg, err := interruption.NewEventParser(interruption.DefaultParsers...).Parse(awsHealthScheduledChangeEvent)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msg.Kind()).To(Equal(messages.ScheduledChangeKind))
+		Expect(msg.EC2InstanceIDs()).To(ConsistOf("i-0123456789abcdef0", "i-0123456789abcdef1"))
+		Expect(msg.StartTime()).To(Equal(time.Date(2023, 1, 27, 1, 43, 21, 0, time.UTC)))
+	})
+	It("should ignore AWS Health events that aren't EC2 scheduled changes", func() {
+		raw := strings.ReplaceAll(awsHealthScheduledChangeEvent, `"eventTypeCategory": "scheduledChange"`, `"eventTypeCategory": "issue"`)
+		msg, err := interruption.NewEventParser(interruption.DefaultParsers...).Parse(raw)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msg.Kind()).To(Equal(messages.NoOpKind))
+	})
 })
 
 var _ = Describe("Error Handling", func() {
@@ -250,8 +752,9 @@ var _ = Describe("Error Handling", func() {
 func ExpectMessagesCreated(messages ...interface{}) {
 	raw := lo.Map(messages, func(m interface{}, _ int) *servicesqs.Message {
 		return &servicesqs.Message{
-			Body:      aws.String(string(lo.Must(json.Marshal(m)))),
-			MessageId: aws.String(string(uuid.NewUUID())),
+			Body:          aws.String(string(lo.Must(json.Marshal(m)))),
+			MessageId:     aws.String(string(uuid.NewUUID())),
+			ReceiptHandle: aws.String(string(uuid.NewUUID())),
 		}
 	})
 	sqsapi.ReceiveMessageBehavior.Output.Set(
@@ -261,6 +764,42 @@ func ExpectMessagesCreated(messages ...interface{}) {
 	)
 }
 
+// deletedMessageCount returns the number of messages that were requested for deletion across all batch deletes
+func deletedMessageCount() int {
+	count := 0
+	sqsapi.DeleteMessageBatchBehavior.CalledWithInput.ForEach(func(input *servicesqs.DeleteMessageBatchInput) {
+		count += len(input.Entries)
+	})
+	return count
+}
+
+// disruptingCount returns the number of the passed NodeClaims that have been deleted
+func disruptingCount(nodeClaims []*corev1beta1.NodeClaim) int {
+	GinkgoHelper()
+	return lo.CountBy(nodeClaims, func(nc *corev1beta1.NodeClaim) bool {
+		return !ExpectExists(ctx, env.Client, nc).DeletionTimestamp.IsZero()
+	})
+}
+
+func queuedDisruptions() float64 {
+	GinkgoHelper()
+	metric, ok := FindMetricWithLabelValues("karpenter_interruption_queued_disruptions", map[string]string{})
+	Expect(ok).To(BeTrue())
+	return metric.GetGauge().GetValue()
+}
+
+func actionsPerformed(nodePool string) float64 {
+	GinkgoHelper()
+	metric, ok := FindMetricWithLabelValues("karpenter_interruption_actions_performed", map[string]string{
+		"action_type": string(interruption.CordonAndDrain),
+		"nodepool":    nodePool,
+	})
+	if !ok {
+		return 0
+	}
+	return metric.GetCounter().GetValue()
+}
+
 func awsErrWithCode(code string) awserr.Error {
 	return awserr.New(code, "", fmt.Errorf(""))
 }
@@ -286,6 +825,26 @@ func spotInterruptionMessage(involvedInstanceID string) spotinterruption.Message
 	}
 }
 
+func rebalanceRecommendationMessage(involvedInstanceID string) rebalancerecommendation.Message {
+	return rebalancerecommendation.Message{
+		Metadata: messages.Metadata{
+			Version:    "0",
+			Account:    defaultAccountID,
+			DetailType: "EC2 Instance Rebalance Recommendation",
+			ID:         string(uuid.NewUUID()),
+			Region:     fake.DefaultRegion,
+			Resources: []string{
+				fmt.Sprintf("arn:aws:ec2:%s:instance/%s", fake.DefaultRegion, involvedInstanceID),
+			},
+			Source: ec2Source,
+			Time:   time.Now(),
+		},
+		Detail: rebalancerecommendation.Detail{
+			InstanceID: involvedInstanceID,
+		},
+	}
+}
+
 func stateChangeMessage(involvedInstanceID, state string) statechange.Message {
 	return statechange.Message{
 		Metadata: messages.Metadata{
@@ -332,3 +891,56 @@ func scheduledChangeMessage(involvedInstanceID string) scheduledchange.Message {
 		},
 	}
 }
+
+// awsHealthScheduledChangeEvent is a sample AWS Health event for a planned instance retirement as delivered by EventBridge
+const awsHealthScheduledChangeEvent = `{
+  "version": "0",
+  "id": "7bf73129-1428-4cd3-a780-95db273d1602",
+  "detail-type": "AWS Health Event",
+  "source": "aws.health",
+  "account": "000000000000",
+  "time": "2023-01-27T01:43:21Z",
+  "region": "us-west-2",
+  "resources": [
+    "i-0123456789abcdef0",
+    "i-0123456789abcdef1"
+  ],
+  "detail": {
+    "eventArn": "arn:aws:health:us-west-2::event/EC2/EC2_INSTANCE_RETIREMENT_SCHEDULED/EC2_INSTANCE_RETIREMENT_SCHEDULED_0000000000",
+    "service": "EC2",
+    "eventTypeCode": "AWS_EC2_INSTANCE_RETIREMENT_SCHEDULED",
+    "eventTypeCategory": "scheduledChange",
+    "startTime": "Fri, 10 Feb 2023 02:00:00 GMT",
+    "endTime": "Fri, 10 Feb 2023 04:00:00 GMT",
+    "eventDescription": [
+      {
+        "language": "en_US",
+        "latestDescription": "EC2 has detected degradation of the underlying hardware hosting your Amazon EC2 instance."
+      }
+    ],
+    "affectedEntities": [
+      {"entityValue": "i-0123456789abcdef0"},
+      {"entityValue": "i-0123456789abcdef1"}
+    ]
+  }
+}`
+
+// evictionFailingClient fails every call to the eviction API
+type evictionFailingClient struct {
+	client.Client
+}
+
+func (c evictionFailingClient) SubResource(subResource string) client.SubResourceClient {
+	if subResource == "eviction" {
+		return evictionFailingSubResourceClient{SubResourceClient: c.Client.SubResource(subResource)}
+	}
+	return c.Client.SubResource(subResource)
+}
+
+type evictionFailingSubResourceClient struct {
+	client.SubResourceClient
+}
+
+func (evictionFailingSubResourceClient) Create(_ context.Context, _ client.Object, _ client.Object, _ ...client.SubResourceCreateOption) error {
+	return fmt.Errorf("eviction failed")
+}
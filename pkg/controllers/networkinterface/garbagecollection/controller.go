@@ -0,0 +1,199 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package garbagecollection
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
+)
+
+// GracePeriod is how long a network interface has to be unattached before it's garbage collected. This leaves time for
+// a network interface that was just created by a launch to be attached to its instance.
+const GracePeriod = 10 * time.Minute
+
+// Controller deletes network interfaces that were created from Karpenter's launch templates but were left behind
+// unattached, e.g. when a launch failed after the network interface was created. Network interfaces are collected in
+// every account that EC2NodeClasses launch instances in.
+type Controller struct {
+	accountProvider account.Provider
+	clk             clock.Clock
+
+	// unattachedSince tracks when each network interface was first seen unattached. EC2 doesn't expose when a network
+	// interface was created or detached, so the grace period starts when the controller first sees it.
+	unattachedSince map[string]time.Time
+}
+
+func NewController(accountProvider account.Provider, clk clock.Clock) *Controller {
+	return &Controller{
+		accountProvider: accountProvider,
+		clk:             clk,
+		unattachedSince: map[string]time.Time{},
+	}
+}
+
+// networkInterface is an unattached network interface and the EC2 client of the account that it's in
+type networkInterface struct {
+	*ec2.NetworkInterface
+	ec2api ec2iface.EC2API
+}
+
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	providers, err := c.accountProvider.List(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing account providers, %w", err)
+	}
+	var networkInterfaces []networkInterface
+	var listErrs []error
+	for _, p := range providers {
+		unattached, err := listUnattached(ctx, p.EC2API)
+		if err != nil {
+			listErrs = append(listErrs, err)
+			continue
+		}
+		networkInterfaces = append(networkInterfaces, lo.Map(unattached, func(ni *ec2.NetworkInterface, _ int) networkInterface {
+			return networkInterface{NetworkInterface: ni, ec2api: p.EC2API}
+		})...)
+	}
+	// Forget network interfaces that were attached or deleted since the last reconcile. Network interfaces of an
+	// account that couldn't be listed are forgotten too, which only delays their collection.
+	ids := lo.SliceToMap(networkInterfaces, func(ni networkInterface) (string, struct{}) {
+		return aws.StringValue(ni.NetworkInterfaceId), struct{}{}
+	})
+	for id := range c.unattachedSince {
+		if _, ok := ids[id]; !ok {
+			delete(c.unattachedSince, id)
+		}
+	}
+	var expired []networkInterface
+	for _, ni := range networkInterfaces {
+		since, ok := c.unattachedSince[aws.StringValue(ni.NetworkInterfaceId)]
+		if !ok {
+			c.unattachedSince[aws.StringValue(ni.NetworkInterfaceId)] = c.clk.Now()
+			continue
+		}
+		if c.clk.Since(since) > GracePeriod {
+			expired = append(expired, ni)
+		}
+	}
+	errs := make([]error, len(expired))
+	workqueue.ParallelizeUntil(ctx, 20, len(expired), func(i int) {
+		errs[i] = c.garbageCollect(ctx, expired[i])
+	})
+	for i, ni := range expired {
+		if errs[i] == nil {
+			delete(c.unattachedSince, aws.StringValue(ni.NetworkInterfaceId))
+		}
+	}
+	if err = multierr.Combine(append(listErrs, errs...)...); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: time.Minute * 2}, nil
+}
+
+// listUnattached returns the unattached network interfaces in the account of the EC2 client that were created by this
+// cluster's Karpenter
+func listUnattached(ctx context.Context, ec2api ec2iface.EC2API) ([]*ec2.NetworkInterface, error) {
+	clusterName := options.FromContext(ctx).ClusterName
+	var networkInterfaces []*ec2.NetworkInterface
+	if err := ec2api.DescribeNetworkInterfacesPagesWithContext(ctx, &ec2.DescribeNetworkInterfacesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("status"),
+				Values: aws.StringSlice([]string{ec2.NetworkInterfaceStatusAvailable}),
+			},
+			{
+				Name:   aws.String(fmt.Sprintf("tag:kubernetes.io/cluster/%s", clusterName)),
+				Values: aws.StringSlice([]string{"owned"}),
+			},
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", corev1beta1.ManagedByAnnotationKey)),
+				Values: aws.StringSlice([]string{clusterName}),
+			},
+			{
+				Name:   aws.String("tag-key"),
+				Values: aws.StringSlice([]string{corev1beta1.NodePoolLabelKey}),
+			},
+			{
+				Name:   aws.String("tag-key"),
+				Values: aws.StringSlice([]string{v1beta1.LabelNodeClass}),
+			},
+		},
+	}, func(page *ec2.DescribeNetworkInterfacesOutput, _ bool) bool {
+		networkInterfaces = append(networkInterfaces, page.NetworkInterfaces...)
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("describing network interfaces, %w", err)
+	}
+	// The filters are checked again so that a network interface is never deleted unless its tags prove that it was
+	// created by Karpenter for this cluster
+	return lo.Filter(networkInterfaces, func(ni *ec2.NetworkInterface, _ int) bool {
+		return isUnattachedAndManaged(ni, clusterName)
+	}), nil
+}
+
+func (c *Controller) garbageCollect(ctx context.Context, ni networkInterface) error {
+	tags := lo.SliceToMap(ni.TagSet, func(t *ec2.Tag) (string, string) { return aws.StringValue(t.Key), aws.StringValue(t.Value) })
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("network-interface", aws.StringValue(ni.NetworkInterfaceId), "subnet-id", aws.StringValue(ni.SubnetId)))
+	if _, err := ni.ec2api.DeleteNetworkInterfaceWithContext(ctx, &ec2.DeleteNetworkInterfaceInput{
+		NetworkInterfaceId: ni.NetworkInterfaceId,
+	}); err != nil {
+		return awserrors.IgnoreNotFound(fmt.Errorf("deleting network interface, %w", err))
+	}
+	log.FromContext(ctx).Info("garbage collected network interface")
+	networkInterfacesGarbageCollected.With(prometheus.Labels{
+		metrics.NodePoolLabel: tags[corev1beta1.NodePoolLabelKey],
+	}).Inc()
+	return nil
+}
+
+func isUnattachedAndManaged(ni *ec2.NetworkInterface, clusterName string) bool {
+	if aws.StringValue(ni.Status) != ec2.NetworkInterfaceStatusAvailable || ni.Attachment != nil || aws.BoolValue(ni.RequesterManaged) {
+		return false
+	}
+	tags := lo.SliceToMap(ni.TagSet, func(t *ec2.Tag) (string, string) { return aws.StringValue(t.Key), aws.StringValue(t.Value) })
+	_, hasNodePool := tags[corev1beta1.NodePoolLabelKey]
+	_, hasNodeClass := tags[v1beta1.LabelNodeClass]
+	return tags[fmt.Sprintf("kubernetes.io/cluster/%s", clusterName)] == "owned" &&
+		tags[corev1beta1.ManagedByAnnotationKey] == clusterName &&
+		hasNodePool && hasNodeClass
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controller.NewSingletonManagedBy(m).
+		Named("networkinterface.garbagecollection").
+		Complete(c)
+}
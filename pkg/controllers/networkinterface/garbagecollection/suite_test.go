@@ -0,0 +1,235 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package garbagecollection_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/networkinterface/garbagecollection"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var fakeEC2API *fake.EC2API
+var accountEC2API *fake.EC2API
+var fakeClock *clock.FakeClock
+var controller *garbagecollection.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "NetworkInterfaceGarbageCollection")
+}
+
+var _ = BeforeSuite(func() {
+	ctx = options.ToContext(ctx, test.Options())
+	fakeEC2API = fake.NewEC2API()
+	accountEC2API = fake.NewEC2API()
+})
+
+var _ = BeforeEach(func() {
+	fakeEC2API.Reset()
+	accountEC2API.Reset()
+	fakeClock = clock.NewFakeClock(time.Now())
+	controller = garbagecollection.NewController(accounts{{EC2API: fakeEC2API}, {EC2API: accountEC2API}}, fakeClock)
+})
+
+var _ = Describe("NetworkInterfaceGarbageCollection", func() {
+	var networkInterface *ec2.NetworkInterface
+
+	BeforeEach(func() {
+		networkInterface = managedNetworkInterface("eni-test1")
+		fakeEC2API.NetworkInterfaces.Store(aws.StringValue(networkInterface.NetworkInterfaceId), networkInterface)
+	})
+	It("should not delete an unattached network interface before the grace period", func() {
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+		fakeClock.Step(garbagecollection.GracePeriod - time.Minute)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+
+		_, ok := fakeEC2API.NetworkInterfaces.Load("eni-test1")
+		Expect(ok).To(BeTrue())
+		Expect(fakeEC2API.DeleteNetworkInterfaceBehavior.Calls()).To(Equal(0))
+	})
+	It("should delete an unattached network interface after the grace period", func() {
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+		fakeClock.Step(garbagecollection.GracePeriod + time.Minute)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+
+		_, ok := fakeEC2API.NetworkInterfaces.Load("eni-test1")
+		Expect(ok).To(BeFalse())
+		Expect(fakeEC2API.DeleteNetworkInterfaceBehavior.SuccessfulCalls()).To(Equal(1))
+	})
+	It("should delete many unattached network interfaces after the grace period", func() {
+		for i := 2; i <= 50; i++ {
+			ni := managedNetworkInterface(fmt.Sprintf("eni-test%d", i))
+			fakeEC2API.NetworkInterfaces.Store(aws.StringValue(ni.NetworkInterfaceId), ni)
+		}
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+		fakeClock.Step(garbagecollection.GracePeriod + time.Minute)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+
+		Expect(fakeEC2API.DeleteNetworkInterfaceBehavior.SuccessfulCalls()).To(Equal(50))
+	})
+	It("should count deleted network interfaces by nodepool", func() {
+		before := 0.0
+		if metric, ok := FindMetricWithLabelValues("karpenter_network_interfaces_garbage_collected", map[string]string{"nodepool": "default"}); ok {
+			before = metric.GetCounter().GetValue()
+		}
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+		fakeClock.Step(garbagecollection.GracePeriod + time.Minute)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+
+		metric, ok := FindMetricWithLabelValues("karpenter_network_interfaces_garbage_collected", map[string]string{"nodepool": "default"})
+		Expect(ok).To(BeTrue())
+		Expect(metric.GetCounter().GetValue()).To(BeNumerically("==", before+1))
+	})
+	It("should not delete a network interface that is attached to an instance", func() {
+		networkInterface.Status = aws.String(ec2.NetworkInterfaceStatusInUse)
+		networkInterface.Attachment = &ec2.NetworkInterfaceAttachment{InstanceId: aws.String(fake.InstanceID())}
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+		fakeClock.Step(garbagecollection.GracePeriod + time.Minute)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+
+		_, ok := fakeEC2API.NetworkInterfaces.Load("eni-test1")
+		Expect(ok).To(BeTrue())
+		Expect(fakeEC2API.DeleteNetworkInterfaceBehavior.Calls()).To(Equal(0))
+	})
+	It("should restart the grace period when a network interface is attached in between reconciles", func() {
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+		fakeClock.Step(garbagecollection.GracePeriod - time.Minute)
+		networkInterface.Status = aws.String(ec2.NetworkInterfaceStatusInUse)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+		networkInterface.Status = aws.String(ec2.NetworkInterfaceStatusAvailable)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+		fakeClock.Step(time.Minute * 2)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+
+		_, ok := fakeEC2API.NetworkInterfaces.Load("eni-test1")
+		Expect(ok).To(BeTrue())
+	})
+	DescribeTable("should not delete network interfaces that can't be proven to be created by Karpenter",
+		func(mutate func(*ec2.NetworkInterface)) {
+			mutate(networkInterface)
+			ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+			fakeClock.Step(garbagecollection.GracePeriod + time.Minute)
+			ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+
+			_, ok := fakeEC2API.NetworkInterfaces.Load("eni-test1")
+			Expect(ok).To(BeTrue())
+			Expect(fakeEC2API.DeleteNetworkInterfaceBehavior.Calls()).To(Equal(0))
+		},
+		Entry("without tags", func(ni *ec2.NetworkInterface) { ni.TagSet = nil }),
+		Entry("without the nodepool tag", func(ni *ec2.NetworkInterface) { ni.TagSet = withoutTag(ni.TagSet, corev1beta1.NodePoolLabelKey) }),
+		Entry("without the nodeclass tag", func(ni *ec2.NetworkInterface) { ni.TagSet = withoutTag(ni.TagSet, v1beta1.LabelNodeClass) }),
+		Entry("without the managed-by tag", func(ni *ec2.NetworkInterface) { ni.TagSet = withoutTag(ni.TagSet, corev1beta1.ManagedByAnnotationKey) }),
+		Entry("managed by another cluster", func(ni *ec2.NetworkInterface) {
+			ni.TagSet = append(withoutTag(ni.TagSet, corev1beta1.ManagedByAnnotationKey), &ec2.Tag{Key: aws.String(corev1beta1.ManagedByAnnotationKey), Value: aws.String("other-cluster")})
+		}),
+		Entry("owned by another cluster", func(ni *ec2.NetworkInterface) {
+			ni.TagSet = withoutTag(ni.TagSet, fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName))
+		}),
+	)
+	It("should ignore network interfaces that were already deleted", func() {
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+		fakeClock.Step(garbagecollection.GracePeriod + time.Minute)
+		fakeEC2API.DeleteNetworkInterfaceBehavior.Error.Set(awserr.New("InvalidNetworkInterfaceID.NotFound", "not found", nil))
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+	})
+	It("should retry deleting a network interface when deletion fails", func() {
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+		fakeClock.Step(garbagecollection.GracePeriod + time.Minute)
+		fakeEC2API.DeleteNetworkInterfaceBehavior.Error.Set(awserr.New("InternalError", "failed", nil))
+		ExpectReconcileFailed(ctx, controller, client.ObjectKey{})
+		_, ok := fakeEC2API.NetworkInterfaces.Load("eni-test1")
+		Expect(ok).To(BeTrue())
+
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+		_, ok = fakeEC2API.NetworkInterfaces.Load("eni-test1")
+		Expect(ok).To(BeFalse())
+	})
+	It("should delete unattached network interfaces in the accounts of assumed roles", func() {
+		accountNetworkInterface := managedNetworkInterface("eni-account1")
+		accountEC2API.NetworkInterfaces.Store(aws.StringValue(accountNetworkInterface.NetworkInterfaceId), accountNetworkInterface)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+		fakeClock.Step(garbagecollection.GracePeriod + time.Minute)
+		ExpectReconcileSucceeded(ctx, controller, client.ObjectKey{})
+
+		_, ok := accountEC2API.NetworkInterfaces.Load("eni-account1")
+		Expect(ok).To(BeFalse())
+		Expect(accountEC2API.DeleteNetworkInterfaceBehavior.SuccessfulCalls()).To(Equal(1))
+		_, ok = fakeEC2API.NetworkInterfaces.Load("eni-test1")
+		Expect(ok).To(BeFalse())
+		Expect(fakeEC2API.DeleteNetworkInterfaceBehavior.SuccessfulCalls()).To(Equal(1))
+	})
+	It("should delete network interfaces in the other accounts when an account can't be listed", func() {
+		accountEC2API.NextError.Set(awserr.New("UnauthorizedOperation", "not authorized", nil))
+		ExpectReconcileFailed(ctx, controller, client.ObjectKey{})
+		fakeClock.Step(garbagecollection.GracePeriod + time.Minute)
+		accountEC2API.NextError.Set(awserr.New("UnauthorizedOperation", "not authorized", nil))
+		ExpectReconcileFailed(ctx, controller, client.ObjectKey{})
+
+		_, ok := fakeEC2API.NetworkInterfaces.Load("eni-test1")
+		Expect(ok).To(BeFalse())
+	})
+})
+
+// accounts is an account.Provider that returns a fixed set of account providers
+type accounts []*account.Providers
+
+func (a accounts) Get(context.Context, *v1beta1.EC2NodeClass) *account.Providers { return a[0] }
+func (a accounts) List(context.Context) ([]*account.Providers, error)            { return a, nil }
+
+func managedNetworkInterface(id string) *ec2.NetworkInterface {
+	return &ec2.NetworkInterface{
+		NetworkInterfaceId: aws.String(id),
+		SubnetId:           aws.String("subnet-test1"),
+		Status:             aws.String(ec2.NetworkInterfaceStatusAvailable),
+		TagSet: []*ec2.Tag{
+			{Key: aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)), Value: aws.String("owned")},
+			{Key: aws.String(corev1beta1.ManagedByAnnotationKey), Value: aws.String(options.FromContext(ctx).ClusterName)},
+			{Key: aws.String(corev1beta1.NodePoolLabelKey), Value: aws.String("default")},
+			{Key: aws.String(v1beta1.LabelNodeClass), Value: aws.String("default")},
+		},
+	}
+}
+
+func withoutTag(tags []*ec2.Tag, key string) []*ec2.Tag {
+	var result []*ec2.Tag
+	for _, tag := range tags {
+		if aws.StringValue(tag.Key) != key {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
@@ -0,0 +1,100 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extendedresources
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeclaimutil "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+)
+
+// Controller advertises the static extended resources of an EC2NodeClass on the Nodes that are launched with it. The
+// kubelet has no way to advertise extended resources that aren't managed by a device plugin, so they're added to the
+// capacity of the Node's status once it registers, which the kubelet preserves. Until then, the Node isn't considered
+// initialized since it doesn't have all of the resources that the NodeClaim was launched with.
+type Controller struct {
+	kubeClient client.Client
+}
+
+func NewController(kubeClient client.Client) *Controller {
+	return &Controller{
+		kubeClient: kubeClient,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.extendedresources")
+
+	if nodeClaim.Status.NodeName == "" || nodeClaim.Spec.NodeClassRef == nil || !nodeClaim.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	nodeClass := &v1beta1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	if len(nodeClass.Spec.ExtendedResources) == 0 {
+		return reconcile.Result{}, nil
+	}
+	node := &v1.Node{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Status.NodeName}, node); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	stored := node.DeepCopy()
+	if node.Status.Capacity == nil {
+		node.Status.Capacity = v1.ResourceList{}
+	}
+	if node.Status.Allocatable == nil {
+		node.Status.Allocatable = v1.ResourceList{}
+	}
+	for name, quantity := range nodeClass.Spec.ExtendedResources {
+		node.Status.Capacity[name] = *resource.NewQuantity(quantity, resource.DecimalSI)
+		node.Status.Allocatable[name] = *resource.NewQuantity(quantity, resource.DecimalSI)
+	}
+	if equality.Semantic.DeepEqual(node, stored) {
+		return reconcile.Result{}, nil
+	}
+	if err := c.kubeClient.Status().Patch(ctx, node, client.MergeFrom(stored)); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("patching node status, %w", err))
+	}
+	log.FromContext(ctx).WithValues("Node", node.Name).V(1).Info("advertised extended resources")
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.extendedresources").
+		For(&corev1beta1.NodeClaim{}).
+		WithEventFilter(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			nodeClaim, ok := o.(*corev1beta1.NodeClaim)
+			return !ok || nodeClaim.Status.NodeName != ""
+		})).
+		Watches(&v1.Node{}, nodeclaimutil.NodeEventHandler(c.kubeClient)).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
@@ -0,0 +1,113 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extendedresources_test
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/extendedresources"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var extendedResourcesController *extendedresources.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ExtendedResourcesController")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	extendedResourcesController = extendedresources.NewController(env.Client)
+})
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("ExtendedResourcesController", func() {
+	var nodeClass *v1beta1.EC2NodeClass
+	var nodeClaim *corev1beta1.NodeClaim
+	var node *v1.Node
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1beta1.EC2NodeClass{
+			Spec: v1beta1.EC2NodeClassSpec{
+				ExtendedResources: map[v1.ResourceName]int64{"example.com/fpga": 2},
+			},
+		})
+		nodeClaim, node = coretest.NodeClaimAndNode(corev1beta1.NodeClaim{
+			Spec: corev1beta1.NodeClaimSpec{
+				NodeClassRef: &corev1beta1.NodeClassReference{
+					Name: nodeClass.Name,
+				},
+			},
+		})
+		nodeClaim.Status.NodeName = node.Name
+		node.Status.Capacity = v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}
+		node.Status.Allocatable = v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}
+	})
+
+	It("should add the extended resources of the nodeClass to the capacity of the node", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, extendedResourcesController, nodeClaim)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Status.Capacity).To(HaveKeyWithValue(v1.ResourceName("example.com/fpga"), resource.MustParse("2")))
+		Expect(node.Status.Allocatable).To(HaveKeyWithValue(v1.ResourceName("example.com/fpga"), resource.MustParse("2")))
+		Expect(node.Status.Capacity).To(HaveKeyWithValue(v1.ResourceCPU, resource.MustParse("2")))
+	})
+	It("should update the quantity of an extended resource that the node already advertises", func() {
+		node.Status.Capacity["example.com/fpga"] = resource.MustParse("1")
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, extendedResourcesController, nodeClaim)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Status.Capacity).To(HaveKeyWithValue(v1.ResourceName("example.com/fpga"), resource.MustParse("2")))
+	})
+	It("should not modify the node when the nodeClass doesn't have extended resources", func() {
+		nodeClass.Spec.ExtendedResources = nil
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, extendedResourcesController, nodeClaim)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Status.Capacity).ToNot(HaveKey(v1.ResourceName("example.com/fpga")))
+	})
+	It("should not fail when the node hasn't registered", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, extendedResourcesController, nodeClaim)
+	})
+})
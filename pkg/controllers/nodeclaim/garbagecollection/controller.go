@@ -19,9 +19,11 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
@@ -32,18 +34,28 @@ import (
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 )
 
+// gracePeriod is how long after its launch an instance is left alone, so that a NodeClaim whose launch just returned
+// has time to record the instance's provider ID
+const gracePeriod = 30 * time.Second
+
 type Controller struct {
 	kubeClient      client.Client
+	recorder        events.Recorder
 	cloudProvider   cloudprovider.CloudProvider
 	successfulCount uint64 // keeps track of successful reconciles for more aggressive requeueing near the start of the controller
 }
 
-func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) *Controller {
+func NewController(kubeClient client.Client, recorder events.Recorder, cloudProvider cloudprovider.CloudProvider) *Controller {
 	return &Controller{
 		kubeClient:      kubeClient,
+		recorder:        recorder,
 		cloudProvider:   cloudProvider,
 		successfulCount: 0,
 	}
@@ -57,8 +69,9 @@ func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconc
 	if err != nil {
 		return reconcile.Result{}, fmt.Errorf("listing cloudprovider machines, %w", err)
 	}
+	// Only instances that were launched by this cluster's Karpenter are garbage collected
 	managedRetrieved := lo.Filter(retrieved, func(nc *v1beta1.NodeClaim, _ int) bool {
-		return nc.Annotations[v1beta1.ManagedByAnnotationKey] != "" && nc.DeletionTimestamp.IsZero()
+		return nc.Annotations[v1beta1.ManagedByAnnotationKey] == options.FromContext(ctx).ClusterName && nc.DeletionTimestamp.IsZero()
 	})
 	nodeClaimList := &v1beta1.NodeClaimList{}
 	if err = c.kubeClient.List(ctx, nodeClaimList); err != nil {
@@ -74,7 +87,7 @@ func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconc
 	errs := make([]error, len(retrieved))
 	workqueue.ParallelizeUntil(ctx, 100, len(managedRetrieved), func(i int) {
 		if !resolvedProviderIDs.Has(managedRetrieved[i].Status.ProviderID) &&
-			time.Since(managedRetrieved[i].CreationTimestamp.Time) > time.Second*30 {
+			time.Since(managedRetrieved[i].CreationTimestamp.Time) > gracePeriod {
 			errs[i] = c.garbageCollect(ctx, managedRetrieved[i], nodeList)
 		}
 	})
@@ -91,6 +104,12 @@ func (c *Controller) garbageCollect(ctx context.Context, nodeClaim *v1beta1.Node
 		return cloudprovider.IgnoreNodeClaimNotFoundError(err)
 	}
 	log.FromContext(ctx).V(1).Info("garbage collected cloudprovider instance")
+	nodePoolName := nodeClaim.Labels[v1beta1.NodePoolLabelKey]
+	instancesGarbageCollected.With(prometheus.Labels{metrics.NodePoolLabel: nodePoolName}).Inc()
+	nodePool := &v1beta1.NodePool{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodePoolName}, nodePool); err == nil {
+		c.recorder.Publish(InstanceGarbageCollectedEvent(nodePool, nodeClaim.Status.ProviderID))
+	}
 
 	// Go ahead and cleanup the node if we know that it exists to make scheduling go quicker
 	if node, ok := lo.Find(nodeList.Items, func(n v1.Node) bool {
@@ -53,6 +53,7 @@ var awsEnv *test.Environment
 var env *coretest.Environment
 var garbageCollectionController *garbagecollection.Controller
 var cloudProvider *cloudprovider.CloudProvider
+var recorder *coretest.EventRecorder
 
 func TestAPIs(t *testing.T) {
 	ctx = TestContextWithLogger(t)
@@ -64,9 +65,10 @@ var _ = BeforeSuite(func() {
 	ctx = options.ToContext(ctx, test.Options())
 	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
 	awsEnv = test.NewEnvironment(ctx, env)
-	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.AccountProvider, events.NewRecorder(&record.FakeRecorder{}),
 		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider, awsEnv.SubnetProvider)
-	garbageCollectionController = garbagecollection.NewController(env.Client, cloudProvider)
+	recorder = coretest.NewEventRecorder()
+	garbageCollectionController = garbagecollection.NewController(env.Client, recorder, cloudProvider)
 })
 
 var _ = AfterSuite(func() {
@@ -75,18 +77,20 @@ var _ = AfterSuite(func() {
 
 var _ = BeforeEach(func() {
 	awsEnv.Reset()
+	recorder.Reset()
 })
 
 var _ = Describe("GarbageCollection", func() {
 	var instance *ec2.Instance
 	var nodeClass *v1beta1.EC2NodeClass
+	var nodePool *corev1beta1.NodePool
 	var providerID string
 
 	BeforeEach(func() {
 		instanceID := fake.InstanceID()
 		providerID = fake.ProviderID(instanceID)
 		nodeClass = test.EC2NodeClass()
-		nodePool := coretest.NodePool(corev1beta1.NodePool{
+		nodePool = coretest.NodePool(corev1beta1.NodePool{
 			Spec: corev1beta1.NodePoolSpec{
 				Template: corev1beta1.NodeClaimTemplate{
 					Spec: corev1beta1.NodeClaimSpec{
@@ -285,6 +289,39 @@ var _ = Describe("GarbageCollection", func() {
 		_, err := cloudProvider.Get(ctx, providerID)
 		Expect(err).NotTo(HaveOccurred())
 	})
+	It("should record a metric and an event on the NodePool when it deletes an instance", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instance.LaunchTime = aws.Time(time.Now().Add(-time.Minute))
+		awsEnv.EC2API.Instances.Store(aws.StringValue(instance.InstanceId), instance)
+		var before float64
+		if metric, ok := FindMetricWithLabelValues("karpenter_cloudprovider_instances_garbage_collected", map[string]string{"nodepool": nodePool.Name}); ok {
+			before = metric.GetCounter().GetValue()
+		}
+
+		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+		_, err := cloudProvider.Get(ctx, providerID)
+		Expect(corecloudprovider.IsNodeClaimNotFoundError(err)).To(BeTrue())
+
+		metric, ok := FindMetricWithLabelValues("karpenter_cloudprovider_instances_garbage_collected", map[string]string{"nodepool": nodePool.Name})
+		Expect(ok).To(BeTrue())
+		Expect(metric.GetCounter().GetValue()).To(Equal(before + 1))
+		Expect(recorder.Calls("InstanceGarbageCollected")).To(Equal(1))
+	})
+	It("should not delete an instance that is managed by another cluster", func() {
+		instance.Tags = lo.Map(instance.Tags, func(t *ec2.Tag, _ int) *ec2.Tag {
+			if aws.StringValue(t.Key) == corev1beta1.ManagedByAnnotationKey {
+				return &ec2.Tag{Key: t.Key, Value: aws.String("other-cluster")}
+			}
+			return t
+		})
+		instance.LaunchTime = aws.Time(time.Now().Add(-time.Minute))
+		awsEnv.EC2API.Instances.Store(aws.StringValue(instance.InstanceId), instance)
+
+		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+		_, err := cloudProvider.Get(ctx, providerID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Calls("InstanceGarbageCollected")).To(Equal(0))
+	})
 	It("should not delete an instance if it was not launched by a NodeClaim", func() {
 		// Remove the "karpenter.sh/managed-by" tag (this isn't launched by a machine)
 		instance.Tags = lo.Reject(instance.Tags, func(t *ec2.Tag, _ int) bool {
@@ -0,0 +1,165 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notready
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeclaimutil "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+const terminationReasonLabel = "not_ready"
+
+// Controller deletes NodeClaims whose Node registered but never became Ready, e.g. because of a bad AMI or a CNI
+// failure, so that the instance is terminated and replaced rather than consuming capacity. Nodes are given a grace
+// period after registering to bootstrap, which is the not-ready-timeout option or the not-ready-timeout annotation of
+// the NodeClaim.
+type Controller struct {
+	kubeClient client.Client
+	recorder   events.Recorder
+	clock      clock.Clock
+}
+
+func NewController(kubeClient client.Client, recorder events.Recorder, clk clock.Clock) *Controller {
+	return &Controller{
+		kubeClient: kubeClient,
+		recorder:   recorder,
+		clock:      clk,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.notready")
+
+	if !isRemediable(nodeClaim) {
+		return reconcile.Result{}, nil
+	}
+	timeout := notReadyTimeout(ctx, nodeClaim)
+	if timeout == 0 {
+		return reconcile.Result{}, nil
+	}
+	registered := nodeClaim.StatusConditions().Get(corev1beta1.ConditionTypeRegistered)
+	if registered == nil || !registered.IsTrue() {
+		return reconcile.Result{}, nil
+	}
+	node := &v1.Node{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Status.NodeName}, node); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	// The NodeClaim is initialized once the Node is Ready, so this only happens until the initialization is observed
+	if ready, ok := lo.Find(node.Status.Conditions, func(c v1.NodeCondition) bool { return c.Type == v1.NodeReady }); ok && ready.Status == v1.ConditionTrue {
+		return reconcile.Result{}, nil
+	}
+	if remaining := timeout - c.clock.Since(registered.LastTransitionTime.Time); remaining > 0 {
+		return reconcile.Result{RequeueAfter: remaining}, nil
+	}
+	if err := c.kubeClient.Delete(ctx, nodeClaim); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("deleting nodeclaim, %w", err))
+	}
+	log.FromContext(ctx).WithValues("Node", node.Name, "timeout", timeout).Info("deleted nodeclaim whose node didn't become ready")
+	c.recorder.Publish(NotReadyTimeoutEvents(node, nodeClaim, timeout)...)
+	metrics.NodeClaimsTerminatedCounter.With(prometheus.Labels{
+		metrics.ReasonLabel:       terminationReasonLabel,
+		metrics.NodePoolLabel:     nodeClaim.Labels[corev1beta1.NodePoolLabelKey],
+		metrics.CapacityTypeLabel: nodeClaim.Labels[corev1beta1.CapacityTypeLabelKey],
+	}).Inc()
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.notready").
+		For(&corev1beta1.NodeClaim{}).
+		WithEventFilter(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			nodeClaim, ok := o.(*corev1beta1.NodeClaim)
+			return !ok || isRemediable(nodeClaim)
+		})).
+		Watches(&v1.Node{}, nodeclaimutil.NodeEventHandler(c.kubeClient)).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+// notReadyTimeout returns the grace period for the Node of the NodeClaim to become Ready after registering. An invalid
+// annotation is ignored, since requeueing wouldn't fix it, and the not-ready-timeout option is used instead.
+func notReadyTimeout(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) time.Duration {
+	value, ok := nodeClaim.Annotations[v1beta1.AnnotationNotReadyTimeout]
+	if !ok {
+		return options.FromContext(ctx).NotReadyTimeout
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		log.FromContext(ctx).Error(fmt.Errorf("parsing %s annotation %q, expected a non-negative duration", v1beta1.AnnotationNotReadyTimeout, value), "ignoring invalid not-ready timeout annotation")
+		return options.FromContext(ctx).NotReadyTimeout
+	}
+	return timeout
+}
+
+func isRemediable(nc *corev1beta1.NodeClaim) bool {
+	// Node name is not yet known
+	if nc.Status.NodeName == "" {
+		return false
+	}
+	// NodeClaim doesn't reference an EC2NodeClass
+	if !isEC2NodeClassRef(nc.Spec.NodeClassRef) {
+		return false
+	}
+	// NodeClaim is currently terminating
+	if !nc.DeletionTimestamp.IsZero() {
+		return false
+	}
+	// Node has already become Ready, so it isn't stuck bootstrapping
+	if initialized := nc.StatusConditions().Get(corev1beta1.ConditionTypeInitialized); initialized != nil && initialized.IsTrue() {
+		return false
+	}
+	return true
+}
+
+// isEC2NodeClassRef returns whether the reference is to an EC2NodeClass. The kind and apiVersion of the reference are
+// optional, so a reference that omits them is assumed to be to an EC2NodeClass.
+func isEC2NodeClassRef(ref *corev1beta1.NodeClassReference) bool {
+	if ref == nil {
+		return false
+	}
+	if ref.Kind != "" && ref.Kind != "EC2NodeClass" {
+		return false
+	}
+	if ref.APIVersion != "" {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil || gv.Group != v1beta1.SchemeGroupVersion.Group {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notready
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+func NotReadyTimeoutEvents(node *v1.Node, nodeClaim *corev1beta1.NodeClaim, timeout time.Duration) []events.Event {
+	return []events.Event{
+		{
+			InvolvedObject: nodeClaim,
+			Type:           v1.EventTypeWarning,
+			Reason:         "NotReadyTimeout",
+			Message:        fmt.Sprintf("Node didn't become ready within %s of registering, deleting the NodeClaim", timeout),
+			DedupeValues:   []string{string(nodeClaim.UID)},
+		},
+		{
+			InvolvedObject: node,
+			Type:           v1.EventTypeWarning,
+			Reason:         "NotReadyTimeout",
+			Message:        fmt.Sprintf("Node didn't become ready within %s of registering, deleting the Node", timeout),
+			DedupeValues:   []string{string(node.UID)},
+		},
+	}
+}
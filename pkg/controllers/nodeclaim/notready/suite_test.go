@@ -0,0 +1,219 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notready_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/notready"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var fakeClock *clock.FakeClock
+var recorder *coretest.EventRecorder
+var notReadyController *notready.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "NotReadyController")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	fakeClock = clock.NewFakeClock(time.Now())
+	recorder = coretest.NewEventRecorder()
+	notReadyController = notready.NewController(env.Client, recorder, fakeClock)
+})
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+		NotReadyTimeout: lo.ToPtr(15 * time.Minute),
+	}))
+	fakeClock.SetTime(time.Now())
+	recorder.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("NotReadyController", func() {
+	var nodeClass *v1beta1.EC2NodeClass
+	var nodeClaim *corev1beta1.NodeClaim
+	var node *v1.Node
+	var nodePoolName string
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+		// Each test uses a unique nodepool so that the terminated counter for each test starts empty
+		nodePoolName = coretest.RandomName()
+		nodeClaim, node = coretest.NodeClaimAndNode(corev1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					corev1beta1.NodePoolLabelKey:     nodePoolName,
+					corev1beta1.CapacityTypeLabelKey: corev1beta1.CapacityTypeOnDemand,
+				},
+			},
+			Spec: corev1beta1.NodeClaimSpec{
+				NodeClassRef: &corev1beta1.NodeClassReference{
+					Name: nodeClass.Name,
+				},
+			},
+		})
+		nodeClaim.Status.NodeName = node.Name
+		nodeClaim.StatusConditions().SetTrue(corev1beta1.ConditionTypeLaunched)
+		nodeClaim.StatusConditions().SetTrue(corev1beta1.ConditionTypeRegistered)
+		node.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionFalse}}
+	})
+
+	It("should delete a nodeclaim whose node is stuck NotReady after the timeout", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		fakeClock.Step(16 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, notReadyController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeFalse())
+		Expect(recorder.Calls("NotReadyTimeout")).To(Equal(2))
+		metric, found := FindMetricWithLabelValues("karpenter_nodeclaims_terminated", map[string]string{
+			"reason":   "not_ready",
+			"nodepool": nodePoolName,
+		})
+		Expect(found).To(BeTrue())
+		Expect(metric.GetCounter().GetValue()).To(BeNumerically("==", 1))
+	})
+	It("should requeue a nodeclaim whose node is NotReady within the timeout", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		fakeClock.Step(10 * time.Minute)
+		result := ExpectObjectReconciled(ctx, env.Client, notReadyController, nodeClaim)
+		Expect(result.RequeueAfter).To(BeNumerically("~", 5*time.Minute, time.Minute))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+		Expect(recorder.Calls("NotReadyTimeout")).To(Equal(0))
+	})
+	It("should not delete a nodeclaim whose node is Ready", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		ExpectMakeNodesReady(ctx, env.Client, node)
+		fakeClock.Step(16 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, notReadyController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+	})
+	It("should not delete a nodeclaim that has been initialized", func() {
+		nodeClaim.StatusConditions().SetTrue(corev1beta1.ConditionTypeInitialized)
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		fakeClock.Step(16 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, notReadyController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+	})
+	It("should not delete a nodeclaim that hasn't registered", func() {
+		nodeClaim.StatusConditions().SetUnknown(corev1beta1.ConditionTypeRegistered)
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		fakeClock.Step(16 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, notReadyController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+	})
+	It("should give nodes the grace period of the not-ready-timeout annotation", func() {
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1beta1.AnnotationNotReadyTimeout: "30m"})
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		fakeClock.Step(16 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, notReadyController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+
+		fakeClock.Step(15 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, notReadyController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeFalse())
+	})
+	It("should use the not-ready-timeout option when the not-ready-timeout annotation is invalid", func() {
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1beta1.AnnotationNotReadyTimeout: "soon"})
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		fakeClock.Step(10 * time.Minute)
+		result := ExpectObjectReconciled(ctx, env.Client, notReadyController, nodeClaim)
+		Expect(result.RequeueAfter).To(BeNumerically("~", 5*time.Minute, time.Minute))
+
+		fakeClock.Step(6 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, notReadyController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeFalse())
+	})
+	It("should not delete a nodeclaim that references a node class of another kind", func() {
+		nodeClaim.Spec.NodeClassRef = &corev1beta1.NodeClassReference{
+			APIVersion: "karpenter.kwok.sh/v1alpha1",
+			Kind:       "KWOKNodeClass",
+			Name:       nodeClass.Name,
+		}
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		fakeClock.Step(16 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, notReadyController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+	})
+	It("should delete a nodeclaim that references an EC2NodeClass by kind and apiVersion", func() {
+		nodeClaim.Spec.NodeClassRef = &corev1beta1.NodeClassReference{
+			APIVersion: v1beta1.SchemeGroupVersion.String(),
+			Kind:       "EC2NodeClass",
+			Name:       nodeClass.Name,
+		}
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		fakeClock.Step(16 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, notReadyController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeFalse())
+	})
+	It("should not delete nodeclaims when the timeout is 0", func() {
+		ctx = options.ToContext(ctx, test.Options())
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		fakeClock.Step(24 * time.Hour)
+		ExpectObjectReconciled(ctx, env.Client, notReadyController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+	})
+})
@@ -0,0 +1,118 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readiness
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeclaimutil "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+)
+
+// Controller records the time between an instance being launched for a NodeClaim and its Node becoming Ready. The
+// latency is recorded once per NodeClaim, which is tracked through an annotation so that it isn't recorded again
+// after a restart.
+type Controller struct {
+	kubeClient client.Client
+}
+
+func NewController(kubeClient client.Client) *Controller {
+	return &Controller{
+		kubeClient: kubeClient,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.readiness")
+
+	if !isRecordable(nodeClaim) {
+		return reconcile.Result{}, nil
+	}
+	launched := nodeClaim.StatusConditions().Get(corev1beta1.ConditionTypeLaunched)
+	if launched == nil || !launched.IsTrue() {
+		return reconcile.Result{}, nil
+	}
+	node := &v1.Node{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Status.NodeName}, node); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	ready, ok := lo.Find(node.Status.Conditions, func(c v1.NodeCondition) bool { return c.Type == v1.NodeReady })
+	if !ok || ready.Status != v1.ConditionTrue {
+		return reconcile.Result{}, nil
+	}
+	nodeClass := &v1beta1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1beta1.AnnotationLaunchToReadyRecorded: "true"})
+	if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	// The Ready condition is set by the kubelet, so clock skew between the instance and Karpenter can make the Node
+	// appear to become Ready before the instance was launched
+	latency := lo.Max([]float64{ready.LastTransitionTime.Sub(launched.LastTransitionTime.Time).Seconds(), 0})
+	nodeLaunchToReadySeconds.With(map[string]string{
+		amiFamilyLabel:      lo.Ternary(nodeClass.Spec.AMIFamily != nil, aws.StringValue(nodeClass.Spec.AMIFamily), v1beta1.AMIFamilyAL2),
+		instanceFamilyLabel: nodeClaim.Labels[v1beta1.LabelInstanceFamily],
+		capacityTypeLabel:   nodeClaim.Labels[corev1beta1.CapacityTypeLabelKey],
+	}).Observe(latency)
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.readiness").
+		For(&corev1beta1.NodeClaim{}).
+		WithEventFilter(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			nodeClaim, ok := o.(*corev1beta1.NodeClaim)
+			return !ok || isRecordable(nodeClaim)
+		})).
+		Watches(&v1.Node{}, nodeclaimutil.NodeEventHandler(c.kubeClient)).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+func isRecordable(nc *corev1beta1.NodeClaim) bool {
+	// Latency has already been recorded
+	if val := nc.Annotations[v1beta1.AnnotationLaunchToReadyRecorded]; val == "true" {
+		return false
+	}
+	// Node name is not yet known
+	if nc.Status.NodeName == "" {
+		return false
+	}
+	// NodeClaim doesn't reference an EC2NodeClass
+	if nc.Spec.NodeClassRef == nil {
+		return false
+	}
+	// NodeClaim is currently terminating
+	if !nc.DeletionTimestamp.IsZero() {
+		return false
+	}
+	return true
+}
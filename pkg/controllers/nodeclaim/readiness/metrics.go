@@ -0,0 +1,45 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readiness
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	amiFamilyLabel      = "ami_family"
+	instanceFamilyLabel = "instance_family"
+	capacityTypeLabel   = metrics.CapacityTypeLabel
+)
+
+var (
+	nodeLaunchToReadySeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "node",
+			Name:      "launch_to_ready_seconds",
+			Help:      "The time from an instance being launched to its node becoming ready. Labeled by AMI family, instance family, and capacity type.",
+			Buckets:   metrics.DurationBuckets(),
+		},
+		[]string{amiFamilyLabel, instanceFamilyLabel, capacityTypeLabel},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(nodeLaunchToReadySeconds)
+}
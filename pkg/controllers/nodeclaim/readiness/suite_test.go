@@ -0,0 +1,158 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readiness_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/readiness"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var readinessController *readiness.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ReadinessController")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	readinessController = readiness.NewController(env.Client)
+})
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("ReadinessController", func() {
+	var nodeClass *v1beta1.EC2NodeClass
+	var nodeClaim *corev1beta1.NodeClaim
+	var node *v1.Node
+	var instanceFamily string
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1beta1.EC2NodeClass{
+			Spec: v1beta1.EC2NodeClassSpec{
+				AMIFamily: &v1beta1.AMIFamilyBottlerocket,
+			},
+		})
+		// Each test uses a unique instance family so that the histogram for each test starts empty
+		instanceFamily = coretest.RandomName()
+		nodeClaim, node = coretest.NodeClaimAndNode(corev1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.LabelInstanceFamily:      instanceFamily,
+					corev1beta1.CapacityTypeLabelKey: corev1beta1.CapacityTypeSpot,
+				},
+			},
+			Spec: corev1beta1.NodeClaimSpec{
+				NodeClassRef: &corev1beta1.NodeClassReference{
+					Name: nodeClass.Name,
+				},
+			},
+		})
+		nodeClaim.Status.NodeName = node.Name
+		nodeClaim.StatusConditions().SetTrue(corev1beta1.ConditionTypeLaunched)
+		for i := range nodeClaim.Status.Conditions {
+			if nodeClaim.Status.Conditions[i].Type == corev1beta1.ConditionTypeLaunched {
+				nodeClaim.Status.Conditions[i].LastTransitionTime = metav1.NewTime(time.Now().Add(-2 * time.Minute))
+			}
+		}
+		node.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionFalse}}
+	})
+
+	It("should record the launch to ready latency when the node becomes ready", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, readinessController, nodeClaim)
+		_, found := FindMetricWithLabelValues("karpenter_node_launch_to_ready_seconds", map[string]string{"instance_family": instanceFamily})
+		Expect(found).To(BeFalse())
+
+		ExpectMakeNodesReady(ctx, env.Client, node)
+		ExpectObjectReconciled(ctx, env.Client, readinessController, nodeClaim)
+		metric, found := FindMetricWithLabelValues("karpenter_node_launch_to_ready_seconds", map[string]string{
+			"ami_family":      v1beta1.AMIFamilyBottlerocket,
+			"instance_family": instanceFamily,
+			"capacity_type":   corev1beta1.CapacityTypeSpot,
+		})
+		Expect(found).To(BeTrue())
+		Expect(metric.GetHistogram().GetSampleCount()).To(BeNumerically("==", 1))
+		Expect(metric.GetHistogram().GetSampleSum()).To(BeNumerically(">=", 120))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationLaunchToReadyRecorded, "true"))
+	})
+	It("should only record the latency once for a nodeclaim", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		ExpectMakeNodesReady(ctx, env.Client, node)
+		ExpectObjectReconciled(ctx, env.Client, readinessController, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, readinessController, nodeClaim)
+		metric, found := FindMetricWithLabelValues("karpenter_node_launch_to_ready_seconds", map[string]string{"instance_family": instanceFamily})
+		Expect(found).To(BeTrue())
+		Expect(metric.GetHistogram().GetSampleCount()).To(BeNumerically("==", 1))
+	})
+	It("should default the AMI family label to AL2", func() {
+		nodeClass.Spec.AMIFamily = nil
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		ExpectMakeNodesReady(ctx, env.Client, node)
+		ExpectObjectReconciled(ctx, env.Client, readinessController, nodeClaim)
+		_, found := FindMetricWithLabelValues("karpenter_node_launch_to_ready_seconds", map[string]string{
+			"ami_family":      v1beta1.AMIFamilyAL2,
+			"instance_family": instanceFamily,
+		})
+		Expect(found).To(BeTrue())
+	})
+	It("should not record the latency for nodeclaims that haven't launched", func() {
+		nodeClaim.Status.Conditions = nil
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, node)
+		ExpectMakeNodesReady(ctx, env.Client, node)
+		ExpectObjectReconciled(ctx, env.Client, readinessController, nodeClaim)
+		_, found := FindMetricWithLabelValues("karpenter_node_launch_to_ready_seconds", map[string]string{"instance_family": instanceFamily})
+		Expect(found).To(BeFalse())
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationLaunchToReadyRecorded))
+	})
+	It("should not record the latency for nodeclaims without a node", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, readinessController, nodeClaim)
+		_, found := FindMetricWithLabelValues("karpenter_node_launch_to_ready_seconds", map[string]string{"instance_family": instanceFamily})
+		Expect(found).To(BeFalse())
+	})
+})
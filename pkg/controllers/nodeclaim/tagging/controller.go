@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -34,6 +35,7 @@ import (
 	"github.com/awslabs/operatorpkg/reasonable"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
@@ -42,14 +44,14 @@ import (
 )
 
 type Controller struct {
-	kubeClient       client.Client
-	instanceProvider instance.Provider
+	kubeClient      client.Client
+	accountProvider account.Provider
 }
 
-func NewController(kubeClient client.Client, instanceProvider instance.Provider) *Controller {
+func NewController(kubeClient client.Client, accountProvider account.Provider) *Controller {
 	return &Controller{
-		kubeClient:       kubeClient,
-		instanceProvider: instanceProvider,
+		kubeClient:      kubeClient,
+		accountProvider: accountProvider,
 	}
 }
 
@@ -99,8 +101,12 @@ func (c *Controller) tagInstance(ctx context.Context, nc *corev1beta1.NodeClaim,
 		v1beta1.TagNodeClaim: nc.Name,
 	}
 
+	instanceProvider, err := c.resolveInstanceProvider(ctx, nc)
+	if err != nil {
+		return fmt.Errorf("tagging nodeclaim, %w", err)
+	}
 	// Remove tags which have been already populated
-	instance, err := c.instanceProvider.Get(ctx, id)
+	instance, err := instanceProvider.Get(ctx, id)
 	if err != nil {
 		return fmt.Errorf("tagging nodeclaim, %w", err)
 	}
@@ -112,12 +118,24 @@ func (c *Controller) tagInstance(ctx context.Context, nc *corev1beta1.NodeClaim,
 	// Ensures that no more than 1 CreateTags call is made per second. Rate limiting is required since CreateTags
 	// shares a pool with other mutating calls (e.g. CreateFleet).
 	defer time.Sleep(time.Second)
-	if err := c.instanceProvider.CreateTags(ctx, id, tags); err != nil {
+	if err := instanceProvider.CreateTags(ctx, id, tags); err != nil {
 		return fmt.Errorf("tagging nodeclaim, %w", err)
 	}
 	return nil
 }
 
+// resolveInstanceProvider returns the instance provider for the account that the NodeClaim's instance was launched in.
+// NodeClaims whose EC2NodeClass can't be found fall back to the account that Karpenter runs in.
+func (c *Controller) resolveInstanceProvider(ctx context.Context, nc *corev1beta1.NodeClaim) (instance.Provider, error) {
+	nodeClass := &v1beta1.EC2NodeClass{}
+	if nc.Spec.NodeClassRef != nil {
+		if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nc.Spec.NodeClassRef.Name}, nodeClass); client.IgnoreNotFound(err) != nil {
+			return nil, fmt.Errorf("resolving ec2nodeclass, %w", err)
+		}
+	}
+	return c.accountProvider.Get(ctx, nodeClass).InstanceProvider, nil
+}
+
 func isTaggable(nc *corev1beta1.NodeClaim) bool {
 	// Instance has already been tagged
 	if val := nc.Annotations[v1beta1.AnnotationInstanceTagged]; val == "true" {
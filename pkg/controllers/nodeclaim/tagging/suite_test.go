@@ -59,7 +59,7 @@ var _ = BeforeSuite(func() {
 	ctx = coreoptions.ToContext(ctx, coretest.Options())
 	ctx = options.ToContext(ctx, test.Options())
 	awsEnv = test.NewEnvironment(ctx, env)
-	taggingController = tagging.NewController(env.Client, awsEnv.InstanceProvider)
+	taggingController = tagging.NewController(env.Client, awsEnv.AccountProvider)
 })
 var _ = AfterSuite(func() {
 	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
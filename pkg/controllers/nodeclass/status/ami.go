@@ -18,29 +18,42 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
 )
 
 type AMI struct {
-	amiProvider amifamily.Provider
+	accountProvider account.Provider
 }
 
 func (a *AMI) Reconcile(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (reconcile.Result, error) {
-	amis, err := a.amiProvider.List(ctx, nodeClass)
+	amiProvider := a.accountProvider.Get(ctx, nodeClass).AMIProvider
+	amis, err := amiProvider.List(ctx, nodeClass)
 	if err != nil {
 		return reconcile.Result{}, fmt.Errorf("getting amis, %w", err)
 	}
+	if amis, err = a.reconcileSelectorFallback(ctx, nodeClass, amiProvider, amis); err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := a.reconcileImagePipelines(ctx, nodeClass); err != nil {
+		return reconcile.Result{}, err
+	}
 	if len(amis) == 0 {
 		nodeClass.Status.AMIs = nil
+		_ = nodeClass.StatusConditions().Clear(v1beta1.ConditionTypeAMIsNotDeprecated)
 		return reconcile.Result{}, nil
 	}
 	nodeClass.Status.AMIs = lo.Map(amis, func(ami amifamily.AMI, _ int) v1beta1.AMI {
@@ -55,10 +68,69 @@ func (a *AMI) Reconcile(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (r
 			return reqs[i].Key < reqs[j].Key
 		})
 		return v1beta1.AMI{
-			Name:         ami.Name,
-			ID:           ami.AmiID,
-			Requirements: reqs,
+			Name:             ami.Name,
+			ID:               ami.AmiID,
+			Requirements:     reqs,
+			RootDeviceName:   ami.RootDeviceName,
+			RootSnapshotSize: ami.RootSnapshotSize,
+			Deprecated:       ami.Deprecated,
 		}
 	})
+	if deprecatedAMIs := lo.Uniq(lo.FilterMap(amis, func(ami amifamily.AMI, _ int) (string, bool) { return ami.AmiID, ami.Deprecated })); len(deprecatedAMIs) > 0 {
+		nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeAMIsNotDeprecated, "AMIDeprecated", fmt.Sprintf("AMIs %s are deprecated", strings.Join(deprecatedAMIs, ", ")))
+	} else {
+		nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeAMIsNotDeprecated)
+	}
 	return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
 }
+
+func (a *AMI) reconcileImagePipelines(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) error {
+	if !lo.ContainsBy(nodeClass.Spec.AMISelectorTerms, func(term v1beta1.AMISelectorTerm) bool { return term.ImagePipelineARN != "" }) {
+		_ = nodeClass.StatusConditions().Clear(v1beta1.ConditionTypeImagePipelineBuildsSucceeded)
+		return nil
+	}
+	failed, err := a.accountProvider.Get(ctx, nodeClass).AMIProvider.FailedImagePipelines(ctx, nodeClass)
+	if err != nil {
+		return fmt.Errorf("getting image pipelines, %w", err)
+	}
+	if len(failed) > 0 {
+		nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeImagePipelineBuildsSucceeded, "ImagePipelineBuildFailed", fmt.Sprintf("The latest build of image pipelines %s failed", strings.Join(failed, ", ")))
+		return nil
+	}
+	nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeImagePipelineBuildsSucceeded)
+	return nil
+}
+
+// reconcileSelectorFallback sets the AMISelectorTermsResolved condition. If the amiSelectorTerms don't match any AMIs
+// and the fallback is enabled, the default AMIs of the amiFamily are returned in place of the selected AMIs.
+func (a *AMI) reconcileSelectorFallback(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, amiProvider amifamily.Provider, amis amifamily.AMIs) (amifamily.AMIs, error) {
+	fallbackActive := amiSelectorFallbackActive.With(prometheus.Labels{nodeClassLabel: nodeClass.Name})
+	if len(nodeClass.Spec.AMISelectorTerms) == 0 {
+		fallbackActive.Set(0)
+		_ = nodeClass.StatusConditions().Clear(v1beta1.ConditionTypeAMISelectorTermsResolved)
+		return amis, nil
+	}
+	if len(amis) > 0 {
+		fallbackActive.Set(0)
+		nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeAMISelectorTermsResolved)
+		return amis, nil
+	}
+	if options.FromContext(ctx).AMISelectorFallback {
+		defaults, err := amiProvider.ListDefaults(ctx, nodeClass)
+		if err != nil {
+			return nil, fmt.Errorf("getting default amis, %w", err)
+		}
+		if len(defaults) > 0 {
+			amiFamily := lo.FromPtrOr(nodeClass.Spec.AMIFamily, v1beta1.AMIFamilyAL2)
+			if condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeAMISelectorTermsResolved); condition == nil || condition.Reason != "DefaultAMIFallback" {
+				log.FromContext(ctx).WithValues("amiFamily", amiFamily).Info("amiSelectorTerms didn't match any amis, falling back to the default amis")
+			}
+			fallbackActive.Set(1)
+			nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeAMISelectorTermsResolved, "DefaultAMIFallback", fmt.Sprintf("amiSelectorTerms didn't match any AMIs, using the default AMIs of the %s amiFamily", amiFamily))
+			return defaults, nil
+		}
+	}
+	fallbackActive.Set(0)
+	nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeAMISelectorTermsResolved, "AMISelectorTermsNotMatched", "amiSelectorTerms didn't match any AMIs")
+	return amis, nil
+}
@@ -20,11 +20,15 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/imagebuilder"
+	"github.com/awslabs/operatorpkg/status"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -88,6 +92,29 @@ var _ = Describe("NodeClass AMI Status Controller", func() {
 			},
 		})
 	})
+	It("should resolve the root snapshot size of AMIs into status", func() {
+		awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{
+			Images: []*ec2.Image{
+				{
+					Name:           aws.String("test-ami-1"),
+					ImageId:        aws.String("ami-test1"),
+					CreationDate:   aws.String(time.Now().Format(time.RFC3339)),
+					Architecture:   aws.String("x86_64"),
+					RootDeviceName: aws.String("/dev/xvda"),
+					BlockDeviceMappings: []*ec2.BlockDeviceMapping{
+						{DeviceName: aws.String("/dev/xvda"), Ebs: &ec2.EbsBlockDevice{VolumeSize: aws.Int64(50)}},
+						{DeviceName: aws.String("/dev/xvdb"), Ebs: &ec2.EbsBlockDevice{VolumeSize: aws.Int64(100)}},
+					},
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.AMIs).To(HaveLen(1))
+		Expect(nodeClass.Status.AMIs[0].RootDeviceName).To(Equal("/dev/xvda"))
+		Expect(nodeClass.Status.AMIs[0].RootSnapshotSize.String()).To(Equal("50Gi"))
+	})
 	It("should resolve amiSelector AMIs and requirements into status", func() {
 		version := lo.Must(awsEnv.VersionProvider.Get(ctx))
 
@@ -303,4 +330,202 @@ var _ = Describe("NodeClass AMI Status Controller", func() {
 			},
 		))
 	})
+	Context("Image Pipelines", func() {
+		const pipelineARN = "arn:aws:imagebuilder:us-west-2:123456789012:image-pipeline/test-pipeline"
+		setPipelineImages := func(statuses ...string) {
+			awsEnv.ImagebuilderAPI.ListImagePipelineImagesBehavior.Output.Set(&imagebuilder.ListImagePipelineImagesOutput{
+				ImageSummaryList: lo.Map(statuses, func(status string, i int) *imagebuilder.ImageSummary {
+					return &imagebuilder.ImageSummary{
+						Arn:         aws.String(fmt.Sprintf("arn:aws:imagebuilder:us-west-2:123456789012:image/test-recipe/1.0.0/%d", i)),
+						DateCreated: aws.String(time.Now().Add(-time.Duration(i) * time.Hour).Format(time.RFC3339)),
+						State:       &imagebuilder.ImageState{Status: aws.String(status)},
+						OutputResources: &imagebuilder.OutputResources{
+							Amis: []*imagebuilder.Ami{{Region: aws.String(fake.DefaultRegion), Image: aws.String("ami-test1")}},
+						},
+					}
+				}),
+			})
+		}
+		BeforeEach(func() {
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{ImagePipelineARN: pipelineARN}}
+		})
+		It("should resolve the amis of the latest successful build into status", func() {
+			setPipelineImages(imagebuilder.ImageStatusAvailable)
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(lo.Map(nodeClass.Status.AMIs, func(a v1beta1.AMI, _ int) string { return a.ID })).To(ConsistOf("ami-test1"))
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeImagePipelineBuildsSucceeded).IsTrue()).To(BeTrue())
+		})
+		It("should set the condition to false when the latest build failed", func() {
+			setPipelineImages(imagebuilder.ImageStatusFailed, imagebuilder.ImageStatusAvailable)
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(lo.Map(nodeClass.Status.AMIs, func(a v1beta1.AMI, _ int) string { return a.ID })).To(ConsistOf("ami-test1"))
+			condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeImagePipelineBuildsSucceeded)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("ImagePipelineBuildFailed"))
+			Expect(condition.Message).To(ContainSubstring(pipelineARN))
+		})
+		It("should not set the condition when no image pipelines are selected", func() {
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}}}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeImagePipelineBuildsSucceeded)).To(BeNil())
+		})
+	})
+	Context("Deprecation", func() {
+		BeforeEach(func() {
+			awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{
+				Images: []*ec2.Image{
+					{
+						Name:            aws.String("test-ami-1"),
+						ImageId:         aws.String("ami-test1"),
+						CreationDate:    aws.String(time.Now().Add(-time.Hour).Format(time.RFC3339)),
+						DeprecationTime: aws.String(time.Now().Add(-time.Minute).Format(time.RFC3339)),
+						Architecture:    aws.String("x86_64"),
+						Tags: []*ec2.Tag{
+							{Key: aws.String("*"), Value: aws.String("*")},
+						},
+					},
+					{
+						Name:            aws.String("test-ami-2"),
+						ImageId:         aws.String("ami-test2"),
+						CreationDate:    aws.String(time.Now().Add(-time.Hour).Format(time.RFC3339)),
+						DeprecationTime: aws.String(time.Now().Add(time.Hour).Format(time.RFC3339)),
+						Architecture:    aws.String("arm64"),
+						Tags: []*ec2.Tag{
+							{Key: aws.String("*"), Value: aws.String("*")},
+						},
+					},
+				},
+			})
+		})
+		It("should resolve the deprecation of AMIs into status", func() {
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{ID: "ami-test1"}, {ID: "ami-test2"}}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(lo.SliceToMap(nodeClass.Status.AMIs, func(a v1beta1.AMI) (string, bool) { return a.ID, a.Deprecated })).To(Equal(map[string]bool{
+				"ami-test1": true,
+				"ami-test2": false,
+			}))
+			condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeAMIsNotDeprecated)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("AMIDeprecated"))
+			Expect(condition.Message).To(ContainSubstring("ami-test1"))
+			Expect(condition.Message).ToNot(ContainSubstring("ami-test2"))
+		})
+		It("should set the condition to true when no resolved AMIs are deprecated", func() {
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{ID: "ami-test2"}}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.Status.AMIs).To(HaveLen(1))
+			Expect(nodeClass.Status.AMIs[0].Deprecated).To(BeFalse())
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeAMIsNotDeprecated).IsTrue()).To(BeTrue())
+		})
+		It("should exclude deprecated AMIs selected by tags unless they're included", func() {
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}}}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(lo.Map(nodeClass.Status.AMIs, func(a v1beta1.AMI, _ int) string { return a.ID })).To(ConsistOf("ami-test2"))
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeAMIsNotDeprecated).IsTrue()).To(BeTrue())
+
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}, IncludeDeprecated: true}}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(lo.Map(nodeClass.Status.AMIs, func(a v1beta1.AMI, _ int) string { return a.ID })).To(ConsistOf("ami-test1", "ami-test2"))
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeAMIsNotDeprecated).IsFalse()).To(BeTrue())
+			Expect(aws.BoolValue(awsEnv.EC2API.CalledWithDescribeImagesInput.Pop().IncludeDeprecated)).To(BeTrue())
+		})
+	})
+	Context("Selector Fallback", func() {
+		BeforeEach(func() {
+			version := lo.Must(awsEnv.VersionProvider.Get(ctx))
+			awsEnv.SSMAPI.Parameters = map[string]string{
+				fmt.Sprintf("/aws/service/bottlerocket/aws-k8s-%s/x86_64/latest/image_id", version): "ami-id-123",
+				fmt.Sprintf("/aws/service/bottlerocket/aws-k8s-%s/arm64/latest/image_id", version):  "ami-id-456",
+			}
+			awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{
+				Images: []*ec2.Image{
+					{
+						Name:         aws.String("test-ami-1"),
+						ImageId:      aws.String("ami-id-123"),
+						CreationDate: aws.String(time.Now().Format(time.RFC3339)),
+						Architecture: aws.String("x86_64"),
+						Tags:         []*ec2.Tag{{Key: aws.String("foo"), Value: aws.String("bar")}},
+					},
+					{
+						Name:         aws.String("test-ami-2"),
+						ImageId:      aws.String("ami-id-456"),
+						CreationDate: aws.String(time.Now().Format(time.RFC3339)),
+						Architecture: aws.String("arm64"),
+						Tags:         []*ec2.Tag{{Key: aws.String("foo"), Value: aws.String("bar")}},
+					},
+				},
+			})
+			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyBottlerocket
+			// The selector has a typo in the tag key, so it doesn't match any AMIs
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{Tags: map[string]string{"fooo": "bar"}}}
+		})
+		It("should set the condition to false and not resolve any AMIs when the fallback isn't enabled", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+
+			Expect(nodeClass.Status.AMIs).To(BeEmpty())
+			condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeAMISelectorTermsResolved)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("AMISelectorTermsNotMatched"))
+			Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsFalse()).To(BeTrue())
+		})
+		It("should resolve the default AMIs of the AMIFamily into status when the fallback is enabled", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{AMISelectorFallback: lo.ToPtr(true)}))
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+
+			Expect(lo.Map(nodeClass.Status.AMIs, func(a v1beta1.AMI, _ int) string { return a.ID })).To(ConsistOf("ami-id-123", "ami-id-456"))
+			condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeAMISelectorTermsResolved)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("DefaultAMIFallback"))
+			Expect(condition.Message).To(ContainSubstring(v1beta1.AMIFamilyBottlerocket))
+			Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+			metric, ok := FindMetricWithLabelValues("karpenter_nodeclass_ami_selector_fallback_active", map[string]string{"nodeclass": nodeClass.Name})
+			Expect(ok).To(BeTrue())
+			Expect(metric.GetGauge().GetValue()).To(BeNumerically("==", 1))
+		})
+		It("should stop falling back once the amiSelectorTerms match AMIs again", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{AMISelectorFallback: lo.ToPtr(true)}))
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeAMISelectorTermsResolved).IsFalse()).To(BeTrue())
+
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{ID: "ami-id-123"}}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+
+			Expect(lo.Map(nodeClass.Status.AMIs, func(a v1beta1.AMI, _ int) string { return a.ID })).To(ConsistOf("ami-id-123"))
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeAMISelectorTermsResolved).IsTrue()).To(BeTrue())
+			metric, ok := FindMetricWithLabelValues("karpenter_nodeclass_ami_selector_fallback_active", map[string]string{"nodeclass": nodeClass.Name})
+			Expect(ok).To(BeTrue())
+			Expect(metric.GetGauge().GetValue()).To(BeNumerically("==", 0))
+		})
+		It("should not set the condition when no amiSelectorTerms are specified", func() {
+			nodeClass.Spec.AMISelectorTerms = nil
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+
+			Expect(nodeClass.Status.AMIs).To(HaveLen(2))
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeAMISelectorTermsResolved)).To(BeNil())
+		})
+	})
 })
@@ -0,0 +1,68 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+)
+
+type BlockDeviceMapping struct{}
+
+func (b *BlockDeviceMapping) Reconcile(_ context.Context, nodeClass *v1beta1.EC2NodeClass) (reconcile.Result, error) {
+	b.reconcileRootVolumeSize(nodeClass)
+	blockDeviceMappings := amifamily.BlockDeviceMappings(nodeClass)
+	if len(blockDeviceMappings) == 0 {
+		nodeClass.Status.BlockDeviceMappings = nil
+		return reconcile.Result{}, nil
+	}
+	// The default block device mappings of an AMI family are shared, so they're copied before being stored in the status
+	nodeClass.Status.BlockDeviceMappings = lo.Map(blockDeviceMappings, func(b *v1beta1.BlockDeviceMapping, _ int) *v1beta1.BlockDeviceMapping {
+		return b.DeepCopy()
+	})
+	return reconcile.Result{}, nil
+}
+
+// reconcileRootVolumeSize surfaces block device mappings that explicitly request a root volume smaller than the root
+// snapshot of a resolved AMI, since the root volume of those instances is launched with the size of the snapshot instead
+func (b *BlockDeviceMapping) reconcileRootVolumeSize(nodeClass *v1beta1.EC2NodeClass) {
+	if len(nodeClass.Spec.BlockDeviceMappings) == 0 {
+		_ = nodeClass.StatusConditions().Clear(v1beta1.ConditionTypeRootVolumeSizeSufficient)
+		return
+	}
+	var messages []string
+	for _, bdm := range nodeClass.Spec.BlockDeviceMappings {
+		for _, ami := range nodeClass.Status.AMIs {
+			if amifamily.RootVolumeTooSmall(bdm, ami) {
+				messages = append(messages, fmt.Sprintf("%s of %s is smaller than the %s root snapshot of %s",
+					bdm.EBS.VolumeSize.String(), aws.StringValue(bdm.DeviceName), ami.RootSnapshotSize.String(), ami.ID))
+			}
+		}
+	}
+	if len(messages) > 0 {
+		nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeRootVolumeSizeSufficient, "RootVolumeSizeTooSmall",
+			fmt.Sprintf("VolumeSize %s, the root snapshot size is used instead", strings.Join(messages, ", ")))
+		return
+	}
+	nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeRootVolumeSizeSufficient)
+}
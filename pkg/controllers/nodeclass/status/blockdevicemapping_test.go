@@ -0,0 +1,149 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status_test
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Block Device Mapping Status Controller", func() {
+	It("Should update EC2NodeClass status with the default Block Device Mappings of the AMI family", func() {
+		nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.BlockDeviceMappings).To(HaveLen(1))
+		Expect(aws.StringValue(nodeClass.Status.BlockDeviceMappings[0].DeviceName)).To(Equal("/dev/xvda"))
+		Expect(aws.StringValue(nodeClass.Status.BlockDeviceMappings[0].EBS.VolumeType)).To(Equal(ec2.VolumeTypeGp3))
+		Expect(nodeClass.Status.BlockDeviceMappings[0].EBS.VolumeSize.String()).To(Equal("20Gi"))
+		Expect(aws.BoolValue(nodeClass.Status.BlockDeviceMappings[0].EBS.Encrypted)).To(BeTrue())
+	})
+	It("Should update EC2NodeClass status with the default Block Device Mappings of Bottlerocket", func() {
+		nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyBottlerocket
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(lo.Map(nodeClass.Status.BlockDeviceMappings, func(b *v1beta1.BlockDeviceMapping, _ int) string {
+			return aws.StringValue(b.DeviceName) + "=" + b.EBS.VolumeSize.String()
+		})).To(Equal([]string{"/dev/xvda=4Gi", "/dev/xvdb=20Gi"}))
+		for _, blockDeviceMapping := range nodeClass.Status.BlockDeviceMappings {
+			Expect(aws.BoolValue(blockDeviceMapping.EBS.Encrypted)).To(BeTrue())
+		}
+	})
+	It("Should update EC2NodeClass status with the Block Device Mappings of the EC2NodeClass", func() {
+		nodeClass.Spec.BlockDeviceMappings = []*v1beta1.BlockDeviceMapping{
+			{
+				DeviceName: aws.String("/dev/xvdb"),
+				EBS: &v1beta1.BlockDevice{
+					VolumeSize: lo.ToPtr(resource.MustParse("100Gi")),
+					VolumeType: aws.String(ec2.VolumeTypeIo2),
+					IOPS:       aws.Int64(10_000),
+					Encrypted:  aws.Bool(true),
+					KMSKeyID:   aws.String("arn:aws:kms:us-west-2:111122223333:key/test"),
+				},
+				RootVolume: true,
+			},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.BlockDeviceMappings).To(HaveLen(1))
+		blockDeviceMapping := nodeClass.Status.BlockDeviceMappings[0]
+		Expect(aws.StringValue(blockDeviceMapping.DeviceName)).To(Equal("/dev/xvdb"))
+		Expect(blockDeviceMapping.RootVolume).To(BeTrue())
+		Expect(blockDeviceMapping.EBS.VolumeSize.String()).To(Equal("100Gi"))
+		Expect(aws.StringValue(blockDeviceMapping.EBS.VolumeType)).To(Equal(ec2.VolumeTypeIo2))
+		Expect(aws.Int64Value(blockDeviceMapping.EBS.IOPS)).To(BeNumerically("==", 10_000))
+		Expect(aws.BoolValue(blockDeviceMapping.EBS.Encrypted)).To(BeTrue())
+		Expect(aws.StringValue(blockDeviceMapping.EBS.KMSKeyID)).To(Equal("arn:aws:kms:us-west-2:111122223333:key/test"))
+	})
+	It("Should not set Block Device Mappings in the EC2NodeClass status for the Custom AMI family", func() {
+		nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyCustom
+		nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}}}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.BlockDeviceMappings).To(BeNil())
+	})
+	Context("Root Volume Size", func() {
+		BeforeEach(func() {
+			awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{
+				Images: []*ec2.Image{
+					{
+						Name:           aws.String("test-ami-1"),
+						ImageId:        aws.String("ami-test1"),
+						CreationDate:   aws.String(time.Now().Format(time.RFC3339)),
+						Architecture:   aws.String("x86_64"),
+						RootDeviceName: aws.String("/dev/xvda"),
+						BlockDeviceMappings: []*ec2.BlockDeviceMapping{
+							{DeviceName: aws.String("/dev/xvda"), Ebs: &ec2.EbsBlockDevice{VolumeSize: aws.Int64(50)}},
+						},
+					},
+				},
+			})
+		})
+		It("Should set RootVolumeSizeSufficient to false when the root volume is smaller than the AMI snapshot", func() {
+			nodeClass.Spec.BlockDeviceMappings = []*v1beta1.BlockDeviceMapping{
+				{
+					DeviceName: aws.String("/dev/xvda"),
+					EBS:        &v1beta1.BlockDevice{VolumeSize: lo.ToPtr(resource.MustParse("20Gi"))},
+				},
+			}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeRootVolumeSizeSufficient)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("RootVolumeSizeTooSmall"))
+			Expect(condition.Message).To(ContainSubstring("20Gi of /dev/xvda is smaller than the 50Gi root snapshot of ami-test1"))
+			// The root volume size doesn't prevent launches, so the EC2NodeClass is still ready
+			Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+		})
+		It("Should set RootVolumeSizeSufficient to true when the root volume is at least the size of the AMI snapshot", func() {
+			nodeClass.Spec.BlockDeviceMappings = []*v1beta1.BlockDeviceMapping{
+				{
+					DeviceName: aws.String("/dev/xvda"),
+					EBS:        &v1beta1.BlockDevice{VolumeSize: lo.ToPtr(resource.MustParse("50Gi"))},
+				},
+				{
+					DeviceName: aws.String("/dev/xvdb"),
+					EBS:        &v1beta1.BlockDevice{VolumeSize: lo.ToPtr(resource.MustParse("10Gi"))},
+				},
+			}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeRootVolumeSizeSufficient).IsTrue()).To(BeTrue())
+		})
+		It("Should not set RootVolumeSizeSufficient when the EC2NodeClass doesn't specify block device mappings", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeRootVolumeSizeSufficient)).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,156 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/awslabs/operatorpkg/status"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Capacity Reservation Status Controller", func() {
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1beta1.EC2NodeClass{
+			Spec: v1beta1.EC2NodeClassSpec{
+				SubnetSelectorTerms: []v1beta1.SubnetSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				SecurityGroupSelectorTerms: []v1beta1.SecurityGroupSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				AMISelectorTerms: []v1beta1.AMISelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				CapacityReservationSelectorTerms: []v1beta1.CapacityReservationSelectorTerm{
+					{
+						Tags: map[string]string{"foo": "bar"},
+					},
+				},
+			},
+		})
+	})
+	It("Should update EC2NodeClass status for Capacity Reservations", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityReservations).To(Equal([]v1beta1.CapacityReservation{
+			{
+				ID:                     "cr-test1",
+				InstanceType:           "m5.large",
+				Zone:                   "test-zone-1a",
+				AvailableInstanceCount: 5,
+			},
+			{
+				ID:                     "cr-test2",
+				InstanceType:           "m5.xlarge",
+				Zone:                   "test-zone-1b",
+				AvailableInstanceCount: 2,
+			},
+		}))
+		Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeCapacityReservationsReady).IsTrue()).To(BeTrue())
+	})
+	It("Should resolve a valid selectors for Capacity Reservations by ids", func() {
+		nodeClass.Spec.CapacityReservationSelectorTerms = []v1beta1.CapacityReservationSelectorTerm{
+			{
+				ID: "cr-test2",
+			},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityReservations).To(Equal([]v1beta1.CapacityReservation{
+			{
+				ID:                     "cr-test2",
+				InstanceType:           "m5.xlarge",
+				Zone:                   "test-zone-1b",
+				AvailableInstanceCount: 2,
+			},
+		}))
+	})
+	It("Should not include Capacity Reservations that aren't active", func() {
+		nodeClass.Spec.CapacityReservationSelectorTerms = []v1beta1.CapacityReservationSelectorTerm{
+			{
+				Tags: map[string]string{"TestTag": "*"},
+			},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityReservations).To(BeNil())
+	})
+	It("Should set the CapacityReservationsReady condition to false without affecting readiness when no Capacity Reservations match", func() {
+		nodeClass.Spec.CapacityReservationSelectorTerms = []v1beta1.CapacityReservationSelectorTerm{
+			{
+				Tags: map[string]string{"foo": "invalid"},
+			},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityReservations).To(BeNil())
+		Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeCapacityReservationsReady).IsFalse()).To(BeTrue())
+		Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeCapacityReservationsReady).Reason).To(Equal("CapacityReservationsNotFound"))
+		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+	})
+	It("Should clear the Capacity Reservations status when the selector is removed", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityReservations).To(HaveLen(2))
+
+		nodeClass.Spec.CapacityReservationSelectorTerms = nil
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityReservations).To(BeNil())
+		Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeCapacityReservationsReady)).To(BeNil())
+	})
+	It("Should use the available instance count reported for Capacity Reservations", func() {
+		awsEnv.EC2API.DescribeCapacityReservationsOutput.Set(&ec2.DescribeCapacityReservationsOutput{CapacityReservations: []*ec2.CapacityReservation{
+			{
+				CapacityReservationId:  aws.String("cr-test1"),
+				InstanceType:           aws.String("m5.large"),
+				AvailabilityZone:       aws.String("test-zone-1a"),
+				AvailableInstanceCount: aws.Int64(1),
+				State:                  aws.String(ec2.CapacityReservationStateActive),
+				Tags:                   []*ec2.Tag{{Key: aws.String("foo"), Value: aws.String("bar")}},
+			},
+		}})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityReservations).To(Equal([]v1beta1.CapacityReservation{
+			{
+				ID:                     "cr-test1",
+				InstanceType:           "m5.large",
+				Zone:                   "test-zone-1a",
+				AvailableInstanceCount: 1,
+			},
+		}))
+	})
+})
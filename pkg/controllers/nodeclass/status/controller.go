@@ -32,11 +32,8 @@ import (
 	"github.com/awslabs/operatorpkg/reasonable"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 )
 
 type nodeClassStatusReconciler interface {
@@ -46,23 +43,34 @@ type nodeClassStatusReconciler interface {
 type Controller struct {
 	kubeClient client.Client
 
-	ami             *AMI
-	instanceprofile *InstanceProfile
-	subnet          *Subnet
-	securitygroup   *SecurityGroup
-	readiness       *Readiness //TODO : Remove this when we have sub status conditions
+	ami                 *AMI
+	instanceprofile     *InstanceProfile
+	subnet              *Subnet
+	securitygroup       *SecurityGroup
+	capacityreservation *CapacityReservation
+	blockdevicemapping  *BlockDeviceMapping
+	maxhourlyprice      *MaxHourlyPrice
+	cpuoptions          *CPUOptions
+	trustedcabundle     *TrustedCABundle
+	vpc                 *VPC
+	readiness           *Readiness //TODO : Remove this when we have sub status conditions
 }
 
-func NewController(kubeClient client.Client, subnetProvider subnet.Provider, securityGroupProvider securitygroup.Provider,
-	amiProvider amifamily.Provider, instanceProfileProvider instanceprofile.Provider, launchTemplateProvider launchtemplate.Provider) *Controller {
+func NewController(kubeClient client.Client, accountProvider account.Provider, launchTemplateProvider launchtemplate.Provider) *Controller {
 	return &Controller{
 		kubeClient: kubeClient,
 
-		ami:             &AMI{amiProvider: amiProvider},
-		subnet:          &Subnet{subnetProvider: subnetProvider},
-		securitygroup:   &SecurityGroup{securityGroupProvider: securityGroupProvider},
-		instanceprofile: &InstanceProfile{instanceProfileProvider: instanceProfileProvider},
-		readiness:       &Readiness{launchTemplateProvider: launchTemplateProvider},
+		ami:                 &AMI{accountProvider: accountProvider},
+		subnet:              &Subnet{accountProvider: accountProvider},
+		securitygroup:       &SecurityGroup{accountProvider: accountProvider},
+		capacityreservation: &CapacityReservation{accountProvider: accountProvider},
+		blockdevicemapping:  &BlockDeviceMapping{},
+		maxhourlyprice:      &MaxHourlyPrice{accountProvider: accountProvider},
+		cpuoptions:          &CPUOptions{accountProvider: accountProvider},
+		trustedcabundle:     &TrustedCABundle{},
+		vpc:                 &VPC{},
+		instanceprofile:     &InstanceProfile{accountProvider: accountProvider},
+		readiness:           &Readiness{launchTemplateProvider: launchTemplateProvider},
 	}
 }
 
@@ -80,17 +88,40 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClass *v1beta1.EC2NodeCl
 
 	var results []reconcile.Result
 	var errs error
+	// unresolved tracks the resources that failed to resolve in this reconcile, so that readiness doesn't rely on
+	// status that was resolved by a previous reconcile, e.g. before the selectors were changed
+	var unresolved []string
+	for _, resolver := range []struct {
+		resource   string
+		reconciler nodeClassStatusReconciler
+	}{
+		{"AMIs", c.ami},
+		{"subnets", c.subnet},
+		{"security groups", c.securitygroup},
+		{"instance profile", c.instanceprofile},
+	} {
+		res, err := resolver.reconciler.Reconcile(ctx, nodeClass)
+		if err != nil {
+			unresolved = append(unresolved, resolver.resource)
+		}
+		errs = multierr.Append(errs, err)
+		results = append(results, res)
+	}
 	for _, reconciler := range []nodeClassStatusReconciler{
-		c.ami,
-		c.subnet,
-		c.securitygroup,
-		c.instanceprofile,
-		c.readiness,
+		c.capacityreservation,
+		c.blockdevicemapping,
+		c.maxhourlyprice,
+		c.cpuoptions,
+		c.trustedcabundle,
+		c.vpc,
 	} {
 		res, err := reconciler.Reconcile(ctx, nodeClass)
 		errs = multierr.Append(errs, err)
 		results = append(results, res)
 	}
+	res, err := c.readiness.Reconcile(ctx, nodeClass, unresolved)
+	errs = multierr.Append(errs, err)
+	results = append(results, res)
 
 	if !equality.Semantic.DeepEqual(stored, nodeClass) {
 		if err := c.kubeClient.Status().Patch(ctx, nodeClass, client.MergeFrom(stored)); err != nil {
@@ -0,0 +1,67 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
+)
+
+type CPUOptions struct {
+	accountProvider account.Provider
+}
+
+func (c *CPUOptions) Reconcile(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (reconcile.Result, error) {
+	options, ok := nodeClass.CPUOptions()
+	if !ok {
+		_ = nodeClass.StatusConditions().Clear(v1beta1.ConditionTypeCPUOptionsSupported)
+		return reconcile.Result{}, nil
+	}
+	// Instance types can't be listed until the subnets of the nodeclass are resolved, and a condition from before the
+	// subnets stopped resolving would be stale
+	if len(nodeClass.Status.Subnets) == 0 {
+		_ = nodeClass.StatusConditions().Clear(v1beta1.ConditionTypeCPUOptionsSupported)
+		return reconcile.Result{}, nil
+	}
+	// Instance types that don't support the cpu options are filtered out, so that they're never launched
+	instanceTypes, err := c.accountProvider.Get(ctx, nodeClass).InstanceTypeProvider.List(ctx, nil, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting instance types, %w", err)
+	}
+	if len(instanceTypes) == 0 {
+		nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeCPUOptionsSupported, "NoInstanceTypesSupportCPUOptions", fmt.Sprintf("No instance types support %s", describeCPUOptions(options)))
+		return reconcile.Result{}, nil
+	}
+	nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeCPUOptionsSupported)
+	return reconcile.Result{}, nil
+}
+
+func describeCPUOptions(options v1beta1.CPUOptions) string {
+	var parts []string
+	if options.CoreCount != nil {
+		parts = append(parts, fmt.Sprintf("%d cores", lo.FromPtr(options.CoreCount)))
+	}
+	if options.ThreadsPerCore != nil {
+		parts = append(parts, fmt.Sprintf("%d threads per core", lo.FromPtr(options.ThreadsPerCore)))
+	}
+	return strings.Join(parts, " with ")
+}
@@ -18,26 +18,55 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
 )
 
 type InstanceProfile struct {
-	instanceProfileProvider instanceprofile.Provider
+	accountProvider account.Provider
 }
 
 func (ip *InstanceProfile) Reconcile(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (reconcile.Result, error) {
 	if nodeClass.Spec.Role != "" {
-		name, err := ip.instanceProfileProvider.Create(ctx, nodeClass)
+		instanceProfileProvider := ip.accountProvider.Get(ctx, nodeClass).InstanceProfileProvider
+		name, err := instanceProfileProvider.Create(ctx, nodeClass)
 		if err != nil {
 			return reconcile.Result{}, fmt.Errorf("creating instance profile, %w", err)
 		}
+		// Verify the role of the instance profile, since Create caches the instance profile and wouldn't observe the role
+		// being changed out-of-band until the cache expires. An empty role is unknown rather than drifted, since IAM may
+		// not report the role of an instance profile that was just created yet.
+		role, err := instanceProfileProvider.Role(ctx, nodeClass)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("getting instance profile role, %w", err)
+		}
+		if role != "" && role != nodeClass.Spec.Role {
+			instanceProfileRoleDrifted.With(prometheus.Labels{nodeClassLabel: nodeClass.Name}).Inc()
+			log.FromContext(ctx).WithValues("instance-profile", name, "role", role, "expected-role", nodeClass.Spec.Role).Info("instance profile role drifted, reassigning role")
+			if _, err = instanceProfileProvider.Create(ctx, nodeClass); err != nil {
+				nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeInstanceProfileNotDrifted, "InstanceProfileDrifted", fmt.Sprintf("Instance profile %s has role %q instead of %q", name, role, nodeClass.Spec.Role))
+				return reconcile.Result{}, fmt.Errorf("reassigning role to instance profile, %w", err)
+			}
+			role = nodeClass.Spec.Role
+		}
+		nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeInstanceProfileNotDrifted)
 		nodeClass.Status.InstanceProfile = name
+		nodeClass.Status.Role = role
 	} else {
+		// Karpenter doesn't know which role is expected for an unmanaged instance profile, but still reports the role
+		// that's attached to it
+		role, err := ip.accountProvider.Get(ctx, nodeClass).InstanceProfileProvider.Role(ctx, nodeClass)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("getting instance profile role, %w", err)
+		}
+		_ = nodeClass.StatusConditions().Clear(v1beta1.ConditionTypeInstanceProfileNotDrifted)
 		nodeClass.Status.InstanceProfile = lo.FromPtr(nodeClass.Spec.InstanceProfile)
+		nodeClass.Status.Role = role
 	}
 
 	return reconcile.Result{}, nil
@@ -15,10 +15,14 @@ limitations under the License.
 package status_test
 
 import (
+	"fmt"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/awslabs/operatorpkg/status"
 	"github.com/samber/lo"
 
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 
@@ -43,6 +47,7 @@ var _ = Describe("NodeClass InstanceProfile Status Controller", func() {
 
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.InstanceProfile).To(Equal(profileName))
+		Expect(nodeClass.Status.Role).To(Equal("test-role"))
 	})
 	It("should add the role to the instance profile when it exists without a role", func() {
 		awsEnv.IAMAPI.InstanceProfiles = map[string]*iam.InstanceProfile{
@@ -86,6 +91,7 @@ var _ = Describe("NodeClass InstanceProfile Status Controller", func() {
 
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.InstanceProfile).To(Equal(profileName))
+		Expect(nodeClass.Status.Role).To(Equal("test-role"))
 	})
 	It("should not call CreateInstanceProfile or AddRoleToInstanceProfile when instance profile exists with correct role", func() {
 		awsEnv.IAMAPI.InstanceProfiles = map[string]*iam.InstanceProfile{
@@ -112,6 +118,13 @@ var _ = Describe("NodeClass InstanceProfile Status Controller", func() {
 		Expect(awsEnv.IAMAPI.AddRoleToInstanceProfileBehavior.Calls()).To(BeZero())
 	})
 	It("should resolve the specified instance profile into the status when using instanceProfile field", func() {
+		awsEnv.IAMAPI.InstanceProfiles = map[string]*iam.InstanceProfile{
+			"test-instance-profile": {
+				InstanceProfileId:   aws.String(fake.InstanceProfileID()),
+				InstanceProfileName: aws.String("test-instance-profile"),
+				Roles:               []*iam.Role{{RoleName: aws.String("custom-role")}},
+			},
+		}
 		nodeClass.Spec.Role = ""
 		nodeClass.Spec.InstanceProfile = lo.ToPtr("test-instance-profile")
 		ExpectApplied(ctx, env.Client, nodeClass)
@@ -119,8 +132,42 @@ var _ = Describe("NodeClass InstanceProfile Status Controller", func() {
 
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.InstanceProfile).To(Equal(lo.FromPtr(nodeClass.Spec.InstanceProfile)))
+		Expect(nodeClass.Status.Role).To(Equal("custom-role"))
 	})
-	It("should not call the the IAM API when specifying an instance profile", func() {
+	It("should resolve an empty role when the specified instance profile doesn't have a role", func() {
+		awsEnv.IAMAPI.InstanceProfiles = map[string]*iam.InstanceProfile{
+			"test-instance-profile": {
+				InstanceProfileId:   aws.String(fake.InstanceProfileID()),
+				InstanceProfileName: aws.String("test-instance-profile"),
+			},
+		}
+		nodeClass.Spec.Role = ""
+		nodeClass.Spec.InstanceProfile = lo.ToPtr("test-instance-profile")
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Role).To(BeEmpty())
+	})
+	It("should not read the role back from IAM in the reconcile that assigned it", func() {
+		nodeClass.Spec.Role = "test-role"
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+
+		// The only call is the one that checked whether the instance profile exists before it was created
+		Expect(awsEnv.IAMAPI.GetInstanceProfileBehavior.Calls()).To(Equal(1))
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeInstanceProfileNotDrifted).IsTrue()).To(BeTrue())
+	})
+	It("should cache the role of the instance profile between reconciles", func() {
+		nodeClass.Spec.Role = "test-role"
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+
+		Expect(awsEnv.IAMAPI.GetInstanceProfileBehavior.Calls()).To(Equal(1))
+	})
+	It("should not create or modify the instance profile when specifying an instance profile", func() {
 		nodeClass.Spec.Role = ""
 		nodeClass.Spec.InstanceProfile = lo.ToPtr("test-instance-profile")
 		ExpectApplied(ctx, env.Client, nodeClass)
@@ -129,4 +176,72 @@ var _ = Describe("NodeClass InstanceProfile Status Controller", func() {
 		Expect(awsEnv.IAMAPI.CreateInstanceProfileBehavior.Calls()).To(BeZero())
 		Expect(awsEnv.IAMAPI.AddRoleToInstanceProfileBehavior.Calls()).To(BeZero())
 	})
+	Context("Drift", func() {
+		BeforeEach(func() {
+			nodeClass.Spec.Role = "test-role"
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeInstanceProfileNotDrifted).IsTrue()).To(BeTrue())
+
+			// The role of the instance profile is changed out-of-band after Karpenter cached the instance profile, and is
+			// observed once the cached role expires
+			awsEnv.IAMAPI.InstanceProfiles[profileName].Roles = []*iam.Role{{RoleName: aws.String("other-role")}}
+			awsEnv.InstanceProfileRoleCache.Flush()
+		})
+		It("should reassign the role when the instance profile has a different role", func() {
+			before := 0.0
+			if metric, ok := FindMetricWithLabelValues("karpenter_nodeclass_instance_profile_role_drifted", map[string]string{"nodeclass": nodeClass.Name}); ok {
+				before = metric.GetCounter().GetValue()
+			}
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+
+			Expect(awsEnv.IAMAPI.InstanceProfiles[profileName].Roles).To(HaveLen(1))
+			Expect(*awsEnv.IAMAPI.InstanceProfiles[profileName].Roles[0].RoleName).To(Equal("test-role"))
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeInstanceProfileNotDrifted).IsTrue()).To(BeTrue())
+			Expect(nodeClass.Status.Role).To(Equal("test-role"))
+
+			metric, ok := FindMetricWithLabelValues("karpenter_nodeclass_instance_profile_role_drifted", map[string]string{"nodeclass": nodeClass.Name})
+			Expect(ok).To(BeTrue())
+			Expect(metric.GetCounter().GetValue()).To(BeNumerically("==", before+1))
+		})
+		It("should not treat an instance profile without a role as drifted", func() {
+			awsEnv.IAMAPI.InstanceProfiles[profileName].Roles = nil
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+
+			Expect(awsEnv.IAMAPI.AddRoleToInstanceProfileBehavior.Calls()).To(Equal(1))
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeInstanceProfileNotDrifted).IsTrue()).To(BeTrue())
+			Expect(nodeClass.Status.Role).To(BeEmpty())
+		})
+		It("should not treat an instance profile that can't be found as drifted", func() {
+			delete(awsEnv.IAMAPI.InstanceProfiles, profileName)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+
+			Expect(awsEnv.IAMAPI.CreateInstanceProfileBehavior.Calls()).To(Equal(1))
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeInstanceProfileNotDrifted).IsTrue()).To(BeTrue())
+		})
+		It("should set the condition to false when the role can't be reassigned", func() {
+			awsEnv.IAMAPI.AddRoleToInstanceProfileBehavior.Error.Set(fmt.Errorf("failed"))
+			_ = ExpectObjectReconcileFailed(ctx, env.Client, statusController, nodeClass)
+
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeInstanceProfileNotDrifted)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("InstanceProfileDrifted"))
+			Expect(condition.Message).To(ContainSubstring(`has role "other-role" instead of "test-role"`))
+			Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsFalse()).To(BeTrue())
+		})
+		It("should not set the condition when specifying an instance profile", func() {
+			nodeClass.Spec.Role = ""
+			nodeClass.Spec.InstanceProfile = lo.ToPtr("test-instance-profile")
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeInstanceProfileNotDrifted)).To(BeNil())
+		})
+	})
 })
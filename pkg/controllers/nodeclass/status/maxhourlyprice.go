@@ -0,0 +1,56 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
+)
+
+type MaxHourlyPrice struct {
+	accountProvider account.Provider
+}
+
+func (m *MaxHourlyPrice) Reconcile(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (reconcile.Result, error) {
+	maxPrice, ok := nodeClass.MaxHourlyPrice()
+	if !ok {
+		_ = nodeClass.StatusConditions().Clear(v1beta1.ConditionTypeOfferingsWithinMaxHourlyPrice)
+		return reconcile.Result{}, nil
+	}
+	// Offerings are only resolved for the zones of the nodeclass's subnets, so there's nothing to check until they are
+	if len(nodeClass.Status.Subnets) == 0 {
+		_ = nodeClass.StatusConditions().Clear(v1beta1.ConditionTypeOfferingsWithinMaxHourlyPrice)
+		return reconcile.Result{}, nil
+	}
+	instanceTypes, err := m.accountProvider.Get(ctx, nodeClass).InstanceTypeProvider.List(ctx, nil, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting instance types, %w", err)
+	}
+	// Prices are refreshed periodically, so offerings can move across the max price without the nodeclass changing
+	if !lo.ContainsBy(instanceTypes, func(it *cloudprovider.InstanceType) bool { return len(it.Offerings.Available()) > 0 }) {
+		nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeOfferingsWithinMaxHourlyPrice, "NoOfferingsWithinMaxHourlyPrice", fmt.Sprintf("No available offerings are priced at or below the max hourly price of %g", maxPrice))
+		return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+	}
+	nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeOfferingsWithinMaxHourlyPrice)
+	return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+}
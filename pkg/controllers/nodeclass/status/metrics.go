@@ -0,0 +1,49 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const nodeClassLabel = "nodeclass"
+
+var (
+	instanceProfileRoleDrifted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "nodeclass",
+			Name:      "instance_profile_role_drifted",
+			Help:      "Count of times the managed instance profile of an EC2NodeClass was found with a different role than expected. Labeled by EC2NodeClass.",
+		},
+		[]string{nodeClassLabel},
+	)
+	amiSelectorFallbackActive = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "nodeclass",
+			Name:      "ami_selector_fallback_active",
+			Help:      "Whether an EC2NodeClass uses the default AMIs of its amiFamily because its amiSelectorTerms don't match any AMIs. Labeled by EC2NodeClass.",
+		},
+		[]string{nodeClassLabel},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(instanceProfileRoleDrifted, amiSelectorFallbackActive)
+}
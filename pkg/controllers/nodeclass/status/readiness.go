@@ -17,6 +17,7 @@ package status
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/awslabs/operatorpkg/status"
 	"github.com/samber/lo"
@@ -32,7 +33,13 @@ type Readiness struct {
 	launchTemplateProvider launchtemplate.Provider
 }
 
-func (n Readiness) Reconcile(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (reconcile.Result, error) {
+// Reconcile sets the Ready condition of the nodeClass. The nodeClass is only ready when none of its resources failed to
+// resolve in the current reconcile, and all of them resolved to at least one value.
+func (n Readiness) Reconcile(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, unresolved []string) (reconcile.Result, error) {
+	if len(unresolved) > 0 {
+		nodeClass.StatusConditions().SetFalse(status.ConditionReady, "NodeClassNotReady", fmt.Sprintf("Failed to resolve %s", strings.Join(unresolved, ", ")))
+		return reconcile.Result{}, nil
+	}
 	if len(nodeClass.Status.AMIs) == 0 {
 		nodeClass.StatusConditions().SetFalse(status.ConditionReady, "NodeClassNotReady", "Failed to resolve AMIs")
 		return reconcile.Result{}, nil
@@ -49,6 +56,14 @@ func (n Readiness) Reconcile(ctx context.Context, nodeClass *v1beta1.EC2NodeClas
 		nodeClass.StatusConditions().SetFalse(status.ConditionReady, "NodeClassNotReady", "Failed to resolve instance profile")
 		return reconcile.Result{}, nil
 	}
+	if condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetVPCsHaveSecurityGroups); condition != nil && condition.Reason == "NoSubnetVPCsHaveSecurityGroups" {
+		nodeClass.StatusConditions().SetFalse(status.ConditionReady, "NodeClassNotReady", "No subnets are in the VPCs of the security groups")
+		return reconcile.Result{}, nil
+	}
+	if condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeTrustedCABundleValid); condition != nil && condition.IsFalse() {
+		nodeClass.StatusConditions().SetFalse(status.ConditionReady, "NodeClassNotReady", "Invalid trusted CA bundle")
+		return reconcile.Result{}, nil
+	}
 	// A NodeClass that uses AL2023 requires the cluster CIDR for launching nodes.
 	// To allow Karpenter to be used for Non-EKS clusters, resolving the Cluster CIDR
 	// will not be done at startup but instead in a reconcile loop.
@@ -18,27 +18,30 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/samber/lo"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
 )
 
 type Subnet struct {
-	subnetProvider subnet.Provider
+	accountProvider account.Provider
 }
 
 func (s *Subnet) Reconcile(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (reconcile.Result, error) {
-	subnets, err := s.subnetProvider.List(ctx, nodeClass)
+	subnets, err := s.accountProvider.Get(ctx, nodeClass).SubnetProvider.List(ctx, nodeClass)
 	if err != nil {
 		return reconcile.Result{}, fmt.Errorf("getting subnets, %w", err)
 	}
 	if len(subnets) == 0 {
 		nodeClass.Status.Subnets = nil
+		_ = nodeClass.StatusConditions().Clear(v1beta1.ConditionTypeSubnetsMatchNetworkMode)
 		return reconcile.Result{}, nil
 	}
 	sort.Slice(subnets, func(i, j int) bool {
@@ -49,10 +52,39 @@ func (s *Subnet) Reconcile(ctx context.Context, nodeClass *v1beta1.EC2NodeClass)
 	})
 	nodeClass.Status.Subnets = lo.Map(subnets, func(ec2subnet *ec2.Subnet, _ int) v1beta1.Subnet {
 		return v1beta1.Subnet{
-			ID:   *ec2subnet.SubnetId,
-			Zone: *ec2subnet.AvailabilityZone,
+			ID:     *ec2subnet.SubnetId,
+			Zone:   *ec2subnet.AvailabilityZone,
+			ZoneID: aws.StringValue(ec2subnet.AvailabilityZoneId),
+			VPCID:  aws.StringValue(ec2subnet.VpcId),
 		}
 	})
+	s.reconcileNetworkMode(nodeClass, subnets)
 
 	return reconcile.Result{RequeueAfter: time.Minute}, nil
 }
+
+// reconcileNetworkMode surfaces the subnets whose public IP assignment contradicts the networkMode of the nodeClass.
+// Subnets only decide whether instances get a public IP when associatePublicIPAddress isn't set on the nodeClass.
+func (s *Subnet) reconcileNetworkMode(nodeClass *v1beta1.EC2NodeClass, subnets []*ec2.Subnet) {
+	if nodeClass.Spec.NetworkMode == nil {
+		_ = nodeClass.StatusConditions().Clear(v1beta1.ConditionTypeSubnetsMatchNetworkMode)
+		return
+	}
+	public := *nodeClass.Spec.NetworkMode == v1beta1.NetworkModePublic
+	mismatched := lo.FilterMap(subnets, func(subnet *ec2.Subnet, _ int) (string, bool) {
+		assignsPublicIP := lo.FromPtrOr(nodeClass.Spec.AssociatePublicIPAddress, aws.BoolValue(subnet.MapPublicIpOnLaunch))
+		return aws.StringValue(subnet.SubnetId), assignsPublicIP != public
+	})
+	if len(mismatched) == 0 {
+		nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeSubnetsMatchNetworkMode)
+		return
+	}
+	sort.Strings(mismatched)
+	if public {
+		nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeSubnetsMatchNetworkMode, "SubnetsDontAssignPublicIPs",
+			fmt.Sprintf("Subnets %s don't assign public IP addresses, but networkMode is %s", strings.Join(mismatched, ", "), v1beta1.NetworkModePublic))
+		return
+	}
+	nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeSubnetsMatchNetworkMode, "SubnetsAssignPublicIPs",
+		fmt.Sprintf("Subnets %s assign public IP addresses, but networkMode is %s", strings.Join(mismatched, ", "), v1beta1.NetworkModePrivate))
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
@@ -55,44 +56,73 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 			{
-				ID:   "subnet-test1",
-				Zone: "test-zone-1a",
+				ID:     "subnet-test1",
+				Zone:   "test-zone-1a",
+				ZoneID: "testzone1a",
 			},
 			{
-				ID:   "subnet-test2",
-				Zone: "test-zone-1b",
+				ID:     "subnet-test2",
+				Zone:   "test-zone-1b",
+				ZoneID: "testzone1b",
 			},
 			{
-				ID:   "subnet-test3",
-				Zone: "test-zone-1c",
+				ID:     "subnet-test3",
+				Zone:   "test-zone-1c",
+				ZoneID: "testzone1c",
 			},
 			{
-				ID:   "subnet-test4",
-				Zone: "test-zone-1a-local",
+				ID:     "subnet-test4",
+				Zone:   "test-zone-1a-local",
+				ZoneID: "testzone1alocal",
 			},
 		}))
 	})
 	It("Should have the correct ordering for the Subnets", func() {
 		awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
-			{SubnetId: aws.String("subnet-test1"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int64(20)},
-			{SubnetId: aws.String("subnet-test2"), AvailabilityZone: aws.String("test-zone-1b"), AvailableIpAddressCount: aws.Int64(100)},
-			{SubnetId: aws.String("subnet-test3"), AvailabilityZone: aws.String("test-zone-1c"), AvailableIpAddressCount: aws.Int64(50)},
+			{SubnetId: aws.String("subnet-test1"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("testzone1a"), AvailableIpAddressCount: aws.Int64(20)},
+			{SubnetId: aws.String("subnet-test2"), AvailabilityZone: aws.String("test-zone-1b"), AvailabilityZoneId: aws.String("testzone1b"), AvailableIpAddressCount: aws.Int64(100)},
+			{SubnetId: aws.String("subnet-test3"), AvailabilityZone: aws.String("test-zone-1c"), AvailabilityZoneId: aws.String("testzone1c"), AvailableIpAddressCount: aws.Int64(50)},
 		}})
 		ExpectApplied(ctx, env.Client, nodeClass)
 		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 			{
-				ID:   "subnet-test2",
-				Zone: "test-zone-1b",
+				ID:     "subnet-test2",
+				Zone:   "test-zone-1b",
+				ZoneID: "testzone1b",
 			},
 			{
-				ID:   "subnet-test3",
-				Zone: "test-zone-1c",
+				ID:     "subnet-test3",
+				Zone:   "test-zone-1c",
+				ZoneID: "testzone1c",
 			},
 			{
-				ID:   "subnet-test1",
-				Zone: "test-zone-1a",
+				ID:     "subnet-test1",
+				Zone:   "test-zone-1a",
+				ZoneID: "testzone1a",
+			},
+		}))
+	})
+	It("Should resolve Subnets in the account of the assumed role", func() {
+		nodeClass.Spec.AssumeRoleARN = aws.String("arn:aws:iam::123456789012:role/karpenter-nodes")
+		awsEnv.AccountEC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
+			{SubnetId: aws.String("subnet-account1"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("testzone1b"), AvailableIpAddressCount: aws.Int64(100)},
+			{SubnetId: aws.String("subnet-account2"), AvailabilityZone: aws.String("test-zone-1b"), AvailabilityZoneId: aws.String("testzone1a"), AvailableIpAddressCount: aws.Int64(50)},
+		}})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
+			{
+				ID:     "subnet-account1",
+				Zone:   "test-zone-1a",
+				ZoneID: "testzone1b",
+			},
+			{
+				ID:     "subnet-account2",
+				Zone:   "test-zone-1b",
+				ZoneID: "testzone1a",
 			},
 		}))
 	})
@@ -110,12 +140,14 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 			{
-				ID:   "subnet-test1",
-				Zone: "test-zone-1a",
+				ID:     "subnet-test1",
+				Zone:   "test-zone-1a",
+				ZoneID: "testzone1a",
 			},
 			{
-				ID:   "subnet-test2",
-				Zone: "test-zone-1b",
+				ID:     "subnet-test2",
+				Zone:   "test-zone-1b",
+				ZoneID: "testzone1b",
 			},
 		}))
 	})
@@ -130,8 +162,9 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 			{
-				ID:   "subnet-test1",
-				Zone: "test-zone-1a",
+				ID:     "subnet-test1",
+				Zone:   "test-zone-1a",
+				ZoneID: "testzone1a",
 			},
 		}))
 	})
@@ -141,20 +174,24 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 			{
-				ID:   "subnet-test1",
-				Zone: "test-zone-1a",
+				ID:     "subnet-test1",
+				Zone:   "test-zone-1a",
+				ZoneID: "testzone1a",
 			},
 			{
-				ID:   "subnet-test2",
-				Zone: "test-zone-1b",
+				ID:     "subnet-test2",
+				Zone:   "test-zone-1b",
+				ZoneID: "testzone1b",
 			},
 			{
-				ID:   "subnet-test3",
-				Zone: "test-zone-1c",
+				ID:     "subnet-test3",
+				Zone:   "test-zone-1c",
+				ZoneID: "testzone1c",
 			},
 			{
-				ID:   "subnet-test4",
-				Zone: "test-zone-1a-local",
+				ID:     "subnet-test4",
+				Zone:   "test-zone-1a-local",
+				ZoneID: "testzone1alocal",
 			},
 		}))
 
@@ -175,12 +212,14 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 			{
-				ID:   "subnet-test1",
-				Zone: "test-zone-1a",
+				ID:     "subnet-test1",
+				Zone:   "test-zone-1a",
+				ZoneID: "testzone1a",
 			},
 			{
-				ID:   "subnet-test2",
-				Zone: "test-zone-1b",
+				ID:     "subnet-test2",
+				Zone:   "test-zone-1b",
+				ZoneID: "testzone1b",
 			},
 		}))
 	})
@@ -190,20 +229,24 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 			{
-				ID:   "subnet-test1",
-				Zone: "test-zone-1a",
+				ID:     "subnet-test1",
+				Zone:   "test-zone-1a",
+				ZoneID: "testzone1a",
 			},
 			{
-				ID:   "subnet-test2",
-				Zone: "test-zone-1b",
+				ID:     "subnet-test2",
+				Zone:   "test-zone-1b",
+				ZoneID: "testzone1b",
 			},
 			{
-				ID:   "subnet-test3",
-				Zone: "test-zone-1c",
+				ID:     "subnet-test3",
+				Zone:   "test-zone-1c",
+				ZoneID: "testzone1c",
 			},
 			{
-				ID:   "subnet-test4",
-				Zone: "test-zone-1a-local",
+				ID:     "subnet-test4",
+				Zone:   "test-zone-1a-local",
+				ZoneID: "testzone1alocal",
 			},
 		}))
 
@@ -217,8 +260,9 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 			{
-				ID:   "subnet-test1",
-				Zone: "test-zone-1a",
+				ID:     "subnet-test1",
+				Zone:   "test-zone-1a",
+				ZoneID: "testzone1a",
 			},
 		}))
 	})
@@ -241,20 +285,24 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 			{
-				ID:   "subnet-test1",
-				Zone: "test-zone-1a",
+				ID:     "subnet-test1",
+				Zone:   "test-zone-1a",
+				ZoneID: "testzone1a",
 			},
 			{
-				ID:   "subnet-test2",
-				Zone: "test-zone-1b",
+				ID:     "subnet-test2",
+				Zone:   "test-zone-1b",
+				ZoneID: "testzone1b",
 			},
 			{
-				ID:   "subnet-test3",
-				Zone: "test-zone-1c",
+				ID:     "subnet-test3",
+				Zone:   "test-zone-1c",
+				ZoneID: "testzone1c",
 			},
 			{
-				ID:   "subnet-test4",
-				Zone: "test-zone-1a-local",
+				ID:     "subnet-test4",
+				Zone:   "test-zone-1a-local",
+				ZoneID: "testzone1alocal",
 			},
 		}))
 
@@ -270,4 +318,63 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsFalse()).To(BeTrue())
 		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).Message).To(Equal("Failed to resolve subnets"))
 	})
+	Context("Network Mode", func() {
+		It("should not set the condition when the network mode isn't set", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetsMatchNetworkMode)).To(BeNil())
+		})
+		It("should set the condition to true when private subnets are selected for a private network mode", func() {
+			nodeClass.Spec.NetworkMode = lo.ToPtr(v1beta1.NetworkModePrivate)
+			nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{{ID: "subnet-test1"}, {ID: "subnet-test3"}}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetsMatchNetworkMode).IsTrue()).To(BeTrue())
+		})
+		It("should set the condition to false when public subnets are selected for a private network mode", func() {
+			nodeClass.Spec.NetworkMode = lo.ToPtr(v1beta1.NetworkModePrivate)
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetsMatchNetworkMode)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("SubnetsAssignPublicIPs"))
+			Expect(condition.Message).To(Equal("Subnets subnet-test2, subnet-test4 assign public IP addresses, but networkMode is Private"))
+			// The network mode doesn't affect the readiness of the nodeclass
+			Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+		})
+		It("should set the condition to false when private subnets are selected for a public network mode", func() {
+			nodeClass.Spec.NetworkMode = lo.ToPtr(v1beta1.NetworkModePublic)
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetsMatchNetworkMode)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal("SubnetsDontAssignPublicIPs"))
+			Expect(condition.Message).To(Equal("Subnets subnet-test1, subnet-test3 don't assign public IP addresses, but networkMode is Public"))
+		})
+		It("should set the condition to true when associatePublicIPAddress overrides the public subnets", func() {
+			nodeClass.Spec.NetworkMode = lo.ToPtr(v1beta1.NetworkModePrivate)
+			nodeClass.Spec.AssociatePublicIPAddress = aws.Bool(false)
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetsMatchNetworkMode).IsTrue()).To(BeTrue())
+		})
+		It("should clear the condition when the network mode is removed", func() {
+			nodeClass.Spec.NetworkMode = lo.ToPtr(v1beta1.NetworkModePrivate)
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetsMatchNetworkMode).IsFalse()).To(BeTrue())
+
+			nodeClass.Spec.NetworkMode = nil
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetsMatchNetworkMode)).To(BeNil())
+		})
+	})
 })
@@ -54,10 +54,7 @@ var _ = BeforeSuite(func() {
 
 	statusController = status.NewController(
 		env.Client,
-		awsEnv.SubnetProvider,
-		awsEnv.SecurityGroupProvider,
-		awsEnv.AMIProvider,
-		awsEnv.InstanceProfileProvider,
+		awsEnv.AccountProvider,
 		awsEnv.LaunchTemplateProvider,
 	)
 })
@@ -68,6 +65,7 @@ var _ = AfterSuite(func() {
 
 var _ = BeforeEach(func() {
 	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
 	nodeClass = test.EC2NodeClass()
 	awsEnv.Reset()
 })
@@ -0,0 +1,42 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily/bootstrap"
+)
+
+type TrustedCABundle struct{}
+
+// Reconcile validates that the trusted CA bundle of the nodeClass is a bundle of PEM encoded certificates, since it's
+// written to the trust store of nodes as is and an invalid bundle would only be noticed once nodes fail to pull images
+func (t *TrustedCABundle) Reconcile(_ context.Context, nodeClass *v1beta1.EC2NodeClass) (reconcile.Result, error) {
+	if lo.FromPtr(nodeClass.Spec.TrustedCABundle) == "" {
+		_ = nodeClass.StatusConditions().Clear(v1beta1.ConditionTypeTrustedCABundleValid)
+		return reconcile.Result{}, nil
+	}
+	if _, err := bootstrap.ParseCABundle(*nodeClass.Spec.TrustedCABundle); err != nil {
+		nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeTrustedCABundleValid, "InvalidTrustedCABundle", err.Error())
+		return reconcile.Result{}, nil
+	}
+	nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeTrustedCABundleValid)
+	return reconcile.Result{}, nil
+}
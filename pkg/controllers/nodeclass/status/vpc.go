@@ -0,0 +1,57 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+)
+
+// VPC surfaces the resolved subnets that aren't launched into, because none of the resolved security groups are in
+// their VPC
+type VPC struct{}
+
+func (v *VPC) Reconcile(_ context.Context, nodeClass *v1beta1.EC2NodeClass) (reconcile.Result, error) {
+	if len(nodeClass.Status.Subnets) == 0 || len(nodeClass.Status.SecurityGroups) == 0 {
+		_ = nodeClass.StatusConditions().Clear(v1beta1.ConditionTypeSubnetVPCsHaveSecurityGroups)
+		return reconcile.Result{}, nil
+	}
+	vpcs := sets.New(lo.Map(nodeClass.Status.SecurityGroups, func(sg v1beta1.SecurityGroup, _ int) string { return sg.VPCID })...)
+	// Security groups whose VPC isn't known can be launched with subnets of any VPC
+	mismatched := lo.FilterMap(nodeClass.Status.Subnets, func(subnet v1beta1.Subnet, _ int) (string, bool) {
+		return subnet.ID, subnet.VPCID != "" && !vpcs.Has("") && !vpcs.Has(subnet.VPCID)
+	})
+	if len(mismatched) == 0 {
+		nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeSubnetVPCsHaveSecurityGroups)
+		return reconcile.Result{}, nil
+	}
+	if len(mismatched) == len(nodeClass.Status.Subnets) {
+		nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeSubnetVPCsHaveSecurityGroups, "NoSubnetVPCsHaveSecurityGroups",
+			fmt.Sprintf("None of the subnets are in the VPCs of the security groups (%s)", strings.Join(sets.List(vpcs), ", ")))
+		return reconcile.Result{}, nil
+	}
+	sort.Strings(mismatched)
+	nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeSubnetVPCsHaveSecurityGroups, "SubnetVPCsDontHaveSecurityGroups",
+		fmt.Sprintf("Subnets %s aren't launched into, since none of the security groups are in their VPCs", strings.Join(mismatched, ", ")))
+	return reconcile.Result{}, nil
+}
@@ -0,0 +1,93 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/awslabs/operatorpkg/status"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass VPC Status Controller", func() {
+	BeforeEach(func() {
+		awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
+			{SubnetId: aws.String("subnet-test1"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int64(100), VpcId: aws.String("vpc-test1")},
+			{SubnetId: aws.String("subnet-test2"), AvailabilityZone: aws.String("test-zone-1b"), AvailableIpAddressCount: aws.Int64(100), VpcId: aws.String("vpc-test1")},
+			{SubnetId: aws.String("subnet-test3"), AvailabilityZone: aws.String("test-zone-1c"), AvailableIpAddressCount: aws.Int64(100), VpcId: aws.String("vpc-test2")},
+		}})
+	})
+	It("should resolve the vpcs of subnets and security groups across vpcs", func() {
+		awsEnv.EC2API.DescribeSecurityGroupsOutput.Set(&ec2.DescribeSecurityGroupsOutput{SecurityGroups: []*ec2.SecurityGroup{
+			{GroupId: aws.String("sg-test1"), GroupName: aws.String("securityGroup-test1"), VpcId: aws.String("vpc-test1")},
+			{GroupId: aws.String("sg-test2"), GroupName: aws.String("securityGroup-test2"), VpcId: aws.String("vpc-test2")},
+		}})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
+			{ID: "subnet-test1", Zone: "test-zone-1a", VPCID: "vpc-test1"},
+			{ID: "subnet-test2", Zone: "test-zone-1b", VPCID: "vpc-test1"},
+			{ID: "subnet-test3", Zone: "test-zone-1c", VPCID: "vpc-test2"},
+		}))
+		Expect(nodeClass.Status.SecurityGroups).To(Equal([]v1beta1.SecurityGroup{
+			{ID: "sg-test1", Name: "securityGroup-test1", VPCID: "vpc-test1"},
+			{ID: "sg-test2", Name: "securityGroup-test2", VPCID: "vpc-test2"},
+		}))
+		Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetVPCsHaveSecurityGroups).IsTrue()).To(BeTrue())
+		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+	})
+	It("should set the condition to true when the vpcs of the security groups aren't known", func() {
+		awsEnv.EC2API.DescribeSecurityGroupsOutput.Set(&ec2.DescribeSecurityGroupsOutput{SecurityGroups: []*ec2.SecurityGroup{
+			{GroupId: aws.String("sg-test1"), GroupName: aws.String("securityGroup-test1")},
+		}})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetVPCsHaveSecurityGroups).IsTrue()).To(BeTrue())
+	})
+	It("should set the condition to false when some subnets are in a vpc without security groups", func() {
+		awsEnv.EC2API.DescribeSecurityGroupsOutput.Set(&ec2.DescribeSecurityGroupsOutput{SecurityGroups: []*ec2.SecurityGroup{
+			{GroupId: aws.String("sg-test1"), GroupName: aws.String("securityGroup-test1"), VpcId: aws.String("vpc-test1")},
+		}})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetVPCsHaveSecurityGroups)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("SubnetVPCsDontHaveSecurityGroups"))
+		Expect(condition.Message).To(Equal("Subnets subnet-test3 aren't launched into, since none of the security groups are in their VPCs"))
+		// The nodeclass can still launch into the subnets of vpc-test1
+		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+	})
+	It("should not be ready when none of the subnets are in a vpc with security groups", func() {
+		awsEnv.EC2API.DescribeSecurityGroupsOutput.Set(&ec2.DescribeSecurityGroupsOutput{SecurityGroups: []*ec2.SecurityGroup{
+			{GroupId: aws.String("sg-test1"), GroupName: aws.String("securityGroup-test1"), VpcId: aws.String("vpc-test3")},
+		}})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetVPCsHaveSecurityGroups)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("NoSubnetVPCsHaveSecurityGroups"))
+		Expect(condition.Message).To(Equal("None of the subnets are in the VPCs of the security groups (vpc-test3)"))
+		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsFalse()).To(BeTrue())
+	})
+})
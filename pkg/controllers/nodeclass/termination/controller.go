@@ -22,8 +22,6 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
 
-	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
-
 	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/types"
@@ -43,24 +41,21 @@ import (
 	"sigs.k8s.io/karpenter/pkg/events"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
 )
 
 type Controller struct {
-	kubeClient              client.Client
-	recorder                events.Recorder
-	instanceProfileProvider instanceprofile.Provider
-	launchTemplateProvider  launchtemplate.Provider
+	kubeClient      client.Client
+	recorder        events.Recorder
+	accountProvider account.Provider
 }
 
-func NewController(kubeClient client.Client, recorder events.Recorder,
-	instanceProfileProvider instanceprofile.Provider, launchTemplateProvider launchtemplate.Provider) *Controller {
+func NewController(kubeClient client.Client, recorder events.Recorder, accountProvider account.Provider) *Controller {
 
 	return &Controller{
-		kubeClient:              kubeClient,
-		recorder:                recorder,
-		instanceProfileProvider: instanceProfileProvider,
-		launchTemplateProvider:  launchTemplateProvider,
+		kubeClient:      kubeClient,
+		recorder:        recorder,
+		accountProvider: accountProvider,
 	}
 }
 
@@ -87,13 +82,14 @@ func (c *Controller) finalize(ctx context.Context, nodeClass *v1beta1.EC2NodeCla
 		return reconcile.Result{RequeueAfter: time.Minute * 10}, nil // periodically fire the event
 	}
 	if nodeClass.Spec.Role != "" {
-		if err := c.instanceProfileProvider.Delete(ctx, nodeClass); err != nil {
+		if err := c.accountProvider.Get(ctx, nodeClass).InstanceProfileProvider.Delete(ctx, nodeClass); err != nil {
 			return reconcile.Result{}, fmt.Errorf("deleting instance profile, %w", err)
 		}
 	}
-	if err := c.launchTemplateProvider.DeleteAll(ctx, nodeClass); err != nil {
+	if err := c.accountProvider.Get(ctx, nodeClass).LaunchTemplateProvider.DeleteAll(ctx, nodeClass); err != nil {
 		return reconcile.Result{}, fmt.Errorf("deleting launch templates, %w", err)
 	}
+	c.accountProvider.Get(ctx, nodeClass).AMIProvider.DeleteAMIAges(nodeClass)
 	controllerutil.RemoveFinalizer(nodeClass, v1beta1.TerminationFinalizer)
 	if !equality.Semantic.DeepEqual(stored, nodeClass) {
 		// We call Update() here rather than Patch() because patching a list with a JSON merge patch
@@ -62,7 +62,7 @@ var _ = BeforeSuite(func() {
 	ctx = options.ToContext(ctx, test.Options())
 	awsEnv = test.NewEnvironment(ctx, env)
 
-	terminationController = termination.NewController(env.Client, events.NewRecorder(&record.FakeRecorder{}), awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+	terminationController = termination.NewController(env.Client, events.NewRecorder(&record.FakeRecorder{}), awsEnv.AccountProvider)
 })
 
 var _ = AfterSuite(func() {
@@ -154,6 +154,21 @@ var _ = Describe("NodeClass Termination", func() {
 		Expect(ok).To(BeFalse())
 		ExpectNotFound(ctx, env.Client, nodeClass)
 	})
+	It("should drop the AMI age metrics of the NodeClass when it's deleted", func() {
+		_, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		_, ok := FindMetricWithLabelValues("karpenter_ami_age_seconds", map[string]string{"nodeclass": nodeClass.Name})
+		Expect(ok).To(BeTrue())
+		controllerutil.AddFinalizer(nodeClass, v1beta1.TerminationFinalizer)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, terminationController, nodeClass)
+
+		Expect(env.Client.Delete(ctx, nodeClass)).To(Succeed())
+		ExpectObjectReconciled(ctx, env.Client, terminationController, nodeClass)
+		ExpectNotFound(ctx, env.Client, nodeClass)
+		_, ok = FindMetricWithLabelValues("karpenter_ami_age_seconds", map[string]string{"nodeclass": nodeClass.Name})
+		Expect(ok).To(BeFalse())
+	})
 	It("should succeed to delete the instance profile with no NodeClaims", func() {
 		awsEnv.IAMAPI.InstanceProfiles = map[string]*iam.InstanceProfile{
 			profileName: {
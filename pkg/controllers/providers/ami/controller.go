@@ -0,0 +1,85 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ami
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	lop "github.com/samber/lo/parallel"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
+)
+
+const (
+	// WarmTimeout bounds how long resolving the AMIs of all EC2NodeClasses at startup may take
+	WarmTimeout = time.Minute
+	// WarmInterval is how long the controller waits before warming the cache again. A singleton that returns an empty
+	// result is reconciled again right away, so the interval is what keeps warming to once at startup in practice.
+	WarmInterval = 12 * time.Hour
+)
+
+// Controller warms the AMI cache at startup by resolving the AMIs of all EC2NodeClasses, so the first launch after a
+// restart doesn't wait on SSM and EC2 to resolve them.
+type Controller struct {
+	kubeClient      client.Client
+	accountProvider account.Provider
+}
+
+func NewController(kubeClient client.Client, accountProvider account.Provider) *Controller {
+	return &Controller{
+		kubeClient:      kubeClient,
+		accountProvider: accountProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, WarmTimeout)
+	defer cancel()
+
+	nodeClassList := &v1beta1.EC2NodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing ec2nodeclasses, %w", err)
+	}
+	nodeClasses := lo.Filter(nodeClassList.Items, func(nodeClass v1beta1.EC2NodeClass, _ int) bool {
+		return nodeClass.DeletionTimestamp.IsZero()
+	})
+	warmed := lop.Map(nodeClasses, func(nodeClass v1beta1.EC2NodeClass, _ int) bool {
+		amis, err := c.accountProvider.Get(ctx, &nodeClass).AMIProvider.List(ctx, &nodeClass)
+		if err != nil {
+			log.FromContext(ctx).WithValues("ec2nodeclass", nodeClass.Name).Error(err, "failed warming ami cache")
+			return false
+		}
+		log.FromContext(ctx).WithValues("ec2nodeclass", nodeClass.Name, "amis", len(amis)).V(1).Info("warmed ami cache")
+		return true
+	})
+	log.FromContext(ctx).WithValues("warmed", lo.Count(warmed, true), "ec2nodeclasses", len(nodeClasses)).Info("warmed ami cache")
+	// The cache is warmed at startup, the nodeclass status controller keeps it warm afterwards
+	return reconcile.Result{RequeueAfter: WarmInterval}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controller.NewSingletonManagedBy(m).
+		Named("providers.ami").
+		Complete(c)
+}
@@ -0,0 +1,120 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ami_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	controllersami "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/ami"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var stop context.CancelFunc
+var env *coretest.Environment
+var awsEnv *test.Environment
+var controller *controllersami.Controller
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AMI")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	ctx, stop = context.WithCancel(ctx)
+	awsEnv = test.NewEnvironment(ctx, env)
+	controller = controllersami.NewController(env.Client, awsEnv.AccountProvider)
+})
+
+var _ = AfterSuite(func() {
+	stop()
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("AMI", func() {
+	It("should resolve the amis of all ec2nodeclasses at startup", func() {
+		nodeClasses := []*v1beta1.EC2NodeClass{test.EC2NodeClass(), test.EC2NodeClass()}
+		ExpectApplied(ctx, env.Client, nodeClasses[0], nodeClasses[1])
+
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(awsEnv.EC2API.CalledWithDescribeImagesInput.Len()).To(BeNumerically(">", 0))
+		Expect(awsEnv.EC2Cache.ItemCount()).To(BeNumerically(">", 0))
+	})
+	It("should requeue after the warm interval rather than immediately", func() {
+		ExpectApplied(ctx, env.Client, test.EC2NodeClass())
+		result := ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(result.RequeueAfter).To(Equal(controllersami.WarmInterval))
+	})
+	It("should requeue after the warm interval when warming fails", func() {
+		ExpectApplied(ctx, env.Client, test.EC2NodeClass())
+		awsEnv.SSMAPI.WantErr = fmt.Errorf("failed to resolve parameter")
+		result := ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(result.RequeueAfter).To(Equal(controllersami.WarmInterval))
+	})
+	It("should serve the amis from the cache after warming it", func() {
+		nodeClass := test.EC2NodeClass()
+		ExpectApplied(ctx, env.Client, nodeClass)
+
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		calls := awsEnv.EC2API.CalledWithDescribeImagesInput.Len()
+		Expect(calls).To(BeNumerically(">", 0))
+
+		amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(amis).ToNot(BeEmpty())
+		Expect(awsEnv.EC2API.CalledWithDescribeImagesInput.Len()).To(Equal(calls))
+	})
+	It("should not resolve any amis when there are no ec2nodeclasses", func() {
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(awsEnv.EC2API.CalledWithDescribeImagesInput.Len()).To(BeZero())
+	})
+	It("should succeed when resolving the amis of an ec2nodeclass fails", func() {
+		nodeClass := test.EC2NodeClass()
+		ExpectApplied(ctx, env.Client, nodeClass)
+		awsEnv.SSMAPI.WantErr = fmt.Errorf("failed to resolve parameter")
+
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+	})
+})
@@ -17,7 +17,6 @@ package instancetype
 import (
 	"context"
 	"fmt"
-	"time"
 
 	lop "github.com/samber/lo/parallel"
 	"go.uber.org/multierr"
@@ -25,23 +24,30 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/karpenter/pkg/operator/controller"
 
-	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
 )
 
 type Controller struct {
-	instancetypeProvider instancetype.Provider
+	accountProvider account.Provider
 }
 
-func NewController(instancetypeProvider instancetype.Provider) *Controller {
+func NewController(accountProvider account.Provider) *Controller {
 	return &Controller{
-		instancetypeProvider: instancetypeProvider,
+		accountProvider: accountProvider,
 	}
 }
 
+// Reconcile refreshes the instance types and their offerings in every account that EC2NodeClasses manage instances in,
+// since zone names, and so the zones that instance types are offered in, differ between accounts
 func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
-	work := []func(ctx context.Context) error{
-		c.instancetypeProvider.UpdateInstanceTypes,
-		c.instancetypeProvider.UpdateInstanceTypeOfferings,
+	providers, err := c.accountProvider.List(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing account providers, %w", err)
+	}
+	var work []func(ctx context.Context) error
+	for _, p := range providers {
+		work = append(work, p.InstanceTypeProvider.UpdateInstanceTypes, p.InstanceTypeProvider.UpdateInstanceTypeOfferings)
 	}
 	errs := make([]error, len(work))
 	lop.ForEach(work, func(f func(ctx context.Context) error, i int) {
@@ -52,7 +58,7 @@ func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconc
 	if err := multierr.Combine(errs...); err != nil {
 		return reconcile.Result{}, fmt.Errorf("updating instancetype, %w", err)
 	}
-	return reconcile.Result{RequeueAfter: 12 * time.Hour}, nil
+	return reconcile.Result{RequeueAfter: options.FromContext(ctx).InstanceTypesRefreshInterval}, nil
 }
 
 func (c *Controller) Register(_ context.Context, m manager.Manager) error {
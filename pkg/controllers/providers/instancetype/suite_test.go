@@ -16,14 +16,19 @@ package instancetype_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/operator/scheme"
 	coretest "sigs.k8s.io/karpenter/pkg/test"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/samber/lo"
 
@@ -58,7 +63,7 @@ var _ = BeforeSuite(func() {
 	ctx = options.ToContext(ctx, test.Options())
 	ctx, stop = context.WithCancel(ctx)
 	awsEnv = test.NewEnvironment(ctx, env)
-	controller = controllersinstancetype.NewController(awsEnv.InstanceTypesProvider)
+	controller = controllersinstancetype.NewController(awsEnv.AccountProvider)
 })
 
 var _ = AfterSuite(func() {
@@ -168,4 +173,77 @@ var _ = Describe("InstanceType", func() {
 		_, err := awsEnv.InstanceTypesProvider.List(ctx, &corev1beta1.KubeletConfiguration{}, &v1beta1.EC2NodeClass{})
 		Expect(err).ToNot(BeNil())
 	})
+	Context("Refresh", func() {
+		var nodeClass *v1beta1.EC2NodeClass
+		listedOfferings := func() map[string][]string {
+			GinkgoHelper()
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, &corev1beta1.KubeletConfiguration{}, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			return lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, []string) {
+				return it.Name, lo.Map(it.Offerings, func(o corecloudprovider.Offering, _ int) string { return o.Zone })
+			})
+		}
+		BeforeEach(func() {
+			nodeClass = &v1beta1.EC2NodeClass{
+				Status: v1beta1.EC2NodeClassStatus{
+					Subnets: []v1beta1.Subnet{
+						{ID: "subnet-test1", Zone: "test-zone-1a"},
+						{ID: "subnet-test2", Zone: "test-zone-1b"},
+					},
+				},
+			}
+		})
+		It("should requeue after the configured refresh interval", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InstanceTypesRefreshInterval: lo.ToPtr(time.Hour)}))
+			result := ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(result.RequeueAfter).To(Equal(time.Hour))
+		})
+		It("should discover instance types that appear after the initial refresh", func() {
+			ec2InstanceTypes := fake.MakeInstances()
+			newInstanceType, existingInstanceTypes := ec2InstanceTypes[0], ec2InstanceTypes[1:]
+			awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{InstanceTypes: existingInstanceTypes})
+			awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{
+				InstanceTypeOfferings: fake.MakeInstanceOfferings(existingInstanceTypes),
+			})
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(listedOfferings()).ToNot(HaveKey(aws.StringValue(newInstanceType.InstanceType)))
+
+			awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{InstanceTypes: ec2InstanceTypes})
+			awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{
+				InstanceTypeOfferings: fake.MakeInstanceOfferings(ec2InstanceTypes),
+			})
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			listed := listedOfferings()
+			Expect(listed).To(HaveKey(aws.StringValue(newInstanceType.InstanceType)))
+			Expect(listed).To(HaveLen(len(ec2InstanceTypes)))
+		})
+		It("should discover zones that an instance type is rolled out to after the initial refresh", func() {
+			ec2InstanceTypes := fake.MakeInstances()
+			ec2Offerings := fake.MakeInstanceOfferings(ec2InstanceTypes)
+			awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{InstanceTypes: ec2InstanceTypes})
+			awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{InstanceTypeOfferings: ec2Offerings})
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			name := aws.StringValue(ec2InstanceTypes[0].InstanceType)
+			Expect(listedOfferings()[name]).ToNot(ContainElement("test-zone-1b"))
+
+			awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{
+				InstanceTypeOfferings: append(ec2Offerings, &ec2.InstanceTypeOffering{InstanceType: aws.String(name), Location: aws.String("test-zone-1b")}),
+			})
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(listedOfferings()[name]).To(ContainElement("test-zone-1b"))
+		})
+		It("should keep serving the previous instance types when a refresh fails", func() {
+			ec2InstanceTypes := fake.MakeInstances()
+			awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{InstanceTypes: ec2InstanceTypes})
+			awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{
+				InstanceTypeOfferings: fake.MakeInstanceOfferings(ec2InstanceTypes),
+			})
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+			awsEnv.EC2API.NextError.Set(fmt.Errorf("failed"))
+			_, err := controller.Reconcile(ctx, reconcile.Request{})
+			Expect(err).To(HaveOccurred())
+			Expect(listedOfferings()).To(HaveLen(len(ec2InstanceTypes)))
+		})
+	})
 })
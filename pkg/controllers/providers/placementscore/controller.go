@@ -0,0 +1,60 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placementscore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/placementscore"
+)
+
+// RefreshInterval is the time between refreshes of the spot placement scores. Scores change as spot capacity changes,
+// but each refresh only requests a fixed set of configurations, so it doesn't count towards EC2's limit on the number of
+// distinct configurations that scores are requested for.
+const RefreshInterval = 10 * time.Minute
+
+type Controller struct {
+	placementScoreProvider placementscore.Provider
+}
+
+func NewController(placementScoreProvider placementscore.Provider) *Controller {
+	return &Controller{
+		placementScoreProvider: placementScoreProvider,
+	}
+}
+
+// Reconcile refreshes the spot placement scores in the background, so that launches never wait on them
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	if !options.FromContext(ctx).SpotPlacementScore {
+		return reconcile.Result{RequeueAfter: RefreshInterval}, nil
+	}
+	if err := c.placementScoreProvider.UpdateScores(ctx); err != nil {
+		return reconcile.Result{}, fmt.Errorf("updating spot placement scores, %w", err)
+	}
+	return reconcile.Result{RequeueAfter: RefreshInterval}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controller.NewSingletonManagedBy(m).
+		Named("providers.placementscore").
+		Complete(c)
+}
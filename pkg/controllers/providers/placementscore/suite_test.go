@@ -0,0 +1,105 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placementscore_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	controllersplacementscore "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/placementscore"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/placementscore"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var awsEnv *test.Environment
+var controller *controllersplacementscore.Controller
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PlacementScore")
+}
+
+var _ = BeforeSuite(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, &coretest.Environment{})
+	controller = controllersplacementscore.NewController(awsEnv.PlacementScoreProvider)
+})
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{SpotPlacementScore: lo.ToPtr(true)}))
+	awsEnv.Reset()
+	awsEnv.EC2API.GetSpotPlacementScoresBehavior.Output.Set(&ec2.GetSpotPlacementScoresOutput{
+		SpotPlacementScores: []*ec2.SpotPlacementScore{
+			{AvailabilityZoneId: aws.String("testzone1a"), Region: aws.String(fake.DefaultRegion), Score: aws.Int64(3)},
+			{AvailabilityZoneId: aws.String("testzone1b"), Region: aws.String(fake.DefaultRegion), Score: aws.Int64(9)},
+		},
+	})
+})
+
+var _ = Describe("PlacementScore", func() {
+	It("should refresh the scores of each architecture", func() {
+		result := ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(result.RequeueAfter).To(Equal(controllersplacementscore.RefreshInterval))
+		Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.Calls()).To(Equal(len(placementscore.ScoredInstanceTypes)))
+		for _, architecture := range []string{corev1beta1.ArchitectureAmd64, corev1beta1.ArchitectureArm64} {
+			Expect(awsEnv.PlacementScoreProvider.Scores(ctx, architecture)).To(Equal(map[string]int64{"testzone1a": 3, "testzone1b": 9}))
+		}
+	})
+	It("should request the same configurations on every refresh", func() {
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		var configurations []string
+		awsEnv.EC2API.GetSpotPlacementScoresBehavior.CalledWithInput.ForEach(func(input *ec2.GetSpotPlacementScoresInput) {
+			configurations = append(configurations, fmt.Sprint(aws.StringValueSlice(input.InstanceTypes)))
+		})
+		Expect(configurations).To(HaveLen(2 * len(placementscore.ScoredInstanceTypes)))
+		Expect(lo.Uniq(configurations)).To(HaveLen(len(placementscore.ScoredInstanceTypes)))
+	})
+	It("should keep the last known scores when the refresh fails", func() {
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		awsEnv.EC2API.GetSpotPlacementScoresBehavior.Error.Set(fmt.Errorf("throttled"))
+		_, err := controller.Reconcile(ctx, reconcile.Request{})
+		Expect(err).To(HaveOccurred())
+		Expect(awsEnv.PlacementScoreProvider.Scores(ctx, corev1beta1.ArchitectureAmd64)).To(HaveKeyWithValue("testzone1b", int64(9)))
+	})
+	It("should not request scores when spot placement scores are disabled", func() {
+		ctx = options.ToContext(ctx, test.Options())
+		result := ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(result.RequeueAfter).To(Equal(controllersplacementscore.RefreshInterval))
+		Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.Calls()).To(Equal(0))
+	})
+})
@@ -17,8 +17,10 @@ package pricing
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"time"
 
+	"github.com/samber/lo"
 	lop "github.com/samber/lo/parallel"
 	"go.uber.org/multierr"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -26,6 +28,7 @@ import (
 
 	"sigs.k8s.io/karpenter/pkg/operator/controller"
 
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
 )
 
@@ -39,21 +42,31 @@ func NewController(pricingProvider pricing.Provider) *Controller {
 	}
 }
 
+// Reconcile refreshes pricing data. The provider keeps serving the last known prices while the refresh is in progress.
 func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
-	work := []func(ctx context.Context) error{
-		c.pricingProvider.UpdateSpotPricing,
-		c.pricingProvider.UpdateOnDemandPricing,
+	work := map[string]func(ctx context.Context) error{
+		"spot":      c.pricingProvider.UpdateSpotPricing,
+		"on-demand": c.pricingProvider.UpdateOnDemandPricing,
 	}
-	errs := make([]error, len(work))
-	lop.ForEach(work, func(f func(ctx context.Context) error, i int) {
-		if err := f(ctx); err != nil {
-			errs[i] = err
-		}
+	errs := lop.Map(lo.Keys(work), func(priceType string, _ int) error {
+		start := time.Now()
+		defer func() { refreshDuration.WithLabelValues(priceType).Observe(time.Since(start).Seconds()) }()
+		return work[priceType](ctx)
 	})
 	if err := multierr.Combine(errs...); err != nil {
 		return reconcile.Result{}, fmt.Errorf("updating pricing, %w", err)
 	}
-	return reconcile.Result{RequeueAfter: 12 * time.Hour}, nil
+	return reconcile.Result{RequeueAfter: RefreshInterval(ctx)}, nil
+}
+
+// RefreshInterval returns the duration until the next pricing refresh, which is the configured interval plus a random
+// jitter of up to the configured maximum
+func RefreshInterval(ctx context.Context) time.Duration {
+	interval := options.FromContext(ctx).PricingRefreshInterval
+	if jitter := options.FromContext(ctx).PricingRefreshJitter; jitter > 0 {
+		interval += time.Duration(rand.Int63n(int64(jitter)))
+	}
+	return interval
 }
 
 func (c *Controller) Register(_ context.Context, m manager.Manager) error {
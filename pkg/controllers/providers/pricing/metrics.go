@@ -0,0 +1,44 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	pricingSubsystem = "pricing"
+	priceTypeLabel   = "price_type"
+)
+
+var (
+	refreshDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: pricingSubsystem,
+			Name:      "refresh_duration_seconds",
+			Help:      "Duration of refreshing pricing data in seconds. Labeled by price type, either on-demand or spot.",
+			Buckets:   metrics.DurationBuckets(),
+		},
+		[]string{priceTypeLabel},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(refreshDuration)
+}
@@ -17,14 +17,17 @@ package pricing_test
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	awspricing "github.com/aws/aws-sdk-go/service/pricing"
 	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/operator/scheme"
 	coretest "sigs.k8s.io/karpenter/pkg/test"
@@ -328,4 +331,106 @@ var _ = Describe("Pricing", func() {
 		Expect(ok).To(BeTrue())
 		Expect(price).To(BeNumerically("==", 1.23))
 	})
+	Context("Refresh", func() {
+		BeforeEach(func() {
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []*ec2.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     aws.String("c98.large"),
+						SpotPrice:        aws.String("1.10"),
+						Timestamp:        lo.ToPtr(time.Now()),
+					},
+				},
+			})
+			awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+				PriceList: []aws.JSONValue{
+					fake.NewOnDemandPrice("c98.large", 1.20),
+				},
+			})
+		})
+		It("should requeue after the configured refresh interval", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				PricingRefreshInterval: lo.ToPtr(time.Hour),
+			}))
+			result := ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(result.RequeueAfter).To(Equal(time.Hour))
+		})
+		It("should add jitter to the refresh interval", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				PricingRefreshInterval: lo.ToPtr(time.Hour),
+				PricingRefreshJitter:   lo.ToPtr(10 * time.Minute),
+			}))
+			for i := 0; i < 10; i++ {
+				result := ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+				Expect(result.RequeueAfter).To(BeNumerically(">=", time.Hour))
+				Expect(result.RequeueAfter).To(BeNumerically("<", time.Hour+10*time.Minute))
+			}
+		})
+		It("should record the refresh duration", func() {
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			for _, priceType := range []string{"spot", "on-demand"} {
+				m, ok := FindMetricWithLabelValues("karpenter_pricing_refresh_duration_seconds", map[string]string{"price_type": priceType})
+				Expect(ok).To(BeTrue())
+				Expect(m.GetHistogram().GetSampleCount()).To(BeNumerically(">", 0))
+			}
+		})
+		It("should serve the last known prices while a refresh is in progress", func() {
+			pricingAPI := &blockingPricingAPI{PricingAPI: awsEnv.PricingAPI, started: make(chan struct{}), unblock: make(chan struct{})}
+			tmpPricingProvider := pricing.NewDefaultProvider(ctx, pricingAPI, awsEnv.EC2API, fake.DefaultRegion)
+			tmpController := controllerspricing.NewController(tmpPricingProvider)
+
+			done := make(chan error)
+			go func() {
+				_, err := tmpController.Reconcile(ctx, reconcile.Request{})
+				done <- err
+			}()
+			Eventually(pricingAPI.started).Should(BeClosed())
+
+			// reads shouldn't block on the in-progress refresh and should return the static pricing data
+			reads := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(reads)
+				var wg sync.WaitGroup
+				for i := 0; i < 10; i++ {
+					wg.Add(1)
+					go func() {
+						defer GinkgoRecover()
+						defer wg.Done()
+						price, ok := tmpPricingProvider.OnDemandPrice("c5.large")
+						Expect(ok).To(BeTrue())
+						Expect(price).To(BeNumerically(">", 0))
+						_, ok = tmpPricingProvider.OnDemandPrice("c98.large")
+						Expect(ok).To(BeFalse())
+						Expect(tmpPricingProvider.InstanceTypes()).ToNot(BeEmpty())
+					}()
+				}
+				wg.Wait()
+			}()
+			Eventually(reads).WithTimeout(time.Second).Should(BeClosed())
+			Consistently(done).ShouldNot(Receive())
+
+			close(pricingAPI.unblock)
+			Eventually(done).Should(Receive(BeNil()))
+			price, ok := tmpPricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+		})
+	})
 })
+
+// blockingPricingAPI blocks product lookups until unblock is closed, allowing tests to observe the provider while a
+// refresh is in progress
+type blockingPricingAPI struct {
+	*fake.PricingAPI
+	once    sync.Once
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func (b *blockingPricingAPI) GetProductsPagesWithContext(ctx aws.Context, input *awspricing.GetProductsInput, fn func(*awspricing.GetProductsOutput, bool) bool, opts ...request.Option) error {
+	b.once.Do(func() { close(b.started) })
+	<-b.unblock
+	return b.PricingAPI.GetProductsPagesWithContext(ctx, input, fn, opts...)
+}
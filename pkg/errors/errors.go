@@ -17,6 +17,7 @@ package errors
 import (
 	"errors"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/iam"
@@ -25,7 +26,8 @@ import (
 )
 
 const (
-	launchTemplateNameNotFoundCode = "InvalidLaunchTemplateName.NotFoundException"
+	launchTemplateNameNotFoundCode  = "InvalidLaunchTemplateName.NotFoundException"
+	reservationCapacityExceededCode = "ReservationCapacityExceeded"
 )
 
 var (
@@ -34,12 +36,18 @@ var (
 		"InvalidInstanceID.NotFound",
 		launchTemplateNameNotFoundCode,
 		"InvalidLaunchTemplateId.NotFound",
+		"InvalidNetworkInterfaceID.NotFound",
 		sqs.ErrCodeQueueDoesNotExist,
 		iam.ErrCodeNoSuchEntityException,
 	)
 	alreadyExistsErrorCodes = sets.New[string](
 		iam.ErrCodeEntityAlreadyExistsException,
 	)
+	accessDeniedErrorCodes = sets.New[string](
+		"AccessDenied",
+		"AccessDeniedException",
+		"UnauthorizedOperation",
+	)
 	// unfulfillableCapacityErrorCodes signify that capacity is temporarily unable to be launched
 	unfulfillableCapacityErrorCodes = sets.New[string](
 		"InsufficientInstanceCapacity",
@@ -48,6 +56,7 @@ var (
 		"UnfulfillableCapacity",
 		"Unsupported",
 		"InsufficientFreeAddressesInSubnet",
+		"SpotMaxPriceTooLow",
 	)
 )
 
@@ -90,6 +99,19 @@ func IgnoreAlreadyExists(err error) error {
 	return err
 }
 
+// IsAccessDenied returns true if the err is an AWS error (even if it's
+// wrapped) that signals the caller lacks permissions for the operation
+func IsAccessDenied(err error) bool {
+	if err == nil {
+		return false
+	}
+	var awsError awserr.Error
+	if errors.As(err, &awsError) {
+		return accessDeniedErrorCodes.Has(awsError.Code())
+	}
+	return false
+}
+
 // IsUnfulfillableCapacity returns true if the Fleet err means
 // capacity is temporarily unavailable for launching.
 // This could be due to account limits, insufficient ec2 capacity, etc.
@@ -97,6 +119,12 @@ func IsUnfulfillableCapacity(err *ec2.CreateFleetError) bool {
 	return unfulfillableCapacityErrorCodes.Has(*err.ErrorCode)
 }
 
+// IsReservationCapacityExceeded returns true if the Fleet err means the capacity reservation targeted by the launch
+// has no instances available
+func IsReservationCapacityExceeded(err *ec2.CreateFleetError) bool {
+	return aws.StringValue(err.ErrorCode) == reservationCapacityExceededCode
+}
+
 func IsLaunchTemplateNotFound(err error) bool {
 	if err == nil {
 		return false
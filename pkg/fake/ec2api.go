@@ -51,19 +51,28 @@ type EC2Behavior struct {
 	DescribeSubnetsOutput               AtomicPtr[ec2.DescribeSubnetsOutput]
 	DescribeSecurityGroupsOutput        AtomicPtr[ec2.DescribeSecurityGroupsOutput]
 	DescribeInstanceTypesOutput         AtomicPtr[ec2.DescribeInstanceTypesOutput]
+	DescribeInstanceTypeOfferingsInput  AtomicPtr[ec2.DescribeInstanceTypeOfferingsInput]
 	DescribeInstanceTypeOfferingsOutput AtomicPtr[ec2.DescribeInstanceTypeOfferingsOutput]
 	DescribeAvailabilityZonesOutput     AtomicPtr[ec2.DescribeAvailabilityZonesOutput]
+	DescribeCapacityReservationsOutput  AtomicPtr[ec2.DescribeCapacityReservationsOutput]
 	DescribeSpotPriceHistoryInput       AtomicPtr[ec2.DescribeSpotPriceHistoryInput]
 	DescribeSpotPriceHistoryOutput      AtomicPtr[ec2.DescribeSpotPriceHistoryOutput]
 	CreateFleetBehavior                 MockedFunction[ec2.CreateFleetInput, ec2.CreateFleetOutput]
 	TerminateInstancesBehavior          MockedFunction[ec2.TerminateInstancesInput, ec2.TerminateInstancesOutput]
+	StopInstancesBehavior               MockedFunction[ec2.StopInstancesInput, ec2.StopInstancesOutput]
+	StartInstancesBehavior              MockedFunction[ec2.StartInstancesInput, ec2.StartInstancesOutput]
 	DescribeInstancesBehavior           MockedFunction[ec2.DescribeInstancesInput, ec2.DescribeInstancesOutput]
 	CreateTagsBehavior                  MockedFunction[ec2.CreateTagsInput, ec2.CreateTagsOutput]
+	DeleteTagsBehavior                  MockedFunction[ec2.DeleteTagsInput, ec2.DeleteTagsOutput]
+	DeleteNetworkInterfaceBehavior      MockedFunction[ec2.DeleteNetworkInterfaceInput, ec2.DeleteNetworkInterfaceOutput]
+	GetSpotPlacementScoresBehavior      MockedFunction[ec2.GetSpotPlacementScoresInput, ec2.GetSpotPlacementScoresOutput]
 	CalledWithCreateLaunchTemplateInput AtomicPtrSlice[ec2.CreateLaunchTemplateInput]
 	CalledWithDescribeImagesInput       AtomicPtrSlice[ec2.DescribeImagesInput]
 	Instances                           sync.Map
 	LaunchTemplates                     sync.Map
+	NetworkInterfaces                   sync.Map
 	InsufficientCapacityPools           atomic.Slice[CapacityPool]
+	ExhaustedCapacityReservations       atomic.Slice[string]
 	NextError                           AtomicError
 }
 
@@ -87,11 +96,19 @@ func (e *EC2API) Reset() {
 	e.DescribeSubnetsOutput.Reset()
 	e.DescribeSecurityGroupsOutput.Reset()
 	e.DescribeInstanceTypesOutput.Reset()
+	e.DescribeInstanceTypeOfferingsInput.Reset()
 	e.DescribeInstanceTypeOfferingsOutput.Reset()
 	e.DescribeAvailabilityZonesOutput.Reset()
+	e.DescribeCapacityReservationsOutput.Reset()
 	e.CreateFleetBehavior.Reset()
 	e.TerminateInstancesBehavior.Reset()
+	e.StopInstancesBehavior.Reset()
+	e.StartInstancesBehavior.Reset()
 	e.DescribeInstancesBehavior.Reset()
+	e.CreateTagsBehavior.Reset()
+	e.DeleteTagsBehavior.Reset()
+	e.DeleteNetworkInterfaceBehavior.Reset()
+	e.GetSpotPlacementScoresBehavior.Reset()
 	e.CalledWithCreateLaunchTemplateInput.Reset()
 	e.CalledWithDescribeImagesInput.Reset()
 	e.DescribeSpotPriceHistoryInput.Reset()
@@ -104,7 +121,12 @@ func (e *EC2API) Reset() {
 		e.LaunchTemplates.Delete(k)
 		return true
 	})
+	e.NetworkInterfaces.Range(func(k, v any) bool {
+		e.NetworkInterfaces.Delete(k)
+		return true
+	})
 	e.InsufficientCapacityPools.Reset()
+	e.ExhaustedCapacityReservations.Reset()
 	e.NextError.Reset()
 }
 
@@ -121,9 +143,33 @@ func (e *EC2API) CreateFleetWithContext(_ context.Context, input *ec2.CreateFlee
 		if aws.StringValue(input.TargetCapacitySpecification.DefaultTargetCapacityType) == corev1beta1.CapacityTypeSpot {
 			spotInstanceRequestID = aws.String(test.RandomName())
 		}
+		// Requests that mix capacity types launch their on-demand capacity first
+		onDemandCapacity := 0
+		if input.TargetCapacitySpecification.SpotTargetCapacity != nil {
+			onDemandCapacity = int(aws.Int64Value(input.TargetCapacitySpecification.OnDemandTargetCapacity))
+		}
+
+		// Launch templates that target an exhausted capacity reservation fail with a reservation error
+		exhaustedLaunchTemplates := sets.New[string]()
+		e.CalledWithCreateLaunchTemplateInput.ForEach(func(lt *ec2.CreateLaunchTemplateInput) {
+			if spec := lt.LaunchTemplateData.CapacityReservationSpecification; spec != nil && spec.CapacityReservationTarget != nil {
+				e.ExhaustedCapacityReservations.Range(func(id string) bool {
+					if id == aws.StringValue(spec.CapacityReservationTarget.CapacityReservationId) {
+						exhaustedLaunchTemplates.Insert(aws.StringValue(lt.LaunchTemplateName))
+						return false
+					}
+					return true
+				})
+			}
+		})
+		var exhaustedOverrides []*ec2.FleetLaunchTemplateOverridesRequest
 
 		fulfilled := 0
 		for _, ltc := range input.LaunchTemplateConfigs {
+			if exhaustedLaunchTemplates.Has(aws.StringValue(ltc.LaunchTemplateSpecification.LaunchTemplateName)) {
+				exhaustedOverrides = append(exhaustedOverrides, ltc.Overrides...)
+				continue
+			}
 			for _, override := range ltc.Overrides {
 				skipInstance := false
 				e.InsufficientCapacityPools.Range(func(pool CapacityPool) bool {
@@ -153,7 +199,8 @@ func (e *EC2API) CreateFleetWithContext(_ context.Context, input *ec2.CreateFlee
 						Placement:             &ec2.Placement{AvailabilityZone: input.LaunchTemplateConfigs[0].Overrides[0].AvailabilityZone},
 						PrivateDnsName:        aws.String(randomdata.IpV4Address()),
 						InstanceType:          input.LaunchTemplateConfigs[0].Overrides[0].InstanceType,
-						SpotInstanceRequestId: spotInstanceRequestID,
+						RootDeviceType:        aws.String(ec2.DeviceTypeEbs),
+						SpotInstanceRequestId: lo.Ternary(fulfilled < onDemandCapacity, nil, spotInstanceRequestID),
 						State: &ec2.InstanceState{
 							Name: &instanceState,
 						},
@@ -181,6 +228,12 @@ func (e *EC2API) CreateFleetWithContext(_ context.Context, input *ec2.CreateFlee
 				},
 			},
 		}}
+		if onDemandCapacity > 0 && len(instanceIds) > 0 {
+			onDemand, spot := *result.Instances[0], *result.Instances[0]
+			onDemand.InstanceIds, onDemand.Lifecycle = instanceIds[:min(onDemandCapacity, len(instanceIds))], aws.String(corev1beta1.CapacityTypeOnDemand)
+			spot.InstanceIds = instanceIds[len(onDemand.InstanceIds):]
+			result.Instances = lo.Filter([]*ec2.CreateFleetInstance{&onDemand, &spot}, func(i *ec2.CreateFleetInstance, _ int) bool { return len(i.InstanceIds) > 0 })
+		}
 		for _, pool := range skippedPools {
 			result.Errors = append(result.Errors, &ec2.CreateFleetError{
 				ErrorCode: aws.String("InsufficientInstanceCapacity"),
@@ -192,6 +245,17 @@ func (e *EC2API) CreateFleetWithContext(_ context.Context, input *ec2.CreateFlee
 				},
 			})
 		}
+		for _, override := range exhaustedOverrides {
+			result.Errors = append(result.Errors, &ec2.CreateFleetError{
+				ErrorCode: aws.String("ReservationCapacityExceeded"),
+				LaunchTemplateAndOverrides: &ec2.LaunchTemplateAndOverridesResponse{
+					Overrides: &ec2.FleetLaunchTemplateOverrides{
+						InstanceType:     override.InstanceType,
+						AvailabilityZone: override.AvailabilityZone,
+					},
+				},
+			})
+		}
 		return result, nil
 	})
 }
@@ -213,6 +277,46 @@ func (e *EC2API) TerminateInstancesWithContext(_ context.Context, input *ec2.Ter
 	})
 }
 
+func (e *EC2API) StopInstancesWithContext(_ context.Context, input *ec2.StopInstancesInput, _ ...request.Option) (*ec2.StopInstancesOutput, error) {
+	return e.StopInstancesBehavior.Invoke(input, func(input *ec2.StopInstancesInput) (*ec2.StopInstancesOutput, error) {
+		var instanceStateChanges []*ec2.InstanceStateChange
+		for _, id := range input.InstanceIds {
+			raw, ok := e.Instances.Load(aws.StringValue(id))
+			if !ok {
+				continue
+			}
+			instance := raw.(*ec2.Instance)
+			instanceStateChanges = append(instanceStateChanges, &ec2.InstanceStateChange{
+				PreviousState: instance.State,
+				CurrentState:  &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameStopped), Code: aws.Int64(80)},
+				InstanceId:    id,
+			})
+			instance.State = &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameStopped), Code: aws.Int64(80)}
+		}
+		return &ec2.StopInstancesOutput{StoppingInstances: instanceStateChanges}, nil
+	})
+}
+
+func (e *EC2API) StartInstancesWithContext(_ context.Context, input *ec2.StartInstancesInput, _ ...request.Option) (*ec2.StartInstancesOutput, error) {
+	return e.StartInstancesBehavior.Invoke(input, func(input *ec2.StartInstancesInput) (*ec2.StartInstancesOutput, error) {
+		var instanceStateChanges []*ec2.InstanceStateChange
+		for _, id := range input.InstanceIds {
+			raw, ok := e.Instances.Load(aws.StringValue(id))
+			if !ok {
+				continue
+			}
+			instance := raw.(*ec2.Instance)
+			instanceStateChanges = append(instanceStateChanges, &ec2.InstanceStateChange{
+				PreviousState: instance.State,
+				CurrentState:  &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNamePending), Code: aws.Int64(0)},
+				InstanceId:    id,
+			})
+			instance.State = &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNamePending), Code: aws.Int64(0)}
+		}
+		return &ec2.StartInstancesOutput{StartingInstances: instanceStateChanges}, nil
+	})
+}
+
 func (e *EC2API) CreateLaunchTemplateWithContext(_ context.Context, input *ec2.CreateLaunchTemplateInput, _ ...request.Option) (*ec2.CreateLaunchTemplateOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
@@ -220,6 +324,11 @@ func (e *EC2API) CreateLaunchTemplateWithContext(_ context.Context, input *ec2.C
 	}
 	e.CalledWithCreateLaunchTemplateInput.Add(input)
 	launchTemplate := &ec2.LaunchTemplate{LaunchTemplateName: input.LaunchTemplateName}
+	for _, tagSpecification := range input.TagSpecifications {
+		if aws.StringValue(tagSpecification.ResourceType) == ec2.ResourceTypeLaunchTemplate {
+			launchTemplate.Tags = append(launchTemplate.Tags, tagSpecification.Tags...)
+		}
+	}
 	e.LaunchTemplates.Store(input.LaunchTemplateName, launchTemplate)
 	return &ec2.CreateLaunchTemplateOutput{LaunchTemplate: launchTemplate}, nil
 }
@@ -247,6 +356,22 @@ func (e *EC2API) CreateTagsWithContext(_ context.Context, input *ec2.CreateTagsI
 	})
 }
 
+func (e *EC2API) DeleteTagsWithContext(_ context.Context, input *ec2.DeleteTagsInput, _ ...request.Option) (*ec2.DeleteTagsOutput, error) {
+	return e.DeleteTagsBehavior.Invoke(input, func(input *ec2.DeleteTagsInput) (*ec2.DeleteTagsOutput, error) {
+		for _, id := range input.Resources {
+			raw, ok := e.Instances.Load(aws.StringValue(id))
+			if !ok {
+				return nil, fmt.Errorf("instance with id '%s' does not exist", aws.StringValue(id))
+			}
+			instance := raw.(*ec2.Instance)
+			instance.Tags = lo.Reject(instance.Tags, func(t *ec2.Tag, _ int) bool {
+				return lo.ContainsBy(input.Tags, func(deleted *ec2.Tag) bool { return aws.StringValue(deleted.Key) == aws.StringValue(t.Key) })
+			})
+		}
+		return &ec2.DeleteTagsOutput{}, nil
+	})
+}
+
 func (e *EC2API) DescribeInstancesWithContext(_ context.Context, input *ec2.DescribeInstancesInput, _ ...request.Option) (*ec2.DescribeInstancesOutput, error) {
 	return e.DescribeInstancesBehavior.Invoke(input, func(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
 		var instances []*ec2.Instance
@@ -335,6 +460,13 @@ func (e *EC2API) DescribeImagesWithContext(_ context.Context, input *ec2.Describ
 	if !e.DescribeImagesOutput.IsNil() {
 		describeImagesOutput := e.DescribeImagesOutput.Clone()
 		describeImagesOutput.Images = FilterDescribeImages(describeImagesOutput.Images, input.Filters)
+		// Deprecated images are only returned when they're included or selected by ID
+		if !aws.BoolValue(input.IncludeDeprecated) && !lo.ContainsBy(input.Filters, func(f *ec2.Filter) bool { return aws.StringValue(f.Name) == "image-id" }) {
+			describeImagesOutput.Images = lo.Reject(describeImagesOutput.Images, func(image *ec2.Image, _ int) bool {
+				deprecationTime, err := time.Parse(time.RFC3339, aws.StringValue(image.DeprecationTime))
+				return err == nil && !deprecationTime.After(time.Now())
+			})
+		}
 		return describeImagesOutput, nil
 	}
 	if aws.StringValue(input.Filters[0].Values[0]) == "invalid" {
@@ -404,6 +536,37 @@ func (e *EC2API) DeleteLaunchTemplateWithContext(_ context.Context, input *ec2.D
 	return nil, nil
 }
 
+func (e *EC2API) DescribeNetworkInterfacesWithContext(_ context.Context, input *ec2.DescribeNetworkInterfacesInput, _ ...request.Option) (*ec2.DescribeNetworkInterfacesOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	var networkInterfaces []*ec2.NetworkInterface
+	e.NetworkInterfaces.Range(func(_, value any) bool {
+		networkInterfaces = append(networkInterfaces, value.(*ec2.NetworkInterface))
+		return true
+	})
+	return &ec2.DescribeNetworkInterfacesOutput{NetworkInterfaces: FilterDescribeNetworkInterfaces(networkInterfaces, input.Filters)}, nil
+}
+
+func (e *EC2API) DescribeNetworkInterfacesPagesWithContext(ctx context.Context, input *ec2.DescribeNetworkInterfacesInput, fn func(*ec2.DescribeNetworkInterfacesOutput, bool) bool, _ ...request.Option) error {
+	output, err := e.DescribeNetworkInterfacesWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+	fn(output, true)
+	return nil
+}
+
+func (e *EC2API) DeleteNetworkInterfaceWithContext(_ context.Context, input *ec2.DeleteNetworkInterfaceInput, _ ...request.Option) (*ec2.DeleteNetworkInterfaceOutput, error) {
+	return e.DeleteNetworkInterfaceBehavior.Invoke(input, func(input *ec2.DeleteNetworkInterfaceInput) (*ec2.DeleteNetworkInterfaceOutput, error) {
+		if _, ok := e.NetworkInterfaces.LoadAndDelete(aws.StringValue(input.NetworkInterfaceId)); !ok {
+			return nil, awserr.New("InvalidNetworkInterfaceID.NotFound", "not found", nil)
+		}
+		return &ec2.DeleteNetworkInterfaceOutput{}, nil
+	})
+}
+
 func (e *EC2API) DescribeSubnetsWithContext(_ context.Context, input *ec2.DescribeSubnetsInput, _ ...request.Option) (*ec2.DescribeSubnetsOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
@@ -418,6 +581,7 @@ func (e *EC2API) DescribeSubnetsWithContext(_ context.Context, input *ec2.Descri
 		{
 			SubnetId:                aws.String("subnet-test1"),
 			AvailabilityZone:        aws.String("test-zone-1a"),
+			AvailabilityZoneId:      aws.String("testzone1a"),
 			AvailableIpAddressCount: aws.Int64(100),
 			MapPublicIpOnLaunch:     aws.Bool(false),
 			Tags: []*ec2.Tag{
@@ -428,6 +592,7 @@ func (e *EC2API) DescribeSubnetsWithContext(_ context.Context, input *ec2.Descri
 		{
 			SubnetId:                aws.String("subnet-test2"),
 			AvailabilityZone:        aws.String("test-zone-1b"),
+			AvailabilityZoneId:      aws.String("testzone1b"),
 			AvailableIpAddressCount: aws.Int64(100),
 			MapPublicIpOnLaunch:     aws.Bool(true),
 			Tags: []*ec2.Tag{
@@ -438,6 +603,7 @@ func (e *EC2API) DescribeSubnetsWithContext(_ context.Context, input *ec2.Descri
 		{
 			SubnetId:                aws.String("subnet-test3"),
 			AvailabilityZone:        aws.String("test-zone-1c"),
+			AvailabilityZoneId:      aws.String("testzone1c"),
 			AvailableIpAddressCount: aws.Int64(100),
 			Tags: []*ec2.Tag{
 				{Key: aws.String("Name"), Value: aws.String("test-subnet-3")},
@@ -448,6 +614,7 @@ func (e *EC2API) DescribeSubnetsWithContext(_ context.Context, input *ec2.Descri
 		{
 			SubnetId:                aws.String("subnet-test4"),
 			AvailabilityZone:        aws.String("test-zone-1a-local"),
+			AvailabilityZoneId:      aws.String("testzone1alocal"),
 			AvailableIpAddressCount: aws.Int64(100),
 			MapPublicIpOnLaunch:     aws.Bool(true),
 			Tags: []*ec2.Tag{
@@ -520,6 +687,82 @@ func (e *EC2API) DescribeAvailabilityZonesWithContext(context.Context, *ec2.Desc
 	}}, nil
 }
 
+func (e *EC2API) GetSpotPlacementScoresWithContext(_ context.Context, input *ec2.GetSpotPlacementScoresInput, _ ...request.Option) (*ec2.GetSpotPlacementScoresOutput, error) {
+	return e.GetSpotPlacementScoresBehavior.Invoke(input, func(_ *ec2.GetSpotPlacementScoresInput) (*ec2.GetSpotPlacementScoresOutput, error) {
+		return &ec2.GetSpotPlacementScoresOutput{}, nil
+	})
+}
+
+func (e *EC2API) GetSpotPlacementScoresPagesWithContext(ctx context.Context, input *ec2.GetSpotPlacementScoresInput, fn func(*ec2.GetSpotPlacementScoresOutput, bool) bool, _ ...request.Option) error {
+	out, err := e.GetSpotPlacementScoresWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+	fn(out, false)
+	return nil
+}
+
+func (e *EC2API) DescribeCapacityReservationsWithContext(_ context.Context, input *ec2.DescribeCapacityReservationsInput, _ ...request.Option) (*ec2.DescribeCapacityReservationsOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	if !e.DescribeCapacityReservationsOutput.IsNil() {
+		describeCapacityReservationsOutput := e.DescribeCapacityReservationsOutput.Clone()
+		describeCapacityReservationsOutput.CapacityReservations = FilterDescribeCapacityReservations(describeCapacityReservationsOutput.CapacityReservations, input.CapacityReservationIds, input.Filters)
+		return describeCapacityReservationsOutput, nil
+	}
+	capacityReservations := []*ec2.CapacityReservation{
+		{
+			CapacityReservationId:  aws.String("cr-test1"),
+			InstanceType:           aws.String("m5.large"),
+			AvailabilityZone:       aws.String("test-zone-1a"),
+			AvailableInstanceCount: aws.Int64(5),
+			TotalInstanceCount:     aws.Int64(10),
+			State:                  aws.String(ec2.CapacityReservationStateActive),
+			Tags: []*ec2.Tag{
+				{Key: aws.String("Name"), Value: aws.String("test-capacity-reservation-1")},
+				{Key: aws.String("foo"), Value: aws.String("bar")},
+			},
+		},
+		{
+			CapacityReservationId:  aws.String("cr-test2"),
+			InstanceType:           aws.String("m5.xlarge"),
+			AvailabilityZone:       aws.String("test-zone-1b"),
+			AvailableInstanceCount: aws.Int64(2),
+			TotalInstanceCount:     aws.Int64(2),
+			State:                  aws.String(ec2.CapacityReservationStateActive),
+			Tags: []*ec2.Tag{
+				{Key: aws.String("Name"), Value: aws.String("test-capacity-reservation-2")},
+				{Key: aws.String("foo"), Value: aws.String("bar")},
+			},
+		},
+		{
+			CapacityReservationId:  aws.String("cr-test3"),
+			InstanceType:           aws.String("m5.large"),
+			AvailabilityZone:       aws.String("test-zone-1c"),
+			AvailableInstanceCount: aws.Int64(0),
+			TotalInstanceCount:     aws.Int64(4),
+			State:                  aws.String(ec2.CapacityReservationStateExpired),
+			Tags: []*ec2.Tag{
+				{Key: aws.String("Name"), Value: aws.String("test-capacity-reservation-3")},
+				{Key: aws.String("TestTag")},
+				{Key: aws.String("foo"), Value: aws.String("bar")},
+			},
+		},
+	}
+	return &ec2.DescribeCapacityReservationsOutput{CapacityReservations: FilterDescribeCapacityReservations(capacityReservations, input.CapacityReservationIds, input.Filters)}, nil
+}
+
+func (e *EC2API) DescribeCapacityReservationsPagesWithContext(ctx context.Context, input *ec2.DescribeCapacityReservationsInput, fn func(*ec2.DescribeCapacityReservationsOutput, bool) bool, _ ...request.Option) error {
+	out, err := e.DescribeCapacityReservationsWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+	fn(out, false)
+	return nil
+}
+
 func (e *EC2API) DescribeInstanceTypesWithContext(_ context.Context, _ *ec2.DescribeInstanceTypesInput, _ ...request.Option) (*ec2.DescribeInstanceTypesOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
@@ -540,7 +783,8 @@ func (e *EC2API) DescribeInstanceTypesPagesWithContext(ctx context.Context, inpu
 	return nil
 }
 
-func (e *EC2API) DescribeInstanceTypeOfferingsWithContext(_ context.Context, _ *ec2.DescribeInstanceTypeOfferingsInput, _ ...request.Option) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+func (e *EC2API) DescribeInstanceTypeOfferingsWithContext(_ context.Context, input *ec2.DescribeInstanceTypeOfferingsInput, _ ...request.Option) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+	e.DescribeInstanceTypeOfferingsInput.Set(input)
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
 		return nil, e.NextError.Get()
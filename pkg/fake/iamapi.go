@@ -38,6 +38,7 @@ type IAMAPIBehavior struct {
 	DeleteInstanceProfileBehavior         MockedFunction[iam.DeleteInstanceProfileInput, iam.DeleteInstanceProfileOutput]
 	AddRoleToInstanceProfileBehavior      MockedFunction[iam.AddRoleToInstanceProfileInput, iam.AddRoleToInstanceProfileOutput]
 	RemoveRoleFromInstanceProfileBehavior MockedFunction[iam.RemoveRoleFromInstanceProfileInput, iam.RemoveRoleFromInstanceProfileOutput]
+	TagInstanceProfileBehavior            MockedFunction[iam.TagInstanceProfileInput, iam.TagInstanceProfileOutput]
 }
 
 type IAMAPI struct {
@@ -61,6 +62,7 @@ func (s *IAMAPI) Reset() {
 	s.DeleteInstanceProfileBehavior.Reset()
 	s.AddRoleToInstanceProfileBehavior.Reset()
 	s.RemoveRoleFromInstanceProfileBehavior.Reset()
+	s.TagInstanceProfileBehavior.Reset()
 	s.InstanceProfiles = map[string]*iam.InstanceProfile{}
 }
 
@@ -146,3 +148,23 @@ func (s *IAMAPI) RemoveRoleFromInstanceProfileWithContext(_ context.Context, inp
 		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, fmt.Sprintf("Instance Profile %s cannot be found", aws.StringValue(input.InstanceProfileName)), nil)
 	})
 }
+
+func (s *IAMAPI) TagInstanceProfileWithContext(_ context.Context, input *iam.TagInstanceProfileInput, _ ...request.Option) (*iam.TagInstanceProfileOutput, error) {
+	return s.TagInstanceProfileBehavior.Invoke(input, func(output *iam.TagInstanceProfileInput) (*iam.TagInstanceProfileOutput, error) {
+		s.Lock()
+		defer s.Unlock()
+
+		if i, ok := s.InstanceProfiles[aws.StringValue(input.InstanceProfileName)]; ok {
+			// Upsert any tags that have the same key
+			tagsToMap := func(tag *iam.Tag) (string, string) {
+				return aws.StringValue(tag.Key), aws.StringValue(tag.Value)
+			}
+			tags := lo.Assign(lo.SliceToMap(i.Tags, tagsToMap), lo.SliceToMap(input.Tags, tagsToMap))
+			i.Tags = lo.MapToSlice(tags, func(key, value string) *iam.Tag {
+				return &iam.Tag{Key: aws.String(key), Value: aws.String(value)}
+			})
+			return &iam.TagInstanceProfileOutput{}, nil
+		}
+		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, fmt.Sprintf("Instance Profile %s cannot be found", aws.StringValue(input.InstanceProfileName)), nil)
+	})
+}
@@ -0,0 +1,59 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/imagebuilder"
+	"github.com/aws/aws-sdk-go/service/imagebuilder/imagebuilderiface"
+)
+
+// ImagebuilderAPIBehavior must be reset between tests otherwise tests will
+// pollute each other.
+type ImagebuilderAPIBehavior struct {
+	ListImagePipelineImagesBehavior MockedFunction[imagebuilder.ListImagePipelineImagesInput, imagebuilder.ListImagePipelineImagesOutput]
+}
+
+type ImagebuilderAPI struct {
+	imagebuilderiface.ImagebuilderAPI
+	ImagebuilderAPIBehavior
+}
+
+func NewImagebuilderAPI() *ImagebuilderAPI {
+	return &ImagebuilderAPI{}
+}
+
+// Reset must be called between tests otherwise tests will pollute
+// each other.
+func (a *ImagebuilderAPI) Reset() {
+	a.ListImagePipelineImagesBehavior.Reset()
+}
+
+func (a *ImagebuilderAPI) ListImagePipelineImagesPagesWithContext(ctx context.Context, input *imagebuilder.ListImagePipelineImagesInput, fn func(*imagebuilder.ListImagePipelineImagesOutput, bool) bool, opts ...request.Option) error {
+	output, err := a.ListImagePipelineImagesWithContext(ctx, input, opts...)
+	if err != nil {
+		return err
+	}
+	fn(output, true)
+	return nil
+}
+
+func (a *ImagebuilderAPI) ListImagePipelineImagesWithContext(_ context.Context, input *imagebuilder.ListImagePipelineImagesInput, _ ...request.Option) (*imagebuilder.ListImagePipelineImagesOutput, error) {
+	return a.ListImagePipelineImagesBehavior.Invoke(input, func(*imagebuilder.ListImagePipelineImagesInput) (*imagebuilder.ListImagePipelineImagesOutput, error) {
+		return &imagebuilder.ListImagePipelineImagesOutput{}, nil
+	})
+}
@@ -16,23 +16,42 @@ package fake
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/samber/lo"
 )
 
 const (
 	dummyQueueURL = "https://sqs.us-west-2.amazonaws.com/000000000000/Karpenter-cluster-Queue"
+	dummyQueueARN = "arn:aws:sqs:us-west-2:000000000000:Karpenter-cluster-Queue"
 )
 
+// DefaultQueuePolicy is an access policy that allows EventBridge to deliver interruption events to the queue
+var DefaultQueuePolicy = fmt.Sprintf(`{
+	"Version": "2012-10-17",
+	"Id": "EC2InterruptionPolicy",
+	"Statement": [{
+		"Effect": "Allow",
+		"Principal": {"Service": ["events.amazonaws.com", "sqs.amazonaws.com"]},
+		"Action": "sqs:SendMessage",
+		"Resource": %q
+	}]
+}`, dummyQueueARN)
+
 // SQSBehavior must be reset between tests otherwise tests will
 // pollute each other.
 type SQSBehavior struct {
-	GetQueueURLBehavior    MockedFunction[sqs.GetQueueUrlInput, sqs.GetQueueUrlOutput]
-	ReceiveMessageBehavior MockedFunction[sqs.ReceiveMessageInput, sqs.ReceiveMessageOutput]
-	DeleteMessageBehavior  MockedFunction[sqs.DeleteMessageInput, sqs.DeleteMessageOutput]
+	GetQueueURLBehavior        MockedFunction[sqs.GetQueueUrlInput, sqs.GetQueueUrlOutput]
+	ReceiveMessageBehavior     MockedFunction[sqs.ReceiveMessageInput, sqs.ReceiveMessageOutput]
+	DeleteMessageBehavior      MockedFunction[sqs.DeleteMessageInput, sqs.DeleteMessageOutput]
+	DeleteMessageBatchBehavior MockedFunction[sqs.DeleteMessageBatchInput, sqs.DeleteMessageBatchOutput]
+	GetQueueAttributesBehavior MockedFunction[sqs.GetQueueAttributesInput, sqs.GetQueueAttributesOutput]
+	TagQueueBehavior           MockedFunction[sqs.TagQueueInput, sqs.TagQueueOutput]
 }
 
 type SQSAPI struct {
@@ -46,6 +65,9 @@ func (s *SQSAPI) Reset() {
 	s.GetQueueURLBehavior.Reset()
 	s.ReceiveMessageBehavior.Reset()
 	s.DeleteMessageBehavior.Reset()
+	s.DeleteMessageBatchBehavior.Reset()
+	s.GetQueueAttributesBehavior.Reset()
+	s.TagQueueBehavior.Reset()
 }
 
 //nolint:revive,stylecheck
@@ -57,7 +79,10 @@ func (s *SQSAPI) GetQueueUrlWithContext(_ context.Context, input *sqs.GetQueueUr
 	})
 }
 
-func (s *SQSAPI) ReceiveMessageWithContext(_ context.Context, input *sqs.ReceiveMessageInput, _ ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+func (s *SQSAPI) ReceiveMessageWithContext(ctx context.Context, input *sqs.ReceiveMessageInput, _ ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	if ctx.Err() != nil {
+		return nil, awserr.New(request.CanceledErrorCode, "request context canceled", ctx.Err())
+	}
 	return s.ReceiveMessageBehavior.Invoke(input, func(_ *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
 		return nil, nil
 	})
@@ -68,3 +93,30 @@ func (s *SQSAPI) DeleteMessageWithContext(_ context.Context, input *sqs.DeleteMe
 		return nil, nil
 	})
 }
+
+func (s *SQSAPI) DeleteMessageBatchWithContext(_ context.Context, input *sqs.DeleteMessageBatchInput, _ ...request.Option) (*sqs.DeleteMessageBatchOutput, error) {
+	return s.DeleteMessageBatchBehavior.Invoke(input, func(input *sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error) {
+		return &sqs.DeleteMessageBatchOutput{
+			Successful: lo.Map(input.Entries, func(e *sqs.DeleteMessageBatchRequestEntry, _ int) *sqs.DeleteMessageBatchResultEntry {
+				return &sqs.DeleteMessageBatchResultEntry{Id: e.Id}
+			}),
+		}, nil
+	})
+}
+
+func (s *SQSAPI) GetQueueAttributesWithContext(_ context.Context, input *sqs.GetQueueAttributesInput, _ ...request.Option) (*sqs.GetQueueAttributesOutput, error) {
+	return s.GetQueueAttributesBehavior.Invoke(input, func(_ *sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error) {
+		return &sqs.GetQueueAttributesOutput{
+			Attributes: aws.StringMap(map[string]string{
+				sqs.QueueAttributeNamePolicy:   DefaultQueuePolicy,
+				sqs.QueueAttributeNameQueueArn: dummyQueueARN,
+			}),
+		}, nil
+	})
+}
+
+func (s *SQSAPI) TagQueueWithContext(_ context.Context, input *sqs.TagQueueInput, _ ...request.Option) (*sqs.TagQueueOutput, error) {
+	return s.TagQueueBehavior.Invoke(input, func(_ *sqs.TagQueueInput) (*sqs.TagQueueOutput, error) {
+		return &sqs.TagQueueOutput{}, nil
+	})
+}
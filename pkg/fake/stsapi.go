@@ -0,0 +1,64 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Pallinder/go-randomdata"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+)
+
+// STSAPIBehavior must be reset between tests otherwise tests will
+// pollute each other.
+type STSAPIBehavior struct {
+	AssumeRoleBehavior MockedFunction[sts.AssumeRoleInput, sts.AssumeRoleOutput]
+}
+
+type STSAPI struct {
+	stsiface.STSAPI
+	STSAPIBehavior
+}
+
+func NewSTSAPI() *STSAPI {
+	return &STSAPI{}
+}
+
+// Reset must be called between tests otherwise tests will pollute
+// each other.
+func (s *STSAPI) Reset() {
+	s.AssumeRoleBehavior.Reset()
+}
+
+func (s *STSAPI) AssumeRoleWithContext(_ context.Context, input *sts.AssumeRoleInput, _ ...request.Option) (*sts.AssumeRoleOutput, error) {
+	return s.AssumeRoleBehavior.Invoke(input, func(input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+		return &sts.AssumeRoleOutput{
+			AssumedRoleUser: &sts.AssumedRoleUser{
+				Arn: aws.String(fmt.Sprintf("%s/%s", aws.StringValue(input.RoleArn), aws.StringValue(input.RoleSessionName))),
+			},
+			Credentials: &sts.Credentials{
+				AccessKeyId:     aws.String(fmt.Sprintf("ASIA%s", randomdata.Alphanumeric(16))),
+				SecretAccessKey: aws.String(randomdata.Alphanumeric(40)),
+				SessionToken:    aws.String(randomdata.Alphanumeric(64)),
+				Expiration:      aws.Time(time.Now().Add(time.Duration(aws.Int64Value(input.DurationSeconds)) * time.Second)),
+			},
+		}, nil
+	})
+}
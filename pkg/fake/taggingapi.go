@@ -0,0 +1,104 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
+	"github.com/samber/lo"
+)
+
+// TaggingAPIBehavior must be reset between tests otherwise tests will
+// pollute each other.
+type TaggingAPIBehavior struct {
+	GetResourcesBehavior MockedFunction[resourcegroupstaggingapi.GetResourcesInput, resourcegroupstaggingapi.GetResourcesOutput]
+}
+
+type TaggingAPI struct {
+	resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI
+	TaggingAPIBehavior
+}
+
+func NewTaggingAPI() *TaggingAPI {
+	return &TaggingAPI{}
+}
+
+// Reset must be called between tests otherwise tests will pollute
+// each other.
+func (t *TaggingAPI) Reset() {
+	t.GetResourcesBehavior.Reset()
+}
+
+func (t *TaggingAPI) GetResourcesPagesWithContext(ctx context.Context, input *resourcegroupstaggingapi.GetResourcesInput, fn func(*resourcegroupstaggingapi.GetResourcesOutput, bool) bool, opts ...request.Option) error {
+	output, err := t.GetResourcesWithContext(ctx, input, opts...)
+	if err != nil {
+		return err
+	}
+	fn(output, true)
+	return nil
+}
+
+func (t *TaggingAPI) GetResourcesWithContext(_ context.Context, input *resourcegroupstaggingapi.GetResourcesInput, _ ...request.Option) (*resourcegroupstaggingapi.GetResourcesOutput, error) {
+	return t.GetResourcesBehavior.Invoke(input, func(input *resourcegroupstaggingapi.GetResourcesInput) (*resourcegroupstaggingapi.GetResourcesOutput, error) {
+		// The default resources mirror the default subnets returned by the fake EC2API
+		resources := []*resourcegroupstaggingapi.ResourceTagMapping{
+			subnetResource("subnet-test1", map[string]string{"Name": "test-subnet-1", "foo": "bar"}),
+			subnetResource("subnet-test2", map[string]string{"Name": "test-subnet-2", "foo": "bar"}),
+			subnetResource("subnet-test3", map[string]string{"Name": "test-subnet-3", "TestTag": "", "foo": "bar"}),
+			subnetResource("subnet-test4", map[string]string{"Name": "test-subnet-4"}),
+		}
+		return &resourcegroupstaggingapi.GetResourcesOutput{
+			ResourceTagMappingList: lo.Filter(resources, func(r *resourcegroupstaggingapi.ResourceTagMapping, _ int) bool {
+				return matchResourceTypes(r, input.ResourceTypeFilters) && matchTagFilters(r, input.TagFilters)
+			}),
+		}, nil
+	})
+}
+
+func subnetResource(id string, tags map[string]string) *resourcegroupstaggingapi.ResourceTagMapping {
+	return &resourcegroupstaggingapi.ResourceTagMapping{
+		ResourceARN: aws.String(fmt.Sprintf("arn:aws:ec2:%s:%s:subnet/%s", DefaultRegion, DefaultAccount, id)),
+		Tags: lo.MapToSlice(tags, func(k, v string) *resourcegroupstaggingapi.Tag {
+			return &resourcegroupstaggingapi.Tag{Key: aws.String(k), Value: aws.String(v)}
+		}),
+	}
+}
+
+func matchResourceTypes(resource *resourcegroupstaggingapi.ResourceTagMapping, resourceTypes []*string) bool {
+	if len(resourceTypes) == 0 {
+		return true
+	}
+	return lo.ContainsBy(resourceTypes, func(resourceType *string) bool {
+		service, typ, _ := strings.Cut(aws.StringValue(resourceType), ":")
+		return strings.Contains(aws.StringValue(resource.ResourceARN), fmt.Sprintf(":%s:", service)) &&
+			strings.Contains(aws.StringValue(resource.ResourceARN), fmt.Sprintf(":%s/", typ))
+	})
+}
+
+// matchTagFilters matches all the tag filters, where a filter without values matches any value of its key
+func matchTagFilters(resource *resourcegroupstaggingapi.ResourceTagMapping, filters []*resourcegroupstaggingapi.TagFilter) bool {
+	return lo.EveryBy(filters, func(filter *resourcegroupstaggingapi.TagFilter) bool {
+		return lo.ContainsBy(resource.Tags, func(tag *resourcegroupstaggingapi.Tag) bool {
+			return aws.StringValue(tag.Key) == aws.StringValue(filter.Key) &&
+				(len(filter.Values) == 0 || lo.Contains(aws.StringValueSlice(filter.Values), aws.StringValue(tag.Value)))
+		})
+	})
+}
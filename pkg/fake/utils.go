@@ -101,9 +101,49 @@ func FilterDescribeSubnets(subnets []*ec2.Subnet, filters []*ec2.Filter) []*ec2.
 	})
 }
 
+// FilterDescribeCapacityReservations filters the passed in capacity reservations based on the ids and filters passed in.
+// Filters are chained with a logical "AND"
+func FilterDescribeCapacityReservations(capacityReservations []*ec2.CapacityReservation, ids []*string, filters []*ec2.Filter) []*ec2.CapacityReservation {
+	stateFilters := lo.Filter(filters, func(filter *ec2.Filter, _ int) bool { return aws.StringValue(filter.Name) == "state" })
+	filters = lo.Reject(filters, func(filter *ec2.Filter, _ int) bool { return aws.StringValue(filter.Name) == "state" })
+	return lo.Filter(capacityReservations, func(capacityReservation *ec2.CapacityReservation, _ int) bool {
+		if len(ids) > 0 && !lo.Contains(aws.StringValueSlice(ids), aws.StringValue(capacityReservation.CapacityReservationId)) {
+			return false
+		}
+		if !lo.EveryBy(stateFilters, func(filter *ec2.Filter) bool {
+			return lo.Contains(aws.StringValueSlice(filter.Values), aws.StringValue(capacityReservation.State))
+		}) {
+			return false
+		}
+		return Filter(filters, *capacityReservation.CapacityReservationId, "", capacityReservation.Tags)
+	})
+}
+
+// FilterDescribeNetworkInterfaces filters the passed in network interfaces based on the filters passed in.
+// Filters are chained with a logical "AND"
+func FilterDescribeNetworkInterfaces(networkInterfaces []*ec2.NetworkInterface, filters []*ec2.Filter) []*ec2.NetworkInterface {
+	statusFilters := lo.Filter(filters, func(filter *ec2.Filter, _ int) bool { return aws.StringValue(filter.Name) == "status" })
+	filters = lo.Reject(filters, func(filter *ec2.Filter, _ int) bool { return aws.StringValue(filter.Name) == "status" })
+	return lo.Filter(networkInterfaces, func(networkInterface *ec2.NetworkInterface, _ int) bool {
+		if !lo.EveryBy(statusFilters, func(filter *ec2.Filter) bool {
+			return lo.Contains(aws.StringValueSlice(filter.Values), aws.StringValue(networkInterface.Status))
+		}) {
+			return false
+		}
+		return Filter(filters, aws.StringValue(networkInterface.NetworkInterfaceId), "", networkInterface.TagSet)
+	})
+}
+
 func FilterDescribeImages(images []*ec2.Image, filters []*ec2.Filter) []*ec2.Image {
+	architectureFilters, filters := lo.Filter(filters, func(filter *ec2.Filter, _ int) bool {
+		return aws.StringValue(filter.Name) == "architecture"
+	}), lo.Reject(filters, func(filter *ec2.Filter, _ int) bool {
+		return aws.StringValue(filter.Name) == "architecture"
+	})
 	return lo.Filter(images, func(image *ec2.Image, _ int) bool {
-		return Filter(filters, *image.ImageId, *image.Name, image.Tags)
+		return lo.EveryBy(architectureFilters, func(filter *ec2.Filter) bool {
+			return lo.Contains(aws.StringValueSlice(filter.Values), aws.StringValue(image.Architecture))
+		}) && Filter(filters, *image.ImageId, *image.Name, image.Tags)
 	})
 }
 
@@ -34,13 +34,16 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			NitroEnclavesSupport:          aws.String("supported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("AWS"),
 				SupportedArchitectures: aws.StringSlice([]string{"arm64"}),
 			},
 			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(2),
-				DefaultVCpus: aws.Int64(2),
+				DefaultCores:          aws.Int64(2),
+				DefaultVCpus:          aws.Int64(2),
+				DefaultThreadsPerCore: aws.Int64(1),
+				ValidThreadsPerCore:   aws.Int64Slice([]int64{1}),
 			},
 			MemoryInfo: &ec2.MemoryInfo{
 				SizeInMiB: aws.Int64(4096),
@@ -78,13 +81,16 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			NitroEnclavesSupport:          aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
 			},
 			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(48),
-				DefaultVCpus: aws.Int64(96),
+				DefaultCores:          aws.Int64(48),
+				DefaultVCpus:          aws.Int64(96),
+				DefaultThreadsPerCore: aws.Int64(2),
+				ValidThreadsPerCore:   aws.Int64Slice([]int64{1, 2}),
 			},
 			MemoryInfo: &ec2.MemoryInfo{
 				SizeInMiB: aws.Int64(786432),
@@ -152,13 +158,17 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			NitroEnclavesSupport:          aws.String("supported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
 			},
 			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(16),
-				DefaultVCpus: aws.Int64(32),
+				DefaultCores:          aws.Int64(16),
+				DefaultVCpus:          aws.Int64(32),
+				DefaultThreadsPerCore: aws.Int64(2),
+				ValidCores:            aws.Int64Slice([]int64{2, 4, 6, 8, 10, 12, 14, 16}),
+				ValidThreadsPerCore:   aws.Int64Slice([]int64{1, 2}),
 			},
 			MemoryInfo: &ec2.MemoryInfo{
 				SizeInMiB: aws.Int64(131072),
@@ -214,13 +224,17 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			NitroEnclavesSupport:          aws.String("supported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
 			},
 			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(4),
-				DefaultVCpus: aws.Int64(8),
+				DefaultCores:          aws.Int64(4),
+				DefaultVCpus:          aws.Int64(8),
+				DefaultThreadsPerCore: aws.Int64(2),
+				ValidCores:            aws.Int64Slice([]int64{2, 4}),
+				ValidThreadsPerCore:   aws.Int64Slice([]int64{1, 2}),
 			},
 			MemoryInfo: &ec2.MemoryInfo{
 				SizeInMiB: aws.Int64(16384),
@@ -267,13 +281,17 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			NitroEnclavesSupport:          aws.String("supported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
 			},
 			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(12),
-				DefaultVCpus: aws.Int64(24),
+				DefaultCores:          aws.Int64(12),
+				DefaultVCpus:          aws.Int64(24),
+				DefaultThreadsPerCore: aws.Int64(2),
+				ValidCores:            aws.Int64Slice([]int64{2, 4, 6, 8, 10, 12}),
+				ValidThreadsPerCore:   aws.Int64Slice([]int64{1, 2}),
 			},
 			MemoryInfo: &ec2.MemoryInfo{
 				SizeInMiB: aws.Int64(49152),
@@ -320,13 +338,17 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			NitroEnclavesSupport:          aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
 			},
 			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(1),
-				DefaultVCpus: aws.Int64(2),
+				DefaultCores:          aws.Int64(1),
+				DefaultVCpus:          aws.Int64(2),
+				DefaultThreadsPerCore: aws.Int64(2),
+				ValidCores:            aws.Int64Slice([]int64{1}),
+				ValidThreadsPerCore:   aws.Int64Slice([]int64{1, 2}),
 			},
 			MemoryInfo: &ec2.MemoryInfo{
 				SizeInMiB: aws.Int64(8192),
@@ -364,13 +386,15 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(true),
 			Hypervisor:                    aws.String(""),
+			NitroEnclavesSupport:          aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
 			},
 			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(48),
-				DefaultVCpus: aws.Int64(96),
+				DefaultCores:          aws.Int64(48),
+				DefaultVCpus:          aws.Int64(96),
+				DefaultThreadsPerCore: aws.Int64(2),
 			},
 			MemoryInfo: &ec2.MemoryInfo{
 				SizeInMiB: aws.Int64(393216),
@@ -408,13 +432,17 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			NitroEnclavesSupport:          aws.String("supported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
 			},
 			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(2),
-				DefaultVCpus: aws.Int64(4),
+				DefaultCores:          aws.Int64(2),
+				DefaultVCpus:          aws.Int64(4),
+				DefaultThreadsPerCore: aws.Int64(2),
+				ValidCores:            aws.Int64Slice([]int64{2}),
+				ValidThreadsPerCore:   aws.Int64Slice([]int64{1, 2}),
 			},
 			MemoryInfo: &ec2.MemoryInfo{
 				SizeInMiB: aws.Int64(16384),
@@ -452,13 +480,16 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			NitroEnclavesSupport:          aws.String("supported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
 			},
 			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(64),
-				DefaultVCpus: aws.Int64(128),
+				DefaultCores:          aws.Int64(64),
+				DefaultVCpus:          aws.Int64(128),
+				DefaultThreadsPerCore: aws.Int64(2),
+				ValidThreadsPerCore:   aws.Int64Slice([]int64{1, 2}),
 			},
 			MemoryInfo: &ec2.MemoryInfo{
 				SizeInMiB: aws.Int64(524288),
@@ -506,13 +537,17 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("xen"),
+			NitroEnclavesSupport:          aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
 			},
 			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(16),
-				DefaultVCpus: aws.Int64(32),
+				DefaultCores:          aws.Int64(16),
+				DefaultVCpus:          aws.Int64(32),
+				DefaultThreadsPerCore: aws.Int64(2),
+				ValidCores:            aws.Int64Slice([]int64{2, 4, 6, 8, 10, 12, 14, 16}),
+				ValidThreadsPerCore:   aws.Int64Slice([]int64{1, 2}),
 			},
 			MemoryInfo: &ec2.MemoryInfo{
 				SizeInMiB: aws.Int64(249856),
@@ -562,13 +597,17 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(true),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			NitroEnclavesSupport:          aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
 			},
 			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(1),
-				DefaultVCpus: aws.Int64(2),
+				DefaultCores:          aws.Int64(1),
+				DefaultVCpus:          aws.Int64(2),
+				DefaultThreadsPerCore: aws.Int64(2),
+				ValidCores:            aws.Int64Slice([]int64{1}),
+				ValidThreadsPerCore:   aws.Int64Slice([]int64{1, 2}),
 			},
 			MemoryInfo: &ec2.MemoryInfo{
 				SizeInMiB: aws.Int64(8192),
@@ -606,13 +645,16 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(true),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			NitroEnclavesSupport:          aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("AWS"),
 				SupportedArchitectures: aws.StringSlice([]string{"arm64"}),
 			},
 			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(2),
-				DefaultVCpus: aws.Int64(2),
+				DefaultCores:          aws.Int64(2),
+				DefaultVCpus:          aws.Int64(2),
+				DefaultThreadsPerCore: aws.Int64(1),
+				ValidThreadsPerCore:   aws.Int64Slice([]int64{1}),
 			},
 			MemoryInfo: &ec2.MemoryInfo{
 				SizeInMiB: aws.Int64(4096),
@@ -650,13 +692,16 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(true),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			NitroEnclavesSupport:          aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("AWS"),
 				SupportedArchitectures: aws.StringSlice([]string{"arm64"}),
 			},
 			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(2),
-				DefaultVCpus: aws.Int64(2),
+				DefaultCores:          aws.Int64(2),
+				DefaultVCpus:          aws.Int64(2),
+				DefaultThreadsPerCore: aws.Int64(1),
+				ValidThreadsPerCore:   aws.Int64Slice([]int64{1}),
 			},
 			MemoryInfo: &ec2.MemoryInfo{
 				SizeInMiB: aws.Int64(2048),
@@ -694,13 +739,16 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(true),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			NitroEnclavesSupport:          aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("AWS"),
 				SupportedArchitectures: aws.StringSlice([]string{"arm64"}),
 			},
 			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(4),
-				DefaultVCpus: aws.Int64(4),
+				DefaultCores:          aws.Int64(4),
+				DefaultVCpus:          aws.Int64(4),
+				DefaultThreadsPerCore: aws.Int64(1),
+				ValidThreadsPerCore:   aws.Int64Slice([]int64{1}),
 			},
 			MemoryInfo: &ec2.MemoryInfo{
 				SizeInMiB: aws.Int64(16384),
@@ -738,13 +786,16 @@ var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
 			Hypervisor:                    aws.String("nitro"),
+			NitroEnclavesSupport:          aws.String("unsupported"),
 			ProcessorInfo: &ec2.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
 				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
 			},
 			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(4),
-				DefaultVCpus: aws.Int64(8),
+				DefaultCores:          aws.Int64(4),
+				DefaultVCpus:          aws.Int64(8),
+				DefaultThreadsPerCore: aws.Int64(2),
+				ValidThreadsPerCore:   aws.Int64Slice([]int64{1, 2}),
 			},
 			MemoryInfo: &ec2.MemoryInfo{
 				SizeInMiB: aws.Int64(32768),
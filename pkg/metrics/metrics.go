@@ -0,0 +1,74 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	providerSubsystem = "provider"
+	providerLabel     = "provider"
+	cacheHitLabel     = "cache_hit"
+)
+
+// The provider types that resolution durations are recorded for
+const (
+	ProviderTypeSubnet        = "subnet"
+	ProviderTypeSecurityGroup = "security_group"
+	ProviderTypeAMI           = "ami"
+	ProviderTypeInstanceType  = "instance_type"
+)
+
+var (
+	ResolutionDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: providerSubsystem,
+			Name:      "resolution_duration_seconds",
+			Help:      "Duration of resolving the selectors of a nodeclass by a provider in seconds, by the type of the provider and whether the result was served from its cache.",
+			Buckets:   metrics.DurationBuckets(),
+		},
+		[]string{
+			providerLabel,
+			cacheHitLabel,
+		},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(ResolutionDuration)
+}
+
+// MeasureResolution starts measuring a resolution by the provider. The returned function records the duration when it's
+// called, with whether the result was served from the cache at that point, so it's meant to be deferred, e.g.
+//
+//	var cacheHit bool
+//	defer metrics.MeasureResolution(metrics.ProviderTypeSubnet)(&cacheHit)
+func MeasureResolution(provider string) func(cacheHit *bool) {
+	start := time.Now()
+	return func(cacheHit *bool) {
+		ResolutionDuration.With(prometheus.Labels{
+			providerLabel: provider,
+			cacheHitLabel: strconv.FormatBool(*cacheHit),
+		}).Observe(time.Since(start).Seconds())
+	}
+}
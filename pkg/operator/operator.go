@@ -24,8 +24,10 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	awsclient "github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/endpoints"
@@ -36,32 +38,44 @@ import (
 	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/aws/aws-sdk-go/service/eks/eksiface"
 	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/imagebuilder"
+	awspricing "github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
 	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel"
+	"golang.org/x/sync/semaphore"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/transport"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/operator"
 	"sigs.k8s.io/karpenter/pkg/operator/scheme"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/batcher"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityreservation"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/placementscore"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/version"
+	"github.com/aws/karpenter-provider-aws/pkg/tracing"
 )
 
 func init() {
@@ -69,23 +83,50 @@ func init() {
 	corev1beta1.NormalizedLabels = lo.Assign(corev1beta1.NormalizedLabels, map[string]string{"topology.ebs.csi.aws.com/zone": corev1.LabelTopologyZone})
 }
 
+// InstanceTypeFilter is applied by the instance type provider as the final stage of listing instance types. Downstream
+// builds can register their own filter by setting it before calling NewOperator.
+var InstanceTypeFilter instancetype.InstanceTypeFilter = instancetype.NoopInstanceTypeFilter{}
+
+// TagMutator is invoked by the instance provider with the tags that are about to be applied at launch. Downstream
+// builds can register their own mutator by setting it before calling NewOperator.
+var TagMutator instance.TagMutator = instance.NoopTagMutator{}
+
 // Operator is injected into the AWS CloudProvider's factories
 type Operator struct {
 	*operator.Operator
 
-	Session                   *session.Session
-	UnavailableOfferingsCache *awscache.UnavailableOfferings
-	EC2API                    ec2iface.EC2API
-	SubnetProvider            subnet.Provider
-	SecurityGroupProvider     securitygroup.Provider
-	InstanceProfileProvider   instanceprofile.Provider
-	AMIProvider               amifamily.Provider
-	AMIResolver               *amifamily.Resolver
-	LaunchTemplateProvider    launchtemplate.Provider
-	PricingProvider           pricing.Provider
-	VersionProvider           version.Provider
-	InstanceTypesProvider     instancetype.Provider
-	InstanceProvider          instance.Provider
+	Session                     *session.Session
+	UnavailableOfferingsCache   *awscache.UnavailableOfferings
+	EC2API                      ec2iface.EC2API
+	SubnetProvider              subnet.Provider
+	SecurityGroupProvider       securitygroup.Provider
+	CapacityReservationProvider capacityreservation.Provider
+	InstanceProfileProvider     instanceprofile.Provider
+	AMIProvider                 amifamily.Provider
+	AMIResolver                 *amifamily.Resolver
+	LaunchTemplateProvider      launchtemplate.Provider
+	PricingProvider             pricing.Provider
+	VersionProvider             version.Provider
+	InstanceTypesProvider       instancetype.Provider
+	PlacementScoreProvider      placementscore.Provider
+	InstanceProvider            instance.Provider
+	AccountProvider             account.Provider
+}
+
+// fipsEndpointsIDs are the endpoints IDs of the services that use their FIPS endpoints when FIPS endpoints are enabled
+var fipsEndpointsIDs = []string{ec2.EndpointsID, ssm.EndpointsID}
+
+// ClientConfig returns the configuration of the client for the service with the endpoints ID. The endpoint of the
+// service is overridden, or its FIPS endpoint is used, as configured in the options.
+func ClientConfig(ctx context.Context, endpointsID string) *aws.Config {
+	config := &aws.Config{}
+	if options.FromContext(ctx).FIPSEndpoints && lo.Contains(fipsEndpointsIDs, endpointsID) {
+		config.UseFIPSEndpoint = endpoints.FIPSEndpointStateEnabled
+	}
+	if endpoint := options.FromContext(ctx).ServiceEndpoints()[endpointsID]; endpoint != "" {
+		config.Endpoint = aws.String(endpoint)
+	}
+	return config
 }
 
 func NewOperator(ctx context.Context, operator *operator.Operator) (context.Context, *Operator) {
@@ -110,7 +151,7 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		region, err := ec2metadata.New(sess).Region()
 		*sess.Config.Region = lo.Must(region, err, "failed to get region from metadata server")
 	}
-	ec2api := ec2.New(sess)
+	ec2api := batcher.NewRateLimitedEC2API(ec2.New(sess, ClientConfig(ctx, ec2.EndpointsID)), "", options.FromContext(ctx).EC2OperationQPS())
 	if err := CheckEC2Connectivity(ctx, ec2api); err != nil {
 		log.FromContext(ctx).Error(err, "ec2 api connectivity check failed")
 		os.Exit(1)
@@ -133,28 +174,52 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		log.FromContext(ctx).WithValues("kube-dns-ip", kubeDNSIP).V(1).Info("discovered kube dns")
 	}
 
+	if options.FromContext(ctx).Tracing {
+		tracerProvider := lo.Must(tracing.NewTracerProvider(ctx))
+		otel.SetTracerProvider(tracerProvider)
+		// Flush the spans that haven't been exported yet when the operator stops
+		lo.Must0(operator.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return tracerProvider.Shutdown(context.Background())
+		})))
+	}
+
 	unavailableOfferingsCache := awscache.NewUnavailableOfferings()
-	subnetProvider := subnet.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval))
-	securityGroupProvider := securitygroup.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
-	instanceProfileProvider := instanceprofile.NewDefaultProvider(*sess.Config.Region, iam.New(sess), cache.New(awscache.InstanceProfileTTL, awscache.DefaultCleanupInterval))
+	subnetProvider := subnet.NewDefaultProvider(ec2api, resourcegroupstaggingapi.New(sess), cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval))
+	securityGroupProvider := securitygroup.NewDefaultProvider(ec2api, eks.New(sess), cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	capacityReservationProvider := capacityreservation.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	instanceProfileProvider := instanceprofile.NewDefaultProvider(*sess.Config.Region, iam.New(sess), cache.New(awscache.InstanceProfileTTL, awscache.DefaultCleanupInterval), cache.New(awscache.InstanceProfileRoleTTL, awscache.DefaultCleanupInterval))
 	pricingProvider := pricing.NewDefaultProvider(
 		ctx,
-		pricing.NewAPI(sess, *sess.Config.Region),
+		pricing.NewAPI(sess, *sess.Config.Region, ClientConfig(ctx, awspricing.EndpointsID)),
 		ec2api,
 		*sess.Config.Region,
 	)
 	versionProvider := version.NewDefaultProvider(operator.KubernetesInterface, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
-	amiProvider := amifamily.NewDefaultProvider(versionProvider, ssm.New(sess), ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	// A configured version pins the version that AMIs are resolved for, so it's checked up front rather than failing
+	// every AMI resolution
+	if kubernetesVersion := options.FromContext(ctx).KubernetesVersion; kubernetesVersion != "" {
+		if err := version.ValidateK8sVersion(kubernetesVersion); err != nil {
+			log.FromContext(ctx).Error(err, "failed validating kubernetes-version")
+			os.Exit(1)
+		}
+	}
+	// The limiter is shared with the AMI providers of the other accounts, so that AMI resolution stays under a single cap
+	amiResolutionLimiter := semaphore.NewWeighted(int64(options.FromContext(ctx).AMIResolutionConcurrency))
+	amiProvider := amifamily.NewDefaultProvider(*sess.Config.Region, versionProvider, ssm.New(sess, ClientConfig(ctx, ssm.EndpointsID)), ec2api, imagebuilder.New(sess), cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), amiResolutionLimiter)
 	amiResolver := amifamily.NewResolver(amiProvider)
+	caBundle := lo.Must(GetCABundle(ctx, operator.GetConfig()))
+	clusterCache := cache.New(awscache.ClusterTTL, awscache.DefaultCleanupInterval)
 	launchTemplateProvider := launchtemplate.NewDefaultProvider(
 		ctx,
 		cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval),
+		clusterCache,
 		ec2api,
 		eks.New(sess),
 		amiResolver,
 		securityGroupProvider,
 		subnetProvider,
-		lo.Must(GetCABundle(ctx, operator.GetConfig())),
+		caBundle,
 		operator.Elected(),
 		kubeDNSIP,
 		clusterEndpoint,
@@ -162,11 +227,14 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 	instanceTypeProvider := instancetype.NewDefaultProvider(
 		*sess.Config.Region,
 		cache.New(awscache.InstanceTypesAndZonesTTL, awscache.DefaultCleanupInterval),
+		cache.New(awscache.InstanceTypesAndZonesTTL, awscache.DefaultCleanupInterval),
 		ec2api,
 		subnetProvider,
 		unavailableOfferingsCache,
 		pricingProvider,
+		InstanceTypeFilter,
 	)
+	placementScoreProvider := placementscore.NewDefaultProvider(aws.StringValue(sess.Config.Region), ec2api)
 	instanceProvider := instance.NewDefaultProvider(
 		ctx,
 		aws.StringValue(sess.Config.Region),
@@ -175,23 +243,110 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		instanceTypeProvider,
 		subnetProvider,
 		launchTemplateProvider,
+		placementScoreProvider,
+		TagMutator,
+	)
+	// EC2NodeClasses that assume a role discover and manage their resources in the account of the role. The cluster and
+	// pricing are shared with the account that Karpenter runs in, while the offerings of instance types are discovered
+	// in each account, since zone names map to different zones in each account.
+	accountProvider := account.NewDefaultProvider(
+		operator.GetClient(),
+		sts.New(sess),
+		&account.Providers{
+			EC2API:                      ec2api,
+			SubnetProvider:              subnetProvider,
+			SecurityGroupProvider:       securityGroupProvider,
+			CapacityReservationProvider: capacityReservationProvider,
+			InstanceProfileProvider:     instanceProfileProvider,
+			AMIProvider:                 amiProvider,
+			LaunchTemplateProvider:      launchTemplateProvider,
+			InstanceTypeProvider:        instanceTypeProvider,
+			InstanceProvider:            instanceProvider,
+			UnavailableOfferings:        unavailableOfferingsCache,
+		},
+		func(roleARN string, credentials *credentials.Credentials) *account.Providers {
+			accountSess := sess.Copy(&aws.Config{Credentials: credentials})
+			accountEC2API := batcher.NewRateLimitedEC2API(ec2.New(accountSess, ClientConfig(ctx, ec2.EndpointsID)), accountID(roleARN), options.FromContext(ctx).EC2OperationQPS())
+			accountSubnetProvider := subnet.NewDefaultProvider(accountEC2API, resourcegroupstaggingapi.New(accountSess), cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval))
+			accountSecurityGroupProvider := securitygroup.NewDefaultProvider(accountEC2API, eks.New(sess), cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+			accountAMIProvider := amifamily.NewDefaultProvider(*sess.Config.Region, versionProvider, ssm.New(accountSess, ClientConfig(ctx, ssm.EndpointsID)), accountEC2API, imagebuilder.New(accountSess), cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), amiResolutionLimiter)
+			accountLaunchTemplateProvider := launchtemplate.NewDefaultProvider(
+				ctx,
+				cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval),
+				clusterCache,
+				accountEC2API,
+				eks.New(sess),
+				amifamily.NewResolver(accountAMIProvider),
+				accountSecurityGroupProvider,
+				accountSubnetProvider,
+				caBundle,
+				operator.Elected(),
+				kubeDNSIP,
+				clusterEndpoint,
+			)
+			accountUnavailableOfferings := awscache.NewUnavailableOfferings()
+			accountInstanceTypeProvider := instancetype.NewDefaultProvider(
+				*sess.Config.Region,
+				cache.New(awscache.InstanceTypesAndZonesTTL, awscache.DefaultCleanupInterval),
+				cache.New(awscache.InstanceTypesAndZonesTTL, awscache.DefaultCleanupInterval),
+				accountEC2API,
+				accountSubnetProvider,
+				accountUnavailableOfferings,
+				pricingProvider,
+				InstanceTypeFilter,
+			)
+			// The instance types of the account are discovered when its providers are built, and refreshed by the
+			// instance type controller afterwards
+			if err := accountInstanceTypeProvider.UpdateInstanceTypes(ctx); err != nil {
+				log.FromContext(ctx).Error(err, "failed discovering instance types for assumed role")
+			}
+			if err := accountInstanceTypeProvider.UpdateInstanceTypeOfferings(ctx); err != nil {
+				log.FromContext(ctx).Error(err, "failed discovering instance type offerings for assumed role")
+			}
+			return &account.Providers{
+				EC2API:                      accountEC2API,
+				SubnetProvider:              accountSubnetProvider,
+				SecurityGroupProvider:       accountSecurityGroupProvider,
+				CapacityReservationProvider: capacityreservation.NewDefaultProvider(accountEC2API, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)),
+				InstanceProfileProvider:     instanceprofile.NewDefaultProvider(*sess.Config.Region, iam.New(accountSess), cache.New(awscache.InstanceProfileTTL, awscache.DefaultCleanupInterval), cache.New(awscache.InstanceProfileRoleTTL, awscache.DefaultCleanupInterval)),
+				AMIProvider:                 accountAMIProvider,
+				LaunchTemplateProvider:      accountLaunchTemplateProvider,
+				InstanceTypeProvider:        accountInstanceTypeProvider,
+				InstanceProvider: instance.NewDefaultProvider(
+					ctx,
+					aws.StringValue(sess.Config.Region),
+					accountEC2API,
+					accountUnavailableOfferings,
+					accountInstanceTypeProvider,
+					accountSubnetProvider,
+					accountLaunchTemplateProvider,
+					placementScoreProvider,
+					TagMutator,
+				),
+				UnavailableOfferings: accountUnavailableOfferings,
+			}
+		},
+		func(provider *stscreds.AssumeRoleProvider) { SetDurationAndExpiry(ctx, provider) },
 	)
 
 	return ctx, &Operator{
-		Operator:                  operator,
-		Session:                   sess,
-		UnavailableOfferingsCache: unavailableOfferingsCache,
-		EC2API:                    ec2api,
-		SubnetProvider:            subnetProvider,
-		SecurityGroupProvider:     securityGroupProvider,
-		InstanceProfileProvider:   instanceProfileProvider,
-		AMIProvider:               amiProvider,
-		AMIResolver:               amiResolver,
-		VersionProvider:           versionProvider,
-		LaunchTemplateProvider:    launchTemplateProvider,
-		PricingProvider:           pricingProvider,
-		InstanceTypesProvider:     instanceTypeProvider,
-		InstanceProvider:          instanceProvider,
+		Operator:                    operator,
+		Session:                     sess,
+		UnavailableOfferingsCache:   unavailableOfferingsCache,
+		EC2API:                      ec2api,
+		SubnetProvider:              subnetProvider,
+		SecurityGroupProvider:       securityGroupProvider,
+		CapacityReservationProvider: capacityReservationProvider,
+		InstanceProfileProvider:     instanceProfileProvider,
+		AMIProvider:                 amiProvider,
+		AMIResolver:                 amiResolver,
+		VersionProvider:             versionProvider,
+		LaunchTemplateProvider:      launchTemplateProvider,
+		PricingProvider:             pricingProvider,
+		InstanceTypesProvider:       instanceTypeProvider,
+		PlacementScoreProvider:      placementScoreProvider,
+		InstanceProvider:            instanceProvider,
+		AccountProvider:             accountProvider,
 	}
 }
 
@@ -265,3 +420,12 @@ func SetDurationAndExpiry(ctx context.Context, provider *stscreds.AssumeRoleProv
 	provider.Duration = options.FromContext(ctx).AssumeRoleDuration
 	provider.ExpiryWindow = time.Duration(10) * time.Second
 }
+
+// accountID returns the ID of the account of the role, or the role ARN itself if it can't be parsed
+func accountID(roleARN string) string {
+	parsed, err := arn.Parse(roleARN)
+	if err != nil {
+		return roleARN
+	}
+	return parsed.AccountID
+}
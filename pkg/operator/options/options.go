@@ -20,8 +20,13 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/utils/env"
 )
@@ -33,15 +38,59 @@ func init() {
 type optionsKey struct{}
 
 type Options struct {
-	AssumeRoleARN           string
-	AssumeRoleDuration      time.Duration
-	ClusterCABundle         string
-	ClusterName             string
-	ClusterEndpoint         string
-	IsolatedVPC             bool
-	VMMemoryOverheadPercent float64
-	InterruptionQueue       string
-	ReservedENIs            int
+	AssumeRoleARN                 string
+	AssumeRoleDuration            time.Duration
+	ClusterCABundle               string
+	ClusterName                   string
+	ClusterEndpoint               string
+	IsolatedVPC                   bool
+	VMMemoryOverheadPercent       float64
+	InterruptionQueue             string
+	InterruptionQueueTagging      bool
+	InterruptionScheduledChanges  bool
+	InterruptionBatchSize         int
+	InterruptionVisibilityTimeout time.Duration
+	InterruptionWorkers           int
+	InterruptionDisruptionLimit   int
+	InterruptionEvictionGrace     time.Duration
+	InterruptionRebalanceReplace  bool
+	InterruptionWaitTime          time.Duration
+	InterruptionReceiveTimeout    time.Duration
+	ReservedENIs                  int
+	InstanceTypeFamilies          string
+	InstanceTypeOfferingsCacheTTL time.Duration
+	InstanceTypesRefreshInterval  time.Duration
+	EC2CreateFleetQPS             float64
+	EC2DescribeInstancesQPS       float64
+	EC2TerminateInstancesQPS      float64
+	EC2CreateTagsQPS              float64
+	NewerGenerationPriceThreshold float64
+	SubnetDiscoveryTaggingAPI     bool
+	CreateFleetClientToken        bool
+	SpotPlacementScore            bool
+	ZoneCapacityCooldownThreshold int
+	LaunchTemplateDebugEndpoint   bool
+	ReadableLaunchTemplateNames   bool
+	MaxNodeClassLaunchBatchSize   int
+	EC2Endpoint                   string
+	SSMEndpoint                   string
+	PricingEndpoint               string
+	FIPSEndpoints                 bool
+	AnnotateInstanceCapabilities  bool
+	NotReadyTimeout               time.Duration
+	MaxStoppedInstanceAge         time.Duration
+	OnDemandAllocationStrategy    string
+	OnDemandFamilyPriority        string
+	Tracing                       bool
+	TracingEndpoint               string
+	SubnetPriorityTagKey          string
+	KubernetesVersion             string
+	PricingRefreshInterval        time.Duration
+	PricingRefreshJitter          time.Duration
+	AMISelectorFallback           bool
+	ClusterDiscovery              bool
+	NetworkingReserved            string
+	AMIResolutionConcurrency      int
 }
 
 func (o *Options) AddFlags(fs *coreoptions.FlagSet) {
@@ -53,7 +102,51 @@ func (o *Options) AddFlags(fs *coreoptions.FlagSet) {
 	fs.BoolVarWithEnv(&o.IsolatedVPC, "isolated-vpc", "ISOLATED_VPC", false, "If true, then assume we can't reach AWS services which don't have a VPC endpoint. This also has the effect of disabling look-ups to the AWS on-demand pricing endpoint.")
 	fs.Float64Var(&o.VMMemoryOverheadPercent, "vm-memory-overhead-percent", env.WithDefaultFloat64("VM_MEMORY_OVERHEAD_PERCENT", 0.075), "The VM memory overhead as a percent that will be subtracted from the total memory for all instance types.")
 	fs.StringVar(&o.InterruptionQueue, "interruption-queue", env.WithDefaultString("INTERRUPTION_QUEUE", ""), "Interruption queue is the name of the SQS queue used for processing interruption events from EC2. Interruption handling is disabled if not specified. Enabling interruption handling may require additional permissions on the controller service account. Additional permissions are outlined in the docs.")
+	fs.BoolVarWithEnv(&o.InterruptionQueueTagging, "interruption-queue-tagging", "INTERRUPTION_QUEUE_TAGGING", false, "If true, the interruption queue is tagged with cluster ownership at startup. Requires the sqs:TagQueue permission on the controller service account.")
+	fs.BoolVarWithEnv(&o.InterruptionScheduledChanges, "interruption-scheduled-changes", "INTERRUPTION_SCHEDULED_CHANGES", true, "If true, AWS Health scheduled change events (e.g. planned instance retirement) received on the interruption queue gracefully disrupt the affected nodes ahead of the scheduled change.")
+	fs.IntVar(&o.InterruptionBatchSize, "interruption-batch-size", env.WithDefaultInt("INTERRUPTION_BATCH_SIZE", 10), "The maximum number of messages received from the interruption queue in a single poll. Must be between 1 and 10.")
+	fs.DurationVar(&o.InterruptionVisibilityTimeout, "interruption-visibility-timeout", env.WithDefaultDuration("INTERRUPTION_VISIBILITY_TIMEOUT", 20*time.Second), "The duration that received interruption messages are hidden from subsequent polls. Messages that fail to be handled are re-delivered once this timeout elapses. Must be between 1s and 12h.")
+	fs.IntVar(&o.InterruptionWorkers, "interruption-workers", env.WithDefaultInt("INTERRUPTION_WORKERS", 10), "The maximum number of interruption messages that are handled concurrently.")
+	fs.IntVar(&o.InterruptionDisruptionLimit, "interruption-disruption-limit", env.WithDefaultInt("INTERRUPTION_DISRUPTION_LIMIT", 0), "The maximum number of NodeClaims that may be disrupted by interruption events at once. Interruptions beyond the limit are deferred until in-flight disruptions complete. Spot interruptions and state changes are never deferred since they have hard deadlines, but they count toward the limit. A value of 0 disables the limit.")
+	fs.DurationVar(&o.InterruptionEvictionGrace, "interruption-eviction-grace-period", env.WithDefaultDuration("INTERRUPTION_EVICTION_GRACE_PERIOD", 0), "The maximum grace period given to pods that are evicted as soon as a spot interruption warning is received. When set, the node is cordoned and its pods are evicted immediately, with termination grace periods capped at this value, rather than waiting for the standard termination flow. A value of 0 disables immediate eviction.")
+	fs.BoolVarWithEnv(&o.InterruptionRebalanceReplace, "interruption-rebalance-replacement", "INTERRUPTION_REBALANCE_REPLACEMENT", false, "If true, NodeClaims whose spot instance receives a rebalance recommendation on the interruption queue are annotated with karpenter.k8s.aws/rebalance-recommended and replaced through drift, so the replacement is launched within the NodePool's limits and disruption budgets and the node is only drained once its replacement is initialized. Requires the Drift feature gate.")
+	fs.DurationVar(&o.InterruptionWaitTime, "interruption-wait-time", env.WithDefaultDuration("INTERRUPTION_WAIT_TIME", 20*time.Second), "The duration that a poll of the interruption queue waits for messages to arrive before returning empty (long polling). Must be between 0 and 20s, and is rounded down to whole seconds. A value of 0 uses short polling, with the queue polled again after 1s when it is empty.")
+	fs.DurationVar(&o.InterruptionReceiveTimeout, "interruption-receive-timeout", env.WithDefaultDuration("INTERRUPTION_RECEIVE_TIMEOUT", 30*time.Second), "The maximum duration of a single poll of the interruption queue, including retries. Must be greater than interruption-wait-time.")
 	fs.IntVar(&o.ReservedENIs, "reserved-enis", env.WithDefaultInt("RESERVED_ENIS", 0), "Reserved ENIs are not included in the calculations for max-pods or kube-reserved. This is most often used in the VPC CNI custom networking setup https://docs.aws.amazon.com/eks/latest/userguide/cni-custom-network.html.")
+	fs.StringVar(&o.InstanceTypeFamilies, "instance-type-families", env.WithDefaultString("INSTANCE_TYPE_FAMILIES", ""), "Comma separated list of instance families (e.g. m5,c6g) used to scope instance type offering discovery. All instance families in the region are discovered if not specified.")
+	fs.DurationVar(&o.InstanceTypeOfferingsCacheTTL, "instance-type-offerings-cache-ttl", env.WithDefaultDuration("INSTANCE_TYPE_OFFERINGS_CACHE_TTL", 5*time.Minute), "The duration that instance type offerings are cached for. Offerings are cached separately for each account, since zone names map to different zones in each account, and are refreshed when the instance type families change. A value of 0 disables the cache.")
+	fs.DurationVar(&o.InstanceTypesRefreshInterval, "instance-types-refresh-interval", env.WithDefaultDuration("INSTANCE_TYPES_REFRESH_INTERVAL", 12*time.Hour), "The interval at which instance types and their offerings are refreshed from EC2, so that new instance types and zones are discovered without a restart. Must be greater than 0.")
+	fs.Float64Var(&o.EC2CreateFleetQPS, "ec2-createfleet-qps", env.WithDefaultFloat64("EC2_CREATEFLEET_QPS", 0), "The maximum rate of EC2 CreateFleet calls per second. Calls over the limit wait rather than fail. Client-side rate limiting is disabled for the operation if set to 0.")
+	fs.Float64Var(&o.EC2DescribeInstancesQPS, "ec2-describeinstances-qps", env.WithDefaultFloat64("EC2_DESCRIBEINSTANCES_QPS", 0), "The maximum rate of EC2 DescribeInstances calls per second. Calls over the limit wait rather than fail. Client-side rate limiting is disabled for the operation if set to 0.")
+	fs.Float64Var(&o.EC2TerminateInstancesQPS, "ec2-terminateinstances-qps", env.WithDefaultFloat64("EC2_TERMINATEINSTANCES_QPS", 0), "The maximum rate of EC2 TerminateInstances calls per second. Calls over the limit wait rather than fail. Client-side rate limiting is disabled for the operation if set to 0.")
+	fs.Float64Var(&o.EC2CreateTagsQPS, "ec2-createtags-qps", env.WithDefaultFloat64("EC2_CREATETAGS_QPS", 0), "The maximum rate of EC2 CreateTags calls per second. Calls over the limit wait rather than fail. Client-side rate limiting is disabled for the operation if set to 0.")
+	fs.Float64Var(&o.NewerGenerationPriceThreshold, "newer-generation-price-threshold", env.WithDefaultFloat64("NEWER_GENERATION_PRICE_THRESHOLD", 0), "The price difference, as a fraction of the older generation's price, within which a newer instance generation is launched in place of an older generation of the same family, architecture and size. Must be between 0 and 0.1. Preferring newer generations is disabled if set to 0.")
+	fs.BoolVarWithEnv(&o.SubnetDiscoveryTaggingAPI, "subnet-discovery-tagging-api", "SUBNET_DISCOVERY_TAGGING_API", false, "If true, subnets selected by tags are discovered with the Resource Groups Tagging API before their details are described, which reduces discovery latency in accounts with many subnets. Discovery falls back to DescribeSubnets if the Tagging API can't be called or doesn't return any subnets. Requires the tag:GetResources permission on the controller service account.")
+	fs.BoolVarWithEnv(&o.CreateFleetClientToken, "create-fleet-client-token", "CREATE_FLEET_CLIENT_TOKEN", false, "If true, launches are idempotent per NodeClaim so that retried launches don't launch duplicate instances. Each CreateFleet request has a client token derived from the UID of the NodeClaim being launched, launched instances are tagged with the NodeClaim's UID, and an existing instance tagged with the UID is adopted rather than launching another. CreateFleet requests for different NodeClaims aren't batched together when enabled.")
+	fs.BoolVarWithEnv(&o.SpotPlacementScore, "spot-placement-score", "SPOT_PLACEMENT_SCORE", false, "If true, spot launches prioritize the zones with the highest spot placement score with the capacity-optimized-prioritized allocation strategy, which reduces the likelihood of interruption while still allowing launches into lower scoring zones. Scores are refreshed in the background every 10 minutes for a fixed set of instance types of each architecture. Requires the ec2:GetSpotPlacementScores permission on the controller service account.")
+	fs.IntVar(&o.ZoneCapacityCooldownThreshold, "zone-capacity-cooldown-threshold", env.WithDefaultInt("ZONE_CAPACITY_COOLDOWN_THRESHOLD", 0), "The number of distinct instance types that must fail to launch with insufficient capacity in a zone, within the unavailable offerings TTL, before all offerings of the capacity type in that zone are treated as unavailable for a cooldown period. Zone cooldowns are disabled if set to 0, and the value cannot be negative.")
+	fs.BoolVarWithEnv(&o.LaunchTemplateDebugEndpoint, "launch-template-debug-endpoint", "LAUNCH_TEMPLATE_DEBUG_ENDPOINT", false, "If true, the launch templates that would be created for an EC2NodeClass are served as JSON from /debug/launchtemplates on the metrics port. The EC2NodeClass is selected with the nodeclass query parameter, and the requirements of a NodePool can be applied with the nodepool query parameter. The response includes the decoded user data, which may contain sensitive bootstrap configuration.")
+	fs.BoolVarWithEnv(&o.ReadableLaunchTemplateNames, "readable-launch-template-names", "READABLE_LAUNCH_TEMPLATE_NAMES", false, "If true, the names of the launch templates that Karpenter creates include the cluster and EC2NodeClass names, truncated to fit EC2's 128 character limit, before the hash of the launch template, and the launch templates are given a description naming the cluster and EC2NodeClass. Existing launch templates are replaced on the next launch when this is changed.")
+	fs.IntVar(&o.MaxNodeClassLaunchBatchSize, "max-nodeclass-launch-batch-size", env.WithDefaultInt("MAX_NODECLASS_LAUNCH_BATCH_SIZE", 0), "The maximum number of instances launched per EC2NodeClass each second. Launches over the limit are queued until the next second rather than dropped, which paces large scale-ups. Launches aren't paced if set to 0.")
+	fs.StringVar(&o.EC2Endpoint, "ec2-endpoint", env.WithDefaultString("EC2_ENDPOINT", ""), "The URL of the EC2 endpoint, e.g. an interface VPC endpoint, that Karpenter calls instead of the regional EC2 endpoint.")
+	fs.StringVar(&o.SSMEndpoint, "ssm-endpoint", env.WithDefaultString("SSM_ENDPOINT", ""), "The URL of the SSM endpoint, e.g. an interface VPC endpoint, that Karpenter calls instead of the regional SSM endpoint.")
+	fs.StringVar(&o.PricingEndpoint, "pricing-endpoint", env.WithDefaultString("PRICING_ENDPOINT", ""), "The URL of the Pricing endpoint, e.g. an interface VPC endpoint, that Karpenter calls instead of the regional Pricing endpoint.")
+	fs.BoolVarWithEnv(&o.FIPSEndpoints, "fips-endpoints", "FIPS_ENDPOINTS", false, "If true, the FIPS endpoints of EC2 and SSM are used. Endpoints that are set with ec2-endpoint or ssm-endpoint take precedence.")
+	fs.BoolVarWithEnv(&o.AnnotateInstanceCapabilities, "annotate-instance-capabilities", "ANNOTATE_INSTANCE_CAPABILITIES", false, "If true, Nodes are annotated with karpenter.k8s.aws/instance-capabilities, a compact JSON of the vCPUs, memory, GPUs, network bandwidth, EBS bandwidth and local storage of their instance type.")
+	fs.DurationVar(&o.NotReadyTimeout, "not-ready-timeout", env.WithDefaultDuration("NOT_READY_TIMEOUT", 0), "The time a node has to become Ready after it registers before its NodeClaim is deleted so that the instance is replaced. Nodes can be given a longer grace period with the karpenter.k8s.aws/not-ready-timeout annotation. Nodes stuck NotReady aren't remediated if set to 0.")
+	fs.DurationVar(&o.MaxStoppedInstanceAge, "max-stopped-instance-age", env.WithDefaultDuration("MAX_STOPPED_INSTANCE_AGE", 24*time.Hour), "The time that an instance stopped for a NodeClaim with the karpenter.k8s.aws/stop-on-delete annotation is kept for a later NodeClaim to start it again. Instances that are stopped for longer are terminated by garbage collection. Must be greater than 0.")
+	fs.StringVar(&o.OnDemandAllocationStrategy, "on-demand-allocation-strategy", env.WithDefaultString("ON_DEMAND_ALLOCATION_STRATEGY", "lowest-price"), "The allocation strategy of on-demand launches, either lowest-price or prioritized. When prioritized, the instance types of a launch are prioritized by the families of on-demand-family-priority and then by price.")
+	fs.StringVar(&o.OnDemandFamilyPriority, "on-demand-family-priority", env.WithDefaultString("ON_DEMAND_FAMILY_PRIORITY", ""), "Comma separated list of instance families (e.g. m7i,m6i) in the order that they're prioritized for on-demand launches when on-demand-allocation-strategy is prioritized. Families that aren't listed are prioritized after the listed families.")
+	fs.BoolVarWithEnv(&o.Tracing, "tracing", "TRACING", false, "If true, the launch path and the provider calls that it makes are traced with OpenTelemetry, and the spans are exported with OTLP over HTTP to tracing-endpoint.")
+	fs.StringVar(&o.TracingEndpoint, "tracing-endpoint", env.WithDefaultString("TRACING_ENDPOINT", ""), "The URL of the OTLP HTTP endpoint, e.g. http://otel-collector:4318, that spans are exported to when tracing is enabled. Defaults to the OTEL_EXPORTER_OTLP_ENDPOINT environment variable, or to http://localhost:4318.")
+	fs.StringVar(&o.SubnetPriorityTagKey, "subnet-priority-tag-key", env.WithDefaultString("SUBNET_PRIORITY_TAG_KEY", ""), "The key of a subnet tag with a numeric priority. When set, launches prefer the subnet with the highest priority in each zone among the subnets with enough available IPs for the launch. Subnets with a missing or invalid priority tag have the lowest priority.")
+	fs.StringVar(&o.KubernetesVersion, "kubernetes-version", env.WithDefaultString("KUBERNETES_VERSION", ""), "The minor version of Kubernetes (e.g. 1.29) that the EKS optimized AMIs are resolved for, which pins nodes to that version. Must be within the Kubernetes versions that Karpenter supports. Defaults to the version of the cluster's API server.")
+	fs.DurationVar(&o.PricingRefreshInterval, "pricing-refresh-interval", env.WithDefaultDuration("PRICING_REFRESH_INTERVAL", 12*time.Hour), "The interval between refreshes of on-demand and spot pricing data.")
+	fs.DurationVar(&o.PricingRefreshJitter, "pricing-refresh-jitter", env.WithDefaultDuration("PRICING_REFRESH_JITTER", 0), "The maximum random duration that is added to the pricing refresh interval, which spreads the pricing requests of many clusters over time.")
+	fs.BoolVarWithEnv(&o.AMISelectorFallback, "ami-selector-fallback", "AMI_SELECTOR_FALLBACK", false, "If true, EC2NodeClasses whose amiSelectorTerms don't match any AMIs fall back to the default EKS optimized AMIs of their amiFamily, so that nodes can still be launched while the selectors are fixed. The AMISelectorTermsResolved status condition is set to False while the fallback is active, and nodes aren't drifted to the default AMIs.")
+	fs.StringVar(&o.NetworkingReserved, "networking-reserved", env.WithDefaultString("NETWORKING_RESERVED", ""), "Comma separated list of resources (e.g. cpu=100m,memory=200Mi) that are reserved on every node for networking components such as the VPC CNI and kube-proxy, on top of the requests of their DaemonSet pods. The resources are subtracted from the allocatable of instance types when scheduling, without changing the kube-reserved of the kubelet. Valid resources are cpu, memory and ephemeral-storage.")
+	fs.IntVar(&o.AMIResolutionConcurrency, "ami-resolution-concurrency", env.WithDefaultInt("AMI_RESOLUTION_CONCURRENCY", 10), "The maximum number of SSM, DescribeImages and Image Builder calls that are in-flight at once when resolving the AMIs of EC2NodeClasses. The limit is shared by all EC2NodeClasses.")
+	fs.BoolVarWithEnv(&o.ClusterDiscovery, "cluster-discovery", "CLUSTER_DISCOVERY", false, "If true, the cluster endpoint and CA bundle that nodes bootstrap with are discovered with the EKS DescribeCluster API and cached for 5 minutes, so that user data follows changes to the control plane. The values of cluster-endpoint and cluster-ca-bundle, or the values discovered at startup, are used when discovery fails.")
 }
 
 func (o *Options) Parse(fs *coreoptions.FlagSet, args ...string) error {
@@ -69,6 +162,59 @@ func (o *Options) Parse(fs *coreoptions.FlagSet, args ...string) error {
 	return nil
 }
 
+// InstanceTypeFamilyList returns the parsed set of instance families from InstanceTypeFamilies
+func (o *Options) InstanceTypeFamilyList() []string {
+	return lo.Compact(lo.Map(strings.Split(o.InstanceTypeFamilies, ","), func(f string, _ int) string { return strings.TrimSpace(f) }))
+}
+
+// OnDemandFamilyPriorityList returns the parsed instance families of OnDemandFamilyPriority in priority order
+func (o *Options) OnDemandFamilyPriorityList() []string {
+	return lo.Compact(lo.Map(strings.Split(o.OnDemandFamilyPriority, ","), func(f string, _ int) string { return strings.TrimSpace(f) }))
+}
+
+// EC2OperationQPS returns the configured client-side rate limit for each EC2 operation, keyed by operation name
+func (o *Options) EC2OperationQPS() map[string]float64 {
+	return map[string]float64{
+		"CreateFleet":        o.EC2CreateFleetQPS,
+		"DescribeInstances":  o.EC2DescribeInstancesQPS,
+		"TerminateInstances": o.EC2TerminateInstancesQPS,
+		"CreateTags":         o.EC2CreateTagsQPS,
+	}
+}
+
+// ServiceEndpoints returns the configured endpoint overrides, keyed by the endpoints ID of the AWS service
+func (o *Options) ServiceEndpoints() map[string]string {
+	return map[string]string{
+		"ec2":         o.EC2Endpoint,
+		"ssm":         o.SSMEndpoint,
+		"api.pricing": o.PricingEndpoint,
+	}
+}
+
+// NetworkingReservedResources returns the parsed resources of NetworkingReserved
+func (o *Options) NetworkingReservedResources() (v1.ResourceList, error) {
+	resources := v1.ResourceList{}
+	for _, entry := range lo.Compact(lo.Map(strings.Split(o.NetworkingReserved, ","), func(e string, _ int) string { return strings.TrimSpace(e) })) {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not a resource=quantity pair", entry)
+		}
+		resourceName := v1.ResourceName(strings.TrimSpace(name))
+		if !lo.Contains([]v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory, v1.ResourceEphemeralStorage}, resourceName) {
+			return nil, fmt.Errorf("%q is not a valid resource, valid resources are cpu, memory and ephemeral-storage", resourceName)
+		}
+		quantity, err := resource.ParseQuantity(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("parsing quantity of %q, %w", resourceName, err)
+		}
+		if quantity.Sign() < 0 {
+			return nil, fmt.Errorf("quantity of %q cannot be negative", resourceName)
+		}
+		resources[resourceName] = quantity
+	}
+	return resources, nil
+}
+
 func (o *Options) ToContext(ctx context.Context) context.Context {
 	return ToContext(ctx, o)
 }
@@ -17,17 +17,40 @@ package options
 import (
 	"fmt"
 	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/samber/lo"
 	"go.uber.org/multierr"
 )
 
+var instanceTypeFamilyRegex = regexp.MustCompile(`^[a-z0-9-]+$`)
+var kubernetesVersionRegex = regexp.MustCompile(`^[0-9]+\.[0-9]+$`)
+
 func (o Options) Validate() error {
 	return multierr.Combine(
 		o.validateEndpoint(),
+		o.validateServiceEndpoints(),
 		o.validateVMMemoryOverheadPercent(),
 		o.validateAssumeRoleDuration(),
 		o.validateReservedENIs(),
+		o.validateZoneCapacityCooldownThreshold(),
+		o.validateInterruption(),
+		o.validateInstanceTypeFamilies(),
+		o.validateInstanceTypeOfferingsCacheTTL(),
+		o.validateInstanceTypesRefreshInterval(),
+		o.validateEC2OperationQPS(),
+		o.validateNewerGenerationPriceThreshold(),
+		o.validateMaxNodeClassLaunchBatchSize(),
+		o.validateAMIResolutionConcurrency(),
+		o.validateNotReadyTimeout(),
+		o.validateMaxStoppedInstanceAge(),
+		o.validateOnDemandAllocationStrategy(),
+		o.validateKubernetesVersion(),
+		o.validatePricingRefresh(),
+		o.validateNetworkingReserved(),
 		o.validateRequiredFields(),
 	)
 }
@@ -52,6 +75,24 @@ func (o Options) validateEndpoint() error {
 	return nil
 }
 
+func (o Options) validateServiceEndpoints() error {
+	for flag, value := range map[string]string{
+		"ec2-endpoint":     o.EC2Endpoint,
+		"ssm-endpoint":     o.SSMEndpoint,
+		"pricing-endpoint": o.PricingEndpoint,
+		"tracing-endpoint": o.TracingEndpoint,
+	} {
+		if value == "" {
+			continue
+		}
+		endpoint, err := url.Parse(value)
+		if err != nil || !endpoint.IsAbs() || endpoint.Hostname() == "" {
+			return fmt.Errorf("%q is not a valid %s URL", value, flag)
+		}
+	}
+	return nil
+}
+
 func (o Options) validateVMMemoryOverheadPercent() error {
 	if o.VMMemoryOverheadPercent < 0 {
 		return fmt.Errorf("vm-memory-overhead-percent cannot be negative")
@@ -66,6 +107,142 @@ func (o Options) validateReservedENIs() error {
 	return nil
 }
 
+func (o Options) validateZoneCapacityCooldownThreshold() error {
+	if o.ZoneCapacityCooldownThreshold < 0 {
+		return fmt.Errorf("zone-capacity-cooldown-threshold cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) validateMaxNodeClassLaunchBatchSize() error {
+	if o.MaxNodeClassLaunchBatchSize < 0 {
+		return fmt.Errorf("max-nodeclass-launch-batch-size cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) validateAMIResolutionConcurrency() error {
+	if o.AMIResolutionConcurrency < 1 {
+		return fmt.Errorf("ami-resolution-concurrency must be greater than 0")
+	}
+	return nil
+}
+
+func (o Options) validateNotReadyTimeout() error {
+	if o.NotReadyTimeout < 0 {
+		return fmt.Errorf("not-ready-timeout cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) validateMaxStoppedInstanceAge() error {
+	if o.MaxStoppedInstanceAge <= 0 {
+		return fmt.Errorf("max-stopped-instance-age must be greater than 0")
+	}
+	return nil
+}
+
+func (o Options) validateOnDemandAllocationStrategy() error {
+	if !lo.Contains(ec2.FleetOnDemandAllocationStrategy_Values(), o.OnDemandAllocationStrategy) {
+		return fmt.Errorf("%q is not a valid on-demand-allocation-strategy, must be one of %s", o.OnDemandAllocationStrategy, strings.Join(ec2.FleetOnDemandAllocationStrategy_Values(), ", "))
+	}
+	for _, family := range o.OnDemandFamilyPriorityList() {
+		if !instanceTypeFamilyRegex.MatchString(family) {
+			return fmt.Errorf("%q is not a valid instance family for on-demand-family-priority", family)
+		}
+	}
+	return nil
+}
+
+func (o Options) validateKubernetesVersion() error {
+	if o.KubernetesVersion != "" && !kubernetesVersionRegex.MatchString(o.KubernetesVersion) {
+		return fmt.Errorf("%q is not a valid kubernetes-version, must be a minor version such as 1.29", o.KubernetesVersion)
+	}
+	return nil
+}
+
+func (o Options) validatePricingRefresh() error {
+	if o.PricingRefreshInterval < time.Minute {
+		return fmt.Errorf("pricing-refresh-interval cannot be less than 1 minute")
+	}
+	if o.PricingRefreshJitter < 0 {
+		return fmt.Errorf("pricing-refresh-jitter cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) validateInterruption() error {
+	if o.InterruptionBatchSize < 1 || o.InterruptionBatchSize > 10 {
+		return fmt.Errorf("interruption-batch-size must be between 1 and 10")
+	}
+	// SQS visibility timeouts are in whole seconds, and a timeout of 0 immediately re-delivers messages that are being handled
+	if o.InterruptionVisibilityTimeout < time.Second || o.InterruptionVisibilityTimeout > 12*time.Hour {
+		return fmt.Errorf("interruption-visibility-timeout must be between 1 second and 12 hours")
+	}
+	if o.InterruptionWorkers < 1 {
+		return fmt.Errorf("interruption-workers must be greater than 0")
+	}
+	if o.InterruptionDisruptionLimit < 0 {
+		return fmt.Errorf("interruption-disruption-limit cannot be negative")
+	}
+	if o.InterruptionEvictionGrace < 0 {
+		return fmt.Errorf("interruption-eviction-grace-period cannot be negative")
+	}
+	if o.InterruptionWaitTime < 0 || o.InterruptionWaitTime > 20*time.Second {
+		return fmt.Errorf("interruption-wait-time must be between 0 and 20 seconds")
+	}
+	if o.InterruptionReceiveTimeout <= o.InterruptionWaitTime {
+		return fmt.Errorf("interruption-receive-timeout must be greater than interruption-wait-time")
+	}
+	return nil
+}
+
+func (o Options) validateInstanceTypeOfferingsCacheTTL() error {
+	if o.InstanceTypeOfferingsCacheTTL < 0 {
+		return fmt.Errorf("instance-type-offerings-cache-ttl cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) validateInstanceTypesRefreshInterval() error {
+	if o.InstanceTypesRefreshInterval <= 0 {
+		return fmt.Errorf("instance-types-refresh-interval must be greater than 0")
+	}
+	return nil
+}
+
+func (o Options) validateInstanceTypeFamilies() error {
+	for _, family := range o.InstanceTypeFamilyList() {
+		if !instanceTypeFamilyRegex.MatchString(family) {
+			return fmt.Errorf("%q is not a valid instance family for instance-type-families", family)
+		}
+	}
+	return nil
+}
+
+func (o Options) validateEC2OperationQPS() error {
+	for operation, qps := range o.EC2OperationQPS() {
+		if qps < 0 {
+			return fmt.Errorf("ec2-%s-qps cannot be negative", strings.ToLower(operation))
+		}
+	}
+	return nil
+}
+
+func (o Options) validateNewerGenerationPriceThreshold() error {
+	if o.NewerGenerationPriceThreshold < 0 || o.NewerGenerationPriceThreshold > 0.1 {
+		return fmt.Errorf("newer-generation-price-threshold must be between 0 and 0.1")
+	}
+	return nil
+}
+
+func (o Options) validateNetworkingReserved() error {
+	if _, err := o.NetworkingReservedResources(); err != nil {
+		return fmt.Errorf("networking-reserved is invalid, %w", err)
+	}
+	return nil
+}
+
 func (o Options) validateRequiredFields() error {
 	if o.ClusterName == "" {
 		return fmt.Errorf("missing field, cluster-name")
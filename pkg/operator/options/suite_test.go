@@ -65,18 +65,106 @@ var _ = Describe("Options", func() {
 			"--isolated-vpc",
 			"--vm-memory-overhead-percent", "0.1",
 			"--interruption-queue", "env-cluster",
-			"--reserved-enis", "10")
+			"--interruption-queue-tagging",
+			"--interruption-scheduled-changes=false",
+			"--interruption-batch-size", "5",
+			"--interruption-visibility-timeout", "1m",
+			"--interruption-workers", "20",
+			"--reserved-enis", "10",
+			"--instance-type-families", "m5,c6g",
+			"--ec2-createfleet-qps", "5",
+			"--ec2-describeinstances-qps", "10",
+			"--ec2-terminateinstances-qps", "2.5",
+			"--ec2-createtags-qps", "1",
+			"--newer-generation-price-threshold", "0.05",
+			"--subnet-discovery-tagging-api",
+			"--create-fleet-client-token",
+			"--spot-placement-score",
+			"--zone-capacity-cooldown-threshold", "3",
+			"--launch-template-debug-endpoint",
+			"--readable-launch-template-names",
+			"--max-nodeclass-launch-batch-size", "10",
+			"--ec2-endpoint", "https://ec2.vpce.test",
+			"--ssm-endpoint", "https://ssm.vpce.test",
+			"--pricing-endpoint", "https://pricing.vpce.test",
+			"--fips-endpoints",
+			"--annotate-instance-capabilities",
+			"--not-ready-timeout", "20m",
+			"--max-stopped-instance-age", "1h",
+			"--on-demand-allocation-strategy", "prioritized",
+			"--on-demand-family-priority", "m7i,m6i",
+			"--tracing",
+			"--tracing-endpoint", "http://otel-collector:4318",
+			"--subnet-priority-tag-key", "example.com/priority",
+			"--kubernetes-version", "1.28",
+			"--interruption-disruption-limit", "5",
+			"--pricing-refresh-interval", "6h",
+			"--pricing-refresh-jitter", "30m",
+			"--interruption-eviction-grace-period", "90s",
+			"--instance-type-offerings-cache-ttl", "10m",
+			"--instance-types-refresh-interval", "1h",
+			"--ami-selector-fallback",
+			"--cluster-discovery",
+			"--networking-reserved", "cpu=100m,memory=200Mi",
+			"--interruption-rebalance-replacement",
+			"--ami-resolution-concurrency", "3",
+			"--interruption-wait-time", "5s",
+			"--interruption-receive-timeout", "10s")
 		Expect(err).ToNot(HaveOccurred())
 		expectOptionsEqual(opts, test.Options(test.OptionsFields{
-			AssumeRoleARN:           lo.ToPtr("env-role"),
-			AssumeRoleDuration:      lo.ToPtr(20 * time.Minute),
-			ClusterCABundle:         lo.ToPtr("env-bundle"),
-			ClusterName:             lo.ToPtr("env-cluster"),
-			ClusterEndpoint:         lo.ToPtr("https://env-cluster"),
-			IsolatedVPC:             lo.ToPtr(true),
-			VMMemoryOverheadPercent: lo.ToPtr[float64](0.1),
-			InterruptionQueue:       lo.ToPtr("env-cluster"),
-			ReservedENIs:            lo.ToPtr(10),
+			AssumeRoleARN:                 lo.ToPtr("env-role"),
+			AssumeRoleDuration:            lo.ToPtr(20 * time.Minute),
+			ClusterCABundle:               lo.ToPtr("env-bundle"),
+			ClusterName:                   lo.ToPtr("env-cluster"),
+			ClusterEndpoint:               lo.ToPtr("https://env-cluster"),
+			IsolatedVPC:                   lo.ToPtr(true),
+			VMMemoryOverheadPercent:       lo.ToPtr[float64](0.1),
+			InterruptionQueue:             lo.ToPtr("env-cluster"),
+			InterruptionQueueTagging:      lo.ToPtr(true),
+			InterruptionScheduledChanges:  lo.ToPtr(false),
+			InterruptionBatchSize:         lo.ToPtr(5),
+			InterruptionVisibilityTimeout: lo.ToPtr(time.Minute),
+			InterruptionWorkers:           lo.ToPtr(20),
+			InterruptionDisruptionLimit:   lo.ToPtr(5),
+			ReservedENIs:                  lo.ToPtr(10),
+			InstanceTypeFamilies:          lo.ToPtr("m5,c6g"),
+			EC2CreateFleetQPS:             lo.ToPtr[float64](5),
+			EC2DescribeInstancesQPS:       lo.ToPtr[float64](10),
+			EC2TerminateInstancesQPS:      lo.ToPtr(2.5),
+			EC2CreateTagsQPS:              lo.ToPtr[float64](1),
+			NewerGenerationPriceThreshold: lo.ToPtr(0.05),
+			SubnetDiscoveryTaggingAPI:     lo.ToPtr(true),
+			CreateFleetClientToken:        lo.ToPtr(true),
+			SpotPlacementScore:            lo.ToPtr(true),
+			ZoneCapacityCooldownThreshold: lo.ToPtr(3),
+			LaunchTemplateDebugEndpoint:   lo.ToPtr(true),
+			ReadableLaunchTemplateNames:   lo.ToPtr(true),
+			MaxNodeClassLaunchBatchSize:   lo.ToPtr(10),
+			EC2Endpoint:                   lo.ToPtr("https://ec2.vpce.test"),
+			SSMEndpoint:                   lo.ToPtr("https://ssm.vpce.test"),
+			PricingEndpoint:               lo.ToPtr("https://pricing.vpce.test"),
+			FIPSEndpoints:                 lo.ToPtr(true),
+			AnnotateInstanceCapabilities:  lo.ToPtr(true),
+			NotReadyTimeout:               lo.ToPtr(20 * time.Minute),
+			MaxStoppedInstanceAge:         lo.ToPtr(time.Hour),
+			OnDemandAllocationStrategy:    lo.ToPtr("prioritized"),
+			OnDemandFamilyPriority:        lo.ToPtr("m7i,m6i"),
+			Tracing:                       lo.ToPtr(true),
+			TracingEndpoint:               lo.ToPtr("http://otel-collector:4318"),
+			SubnetPriorityTagKey:          lo.ToPtr("example.com/priority"),
+			KubernetesVersion:             lo.ToPtr("1.28"),
+			PricingRefreshInterval:        lo.ToPtr(6 * time.Hour),
+			PricingRefreshJitter:          lo.ToPtr(30 * time.Minute),
+			InterruptionEvictionGrace:     lo.ToPtr(90 * time.Second),
+			InstanceTypeOfferingsCacheTTL: lo.ToPtr(10 * time.Minute),
+			InstanceTypesRefreshInterval:  lo.ToPtr(time.Hour),
+			AMISelectorFallback:           lo.ToPtr(true),
+			ClusterDiscovery:              lo.ToPtr(true),
+			NetworkingReserved:            lo.ToPtr("cpu=100m,memory=200Mi"),
+			AMIResolutionConcurrency:      lo.ToPtr(3),
+			InterruptionRebalanceReplace:  lo.ToPtr(true),
+			InterruptionWaitTime:          lo.ToPtr(5 * time.Second),
+			InterruptionReceiveTimeout:    lo.ToPtr(10 * time.Second),
 		}))
 	})
 	It("should correctly fallback to env vars when CLI flags aren't set", func() {
@@ -88,7 +176,51 @@ var _ = Describe("Options", func() {
 		os.Setenv("ISOLATED_VPC", "true")
 		os.Setenv("VM_MEMORY_OVERHEAD_PERCENT", "0.1")
 		os.Setenv("INTERRUPTION_QUEUE", "env-cluster")
+		os.Setenv("INTERRUPTION_QUEUE_TAGGING", "true")
+		os.Setenv("INTERRUPTION_SCHEDULED_CHANGES", "false")
+		os.Setenv("INTERRUPTION_BATCH_SIZE", "5")
+		os.Setenv("INTERRUPTION_VISIBILITY_TIMEOUT", "1m")
+		os.Setenv("INTERRUPTION_WORKERS", "20")
 		os.Setenv("RESERVED_ENIS", "10")
+		os.Setenv("INSTANCE_TYPE_FAMILIES", "m5,c6g")
+		os.Setenv("EC2_CREATEFLEET_QPS", "5")
+		os.Setenv("EC2_DESCRIBEINSTANCES_QPS", "10")
+		os.Setenv("EC2_TERMINATEINSTANCES_QPS", "2.5")
+		os.Setenv("EC2_CREATETAGS_QPS", "1")
+		os.Setenv("NEWER_GENERATION_PRICE_THRESHOLD", "0.05")
+		os.Setenv("SUBNET_DISCOVERY_TAGGING_API", "true")
+		os.Setenv("CREATE_FLEET_CLIENT_TOKEN", "true")
+		os.Setenv("SPOT_PLACEMENT_SCORE", "true")
+		os.Setenv("ZONE_CAPACITY_COOLDOWN_THRESHOLD", "3")
+		os.Setenv("LAUNCH_TEMPLATE_DEBUG_ENDPOINT", "true")
+		os.Setenv("READABLE_LAUNCH_TEMPLATE_NAMES", "true")
+		os.Setenv("MAX_NODECLASS_LAUNCH_BATCH_SIZE", "10")
+		os.Setenv("EC2_ENDPOINT", "https://ec2.vpce.test")
+		os.Setenv("SSM_ENDPOINT", "https://ssm.vpce.test")
+		os.Setenv("PRICING_ENDPOINT", "https://pricing.vpce.test")
+		os.Setenv("FIPS_ENDPOINTS", "true")
+		os.Setenv("ANNOTATE_INSTANCE_CAPABILITIES", "true")
+		os.Setenv("NOT_READY_TIMEOUT", "20m")
+		os.Setenv("MAX_STOPPED_INSTANCE_AGE", "1h")
+		os.Setenv("ON_DEMAND_ALLOCATION_STRATEGY", "prioritized")
+		os.Setenv("ON_DEMAND_FAMILY_PRIORITY", "m7i,m6i")
+		os.Setenv("TRACING", "true")
+		os.Setenv("TRACING_ENDPOINT", "http://otel-collector:4318")
+		os.Setenv("SUBNET_PRIORITY_TAG_KEY", "example.com/priority")
+		os.Setenv("KUBERNETES_VERSION", "1.28")
+		os.Setenv("INTERRUPTION_DISRUPTION_LIMIT", "5")
+		os.Setenv("PRICING_REFRESH_INTERVAL", "6h")
+		os.Setenv("PRICING_REFRESH_JITTER", "30m")
+		os.Setenv("INTERRUPTION_EVICTION_GRACE_PERIOD", "90s")
+		os.Setenv("INSTANCE_TYPE_OFFERINGS_CACHE_TTL", "10m")
+		os.Setenv("INSTANCE_TYPES_REFRESH_INTERVAL", "1h")
+		os.Setenv("AMI_SELECTOR_FALLBACK", "true")
+		os.Setenv("CLUSTER_DISCOVERY", "true")
+		os.Setenv("NETWORKING_RESERVED", "cpu=100m,memory=200Mi")
+		os.Setenv("AMI_RESOLUTION_CONCURRENCY", "3")
+		os.Setenv("INTERRUPTION_REBALANCE_REPLACEMENT", "true")
+		os.Setenv("INTERRUPTION_WAIT_TIME", "5s")
+		os.Setenv("INTERRUPTION_RECEIVE_TIMEOUT", "10s")
 
 		// Add flags after we set the environment variables so that the parsing logic correctly refers
 		// to the new environment variable values
@@ -96,15 +228,59 @@ var _ = Describe("Options", func() {
 		err := opts.Parse(fs)
 		Expect(err).ToNot(HaveOccurred())
 		expectOptionsEqual(opts, test.Options(test.OptionsFields{
-			AssumeRoleARN:           lo.ToPtr("env-role"),
-			AssumeRoleDuration:      lo.ToPtr(20 * time.Minute),
-			ClusterCABundle:         lo.ToPtr("env-bundle"),
-			ClusterName:             lo.ToPtr("env-cluster"),
-			ClusterEndpoint:         lo.ToPtr("https://env-cluster"),
-			IsolatedVPC:             lo.ToPtr(true),
-			VMMemoryOverheadPercent: lo.ToPtr[float64](0.1),
-			InterruptionQueue:       lo.ToPtr("env-cluster"),
-			ReservedENIs:            lo.ToPtr(10),
+			AssumeRoleARN:                 lo.ToPtr("env-role"),
+			AssumeRoleDuration:            lo.ToPtr(20 * time.Minute),
+			ClusterCABundle:               lo.ToPtr("env-bundle"),
+			ClusterName:                   lo.ToPtr("env-cluster"),
+			ClusterEndpoint:               lo.ToPtr("https://env-cluster"),
+			IsolatedVPC:                   lo.ToPtr(true),
+			VMMemoryOverheadPercent:       lo.ToPtr[float64](0.1),
+			InterruptionQueue:             lo.ToPtr("env-cluster"),
+			InterruptionQueueTagging:      lo.ToPtr(true),
+			InterruptionScheduledChanges:  lo.ToPtr(false),
+			InterruptionBatchSize:         lo.ToPtr(5),
+			InterruptionVisibilityTimeout: lo.ToPtr(time.Minute),
+			InterruptionWorkers:           lo.ToPtr(20),
+			InterruptionDisruptionLimit:   lo.ToPtr(5),
+			ReservedENIs:                  lo.ToPtr(10),
+			InstanceTypeFamilies:          lo.ToPtr("m5,c6g"),
+			EC2CreateFleetQPS:             lo.ToPtr[float64](5),
+			EC2DescribeInstancesQPS:       lo.ToPtr[float64](10),
+			EC2TerminateInstancesQPS:      lo.ToPtr(2.5),
+			EC2CreateTagsQPS:              lo.ToPtr[float64](1),
+			NewerGenerationPriceThreshold: lo.ToPtr(0.05),
+			SubnetDiscoveryTaggingAPI:     lo.ToPtr(true),
+			CreateFleetClientToken:        lo.ToPtr(true),
+			SpotPlacementScore:            lo.ToPtr(true),
+			ZoneCapacityCooldownThreshold: lo.ToPtr(3),
+			LaunchTemplateDebugEndpoint:   lo.ToPtr(true),
+			ReadableLaunchTemplateNames:   lo.ToPtr(true),
+			MaxNodeClassLaunchBatchSize:   lo.ToPtr(10),
+			EC2Endpoint:                   lo.ToPtr("https://ec2.vpce.test"),
+			SSMEndpoint:                   lo.ToPtr("https://ssm.vpce.test"),
+			PricingEndpoint:               lo.ToPtr("https://pricing.vpce.test"),
+			FIPSEndpoints:                 lo.ToPtr(true),
+			AnnotateInstanceCapabilities:  lo.ToPtr(true),
+			NotReadyTimeout:               lo.ToPtr(20 * time.Minute),
+			MaxStoppedInstanceAge:         lo.ToPtr(time.Hour),
+			OnDemandAllocationStrategy:    lo.ToPtr("prioritized"),
+			OnDemandFamilyPriority:        lo.ToPtr("m7i,m6i"),
+			Tracing:                       lo.ToPtr(true),
+			TracingEndpoint:               lo.ToPtr("http://otel-collector:4318"),
+			SubnetPriorityTagKey:          lo.ToPtr("example.com/priority"),
+			KubernetesVersion:             lo.ToPtr("1.28"),
+			PricingRefreshInterval:        lo.ToPtr(6 * time.Hour),
+			PricingRefreshJitter:          lo.ToPtr(30 * time.Minute),
+			InterruptionEvictionGrace:     lo.ToPtr(90 * time.Second),
+			InstanceTypeOfferingsCacheTTL: lo.ToPtr(10 * time.Minute),
+			InstanceTypesRefreshInterval:  lo.ToPtr(time.Hour),
+			AMISelectorFallback:           lo.ToPtr(true),
+			ClusterDiscovery:              lo.ToPtr(true),
+			NetworkingReserved:            lo.ToPtr("cpu=100m,memory=200Mi"),
+			AMIResolutionConcurrency:      lo.ToPtr(3),
+			InterruptionRebalanceReplace:  lo.ToPtr(true),
+			InterruptionWaitTime:          lo.ToPtr(5 * time.Second),
+			InterruptionReceiveTimeout:    lo.ToPtr(10 * time.Second),
 		}))
 	})
 
@@ -128,10 +304,135 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--vm-memory-overhead-percent", "-0.01")
 			Expect(err).To(HaveOccurred())
 		})
+		It("should fail when zoneCapacityCooldownThreshold is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--zone-capacity-cooldown-threshold", "-1")
+			Expect(err).To(HaveOccurred())
+		})
 		It("should fail when reservedENIs is negative", func() {
 			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--reserved-enis", "-1")
 			Expect(err).To(HaveOccurred())
 		})
+		It("should fail when interruptionBatchSize is less than 1", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-batch-size", "0")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when interruptionBatchSize is greater than 10", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-batch-size", "11")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when interruptionVisibilityTimeout is 0", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-visibility-timeout", "0s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when interruptionVisibilityTimeout is less than 1 second", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-visibility-timeout", "500ms")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when interruptionVisibilityTimeout is greater than 12 hours", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-visibility-timeout", "13h")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when interruptionWorkers is less than 1", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-workers", "0")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when pricingRefreshInterval is less than 1 minute", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--pricing-refresh-interval", "30s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when pricingRefreshJitter is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--pricing-refresh-jitter", "-1m")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when interruptionDisruptionLimit is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-disruption-limit", "-1")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when interruptionEvictionGracePeriod is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-eviction-grace-period", "-1s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when interruptionWaitTime is greater than 20 seconds", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-wait-time", "21s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when interruptionReceiveTimeout isn't greater than interruptionWaitTime", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-wait-time", "20s", "--interruption-receive-timeout", "20s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when amiResolutionConcurrency is less than 1", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--ami-resolution-concurrency", "0")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when instanceTypeOfferingsCacheTTL is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--instance-type-offerings-cache-ttl", "-1m")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when instanceTypesRefreshInterval is not positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--instance-types-refresh-interval", "0s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when instanceTypeFamilies contains an invalid family", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--instance-type-families", "m5,m5.large")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when an EC2 operation QPS is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--ec2-createfleet-qps", "-1")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when newerGenerationPriceThreshold is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--newer-generation-price-threshold", "-0.01")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when newerGenerationPriceThreshold is greater than 0.1", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--newer-generation-price-threshold", "0.2")
+			Expect(err).To(HaveOccurred())
+		})
+		DescribeTable("should fail when a service endpoint is invalid", func(flag string) {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", flag, "vpce-0123456789.ec2.us-west-2.vpce.amazonaws.com")
+			Expect(err).To(HaveOccurred())
+		},
+			Entry("EC2", "--ec2-endpoint"),
+			Entry("SSM", "--ssm-endpoint"),
+			Entry("Pricing", "--pricing-endpoint"),
+		)
+		It("should fail when maxNodeClassLaunchBatchSize is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--max-nodeclass-launch-batch-size", "-1")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when onDemandAllocationStrategy isn't an EC2 allocation strategy", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--on-demand-allocation-strategy", "capacity-optimized")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when onDemandFamilyPriority contains an invalid family", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--on-demand-family-priority", "m5,M6I")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when notReadyTimeout is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--not-ready-timeout", "-1m")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when maxStoppedInstanceAge isn't positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--max-stopped-instance-age", "0s")
+			Expect(err).To(HaveOccurred())
+		})
+		DescribeTable("should fail when networkingReserved is invalid", func(networkingReserved string) {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--networking-reserved", networkingReserved)
+			Expect(err).To(HaveOccurred())
+		},
+			Entry("without a quantity", "cpu"),
+			Entry("with an unsupported resource", "pods=10"),
+			Entry("with an invalid quantity", "memory=lots"),
+			Entry("with a negative quantity", "cpu=-100m"),
+		)
+		DescribeTable("should fail when kubernetesVersion isn't a minor version", func(version string) {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--kubernetes-version", version)
+			Expect(err).To(HaveOccurred())
+		},
+			Entry("patch version", "1.29.1"),
+			Entry("prefixed version", "v1.29"),
+			Entry("major version", "1"),
+		)
 	})
 })
 
@@ -145,5 +446,49 @@ func expectOptionsEqual(optsA *options.Options, optsB *options.Options) {
 	Expect(optsA.IsolatedVPC).To(Equal(optsB.IsolatedVPC))
 	Expect(optsA.VMMemoryOverheadPercent).To(Equal(optsB.VMMemoryOverheadPercent))
 	Expect(optsA.InterruptionQueue).To(Equal(optsB.InterruptionQueue))
+	Expect(optsA.InterruptionQueueTagging).To(Equal(optsB.InterruptionQueueTagging))
+	Expect(optsA.InterruptionScheduledChanges).To(Equal(optsB.InterruptionScheduledChanges))
+	Expect(optsA.InterruptionBatchSize).To(Equal(optsB.InterruptionBatchSize))
+	Expect(optsA.InterruptionVisibilityTimeout).To(Equal(optsB.InterruptionVisibilityTimeout))
+	Expect(optsA.InterruptionWorkers).To(Equal(optsB.InterruptionWorkers))
+	Expect(optsA.InterruptionDisruptionLimit).To(Equal(optsB.InterruptionDisruptionLimit))
 	Expect(optsA.ReservedENIs).To(Equal(optsB.ReservedENIs))
+	Expect(optsA.InstanceTypeFamilies).To(Equal(optsB.InstanceTypeFamilies))
+	Expect(optsA.EC2CreateFleetQPS).To(Equal(optsB.EC2CreateFleetQPS))
+	Expect(optsA.EC2DescribeInstancesQPS).To(Equal(optsB.EC2DescribeInstancesQPS))
+	Expect(optsA.EC2TerminateInstancesQPS).To(Equal(optsB.EC2TerminateInstancesQPS))
+	Expect(optsA.EC2CreateTagsQPS).To(Equal(optsB.EC2CreateTagsQPS))
+	Expect(optsA.NewerGenerationPriceThreshold).To(Equal(optsB.NewerGenerationPriceThreshold))
+	Expect(optsA.SubnetDiscoveryTaggingAPI).To(Equal(optsB.SubnetDiscoveryTaggingAPI))
+	Expect(optsA.CreateFleetClientToken).To(Equal(optsB.CreateFleetClientToken))
+	Expect(optsA.SpotPlacementScore).To(Equal(optsB.SpotPlacementScore))
+	Expect(optsA.ZoneCapacityCooldownThreshold).To(Equal(optsB.ZoneCapacityCooldownThreshold))
+	Expect(optsA.LaunchTemplateDebugEndpoint).To(Equal(optsB.LaunchTemplateDebugEndpoint))
+	Expect(optsA.ReadableLaunchTemplateNames).To(Equal(optsB.ReadableLaunchTemplateNames))
+	Expect(optsA.MaxNodeClassLaunchBatchSize).To(Equal(optsB.MaxNodeClassLaunchBatchSize))
+	Expect(optsA.EC2Endpoint).To(Equal(optsB.EC2Endpoint))
+	Expect(optsA.SSMEndpoint).To(Equal(optsB.SSMEndpoint))
+	Expect(optsA.PricingEndpoint).To(Equal(optsB.PricingEndpoint))
+	Expect(optsA.FIPSEndpoints).To(Equal(optsB.FIPSEndpoints))
+	Expect(optsA.AnnotateInstanceCapabilities).To(Equal(optsB.AnnotateInstanceCapabilities))
+	Expect(optsA.NotReadyTimeout).To(Equal(optsB.NotReadyTimeout))
+	Expect(optsA.MaxStoppedInstanceAge).To(Equal(optsB.MaxStoppedInstanceAge))
+	Expect(optsA.OnDemandAllocationStrategy).To(Equal(optsB.OnDemandAllocationStrategy))
+	Expect(optsA.OnDemandFamilyPriority).To(Equal(optsB.OnDemandFamilyPriority))
+	Expect(optsA.Tracing).To(Equal(optsB.Tracing))
+	Expect(optsA.TracingEndpoint).To(Equal(optsB.TracingEndpoint))
+	Expect(optsA.SubnetPriorityTagKey).To(Equal(optsB.SubnetPriorityTagKey))
+	Expect(optsA.KubernetesVersion).To(Equal(optsB.KubernetesVersion))
+	Expect(optsA.PricingRefreshInterval).To(Equal(optsB.PricingRefreshInterval))
+	Expect(optsA.PricingRefreshJitter).To(Equal(optsB.PricingRefreshJitter))
+	Expect(optsA.InterruptionEvictionGrace).To(Equal(optsB.InterruptionEvictionGrace))
+	Expect(optsA.InstanceTypeOfferingsCacheTTL).To(Equal(optsB.InstanceTypeOfferingsCacheTTL))
+	Expect(optsA.InstanceTypesRefreshInterval).To(Equal(optsB.InstanceTypesRefreshInterval))
+	Expect(optsA.AMISelectorFallback).To(Equal(optsB.AMISelectorFallback))
+	Expect(optsA.ClusterDiscovery).To(Equal(optsB.ClusterDiscovery))
+	Expect(optsA.NetworkingReserved).To(Equal(optsB.NetworkingReserved))
+	Expect(optsA.AMIResolutionConcurrency).To(Equal(optsB.AMIResolutionConcurrency))
+	Expect(optsA.InterruptionRebalanceReplace).To(Equal(optsB.InterruptionRebalanceReplace))
+	Expect(optsA.InterruptionWaitTime).To(Equal(optsB.InterruptionWaitTime))
+	Expect(optsA.InterruptionReceiveTimeout).To(Equal(optsB.InterruptionReceiveTimeout))
 }
@@ -19,7 +19,13 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/eks"
+	awspricing "github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/samber/lo"
 
 	"sigs.k8s.io/karpenter/pkg/operator/scheme"
@@ -29,6 +35,7 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
 	awscontext "github.com/aws/karpenter-provider-aws/pkg/operator"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -102,4 +109,43 @@ var _ = Describe("Operator", func() {
 		_, err := awscontext.ResolveClusterEndpoint(ctx, fakeEKSAPI)
 		Expect(err).To(HaveOccurred())
 	})
+	Context("Client Config", func() {
+		var sess *session.Session
+		BeforeEach(func() {
+			sess = session.Must(session.NewSession(&aws.Config{Region: aws.String("us-west-2"), Credentials: credentials.AnonymousCredentials}))
+		})
+		It("should use the regional endpoints by default", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			Expect(ec2.New(sess, awscontext.ClientConfig(ctx, ec2.EndpointsID)).Endpoint).To(Equal("https://ec2.us-west-2.amazonaws.com"))
+			Expect(ssm.New(sess, awscontext.ClientConfig(ctx, ssm.EndpointsID)).Endpoint).To(Equal("https://ssm.us-west-2.amazonaws.com"))
+		})
+		It("should construct clients with the overridden endpoints", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				EC2Endpoint:     lo.ToPtr("https://vpce-ec2.test.vpce.amazonaws.com"),
+				SSMEndpoint:     lo.ToPtr("https://vpce-ssm.test.vpce.amazonaws.com"),
+				PricingEndpoint: lo.ToPtr("https://vpce-pricing.test.vpce.amazonaws.com"),
+			}))
+			Expect(ec2.New(sess, awscontext.ClientConfig(ctx, ec2.EndpointsID)).Endpoint).To(Equal("https://vpce-ec2.test.vpce.amazonaws.com"))
+			Expect(ssm.New(sess, awscontext.ClientConfig(ctx, ssm.EndpointsID)).Endpoint).To(Equal("https://vpce-ssm.test.vpce.amazonaws.com"))
+			pricingAPI := pricing.NewAPI(sess, "us-west-2", awscontext.ClientConfig(ctx, awspricing.EndpointsID))
+			Expect(pricingAPI.(*awspricing.Pricing).Endpoint).To(Equal("https://vpce-pricing.test.vpce.amazonaws.com"))
+		})
+		It("should construct clients with the FIPS endpoints", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				FIPSEndpoints: lo.ToPtr(true),
+			}))
+			Expect(ec2.New(sess, awscontext.ClientConfig(ctx, ec2.EndpointsID)).Endpoint).To(Equal("https://ec2-fips.us-west-2.amazonaws.com"))
+			Expect(ssm.New(sess, awscontext.ClientConfig(ctx, ssm.EndpointsID)).Endpoint).To(Equal("https://ssm-fips.us-west-2.amazonaws.com"))
+			pricingAPI := pricing.NewAPI(sess, "us-west-2", awscontext.ClientConfig(ctx, awspricing.EndpointsID))
+			Expect(pricingAPI.(*awspricing.Pricing).Endpoint).To(Equal("https://api.pricing.us-east-1.amazonaws.com"))
+		})
+		It("should prefer the overridden endpoints over the FIPS endpoints", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				EC2Endpoint:   lo.ToPtr("https://vpce-ec2.test.vpce.amazonaws.com"),
+				FIPSEndpoints: lo.ToPtr(true),
+			}))
+			Expect(ec2.New(sess, awscontext.ClientConfig(ctx, ec2.EndpointsID)).Endpoint).To(Equal("https://vpce-ec2.test.vpce.amazonaws.com"))
+			Expect(ssm.New(sess, awscontext.ClientConfig(ctx, ssm.EndpointsID)).Endpoint).To(Equal("https://ssm-fips.us-west-2.amazonaws.com"))
+		})
+	})
 })
@@ -0,0 +1,158 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package account
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityreservation"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+)
+
+// Providers are the providers that discover and manage the resources of EC2NodeClasses in a single AWS account. Zone
+// names map to different zones in each account, so the offerings of instance types and the offerings that are
+// unavailable after insufficient capacity errors are tracked separately for each account.
+type Providers struct {
+	// EC2API is the EC2 client of the account, for controllers that clean up resources that aren't tracked by the
+	// other providers
+	EC2API                      ec2iface.EC2API
+	SubnetProvider              subnet.Provider
+	SecurityGroupProvider       securitygroup.Provider
+	CapacityReservationProvider capacityreservation.Provider
+	InstanceProfileProvider     instanceprofile.Provider
+	AMIProvider                 amifamily.Provider
+	LaunchTemplateProvider      launchtemplate.Provider
+	InstanceTypeProvider        instancetype.Provider
+	InstanceProvider            instance.Provider
+	UnavailableOfferings        *awscache.UnavailableOfferings
+}
+
+type Provider interface {
+	// Get returns the providers for the account that the EC2NodeClass manages its instances in
+	Get(context.Context, *v1beta1.EC2NodeClass) *Providers
+	// List returns the providers for every account that EC2NodeClasses manage instances in, starting with the account
+	// that Karpenter runs in
+	List(context.Context) ([]*Providers, error)
+}
+
+type DefaultProvider struct {
+	sync.Mutex
+	kubeClient       client.Client
+	stsapi           stsiface.STSAPI
+	defaultProviders *Providers
+	newProviders     func(string, *credentials.Credentials) *Providers
+	credentialsOpts  []func(*stscreds.AssumeRoleProvider)
+
+	// providers are the providers of the assumed roles, keyed by role ARN
+	providers map[string]*Providers
+}
+
+// NewDefaultProvider returns a provider that uses the defaultProviders for EC2NodeClasses that don't assume a role. The
+// providers of an assumed role are built once with newProviders from the role ARN and its credentials, which are cached
+// and refreshed by STS before they expire.
+func NewDefaultProvider(kubeClient client.Client, stsapi stsiface.STSAPI, defaultProviders *Providers,
+	newProviders func(string, *credentials.Credentials) *Providers, credentialsOpts ...func(*stscreds.AssumeRoleProvider)) *DefaultProvider {
+	return &DefaultProvider{
+		kubeClient:       kubeClient,
+		stsapi:           stsapi,
+		defaultProviders: defaultProviders,
+		newProviders:     newProviders,
+		credentialsOpts:  credentialsOpts,
+		providers:        map[string]*Providers{},
+	}
+}
+
+func (p *DefaultProvider) Get(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) *Providers {
+	roleARN := lo.FromPtr(nodeClass.Spec.AssumeRoleARN)
+	if roleARN == "" {
+		return p.defaultProviders
+	}
+	return p.forRole(ctx, roleARN)
+}
+
+func (p *DefaultProvider) List(ctx context.Context) ([]*Providers, error) {
+	nodeClassList := &v1beta1.EC2NodeClassList{}
+	if err := p.kubeClient.List(ctx, nodeClassList); err != nil {
+		return nil, fmt.Errorf("listing ec2nodeclasses, %w", err)
+	}
+	roleARNs := sets.New[string]()
+	for i := range nodeClassList.Items {
+		if roleARN := lo.FromPtr(nodeClassList.Items[i].Spec.AssumeRoleARN); roleARN != "" {
+			roleARNs.Insert(roleARN)
+			p.forRole(ctx, roleARN)
+		}
+	}
+	p.Lock()
+	defer p.Unlock()
+	// The assumed role of an EC2NodeClass is immutable and the EC2NodeClass isn't deleted until its NodeClaims are, so
+	// the providers of roles that are no longer referenced don't manage any instances
+	for roleARN := range p.providers {
+		if !roleARNs.Has(roleARN) {
+			log.FromContext(ctx).WithValues("role-arn", roleARN).V(1).Info("removing providers for assumed role")
+			delete(p.providers, roleARN)
+		}
+	}
+	return append([]*Providers{p.defaultProviders}, lo.Map(sets.List(roleARNs), func(roleARN string, _ int) *Providers {
+		return p.providers[roleARN]
+	})...), nil
+}
+
+// forRole returns the providers of the role, building them if they don't exist yet. Building the providers discovers
+// the instance types of the account, so it's done without holding the lock to avoid blocking lookups for other roles.
+func (p *DefaultProvider) forRole(ctx context.Context, roleARN string) *Providers {
+	p.Lock()
+	providers, ok := p.providers[roleARN]
+	p.Unlock()
+	if ok {
+		return providers
+	}
+	log.FromContext(ctx).WithValues("role-arn", roleARN).V(1).Info("building providers for assumed role")
+	providers = p.newProviders(roleARN, stscreds.NewCredentialsWithClient(p.stsapi, roleARN, p.credentialsOpts...))
+
+	p.Lock()
+	defer p.Unlock()
+	// Another caller may have built the providers of the role while we weren't holding the lock
+	if existing, ok := p.providers[roleARN]; ok {
+		return existing
+	}
+	p.providers[roleARN] = providers
+	return providers
+}
+
+// Reset drops the providers of the assumed roles, so that they're built again on their next use
+func (p *DefaultProvider) Reset() {
+	p.Lock()
+	defer p.Unlock()
+	p.providers = map[string]*Providers{}
+}
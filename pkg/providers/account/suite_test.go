@@ -0,0 +1,210 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package account_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/samber/lo"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var stop context.CancelFunc
+var env *coretest.Environment
+var awsEnv *test.Environment
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AccountProvider")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	ctx, stop = context.WithCancel(ctx)
+	awsEnv = test.NewEnvironment(ctx, env)
+})
+
+var _ = AfterSuite(func() {
+	stop()
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("AccountProvider", func() {
+	var defaultProviders *account.Providers
+	var accountCredentials []*credentials.Credentials
+	var accountProvider *account.DefaultProvider
+	BeforeEach(func() {
+		defaultProviders = &account.Providers{}
+		accountCredentials = nil
+		accountProvider = account.NewDefaultProvider(env.Client, awsEnv.STSAPI, defaultProviders, func(_ string, c *credentials.Credentials) *account.Providers {
+			accountCredentials = append(accountCredentials, c)
+			return &account.Providers{}
+		})
+	})
+	It("should use the default providers when the EC2NodeClass doesn't assume a role", func() {
+		nodeClass := test.EC2NodeClass()
+		Expect(accountProvider.Get(ctx, nodeClass)).To(BeIdenticalTo(defaultProviders))
+		Expect(accountCredentials).To(BeEmpty())
+	})
+	It("should build the providers of an assumed role once", func() {
+		nodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AssumeRoleARN: lo.ToPtr("arn:aws:iam::123456789012:role/karpenter-nodes")}})
+		otherNodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AssumeRoleARN: lo.ToPtr("arn:aws:iam::123456789012:role/karpenter-nodes")}})
+		providers := accountProvider.Get(ctx, nodeClass)
+		Expect(providers).ToNot(BeIdenticalTo(defaultProviders))
+		Expect(accountProvider.Get(ctx, otherNodeClass)).To(BeIdenticalTo(providers))
+		Expect(accountCredentials).To(HaveLen(1))
+	})
+	It("should build separate providers for each assumed role", func() {
+		nodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AssumeRoleARN: lo.ToPtr("arn:aws:iam::123456789012:role/karpenter-nodes")}})
+		otherNodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AssumeRoleARN: lo.ToPtr("arn:aws:iam::210987654321:role/karpenter-nodes")}})
+		Expect(accountProvider.Get(ctx, nodeClass)).ToNot(BeIdenticalTo(accountProvider.Get(ctx, otherNodeClass)))
+		Expect(accountCredentials).To(HaveLen(2))
+	})
+	It("should assume the role of the EC2NodeClass when retrieving credentials", func() {
+		nodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AssumeRoleARN: lo.ToPtr("arn:aws:iam::123456789012:role/karpenter-nodes")}})
+		accountProvider.Get(ctx, nodeClass)
+		Expect(accountCredentials).To(HaveLen(1))
+		Expect(awsEnv.STSAPI.AssumeRoleBehavior.Calls()).To(Equal(0))
+
+		value, err := accountCredentials[0].GetWithContext(ctx)
+		Expect(err).To(BeNil())
+		Expect(value.AccessKeyID).ToNot(BeEmpty())
+		Expect(value.SessionToken).ToNot(BeEmpty())
+		Expect(awsEnv.STSAPI.AssumeRoleBehavior.Calls()).To(Equal(1))
+		Expect(aws.StringValue(awsEnv.STSAPI.AssumeRoleBehavior.CalledWithInput.Pop().RoleArn)).To(Equal("arn:aws:iam::123456789012:role/karpenter-nodes"))
+	})
+	It("should cache the credentials of an assumed role until they expire", func() {
+		nodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AssumeRoleARN: lo.ToPtr("arn:aws:iam::123456789012:role/karpenter-nodes")}})
+		accountProvider.Get(ctx, nodeClass)
+		for i := 0; i < 3; i++ {
+			_, err := accountCredentials[0].GetWithContext(ctx)
+			Expect(err).To(BeNil())
+		}
+		Expect(awsEnv.STSAPI.AssumeRoleBehavior.Calls()).To(Equal(1))
+	})
+	It("should refresh the credentials of an assumed role when they expire", func() {
+		awsEnv.STSAPI.AssumeRoleBehavior.Output.Set(&sts.AssumeRoleOutput{
+			Credentials: &sts.Credentials{
+				AccessKeyId:     aws.String("ASIAEXPIRED"),
+				SecretAccessKey: aws.String("secret"),
+				SessionToken:    aws.String("token"),
+				Expiration:      aws.Time(time.Now().Add(-time.Minute)),
+			},
+		})
+		nodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AssumeRoleARN: lo.ToPtr("arn:aws:iam::123456789012:role/karpenter-nodes")}})
+		accountProvider.Get(ctx, nodeClass)
+		_, err := accountCredentials[0].GetWithContext(ctx)
+		Expect(err).To(BeNil())
+		Expect(accountCredentials[0].IsExpired()).To(BeTrue())
+		_, err = accountCredentials[0].GetWithContext(ctx)
+		Expect(err).To(BeNil())
+		Expect(awsEnv.STSAPI.AssumeRoleBehavior.Calls()).To(Equal(2))
+	})
+	It("should apply the credentials options when assuming a role", func() {
+		accountProvider = account.NewDefaultProvider(env.Client, awsEnv.STSAPI, defaultProviders, func(_ string, c *credentials.Credentials) *account.Providers {
+			accountCredentials = append(accountCredentials, c)
+			return &account.Providers{}
+		}, func(provider *stscreds.AssumeRoleProvider) { provider.Duration = time.Hour })
+		nodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AssumeRoleARN: lo.ToPtr("arn:aws:iam::123456789012:role/karpenter-nodes")}})
+		accountProvider.Get(ctx, nodeClass)
+		_, err := accountCredentials[0].GetWithContext(ctx)
+		Expect(err).To(BeNil())
+		Expect(aws.Int64Value(awsEnv.STSAPI.AssumeRoleBehavior.CalledWithInput.Pop().DurationSeconds)).To(BeNumerically("==", time.Hour.Seconds()))
+	})
+	It("should list the providers of every account, starting with the default providers", func() {
+		nodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AssumeRoleARN: lo.ToPtr("arn:aws:iam::123456789012:role/karpenter-nodes")}})
+		otherNodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AssumeRoleARN: lo.ToPtr("arn:aws:iam::210987654321:role/karpenter-nodes")}})
+		ExpectApplied(ctx, env.Client, nodeClass, otherNodeClass, test.EC2NodeClass())
+
+		providers, err := accountProvider.List(ctx)
+		Expect(err).To(BeNil())
+		Expect(providers).To(HaveLen(3))
+		Expect(providers[0]).To(BeIdenticalTo(defaultProviders))
+		Expect(providers[1:]).To(ConsistOf(accountProvider.Get(ctx, nodeClass), accountProvider.Get(ctx, otherNodeClass)))
+	})
+	It("should remove the providers of roles that are no longer assumed", func() {
+		nodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AssumeRoleARN: lo.ToPtr("arn:aws:iam::123456789012:role/karpenter-nodes")}})
+		providers := accountProvider.Get(ctx, nodeClass)
+
+		listed, err := accountProvider.List(ctx)
+		Expect(err).To(BeNil())
+		Expect(listed).To(HaveLen(1))
+		Expect(listed[0]).To(BeIdenticalTo(defaultProviders))
+
+		// The providers are built again the next time that the role is assumed
+		Expect(accountProvider.Get(ctx, nodeClass)).ToNot(BeIdenticalTo(providers))
+		Expect(accountCredentials).To(HaveLen(2))
+	})
+	It("should look up the providers of other roles while building the providers of a role", func() {
+		building := make(chan struct{})
+		release := make(chan struct{})
+		accountProvider = account.NewDefaultProvider(env.Client, awsEnv.STSAPI, defaultProviders, func(_ string, c *credentials.Credentials) *account.Providers {
+			if len(accountCredentials) == 1 {
+				close(building)
+				<-release
+			}
+			accountCredentials = append(accountCredentials, c)
+			return &account.Providers{}
+		})
+		nodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AssumeRoleARN: lo.ToPtr("arn:aws:iam::123456789012:role/karpenter-nodes")}})
+		otherNodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AssumeRoleARN: lo.ToPtr("arn:aws:iam::210987654321:role/karpenter-nodes")}})
+		providers := accountProvider.Get(ctx, nodeClass)
+
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			accountProvider.Get(ctx, otherNodeClass)
+		}()
+		Eventually(building).Should(BeClosed())
+		Expect(accountProvider.Get(ctx, nodeClass)).To(BeIdenticalTo(providers))
+		close(release)
+		Eventually(done).Should(BeClosed())
+	})
+})
@@ -32,14 +32,36 @@ type AL2023 struct {
 	*Options
 }
 
+// DefaultAMIs returns the AMI name, and Requirements, with an SSM query. NVIDIA GPU and Neuron instance types
+// resolve to the accelerated variants of the recommended image, all other x86_64 instance types to the standard one.
+// Accelerated instance types fall back to the standard image when their variant isn't published for the version.
 func (a AL2023) DefaultAMIs(version string) []DefaultAMIOutput {
+	standard := fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/standard/recommended/image_id", version)
 	return []DefaultAMIOutput{
 		{
-			Query: fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/standard/recommended/image_id", version),
+			Query: standard,
 			Requirements: scheduling.NewRequirements(
 				scheduling.NewRequirement(v1.LabelArchStable, v1.NodeSelectorOpIn, corev1beta1.ArchitectureAmd64),
+				scheduling.NewRequirement(v1beta1.LabelInstanceGPUManufacturer, v1.NodeSelectorOpNotIn, "nvidia"),
+				scheduling.NewRequirement(v1beta1.LabelInstanceAcceleratorManufacturer, v1.NodeSelectorOpNotIn, "aws"),
 			),
 		},
+		{
+			Query: fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/nvidia/recommended/image_id", version),
+			Requirements: scheduling.NewRequirements(
+				scheduling.NewRequirement(v1.LabelArchStable, v1.NodeSelectorOpIn, corev1beta1.ArchitectureAmd64),
+				scheduling.NewRequirement(v1beta1.LabelInstanceGPUManufacturer, v1.NodeSelectorOpIn, "nvidia"),
+			),
+			FallbackQuery: standard,
+		},
+		{
+			Query: fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/neuron/recommended/image_id", version),
+			Requirements: scheduling.NewRequirements(
+				scheduling.NewRequirement(v1.LabelArchStable, v1.NodeSelectorOpIn, corev1beta1.ArchitectureAmd64),
+				scheduling.NewRequirement(v1beta1.LabelInstanceAcceleratorManufacturer, v1.NodeSelectorOpIn, "aws"),
+			),
+			FallbackQuery: standard,
+		},
 		{
 			Query: fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/arm64/standard/recommended/image_id", version),
 			Requirements: scheduling.NewRequirements(
@@ -49,7 +71,7 @@ func (a AL2023) DefaultAMIs(version string) []DefaultAMIOutput {
 	}
 }
 
-func (a AL2023) UserData(kubeletConfig *corev1beta1.KubeletConfiguration, taints []v1.Taint, labels map[string]string, caBundle *string, _ []*cloudprovider.InstanceType, customUserData *string, instanceStorePolicy *v1beta1.InstanceStorePolicy) bootstrap.Bootstrapper {
+func (a AL2023) UserData(kubeletConfig *corev1beta1.KubeletConfiguration, taints []v1.Taint, labels map[string]string, caBundle *string, _ []*cloudprovider.InstanceType, customUserData *string, userDataFragments []string, instanceStorePolicy *v1beta1.InstanceStorePolicy) bootstrap.Bootstrapper {
 	return bootstrap.Nodeadm{
 		Options: bootstrap.Options{
 			ClusterName:             a.Options.ClusterName,
@@ -61,7 +83,10 @@ func (a AL2023) UserData(kubeletConfig *corev1beta1.KubeletConfiguration, taints
 			CABundle:                caBundle,
 			AWSENILimitedPodDensity: false,
 			CustomUserData:          customUserData,
+			UserDataFragments:       userDataFragments,
 			InstanceStorePolicy:     instanceStorePolicy,
+			TrustedCABundle:         a.Options.TrustedCABundle,
+			RegistryMirrors:         a.Options.RegistryMirrors,
 		},
 	}
 }
@@ -24,16 +24,24 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/imagebuilder"
+	"github.com/aws/aws-sdk-go/service/imagebuilder/imagebuilderiface"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
+	"golang.org/x/sync/semaphore"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/metrics"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/version"
+	"github.com/aws/karpenter-provider-aws/pkg/tracing"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
@@ -42,15 +50,32 @@ import (
 
 type Provider interface {
 	List(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (AMIs, error)
+	FailedImagePipelines(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) ([]string, error)
+	ListDefaults(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (AMIs, error)
+	DeleteAMIAges(nodeClass *v1beta1.EC2NodeClass)
 }
 
 type DefaultProvider struct {
-	sync.Mutex
+	// muAMIAges serializes the publishing of the AMI age metric, which drops and re-creates the series of a nodeclass
+	muAMIAges       sync.Mutex
+	region          string
 	cache           *cache.Cache
 	ssm             ssmiface.SSMAPI
 	ec2api          ec2iface.EC2API
+	imagebuilder    imagebuilderiface.ImagebuilderAPI
 	cm              *pretty.ChangeMonitor
 	versionProvider version.Provider
+	// limiter caps the SSM, DescribeImages and Image Builder calls that are in-flight at once, and is shared by the
+	// providers of a process so that resolving the AMIs of many nodeclasses at once doesn't get throttled
+	limiter *semaphore.Weighted
+}
+
+// imagePipeline is the resolved state of an EC2 Image Builder image pipeline
+type imagePipeline struct {
+	// AMIIDs are the AMIs distributed to the region by the latest successful build of the pipeline
+	AMIIDs []string
+	// LatestBuildFailed is true when the most recent build of the pipeline failed
+	LatestBuildFailed bool
 }
 
 type AMI struct {
@@ -58,6 +83,11 @@ type AMI struct {
 	AmiID        string
 	CreationDate string
 	Requirements scheduling.Requirements
+	// RootDeviceName and RootSnapshotSize describe the root volume of the AMI, which can't be launched smaller than its
+	// snapshot
+	RootDeviceName   string
+	RootSnapshotSize *resource.Quantity
+	Deprecated       bool
 }
 
 type AMIs []AMI
@@ -89,30 +119,36 @@ func MapToInstanceTypes(instanceTypes []*cloudprovider.InstanceType, amis []v1be
 	return amiIDs
 }
 
-func NewDefaultProvider(versionProvider version.Provider, ssm ssmiface.SSMAPI, ec2api ec2iface.EC2API, cache *cache.Cache) *DefaultProvider {
+func NewDefaultProvider(region string, versionProvider version.Provider, ssm ssmiface.SSMAPI, ec2api ec2iface.EC2API,
+	imagebuilder imagebuilderiface.ImagebuilderAPI, cache *cache.Cache, limiter *semaphore.Weighted) *DefaultProvider {
 	return &DefaultProvider{
+		region:          region,
 		cache:           cache,
 		ssm:             ssm,
 		ec2api:          ec2api,
+		imagebuilder:    imagebuilder,
 		cm:              pretty.NewChangeMonitor(),
 		versionProvider: versionProvider,
+		limiter:         limiter,
 	}
 }
 
 // Get Returning a list of AMIs with its associated requirements
 func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (AMIs, error) {
-	p.Lock()
-	defer p.Unlock()
+	ctx, span := tracing.Start(ctx, "ami.List", tracing.NodeClass(nodeClass))
+	defer span.End()
+	var cacheHit bool
+	defer metrics.MeasureResolution(metrics.ProviderTypeAMI)(&cacheHit)
 
 	var err error
 	var amis AMIs
 	if len(nodeClass.Spec.AMISelectorTerms) == 0 {
-		amis, err = p.getDefaultAMIs(ctx, nodeClass)
+		amis, cacheHit, err = p.getDefaultAMIs(ctx, nodeClass)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		amis, err = p.getAMIs(ctx, nodeClass.Spec.AMISelectorTerms)
+		amis, cacheHit, err = p.getAMIs(ctx, nodeClass.Spec.AMISelectorTerms)
 		if err != nil {
 			return nil, err
 		}
@@ -123,30 +159,100 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1beta1.EC2NodeCl
 		log.FromContext(ctx).WithValues(
 			"ids", uniqueAMIs).V(1).Info("discovered amis")
 	}
+	p.recordAMIAges(nodeClass, amis)
 	return amis, nil
 }
 
-func (p *DefaultProvider) getDefaultAMIs(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (res AMIs, err error) {
+// recordAMIAges publishes the age of each AMI resolved for the nodeclass. Series for AMIs that the nodeclass no longer
+// resolves are dropped so that cardinality stays bounded by the currently resolved AMIs.
+func (p *DefaultProvider) recordAMIAges(nodeClass *v1beta1.EC2NodeClass, amis AMIs) {
+	p.muAMIAges.Lock()
+	defer p.muAMIAges.Unlock()
+
+	now := time.Now()
+	amiAgeSeconds.DeletePartialMatch(prometheus.Labels{nodeClassLabel: nodeClass.Name})
+	for _, ami := range amis {
+		creationDate, err := time.Parse(time.RFC3339, ami.CreationDate)
+		if err != nil {
+			continue
+		}
+		amiAgeSeconds.With(prometheus.Labels{
+			nodeClassLabel: nodeClass.Name,
+			amiIDLabel:     ami.AmiID,
+		}).Set(float64(now.Unix() - creationDate.Unix()))
+	}
+}
+
+// DeleteAMIAges drops the AMI age series of the nodeclass, which is called when the nodeclass is deleted
+func (p *DefaultProvider) DeleteAMIAges(nodeClass *v1beta1.EC2NodeClass) {
+	p.muAMIAges.Lock()
+	defer p.muAMIAges.Unlock()
+
+	amiAgeSeconds.DeletePartialMatch(prometheus.Labels{nodeClassLabel: nodeClass.Name})
+}
+
+// ListDefaults returns the default AMIs of the nodeclass's AMIFamily, regardless of its amiSelectorTerms
+func (p *DefaultProvider) ListDefaults(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (AMIs, error) {
+	amis, _, err := p.getDefaultAMIs(ctx, nodeClass)
+	if err != nil {
+		return nil, err
+	}
+	amis.Sort()
+	return amis, nil
+}
+
+// FailedImagePipelines returns the ARNs of the image pipelines selected by the nodeclass whose latest build failed
+func (p *DefaultProvider) FailedImagePipelines(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) ([]string, error) {
+	var failed []string
+	for _, term := range nodeClass.Spec.AMISelectorTerms {
+		if term.ImagePipelineARN == "" {
+			continue
+		}
+		pipeline, err := p.getImagePipeline(ctx, term.ImagePipelineARN)
+		if err != nil {
+			return nil, err
+		}
+		if pipeline.LatestBuildFailed {
+			failed = append(failed, term.ImagePipelineARN)
+		}
+	}
+	return failed, nil
+}
+
+// getDefaultAMIs returns the default AMIs of the nodeclass's AMIFamily, and whether they were served from the cache
+func (p *DefaultProvider) getDefaultAMIs(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (res AMIs, cacheHit bool, err error) {
 	if images, ok := p.cache.Get(lo.FromPtr(nodeClass.Spec.AMIFamily)); ok {
 		// Ensure what's returned from this function is a deep-copy of AMIs so alterations
 		// to the data don't affect the original
-		return append(AMIs{}, images.(AMIs)...), nil
+		return append(AMIs{}, images.(AMIs)...), true, nil
 	}
 	amiFamily := GetAMIFamily(nodeClass.Spec.AMIFamily, &Options{})
 	kubernetesVersion, err := p.versionProvider.Get(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("getting kubernetes version %w", err)
+		return nil, false, fmt.Errorf("getting kubernetes version %w", err)
 	}
 	defaultAMIs := amiFamily.DefaultAMIs(kubernetesVersion)
-	for _, ami := range defaultAMIs {
-		if id, err := p.resolveSSMParameter(ctx, ami.Query); err != nil {
-			log.FromContext(ctx).WithValues("query", ami.Query).Error(err, "failed discovering amis from ssm")
-		} else {
-			res = append(res, AMI{AmiID: id, Requirements: ami.Requirements})
+	// The queries are resolved in parallel, bounded by the limiter, and collected in the order of the AMI family
+	ids := make([]string, len(defaultAMIs))
+	workqueue.ParallelizeUntil(ctx, len(defaultAMIs), len(defaultAMIs), func(i int) {
+		id, err := p.resolveSSMParameter(ctx, defaultAMIs[i].Query)
+		if err != nil && defaultAMIs[i].FallbackQuery != "" {
+			log.FromContext(ctx).WithValues("query", defaultAMIs[i].Query, "fallbackQuery", defaultAMIs[i].FallbackQuery).V(1).Info("failed discovering amis from ssm, resolving the fallback query")
+			id, err = p.resolveSSMParameter(ctx, defaultAMIs[i].FallbackQuery)
+		}
+		if err != nil {
+			log.FromContext(ctx).WithValues("query", defaultAMIs[i].Query).Error(err, "failed discovering amis from ssm")
+			return
+		}
+		ids[i] = id
+	})
+	for i, ami := range defaultAMIs {
+		if ids[i] != "" {
+			res = append(res, AMI{AmiID: ids[i], Requirements: ami.Requirements})
 		}
 	}
 	// Resolve Name and CreationDate information into the DefaultAMIs
-	if err = p.ec2api.DescribeImagesPagesWithContext(ctx, &ec2.DescribeImagesInput{
+	if err = p.describeImagesPages(ctx, &ec2.DescribeImagesInput{
 		Filters:    []*ec2.Filter{{Name: aws.String("image-id"), Values: aws.StringSlice(lo.Map(res, func(a AMI, _ int) string { return a.AmiID }))}},
 		MaxResults: aws.Int64(500),
 	}, func(page *ec2.DescribeImagesOutput, _ bool) bool {
@@ -155,19 +261,59 @@ func (p *DefaultProvider) getDefaultAMIs(ctx context.Context, nodeClass *v1beta1
 				if res[j].AmiID == aws.StringValue(page.Images[i].ImageId) {
 					res[j].Name = aws.StringValue(page.Images[i].Name)
 					res[j].CreationDate = aws.StringValue(page.Images[i].CreationDate)
+					res[j].RootDeviceName = aws.StringValue(page.Images[i].RootDeviceName)
+					res[j].RootSnapshotSize = rootSnapshotSize(page.Images[i])
+					res[j].Deprecated = deprecated(page.Images[i])
 				}
 			}
 		}
 		return true
 	}); err != nil {
-		return nil, fmt.Errorf("describing images, %w", err)
+		return nil, false, fmt.Errorf("describing images, %w", err)
 	}
 	p.cache.SetDefault(lo.FromPtr(nodeClass.Spec.AMIFamily), res)
-	return res, nil
+	return res, false, nil
+}
+
+// rootSnapshotSize returns the size of the snapshot of the root volume of the image, if the image has one
+func rootSnapshotSize(image *ec2.Image) *resource.Quantity {
+	bdm, ok := lo.Find(image.BlockDeviceMappings, func(bdm *ec2.BlockDeviceMapping) bool {
+		return aws.StringValue(bdm.DeviceName) == aws.StringValue(image.RootDeviceName)
+	})
+	if !ok || bdm.Ebs == nil || bdm.Ebs.VolumeSize == nil {
+		return nil
+	}
+	return lo.ToPtr(resource.MustParse(fmt.Sprintf("%dGi", aws.Int64Value(bdm.Ebs.VolumeSize))))
+}
+
+// deprecated returns true if the deprecation time of the image has passed
+func deprecated(image *ec2.Image) bool {
+	if image.DeprecationTime == nil {
+		return false
+	}
+	deprecationTime, err := time.Parse(time.RFC3339, aws.StringValue(image.DeprecationTime))
+	return err == nil && !deprecationTime.After(time.Now())
+}
+
+// limit runs the call once fewer calls than the capacity of the limiter are in-flight
+func (p *DefaultProvider) limit(ctx context.Context, call func() error) error {
+	if err := p.limiter.Acquire(ctx, 1); err != nil {
+		return err
+	}
+	defer p.limiter.Release(1)
+	return call()
+}
+
+func (p *DefaultProvider) describeImagesPages(ctx context.Context, input *ec2.DescribeImagesInput, fn func(*ec2.DescribeImagesOutput, bool) bool) error {
+	return p.limit(ctx, func() error { return p.ec2api.DescribeImagesPagesWithContext(ctx, input, fn) })
 }
 
 func (p *DefaultProvider) resolveSSMParameter(ctx context.Context, ssmQuery string) (string, error) {
-	output, err := p.ssm.GetParameterWithContext(ctx, &ssm.GetParameterInput{Name: aws.String(ssmQuery)})
+	var output *ssm.GetParameterOutput
+	err := p.limit(ctx, func() (err error) {
+		output, err = p.ssm.GetParameterWithContext(ctx, &ssm.GetParameterInput{Name: aws.String(ssmQuery)})
+		return err
+	})
 	if err != nil {
 		return "", fmt.Errorf("getting ssm parameter %q, %w", ssmQuery, err)
 	}
@@ -175,24 +321,34 @@ func (p *DefaultProvider) resolveSSMParameter(ctx context.Context, ssmQuery stri
 	return ami, nil
 }
 
-func (p *DefaultProvider) getAMIs(ctx context.Context, terms []v1beta1.AMISelectorTerm) (AMIs, error) {
+// getAMIs returns the AMIs selected by the terms, and whether they were served from the cache
+func (p *DefaultProvider) getAMIs(ctx context.Context, terms []v1beta1.AMISelectorTerm) (AMIs, bool, error) {
+	terms, err := p.resolveImagePipelineTerms(ctx, terms)
+	if err != nil {
+		return nil, false, err
+	}
+	terms, err = p.resolveSSMParameterTerms(ctx, terms)
+	if err != nil {
+		return nil, false, err
+	}
 	filterAndOwnerSets := GetFilterAndOwnerSets(terms)
 	hash, err := hashstructure.Hash(filterAndOwnerSets, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	if images, ok := p.cache.Get(fmt.Sprintf("%d", hash)); ok {
 		// Ensure what's returned from this function is a deep-copy of AMIs so alterations
 		// to the data don't affect the original
-		return append(AMIs{}, images.(AMIs)...), nil
+		return append(AMIs{}, images.(AMIs)...), true, nil
 	}
 	images := map[uint64]AMI{}
 	for _, filtersAndOwners := range filterAndOwnerSets {
-		if err = p.ec2api.DescribeImagesPagesWithContext(ctx, &ec2.DescribeImagesInput{
+		if err = p.describeImagesPages(ctx, &ec2.DescribeImagesInput{
 			// Don't include filters in the Describe Images call as EC2 API doesn't allow empty filters.
-			Filters:    lo.Ternary(len(filtersAndOwners.Filters) > 0, filtersAndOwners.Filters, nil),
-			Owners:     lo.Ternary(len(filtersAndOwners.Owners) > 0, aws.StringSlice(filtersAndOwners.Owners), nil),
-			MaxResults: aws.Int64(1000),
+			Filters:           lo.Ternary(len(filtersAndOwners.Filters) > 0, filtersAndOwners.Filters, nil),
+			Owners:            lo.Ternary(len(filtersAndOwners.Owners) > 0, aws.StringSlice(filtersAndOwners.Owners), nil),
+			IncludeDeprecated: lo.Ternary(filtersAndOwners.IncludeDeprecated, aws.Bool(true), nil),
+			MaxResults:        aws.Int64(1000),
 		}, func(page *ec2.DescribeImagesOutput, _ bool) bool {
 			for i := range page.Images {
 				reqs := p.getRequirementsFromImage(page.Images[i])
@@ -204,49 +360,161 @@ func (p *DefaultProvider) getAMIs(ctx context.Context, terms []v1beta1.AMISelect
 				if v, ok := images[reqsHash]; ok {
 					candidateCreationTime, _ := time.Parse(time.RFC3339, lo.FromPtr(page.Images[i].CreationDate))
 					existingCreationTime, _ := time.Parse(time.RFC3339, v.CreationDate)
-					if existingCreationTime == candidateCreationTime && lo.FromPtr(page.Images[i].Name) < v.Name {
-						continue
-					}
 					if candidateCreationTime.Unix() < existingCreationTime.Unix() {
 						continue
 					}
+					// Break ties on the name and then on the ID, which is guaranteed to be unique, so that the
+					// selected image doesn't depend on the order that the images are returned in
+					if candidateCreationTime.Unix() == existingCreationTime.Unix() {
+						if lo.FromPtr(page.Images[i].Name) < v.Name {
+							continue
+						}
+						if lo.FromPtr(page.Images[i].Name) == v.Name && lo.FromPtr(page.Images[i].ImageId) > v.AmiID {
+							continue
+						}
+					}
 				}
 				images[reqsHash] = AMI{
-					Name:         lo.FromPtr(page.Images[i].Name),
-					AmiID:        lo.FromPtr(page.Images[i].ImageId),
-					CreationDate: lo.FromPtr(page.Images[i].CreationDate),
-					Requirements: reqs,
+					Name:             lo.FromPtr(page.Images[i].Name),
+					AmiID:            lo.FromPtr(page.Images[i].ImageId),
+					CreationDate:     lo.FromPtr(page.Images[i].CreationDate),
+					Requirements:     reqs,
+					RootDeviceName:   lo.FromPtr(page.Images[i].RootDeviceName),
+					RootSnapshotSize: rootSnapshotSize(page.Images[i]),
+					Deprecated:       deprecated(page.Images[i]),
 				}
 			}
 			return true
 		}); err != nil {
-			return nil, fmt.Errorf("describing images, %w", err)
+			return nil, false, fmt.Errorf("describing images, %w", err)
 		}
 	}
 	p.cache.SetDefault(fmt.Sprintf("%d", hash), AMIs(lo.Values(images)))
-	return lo.Values(images), nil
+	return lo.Values(images), false, nil
+}
+
+// resolveImagePipelineTerms replaces the terms that select an image pipeline with terms that select the AMIs of the
+// latest successful build of the pipeline by ID
+func (p *DefaultProvider) resolveImagePipelineTerms(ctx context.Context, terms []v1beta1.AMISelectorTerm) ([]v1beta1.AMISelectorTerm, error) {
+	var res []v1beta1.AMISelectorTerm
+	for _, term := range terms {
+		if term.ImagePipelineARN == "" {
+			res = append(res, term)
+			continue
+		}
+		pipeline, err := p.getImagePipeline(ctx, term.ImagePipelineARN)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range pipeline.AMIIDs {
+			res = append(res, v1beta1.AMISelectorTerm{ID: id, Architecture: term.Architecture})
+		}
+	}
+	return res, nil
+}
+
+// resolveSSMParameterTerms replaces the terms that select an SSM parameter with terms that select the AMI that the
+// parameter holds by ID. Parameters that are suffixed with a version are resolved at that version, since GetParameter
+// accepts the same name:version selector.
+func (p *DefaultProvider) resolveSSMParameterTerms(ctx context.Context, terms []v1beta1.AMISelectorTerm) ([]v1beta1.AMISelectorTerm, error) {
+	var res []v1beta1.AMISelectorTerm
+	for _, term := range terms {
+		if term.SSMParameter == "" {
+			res = append(res, term)
+			continue
+		}
+		key := fmt.Sprintf("ssm-parameter/%s", term.SSMParameter)
+		id, ok := p.cache.Get(key)
+		if !ok {
+			resolved, err := p.resolveSSMParameter(ctx, term.SSMParameter)
+			if err != nil {
+				return nil, err
+			}
+			p.cache.SetDefault(key, resolved)
+			id = resolved
+		}
+		res = append(res, v1beta1.AMISelectorTerm{ID: id.(string), Architecture: term.Architecture})
+	}
+	return res, nil
+}
+
+func (p *DefaultProvider) getImagePipeline(ctx context.Context, arn string) (imagePipeline, error) {
+	key := fmt.Sprintf("image-pipeline/%s", arn)
+	if pipeline, ok := p.cache.Get(key); ok {
+		return pipeline.(imagePipeline), nil
+	}
+	var images []*imagebuilder.ImageSummary
+	if err := p.limit(ctx, func() error {
+		return p.imagebuilder.ListImagePipelineImagesPagesWithContext(ctx, &imagebuilder.ListImagePipelineImagesInput{
+			ImagePipelineArn: aws.String(arn),
+		}, func(page *imagebuilder.ListImagePipelineImagesOutput, _ bool) bool {
+			images = append(images, page.ImageSummaryList...)
+			return true
+		})
+	}); err != nil {
+		return imagePipeline{}, fmt.Errorf("listing images of image pipeline %q, %w", arn, err)
+	}
+	// Order the images from the newest build to the oldest, breaking ties on the ARN so that the order is stable
+	sort.Slice(images, func(i, j int) bool {
+		itime, _ := time.Parse(time.RFC3339, aws.StringValue(images[i].DateCreated))
+		jtime, _ := time.Parse(time.RFC3339, aws.StringValue(images[j].DateCreated))
+		if !itime.Equal(jtime) {
+			return itime.After(jtime)
+		}
+		return aws.StringValue(images[i].Arn) > aws.StringValue(images[j].Arn)
+	})
+	status := func(image *imagebuilder.ImageSummary) string {
+		if image.State == nil {
+			return ""
+		}
+		return aws.StringValue(image.State.Status)
+	}
+	var pipeline imagePipeline
+	if len(images) > 0 {
+		pipeline.LatestBuildFailed = status(images[0]) == imagebuilder.ImageStatusFailed
+	}
+	if latest, ok := lo.Find(images, func(image *imagebuilder.ImageSummary) bool {
+		return status(image) == imagebuilder.ImageStatusAvailable
+	}); ok && latest.OutputResources != nil {
+		for _, ami := range latest.OutputResources.Amis {
+			if aws.StringValue(ami.Region) == p.region {
+				pipeline.AMIIDs = append(pipeline.AMIIDs, aws.StringValue(ami.Image))
+			}
+		}
+	}
+	p.cache.SetDefault(key, pipeline)
+	return pipeline, nil
 }
 
 type FiltersAndOwners struct {
-	Filters []*ec2.Filter
-	Owners  []string
+	Filters           []*ec2.Filter
+	Owners            []string
+	IncludeDeprecated bool
 }
 
 func GetFilterAndOwnerSets(terms []v1beta1.AMISelectorTerm) (res []FiltersAndOwners) {
 	idFilter := &ec2.Filter{Name: aws.String("image-id")}
 	for _, term := range terms {
 		switch {
+		case term.ID != "" && term.Architecture != "":
+			// IDs that are constrained to an architecture can't share the filter of the other IDs
+			res = append(res, FiltersAndOwners{Filters: []*ec2.Filter{
+				{Name: aws.String("image-id"), Values: aws.StringSlice([]string{term.ID})},
+				architectureFilter(term.Architecture),
+			}})
 		case term.ID != "":
 			idFilter.Values = append(idFilter.Values, aws.String(term.ID))
 		default:
 			elem := FiltersAndOwners{
-				Owners: lo.Ternary(term.Owner != "", []string{term.Owner}, []string{}),
+				Owners:            lo.Ternary(term.Owner != "", []string{term.Owner}, []string{}),
+				IncludeDeprecated: term.IncludeDeprecated,
 			}
 			if term.Name != "" {
 				// Default owners to self,amazon to ensure Karpenter only discovers cross-account AMIs if the user specifically allows it.
 				// Removing this default would cause Karpenter to discover publicly shared AMIs passing the name filter.
 				elem = FiltersAndOwners{
-					Owners: lo.Ternary(term.Owner != "", []string{term.Owner}, []string{"self", "amazon"}),
+					Owners:            lo.Ternary(term.Owner != "", []string{term.Owner}, []string{"self", "amazon"}),
+					IncludeDeprecated: term.IncludeDeprecated,
 				}
 				elem.Filters = append(elem.Filters, &ec2.Filter{
 					Name:   aws.String("name"),
@@ -267,6 +535,9 @@ func GetFilterAndOwnerSets(terms []v1beta1.AMISelectorTerm) (res []FiltersAndOwn
 					})
 				}
 			}
+			if term.Architecture != "" {
+				elem.Filters = append(elem.Filters, architectureFilter(term.Architecture))
+			}
 			res = append(res, elem)
 		}
 	}
@@ -276,6 +547,17 @@ func GetFilterAndOwnerSets(terms []v1beta1.AMISelectorTerm) (res []FiltersAndOwn
 	return res
 }
 
+// architectureFilter returns the DescribeImages filter that selects the AMIs of the Kubernetes architecture
+func architectureFilter(architecture string) *ec2.Filter {
+	value := architecture
+	for awsArchitecture, kubeArchitecture := range v1beta1.AWSToKubeArchitectures {
+		if kubeArchitecture == architecture {
+			value = awsArchitecture
+		}
+	}
+	return &ec2.Filter{Name: aws.String("architecture"), Values: aws.StringSlice([]string{value})}
+}
+
 func (p *DefaultProvider) getRequirementsFromImage(ec2Image *ec2.Image) scheduling.Requirements {
 	requirements := scheduling.NewRequirements()
 	// Always add the architecture of an image as a requirement, irrespective of what's specified in EC2 tags.
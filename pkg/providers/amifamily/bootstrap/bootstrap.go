@@ -15,6 +15,8 @@ limitations under the License.
 package bootstrap
 
 import (
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"sort"
 	"strings"
@@ -40,7 +42,10 @@ type Options struct {
 	AWSENILimitedPodDensity bool
 	ContainerRuntime        *string
 	CustomUserData          *string
+	UserDataFragments       []string
 	InstanceStorePolicy     *v1beta1.InstanceStorePolicy
+	TrustedCABundle         *string
+	RegistryMirrors         []v1beta1.RegistryMirror
 }
 
 func (o Options) kubeletExtraArgs() (args []string) {
@@ -122,3 +127,52 @@ func joinParameterArgs[K comparable, V any](name string, m map[K]V, separator st
 type Bootstrapper interface {
 	Script() (string, error)
 }
+
+// ParseCABundle returns the PEM encoded certificates of the bundle. It fails if the bundle doesn't contain any
+// certificates, or contains anything other than valid certificates.
+func ParseCABundle(bundle string) ([]string, error) {
+	var certificates []string
+	rest := []byte(bundle)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("unexpected PEM block of type %q", block.Type)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return nil, fmt.Errorf("parsing certificate, %w", err)
+		}
+		certificates = append(certificates, string(pem.EncodeToMemory(block)))
+	}
+	if len(strings.TrimSpace(string(rest))) != 0 {
+		return nil, fmt.Errorf("unexpected data that isn't PEM encoded")
+	}
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+	return certificates, nil
+}
+
+// trustedCABundleScript returns the shell script that adds the trusted CA bundle to the system trust store of either
+// RHEL-based (AL2, AL2023) or Debian-based (Ubuntu) distributions, and restarts containerd so that image pulls use it
+func (o Options) trustedCABundleScript() string {
+	if lo.FromPtr(o.TrustedCABundle) == "" {
+		return ""
+	}
+	var script strings.Builder
+	script.WriteString("cat > /tmp/karpenter-trusted-ca-bundle.pem <<'KARPENTER_TRUSTED_CA_BUNDLE'\n")
+	script.WriteString(strings.TrimSpace(*o.TrustedCABundle) + "\n")
+	script.WriteString("KARPENTER_TRUSTED_CA_BUNDLE\n")
+	script.WriteString("if command -v update-ca-trust >/dev/null 2>&1; then\n")
+	script.WriteString("  install -m 0644 /tmp/karpenter-trusted-ca-bundle.pem /etc/pki/ca-trust/source/anchors/karpenter-trusted-ca-bundle.pem\n")
+	script.WriteString("  update-ca-trust extract\n")
+	script.WriteString("else\n")
+	script.WriteString("  install -m 0644 /tmp/karpenter-trusted-ca-bundle.pem /usr/local/share/ca-certificates/karpenter-trusted-ca-bundle.crt\n")
+	script.WriteString("  update-ca-certificates\n")
+	script.WriteString("fi\n")
+	script.WriteString("systemctl restart containerd\n")
+	return script.String()
+}
@@ -17,12 +17,16 @@ package bootstrap
 import (
 	"encoding/base64"
 	"fmt"
+	"sort"
 	"strconv"
 
 	"github.com/imdario/mergo"
 	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/aws/aws-sdk-go/aws"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
 )
 
 type Bottlerocket struct {
@@ -35,6 +39,11 @@ func (b Bottlerocket) Script() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("invalid UserData %w", err)
 	}
+	for _, fragment := range b.UserDataFragments {
+		if err := s.MergeTOML([]byte(fragment)); err != nil {
+			return "", fmt.Errorf("invalid UserData fragment %w", err)
+		}
+	}
 	// Karpenter will overwrite settings present inside custom UserData
 	// based on other fields specified in the NodePool
 	s.Settings.Kubernetes.ClusterName = &b.ClusterName
@@ -75,6 +84,19 @@ func (b Bottlerocket) Script() (string, error) {
 		}
 	}
 
+	if bundle := lo.FromPtr(b.TrustedCABundle); bundle != "" {
+		s.Settings.PKI = lo.Assign(s.Settings.PKI, map[string]BottlerocketPKI{
+			"karpenter-trusted-ca-bundle": {Data: lo.ToPtr(base64.StdEncoding.EncodeToString([]byte(bundle))), Trusted: lo.ToPtr(true)},
+		})
+	}
+
+	if len(b.RegistryMirrors) > 0 {
+		if s.SettingsRaw == nil {
+			s.SettingsRaw = map[string]interface{}{}
+		}
+		mergeRegistryMirrors(s.SettingsRaw, b.RegistryMirrors)
+	}
+
 	s.Settings.Kubernetes.NodeTaints = map[string][]string{}
 	for _, taint := range b.Taints {
 		s.Settings.Kubernetes.NodeTaints[taint.Key] = append(s.Settings.Kubernetes.NodeTaints[taint.Key], fmt.Sprintf("%s:%s", taint.Value, taint.Effect))
@@ -85,3 +107,36 @@ func (b Bottlerocket) Script() (string, error) {
 	}
 	return base64.StdEncoding.EncodeToString(script), nil
 }
+
+// mergeRegistryMirrors sets the registry mirrors in the container-registry settings. Mirrors from the custom UserData
+// are preserved for other registries, and may be specified either as an array of tables or as a table of registries.
+func mergeRegistryMirrors(settings map[string]interface{}, mirrors []v1beta1.RegistryMirror) {
+	containerRegistry, _ := settings["container-registry"].(map[string]interface{})
+	if containerRegistry == nil {
+		containerRegistry = map[string]interface{}{}
+	}
+	overridden := sets.New(lo.Map(mirrors, func(m v1beta1.RegistryMirror, _ int) string { return m.Registry })...)
+	var merged []interface{}
+	switch existing := containerRegistry["mirrors"].(type) {
+	case []interface{}:
+		for _, m := range existing {
+			if entry, ok := m.(map[string]interface{}); ok && overridden.Has(fmt.Sprint(entry["registry"])) {
+				continue
+			}
+			merged = append(merged, m)
+		}
+	case map[string]interface{}:
+		registries := lo.Keys(existing)
+		sort.Strings(registries)
+		for _, registry := range registries {
+			if !overridden.Has(registry) {
+				merged = append(merged, map[string]interface{}{"registry": registry, "endpoint": existing[registry]})
+			}
+		}
+	}
+	for _, m := range mirrors {
+		merged = append(merged, map[string]interface{}{"registry": m.Registry, "endpoint": m.Endpoints})
+	}
+	containerRegistry["mirrors"] = merged
+	settings["container-registry"] = containerRegistry
+}
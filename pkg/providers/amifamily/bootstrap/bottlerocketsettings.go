@@ -15,6 +15,7 @@ limitations under the License.
 package bootstrap
 
 import (
+	"github.com/imdario/mergo"
 	"github.com/pelletier/go-toml/v2"
 )
 
@@ -38,7 +39,8 @@ type BottlerocketConfig struct {
 // BottlerocketSettings is a subset of all configuration in https://github.com/bottlerocket-os/bottlerocket/blob/d427c40931cba6e6bedc5b75e9c084a6e1818db9/sources/models/src/lib.rs#L260
 // These settings apply across all K8s versions that karpenter supports.
 type BottlerocketSettings struct {
-	Kubernetes BottlerocketKubernetes `toml:"kubernetes"`
+	Kubernetes BottlerocketKubernetes     `toml:"kubernetes"`
+	PKI        map[string]BottlerocketPKI `toml:"pki,omitempty"`
 }
 
 // BottlerocketKubernetes is k8s specific configuration for bottlerocket api
@@ -93,6 +95,13 @@ type BottlerocketCredentialProvider struct {
 	Environment   map[string]string `toml:"environment,omitempty"`
 }
 
+// BottlerocketPKI is a certificate bundle that's added to the trust store of Bottlerocket
+// See Bottlerocket struct at https://github.com/bottlerocket-os/bottlerocket/blob/d427c40931cba6e6bedc5b75e9c084a6e1818db9/sources/models/src/lib.rs#L335
+type BottlerocketPKI struct {
+	Data    *string `toml:"data"`
+	Trusted *bool   `toml:"trusted"`
+}
+
 func (c *BottlerocketConfig) UnmarshalTOML(data []byte) error {
 	// unmarshal known settings
 	s := struct {
@@ -109,10 +118,39 @@ func (c *BottlerocketConfig) UnmarshalTOML(data []byte) error {
 	return nil
 }
 
+// MergeTOML merges the settings in data into the config. Settings in data take precedence over the same settings in
+// the config, while tables that are set in both are merged.
+func (c *BottlerocketConfig) MergeTOML(data []byte) error {
+	base, err := c.MarshalTOML()
+	if err != nil {
+		return err
+	}
+	merged := map[string]interface{}{}
+	if err := toml.Unmarshal(base, &merged); err != nil {
+		return err
+	}
+	overlay := map[string]interface{}{}
+	if err := toml.Unmarshal(data, &overlay); err != nil {
+		return err
+	}
+	if err := mergo.Merge(&merged, overlay, mergo.WithOverride); err != nil {
+		return err
+	}
+	mergedTOML, err := toml.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	*c = BottlerocketConfig{}
+	return c.UnmarshalTOML(mergedTOML)
+}
+
 func (c *BottlerocketConfig) MarshalTOML() ([]byte, error) {
 	if c.SettingsRaw == nil {
 		c.SettingsRaw = map[string]interface{}{}
 	}
 	c.SettingsRaw["kubernetes"] = c.Settings.Kubernetes
+	if len(c.Settings.PKI) > 0 {
+		c.SettingsRaw["pki"] = c.Settings.PKI
+	}
 	return toml.Marshal(c)
 }
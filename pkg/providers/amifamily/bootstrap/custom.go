@@ -16,8 +16,10 @@ package bootstrap
 
 import (
 	"encoding/base64"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/samber/lo"
 )
 
 type Custom struct {
@@ -25,5 +27,5 @@ type Custom struct {
 }
 
 func (e Custom) Script() (string, error) {
-	return base64.StdEncoding.EncodeToString([]byte(aws.StringValue(e.Options.CustomUserData))), nil
+	return base64.StdEncoding.EncodeToString([]byte(strings.Join(lo.Compact(append([]string{aws.StringValue(e.Options.CustomUserData)}, e.UserDataFragments...)), "\n"))), nil
 }
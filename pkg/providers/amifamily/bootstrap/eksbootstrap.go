@@ -44,7 +44,8 @@ const (
 )
 
 func (e EKS) Script() (string, error) {
-	userData, err := e.mergeCustomUserData(lo.Compact([]string{lo.FromPtr(e.CustomUserData), e.eksBootstrapScript()})...)
+	userDatas := append([]string{lo.FromPtr(e.CustomUserData)}, e.UserDataFragments...)
+	userData, err := e.mergeCustomUserData(lo.Compact(append(userDatas, e.eksBootstrapScript()))...)
 	if err != nil {
 		return "", err
 	}
@@ -62,6 +63,7 @@ func (e EKS) eksBootstrapScript() string {
 	var userData bytes.Buffer
 	userData.WriteString("#!/bin/bash -xe\n")
 	userData.WriteString("exec > >(tee /var/log/user-data.log|logger -t user-data -s 2>/dev/console) 2>&1\n")
+	userData.WriteString(e.trustedCABundleScript())
 	// Due to the way bootstrap.sh is written, parameters should not be passed to it with an equal sign
 	userData.WriteString(fmt.Sprintf("/etc/eks/bootstrap.sh '%s' --apiserver-endpoint '%s' %s", e.ClusterName, e.ClusterEndpoint, caBundleArg))
 
@@ -17,6 +17,7 @@ package bootstrap
 import (
 	"encoding/base64"
 	"fmt"
+	"path"
 	"strings"
 
 	admapi "github.com/awslabs/amazon-eks-ami/nodeadm/api"
@@ -41,14 +42,34 @@ func (n Nodeadm) Script() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("generating NodeConfig, %w", err)
 	}
-	customEntries, err := n.parseUserData()
+	customEntries, err := parseNodeadmUserData(lo.FromPtr(n.CustomUserData))
 	if err != nil {
 		return "", fmt.Errorf("parsing custom UserData, %w", err)
 	}
-	mimeArchive := mime.Archive(append([]mime.Entry{{
+	for _, fragment := range n.UserDataFragments {
+		fragmentEntries, err := parseNodeadmUserData(fragment)
+		if err != nil {
+			return "", fmt.Errorf("parsing UserData fragment, %w", err)
+		}
+		customEntries = append(customEntries, fragmentEntries...)
+	}
+	entries := []mime.Entry{{
 		ContentType: mime.ContentTypeNodeConfig,
 		Content:     nodeConfigYAML,
-	}}, customEntries...))
+	}}
+	if script := n.trustedCABundleScript(); script != "" {
+		entries = append(entries, mime.Entry{
+			ContentType: mime.ContentTypeShellScript,
+			Content:     "#!/bin/bash -xe\n" + script,
+		})
+	}
+	if script := n.registryMirrorsScript(); script != "" {
+		entries = append(entries, mime.Entry{
+			ContentType: mime.ContentTypeShellScript,
+			Content:     "#!/bin/bash -xe\n" + script,
+		})
+	}
+	mimeArchive := mime.Archive(append(entries, customEntries...))
 	userData, err := mimeArchive.Serialize()
 	if err != nil {
 		return "", err
@@ -56,6 +77,23 @@ func (n Nodeadm) Script() (string, error) {
 	return userData, nil
 }
 
+// registryMirrorsScript returns the shell script that writes a containerd hosts.toml for each of the registry mirrors.
+// The AL2023 containerd configuration already sets config_path to /etc/containerd/certs.d, and containerd reads the
+// hosts.toml files on each pull, so no restart is necessary.
+func (n Nodeadm) registryMirrorsScript() string {
+	var script strings.Builder
+	for _, mirror := range n.RegistryMirrors {
+		dir := path.Join("/etc/containerd/certs.d", lo.Ternary(mirror.Registry == "*", "_default", mirror.Registry))
+		script.WriteString(fmt.Sprintf("mkdir -p %s\n", dir))
+		script.WriteString(fmt.Sprintf("cat > %s/hosts.toml <<'KARPENTER_REGISTRY_MIRROR'\n", dir))
+		for _, endpoint := range mirror.Endpoints {
+			script.WriteString(fmt.Sprintf("[host.%q]\n  capabilities = [\"pull\", \"resolve\"]\n", endpoint))
+		}
+		script.WriteString("KARPENTER_REGISTRY_MIRROR\n")
+	}
+	return script.String()
+}
+
 // getNodeConfigYAML returns the Karpenter generated NodeConfig YAML object serialized as a string
 func (n Nodeadm) getNodeConfigYAML() (string, error) {
 	config := &admv1alpha1.NodeConfig{
@@ -123,11 +161,9 @@ func (n Nodeadm) generateInlineKubeletConfiguration() (map[string]runtime.RawExt
 	return kubeConfigMap, nil
 }
 
-// parseUserData returns a slice of MIMEEntrys corresponding to each entry in the custom UserData. If the custom
-// UserData is not a MIME multi-part archive, the content type will be detected (NodeConfig or shell) and an entry
-// will be created.
-func (n Nodeadm) parseUserData() ([]mime.Entry, error) {
-	userData := lo.FromPtr(n.CustomUserData)
+// parseNodeadmUserData returns a slice of MIMEEntrys corresponding to each entry in the UserData. If the UserData is
+// not a MIME multi-part archive, the content type will be detected (NodeConfig or shell) and an entry will be created.
+func parseNodeadmUserData(userData string) ([]mime.Entry, error) {
 	if userData == "" {
 		return nil, nil
 	}
@@ -141,7 +177,7 @@ func (n Nodeadm) parseUserData() ([]mime.Entry, error) {
 	}
 	// Fallback to YAML or shall script if UserData is not in MIME format. Determine the content type for the
 	// generated MIME header depending on the type of the custom UserData.
-	if err := yaml.Unmarshal([]byte(*n.CustomUserData), lo.ToPtr(map[string]interface{}{})); err == nil {
+	if err := yaml.Unmarshal([]byte(userData), lo.ToPtr(map[string]interface{}{})); err == nil {
 		return []mime.Entry{{
 			ContentType: mime.ContentTypeNodeConfig,
 			Content:     userData,
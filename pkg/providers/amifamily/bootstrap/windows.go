@@ -32,11 +32,25 @@ func (w Windows) Script() (string, error) {
 	var userData bytes.Buffer
 	userData.WriteString("<powershell>\n")
 
-	customUserData := lo.FromPtr(w.CustomUserData)
-	if customUserData != "" {
-		userData.WriteString(customUserData + "\n")
+	for _, customUserData := range append([]string{lo.FromPtr(w.CustomUserData)}, w.UserDataFragments...) {
+		if customUserData != "" {
+			userData.WriteString(customUserData + "\n")
+		}
 	}
 
+	if bundle := lo.FromPtr(w.TrustedCABundle); bundle != "" {
+		certificates, err := ParseCABundle(bundle)
+		if err != nil {
+			return "", fmt.Errorf("parsing trusted CA bundle, %w", err)
+		}
+		// Import-Certificate only imports the first certificate of a file, so each certificate is written separately
+		for i, certificate := range certificates {
+			userData.WriteString(fmt.Sprintf("$KarpenterTrustedCAFile = \"$env:TEMP\\karpenter-trusted-ca-%d.pem\"\n", i))
+			userData.WriteString(fmt.Sprintf("Set-Content -Path $KarpenterTrustedCAFile -Value @'\n%s\n'@\n", strings.TrimSpace(certificate)))
+			userData.WriteString("Import-Certificate -FilePath $KarpenterTrustedCAFile -CertStoreLocation Cert:\\LocalMachine\\Root\n")
+		}
+		userData.WriteString("Restart-Service containerd\n")
+	}
 	userData.WriteString("[string]$EKSBootstrapScriptFile = \"$env:ProgramFiles\\Amazon\\EKS\\Start-EKSBootstrap.ps1\"\n")
 	userData.WriteString(fmt.Sprintf(`& $EKSBootstrapScriptFile -EKSClusterName '%s' -APIServerEndpoint '%s'`, w.ClusterName, w.ClusterEndpoint))
 	if w.CABundle != nil {
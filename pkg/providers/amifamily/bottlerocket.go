@@ -88,16 +88,19 @@ func (b Bottlerocket) DefaultAMIs(version string) []DefaultAMIOutput {
 }
 
 // UserData returns the default userdata script for the AMI Family
-func (b Bottlerocket) UserData(kubeletConfig *corev1beta1.KubeletConfiguration, taints []v1.Taint, labels map[string]string, caBundle *string, _ []*cloudprovider.InstanceType, customUserData *string, _ *v1beta1.InstanceStorePolicy) bootstrap.Bootstrapper {
+func (b Bottlerocket) UserData(kubeletConfig *corev1beta1.KubeletConfiguration, taints []v1.Taint, labels map[string]string, caBundle *string, _ []*cloudprovider.InstanceType, customUserData *string, userDataFragments []string, _ *v1beta1.InstanceStorePolicy) bootstrap.Bootstrapper {
 	return bootstrap.Bottlerocket{
 		Options: bootstrap.Options{
-			ClusterName:     b.Options.ClusterName,
-			ClusterEndpoint: b.Options.ClusterEndpoint,
-			KubeletConfig:   kubeletConfig,
-			Taints:          taints,
-			Labels:          labels,
-			CABundle:        caBundle,
-			CustomUserData:  customUserData,
+			ClusterName:       b.Options.ClusterName,
+			ClusterEndpoint:   b.Options.ClusterEndpoint,
+			KubeletConfig:     kubeletConfig,
+			Taints:            taints,
+			Labels:            labels,
+			CABundle:          caBundle,
+			CustomUserData:    customUserData,
+			UserDataFragments: userDataFragments,
+			TrustedCABundle:   b.Options.TrustedCABundle,
+			RegistryMirrors:   b.Options.RegistryMirrors,
 		},
 	}
 }
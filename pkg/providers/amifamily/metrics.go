@@ -0,0 +1,47 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package amifamily
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	amiSubsystem   = "ami"
+	nodeClassLabel = "nodeclass"
+	amiIDLabel     = "ami_id"
+)
+
+var (
+	amiAgeSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: amiSubsystem,
+			Name:      "age_seconds",
+			Help:      "Age, in seconds, of the AMIs resolved for an EC2NodeClass, based on their creation date. Labeled by EC2NodeClass and AMI ID.",
+		},
+		[]string{
+			nodeClassLabel,
+			amiIDLabel,
+		},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(amiAgeSeconds)
+}
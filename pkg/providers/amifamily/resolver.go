@@ -17,6 +17,7 @@ package amifamily
 import (
 	"fmt"
 	"net"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
@@ -53,6 +54,8 @@ type Options struct {
 	InstanceProfile     string
 	CABundle            *string `hash:"ignore"`
 	InstanceStorePolicy *v1beta1.InstanceStorePolicy
+	TrustedCABundle     *string
+	RegistryMirrors     []v1beta1.RegistryMirror
 	// Level-triggered fields that may change out of sync.
 	SecurityGroups           []v1beta1.SecurityGroup
 	Tags                     map[string]string
@@ -71,14 +74,26 @@ type LaunchTemplate struct {
 	AMIID               string
 	InstanceTypes       []*cloudprovider.InstanceType `hash:"ignore"`
 	DetailedMonitoring  bool
-	EFACount            int
-	CapacityType        string
+	NitroEnclaves       bool
+	// Hardened disables instance tags in instance metadata, on top of the metadata options that it enforces
+	Hardened bool
+	// CPUOptions customizes the cores and threads per core of instances, which is nil when the defaults are used
+	CPUOptions *v1beta1.CPUOptions
+	// CPUCredits is the credit option of the launch template, which is only set for burstable instance types
+	CPUCredits   string
+	EFACount     int
+	CapacityType string
+	// NetworkCardIndex is the network card that an additional network interface is attached to, where 0 means that no
+	// additional network interface is attached
+	NetworkCardIndex int64
+	// CapacityReservationID is the capacity reservation that instances launched with the launch template are placed into
+	CapacityReservationID string
 }
 
 // AMIFamily can be implemented to override the default logic for generating dynamic launch template parameters
 type AMIFamily interface {
 	DefaultAMIs(version string) []DefaultAMIOutput
-	UserData(kubeletConfig *corev1beta1.KubeletConfiguration, taints []core.Taint, labels map[string]string, caBundle *string, instanceTypes []*cloudprovider.InstanceType, customUserData *string, instanceStorePolicy *v1beta1.InstanceStorePolicy) bootstrap.Bootstrapper
+	UserData(kubeletConfig *corev1beta1.KubeletConfiguration, taints []core.Taint, labels map[string]string, caBundle *string, instanceTypes []*cloudprovider.InstanceType, customUserData *string, userDataFragments []string, instanceStorePolicy *v1beta1.InstanceStorePolicy) bootstrap.Bootstrapper
 	DefaultBlockDeviceMappings() []*v1beta1.BlockDeviceMapping
 	DefaultMetadataOptions() *v1beta1.MetadataOptions
 	EphemeralBlockDevice() *string
@@ -88,6 +103,9 @@ type AMIFamily interface {
 type DefaultAMIOutput struct {
 	Query        string
 	Requirements scheduling.Requirements
+	// FallbackQuery is resolved in place of the query when the query's parameter doesn't exist, e.g. when an accelerated
+	// variant of an image hasn't been published for the Kubernetes version
+	FallbackQuery string
 }
 
 // FeatureFlags describes whether the features below are enabled for a given AMIFamily
@@ -130,14 +148,19 @@ func (r Resolver) Resolve(nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta
 	}
 	var resolvedTemplates []*LaunchTemplate
 	for amiID, instanceTypes := range mappedAMIs {
+		ami, _ := lo.Find(nodeClass.Status.AMIs, func(a v1beta1.AMI) bool { return a.ID == amiID })
 		// In order to support reserved ENIs for CNI custom networking setups,
 		// we need to pass down the max-pods calculation to the kubelet.
 		// This requires that we resolve a unique launch template per max-pods value.
 		// Similarly, instance types configured with EfAs require unique launch templates depending on the number of
-		// EFAs they support.
+		// EFAs they support, and instance types require unique launch templates depending on the user data fragments
+		// that apply to them. CPU credits can only be specified for burstable instance types, so they're also resolved
+		// into a separate launch template.
 		type launchTemplateParams struct {
-			efaCount int
-			maxPods  int
+			efaCount          int
+			maxPods           int
+			userDataFragments string
+			cpuCredits        string
 		}
 		paramsToInstanceTypes := lo.GroupBy(instanceTypes, func(instanceType *cloudprovider.InstanceType) launchTemplateParams {
 			return launchTemplateParams{
@@ -146,11 +169,20 @@ func (r Resolver) Resolve(nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta
 					int(lo.ToPtr(instanceType.Capacity[v1beta1.ResourceEFA]).Value()),
 					0,
 				),
-				maxPods: int(instanceType.Capacity.Pods().Value()),
+				maxPods:           int(instanceType.Capacity.Pods().Value()),
+				userDataFragments: fmt.Sprint(userDataFragmentIndices(nodeClass, instanceType)),
+				cpuCredits: lo.Ternary(
+					instanceType.Requirements.Get(v1beta1.LabelInstanceBurstable).Has("true"),
+					aws.StringValue(nodeClass.Spec.CPUCredits),
+					"",
+				),
 			}
 		})
 		for params, instanceTypes := range paramsToInstanceTypes {
-			resolved, err := r.resolveLaunchTemplate(nodeClass, nodeClaim, instanceTypes, capacityType, amiFamily, amiID, params.maxPods, params.efaCount, options)
+			userDataFragments := lo.Map(userDataFragmentIndices(nodeClass, instanceTypes[0]), func(i int, _ int) string {
+				return nodeClass.Spec.UserDataFragments[i].UserData
+			})
+			resolved, err := r.resolveLaunchTemplate(nodeClass, nodeClaim, instanceTypes, capacityType, amiFamily, ami, params.maxPods, params.efaCount, params.cpuCredits, userDataFragments, options)
 			if err != nil {
 				return nil, err
 			}
@@ -160,6 +192,19 @@ func (r Resolver) Resolve(nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta
 	return resolvedTemplates, nil
 }
 
+// userDataFragmentIndices returns the indices of the user data fragments of the nodeClass that apply to the instance
+// type, in the order that they're specified in
+func userDataFragmentIndices(nodeClass *v1beta1.EC2NodeClass, instanceType *cloudprovider.InstanceType) []int {
+	family := strings.Split(instanceType.Name, ".")[0]
+	var indices []int
+	for i, fragment := range nodeClass.Spec.UserDataFragments {
+		if lo.Contains(fragment.InstanceTypes, instanceType.Name) || lo.Contains(fragment.InstanceFamilies, family) {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
 func GetAMIFamily(amiFamily *string, options *Options) AMIFamily {
 	switch aws.StringValue(amiFamily) {
 	case v1beta1.AMIFamilyBottlerocket:
@@ -179,6 +224,43 @@ func GetAMIFamily(amiFamily *string, options *Options) AMIFamily {
 	}
 }
 
+// BlockDeviceMappings returns the block device mappings that are applied to instances launched with the nodeClass. These
+// are the block device mappings of the nodeClass if they're specified, and the defaults of its AMI family otherwise.
+func BlockDeviceMappings(nodeClass *v1beta1.EC2NodeClass) []*v1beta1.BlockDeviceMapping {
+	return resolveBlockDeviceMappings(nodeClass, GetAMIFamily(nodeClass.Spec.AMIFamily, &Options{}))
+}
+
+func resolveBlockDeviceMappings(nodeClass *v1beta1.EC2NodeClass, amiFamily AMIFamily) []*v1beta1.BlockDeviceMapping {
+	if len(nodeClass.Spec.BlockDeviceMappings) != 0 {
+		return nodeClass.Spec.BlockDeviceMappings
+	}
+	return amiFamily.DefaultBlockDeviceMappings()
+}
+
+// withRootSnapshotSize raises the size of the root volume to the size of the root snapshot of the AMI, since EC2 fails to
+// launch instances with a root volume that is smaller than its snapshot
+func withRootSnapshotSize(blockDeviceMappings []*v1beta1.BlockDeviceMapping, ami v1beta1.AMI) []*v1beta1.BlockDeviceMapping {
+	if ami.RootSnapshotSize == nil {
+		return blockDeviceMappings
+	}
+	return lo.Map(blockDeviceMappings, func(bdm *v1beta1.BlockDeviceMapping, _ int) *v1beta1.BlockDeviceMapping {
+		if !RootVolumeTooSmall(bdm, ami) {
+			return bdm
+		}
+		// The block device mappings may be the shared defaults of the AMI family, so they're copied before being modified
+		bdm = bdm.DeepCopy()
+		bdm.EBS.VolumeSize = lo.ToPtr(ami.RootSnapshotSize.DeepCopy())
+		return bdm
+	})
+}
+
+// RootVolumeTooSmall returns true if the block device mapping is for the root volume of the AMI and requests a size that
+// is smaller than the root snapshot of the AMI
+func RootVolumeTooSmall(bdm *v1beta1.BlockDeviceMapping, ami v1beta1.AMI) bool {
+	return ami.RootSnapshotSize != nil && aws.StringValue(bdm.DeviceName) == ami.RootDeviceName &&
+		bdm.EBS != nil && bdm.EBS.VolumeSize != nil && bdm.EBS.VolumeSize.Cmp(*ami.RootSnapshotSize) < 0
+}
+
 func (o Options) DefaultMetadataOptions() *v1beta1.MetadataOptions {
 	return &v1beta1.MetadataOptions{
 		HTTPEndpoint:            aws.String(ec2.LaunchTemplateInstanceMetadataEndpointStateEnabled),
@@ -188,6 +270,15 @@ func (o Options) DefaultMetadataOptions() *v1beta1.MetadataOptions {
 	}
 }
 
+// hardenedMetadataOptions returns a copy of the metadata options that requires tokens and limits metadata requests
+// to a single hop, so that they can't be made from containers that aren't on the host network
+func hardenedMetadataOptions(metadataOptions *v1beta1.MetadataOptions) *v1beta1.MetadataOptions {
+	hardened := metadataOptions.DeepCopy()
+	hardened.HTTPTokens = aws.String(ec2.LaunchTemplateHttpTokensStateRequired)
+	hardened.HTTPPutResponseHopLimit = aws.Int64(1)
+	return hardened
+}
+
 func (r Resolver) defaultClusterDNS(opts *Options, kubeletConfig *corev1beta1.KubeletConfiguration) *corev1beta1.KubeletConfiguration {
 	if opts.KubeDNSIP == nil {
 		return kubeletConfig
@@ -205,11 +296,19 @@ func (r Resolver) defaultClusterDNS(opts *Options, kubeletConfig *corev1beta1.Ku
 	return newKubeletConfig
 }
 
+// resolveCPUOptions returns the CPU options of the launch template, or nil if the nodeClass doesn't customize them
+func resolveCPUOptions(nodeClass *v1beta1.EC2NodeClass) *v1beta1.CPUOptions {
+	if options, ok := nodeClass.CPUOptions(); ok {
+		return &options
+	}
+	return nil
+}
+
 func (r Resolver) resolveLaunchTemplate(nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, capacityType string,
-	amiFamily AMIFamily, amiID string, maxPods int, efaCount int, options *Options) (*LaunchTemplate, error) {
+	amiFamily AMIFamily, ami v1beta1.AMI, maxPods int, efaCount int, cpuCredits string, userDataFragments []string, options *Options) (*LaunchTemplate, error) {
 	kubeletConfig := &corev1beta1.KubeletConfiguration{}
-	if nodeClaim.Spec.Kubelet != nil {
-		if err := mergo.Merge(kubeletConfig, nodeClaim.Spec.Kubelet); err != nil {
+	if kc := nodeClass.KubeletConfiguration(nodeClaim.Spec.Kubelet); kc != nil {
+		if err := mergo.Merge(kubeletConfig, kc); err != nil {
 			return nil, err
 		}
 	}
@@ -220,26 +319,32 @@ func (r Resolver) resolveLaunchTemplate(nodeClass *v1beta1.EC2NodeClass, nodeCla
 		Options: options,
 		UserData: amiFamily.UserData(
 			r.defaultClusterDNS(options, kubeletConfig),
-			append(nodeClaim.Spec.Taints, nodeClaim.Spec.StartupTaints...),
+			nodeClass.Taints(append(nodeClaim.Spec.Taints, nodeClaim.Spec.StartupTaints...)),
 			options.Labels,
 			options.CABundle,
 			instanceTypes,
 			nodeClass.Spec.UserData,
+			userDataFragments,
 			options.InstanceStorePolicy,
 		),
-		BlockDeviceMappings: nodeClass.Spec.BlockDeviceMappings,
+		BlockDeviceMappings: withRootSnapshotSize(resolveBlockDeviceMappings(nodeClass, amiFamily), ami),
 		MetadataOptions:     nodeClass.Spec.MetadataOptions,
 		DetailedMonitoring:  aws.BoolValue(nodeClass.Spec.DetailedMonitoring),
-		AMIID:               amiID,
+		NitroEnclaves:       aws.BoolValue(nodeClass.Spec.NitroEnclaves),
+		Hardened:            aws.BoolValue(nodeClass.Spec.Hardened),
+		CPUOptions:          resolveCPUOptions(nodeClass),
+		CPUCredits:          cpuCredits,
+		AMIID:               ami.ID,
 		InstanceTypes:       instanceTypes,
 		EFACount:            efaCount,
 		CapacityType:        capacityType,
-	}
-	if len(resolved.BlockDeviceMappings) == 0 {
-		resolved.BlockDeviceMappings = amiFamily.DefaultBlockDeviceMappings()
+		NetworkCardIndex:    aws.Int64Value(nodeClass.Spec.NetworkCardIndex),
 	}
 	if resolved.MetadataOptions == nil {
 		resolved.MetadataOptions = amiFamily.DefaultMetadataOptions()
 	}
+	if resolved.Hardened {
+		resolved.MetadataOptions = hardenedMetadataOptions(resolved.MetadataOptions)
+	}
 	return resolved, nil
 }
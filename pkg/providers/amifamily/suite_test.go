@@ -19,20 +19,28 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/imagebuilder"
+	"github.com/aws/aws-sdk-go/service/ssm"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
 	. "sigs.k8s.io/karpenter/pkg/utils/testing"
 
+	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
+	"golang.org/x/sync/semaphore"
 	v1 "k8s.io/api/core/v1"
 
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/operator/scheme"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
@@ -40,6 +48,8 @@ import (
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
@@ -61,6 +71,7 @@ const (
 	arm64AMI       = "arm64-ami-id"
 	amd64NvidiaAMI = "amd64-nvidia-ami-id"
 	arm64NvidiaAMI = "arm64-nvidia-ami-id"
+	amd64NeuronAMI = "amd64-neuron-ami-id"
 )
 
 var _ = BeforeSuite(func() {
@@ -147,11 +158,13 @@ var _ = Describe("AMIProvider", func() {
 		nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2023
 		awsEnv.SSMAPI.Parameters = map[string]string{
 			fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/standard/recommended/image_id", version): amd64AMI,
+			fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/nvidia/recommended/image_id", version):   amd64NvidiaAMI,
+			fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/neuron/recommended/image_id", version):   amd64NeuronAMI,
 			fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/arm64/standard/recommended/image_id", version):  arm64AMI,
 		}
 		amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
 		Expect(err).ToNot(HaveOccurred())
-		Expect(amis).To(HaveLen(2))
+		Expect(amis).To(HaveLen(4))
 	})
 	It("should succeed to resolve AMIs (Bottlerocket)", func() {
 		nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyBottlerocket
@@ -242,6 +255,43 @@ var _ = Describe("AMIProvider", func() {
 		}
 		wg.Wait()
 	})
+	Context("AMI Age Metric", func() {
+		BeforeEach(func() {
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
+				{ID: "amd64-ami-id"},
+				{ID: "arm64-ami-id"},
+			}
+		})
+		It("should record the age of each resolved AMI", func() {
+			_, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			for _, id := range []string{"amd64-ami-id", "arm64-ami-id"} {
+				metric, ok := FindMetricWithLabelValues("karpenter_ami_age_seconds", map[string]string{
+					"nodeclass": nodeClass.Name,
+					"ami_id":    id,
+				})
+				Expect(ok).To(BeTrue())
+				Expect(metric.GetGauge().GetValue()).To(BeNumerically(">", 0))
+			}
+			amd64, _ := FindMetricWithLabelValues("karpenter_ami_age_seconds", map[string]string{"nodeclass": nodeClass.Name, "ami_id": "amd64-ami-id"})
+			arm64, _ := FindMetricWithLabelValues("karpenter_ami_age_seconds", map[string]string{"nodeclass": nodeClass.Name, "ami_id": "arm64-ami-id"})
+			Expect(amd64.GetGauge().GetValue()).To(BeNumerically(">", arm64.GetGauge().GetValue()))
+		})
+		It("should drop the age of AMIs that are no longer resolved", func() {
+			_, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			_, ok := FindMetricWithLabelValues("karpenter_ami_age_seconds", map[string]string{"nodeclass": nodeClass.Name, "ami_id": "arm64-ami-id"})
+			Expect(ok).To(BeTrue())
+
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{ID: "amd64-ami-id"}}
+			_, err = awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			_, ok = FindMetricWithLabelValues("karpenter_ami_age_seconds", map[string]string{"nodeclass": nodeClass.Name, "ami_id": "arm64-ami-id"})
+			Expect(ok).To(BeFalse())
+			_, ok = FindMetricWithLabelValues("karpenter_ami_age_seconds", map[string]string{"nodeclass": nodeClass.Name, "ami_id": "amd64-ami-id"})
+			Expect(ok).To(BeTrue())
+		})
+	})
 	Context("SSM Alias Missing", func() {
 		It("should succeed to partially resolve AMIs if all SSM aliases don't exist (Al2)", func() {
 			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2
@@ -260,9 +310,10 @@ var _ = Describe("AMIProvider", func() {
 			awsEnv.SSMAPI.Parameters = map[string]string{
 				fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/standard/recommended/image_id", version): amd64AMI,
 			}
+			// The nvidia and neuron requirements sets fall back to the standard x86_64 image
 			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(amis).To(HaveLen(1))
+			Expect(amis).To(HaveLen(3))
 		})
 		It("should succeed to partially resolve AMIs if all SSM aliases don't exist (Bottlerocket)", func() {
 			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyBottlerocket
@@ -289,6 +340,122 @@ var _ = Describe("AMIProvider", func() {
 			Expect(amis).To(HaveLen(1))
 		})
 	})
+	Context("Accelerated Instance Types", func() {
+		var instanceTypes []*cloudprovider.InstanceType
+		BeforeEach(func() {
+			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2023
+			awsEnv.SSMAPI.Parameters = map[string]string{
+				fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/standard/recommended/image_id", version): amd64AMI,
+				fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/nvidia/recommended/image_id", version):   amd64NvidiaAMI,
+				fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/neuron/recommended/image_id", version):   amd64NeuronAMI,
+				fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/arm64/standard/recommended/image_id", version):  arm64AMI,
+			}
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+			var err error
+			instanceTypes, err = awsEnv.InstanceTypesProvider.List(ctx, &corev1beta1.KubeletConfiguration{}, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		DescribeTable("should resolve the AL2023 variant for the accelerators of the instance type",
+			func(instanceType string, expected string) {
+				amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				mapped := amifamily.MapToInstanceTypes(instanceTypes, lo.Map(amis, func(ami amifamily.AMI, _ int) v1beta1.AMI {
+					return v1beta1.AMI{
+						ID: ami.AmiID,
+						Requirements: lo.Map(ami.Requirements.NodeSelectorRequirements(), func(r corev1beta1.NodeSelectorRequirementWithMinValues, _ int) v1.NodeSelectorRequirement {
+							return r.NodeSelectorRequirement
+						}),
+					}
+				}))
+				Expect(lo.Map(mapped[expected], func(it *cloudprovider.InstanceType, _ int) string { return it.Name })).To(ContainElement(instanceType))
+			},
+			Entry("nvidia (g4dn)", "g4dn.8xlarge", amd64NvidiaAMI),
+			Entry("nvidia (p3)", "p3.8xlarge", amd64NvidiaAMI),
+			Entry("neuron (inf1)", "inf1.2xlarge", amd64NeuronAMI),
+			Entry("neuron (trn1)", "trn1.2xlarge", amd64NeuronAMI),
+			Entry("standard (m5)", "m5.large", amd64AMI),
+			Entry("standard (dl1)", "dl1.24xlarge", amd64AMI),
+			Entry("standard (arm64)", "t4g.medium", arm64AMI),
+		)
+		It("should fall back to the standard AL2023 image when the accelerated variants aren't published", func() {
+			awsEnv.SSMAPI.Parameters = map[string]string{
+				fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/standard/recommended/image_id", version): amd64AMI,
+				fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2023/arm64/standard/recommended/image_id", version):  arm64AMI,
+			}
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			mapped := amifamily.MapToInstanceTypes(instanceTypes, lo.Map(amis, func(ami amifamily.AMI, _ int) v1beta1.AMI {
+				return v1beta1.AMI{
+					ID: ami.AmiID,
+					Requirements: lo.Map(ami.Requirements.NodeSelectorRequirements(), func(r corev1beta1.NodeSelectorRequirementWithMinValues, _ int) v1.NodeSelectorRequirement {
+						return r.NodeSelectorRequirement
+					}),
+				}
+			}))
+			Expect(lo.Map(mapped[amd64AMI], func(it *cloudprovider.InstanceType, _ int) string { return it.Name })).To(ContainElements("g4dn.8xlarge", "inf1.2xlarge", "m5.large"))
+		})
+	})
+	Context("Kubernetes Version", func() {
+		const pinnedVersion = "1.20"
+		BeforeEach(func() {
+			Expect(version).ToNot(Equal(pinnedVersion))
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{KubernetesVersion: lo.ToPtr(pinnedVersion)}))
+		})
+		AfterEach(func() {
+			ctx = options.ToContext(ctx, test.Options())
+		})
+		It("should use the configured version rather than the version of the API server", func() {
+			Expect(awsEnv.VersionProvider.Get(ctx)).To(Equal(pinnedVersion))
+		})
+		DescribeTable("should resolve AMIs from the SSM parameters of the configured version",
+			func(amiFamily string, queries map[string]string, expected int) {
+				nodeClass.Spec.AMIFamily = lo.ToPtr(amiFamily)
+				awsEnv.SSMAPI.Parameters = lo.MapKeys(queries, func(_ string, query string) string { return fmt.Sprintf(query, pinnedVersion) })
+				amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(amis).To(HaveLen(expected))
+			},
+			Entry("AL2", v1beta1.AMIFamilyAL2, map[string]string{
+				"/aws/service/eks/optimized-ami/%s/amazon-linux-2/recommended/image_id":       amd64AMI,
+				"/aws/service/eks/optimized-ami/%s/amazon-linux-2-gpu/recommended/image_id":   amd64NvidiaAMI,
+				"/aws/service/eks/optimized-ami/%s/amazon-linux-2-arm64/recommended/image_id": arm64AMI,
+			}, 4),
+			Entry("AL2023", v1beta1.AMIFamilyAL2023, map[string]string{
+				"/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/standard/recommended/image_id": amd64AMI,
+				"/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/nvidia/recommended/image_id":   amd64NvidiaAMI,
+				"/aws/service/eks/optimized-ami/%s/amazon-linux-2023/x86_64/neuron/recommended/image_id":   amd64NeuronAMI,
+				"/aws/service/eks/optimized-ami/%s/amazon-linux-2023/arm64/standard/recommended/image_id":  arm64AMI,
+			}, 4),
+			Entry("Bottlerocket", v1beta1.AMIFamilyBottlerocket, map[string]string{
+				"/aws/service/bottlerocket/aws-k8s-%s/x86_64/latest/image_id":        amd64AMI,
+				"/aws/service/bottlerocket/aws-k8s-%s-nvidia/x86_64/latest/image_id": amd64NvidiaAMI,
+				"/aws/service/bottlerocket/aws-k8s-%s/arm64/latest/image_id":         arm64AMI,
+				"/aws/service/bottlerocket/aws-k8s-%s-nvidia/arm64/latest/image_id":  arm64NvidiaAMI,
+			}, 6),
+			Entry("Ubuntu", v1beta1.AMIFamilyUbuntu, map[string]string{
+				"/aws/service/canonical/ubuntu/eks/20.04/%s/stable/current/amd64/hvm/ebs-gp2/ami-id": amd64AMI,
+				"/aws/service/canonical/ubuntu/eks/20.04/%s/stable/current/arm64/hvm/ebs-gp2/ami-id": arm64AMI,
+			}, 2),
+			Entry("Windows2019", v1beta1.AMIFamilyWindows2019, map[string]string{
+				"/aws/service/ami-windows-latest/Windows_Server-2019-English-Core-EKS_Optimized-%s/image_id": amd64AMI,
+			}, 1),
+			Entry("Windows2022", v1beta1.AMIFamilyWindows2022, map[string]string{
+				"/aws/service/ami-windows-latest/Windows_Server-2022-English-Core-EKS_Optimized-%s/image_id": amd64AMI,
+			}, 1),
+		)
+		It("should not resolve AMIs of the version of the API server", func() {
+			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2
+			awsEnv.SSMAPI.Parameters = map[string]string{
+				fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2/recommended/image_id", version):       amd64AMI,
+				fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2-gpu/recommended/image_id", version):   amd64NvidiaAMI,
+				fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2-arm64/recommended/image_id", version): arm64AMI,
+			}
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(amis).To(BeEmpty())
+		})
+	})
 	Context("AMI Tag Requirements", func() {
 		var img *ec2.Image
 		BeforeEach(func() {
@@ -448,6 +615,83 @@ var _ = Describe("AMIProvider", func() {
 				},
 			}, filterAndOwnersSets)
 		})
+		It("should filter on architecture when the term sets one", func() {
+			amiSelectorTerms := []v1beta1.AMISelectorTerm{
+				{
+					Name:         "my-ami",
+					Architecture: corev1beta1.ArchitectureAmd64,
+				},
+				{
+					ID:           "ami-abcd1234",
+					Architecture: corev1beta1.ArchitectureArm64,
+				},
+				{
+					ID: "ami-cafeaced",
+				},
+			}
+			filterAndOwnersSets := amifamily.GetFilterAndOwnerSets(amiSelectorTerms)
+			ExpectConsistsOfFiltersAndOwners([]amifamily.FiltersAndOwners{
+				{
+					Filters: []*ec2.Filter{
+						{
+							Name:   aws.String("name"),
+							Values: aws.StringSlice([]string{"my-ami"}),
+						},
+						{
+							Name:   aws.String("architecture"),
+							Values: aws.StringSlice([]string{"x86_64"}),
+						},
+					},
+					Owners: []string{
+						"amazon",
+						"self",
+					},
+				},
+				{
+					Filters: []*ec2.Filter{
+						{
+							Name:   aws.String("image-id"),
+							Values: aws.StringSlice([]string{"ami-abcd1234"}),
+						},
+						{
+							Name:   aws.String("architecture"),
+							Values: aws.StringSlice([]string{"arm64"}),
+						},
+					},
+				},
+				{
+					Filters: []*ec2.Filter{
+						{
+							Name:   aws.String("image-id"),
+							Values: aws.StringSlice([]string{"ami-cafeaced"}),
+						},
+					},
+				},
+			}, filterAndOwnersSets)
+		})
+		It("should only resolve amis of the architecture of the term", func() {
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{Tags: map[string]string{"foo": "bar"}}}
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(amis).To(HaveLen(2))
+
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{Tags: map[string]string{"foo": "bar"}, Architecture: corev1beta1.ArchitectureArm64}}
+			amis, err = awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(amis).To(HaveLen(1))
+			Expect(amis[0].AmiID).To(Equal("arm64-nvidia-ami-id"))
+			Expect(amis[0].Requirements.Get(v1.LabelArchStable).Any()).To(Equal(corev1beta1.ArchitectureArm64))
+		})
+		It("should not resolve an ami selected by id that doesn't match the architecture of the term", func() {
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{
+				{ID: "amd64-ami-id", Architecture: corev1beta1.ArchitectureArm64},
+				{ID: "arm64-ami-id", Architecture: corev1beta1.ArchitectureArm64},
+			}
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(amis).To(HaveLen(1))
+			Expect(amis[0].AmiID).To(Equal("arm64-ami-id"))
+		})
 		It("should sort amis by creationDate", func() {
 			amis := amifamily.AMIs{
 				{
@@ -563,6 +807,204 @@ var _ = Describe("AMIProvider", func() {
 				},
 			))
 		})
+		It("should select the same ami across calls when images share a name and creation date", func() {
+			images := []*ec2.Image{
+				{
+					Name:         aws.String("test-ami"),
+					ImageId:      aws.String("ami-2"),
+					CreationDate: aws.String("2021-08-31T00:10:42.000Z"),
+					Architecture: aws.String("x86_64"),
+				},
+				{
+					Name:         aws.String("test-ami"),
+					ImageId:      aws.String("ami-1"),
+					CreationDate: aws.String("2021-08-31T00:10:42.000Z"),
+					Architecture: aws.String("x86_64"),
+				},
+			}
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{Name: "test-ami"}}
+			for _, order := range [][]*ec2.Image{images, lo.Reverse(append([]*ec2.Image{}, images...))} {
+				awsEnv.EC2Cache.Flush()
+				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: order})
+				amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(amis).To(HaveLen(1))
+				Expect(amis[0].AmiID).To(Equal("ami-1"))
+			}
+		})
+	})
+	Context("Concurrency Limit", func() {
+		It("should not exceed the configured number of in-flight ssm calls when resolving many nodeclasses at once", func() {
+			ssmAPI := &inFlightSSMAPI{SSMAPI: fake.NewSSMAPI()}
+			provider := amifamily.NewDefaultProvider(fake.DefaultRegion, awsEnv.VersionProvider, ssmAPI, awsEnv.EC2API, awsEnv.ImagebuilderAPI,
+				cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), semaphore.NewWeighted(2))
+
+			wg := sync.WaitGroup{}
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					nc := test.EC2NodeClass(v1beta1.EC2NodeClass{
+						Spec: v1beta1.EC2NodeClassSpec{
+							AMIFamily:        &v1beta1.AMIFamilyAL2023,
+							AMISelectorTerms: []v1beta1.AMISelectorTerm{{SSMParameter: fmt.Sprintf("/test/ami/parameter-%d", i)}},
+						},
+					})
+					_, err := provider.List(ctx, nc)
+					Expect(err).ToNot(HaveOccurred())
+				}(i)
+			}
+			wg.Wait()
+			Expect(ssmAPI.calls.Load()).To(BeNumerically("==", 10))
+			Expect(ssmAPI.maxInFlight.Load()).To(BeNumerically("<=", 2))
+		})
+		It("should share the limit across the ssm queries of the default amis", func() {
+			ssmAPI := &inFlightSSMAPI{SSMAPI: fake.NewSSMAPI()}
+			provider := amifamily.NewDefaultProvider(fake.DefaultRegion, awsEnv.VersionProvider, ssmAPI, awsEnv.EC2API, awsEnv.ImagebuilderAPI,
+				cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), semaphore.NewWeighted(1))
+			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2023
+			_, err := provider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ssmAPI.calls.Load()).To(BeNumerically(">", 1))
+			Expect(ssmAPI.maxInFlight.Load()).To(BeNumerically("==", 1))
+		})
+	})
+	Context("SSM Parameters", func() {
+		const parameter = "/test/ami/parameter"
+		BeforeEach(func() {
+			awsEnv.SSMAPI.Parameters = map[string]string{
+				parameter:        "arm64-ami-id",
+				parameter + ":1": "amd64-ami-id",
+				parameter + ":2": "arm64-ami-id",
+			}
+		})
+		It("should select the ami of the latest version of an unversioned parameter", func() {
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{SSMParameter: parameter}}
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.Map(amis, func(a amifamily.AMI, _ int) string { return a.AmiID })).To(ConsistOf("arm64-ami-id"))
+		})
+		It("should select the ami of the pinned version of a versioned parameter", func() {
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{SSMParameter: parameter + ":1"}}
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.Map(amis, func(a amifamily.AMI, _ int) string { return a.AmiID })).To(ConsistOf("amd64-ami-id"))
+		})
+		It("should select a new ami when the pinned version is bumped", func() {
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{SSMParameter: parameter + ":1"}}
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.Map(amis, func(a amifamily.AMI, _ int) string { return a.AmiID })).To(ConsistOf("amd64-ami-id"))
+
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{SSMParameter: parameter + ":2"}}
+			amis, err = awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.Map(amis, func(a amifamily.AMI, _ int) string { return a.AmiID })).To(ConsistOf("arm64-ami-id"))
+		})
+		It("should not change the ami of a pinned version when the parameter is updated", func() {
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{SSMParameter: parameter + ":1"}}
+			awsEnv.SSMAPI.Parameters[parameter] = "amd64-nvidia-ami-id"
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.Map(amis, func(a amifamily.AMI, _ int) string { return a.AmiID })).To(ConsistOf("amd64-ami-id"))
+		})
+		It("should combine ssm parameter terms with other terms", func() {
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{SSMParameter: parameter + ":1"}, {ID: "arm64-ami-id"}}
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.Map(amis, func(a amifamily.AMI, _ int) string { return a.AmiID })).To(ConsistOf("amd64-ami-id", "arm64-ami-id"))
+		})
+		It("should return an error when the parameter version doesn't exist", func() {
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{SSMParameter: parameter + ":3"}}
+			_, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("Image Pipelines", func() {
+		const pipelineARN = "arn:aws:imagebuilder:us-west-2:123456789012:image-pipeline/test-pipeline"
+		pipelineImage := func(version, status string, created time.Time, amis map[string]string) *imagebuilder.ImageSummary {
+			return &imagebuilder.ImageSummary{
+				Arn:         aws.String(fmt.Sprintf("arn:aws:imagebuilder:us-west-2:123456789012:image/test-recipe/%s", version)),
+				DateCreated: aws.String(created.Format(time.RFC3339)),
+				State:       &imagebuilder.ImageState{Status: aws.String(status)},
+				OutputResources: &imagebuilder.OutputResources{
+					Amis: lo.MapToSlice(amis, func(region, id string) *imagebuilder.Ami {
+						return &imagebuilder.Ami{Region: aws.String(region), Image: aws.String(id)}
+					}),
+				},
+			}
+		}
+		BeforeEach(func() {
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{ImagePipelineARN: pipelineARN}}
+		})
+		It("should select the amis distributed to the region by the latest successful build", func() {
+			awsEnv.ImagebuilderAPI.ListImagePipelineImagesBehavior.Output.Set(&imagebuilder.ListImagePipelineImagesOutput{
+				ImageSummaryList: []*imagebuilder.ImageSummary{
+					pipelineImage("1.0.0/1", imagebuilder.ImageStatusAvailable, time.Now().Add(-2*time.Hour), map[string]string{fake.DefaultRegion: "amd64-ami-id"}),
+					pipelineImage("1.0.0/2", imagebuilder.ImageStatusAvailable, time.Now().Add(-time.Hour), map[string]string{fake.DefaultRegion: "arm64-ami-id", "eu-west-1": "amd64-ami-id"}),
+				},
+			})
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.Map(amis, func(a amifamily.AMI, _ int) string { return a.AmiID })).To(ConsistOf("arm64-ami-id"))
+			input := awsEnv.ImagebuilderAPI.ListImagePipelineImagesBehavior.CalledWithInput.Pop()
+			Expect(aws.StringValue(input.ImagePipelineArn)).To(Equal(pipelineARN))
+		})
+		It("should keep selecting the amis of the last successful build when the latest build failed", func() {
+			awsEnv.ImagebuilderAPI.ListImagePipelineImagesBehavior.Output.Set(&imagebuilder.ListImagePipelineImagesOutput{
+				ImageSummaryList: []*imagebuilder.ImageSummary{
+					pipelineImage("1.0.0/2", imagebuilder.ImageStatusFailed, time.Now(), nil),
+					pipelineImage("1.0.0/1", imagebuilder.ImageStatusAvailable, time.Now().Add(-time.Hour), map[string]string{fake.DefaultRegion: "amd64-ami-id"}),
+				},
+			})
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.Map(amis, func(a amifamily.AMI, _ int) string { return a.AmiID })).To(ConsistOf("amd64-ami-id"))
+			failed, err := awsEnv.AMIProvider.FailedImagePipelines(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(failed).To(ConsistOf(pipelineARN))
+		})
+		It("should not report image pipelines whose latest build succeeded", func() {
+			awsEnv.ImagebuilderAPI.ListImagePipelineImagesBehavior.Output.Set(&imagebuilder.ListImagePipelineImagesOutput{
+				ImageSummaryList: []*imagebuilder.ImageSummary{
+					pipelineImage("1.0.0/2", imagebuilder.ImageStatusAvailable, time.Now(), map[string]string{fake.DefaultRegion: "amd64-ami-id"}),
+					pipelineImage("1.0.0/1", imagebuilder.ImageStatusFailed, time.Now().Add(-time.Hour), nil),
+				},
+			})
+			failed, err := awsEnv.AMIProvider.FailedImagePipelines(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(failed).To(BeEmpty())
+		})
+		It("should not select any amis when the pipeline has no successful build", func() {
+			awsEnv.ImagebuilderAPI.ListImagePipelineImagesBehavior.Output.Set(&imagebuilder.ListImagePipelineImagesOutput{
+				ImageSummaryList: []*imagebuilder.ImageSummary{
+					pipelineImage("1.0.0/1", imagebuilder.ImageStatusFailed, time.Now(), nil),
+				},
+			})
+			amis, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(amis).To(BeEmpty())
+		})
+		It("should cache the resolved image pipeline", func() {
+			awsEnv.ImagebuilderAPI.ListImagePipelineImagesBehavior.Output.Set(&imagebuilder.ListImagePipelineImagesOutput{
+				ImageSummaryList: []*imagebuilder.ImageSummary{
+					pipelineImage("1.0.0/1", imagebuilder.ImageStatusAvailable, time.Now(), map[string]string{fake.DefaultRegion: "amd64-ami-id"}),
+				},
+			})
+			for i := 0; i < 3; i++ {
+				_, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			_, err := awsEnv.AMIProvider.FailedImagePipelines(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.ImagebuilderAPI.ListImagePipelineImagesBehavior.Calls()).To(Equal(1))
+		})
+		It("should return an error when the image pipeline can't be listed", func() {
+			awsEnv.ImagebuilderAPI.ListImagePipelineImagesBehavior.Error.Set(fmt.Errorf("access denied"))
+			_, err := awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).To(HaveOccurred())
+		})
 	})
 })
 
@@ -585,3 +1027,25 @@ func ExpectConsistsOfFiltersAndOwners(expected, actual []amifamily.FiltersAndOwn
 	}
 	Expect(actual).To(ConsistOf(lo.Map(expected, func(f amifamily.FiltersAndOwners, _ int) interface{} { return f })...))
 }
+
+// inFlightSSMAPI records the largest number of GetParameter calls that were in-flight at once
+type inFlightSSMAPI struct {
+	*fake.SSMAPI
+	calls       atomic.Int64
+	inFlight    atomic.Int64
+	maxInFlight atomic.Int64
+}
+
+func (a *inFlightSSMAPI) GetParameterWithContext(ctx context.Context, input *ssm.GetParameterInput, opts ...request.Option) (*ssm.GetParameterOutput, error) {
+	a.calls.Add(1)
+	n := a.inFlight.Add(1)
+	defer a.inFlight.Add(-1)
+	for {
+		if m := a.maxInFlight.Load(); n <= m || a.maxInFlight.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	// Hold the call open so that calls which aren't limited would overlap
+	time.Sleep(10 * time.Millisecond)
+	return a.SSMAPI.GetParameterWithContext(ctx, input, opts...)
+}
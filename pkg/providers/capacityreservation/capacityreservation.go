@@ -0,0 +1,141 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityreservation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/mitchellh/hashstructure/v2"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+)
+
+type Provider interface {
+	List(context.Context, *v1beta1.EC2NodeClass) ([]*ec2.CapacityReservation, error)
+}
+
+type DefaultProvider struct {
+	sync.Mutex
+	ec2api ec2iface.EC2API
+	cache  *cache.Cache
+	cm     *pretty.ChangeMonitor
+}
+
+// query is a single DescribeCapacityReservations request. Capacity reservations can't be filtered by id, so ids are
+// passed separately from the tag filters.
+type query struct {
+	IDs     []*string
+	Filters []*ec2.Filter
+}
+
+func NewDefaultProvider(ec2api ec2iface.EC2API, cache *cache.Cache) *DefaultProvider {
+	return &DefaultProvider{
+		ec2api: ec2api,
+		cm:     pretty.NewChangeMonitor(),
+		cache:  cache,
+	}
+}
+
+// List returns the active capacity reservations that match the capacityReservationSelectorTerms of the nodeClass
+func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) ([]*ec2.CapacityReservation, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if len(nodeClass.Spec.CapacityReservationSelectorTerms) == 0 {
+		return nil, nil
+	}
+	queries := getQueries(nodeClass.Spec.CapacityReservationSelectorTerms)
+	capacityReservations, err := p.getCapacityReservations(ctx, queries)
+	if err != nil {
+		return nil, err
+	}
+	if p.cm.HasChanged(fmt.Sprintf("capacity-reservations/%s", nodeClass.Name), capacityReservations) {
+		log.FromContext(ctx).
+			WithValues("capacity-reservations", lo.Map(capacityReservations, func(c *ec2.CapacityReservation, _ int) string {
+				return aws.StringValue(c.CapacityReservationId)
+			})).
+			V(1).Info("discovered capacity reservations")
+	}
+	return capacityReservations, nil
+}
+
+func (p *DefaultProvider) getCapacityReservations(ctx context.Context, queries []query) ([]*ec2.CapacityReservation, error) {
+	hash, err := hashstructure.Hash(queries, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	if err != nil {
+		return nil, err
+	}
+	if cr, ok := p.cache.Get(fmt.Sprint(hash)); ok {
+		// Ensure what's returned from this function is a shallow-copy of the slice (not a deep-copy of the data itself)
+		// so that modifications to the ordering of the data don't affect the original
+		return append([]*ec2.CapacityReservation{}, cr.([]*ec2.CapacityReservation)...), nil
+	}
+	capacityReservations := map[string]*ec2.CapacityReservation{}
+	for _, q := range queries {
+		if err := p.ec2api.DescribeCapacityReservationsPagesWithContext(ctx, &ec2.DescribeCapacityReservationsInput{
+			CapacityReservationIds: q.IDs,
+			Filters:                q.Filters,
+		}, func(output *ec2.DescribeCapacityReservationsOutput, _ bool) bool {
+			for i := range output.CapacityReservations {
+				capacityReservations[lo.FromPtr(output.CapacityReservations[i].CapacityReservationId)] = output.CapacityReservations[i]
+			}
+			return true
+		}); err != nil {
+			return nil, fmt.Errorf("describing capacity reservations %+v, %w", queries, err)
+		}
+	}
+	p.cache.SetDefault(fmt.Sprint(hash), lo.Values(capacityReservations))
+	return lo.Values(capacityReservations), nil
+}
+
+func getQueries(terms []v1beta1.CapacityReservationSelectorTerm) (res []query) {
+	// Only active capacity reservations can be launched into
+	stateFilter := &ec2.Filter{Name: aws.String("state"), Values: aws.StringSlice([]string{ec2.CapacityReservationStateActive})}
+	var ids []*string
+	for _, term := range terms {
+		if term.ID != "" {
+			ids = append(ids, aws.String(term.ID))
+			continue
+		}
+		filters := []*ec2.Filter{stateFilter}
+		for k, v := range term.Tags {
+			if v == "*" {
+				filters = append(filters, &ec2.Filter{
+					Name:   aws.String("tag-key"),
+					Values: []*string{aws.String(k)},
+				})
+			} else {
+				filters = append(filters, &ec2.Filter{
+					Name:   aws.String(fmt.Sprintf("tag:%s", k)),
+					Values: []*string{aws.String(v)},
+				})
+			}
+		}
+		res = append(res, query{Filters: filters})
+	}
+	if len(ids) > 0 {
+		res = append(res, query{IDs: ids, Filters: []*ec2.Filter{stateFilter}})
+	}
+	return res
+}
@@ -0,0 +1,164 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityreservation_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/samber/lo"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var stop context.CancelFunc
+var env *coretest.Environment
+var awsEnv *test.Environment
+var nodeClass *v1beta1.EC2NodeClass
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CapacityReservationProvider")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	ctx, stop = context.WithCancel(ctx)
+	awsEnv = test.NewEnvironment(ctx, env)
+})
+
+var _ = AfterSuite(func() {
+	stop()
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	nodeClass = test.EC2NodeClass(v1beta1.EC2NodeClass{
+		Spec: v1beta1.EC2NodeClassSpec{
+			AMIFamily: aws.String(v1beta1.AMIFamilyAL2),
+			CapacityReservationSelectorTerms: []v1beta1.CapacityReservationSelectorTerm{
+				{
+					Tags: map[string]string{
+						"foo": "bar",
+					},
+				},
+			},
+		},
+	})
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("CapacityReservationProvider", func() {
+	It("should discover active capacity reservations by tags", func() {
+		capacityReservations, err := awsEnv.CapacityReservationProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		ExpectConsistsOfCapacityReservations([]string{"cr-test1", "cr-test2"}, capacityReservations)
+	})
+	It("should discover capacity reservations by tag key", func() {
+		nodeClass.Spec.CapacityReservationSelectorTerms = []v1beta1.CapacityReservationSelectorTerm{
+			{
+				Tags: map[string]string{"Name": "*"},
+			},
+		}
+		capacityReservations, err := awsEnv.CapacityReservationProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		ExpectConsistsOfCapacityReservations([]string{"cr-test1", "cr-test2"}, capacityReservations)
+	})
+	It("should discover capacity reservations by IDs", func() {
+		nodeClass.Spec.CapacityReservationSelectorTerms = []v1beta1.CapacityReservationSelectorTerm{
+			{
+				ID: "cr-test1",
+			},
+			{
+				ID: "cr-test2",
+			},
+		}
+		capacityReservations, err := awsEnv.CapacityReservationProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		ExpectConsistsOfCapacityReservations([]string{"cr-test1", "cr-test2"}, capacityReservations)
+	})
+	It("should discover capacity reservations by IDs and tags", func() {
+		nodeClass.Spec.CapacityReservationSelectorTerms = []v1beta1.CapacityReservationSelectorTerm{
+			{
+				ID: "cr-test1",
+			},
+			{
+				Tags: map[string]string{"Name": "test-capacity-reservation-2"},
+			},
+		}
+		capacityReservations, err := awsEnv.CapacityReservationProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		ExpectConsistsOfCapacityReservations([]string{"cr-test1", "cr-test2"}, capacityReservations)
+	})
+	It("should not discover capacity reservations that aren't active", func() {
+		nodeClass.Spec.CapacityReservationSelectorTerms = []v1beta1.CapacityReservationSelectorTerm{
+			{
+				ID: "cr-test3",
+			},
+		}
+		capacityReservations, err := awsEnv.CapacityReservationProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		Expect(capacityReservations).To(BeEmpty())
+	})
+	It("should not call EC2 when there are no capacity reservation selector terms", func() {
+		nodeClass.Spec.CapacityReservationSelectorTerms = nil
+		awsEnv.EC2API.NextError.Set(fmt.Errorf("failed"))
+		capacityReservations, err := awsEnv.CapacityReservationProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		Expect(capacityReservations).To(BeEmpty())
+	})
+	It("should resolve capacity reservations from cache", func() {
+		_, err := awsEnv.CapacityReservationProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		// A failure from EC2 isn't seen as the capacity reservations are cached
+		awsEnv.EC2API.NextError.Set(fmt.Errorf("failed"))
+		capacityReservations, err := awsEnv.CapacityReservationProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		ExpectConsistsOfCapacityReservations([]string{"cr-test1", "cr-test2"}, capacityReservations)
+		Expect(awsEnv.CapacityReservationCache.Items()).To(HaveLen(1))
+	})
+})
+
+func ExpectConsistsOfCapacityReservations(expected []string, actual []*ec2.CapacityReservation) {
+	GinkgoHelper()
+	Expect(lo.Map(actual, func(cr *ec2.CapacityReservation, _ int) string {
+		return aws.StringValue(cr.CapacityReservationId)
+	})).To(ConsistOf(expected))
+}
@@ -19,14 +19,19 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	gocache "github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/multierr"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -42,7 +47,9 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/placementscore"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/tracing"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
@@ -52,6 +59,11 @@ import (
 const (
 	instanceTypeFlexibilityThreshold = 5 // falling back to on-demand without flexibility risks insufficient capacity errors
 	maxInstanceTypes                 = 60
+	// maxTags is the number of tags that EC2 allows on a resource. Karpenter adds the Name, nodeclaim and nodeclaim UID
+	// tags to instances after the launch tags are rendered, so room is kept for them when label tags are added.
+	maxTags           = 50
+	reservedTags      = 3
+	maxTagValueLength = 256
 )
 
 var (
@@ -59,6 +71,7 @@ var (
 		Name:   aws.String("instance-state-name"),
 		Values: aws.StringSlice([]string{ec2.InstanceStateNamePending, ec2.InstanceStateNameRunning, ec2.InstanceStateNameStopping, ec2.InstanceStateNameStopped, ec2.InstanceStateNameShuttingDown}),
 	}
+	invalidTagValueCharacters = regexp.MustCompile(`[^\p{L}\p{Z}\p{N}_.:/=+\-@]`)
 )
 
 type Provider interface {
@@ -66,7 +79,9 @@ type Provider interface {
 	Get(context.Context, string) (*Instance, error)
 	List(context.Context) ([]*Instance, error)
 	Delete(context.Context, string) error
+	Stop(context.Context, string) error
 	CreateTags(context.Context, string, map[string]string) error
+	LaunchTemplates(context.Context, *v1beta1.EC2NodeClass, *corev1beta1.NodeClaim, []*cloudprovider.InstanceType) ([]*launchtemplate.RenderedLaunchTemplate, error)
 }
 
 type DefaultProvider struct {
@@ -76,39 +91,87 @@ type DefaultProvider struct {
 	instanceTypeProvider   instancetype.Provider
 	subnetProvider         subnet.Provider
 	launchTemplateProvider launchtemplate.Provider
+	placementScoreProvider placementscore.Provider
 	ec2Batcher             *batcher.EC2API
+	launchLimiter          *launchLimiter
+	tagMutator             TagMutator
+	// exhaustedCapacityReservations are the capacity reservations that recently failed a launch for lack of available
+	// instances, which the EC2NodeClass status may not reflect yet
+	exhaustedCapacityReservations *gocache.Cache
+	// stoppedInstanceClaims are the stopped instances that are being started for a NodeClaim, which keeps concurrent
+	// launches from starting the same instance for different NodeClaims
+	stoppedInstanceClaims *gocache.Cache
 }
 
 func NewDefaultProvider(ctx context.Context, region string, ec2api ec2iface.EC2API, unavailableOfferings *cache.UnavailableOfferings,
-	instanceTypeProvider instancetype.Provider, subnetProvider subnet.Provider, launchTemplateProvider launchtemplate.Provider) *DefaultProvider {
+	instanceTypeProvider instancetype.Provider, subnetProvider subnet.Provider, launchTemplateProvider launchtemplate.Provider,
+	placementScoreProvider placementscore.Provider, tagMutator TagMutator) *DefaultProvider {
 	return &DefaultProvider{
-		region:                 region,
-		ec2api:                 ec2api,
-		unavailableOfferings:   unavailableOfferings,
-		instanceTypeProvider:   instanceTypeProvider,
-		subnetProvider:         subnetProvider,
-		launchTemplateProvider: launchTemplateProvider,
-		ec2Batcher:             batcher.EC2(ctx, ec2api),
+		region:                        region,
+		ec2api:                        ec2api,
+		unavailableOfferings:          unavailableOfferings,
+		instanceTypeProvider:          instanceTypeProvider,
+		subnetProvider:                subnetProvider,
+		launchTemplateProvider:        launchTemplateProvider,
+		placementScoreProvider:        placementScoreProvider,
+		ec2Batcher:                    batcher.EC2(ctx, ec2api),
+		launchLimiter:                 newLaunchLimiter(),
+		tagMutator:                    tagMutator,
+		exhaustedCapacityReservations: gocache.New(cache.ExhaustedCapacityReservationsTTL, cache.DefaultCleanupInterval),
+		stoppedInstanceClaims:         gocache.New(cache.StoppedInstanceClaimTTL, cache.DefaultCleanupInterval),
 	}
 }
 
+// Reset forgets the capacity reservations that were exhausted by previous launches and the stopped instances that were
+// claimed by previous launches
+func (p *DefaultProvider) Reset() {
+	p.exhaustedCapacityReservations.Flush()
+	p.stoppedInstanceClaims.Flush()
+}
+
 func (p *DefaultProvider) Create(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) (*Instance, error) {
-	schedulingRequirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
-	// Only filter the instances if there are no minValues in the requirement.
-	if !schedulingRequirements.HasMinValues() {
-		instanceTypes = p.filterInstanceTypes(nodeClaim, instanceTypes)
+	ctx, span := tracing.Start(ctx, "instance.Create", append(tracing.NodeClaim(nodeClaim), tracing.NodeClass(nodeClass))...)
+	instance, err := p.create(ctx, nodeClass, nodeClaim, instanceTypes)
+	if instance != nil {
+		span.SetAttributes(attribute.String("instance.id", instance.ID))
 	}
-	instanceTypes, err := cloudprovider.InstanceTypes(instanceTypes).Truncate(schedulingRequirements, maxInstanceTypes)
+	tracing.End(span, err)
+	return instance, err
+}
+
+func (p *DefaultProvider) create(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) (*Instance, error) {
+	// An instance may have been launched for the NodeClaim without the NodeClaim being updated, e.g. if the controller
+	// restarted mid-launch, so that instance is adopted rather than launching another
+	if options.FromContext(ctx).CreateFleetClientToken && nodeClaim.UID != "" {
+		instance, err := p.getByNodeClaimUID(ctx, nodeClaim)
+		if err != nil {
+			return nil, fmt.Errorf("getting existing instance for nodeclaim, %w", err)
+		}
+		if instance != nil {
+			log.FromContext(ctx).WithValues("id", instance.ID).Info("adopted existing instance launched for nodeclaim")
+			return instance, nil
+		}
+	}
+	// Instances that were stopped rather than terminated are started again before launching new capacity
+	if nodeClaim.Annotations[v1beta1.AnnotationStopOnDelete] == "true" {
+		instance, err := p.startStoppedInstance(ctx, nodeClass, nodeClaim, instanceTypes)
+		if err != nil {
+			return nil, fmt.Errorf("starting stopped instance for nodeclaim, %w", err)
+		}
+		if instance != nil {
+			log.FromContext(ctx).WithValues("id", instance.ID).Info("started stopped instance for nodeclaim")
+			return instance, nil
+		}
+	}
+	instanceTypes, err := p.launchInstanceTypes(ctx, nodeClaim, instanceTypes)
 	if err != nil {
-		return nil, fmt.Errorf("truncating instance types, %w", err)
+		return nil, err
 	}
-	tags := getTags(ctx, nodeClass, nodeClaim)
-	fleetInstance, err := p.launchInstance(ctx, nodeClass, nodeClaim, instanceTypes, tags)
-	if awserrors.IsLaunchTemplateNotFound(err) {
-		// retry once if launch template is not found. This allows karpenter to generate a new LT if the
-		// cache was out-of-sync on the first try
-		fleetInstance, err = p.launchInstance(ctx, nodeClass, nodeClaim, instanceTypes, tags)
+	if err := p.launchLimiter.wait(ctx, nodeClass.Name, options.FromContext(ctx).MaxNodeClassLaunchBatchSize); err != nil {
+		return nil, fmt.Errorf("waiting to launch instance, %w", err)
 	}
+	tags := p.getTags(ctx, nodeClass, nodeClaim)
+	fleetInstance, err := p.launchInstanceInVPCs(ctx, nodeClass, nodeClaim, instanceTypes, tags)
 	if err != nil {
 		return nil, err
 	}
@@ -116,6 +179,20 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClass *v1beta1.EC2Node
 	return NewInstanceFromFleet(fleetInstance, tags, efaEnabled), nil
 }
 
+// launchInstanceTypes returns the instance types that are sent in the launch request for the NodeClaim
+func (p *DefaultProvider) launchInstanceTypes(ctx context.Context, nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) ([]*cloudprovider.InstanceType, error) {
+	schedulingRequirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	// Only filter the instances if there are no minValues in the requirement.
+	if !schedulingRequirements.HasMinValues() {
+		instanceTypes = p.filterInstanceTypes(ctx, nodeClaim, instanceTypes)
+	}
+	instanceTypes, err := cloudprovider.InstanceTypes(instanceTypes).Truncate(schedulingRequirements, maxInstanceTypes)
+	if err != nil {
+		return nil, fmt.Errorf("truncating instance types, %w", err)
+	}
+	return instanceTypes, nil
+}
+
 func (p *DefaultProvider) Get(ctx context.Context, id string) (*Instance, error) {
 	out, err := p.ec2Batcher.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
 		InstanceIds: aws.StringSlice([]string{id}),
@@ -137,6 +214,7 @@ func (p *DefaultProvider) Get(ctx context.Context, id string) (*Instance, error)
 	return instances[0], nil
 }
 
+// List returns all the non-terminated instances that carry Karpenter's ownership tags for the cluster
 func (p *DefaultProvider) List(ctx context.Context) ([]*Instance, error) {
 	var out = &ec2.DescribeInstancesOutput{}
 	err := p.ec2api.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
@@ -163,7 +241,51 @@ func (p *DefaultProvider) List(ctx context.Context) ([]*Instance, error) {
 		return nil, fmt.Errorf("describing ec2 instances, %w", err)
 	}
 	instances, err := instancesFromOutput(out)
-	return instances, cloudprovider.IgnoreNodeClaimNotFoundError(err)
+	// Stopped instances don't back a NodeClaim until they're started again, so they're hidden from garbage collection
+	// until they've been stopped for longer than the max stopped instance age. Instances that are being started for a
+	// NodeClaim are hidden regardless of their age.
+	return lo.Reject(instances, func(i *Instance, _ int) bool {
+		if !i.Stopped() {
+			return false
+		}
+		_, claimed := p.stoppedInstanceClaims.Get(i.ID)
+		return claimed || !p.stoppedInstanceExpired(ctx, i)
+	}), cloudprovider.IgnoreNodeClaimNotFoundError(err)
+}
+
+// getByNodeClaimUID returns the pending or running instance that was launched for the NodeClaim, or nil if there isn't
+// one
+func (p *DefaultProvider) getByNodeClaimUID(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (*Instance, error) {
+	var out = &ec2.DescribeInstancesOutput{}
+	if err := p.ec2api.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", v1beta1.TagNodeClaimUID)),
+				Values: aws.StringSlice([]string{string(nodeClaim.UID)}),
+			},
+			{
+				Name:   aws.String("tag-key"),
+				Values: aws.StringSlice([]string{fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)}),
+			},
+			{
+				Name:   aws.String("instance-state-name"),
+				Values: aws.StringSlice([]string{ec2.InstanceStateNamePending, ec2.InstanceStateNameRunning}),
+			},
+		},
+	}, func(page *ec2.DescribeInstancesOutput, _ bool) bool {
+		out.Reservations = append(out.Reservations, page.Reservations...)
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("describing ec2 instances, %w", err)
+	}
+	instances, err := instancesFromOutput(out)
+	if cloudprovider.IsNodeClaimNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return instances[0], nil
 }
 
 func (p *DefaultProvider) Delete(ctx context.Context, id string) error {
@@ -184,6 +306,120 @@ func (p *DefaultProvider) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// Stop stops the instance rather than terminating it, so that it can be started again for a later NodeClaim. Instances
+// that can't be stopped are terminated instead.
+func (p *DefaultProvider) Stop(ctx context.Context, id string) error {
+	instance, err := p.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if instance.Stopped() {
+		return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("instance already stopped"))
+	}
+	if !instance.Stoppable() {
+		log.FromContext(ctx).WithValues("capacity-type", instance.CapacityType, "root-device-type", instance.RootDeviceType).Info("instance can't be stopped, terminating instead")
+		return p.Delete(ctx, id)
+	}
+	// The instance is tagged before it's stopped so that it's never stopped without being recognized as stopped by Karpenter
+	if err := p.CreateTags(ctx, id, map[string]string{v1beta1.TagInstanceStopped: time.Now().UTC().Format(time.RFC3339)}); err != nil {
+		return err
+	}
+	if _, err := p.ec2api.StopInstancesWithContext(ctx, &ec2.StopInstancesInput{
+		InstanceIds: aws.StringSlice([]string{id}),
+	}); err != nil {
+		if awserrors.IsNotFound(err) {
+			return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("stopping instance, %w", err))
+		}
+		return fmt.Errorf("stopping instance, %w", err)
+	}
+	return nil
+}
+
+// startStoppedInstance starts an instance that was stopped for a NodeClaim of the same NodePool and EC2NodeClass, if
+// one is compatible with the NodeClaim, or returns nil if there isn't one
+func (p *DefaultProvider) startStoppedInstance(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) (*Instance, error) {
+	var out = &ec2.DescribeInstancesOutput{}
+	if err := p.ec2api.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("tag-key"),
+				Values: aws.StringSlice([]string{v1beta1.TagInstanceStopped}),
+			},
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", corev1beta1.NodePoolLabelKey)),
+				Values: aws.StringSlice([]string{nodeClaim.Labels[corev1beta1.NodePoolLabelKey]}),
+			},
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", v1beta1.LabelNodeClass)),
+				Values: aws.StringSlice([]string{nodeClass.Name}),
+			},
+			{
+				Name:   aws.String("tag-key"),
+				Values: aws.StringSlice([]string{fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)}),
+			},
+			{
+				Name:   aws.String("instance-state-name"),
+				Values: aws.StringSlice([]string{ec2.InstanceStateNameStopped}),
+			},
+		},
+	}, func(page *ec2.DescribeInstancesOutput, _ bool) bool {
+		out.Reservations = append(out.Reservations, page.Reservations...)
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("describing ec2 instances, %w", err)
+	}
+	instances, err := instancesFromOutput(out)
+	if cloudprovider.IsNodeClaimNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	for _, instance := range instances {
+		instanceType, ok := lo.Find(instanceTypes, func(it *cloudprovider.InstanceType) bool { return it.Name == instance.Type })
+		if !ok {
+			continue
+		}
+		offering, ok := instanceType.Offerings.Get(corev1beta1.CapacityTypeOnDemand, instance.Zone)
+		if !ok || !offering.Available || len(cloudprovider.Offerings{offering}.Compatible(requirements)) == 0 {
+			continue
+		}
+		// Instances that are old enough to be garbage collected aren't started, since they may be terminated
+		if p.stoppedInstanceExpired(ctx, instance) {
+			continue
+		}
+		// The instance is claimed before it's started, so that a concurrent launch doesn't start it for another NodeClaim
+		if err := p.stoppedInstanceClaims.Add(instance.ID, nodeClaim.Name, gocache.DefaultExpiration); err != nil {
+			continue
+		}
+		if _, err := p.ec2api.StartInstancesWithContext(ctx, &ec2.StartInstancesInput{
+			InstanceIds: aws.StringSlice([]string{instance.ID}),
+		}); err != nil {
+			// The capacity for the instance may not be available anymore, in which case another instance is tried
+			log.FromContext(ctx).WithValues("id", instance.ID).Error(err, "failed starting stopped instance")
+			p.stoppedInstanceClaims.Delete(instance.ID)
+			continue
+		}
+		if _, err := p.ec2api.DeleteTagsWithContext(ctx, &ec2.DeleteTagsInput{
+			Resources: aws.StringSlice([]string{instance.ID}),
+			Tags:      []*ec2.Tag{{Key: aws.String(v1beta1.TagInstanceStopped)}},
+		}); err != nil {
+			return nil, fmt.Errorf("untagging started instance, %w", err)
+		}
+		instance.State = ec2.InstanceStateNamePending
+		delete(instance.Tags, v1beta1.TagInstanceStopped)
+		return instance, nil
+	}
+	return nil, nil
+}
+
+// stoppedInstanceExpired returns whether the instance has been stopped for longer than the max stopped instance age,
+// after which it's terminated by garbage collection rather than started again
+func (p *DefaultProvider) stoppedInstanceExpired(ctx context.Context, instance *Instance) bool {
+	return time.Since(instance.StoppedAt()) > options.FromContext(ctx).MaxStoppedInstanceAge
+}
+
 func (p *DefaultProvider) CreateTags(ctx context.Context, id string, tags map[string]string) error {
 	ec2Tags := lo.MapToSlice(tags, func(key, value string) *ec2.Tag {
 		return &ec2.Tag{Key: aws.String(key), Value: aws.String(value)}
@@ -200,24 +436,172 @@ func (p *DefaultProvider) CreateTags(ctx context.Context, id string, tags map[st
 	return nil
 }
 
+// LaunchTemplates returns the launch templates that would be used to launch an instance for the NodeClaim, without
+// creating them
+func (p *DefaultProvider) LaunchTemplates(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) ([]*launchtemplate.RenderedLaunchTemplate, error) {
+	instanceTypes, err := p.launchInstanceTypes(ctx, nodeClaim, instanceTypes)
+	if err != nil {
+		return nil, err
+	}
+	capacityType := p.getCapacityType(nodeClaim, instanceTypes)
+	capacityReservation, reserved := p.getCapacityReservation(nodeClass, nodeClaim, instanceTypes)
+	if reserved {
+		capacityType = corev1beta1.CapacityTypeOnDemand
+		instanceTypes = capacityReservationInstanceTypes(instanceTypes, capacityReservation)
+	}
+	vpcs, err := vpcNodeClasses(nodeClass, nodeClaim)
+	if err != nil {
+		return nil, err
+	}
+	var launchTemplates []*launchtemplate.RenderedLaunchTemplate
+	for _, vpc := range vpcs {
+		rendered, err := p.launchTemplateProvider.RenderAll(ctx, vpc.nodeClass, nodeClaim, instanceTypes, capacityType, capacityReservation.ID, p.getTags(ctx, nodeClass, nodeClaim))
+		if err != nil {
+			return nil, err
+		}
+		launchTemplates = append(launchTemplates, rendered...)
+	}
+	return launchTemplates, nil
+}
+
+// launchInstanceInVPCs launches an instance into the first VPC of the nodeClass that has capacity for the NodeClaim.
+// Each fleet request only uses the subnets and security groups of a single VPC, since EC2 doesn't launch an instance
+// into a subnet with security groups from another VPC.
+func (p *DefaultProvider) launchInstanceInVPCs(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, tags map[string]string) (*ec2.CreateFleetInstance, error) {
+	vpcs, err := vpcNodeClasses(nodeClass, nodeClaim)
+	if err != nil {
+		return nil, err
+	}
+	var fleetInstance *ec2.CreateFleetInstance
+	for i, vpc := range vpcs {
+		if i > 0 {
+			// Capacity pools are shared by the VPCs in a zone, so the pools that the previous VPC failed to launch into
+			// aren't retried in the next VPC
+			instanceTypes = p.filterUnavailableOfferings(instanceTypes)
+			if !lo.ContainsBy(instanceTypes, func(it *cloudprovider.InstanceType) bool { return len(it.Offerings.Available()) > 0 }) {
+				return nil, err
+			}
+		}
+		fleetInstance, err = p.launchInstance(ctx, vpc.nodeClass, nodeClaim, instanceTypes, tags)
+		if awserrors.IsLaunchTemplateNotFound(err) {
+			// retry once if launch template is not found. This allows karpenter to generate a new LT if the
+			// cache was out-of-sync on the first try
+			fleetInstance, err = p.launchInstance(ctx, vpc.nodeClass, nodeClaim, instanceTypes, tags)
+		}
+		if err == nil || !cloudprovider.IsInsufficientCapacityError(err) || i == len(vpcs)-1 {
+			return fleetInstance, err
+		}
+		log.FromContext(ctx).WithValues("vpc", vpc.id, "next-vpc", vpcs[i+1].id).V(1).Info("insufficient capacity in vpc, falling back to the next vpc")
+	}
+	return nil, fmt.Errorf("no vpcs to launch into")
+}
+
+// filterUnavailableOfferings returns copies of the instance types whose offerings are unavailable if they've been marked
+// as unavailable since the instance types were resolved, such as after a launch into them failed with insufficient
+// capacity
+func (p *DefaultProvider) filterUnavailableOfferings(instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+	return lo.Map(instanceTypes, func(it *cloudprovider.InstanceType, _ int) *cloudprovider.InstanceType {
+		return &cloudprovider.InstanceType{
+			Name:         it.Name,
+			Requirements: it.Requirements,
+			Capacity:     it.Capacity,
+			Overhead:     it.Overhead,
+			Offerings: lo.Map(it.Offerings, func(of cloudprovider.Offering, _ int) cloudprovider.Offering {
+				of.Available = of.Available && !p.unavailableOfferings.IsUnavailable(it.Name, of.Zone, of.CapacityType)
+				return of
+			}),
+		}
+	})
+}
+
+// vpcNodeClass is a copy of an EC2NodeClass whose resolved subnets and security groups are constrained to a single VPC
+type vpcNodeClass struct {
+	id        string
+	nodeClass *v1beta1.EC2NodeClass
+}
+
+// vpcNodeClasses groups the resolved subnets and security groups of the nodeClass by their VPC. VPCs without both
+// subnets and security groups can't be launched into, and the remaining VPCs are ordered by the number of zones of the
+// NodeClaim that their subnets are in. Subnets and security groups whose VPC isn't known are included in every VPC, so
+// a nodeClass whose status doesn't have VPCs yet is launched as a single VPC.
+func vpcNodeClasses(nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim) ([]vpcNodeClass, error) {
+	ids := sets.New(lo.Map(nodeClass.Status.Subnets, func(s v1beta1.Subnet, _ int) string { return s.VPCID })...).
+		Insert(lo.Map(nodeClass.Status.SecurityGroups, func(sg v1beta1.SecurityGroup, _ int) string { return sg.VPCID })...).
+		Delete("")
+	if ids.Len() <= 1 {
+		id, _ := ids.PopAny()
+		return []vpcNodeClass{{id: id, nodeClass: nodeClass}}, nil
+	}
+	zones := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...).Get(v1.LabelTopologyZone)
+	zoneCounts := map[string]int{}
+	var vpcs []vpcNodeClass
+	for _, id := range sets.List(ids) {
+		subnets := lo.Filter(nodeClass.Status.Subnets, func(s v1beta1.Subnet, _ int) bool { return s.VPCID == "" || s.VPCID == id })
+		securityGroups := lo.Filter(nodeClass.Status.SecurityGroups, func(sg v1beta1.SecurityGroup, _ int) bool { return sg.VPCID == "" || sg.VPCID == id })
+		if len(subnets) == 0 || len(securityGroups) == 0 {
+			continue
+		}
+		vpc := vpcNodeClass{id: id, nodeClass: nodeClass.DeepCopy()}
+		vpc.nodeClass.Status.Subnets = subnets
+		vpc.nodeClass.Status.SecurityGroups = securityGroups
+		zoneCounts[id] = sets.New(lo.FilterMap(subnets, func(s v1beta1.Subnet, _ int) (string, bool) { return s.Zone, zones.Has(s.Zone) })...).Len()
+		vpcs = append(vpcs, vpc)
+	}
+	if len(vpcs) == 0 {
+		return nil, fmt.Errorf("no vpc has both subnets and security groups, vpcs of subnets %v, vpcs of security groups %v",
+			lo.Uniq(lo.Map(nodeClass.Status.Subnets, func(s v1beta1.Subnet, _ int) string { return s.VPCID })),
+			lo.Uniq(lo.Map(nodeClass.Status.SecurityGroups, func(sg v1beta1.SecurityGroup, _ int) string { return sg.VPCID })))
+	}
+	sort.SliceStable(vpcs, func(i, j int) bool { return zoneCounts[vpcs[i].id] > zoneCounts[vpcs[j].id] })
+	return vpcs, nil
+}
+
 func (p *DefaultProvider) launchInstance(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, tags map[string]string) (*ec2.CreateFleetInstance, error) {
+	requestedInstanceTypes := instanceTypes
 	capacityType := p.getCapacityType(nodeClaim, instanceTypes)
+	// Launch into a matching capacity reservation when there is one, since its capacity is already paid for
+	capacityReservation, reserved := p.getCapacityReservation(nodeClass, nodeClaim, instanceTypes)
+	if reserved {
+		capacityType = corev1beta1.CapacityTypeOnDemand
+		instanceTypes = capacityReservationInstanceTypes(instanceTypes, capacityReservation)
+	}
 	zonalSubnets, err := p.subnetProvider.ZonalSubnetsForLaunch(ctx, nodeClass, instanceTypes, capacityType)
 	if err != nil {
 		return nil, fmt.Errorf("getting subnets, %w", err)
 	}
+	onDemandBase, onDemandPercentage, mixed := p.onDemandSplit(nodeClass, nodeClaim, instanceTypes, capacityType, reserved)
+	capacityTypes := lo.Ternary(mixed, []string{corev1beta1.CapacityTypeSpot, corev1beta1.CapacityTypeOnDemand}, []string{capacityType})
 
 	// Get Launch Template Configs, which may differ due to GPU or Architecture requirements
-	launchTemplateConfigs, err := p.getLaunchTemplateConfigs(ctx, nodeClass, nodeClaim, instanceTypes, zonalSubnets, capacityType, tags)
+	launchTemplateConfigs, err := p.getLaunchTemplateConfigs(ctx, nodeClass, nodeClaim, instanceTypes, zonalSubnets, capacityTypes, capacityReservation.ID, tags)
 	if err != nil {
 		return nil, fmt.Errorf("getting launch template configs, %w", err)
 	}
-	if err := p.checkODFallback(nodeClaim, instanceTypes, launchTemplateConfigs); err != nil {
+	// The flexibility of the launch is checked against the requested instance types, since a launch into a capacity
+	// reservation falls back to them when the reservation is exhausted
+	if err := p.checkODFallback(nodeClaim, requestedInstanceTypes, launchTemplateConfigs); err != nil {
 		log.FromContext(ctx).Error(err, "failed while checking on-demand fallback")
 	}
+	spotAllocationStrategy := ec2.SpotAllocationStrategyPriceCapacityOptimized
+	if capacityType == corev1beta1.CapacityTypeSpot && options.FromContext(ctx).SpotPlacementScore &&
+		p.prioritizeHighestScoringZones(ctx, nodeClass, instanceTypes, launchTemplateConfigs) {
+		spotAllocationStrategy = ec2.SpotAllocationStrategyCapacityOptimizedPrioritized
+	}
+	if capacityType == corev1beta1.CapacityTypeOnDemand && options.FromContext(ctx).OnDemandAllocationStrategy == ec2.FleetOnDemandAllocationStrategyPrioritized {
+		prioritizeOverrides(instanceTypes, p.instanceTypePriority(ctx, nodeClaim), launchTemplateConfigs)
+	}
+	// A client token makes retried launches for the NodeClaim idempotent, so a launch that succeeded but wasn't
+	// persisted doesn't launch a second instance. The NodeClaim's UID is only added to the tags of the fleet request,
+	// rather than to the tags of the launch templates, so that launch templates are still shared across NodeClaims.
+	var clientToken *string
+	if options.FromContext(ctx).CreateFleetClientToken && nodeClaim.UID != "" {
+		clientToken = aws.String(string(nodeClaim.UID))
+		tags = lo.Assign(tags, map[string]string{v1beta1.TagNodeClaimUID: string(nodeClaim.UID)})
+	}
 	// Create fleet
 	createFleetInput := &ec2.CreateFleetInput{
 		Type:                  aws.String(ec2.FleetTypeInstant),
+		ClientToken:           clientToken,
 		Context:               nodeClass.Spec.Context,
 		LaunchTemplateConfigs: launchTemplateConfigs,
 		TargetCapacitySpecification: &ec2.TargetCapacitySpecificationRequest{
@@ -231,12 +615,20 @@ func (p *DefaultProvider) launchInstance(ctx context.Context, nodeClass *v1beta1
 		},
 	}
 	if capacityType == corev1beta1.CapacityTypeSpot {
-		createFleetInput.SpotOptions = &ec2.SpotOptionsRequest{AllocationStrategy: aws.String(ec2.SpotAllocationStrategyPriceCapacityOptimized)}
+		createFleetInput.SpotOptions = &ec2.SpotOptionsRequest{AllocationStrategy: aws.String(spotAllocationStrategy)}
 	} else {
-		createFleetInput.OnDemandOptions = &ec2.OnDemandOptionsRequest{AllocationStrategy: aws.String(ec2.FleetOnDemandAllocationStrategyLowestPrice)}
+		createFleetInput.OnDemandOptions = &ec2.OnDemandOptionsRequest{AllocationStrategy: aws.String(options.FromContext(ctx).OnDemandAllocationStrategy)}
+	}
+	// The batcher caps the on-demand base by the total capacity of the batched request, launches the on-demand
+	// percentage of the rest of it as on-demand, and the remainder as spot
+	if mixed {
+		createFleetInput.TargetCapacitySpecification.OnDemandTargetCapacity = aws.Int64(onDemandBase)
+		createFleetInput.OnDemandOptions = &ec2.OnDemandOptionsRequest{AllocationStrategy: aws.String(options.FromContext(ctx).OnDemandAllocationStrategy)}
 	}
 
-	createFleetOutput, err := p.ec2Batcher.CreateFleet(ctx, createFleetInput)
+	fleetCtx, span := tracing.Start(ctx, "ec2.CreateFleet", attribute.String("capacity-type", capacityType), attribute.Int("instance-types", len(instanceTypes)))
+	createFleetOutput, err := p.ec2Batcher.CreateMixedFleet(fleetCtx, createFleetInput, onDemandPercentage)
+	tracing.End(span, err)
 	p.subnetProvider.UpdateInflightIPs(createFleetInput, createFleetOutput, instanceTypes, lo.Values(zonalSubnets), capacityType)
 	if err != nil {
 		if awserrors.IsLaunchTemplateNotFound(err) {
@@ -253,19 +645,65 @@ func (p *DefaultProvider) launchInstance(ctx context.Context, nodeClass *v1beta1
 	}
 	p.updateUnavailableOfferingsCache(ctx, createFleetOutput.Errors, capacityType)
 	if len(createFleetOutput.Instances) == 0 || len(createFleetOutput.Instances[0].InstanceIds) == 0 {
+		// Retry without the capacity reservation when it ran out of instances, which falls back to the next capacity
+		// reservation or to a launch that isn't reserved
+		if reserved && lo.ContainsBy(createFleetOutput.Errors, awserrors.IsReservationCapacityExceeded) {
+			p.exhaustedCapacityReservations.SetDefault(capacityReservation.ID, struct{}{})
+			log.FromContext(ctx).WithValues("capacity-reservation", capacityReservation.ID).V(1).Info("capacity reservation is exhausted, launching without it")
+			return p.launchInstance(ctx, nodeClass, nodeClaim, requestedInstanceTypes, tags)
+		}
 		return nil, combineFleetErrors(createFleetOutput.Errors)
 	}
 	return createFleetOutput.Instances[0], nil
 }
 
-func getTags(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim) map[string]string {
+func (p *DefaultProvider) getTags(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim) map[string]string {
 	staticTags := map[string]string{
 		fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName): "owned",
 		corev1beta1.NodePoolLabelKey:       nodeClaim.Labels[corev1beta1.NodePoolLabelKey],
 		corev1beta1.ManagedByAnnotationKey: options.FromContext(ctx).ClusterName,
 		v1beta1.LabelNodeClass:             nodeClass.Name,
 	}
-	return lo.Assign(nodeClass.Spec.Tags, staticTags)
+	tags := lo.Assign(nodeClass.Spec.Tags, staticTags)
+	tags = lo.Assign(labelTags(nodeClass, nodeClaim, tags), tags)
+	// The static tags are reapplied after the mutator, since Karpenter relies on them to find the instances it manages
+	return lo.Assign(p.tagMutator.MutateTags(ctx, nodeClass, nodeClaim, tags), staticTags)
+}
+
+// labelTags returns the tags that the EC2NodeClass copies from the NodeClaim's labels. Tag keys that are already set
+// aren't overridden, and label tags are skipped once they would take the instance over the EC2 tag limit.
+func labelTags(nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim, tags map[string]string) map[string]string {
+	result := map[string]string{}
+	labelKeys := lo.Keys(nodeClass.Spec.LabelTags)
+	sort.Strings(labelKeys)
+	for _, labelKey := range labelKeys {
+		tagKey := nodeClass.Spec.LabelTags[labelKey]
+		value, ok := nodeClaim.Labels[labelKey]
+		if !ok {
+			continue
+		}
+		if _, ok := tags[tagKey]; ok {
+			continue
+		}
+		if _, ok := result[tagKey]; ok {
+			continue
+		}
+		if len(tags)+len(result) >= maxTags-reservedTags {
+			break
+		}
+		result[tagKey] = sanitizeTagValue(value)
+	}
+	return result
+}
+
+// sanitizeTagValue replaces the characters that EC2 doesn't allow in tag values and truncates the value to the maximum
+// tag value length
+func sanitizeTagValue(value string) string {
+	value = invalidTagValueCharacters.ReplaceAllString(value, "_")
+	if runes := []rune(value); len(runes) > maxTagValueLength {
+		return string(runes[:maxTagValueLength])
+	}
+	return value
 }
 
 func (p *DefaultProvider) checkODFallback(nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, launchTemplateConfigs []*ec2.FleetLaunchTemplateConfigRequest) error {
@@ -291,16 +729,27 @@ func (p *DefaultProvider) checkODFallback(nodeClaim *corev1beta1.NodeClaim, inst
 	return nil
 }
 
+// getLaunchTemplateConfigs returns the launch template configs of the fleet request. When the request mixes capacity
+// types, the launch templates don't register nodes with a capacity type, since it's only known once the instance is
+// launched, and the overrides cover the offerings of all of the capacity types.
 func (p *DefaultProvider) getLaunchTemplateConfigs(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim,
-	instanceTypes []*cloudprovider.InstanceType, zonalSubnets map[string]*subnet.Subnet, capacityType string, tags map[string]string) ([]*ec2.FleetLaunchTemplateConfigRequest, error) {
+	instanceTypes []*cloudprovider.InstanceType, zonalSubnets map[string]*subnet.Subnet, capacityTypes []string, capacityReservationID string, tags map[string]string) ([]*ec2.FleetLaunchTemplateConfigRequest, error) {
 	var launchTemplateConfigs []*ec2.FleetLaunchTemplateConfigRequest
-	launchTemplates, err := p.launchTemplateProvider.EnsureAll(ctx, nodeClass, nodeClaim, instanceTypes, capacityType, tags)
+	launchTemplates, err := p.launchTemplateProvider.EnsureAll(ctx, nodeClass, nodeClaim, instanceTypes, lo.Ternary(len(capacityTypes) == 1, capacityTypes[0], ""), capacityReservationID, tags)
 	if err != nil {
 		return nil, fmt.Errorf("getting launch templates, %w", err)
 	}
+	zones := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...).Get(v1.LabelTopologyZone)
 	for _, launchTemplate := range launchTemplates {
+		var overrides []*ec2.FleetLaunchTemplateOverridesRequest
+		for _, capacityType := range capacityTypes {
+			overrides = append(overrides, p.getOverrides(nodeClass, launchTemplate.InstanceTypes, zonalSubnets, zones, capacityType, launchTemplate.ImageID)...)
+		}
 		launchTemplateConfig := &ec2.FleetLaunchTemplateConfigRequest{
-			Overrides: p.getOverrides(launchTemplate.InstanceTypes, zonalSubnets, scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...).Get(v1.LabelTopologyZone), capacityType, launchTemplate.ImageID),
+			// An override applies to every capacity type of the request, so offerings of both capacity types are only included once
+			Overrides: lo.UniqBy(overrides, func(o *ec2.FleetLaunchTemplateOverridesRequest) string {
+				return aws.StringValue(o.InstanceType) + "/" + aws.StringValue(o.SubnetId)
+			}),
 			LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecificationRequest{
 				LaunchTemplateName: aws.String(launchTemplate.Name),
 				Version:            aws.String("$Latest"),
@@ -318,19 +767,22 @@ func (p *DefaultProvider) getLaunchTemplateConfigs(ctx context.Context, nodeClas
 
 // getOverrides creates and returns launch template overrides for the cross product of InstanceTypes and subnets (with subnets being constrained by
 // zones and the offerings in InstanceTypes)
-func (p *DefaultProvider) getOverrides(instanceTypes []*cloudprovider.InstanceType, zonalSubnets map[string]*subnet.Subnet, zones *scheduling.Requirement, capacityType string, image string) []*ec2.FleetLaunchTemplateOverridesRequest {
+func (p *DefaultProvider) getOverrides(nodeClass *v1beta1.EC2NodeClass, instanceTypes []*cloudprovider.InstanceType, zonalSubnets map[string]*subnet.Subnet, zones *scheduling.Requirement, capacityType string, image string) []*ec2.FleetLaunchTemplateOverridesRequest {
 	// Unwrap all the offerings to a flat slice that includes a pointer
 	// to the parent instance type name
 	type offeringWithParentName struct {
 		cloudprovider.Offering
 		parentInstanceTypeName string
+		spotMaxPrice           *string
 	}
 	var unwrappedOfferings []offeringWithParentName
 	for _, it := range instanceTypes {
+		spotMaxPrice := getSpotMaxPrice(nodeClass, it)
 		ofs := lo.Map(it.Offerings.Available(), func(of cloudprovider.Offering, _ int) offeringWithParentName {
 			return offeringWithParentName{
 				Offering:               of,
 				parentInstanceTypeName: it.Name,
+				spotMaxPrice:           spotMaxPrice,
 			}
 		})
 		unwrappedOfferings = append(unwrappedOfferings, ofs...)
@@ -355,19 +807,159 @@ func (p *DefaultProvider) getOverrides(instanceTypes []*cloudprovider.InstanceTy
 			// This is technically redundant, but is useful if we have to parse insufficient capacity errors from
 			// CreateFleet so that we can figure out the zone rather than additional API calls to look up the subnet
 			AvailabilityZone: lo.ToPtr(subnet.Zone),
+			MaxPrice:         lo.Ternary(capacityType == corev1beta1.CapacityTypeSpot, offering.spotMaxPrice, nil),
 		})
 	}
 	return overrides
 }
 
+// getSpotMaxPrice returns the price to bid for spot offerings of the instance type, or nil if the nodeclass doesn't
+// cap the spot price. Percentages are resolved against the on-demand price of the instance type.
+func getSpotMaxPrice(nodeClass *v1beta1.EC2NodeClass, instanceType *cloudprovider.InstanceType) *string {
+	onDemand, hasOnDemand := lo.Find(instanceType.Offerings, func(o cloudprovider.Offering) bool {
+		return o.CapacityType == corev1beta1.CapacityTypeOnDemand
+	})
+	price, ok := nodeClass.SpotMaxPrice(onDemand.Price, hasOnDemand)
+	if !ok {
+		return nil
+	}
+	// Round the price so that percentages of the on-demand price don't carry floating point noise
+	return aws.String(strconv.FormatFloat(math.Round(price*1e5)/1e5, 'f', -1, 64))
+}
+
+// prioritizeHighestScoringZones sets the priorities of the overrides of a spot launch so that zones with a higher spot
+// placement score come first, and overrides in the same zone are ordered by price. The priorities only bias the
+// capacity-optimized-prioritized allocation strategy towards the zones where spot instances are least likely to be
+// interrupted, so a launch can still be fulfilled in a lower scoring zone. It returns false, leaving the overrides
+// unchanged, if none of the zones have a score.
+func (p *DefaultProvider) prioritizeHighestScoringZones(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, instanceTypes []*cloudprovider.InstanceType,
+	launchTemplateConfigs []*ec2.FleetLaunchTemplateConfigRequest) bool {
+	instanceTypesByName := lo.SliceToMap(instanceTypes, func(it *cloudprovider.InstanceType) (string, *cloudprovider.InstanceType) { return it.Name, it })
+	// Scores are keyed by zone id, since zone names map to different zones in each account
+	zoneIDs := lo.SliceToMap(lo.Filter(nodeClass.Status.Subnets, func(s v1beta1.Subnet, _ int) bool { return s.ZoneID != "" }), func(s v1beta1.Subnet) (string, string) {
+		return s.Zone, s.ZoneID
+	})
+	score := func(override *ec2.FleetLaunchTemplateOverridesRequest) int64 {
+		it, ok := instanceTypesByName[aws.StringValue(override.InstanceType)]
+		if !ok {
+			return 0
+		}
+		architecture := it.Requirements.Get(v1.LabelArchStable).Any()
+		return p.placementScoreProvider.Scores(ctx, architecture)[zoneIDs[aws.StringValue(override.AvailabilityZone)]]
+	}
+	price := func(override *ec2.FleetLaunchTemplateOverridesRequest) float64 {
+		if it, ok := instanceTypesByName[aws.StringValue(override.InstanceType)]; ok {
+			if offering, ok := it.Offerings.Get(corev1beta1.CapacityTypeSpot, aws.StringValue(override.AvailabilityZone)); ok {
+				return offering.Price
+			}
+		}
+		return math.MaxFloat64
+	}
+	overrides := lo.FlatMap(launchTemplateConfigs, func(ltc *ec2.FleetLaunchTemplateConfigRequest, _ int) []*ec2.FleetLaunchTemplateOverridesRequest {
+		return ltc.Overrides
+	})
+	// None of the zones have a score, so there's nothing to prefer
+	if !lo.ContainsBy(overrides, func(override *ec2.FleetLaunchTemplateOverridesRequest) bool { return score(override) > 0 }) {
+		return false
+	}
+	sort.SliceStable(overrides, func(i, j int) bool {
+		if score(overrides[i]) != score(overrides[j]) {
+			return score(overrides[i]) > score(overrides[j])
+		}
+		return price(overrides[i]) < price(overrides[j])
+	})
+	// A lower number is a higher priority
+	for i, override := range overrides {
+		override.Priority = aws.Float64(float64(i))
+	}
+	return true
+}
+
+// instanceTypePriority returns the instance types and families that the on-demand launch of the NodeClaim prioritizes.
+// The instance-type-priority annotation of the NodeClaim, which is set through the template of its NodePool, takes
+// precedence over on-demand-family-priority. Entries of the annotation that aren't a discovered instance type or
+// family are ignored, so that a typo doesn't silently deprioritize the types that follow it.
+func (p *DefaultProvider) instanceTypePriority(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) []string {
+	value, ok := nodeClaim.Annotations[v1beta1.AnnotationInstanceTypePriority]
+	if !ok {
+		return options.FromContext(ctx).OnDemandFamilyPriorityList()
+	}
+	priority := lo.Compact(lo.Map(strings.Split(value, ","), func(entry string, _ int) string { return strings.TrimSpace(entry) }))
+	offerings, err := p.instanceTypeProvider.ListOfferings(ctx)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed listing instance types, using the instance type priority without validating it")
+		return priority
+	}
+	discovered := sets.New[string]()
+	for name := range offerings {
+		family, _, _ := strings.Cut(name, ".")
+		discovered.Insert(name, family)
+	}
+	valid := lo.Filter(priority, func(entry string, _ int) bool { return discovered.Has(entry) })
+	invalid := lo.Without(priority, valid...)
+	if len(invalid) > 0 {
+		log.FromContext(ctx).WithValues("annotation", v1beta1.AnnotationInstanceTypePriority, "entries", invalid).Error(
+			fmt.Errorf("unknown instance types or families"), "ignoring instance type priority entries")
+	}
+	return valid
+}
+
+// prioritizeOverrides sets the priorities of the overrides of an on-demand launch for the prioritized allocation
+// strategy. Overrides that match the priority list, either by instance type or by family, come first in the order
+// that they're listed, followed by all other overrides. Overrides with the same priority are ordered by price.
+func prioritizeOverrides(instanceTypes []*cloudprovider.InstanceType, priority []string, launchTemplateConfigs []*ec2.FleetLaunchTemplateConfigRequest) {
+	instanceTypesByName := lo.SliceToMap(instanceTypes, func(it *cloudprovider.InstanceType) (string, *cloudprovider.InstanceType) { return it.Name, it })
+	rank := func(override *ec2.FleetLaunchTemplateOverridesRequest) int {
+		family, _, _ := strings.Cut(aws.StringValue(override.InstanceType), ".")
+		_, i, ok := lo.FindIndexOf(priority, func(entry string) bool {
+			return entry == aws.StringValue(override.InstanceType) || entry == family
+		})
+		return lo.Ternary(ok, i, len(priority))
+	}
+	price := func(override *ec2.FleetLaunchTemplateOverridesRequest) float64 {
+		if it, ok := instanceTypesByName[aws.StringValue(override.InstanceType)]; ok {
+			if offering, ok := it.Offerings.Get(corev1beta1.CapacityTypeOnDemand, aws.StringValue(override.AvailabilityZone)); ok {
+				return offering.Price
+			}
+		}
+		return math.MaxFloat64
+	}
+	overrides := lo.FlatMap(launchTemplateConfigs, func(ltc *ec2.FleetLaunchTemplateConfigRequest, _ int) []*ec2.FleetLaunchTemplateOverridesRequest {
+		return ltc.Overrides
+	})
+	sort.SliceStable(overrides, func(i, j int) bool {
+		if rank(overrides[i]) != rank(overrides[j]) {
+			return rank(overrides[i]) < rank(overrides[j])
+		}
+		return price(overrides[i]) < price(overrides[j])
+	})
+	// A lower number is a higher priority
+	for i, override := range overrides {
+		override.Priority = aws.Float64(float64(i))
+	}
+}
+
 func (p *DefaultProvider) updateUnavailableOfferingsCache(ctx context.Context, errors []*ec2.CreateFleetError, capacityType string) {
 	for _, err := range errors {
 		if awserrors.IsUnfulfillableCapacity(err) {
-			p.unavailableOfferings.MarkUnavailableForFleetErr(ctx, err, capacityType)
+			// Errors of fleet requests that mix capacity types are attributed to the capacity type that failed
+			p.unavailableOfferings.MarkUnavailableForFleetErr(ctx, err, lo.Ternary(aws.StringValue(err.Lifecycle) != "", aws.StringValue(err.Lifecycle), capacityType))
 		}
 	}
 }
 
+// onDemandSplit returns the number of instances in the fleet request that are launched as on-demand before the rest
+// are launched as spot, and the percentage of the rest that is launched as on-demand too. The request only mixes
+// capacity types when the EC2NodeClass declares either of them and the launch could be either spot or on-demand, i.e.
+// the NodeClaim allows both capacity types and there are offerings of both.
+func (p *DefaultProvider) onDemandSplit(nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, capacityType string, reserved bool) (int64, int64, bool) {
+	if reserved || capacityType != corev1beta1.CapacityTypeSpot || !p.isMixedCapacityLaunch(nodeClaim, instanceTypes) {
+		return 0, 0, false
+	}
+	base, percentage := aws.Int64Value(nodeClass.Spec.OnDemandBaseCapacity), aws.Int64Value(nodeClass.Spec.OnDemandPercentageAboveBaseCapacity)
+	return base, percentage, base > 0 || percentage > 0
+}
+
 // getCapacityType selects spot if both constraints are flexible and there is an
 // available offering. The AWS Cloud Provider defaults to [ on-demand ], so spot
 // must be explicitly included in capacity type requirements.
@@ -386,15 +978,68 @@ func (p *DefaultProvider) getCapacityType(nodeClaim *corev1beta1.NodeClaim, inst
 	return corev1beta1.CapacityTypeOnDemand
 }
 
+// getCapacityReservation returns the capacity reservation from the EC2NodeClass status that the instance should be
+// launched into. A capacity reservation is only considered if it has instances available, its instance type and zone
+// are compatible with the launch, and there is a subnet in its zone. Since the available instances in the status can
+// be stale, capacity reservations that recently failed a launch for lack of instances are skipped. The reservation
+// with the most available instances is preferred.
+func (p *DefaultProvider) getCapacityReservation(nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) (v1beta1.CapacityReservation, bool) {
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	if !requirements.Get(corev1beta1.CapacityTypeLabelKey).Has(corev1beta1.CapacityTypeOnDemand) {
+		return v1beta1.CapacityReservation{}, false
+	}
+	subnetZones := sets.New(lo.Map(nodeClass.Status.Subnets, func(s v1beta1.Subnet, _ int) string { return s.Zone })...)
+	capacityReservations := lo.Filter(nodeClass.Status.CapacityReservations, func(cr v1beta1.CapacityReservation, _ int) bool {
+		if _, exhausted := p.exhaustedCapacityReservations.Get(cr.ID); exhausted {
+			return false
+		}
+		if cr.AvailableInstanceCount <= 0 || !subnetZones.Has(cr.Zone) || !requirements.Get(v1.LabelTopologyZone).Has(cr.Zone) {
+			return false
+		}
+		instanceType, ok := lo.Find(instanceTypes, func(it *cloudprovider.InstanceType) bool { return it.Name == cr.InstanceType })
+		return ok && lo.ContainsBy(instanceType.Offerings.Available(), func(of cloudprovider.Offering) bool {
+			return of.CapacityType == corev1beta1.CapacityTypeOnDemand && of.Zone == cr.Zone
+		})
+	})
+	if len(capacityReservations) == 0 {
+		return v1beta1.CapacityReservation{}, false
+	}
+	sort.Slice(capacityReservations, func(i, j int) bool {
+		if capacityReservations[i].AvailableInstanceCount != capacityReservations[j].AvailableInstanceCount {
+			return capacityReservations[i].AvailableInstanceCount > capacityReservations[j].AvailableInstanceCount
+		}
+		return capacityReservations[i].ID < capacityReservations[j].ID
+	})
+	return capacityReservations[0], true
+}
+
+// capacityReservationInstanceTypes narrows the instance types for a launch down to the on-demand offering of the
+// capacity reservation's instance type in its zone
+func capacityReservationInstanceTypes(instanceTypes []*cloudprovider.InstanceType, capacityReservation v1beta1.CapacityReservation) []*cloudprovider.InstanceType {
+	instanceType, _ := lo.Find(instanceTypes, func(it *cloudprovider.InstanceType) bool { return it.Name == capacityReservation.InstanceType })
+	return []*cloudprovider.InstanceType{{
+		Name:         instanceType.Name,
+		Requirements: instanceType.Requirements,
+		Capacity:     instanceType.Capacity,
+		Overhead:     instanceType.Overhead,
+		Offerings: lo.Filter(instanceType.Offerings, func(of cloudprovider.Offering, _ int) bool {
+			return of.CapacityType == corev1beta1.CapacityTypeOnDemand && of.Zone == capacityReservation.Zone
+		}),
+	}}
+}
+
 // filterInstanceTypes is used to provide filtering on the list of potential instance types to further limit it to those
 // that make the most sense given our specific AWS cloudprovider.
-func (p *DefaultProvider) filterInstanceTypes(nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+func (p *DefaultProvider) filterInstanceTypes(ctx context.Context, nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
 	instanceTypes = filterExoticInstanceTypes(instanceTypes)
 	// If we could potentially launch either a spot or on-demand node, we want to filter out the spot instance types that
 	// are more expensive than the cheapest on-demand type.
 	if p.isMixedCapacityLaunch(nodeClaim, instanceTypes) {
 		instanceTypes = filterUnwantedSpot(instanceTypes)
 	}
+	if threshold := options.FromContext(ctx).NewerGenerationPriceThreshold; threshold > 0 {
+		instanceTypes = filterOlderGenerations(nodeClaim, instanceTypes, threshold)
+	}
 	return instanceTypes
 }
 
@@ -451,6 +1096,60 @@ func filterUnwantedSpot(instanceTypes []*cloudprovider.InstanceType) []*cloudpro
 	return instanceTypes
 }
 
+// filterOlderGenerations is used to prefer newer instance generations over older generations of the same instance
+// lineage, architecture and size. An older generation is filtered out when a newer generation costs no more than
+// threshold (as a fraction of the older generation's price) above it, so that the preference never overrides larger
+// price differences. Prices are only compared across offerings that the NodeClaim could launch into.
+func filterOlderGenerations(nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, threshold float64) []*cloudprovider.InstanceType {
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	compatible := func(it *cloudprovider.InstanceType) cloudprovider.Offerings {
+		return it.Offerings.Available().Compatible(requirements)
+	}
+	return lo.Reject(instanceTypes, func(older *cloudprovider.InstanceType, _ int) bool {
+		olderLineage, olderGeneration, ok := instanceTypeGeneration(older)
+		if !ok || len(compatible(older)) == 0 {
+			return false
+		}
+		maxPrice := compatible(older).Cheapest().Price * (1 + threshold)
+		return lo.ContainsBy(instanceTypes, func(newer *cloudprovider.InstanceType) bool {
+			lineage, generation, ok := instanceTypeGeneration(newer)
+			if !ok || lineage != olderLineage || generation <= olderGeneration {
+				return false
+			}
+			available := compatible(newer)
+			return len(available) != 0 && available.Cheapest().Price <= maxPrice
+		})
+	})
+}
+
+// instanceTypeLineage identifies instance types that only differ by their generation
+type instanceTypeLineage struct {
+	// family is the instance family without its generation, e.g. "mi" for m6i
+	family       string
+	architecture string
+	size         string
+}
+
+// instanceTypeGeneration returns the lineage and generation of an instance type from its requirements
+func instanceTypeGeneration(it *cloudprovider.InstanceType) (instanceTypeLineage, int, bool) {
+	family := it.Requirements.Get(v1beta1.LabelInstanceFamily).Values()
+	architecture := it.Requirements.Get(v1.LabelArchStable).Values()
+	size := it.Requirements.Get(v1beta1.LabelInstanceSize).Values()
+	generation := it.Requirements.Get(v1beta1.LabelInstanceGeneration).Values()
+	if len(family) != 1 || len(architecture) != 1 || len(size) != 1 || len(generation) != 1 {
+		return instanceTypeLineage{}, 0, false
+	}
+	gen, err := strconv.Atoi(generation[0])
+	if err != nil || !strings.Contains(family[0], generation[0]) {
+		return instanceTypeLineage{}, 0, false
+	}
+	return instanceTypeLineage{
+		family:       strings.Replace(family[0], generation[0], "", 1),
+		architecture: architecture[0],
+		size:         size[0],
+	}, gen, true
+}
+
 // filterExoticInstanceTypes is used to eliminate less desirable instance types (like GPUs) from the list of possible instance types when
 // a set of more appropriate instance types would work. If a set of more desirable instance types is not found, then the original slice
 // of instance types are returned.
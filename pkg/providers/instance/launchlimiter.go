@@ -0,0 +1,66 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"sync"
+
+	gocache "github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+
+	"github.com/aws/karpenter-provider-aws/pkg/cache"
+)
+
+// launchLimiter paces the instances that are launched for each EC2NodeClass. Launches over the batch size of an
+// EC2NodeClass wait for the next window rather than failing, so that they're launched in a later batch. Limiters are
+// dropped once they've been idle for a while, so that the limiters of deleted EC2NodeClasses don't accumulate.
+type launchLimiter struct {
+	mu       sync.Mutex
+	limiters *gocache.Cache
+}
+
+func newLaunchLimiter() *launchLimiter {
+	limiters := gocache.New(cache.LaunchLimiterTTL, cache.DefaultCleanupInterval)
+	limiters.OnEvicted(func(nodeClassName string, _ interface{}) {
+		launchQueueDepth.Delete(prometheus.Labels{nodeClassLabel: nodeClassName})
+	})
+	return &launchLimiter{limiters: limiters}
+}
+
+// wait blocks until an instance can be launched for the EC2NodeClass, allowing batchSize launches per second. Launches
+// aren't paced if batchSize is 0.
+func (l *launchLimiter) wait(ctx context.Context, nodeClassName string, batchSize int) error {
+	if batchSize <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	var limiter *rate.Limiter
+	if cached, ok := l.limiters.Get(nodeClassName); ok {
+		limiter = cached.(*rate.Limiter)
+	}
+	// The limiter is recreated if the batch size has changed since it was created
+	if limiter == nil || limiter.Burst() != batchSize {
+		limiter = rate.NewLimiter(rate.Limit(batchSize), batchSize)
+	}
+	l.limiters.SetDefault(nodeClassName, limiter)
+	l.mu.Unlock()
+
+	queueDepth := launchQueueDepth.With(prometheus.Labels{nodeClassLabel: nodeClassName})
+	queueDepth.Inc()
+	defer queueDepth.Dec()
+	return limiter.Wait(ctx)
+}
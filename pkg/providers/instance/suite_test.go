@@ -16,13 +16,26 @@ package instance_test
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
@@ -31,6 +44,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/events"
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
 	coretest "sigs.k8s.io/karpenter/pkg/test"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
@@ -63,7 +77,7 @@ var _ = BeforeSuite(func() {
 	ctx = coreoptions.ToContext(ctx, coretest.Options())
 	ctx = options.ToContext(ctx, test.Options())
 	awsEnv = test.NewEnvironment(ctx, env)
-	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.AccountProvider, events.NewRecorder(&record.FakeRecorder{}),
 		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider, awsEnv.SubnetProvider)
 })
 
@@ -199,4 +213,1264 @@ var _ = Describe("InstanceProvider", func() {
 		retrievedIDs := sets.New[string](lo.Map(instances, func(i *instance.Instance, _ int) string { return i.ID })...)
 		Expect(ids.Equal(retrievedIDs)).To(BeTrue())
 	})
+	It("should filter List by the ownership tags of the cluster", func() {
+		_, err := awsEnv.InstanceProvider.List(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(awsEnv.EC2API.DescribeInstancesBehavior.CalledWithInput.Len()).To(Equal(1))
+		input := awsEnv.EC2API.DescribeInstancesBehavior.CalledWithInput.Pop()
+		Expect(input.InstanceIds).To(BeEmpty())
+		tagKeys := lo.FlatMap(lo.Filter(input.Filters, func(f *ec2.Filter, _ int) bool {
+			return aws.StringValue(f.Name) == "tag-key"
+		}), func(f *ec2.Filter, _ int) []string { return aws.StringValueSlice(f.Values) })
+		Expect(tagKeys).To(ConsistOf(
+			corev1beta1.NodePoolLabelKey,
+			v1beta1.LabelNodeClass,
+			fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName),
+		))
+	})
+	It("should not return instances owned by another cluster from List", func() {
+		for _, cluster := range []string{options.FromContext(ctx).ClusterName, "other-cluster"} {
+			instanceID := fake.InstanceID()
+			awsEnv.EC2API.Instances.Store(instanceID, &ec2.Instance{
+				State: &ec2.InstanceState{
+					Name: aws.String(ec2.InstanceStateNameRunning),
+				},
+				Tags: []*ec2.Tag{
+					{Key: aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", cluster)), Value: aws.String("owned")},
+					{Key: aws.String(corev1beta1.NodePoolLabelKey), Value: aws.String("default")},
+					{Key: aws.String(v1beta1.LabelNodeClass), Value: aws.String("default")},
+				},
+				PrivateDnsName: aws.String(fake.PrivateDNSName()),
+				Placement: &ec2.Placement{
+					AvailabilityZone: aws.String(fake.DefaultRegion),
+				},
+				LaunchTime:   aws.Time(time.Now().Add(-time.Minute)),
+				InstanceId:   aws.String(instanceID),
+				InstanceType: aws.String("m5.large"),
+			})
+		}
+		instances, err := awsEnv.InstanceProvider.List(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instances).To(HaveLen(1))
+		Expect(instances[0].Tags).To(HaveKey(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)))
+	})
+	It("should not return terminated instances from List", func() {
+		instanceID := fake.InstanceID()
+		awsEnv.EC2API.Instances.Store(instanceID, &ec2.Instance{
+			State: &ec2.InstanceState{
+				Name: aws.String(ec2.InstanceStateNameTerminated),
+			},
+			Tags: []*ec2.Tag{
+				{Key: aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)), Value: aws.String("owned")},
+				{Key: aws.String(corev1beta1.NodePoolLabelKey), Value: aws.String("default")},
+				{Key: aws.String(v1beta1.LabelNodeClass), Value: aws.String("default")},
+			},
+			InstanceId:   aws.String(instanceID),
+			InstanceType: aws.String("m5.large"),
+		})
+		instances, err := awsEnv.InstanceProvider.List(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instances).To(BeEmpty())
+	})
+	Context("Newer Generations", func() {
+		var m5Large *corecloudprovider.InstanceType
+		// generation returns a copy of m5.large for the given generation of the m category with all offerings at price
+		generation := func(gen int, price float64) *corecloudprovider.InstanceType {
+			name := fmt.Sprintf("m%di.large", gen)
+			requirements := scheduling.NewRequirements()
+			for key, requirement := range m5Large.Requirements {
+				requirements[key] = requirement
+			}
+			requirements[v1.LabelInstanceTypeStable] = scheduling.NewRequirement(v1.LabelInstanceTypeStable, v1.NodeSelectorOpIn, name)
+			requirements[v1beta1.LabelInstanceGeneration] = scheduling.NewRequirement(v1beta1.LabelInstanceGeneration, v1.NodeSelectorOpIn, fmt.Sprint(gen))
+			requirements[v1beta1.LabelInstanceFamily] = scheduling.NewRequirement(v1beta1.LabelInstanceFamily, v1.NodeSelectorOpIn, fmt.Sprintf("m%di", gen))
+			return &corecloudprovider.InstanceType{
+				Name:         name,
+				Requirements: requirements,
+				Offerings: lo.Map(m5Large.Offerings, func(o corecloudprovider.Offering, _ int) corecloudprovider.Offering {
+					o.Price = price
+					return o
+				}),
+				Capacity: m5Large.Capacity,
+				Overhead: m5Large.Overhead,
+			}
+		}
+		launchedInstanceTypes := func(instanceTypes ...*corecloudprovider.InstanceType) []string {
+			GinkgoHelper()
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			return sets.List(sets.New(lo.FlatMap(createFleetInput.LaunchTemplateConfigs, func(ltc *ec2.FleetLaunchTemplateConfigRequest, _ int) []string {
+				return lo.Map(ltc.Overrides, func(o *ec2.FleetLaunchTemplateOverridesRequest, _ int) string { return aws.StringValue(o.InstanceType) })
+			})...))
+		}
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				NewerGenerationPriceThreshold: lo.ToPtr(0.05),
+			}))
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			var ok bool
+			m5Large, ok = lo.Find(instanceTypes, func(i *corecloudprovider.InstanceType) bool { return i.Name == "m5.large" })
+			Expect(ok).To(BeTrue())
+		})
+		It("should only launch the newest generation when generations have the same price", func() {
+			Expect(launchedInstanceTypes(generation(5, 1), generation(6, 1), generation(7, 1))).To(ConsistOf("m7i.large"))
+		})
+		It("should launch the newer generation when it is within the price threshold", func() {
+			Expect(launchedInstanceTypes(generation(5, 1), generation(6, 1.04))).To(ConsistOf("m6i.large"))
+		})
+		It("should keep the older generation when the newer generation is outside of the price threshold", func() {
+			Expect(launchedInstanceTypes(generation(5, 1), generation(6, 1.1))).To(ConsistOf("m5i.large", "m6i.large"))
+		})
+		It("should keep the older generation when the newer generation is cheaper in a different size", func() {
+			larger := generation(6, 1)
+			larger.Name = "m6i.xlarge"
+			larger.Requirements[v1.LabelInstanceTypeStable] = scheduling.NewRequirement(v1.LabelInstanceTypeStable, v1.NodeSelectorOpIn, larger.Name)
+			larger.Requirements[v1beta1.LabelInstanceSize] = scheduling.NewRequirement(v1beta1.LabelInstanceSize, v1.NodeSelectorOpIn, "xlarge")
+			Expect(launchedInstanceTypes(generation(5, 1), larger)).To(ConsistOf("m5i.large", "m6i.xlarge"))
+		})
+		It("should keep the older generation when the newer generation is only cheaper for a capacity type the nodeclaim can't use", func() {
+			nodeClaim.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{{
+				NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.CapacityTypeOnDemand}},
+			}}
+			newer := generation(6, 2)
+			newer.Offerings = lo.Map(newer.Offerings, func(o corecloudprovider.Offering, _ int) corecloudprovider.Offering {
+				if o.CapacityType == corev1beta1.CapacityTypeSpot {
+					o.Price = 0.5
+				}
+				return o
+			})
+			Expect(launchedInstanceTypes(generation(5, 1), newer)).To(ConsistOf("m5i.large", "m6i.large"))
+		})
+		It("should keep the older generation when the newer generation has a different architecture", func() {
+			newer := generation(6, 1)
+			newer.Requirements[v1.LabelArchStable] = scheduling.NewRequirement(v1.LabelArchStable, v1.NodeSelectorOpIn, corev1beta1.ArchitectureArm64)
+			Expect(launchedInstanceTypes(generation(5, 1), newer)).To(ConsistOf("m5i.large", "m6i.large"))
+		})
+		It("should not prefer newer generations when the price threshold is 0", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			Expect(launchedInstanceTypes(generation(5, 1), generation(6, 1), generation(7, 1))).To(ConsistOf("m5i.large", "m6i.large", "m7i.large"))
+		})
+	})
+	Context("Tag Mutator", func() {
+		var provider *instance.DefaultProvider
+		var instanceTypes []*corecloudprovider.InstanceType
+		BeforeEach(func() {
+			provider = instance.NewDefaultProvider(ctx, "", awsEnv.EC2API, awsEnv.UnavailableOfferingsCache, awsEnv.InstanceTypesProvider,
+				awsEnv.SubnetProvider, awsEnv.LaunchTemplateProvider, awsEnv.PlacementScoreProvider, classificationTagMutator("confidential"))
+			nodeClass.Spec.Tags = map[string]string{"data-classification": "public", "team": "infra"}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.large" })
+		})
+		It("should apply the tags of the mutator at launch", func() {
+			instance, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.Tags).To(HaveKeyWithValue("data-classification", "confidential"))
+			Expect(instance.Tags).To(HaveKeyWithValue("team", "infra"))
+
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			for _, tagSpecification := range createFleetInput.TagSpecifications {
+				Expect(tagSpecification.Tags).To(ContainElement(&ec2.Tag{Key: aws.String("data-classification"), Value: aws.String("confidential")}))
+			}
+		})
+		It("should not allow the mutator to override the tags that Karpenter manages instances with", func() {
+			instance, err := provider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.Tags).To(HaveKeyWithValue(corev1beta1.ManagedByAnnotationKey, options.FromContext(ctx).ClusterName))
+			Expect(instance.Tags).To(HaveKeyWithValue(corev1beta1.NodePoolLabelKey, nodePool.Name))
+			Expect(instance.Tags).To(HaveKeyWithValue(v1beta1.LabelNodeClass, nodeClass.Name))
+		})
+		It("should not change the tags when the mutator isn't set", func() {
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.Tags).To(HaveKeyWithValue("data-classification", "public"))
+		})
+	})
+	Context("Label Tags", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		BeforeEach(func() {
+			nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{
+				"example.com/team":        "infra",
+				"example.com/cost-center": "cc-1234",
+			})
+			nodeClass.Spec.LabelTags = map[string]string{
+				"example.com/team":        "team",
+				"example.com/cost-center": "billing/cost-center",
+			}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.large" })
+		})
+		It("should copy the configured labels into the instance tags", func() {
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.Tags).To(HaveKeyWithValue("team", "infra"))
+			Expect(instance.Tags).To(HaveKeyWithValue("billing/cost-center", "cc-1234"))
+
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			for _, tagSpecification := range createFleetInput.TagSpecifications {
+				Expect(tagSpecification.Tags).To(ContainElement(&ec2.Tag{Key: aws.String("team"), Value: aws.String("infra")}))
+			}
+		})
+		It("should ignore configured labels that the nodeclaim doesn't have", func() {
+			nodeClass.Spec.LabelTags["example.com/missing"] = "missing"
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.Tags).ToNot(HaveKey("missing"))
+		})
+		It("should not override the tags of the nodeclass or the tags that Karpenter manages instances with", func() {
+			nodeClass.Spec.Tags = map[string]string{"team": "platform"}
+			nodeClass.Spec.LabelTags[corev1beta1.NodePoolLabelKey] = corev1beta1.ManagedByAnnotationKey
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.Tags).To(HaveKeyWithValue("team", "platform"))
+			Expect(instance.Tags).To(HaveKeyWithValue(corev1beta1.ManagedByAnnotationKey, options.FromContext(ctx).ClusterName))
+		})
+		It("should sanitize label values into valid tag values", func() {
+			nodeClaim.Labels["example.com/team"] = "infra#ops"
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.Tags).To(HaveKeyWithValue("team", "infra_ops"))
+		})
+		It("should skip label tags that would exceed the EC2 tag limit", func() {
+			nodeClass.Spec.Tags = map[string]string{}
+			for i := 0; i < 42; i++ {
+				nodeClass.Spec.Tags[fmt.Sprintf("tag-%d", i)] = "value"
+			}
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			// 42 nodeclass tags and 4 static tags leave room for a single label tag, since 3 tags are added after launch
+			Expect(instance.Tags).To(HaveKeyWithValue("billing/cost-center", "cc-1234"))
+			Expect(instance.Tags).ToNot(HaveKey("team"))
+			Expect(len(instance.Tags)).To(BeNumerically("<=", 47))
+		})
+	})
+	Context("Multiple VPCs", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		BeforeEach(func() {
+			nodeClass.Status.Subnets = []v1beta1.Subnet{
+				{ID: "subnet-test1", Zone: "test-zone-1a", VPCID: "vpc-test1"},
+				{ID: "subnet-test2", Zone: "test-zone-1b", VPCID: "vpc-test1"},
+				{ID: "subnet-test3", Zone: "test-zone-1c", VPCID: "vpc-test2"},
+			}
+			nodeClass.Status.SecurityGroups = []v1beta1.SecurityGroup{
+				{ID: "sg-test1", VPCID: "vpc-test1"},
+				{ID: "sg-test2", VPCID: "vpc-test2"},
+			}
+			nodeClaim.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.CapacityTypeOnDemand}}},
+			}
+		})
+		launch := func() (*instance.Instance, error) {
+			GinkgoHelper()
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.large" })
+			return awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+		}
+		subnetIDs := func(createFleetInput *ec2.CreateFleetInput) []string {
+			return lo.Uniq(lo.FlatMap(createFleetInput.LaunchTemplateConfigs, func(ltc *ec2.FleetLaunchTemplateConfigRequest, _ int) []string {
+				return lo.Map(ltc.Overrides, func(o *ec2.FleetLaunchTemplateOverridesRequest, _ int) string { return aws.StringValue(o.SubnetId) })
+			}))
+		}
+		securityGroupIDs := func() []string {
+			ids := sets.New[string]()
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(input *ec2.CreateLaunchTemplateInput) {
+				ids.Insert(aws.StringValueSlice(input.LaunchTemplateData.SecurityGroupIds)...)
+			})
+			return sets.List(ids)
+		}
+		It("should only launch with the subnets and security groups of a single vpc", func() {
+			_, err := launch()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			Expect(subnetIDs(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop())).To(ConsistOf("subnet-test1", "subnet-test2"))
+			Expect(securityGroupIDs()).To(ConsistOf("sg-test1"))
+		})
+		It("should launch into the vpc with subnets in the zones of the nodeclaim", func() {
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, corev1beta1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1c"}},
+			})
+			_, err := launch()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			Expect(subnetIDs(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop())).To(ConsistOf("subnet-test3"))
+			Expect(securityGroupIDs()).To(ConsistOf("sg-test2"))
+		})
+		It("should fall back to the next vpc when the first vpc doesn't have capacity", func() {
+			awsEnv.EC2API.InsufficientCapacityPools.Set([]fake.CapacityPool{
+				{CapacityType: corev1beta1.CapacityTypeOnDemand, InstanceType: "m5.large", Zone: "test-zone-1a"},
+				{CapacityType: corev1beta1.CapacityTypeOnDemand, InstanceType: "m5.large", Zone: "test-zone-1b"},
+			})
+			instance, err := launch()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.SubnetID).To(Equal("subnet-test3"))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(2))
+			Expect(subnetIDs(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop())).To(ConsistOf("subnet-test3"))
+			Expect(subnetIDs(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop())).To(ConsistOf("subnet-test1", "subnet-test2"))
+		})
+		It("should not retry the capacity pools that failed in the previous vpc", func() {
+			nodeClass.Status.Subnets = append(nodeClass.Status.Subnets, v1beta1.Subnet{ID: "subnet-test4", Zone: "test-zone-1a", VPCID: "vpc-test2"})
+			awsEnv.EC2API.InsufficientCapacityPools.Set([]fake.CapacityPool{
+				{CapacityType: corev1beta1.CapacityTypeOnDemand, InstanceType: "m5.large", Zone: "test-zone-1a"},
+				{CapacityType: corev1beta1.CapacityTypeOnDemand, InstanceType: "m5.large", Zone: "test-zone-1b"},
+			})
+			instance, err := launch()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.SubnetID).To(Equal("subnet-test3"))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(2))
+			Expect(subnetIDs(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop())).To(ConsistOf("subnet-test3"))
+		})
+		It("should not fall back to the next vpc when every capacity pool failed in the previous vpc", func() {
+			nodeClass.Status.Subnets = []v1beta1.Subnet{
+				{ID: "subnet-test1", Zone: "test-zone-1a", VPCID: "vpc-test1"},
+				{ID: "subnet-test3", Zone: "test-zone-1a", VPCID: "vpc-test2"},
+			}
+			awsEnv.EC2API.InsufficientCapacityPools.Set([]fake.CapacityPool{
+				{CapacityType: corev1beta1.CapacityTypeOnDemand, InstanceType: "m5.large", Zone: "test-zone-1a"},
+			})
+			_, err := launch()
+			Expect(corecloudprovider.IsInsufficientCapacityError(err)).To(BeTrue())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+		})
+		It("should not fall back to the next vpc when the launch fails for another reason", func() {
+			awsEnv.EC2API.CreateFleetBehavior.Error.Set(fmt.Errorf("failed to create fleet"))
+			_, err := launch()
+			Expect(err).To(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+		})
+		It("should fail to launch when no vpc has both subnets and security groups", func() {
+			nodeClass.Status.SecurityGroups = []v1beta1.SecurityGroup{{ID: "sg-test3", VPCID: "vpc-test3"}}
+			_, err := launch()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("no vpc has both subnets and security groups"))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(0))
+		})
+		It("should launch with subnets and security groups whose vpc isn't known", func() {
+			nodeClass.Status.SecurityGroups = []v1beta1.SecurityGroup{{ID: "sg-test1"}}
+			_, err := launch()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			Expect(subnetIDs(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop())).To(ConsistOf("subnet-test1", "subnet-test2"))
+			Expect(securityGroupIDs()).To(ConsistOf("sg-test1"))
+		})
+	})
+	Context("Spot Max Price", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		launch := func(capacityType string) *ec2.CreateFleetInput {
+			GinkgoHelper()
+			nodeClaim.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{capacityType}}},
+			}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.large" })
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			return awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+		}
+		maxPrices := func(createFleetInput *ec2.CreateFleetInput) []*string {
+			return lo.FlatMap(createFleetInput.LaunchTemplateConfigs, func(ltc *ec2.FleetLaunchTemplateConfigRequest, _ int) []*string {
+				return lo.Map(ltc.Overrides, func(o *ec2.FleetLaunchTemplateOverridesRequest, _ int) *string { return o.MaxPrice })
+			})
+		}
+		It("should not set a max price when spotMaxPrice isn't set", func() {
+			Expect(maxPrices(launch(corev1beta1.CapacityTypeSpot))).To(HaveEach(BeNil()))
+		})
+		It("should bid an absolute price for spot instances", func() {
+			nodeClass.Spec.SpotMaxPrice = &v1beta1.SpotMaxPrice{Price: aws.String("0.05")}
+			prices := maxPrices(launch(corev1beta1.CapacityTypeSpot))
+			Expect(prices).ToNot(BeEmpty())
+			Expect(prices).To(HaveEach(Equal(aws.String("0.05"))))
+		})
+		It("should bid a percentage of the on-demand price for spot instances", func() {
+			nodeClass.Spec.SpotMaxPrice = &v1beta1.SpotMaxPrice{OnDemandPercentage: aws.Int64(50)}
+			onDemandPrice, ok := awsEnv.PricingProvider.OnDemandPrice("m5.large")
+			Expect(ok).To(BeTrue())
+			prices := maxPrices(launch(corev1beta1.CapacityTypeSpot))
+			Expect(prices).ToNot(BeEmpty())
+			for _, price := range prices {
+				Expect(strconv.ParseFloat(aws.StringValue(price), 64)).To(BeNumerically("~", onDemandPrice/2, 1e-5))
+			}
+		})
+		It("should not set a max price for on-demand instances", func() {
+			nodeClass.Spec.SpotMaxPrice = &v1beta1.SpotMaxPrice{Price: aws.String("0.05")}
+			prices := maxPrices(launch(corev1beta1.CapacityTypeOnDemand))
+			Expect(prices).ToNot(BeEmpty())
+			Expect(prices).To(HaveEach(BeNil()))
+		})
+	})
+	Context("On-Demand Base Capacity", func() {
+		launch := func(capacityTypes ...string) (*ec2.CreateFleetInput, *instance.Instance) {
+			GinkgoHelper()
+			nodeClaim.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: capacityTypes}},
+			}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.large" })
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			return awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop(), instance
+		}
+		It("should not request on-demand capacity alongside spot when it isn't set", func() {
+			createFleetInput, instance := launch(corev1beta1.CapacityTypeSpot, corev1beta1.CapacityTypeOnDemand)
+			Expect(createFleetInput.TargetCapacitySpecification.DefaultTargetCapacityType).To(Equal(aws.String(corev1beta1.CapacityTypeSpot)))
+			Expect(createFleetInput.TargetCapacitySpecification.OnDemandTargetCapacity).To(BeNil())
+			Expect(createFleetInput.TargetCapacitySpecification.SpotTargetCapacity).To(BeNil())
+			Expect(createFleetInput.OnDemandOptions).To(BeNil())
+			Expect(instance.CapacityType).To(Equal(corev1beta1.CapacityTypeSpot))
+		})
+		It("should split the fleet request into on-demand and spot capacity", func() {
+			nodeClass.Spec.OnDemandBaseCapacity = aws.Int64(1)
+			createFleetInput, instance := launch(corev1beta1.CapacityTypeSpot, corev1beta1.CapacityTypeOnDemand)
+			Expect(createFleetInput.TargetCapacitySpecification.DefaultTargetCapacityType).To(Equal(aws.String(corev1beta1.CapacityTypeSpot)))
+			Expect(createFleetInput.TargetCapacitySpecification.TotalTargetCapacity).To(Equal(aws.Int64(1)))
+			Expect(createFleetInput.TargetCapacitySpecification.OnDemandTargetCapacity).To(Equal(aws.Int64(1)))
+			Expect(createFleetInput.TargetCapacitySpecification.SpotTargetCapacity).To(Equal(aws.Int64(0)))
+			Expect(createFleetInput.SpotOptions).ToNot(BeNil())
+			Expect(createFleetInput.OnDemandOptions).ToNot(BeNil())
+			Expect(instance.CapacityType).To(Equal(corev1beta1.CapacityTypeOnDemand))
+		})
+		It("should only include each offering once in the overrides of a split fleet request", func() {
+			nodeClass.Spec.OnDemandBaseCapacity = aws.Int64(1)
+			createFleetInput, _ := launch(corev1beta1.CapacityTypeSpot, corev1beta1.CapacityTypeOnDemand)
+			overrides := lo.FlatMap(createFleetInput.LaunchTemplateConfigs, func(ltc *ec2.FleetLaunchTemplateConfigRequest, _ int) []string {
+				return lo.Map(ltc.Overrides, func(o *ec2.FleetLaunchTemplateOverridesRequest, _ int) string {
+					return aws.StringValue(o.InstanceType) + "/" + aws.StringValue(o.SubnetId)
+				})
+			})
+			Expect(overrides).ToNot(BeEmpty())
+			Expect(overrides).To(Equal(lo.Uniq(overrides)))
+		})
+		It("should not register nodes with a capacity type when the fleet request is split", func() {
+			nodeClass.Spec.OnDemandBaseCapacity = aws.Int64(1)
+			launch(corev1beta1.CapacityTypeSpot, corev1beta1.CapacityTypeOnDemand)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				userData, err := base64.StdEncoding.DecodeString(aws.StringValue(ltInput.LaunchTemplateData.UserData))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(userData)).ToNot(ContainSubstring(corev1beta1.CapacityTypeLabelKey))
+			})
+		})
+		It("should split the fleet request when only the on-demand percentage is set", func() {
+			nodeClass.Spec.OnDemandPercentageAboveBaseCapacity = aws.Int64(50)
+			createFleetInput, instance := launch(corev1beta1.CapacityTypeSpot, corev1beta1.CapacityTypeOnDemand)
+			Expect(createFleetInput.TargetCapacitySpecification.DefaultTargetCapacityType).To(Equal(aws.String(corev1beta1.CapacityTypeSpot)))
+			Expect(createFleetInput.TargetCapacitySpecification.TotalTargetCapacity).To(Equal(aws.Int64(1)))
+			// A launch of a single NodeClaim rounds the on-demand share of the request up to the whole request
+			Expect(createFleetInput.TargetCapacitySpecification.OnDemandTargetCapacity).To(Equal(aws.Int64(1)))
+			Expect(createFleetInput.TargetCapacitySpecification.SpotTargetCapacity).To(Equal(aws.Int64(0)))
+			Expect(createFleetInput.SpotOptions).ToNot(BeNil())
+			Expect(createFleetInput.OnDemandOptions).ToNot(BeNil())
+			Expect(instance.CapacityType).To(Equal(corev1beta1.CapacityTypeOnDemand))
+		})
+		It("should not split the fleet request when the on-demand percentage is 0 and there's no base", func() {
+			nodeClass.Spec.OnDemandPercentageAboveBaseCapacity = aws.Int64(0)
+			createFleetInput, instance := launch(corev1beta1.CapacityTypeSpot, corev1beta1.CapacityTypeOnDemand)
+			Expect(createFleetInput.TargetCapacitySpecification.OnDemandTargetCapacity).To(BeNil())
+			Expect(createFleetInput.TargetCapacitySpecification.SpotTargetCapacity).To(BeNil())
+			Expect(instance.CapacityType).To(Equal(corev1beta1.CapacityTypeSpot))
+		})
+		DescribeTable("should not split the fleet request by the on-demand percentage when the nodepool only allows a single capacity type", func(capacityType string) {
+			nodeClass.Spec.OnDemandPercentageAboveBaseCapacity = aws.Int64(50)
+			createFleetInput, instance := launch(capacityType)
+			Expect(createFleetInput.TargetCapacitySpecification.DefaultTargetCapacityType).To(Equal(aws.String(capacityType)))
+			Expect(createFleetInput.TargetCapacitySpecification.OnDemandTargetCapacity).To(BeNil())
+			Expect(createFleetInput.TargetCapacitySpecification.SpotTargetCapacity).To(BeNil())
+			Expect(instance.CapacityType).To(Equal(capacityType))
+		},
+			Entry("spot", corev1beta1.CapacityTypeSpot),
+			Entry("on-demand", corev1beta1.CapacityTypeOnDemand),
+		)
+		DescribeTable("should not split the fleet request when the nodepool only allows a single capacity type", func(capacityType string) {
+			nodeClass.Spec.OnDemandBaseCapacity = aws.Int64(1)
+			createFleetInput, instance := launch(capacityType)
+			Expect(createFleetInput.TargetCapacitySpecification.DefaultTargetCapacityType).To(Equal(aws.String(capacityType)))
+			Expect(createFleetInput.TargetCapacitySpecification.OnDemandTargetCapacity).To(BeNil())
+			Expect(createFleetInput.TargetCapacitySpecification.SpotTargetCapacity).To(BeNil())
+			Expect(instance.CapacityType).To(Equal(capacityType))
+		},
+			Entry("spot", corev1beta1.CapacityTypeSpot),
+			Entry("on-demand", corev1beta1.CapacityTypeOnDemand),
+		)
+	})
+	Context("Spot Placement Score", func() {
+		launch := func(capacityType string) *ec2.CreateFleetInput {
+			GinkgoHelper()
+			nodeClaim.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{capacityType}}},
+			}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.large" })
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			return awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+		}
+		overrides := func(createFleetInput *ec2.CreateFleetInput) []*ec2.FleetLaunchTemplateOverridesRequest {
+			return lo.FlatMap(createFleetInput.LaunchTemplateConfigs, func(ltc *ec2.FleetLaunchTemplateConfigRequest, _ int) []*ec2.FleetLaunchTemplateOverridesRequest {
+				return ltc.Overrides
+			})
+		}
+		zones := func(createFleetInput *ec2.CreateFleetInput) []string {
+			return lo.Uniq(lo.Map(overrides(createFleetInput), func(o *ec2.FleetLaunchTemplateOverridesRequest, _ int) string {
+				return aws.StringValue(o.AvailabilityZone)
+			}))
+		}
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				SpotPlacementScore: lo.ToPtr(true),
+			}))
+			for i := range nodeClass.Status.Subnets {
+				nodeClass.Status.Subnets[i].ZoneID = strings.ReplaceAll(nodeClass.Status.Subnets[i].Zone, "-", "")
+			}
+			awsEnv.EC2API.GetSpotPlacementScoresBehavior.Output.Set(&ec2.GetSpotPlacementScoresOutput{
+				SpotPlacementScores: []*ec2.SpotPlacementScore{
+					{AvailabilityZoneId: aws.String("testzone1a"), Region: aws.String(fake.DefaultRegion), Score: aws.Int64(3)},
+					{AvailabilityZoneId: aws.String("testzone1b"), Region: aws.String(fake.DefaultRegion), Score: aws.Int64(9)},
+					{AvailabilityZoneId: aws.String("testzone1c"), Region: aws.String(fake.DefaultRegion), Score: aws.Int64(9)},
+				},
+			})
+			Expect(awsEnv.PlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+			awsEnv.EC2API.GetSpotPlacementScoresBehavior.Reset()
+		})
+		It("should prioritize the highest scoring zones for spot instances", func() {
+			createFleetInput := launch(corev1beta1.CapacityTypeSpot)
+			Expect(aws.StringValue(createFleetInput.SpotOptions.AllocationStrategy)).To(Equal(ec2.SpotAllocationStrategyCapacityOptimizedPrioritized))
+			Expect(zones(createFleetInput)).To(ConsistOf("test-zone-1a", "test-zone-1b", "test-zone-1c"))
+			for _, override := range overrides(createFleetInput) {
+				Expect(override.Priority).ToNot(BeNil())
+				if aws.StringValue(override.AvailabilityZone) == "test-zone-1a" {
+					Expect(aws.Float64Value(override.Priority)).To(Equal(float64(2)))
+				}
+			}
+		})
+		It("should not request scores when launching", func() {
+			launch(corev1beta1.CapacityTypeSpot)
+			launch(corev1beta1.CapacityTypeSpot)
+			Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.Calls()).To(Equal(0))
+		})
+		It("should not prioritize zones for on-demand instances", func() {
+			createFleetInput := launch(corev1beta1.CapacityTypeOnDemand)
+			Expect(zones(createFleetInput)).To(ContainElements("test-zone-1a", "test-zone-1b", "test-zone-1c"))
+			Expect(overrides(createFleetInput)).To(HaveEach(HaveField("Priority", BeNil())))
+		})
+		It("should launch with the price capacity optimized strategy when none of the zones have a score", func() {
+			awsEnv.PlacementScoreProvider.Reset()
+			createFleetInput := launch(corev1beta1.CapacityTypeSpot)
+			Expect(aws.StringValue(createFleetInput.SpotOptions.AllocationStrategy)).To(Equal(ec2.SpotAllocationStrategyPriceCapacityOptimized))
+			Expect(overrides(createFleetInput)).To(HaveEach(HaveField("Priority", BeNil())))
+		})
+		It("should not prioritize zones when the option is disabled", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			createFleetInput := launch(corev1beta1.CapacityTypeSpot)
+			Expect(aws.StringValue(createFleetInput.SpotOptions.AllocationStrategy)).To(Equal(ec2.SpotAllocationStrategyPriceCapacityOptimized))
+			Expect(overrides(createFleetInput)).To(HaveEach(HaveField("Priority", BeNil())))
+		})
+	})
+	Context("Launch Batch Size", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		// launch creates instances for count NodeClaims of the nodeClass concurrently and returns how long it took
+		launch := func(nodeClass *v1beta1.EC2NodeClass, count int) time.Duration {
+			GinkgoHelper()
+			start := time.Now()
+			var wg sync.WaitGroup
+			for i := 0; i < count; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim.DeepCopy(), instanceTypes)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(instance).ToNot(BeNil())
+				}()
+			}
+			wg.Wait()
+			return time.Since(start)
+		}
+		queueDepth := func(nodeClass *v1beta1.EC2NodeClass) float64 {
+			GinkgoHelper()
+			metric, ok := FindMetricWithLabelValues("karpenter_cloudprovider_instance_launch_queue_depth", map[string]string{
+				"nodeclass": nodeClass.Name,
+			})
+			Expect(ok).To(BeTrue())
+			return metric.GetGauge().GetValue()
+		}
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				MaxNodeClassLaunchBatchSize: lo.ToPtr(2),
+			}))
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.large" })
+		})
+		It("should queue launches over the batch size for the next batch without dropping them", func() {
+			// The first 2 launches are in the first batch, the remaining 4 launches are let through at 2 per second
+			Expect(launch(nodeClass, 6)).To(BeNumerically(">=", time.Millisecond*1800))
+			Expect(queueDepth(nodeClass)).To(BeNumerically("==", 0))
+		})
+		It("should report the launches waiting for the next batch", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				MaxNodeClassLaunchBatchSize: lo.ToPtr(1),
+			}))
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				launch(nodeClass, 4)
+			}()
+			Eventually(func() float64 { return queueDepth(nodeClass) }).Should(BeNumerically(">=", 1))
+			Eventually(done).WithTimeout(time.Second * 10).Should(BeClosed())
+			Expect(queueDepth(nodeClass)).To(BeNumerically("==", 0))
+		})
+		It("should pace the launches of each nodeclass separately", func() {
+			nodeClass2 := test.EC2NodeClass(v1beta1.EC2NodeClass{Status: nodeClass.Status})
+			ExpectApplied(ctx, env.Client, nodeClass2)
+			Expect(launch(nodeClass, 2)).To(BeNumerically("<", time.Millisecond*500))
+			Expect(launch(nodeClass2, 2)).To(BeNumerically("<", time.Millisecond*500))
+		})
+		It("should not pace launches when the batch size is 0", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			Expect(launch(nodeClass, 10)).To(BeNumerically("<", time.Millisecond*500))
+		})
+	})
+	Context("Client Token", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		launch := func() *ec2.CreateFleetInput {
+			GinkgoHelper()
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			return awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+		}
+		instanceTags := func(createFleetInput *ec2.CreateFleetInput) map[string]string {
+			GinkgoHelper()
+			spec, ok := lo.Find(createFleetInput.TagSpecifications, func(s *ec2.TagSpecification) bool {
+				return aws.StringValue(s.ResourceType) == ec2.ResourceTypeInstance
+			})
+			Expect(ok).To(BeTrue())
+			return lo.SliceToMap(spec.Tags, func(t *ec2.Tag) (string, string) { return aws.StringValue(t.Key), aws.StringValue(t.Value) })
+		}
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				CreateFleetClientToken: lo.ToPtr(true),
+			}))
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("should set a client token derived from the nodeclaim UID", func() {
+			createFleetInput := launch()
+			Expect(nodeClaim.UID).ToNot(BeEmpty())
+			Expect(aws.StringValue(createFleetInput.ClientToken)).To(Equal(string(nodeClaim.UID)))
+		})
+		It("should use the same client token when the launch for a nodeclaim is retried", func() {
+			first := launch()
+			second := launch()
+			Expect(first.ClientToken).ToNot(BeNil())
+			Expect(aws.StringValue(second.ClientToken)).To(Equal(aws.StringValue(first.ClientToken)))
+		})
+		It("should use different client tokens for different nodeclaims", func() {
+			first := launch()
+			nodeClaim = coretest.NodeClaim(corev1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						corev1beta1.NodePoolLabelKey: nodePool.Name,
+					},
+				},
+				Spec: corev1beta1.NodeClaimSpec{
+					NodeClassRef: &corev1beta1.NodeClassReference{
+						Name: nodeClass.Name,
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			second := launch()
+			Expect(aws.StringValue(second.ClientToken)).ToNot(Equal(aws.StringValue(first.ClientToken)))
+		})
+		It("should tag launched instances with the nodeclaim UID", func() {
+			createFleetInput := launch()
+			Expect(instanceTags(createFleetInput)).To(HaveKeyWithValue(v1beta1.TagNodeClaimUID, string(nodeClaim.UID)))
+		})
+		It("should not tag launch templates with the nodeclaim UID", func() {
+			launch()
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(input *ec2.CreateLaunchTemplateInput) {
+				for _, spec := range input.TagSpecifications {
+					Expect(lo.Map(spec.Tags, func(t *ec2.Tag, _ int) string { return aws.StringValue(t.Key) })).ToNot(ContainElement(v1beta1.TagNodeClaimUID))
+				}
+			})
+		})
+		It("should adopt an existing instance launched for the nodeclaim instead of launching another", func() {
+			existing := &ec2.Instance{
+				InstanceId:   aws.String(fake.InstanceID()),
+				InstanceType: aws.String("m5.large"),
+				ImageId:      aws.String("ami-test1"),
+				Placement:    &ec2.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+				State:        &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+				Tags: []*ec2.Tag{
+					{Key: aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)), Value: aws.String("owned")},
+					{Key: aws.String(v1beta1.TagNodeClaimUID), Value: aws.String(string(nodeClaim.UID))},
+				},
+			}
+			awsEnv.EC2API.Instances.Store(aws.StringValue(existing.InstanceId), existing)
+
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.ID).To(Equal(aws.StringValue(existing.InstanceId)))
+			Expect(instance.Type).To(Equal("m5.large"))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(0))
+		})
+		It("should launch an instance when the instance launched for the nodeclaim is terminated", func() {
+			existing := &ec2.Instance{
+				InstanceId: aws.String(fake.InstanceID()),
+				State:      &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameTerminated)},
+				Tags: []*ec2.Tag{
+					{Key: aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)), Value: aws.String("owned")},
+					{Key: aws.String(v1beta1.TagNodeClaimUID), Value: aws.String(string(nodeClaim.UID))},
+				},
+			}
+			awsEnv.EC2API.Instances.Store(aws.StringValue(existing.InstanceId), existing)
+
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.ID).ToNot(Equal(aws.StringValue(existing.InstanceId)))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+		})
+		It("should not adopt instances launched for other nodeclaims", func() {
+			existing := &ec2.Instance{
+				InstanceId:   aws.String(fake.InstanceID()),
+				InstanceType: aws.String("m5.large"),
+				Placement:    &ec2.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+				State:        &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+				Tags: []*ec2.Tag{
+					{Key: aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)), Value: aws.String("owned")},
+					{Key: aws.String(v1beta1.TagNodeClaimUID), Value: aws.String("other-uid")},
+				},
+			}
+			awsEnv.EC2API.Instances.Store(aws.StringValue(existing.InstanceId), existing)
+
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.ID).ToNot(Equal(aws.StringValue(existing.InstanceId)))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+		})
+		It("should not set a client token when disabled", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			createFleetInput := launch()
+			Expect(createFleetInput.ClientToken).To(BeNil())
+			Expect(instanceTags(createFleetInput)).ToNot(HaveKey(v1beta1.TagNodeClaimUID))
+		})
+	})
+	Context("On-Demand Allocation Strategy", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		launch := func() *ec2.CreateFleetInput {
+			GinkgoHelper()
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			return awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+		}
+		// overridesByPriority returns the overrides of the launch, ordered from the highest to the lowest priority
+		overridesByPriority := func(createFleetInput *ec2.CreateFleetInput) []*ec2.FleetLaunchTemplateOverridesRequest {
+			GinkgoHelper()
+			overrides := lo.FlatMap(createFleetInput.LaunchTemplateConfigs, func(ltc *ec2.FleetLaunchTemplateConfigRequest, _ int) []*ec2.FleetLaunchTemplateOverridesRequest {
+				return ltc.Overrides
+			})
+			Expect(overrides).ToNot(BeEmpty())
+			for _, override := range overrides {
+				Expect(override.Priority).ToNot(BeNil())
+			}
+			sort.Slice(overrides, func(i, j int) bool {
+				return aws.Float64Value(overrides[i].Priority) < aws.Float64Value(overrides[j].Priority)
+			})
+			return overrides
+		}
+		price := func(override *ec2.FleetLaunchTemplateOverridesRequest) float64 {
+			GinkgoHelper()
+			it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool {
+				return it.Name == aws.StringValue(override.InstanceType)
+			})
+			Expect(ok).To(BeTrue())
+			offering, ok := it.Offerings.Get(corev1beta1.CapacityTypeOnDemand, aws.StringValue(override.AvailabilityZone))
+			Expect(ok).To(BeTrue())
+			return offering.Price
+		}
+		BeforeEach(func() {
+			nodeClaim.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.CapacityTypeOnDemand}}},
+			}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("should use the lowest-price strategy without priorities by default", func() {
+			createFleetInput := launch()
+			Expect(aws.StringValue(createFleetInput.OnDemandOptions.AllocationStrategy)).To(Equal(ec2.FleetOnDemandAllocationStrategyLowestPrice))
+			for _, ltc := range createFleetInput.LaunchTemplateConfigs {
+				for _, override := range ltc.Overrides {
+					Expect(override.Priority).To(BeNil())
+				}
+			}
+		})
+		It("should prioritize overrides by price with the prioritized strategy", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				OnDemandAllocationStrategy: lo.ToPtr(ec2.FleetOnDemandAllocationStrategyPrioritized),
+			}))
+			createFleetInput := launch()
+			Expect(aws.StringValue(createFleetInput.OnDemandOptions.AllocationStrategy)).To(Equal(ec2.FleetOnDemandAllocationStrategyPrioritized))
+			overrides := overridesByPriority(createFleetInput)
+			for i := 1; i < len(overrides); i++ {
+				Expect(price(overrides[i-1])).To(BeNumerically("<=", price(overrides[i])))
+			}
+		})
+		It("should prioritize overrides by the family priority list before price", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				OnDemandAllocationStrategy: lo.ToPtr(ec2.FleetOnDemandAllocationStrategyPrioritized),
+				OnDemandFamilyPriority:     lo.ToPtr("m5,c6g"),
+			}))
+			overrides := overridesByPriority(launch())
+			rank := func(override *ec2.FleetLaunchTemplateOverridesRequest) int {
+				family := strings.Split(aws.StringValue(override.InstanceType), ".")[0]
+				return lo.ValueOr(map[string]int{"m5": 0, "c6g": 1}, family, 2)
+			}
+			Expect(rank(overrides[0])).To(Equal(0))
+			for i := 1; i < len(overrides); i++ {
+				Expect(rank(overrides[i-1])).To(BeNumerically("<=", rank(overrides[i])))
+				if rank(overrides[i-1]) == rank(overrides[i]) {
+					Expect(price(overrides[i-1])).To(BeNumerically("<=", price(overrides[i])))
+				}
+			}
+		})
+		It("should prioritize overrides by the instance type priority of the nodeclaim before the family priority list", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				OnDemandAllocationStrategy: lo.ToPtr(ec2.FleetOnDemandAllocationStrategyPrioritized),
+				OnDemandFamilyPriority:     lo.ToPtr("c6g"),
+			}))
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+				v1beta1.AnnotationInstanceTypePriority: "m5.xlarge, c6g, m5",
+			})
+			overrides := overridesByPriority(launch())
+			rank := func(override *ec2.FleetLaunchTemplateOverridesRequest) int {
+				if aws.StringValue(override.InstanceType) == "m5.xlarge" {
+					return 0
+				}
+				family := strings.Split(aws.StringValue(override.InstanceType), ".")[0]
+				return lo.ValueOr(map[string]int{"c6g": 1, "m5": 2}, family, 3)
+			}
+			Expect(aws.StringValue(overrides[0].InstanceType)).To(Equal("m5.xlarge"))
+			Expect(lo.Map(overrides, func(o *ec2.FleetLaunchTemplateOverridesRequest, _ int) int { return rank(o) })).To(ContainElements(2, 3))
+			for i := 1; i < len(overrides); i++ {
+				Expect(rank(overrides[i-1])).To(BeNumerically("<=", rank(overrides[i])))
+				if rank(overrides[i-1]) == rank(overrides[i]) {
+					Expect(price(overrides[i-1])).To(BeNumerically("<=", price(overrides[i])))
+				}
+			}
+		})
+		It("should ignore entries of the instance type priority that aren't discovered instance types or families", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				OnDemandAllocationStrategy: lo.ToPtr(ec2.FleetOnDemandAllocationStrategyPrioritized),
+			}))
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+				v1beta1.AnnotationInstanceTypePriority: "m5.huge,not-a-family,c6g",
+			})
+			overrides := overridesByPriority(launch())
+			Expect(strings.Split(aws.StringValue(overrides[0].InstanceType), ".")[0]).To(Equal("c6g"))
+			for i := 1; i < len(overrides); i++ {
+				prev := strings.HasPrefix(aws.StringValue(overrides[i-1].InstanceType), "c6g.")
+				cur := strings.HasPrefix(aws.StringValue(overrides[i].InstanceType), "c6g.")
+				Expect(!cur || prev).To(BeTrue())
+				if prev == cur {
+					Expect(price(overrides[i-1])).To(BeNumerically("<=", price(overrides[i])))
+				}
+			}
+		})
+		It("should not prioritize spot overrides", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				OnDemandAllocationStrategy: lo.ToPtr(ec2.FleetOnDemandAllocationStrategyPrioritized),
+			}))
+			nodeClaim.Spec.Requirements[0].Values = []string{corev1beta1.CapacityTypeSpot}
+			createFleetInput := launch()
+			Expect(createFleetInput.OnDemandOptions).To(BeNil())
+			for _, ltc := range createFleetInput.LaunchTemplateConfigs {
+				for _, override := range ltc.Overrides {
+					Expect(override.Priority).To(BeNil())
+				}
+			}
+		})
+	})
+	Context("Stop On Delete", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		stoppedInstance := func(instanceType, zone string) *ec2.Instance {
+			GinkgoHelper()
+			i := &ec2.Instance{
+				InstanceId:     aws.String(fake.InstanceID()),
+				InstanceType:   aws.String(instanceType),
+				ImageId:        aws.String("ami-test1"),
+				Placement:      &ec2.Placement{AvailabilityZone: aws.String(zone)},
+				RootDeviceType: aws.String(ec2.DeviceTypeEbs),
+				State:          &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameStopped)},
+				Tags: []*ec2.Tag{
+					{Key: aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)), Value: aws.String("owned")},
+					{Key: aws.String(corev1beta1.NodePoolLabelKey), Value: aws.String(nodePool.Name)},
+					{Key: aws.String(v1beta1.LabelNodeClass), Value: aws.String(nodeClass.Name)},
+					{Key: aws.String(v1beta1.TagInstanceStopped), Value: aws.String(time.Now().UTC().Format(time.RFC3339))},
+				},
+			}
+			awsEnv.EC2API.Instances.Store(aws.StringValue(i.InstanceId), i)
+			return i
+		}
+		BeforeEach(func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1beta1.AnnotationStopOnDelete: "true"})
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("should tag and stop on-demand instances with an EBS root volume", func() {
+			i := stoppedInstance("m5.large", "test-zone-1a")
+			i.State = &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)}
+			i.Tags = lo.Reject(i.Tags, func(t *ec2.Tag, _ int) bool { return aws.StringValue(t.Key) == v1beta1.TagInstanceStopped })
+
+			Expect(awsEnv.InstanceProvider.Stop(ctx, aws.StringValue(i.InstanceId))).To(Succeed())
+			Expect(awsEnv.EC2API.StopInstancesBehavior.Calls()).To(Equal(1))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(0))
+			instance, err := awsEnv.InstanceProvider.Get(ctx, aws.StringValue(i.InstanceId))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.State).To(Equal(ec2.InstanceStateNameStopped))
+			Expect(instance.Tags).To(HaveKey(v1beta1.TagInstanceStopped))
+		})
+		It("should return a NodeClaimNotFound error when the instance is already stopped", func() {
+			i := stoppedInstance("m5.large", "test-zone-1a")
+			err := awsEnv.InstanceProvider.Stop(ctx, aws.StringValue(i.InstanceId))
+			Expect(corecloudprovider.IsNodeClaimNotFoundError(err)).To(BeTrue())
+			Expect(awsEnv.EC2API.StopInstancesBehavior.Calls()).To(Equal(0))
+		})
+		It("should terminate spot instances instead of stopping them", func() {
+			i := stoppedInstance("m5.large", "test-zone-1a")
+			i.State = &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)}
+			i.SpotInstanceRequestId = aws.String("sir-test")
+			i.Tags = lo.Reject(i.Tags, func(t *ec2.Tag, _ int) bool { return aws.StringValue(t.Key) == v1beta1.TagInstanceStopped })
+
+			Expect(awsEnv.InstanceProvider.Stop(ctx, aws.StringValue(i.InstanceId))).To(Succeed())
+			Expect(awsEnv.EC2API.StopInstancesBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(1))
+		})
+		It("should terminate instances with an instance store root volume instead of stopping them", func() {
+			i := stoppedInstance("m5.large", "test-zone-1a")
+			i.State = &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)}
+			i.RootDeviceType = aws.String(ec2.DeviceTypeInstanceStore)
+			i.Tags = lo.Reject(i.Tags, func(t *ec2.Tag, _ int) bool { return aws.StringValue(t.Key) == v1beta1.TagInstanceStopped })
+
+			Expect(awsEnv.InstanceProvider.Stop(ctx, aws.StringValue(i.InstanceId))).To(Succeed())
+			Expect(awsEnv.EC2API.StopInstancesBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(1))
+		})
+		It("should not return stopped instances from List", func() {
+			stoppedInstance("m5.large", "test-zone-1a")
+			instances, err := awsEnv.InstanceProvider.List(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instances).To(BeEmpty())
+		})
+		It("should return instances that have been stopped for longer than the max stopped instance age from List", func() {
+			i := stoppedInstance("m5.large", "test-zone-1a")
+			i.Tags = append(lo.Reject(i.Tags, func(t *ec2.Tag, _ int) bool { return aws.StringValue(t.Key) == v1beta1.TagInstanceStopped }),
+				&ec2.Tag{Key: aws.String(v1beta1.TagInstanceStopped), Value: aws.String(time.Now().Add(-25 * time.Hour).UTC().Format(time.RFC3339))})
+
+			instances, err := awsEnv.InstanceProvider.List(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instances).To(HaveLen(1))
+			Expect(instances[0].ID).To(Equal(aws.StringValue(i.InstanceId)))
+		})
+		It("should not start instances that have been stopped for longer than the max stopped instance age", func() {
+			i := stoppedInstance("m5.large", "test-zone-1a")
+			i.Tags = append(lo.Reject(i.Tags, func(t *ec2.Tag, _ int) bool { return aws.StringValue(t.Key) == v1beta1.TagInstanceStopped }),
+				&ec2.Tag{Key: aws.String(v1beta1.TagInstanceStopped), Value: aws.String(time.Now().Add(-25 * time.Hour).UTC().Format(time.RFC3339))})
+
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.ID).ToNot(Equal(aws.StringValue(i.InstanceId)))
+			Expect(awsEnv.EC2API.StartInstancesBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+		})
+		It("should not start a stopped instance that was claimed by another nodeclaim", func() {
+			i := stoppedInstance("m5.large", "test-zone-1a")
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.ID).To(Equal(aws.StringValue(i.InstanceId)))
+
+			// DescribeInstances can report the started instance as stopped for a while after it's started
+			i.State = &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameStopped)}
+			i.Tags = append(i.Tags, &ec2.Tag{Key: aws.String(v1beta1.TagInstanceStopped), Value: aws.String(time.Now().UTC().Format(time.RFC3339))})
+			otherNodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{corev1beta1.NodePoolLabelKey: nodePool.Name},
+					Annotations: map[string]string{v1beta1.AnnotationStopOnDelete: "true"},
+				},
+				Spec: nodeClaim.Spec,
+			})
+			instance, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, otherNodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.ID).ToNot(Equal(aws.StringValue(i.InstanceId)))
+			Expect(awsEnv.EC2API.StartInstancesBehavior.Calls()).To(Equal(1))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+		})
+		It("should start a stopped instance instead of launching one", func() {
+			i := stoppedInstance("m5.large", "test-zone-1a")
+
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.ID).To(Equal(aws.StringValue(i.InstanceId)))
+			Expect(instance.State).To(Equal(ec2.InstanceStateNamePending))
+			Expect(instance.Tags).ToNot(HaveKey(v1beta1.TagInstanceStopped))
+			Expect(awsEnv.EC2API.StartInstancesBehavior.Calls()).To(Equal(1))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(0))
+			Expect(lo.Map(i.Tags, func(t *ec2.Tag, _ int) string { return aws.StringValue(t.Key) })).ToNot(ContainElement(v1beta1.TagInstanceStopped))
+		})
+		It("should not start stopped instances in zones that the nodeclaim doesn't allow", func() {
+			i := stoppedInstance("m5.large", "test-zone-1a")
+			nodeClaim.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1b"}}},
+			}
+
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.ID).ToNot(Equal(aws.StringValue(i.InstanceId)))
+			Expect(awsEnv.EC2API.StartInstancesBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+		})
+		It("should not start stopped instances of other nodepools", func() {
+			i := stoppedInstance("m5.large", "test-zone-1a")
+			i.Tags = append(lo.Reject(i.Tags, func(t *ec2.Tag, _ int) bool { return aws.StringValue(t.Key) == corev1beta1.NodePoolLabelKey }),
+				&ec2.Tag{Key: aws.String(corev1beta1.NodePoolLabelKey), Value: aws.String("other-nodepool")})
+
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.ID).ToNot(Equal(aws.StringValue(i.InstanceId)))
+			Expect(awsEnv.EC2API.StartInstancesBehavior.Calls()).To(Equal(0))
+		})
+		It("should launch an instance when the stopped instance can't be started", func() {
+			stoppedInstance("m5.large", "test-zone-1a")
+			awsEnv.EC2API.StartInstancesBehavior.Error.Set(awserr.New("InsufficientInstanceCapacity", "insufficient capacity", nil))
+
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+		})
+		It("should not start stopped instances when the nodeclaim doesn't opt in", func() {
+			stoppedInstance("m5.large", "test-zone-1a")
+			delete(nodeClaim.Annotations, v1beta1.AnnotationStopOnDelete)
+
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.StartInstancesBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+		})
+	})
+	Context("Capacity Reservations", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		launch := func() *ec2.CreateFleetInput {
+			GinkgoHelper()
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			return awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+		}
+		// capacityReservationIDs returns the capacity reservations targeted by the launch templates that were created
+		capacityReservationIDs := func() []string {
+			GinkgoHelper()
+			var ids []string
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(input *ec2.CreateLaunchTemplateInput) {
+				if spec := input.LaunchTemplateData.CapacityReservationSpecification; spec != nil {
+					ids = append(ids, aws.StringValue(spec.CapacityReservationTarget.CapacityReservationId))
+				}
+			})
+			return ids
+		}
+		BeforeEach(func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			nodeClass.Status.CapacityReservations = []v1beta1.CapacityReservation{
+				{ID: "cr-test1", InstanceType: "m5.large", Zone: "test-zone-1a", AvailableInstanceCount: 5},
+				{ID: "cr-test2", InstanceType: "m5.xlarge", Zone: "test-zone-1b", AvailableInstanceCount: 2},
+			}
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("should launch into the capacity reservation with the most available instances", func() {
+			createFleetInput := launch()
+			Expect(aws.StringValue(createFleetInput.TargetCapacitySpecification.DefaultTargetCapacityType)).To(Equal(corev1beta1.CapacityTypeOnDemand))
+			overrides := lo.FlatMap(createFleetInput.LaunchTemplateConfigs, func(ltc *ec2.FleetLaunchTemplateConfigRequest, _ int) []*ec2.FleetLaunchTemplateOverridesRequest {
+				return ltc.Overrides
+			})
+			Expect(overrides).ToNot(BeEmpty())
+			for _, override := range overrides {
+				Expect(aws.StringValue(override.InstanceType)).To(Equal("m5.large"))
+				Expect(aws.StringValue(override.AvailabilityZone)).To(Equal("test-zone-1a"))
+			}
+			Expect(capacityReservationIDs()).ToNot(BeEmpty())
+			Expect(capacityReservationIDs()).To(HaveEach("cr-test1"))
+		})
+		It("should only consider capacity reservations in zones that are allowed by the requirements", func() {
+			nodeClaim.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1b"}}},
+			}
+			createFleetInput := launch()
+			Expect(aws.StringValue(createFleetInput.LaunchTemplateConfigs[0].Overrides[0].InstanceType)).To(Equal("m5.xlarge"))
+			Expect(capacityReservationIDs()).To(HaveEach("cr-test2"))
+		})
+		It("should only consider capacity reservations for instance types that can be launched", func() {
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.xlarge" })
+			launch()
+			Expect(capacityReservationIDs()).To(ConsistOf("cr-test2"))
+		})
+		It("should not launch into capacity reservations without available instances", func() {
+			nodeClass.Status.CapacityReservations[0].AvailableInstanceCount = 0
+			nodeClass.Status.CapacityReservations[1].AvailableInstanceCount = 0
+			launch()
+			Expect(capacityReservationIDs()).To(BeEmpty())
+		})
+		It("should fall back to the next capacity reservation when the capacity reservation is exhausted", func() {
+			awsEnv.EC2API.ExhaustedCapacityReservations.Set([]string{"cr-test1"})
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(2))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(aws.StringValue(createFleetInput.LaunchTemplateConfigs[0].Overrides[0].InstanceType)).To(Equal("m5.xlarge"))
+			Expect(lo.Uniq(capacityReservationIDs())).To(ConsistOf("cr-test1", "cr-test2"))
+		})
+		It("should launch without a capacity reservation when all capacity reservations are exhausted", func() {
+			awsEnv.EC2API.ExhaustedCapacityReservations.Set([]string{"cr-test1", "cr-test2"})
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(3))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(len(lo.Uniq(lo.FlatMap(createFleetInput.LaunchTemplateConfigs, func(ltc *ec2.FleetLaunchTemplateConfigRequest, _ int) []string {
+				return lo.Map(ltc.Overrides, func(o *ec2.FleetLaunchTemplateOverridesRequest, _ int) string { return aws.StringValue(o.InstanceType) })
+			})))).To(BeNumerically(">", 1))
+		})
+		It("should not launch into a capacity reservation that was recently exhausted", func() {
+			awsEnv.EC2API.ExhaustedCapacityReservations.Set([]string{"cr-test1"})
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Reset()
+			createFleetInput := launch()
+			Expect(aws.StringValue(createFleetInput.LaunchTemplateConfigs[0].Overrides[0].InstanceType)).To(Equal("m5.xlarge"))
+		})
+		It("should not launch into capacity reservations when on-demand isn't allowed", func() {
+			nodeClaim.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.CapacityTypeSpot}}},
+			}
+			createFleetInput := launch()
+			Expect(aws.StringValue(createFleetInput.TargetCapacitySpecification.DefaultTargetCapacityType)).To(Equal(corev1beta1.CapacityTypeSpot))
+			Expect(capacityReservationIDs()).To(BeEmpty())
+		})
+	})
+	Context("Tracing", func() {
+		var exporter *tracetest.InMemoryExporter
+		var instanceTypes []*corecloudprovider.InstanceType
+		BeforeEach(func() {
+			exporter = tracetest.NewInMemoryExporter()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			var err error
+			instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		})
+		spanNamed := func(name string) tracetest.SpanStub {
+			GinkgoHelper()
+			span, ok := lo.Find(exporter.GetSpans(), func(s tracetest.SpanStub) bool { return s.Name == name })
+			Expect(ok).To(BeTrue(), "expected a span named %s", name)
+			return span
+		}
+		It("should produce spans around a launch", func() {
+			launched, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			create := spanNamed("instance.Create")
+			Expect(create.Parent.IsValid()).To(BeFalse())
+			Expect(create.Attributes).To(ContainElements(
+				attribute.String("karpenter.sh/nodeclaim", nodeClaim.Name),
+				attribute.String("karpenter.k8s.aws/ec2nodeclass", nodeClass.Name),
+				attribute.String("instance.id", launched.ID),
+			))
+			for _, name := range []string{"subnet.ZonalSubnetsForLaunch", "launchtemplate.EnsureAll", "ec2.CreateFleet"} {
+				span := spanNamed(name)
+				Expect(span.Parent.SpanID()).To(Equal(create.SpanContext.SpanID()))
+				Expect(span.SpanContext.TraceID()).To(Equal(create.SpanContext.TraceID()))
+			}
+			Expect(spanNamed("launchtemplate.EnsureAll").Attributes).To(ContainElement(attribute.String("karpenter.k8s.aws/ec2nodeclass", nodeClass.Name)))
+		})
+		It("should record the error on the span of a failed launch", func() {
+			awsEnv.EC2API.CreateFleetBehavior.Error.Set(fmt.Errorf("failed to create fleet"))
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).To(HaveOccurred())
+
+			Expect(spanNamed("ec2.CreateFleet").Status.Code).To(Equal(codes.Error))
+			Expect(spanNamed("instance.Create").Status.Code).To(Equal(codes.Error))
+		})
+		It("should produce spans for provider list calls", func() {
+			_, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = awsEnv.AMIProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, name := range []string{"subnet.List", "securitygroup.List", "ami.List"} {
+				Expect(spanNamed(name).Attributes).To(ContainElement(attribute.String("karpenter.k8s.aws/ec2nodeclass", nodeClass.Name)))
+			}
+		})
+	})
 })
+
+// classificationTagMutator is a TagMutator that enforces a data-classification tag and tries to override the tags that
+// Karpenter manages instances with
+type classificationTagMutator string
+
+func (m classificationTagMutator) MutateTags(_ context.Context, _ *v1beta1.EC2NodeClass, _ *corev1beta1.NodeClaim, tags map[string]string) map[string]string {
+	tags["data-classification"] = string(m)
+	for _, key := range []string{corev1beta1.NodePoolLabelKey, corev1beta1.ManagedByAnnotationKey, v1beta1.LabelNodeClass} {
+		tags[key] = "overridden"
+	}
+	return tags
+}
@@ -0,0 +1,38 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+)
+
+// TagMutator is invoked by the DefaultProvider with the tags that are about to be applied to the instances, volumes,
+// and launch templates of a NodeClaim. It allows downstream builds to add or override tags with values that are
+// computed at launch, such as a data-classification tag, without changing the provider. The passed map is a copy that
+// may be modified. Tags that Karpenter uses to discover and manage its instances can't be overridden.
+type TagMutator interface {
+	MutateTags(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim, tags map[string]string) map[string]string
+}
+
+// NoopTagMutator is the default TagMutator, which doesn't change any tags
+type NoopTagMutator struct{}
+
+func (NoopTagMutator) MutateTags(_ context.Context, _ *v1beta1.EC2NodeClass, _ *corev1beta1.NodeClaim, tags map[string]string) map[string]string {
+	return tags
+}
@@ -22,6 +22,8 @@ import (
 	"github.com/samber/lo"
 
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
 )
 
 // Instance is an internal data representation of either an ec2.Instance or an ec2.FleetInstance
@@ -38,6 +40,7 @@ type Instance struct {
 	SubnetID         string
 	Tags             map[string]string
 	EFAEnabled       bool
+	RootDeviceType   string
 }
 
 func NewInstance(out *ec2.Instance) *Instance {
@@ -57,6 +60,7 @@ func NewInstance(out *ec2.Instance) *Instance {
 		EFAEnabled: lo.ContainsBy(out.NetworkInterfaces, func(ni *ec2.InstanceNetworkInterface) bool {
 			return ni != nil && lo.FromPtr(ni.InterfaceType) == ec2.NetworkInterfaceTypeEfa
 		}),
+		RootDeviceType: aws.StringValue(out.RootDeviceType),
 	}
 
 }
@@ -75,3 +79,26 @@ func NewInstanceFromFleet(out *ec2.CreateFleetInstance, tags map[string]string,
 		EFAEnabled:   efaEnabled,
 	}
 }
+
+// Stoppable returns whether the instance can be stopped rather than terminated. Only on-demand instances with an
+// EBS root volume can be stopped, since one-time spot requests are terminated on stop and instance store root
+// volumes don't survive one.
+func (i *Instance) Stoppable() bool {
+	return i.CapacityType == corev1beta1.CapacityTypeOnDemand && i.RootDeviceType == ec2.DeviceTypeEbs
+}
+
+// Stopped returns whether the instance was stopped by Karpenter and is stopping or stopped
+func (i *Instance) Stopped() bool {
+	_, ok := i.Tags[v1beta1.TagInstanceStopped]
+	return ok && (i.State == ec2.InstanceStateNameStopping || i.State == ec2.InstanceStateNameStopped)
+}
+
+// StoppedAt returns when the instance was stopped by Karpenter. Instances whose stopped tag can't be parsed are
+// treated as if they were stopped at the zero time, so that they're garbage collected rather than kept indefinitely.
+func (i *Instance) StoppedAt() time.Time {
+	stoppedAt, err := time.Parse(time.RFC3339, i.Tags[v1beta1.TagInstanceStopped])
+	if err != nil {
+		return time.Time{}
+	}
+	return stoppedAt
+}
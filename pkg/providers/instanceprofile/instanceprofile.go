@@ -40,20 +40,23 @@ type ResourceOwner interface {
 
 type Provider interface {
 	Create(context.Context, ResourceOwner) (string, error)
+	Role(context.Context, ResourceOwner) (string, error)
 	Delete(context.Context, ResourceOwner) error
 }
 
 type DefaultProvider struct {
-	region string
-	iamapi iamiface.IAMAPI
-	cache  *cache.Cache
+	region    string
+	iamapi    iamiface.IAMAPI
+	cache     *cache.Cache
+	roleCache *cache.Cache
 }
 
-func NewDefaultProvider(region string, iamapi iamiface.IAMAPI, cache *cache.Cache) *DefaultProvider {
+func NewDefaultProvider(region string, iamapi iamiface.IAMAPI, cache *cache.Cache, roleCache *cache.Cache) *DefaultProvider {
 	return &DefaultProvider{
-		region: region,
-		iamapi: iamapi,
-		cache:  cache,
+		region:    region,
+		iamapi:    iamapi,
+		cache:     cache,
+		roleCache: roleCache,
 	}
 }
 
@@ -87,6 +90,7 @@ func (p *DefaultProvider) Create(ctx context.Context, m ResourceOwner) (string,
 	// https://docs.aws.amazon.com/IAM/latest/UserGuide/id_roles_use_switch-role-ec2_instance-profiles.html
 	if len(instanceProfile.Roles) == 1 {
 		if aws.StringValue(instanceProfile.Roles[0].RoleName) == m.InstanceProfileRole() {
+			p.roleCache.SetDefault(string(m.GetUID()), m.InstanceProfileRole())
 			return profileName, nil
 		}
 		if _, err = p.iamapi.RemoveRoleFromInstanceProfileWithContext(ctx, &iam.RemoveRoleFromInstanceProfileInput{
@@ -103,10 +107,45 @@ func (p *DefaultProvider) Create(ctx context.Context, m ResourceOwner) (string,
 		return "", fmt.Errorf("adding role %q to instance profile %q, %w", m.InstanceProfileRole(), profileName, err)
 	}
 	p.cache.SetDefault(string(m.GetUID()), nil)
+	// IAM is eventually consistent, so the role that was just assigned is cached rather than read back, which could
+	// report the instance profile without it
+	p.roleCache.SetDefault(string(m.GetUID()), m.InstanceProfileRole())
 	return aws.StringValue(instanceProfile.InstanceProfileName), nil
 }
 
+// Role returns the name of the role that's assigned to the owner's instance profile. Unlike Create, the role is only
+// cached for a short time so that out-of-band changes are observed. An empty string is returned when the role is
+// unknown, since an instance profile that's missing or has no role may just not be visible yet after it was created. If
+// the role doesn't match the owner's role, the cached instance profile is invalidated so that the next Create corrects it.
+func (p *DefaultProvider) Role(ctx context.Context, m ResourceOwner) (string, error) {
+	if role, ok := p.roleCache.Get(string(m.GetUID())); ok {
+		return role.(string), nil
+	}
+	profileName := m.InstanceProfileName(options.FromContext(ctx).ClusterName, p.region)
+	out, err := p.iamapi.GetInstanceProfileWithContext(ctx, &iam.GetInstanceProfileInput{InstanceProfileName: aws.String(profileName)})
+	if err != nil {
+		if awserrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("getting instance profile %q, %w", profileName, err)
+	}
+	// Instance profiles can only have a single role assigned to them so this profile either has 1 or 0 roles
+	// https://docs.aws.amazon.com/IAM/latest/UserGuide/id_roles_use_switch-role-ec2_instance-profiles.html
+	if len(out.InstanceProfile.Roles) != 1 {
+		return "", nil
+	}
+	role := aws.StringValue(out.InstanceProfile.Roles[0].RoleName)
+	// Owners without a role use an instance profile that isn't managed by Karpenter, so there's no expected role
+	if m.InstanceProfileRole() != "" && role != m.InstanceProfileRole() {
+		p.cache.Delete(string(m.GetUID()))
+		return role, nil
+	}
+	p.roleCache.SetDefault(string(m.GetUID()), role)
+	return role, nil
+}
+
 func (p *DefaultProvider) Delete(ctx context.Context, m ResourceOwner) error {
+	p.roleCache.Delete(string(m.GetUID()))
 	profileName := m.InstanceProfileName(options.FromContext(ctx).ClusterName, p.region)
 	out, err := p.iamapi.GetInstanceProfileWithContext(ctx, &iam.GetInstanceProfileInput{
 		InstanceProfileName: aws.String(profileName),
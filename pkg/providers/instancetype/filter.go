@@ -0,0 +1,40 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancetype
+
+import (
+	"context"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+)
+
+// InstanceTypeFilter is applied by the DefaultProvider as the final stage of listing instance types, after the
+// instance types for the EC2NodeClass have been resolved. It allows downstream builds to restrict the instance types
+// that Karpenter considers without changing the provider. Filters are called on every List, so rules that change
+// frequently take effect without waiting for the instance type cache to expire.
+type InstanceTypeFilter interface {
+	// Filter returns the subset of the instance types that may be launched with the EC2NodeClass. The passed slice may
+	// be modified, but the instance types themselves are shared and must not be.
+	Filter(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType
+}
+
+// NoopInstanceTypeFilter is the default InstanceTypeFilter, which doesn't filter any instance types
+type NoopInstanceTypeFilter struct{}
+
+func (NoopInstanceTypeFilter) Filter(_ context.Context, _ *v1beta1.EC2NodeClass, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+	return instanceTypes
+}
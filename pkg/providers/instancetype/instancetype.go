@@ -18,6 +18,9 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 
@@ -30,11 +33,15 @@ import (
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/metrics"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
@@ -42,14 +49,29 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 )
 
 type Provider interface {
 	LivenessProbe(*http.Request) error
 	List(context.Context, *corev1beta1.KubeletConfiguration, *v1beta1.EC2NodeClass) ([]*cloudprovider.InstanceType, error)
+	ListOfferings(context.Context) (map[string]cloudprovider.Offerings, error)
+	ListOfferingScores(context.Context, OfferingScoreWeights) (map[string][]OfferingScore, error)
+	SnapshotOfferings(context.Context) ([]OfferingSnapshot, error)
 	UpdateInstanceTypes(ctx context.Context) error
 	UpdateInstanceTypeOfferings(ctx context.Context) error
+	Overhead(context.Context, string, *corev1beta1.KubeletConfiguration, *v1beta1.EC2NodeClass) (*NodeOverhead, error)
+}
+
+// NodeOverhead is the breakdown of the resources that are reserved on a node of an instance type, and the resulting
+// allocatable resources that pods are scheduled against
+type NodeOverhead struct {
+	Capacity          v1.ResourceList
+	KubeReserved      v1.ResourceList
+	SystemReserved    v1.ResourceList
+	EvictionThreshold v1.ResourceList
+	Allocatable       v1.ResourceList
 }
 
 type DefaultProvider struct {
@@ -57,6 +79,7 @@ type DefaultProvider struct {
 	ec2api          ec2iface.EC2API
 	subnetProvider  subnet.Provider
 	pricingProvider pricing.Provider
+	filter          InstanceTypeFilter
 
 	// Values stored *before* considering insufficient capacity errors from the unavailableOfferings cache.
 	// Fully initialized Instance Types are also cached based on the set of all instance types, zones, unavailableOfferings cache,
@@ -70,6 +93,8 @@ type DefaultProvider struct {
 	instanceTypeOfferings   map[string]sets.Set[string]
 
 	instanceTypesCache *cache.Cache
+	// instanceTypeOfferingsCache holds the offerings of each region, and may be shared by the providers of a process
+	instanceTypeOfferingsCache *cache.Cache
 
 	unavailableOfferings *awscache.UnavailableOfferings
 	cm                   *pretty.ChangeMonitor
@@ -79,23 +104,27 @@ type DefaultProvider struct {
 	instanceTypeOfferingsSeqNum uint64
 }
 
-func NewDefaultProvider(region string, instanceTypesCache *cache.Cache, ec2api ec2iface.EC2API, subnetProvider subnet.Provider,
-	unavailableOfferingsCache *awscache.UnavailableOfferings, pricingProvider pricing.Provider) *DefaultProvider {
+func NewDefaultProvider(region string, instanceTypesCache *cache.Cache, instanceTypeOfferingsCache *cache.Cache, ec2api ec2iface.EC2API, subnetProvider subnet.Provider,
+	unavailableOfferingsCache *awscache.UnavailableOfferings, pricingProvider pricing.Provider, filter InstanceTypeFilter) *DefaultProvider {
 	return &DefaultProvider{
-		ec2api:                ec2api,
-		region:                region,
-		subnetProvider:        subnetProvider,
-		pricingProvider:       pricingProvider,
-		instanceTypesInfo:     []*ec2.InstanceTypeInfo{},
-		instanceTypeOfferings: map[string]sets.Set[string]{},
-		instanceTypesCache:    instanceTypesCache,
-		unavailableOfferings:  unavailableOfferingsCache,
-		cm:                    pretty.NewChangeMonitor(),
-		instanceTypesSeqNum:   0,
+		ec2api:                     ec2api,
+		region:                     region,
+		subnetProvider:             subnetProvider,
+		pricingProvider:            pricingProvider,
+		filter:                     filter,
+		instanceTypesInfo:          []*ec2.InstanceTypeInfo{},
+		instanceTypeOfferings:      map[string]sets.Set[string]{},
+		instanceTypesCache:         instanceTypesCache,
+		instanceTypeOfferingsCache: instanceTypeOfferingsCache,
+		unavailableOfferings:       unavailableOfferingsCache,
+		cm:                         pretty.NewChangeMonitor(),
+		instanceTypesSeqNum:        0,
 	}
 }
 
 func (p *DefaultProvider) List(ctx context.Context, kc *corev1beta1.KubeletConfiguration, nodeClass *v1beta1.EC2NodeClass) ([]*cloudprovider.InstanceType, error) {
+	var cacheHit bool
+	defer metrics.MeasureResolution(metrics.ProviderTypeInstanceType)(&cacheHit)
 	p.muInstanceTypeInfo.RLock()
 	p.muInstanceTypeOfferings.RLock()
 	defer p.muInstanceTypeInfo.RUnlock()
@@ -117,25 +146,40 @@ func (p *DefaultProvider) List(ctx context.Context, kc *corev1beta1.KubeletConfi
 	subnetZones := sets.New(lo.Map(nodeClass.Status.Subnets, func(s v1beta1.Subnet, _ int) string {
 		return aws.StringValue(&s.Zone)
 	})...)
+	// Zone names are mapped to zone IDs independently in each account, so the mapping comes from the resolved subnets
+	zoneIDs := lo.SliceToMap(lo.Filter(nodeClass.Status.Subnets, func(s v1beta1.Subnet, _ int) bool {
+		return s.ZoneID != ""
+	}), func(s v1beta1.Subnet) (string, string) {
+		return s.Zone, s.ZoneID
+	})
 
 	// Compute fully initialized instance types hash key
 	subnetZonesHash, _ := hashstructure.Hash(subnetZones, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	zoneIDsHash, _ := hashstructure.Hash(zoneIDs, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 	kcHash, _ := hashstructure.Hash(kc, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 	blockDeviceMappingsHash, _ := hashstructure.Hash(nodeClass.Spec.BlockDeviceMappings, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
-	key := fmt.Sprintf("%d-%d-%d-%016x-%016x-%016x-%s-%s",
+	cpuOptions, _ := nodeClass.CPUOptions()
+	cpuOptionsHash, _ := hashstructure.Hash(cpuOptions, hashstructure.FormatV2, nil)
+	extendedResourcesHash, _ := hashstructure.Hash(nodeClass.Spec.ExtendedResources, hashstructure.FormatV2, nil)
+	key := fmt.Sprintf("%d-%d-%d-%016x-%016x-%016x-%016x-%016x-%016x-%s-%s-%s",
 		p.instanceTypesSeqNum,
 		p.instanceTypeOfferingsSeqNum,
 		p.unavailableOfferings.SeqNum,
 		subnetZonesHash,
+		zoneIDsHash,
 		kcHash,
 		blockDeviceMappingsHash,
+		cpuOptionsHash,
+		extendedResourcesHash,
 		aws.StringValue((*string)(nodeClass.Spec.InstanceStorePolicy)),
 		aws.StringValue(nodeClass.Spec.AMIFamily),
+		aws.StringValue(nodeClass.Spec.MaxHourlyPrice),
 	)
 	if item, ok := p.instanceTypesCache.Get(key); ok {
+		cacheHit = true
 		// Ensure what's returned from this function is a shallow-copy of the slice (not a deep-copy of the data itself)
 		// so that modifications to the ordering of the data don't affect the original
-		return append([]*cloudprovider.InstanceType{}, item.([]*cloudprovider.InstanceType)...), nil
+		return p.filter.Filter(ctx, nodeClass, filterUnsupported(nodeClass, append([]*cloudprovider.InstanceType{}, item.([]*cloudprovider.InstanceType)...))), nil
 	}
 
 	// Get all zones across all offerings
@@ -150,7 +194,12 @@ func (p *DefaultProvider) List(ctx context.Context, kc *corev1beta1.KubeletConfi
 		log.FromContext(ctx).WithValues("zones", allZones.UnsortedList()).V(1).Info("discovered zones")
 	}
 	amiFamily := amifamily.GetAMIFamily(nodeClass.Spec.AMIFamily, &amifamily.Options{})
-	result := lo.Map(p.instanceTypesInfo, func(i *ec2.InstanceTypeInfo, _ int) *cloudprovider.InstanceType {
+	maxPrice, hasMaxPrice := nodeClass.MaxHourlyPrice()
+	infos := p.instanceTypesInfo
+	if options, ok := nodeClass.CPUOptions(); ok {
+		infos = lo.Filter(infos, func(i *ec2.InstanceTypeInfo, _ int) bool { return supportsCPUOptions(i, options) })
+	}
+	result := lo.Map(infos, func(i *ec2.InstanceTypeInfo, _ int) *cloudprovider.InstanceType {
 		instanceTypeVCPU.With(prometheus.Labels{
 			instanceTypeLabel: *i.InstanceType,
 		}).Set(float64(aws.Int64Value(i.VCpuInfo.DefaultVCpus)))
@@ -162,13 +211,124 @@ func (p *DefaultProvider) List(ctx context.Context, kc *corev1beta1.KubeletConfi
 		// Any changes to the values passed into the NewInstanceType method will require making updates to the cache key
 		// so that Karpenter is able to cache the set of InstanceTypes based on values that alter the set of instance types
 		// !!! Important !!!
-		return NewInstanceType(ctx, i, p.region,
+		it := NewInstanceType(ctx, withCPUOptions(nodeClass, i), p.region,
 			nodeClass.Spec.BlockDeviceMappings, nodeClass.Spec.InstanceStorePolicy,
 			kc.MaxPods, kc.PodsPerCore, kc.KubeReserved, kc.SystemReserved, kc.EvictionHard, kc.EvictionSoft,
-			amiFamily, p.createOfferings(ctx, i, p.instanceTypeOfferings[aws.StringValue(i.InstanceType)], allZones, subnetZones))
+			amiFamily, p.createOfferings(ctx, i, p.instanceTypeOfferings[aws.StringValue(i.InstanceType)], allZones, subnetZones, maxPrice, hasMaxPrice))
+		addExtendedResources(nodeClass, it)
+		it.Requirements.Add(scheduling.NewRequirement(v1beta1.LabelTopologyZoneID, v1.NodeSelectorOpIn, lo.FilterMap(it.Offerings.Available(), func(o cloudprovider.Offering, _ int) (string, bool) {
+			id, ok := zoneIDs[o.Zone]
+			return id, ok
+		})...))
+		return it
 	})
 	p.instanceTypesCache.SetDefault(key, result)
-	return result, nil
+	return p.filter.Filter(ctx, nodeClass, filterUnsupported(nodeClass, append([]*cloudprovider.InstanceType{}, result...))), nil
+}
+
+// filterUnsupported removes the instance types that don't support the features that are enabled on the EC2NodeClass
+func filterUnsupported(nodeClass *v1beta1.EC2NodeClass, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+	if aws.BoolValue(nodeClass.Spec.NitroEnclaves) {
+		instanceTypes = lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+			return it.Requirements.Get(v1beta1.LabelInstanceEnclavesSupported).Has("true")
+		})
+	}
+	if nodeClass.Spec.NetworkCardIndex != nil {
+		instanceTypes = lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+			networkCards, err := strconv.ParseInt(it.Requirements.Get(v1beta1.LabelInstanceNetworkCards).Any(), 10, 64)
+			return err == nil && networkCards > aws.Int64Value(nodeClass.Spec.NetworkCardIndex)
+		})
+	}
+	return instanceTypes
+}
+
+// supportsCPUOptions returns true if the instance type can be launched with the passed CPU options
+func supportsCPUOptions(info *ec2.InstanceTypeInfo, options v1beta1.CPUOptions) bool {
+	if info.VCpuInfo == nil {
+		return false
+	}
+	// Instance types that don't support customizing a CPU option don't report its valid values, but they can still be
+	// launched with the default value
+	if threads := options.ThreadsPerCore; threads != nil && *threads != aws.Int64Value(info.VCpuInfo.DefaultThreadsPerCore) &&
+		!lo.Contains(aws.Int64ValueSlice(info.VCpuInfo.ValidThreadsPerCore), *threads) {
+		return false
+	}
+	if cores := options.CoreCount; cores != nil && *cores != aws.Int64Value(info.VCpuInfo.DefaultCores) &&
+		!lo.Contains(aws.Int64ValueSlice(info.VCpuInfo.ValidCores), *cores) {
+		return false
+	}
+	return true
+}
+
+// withCPUOptions returns the instance type info as it's seen by nodes launched with the CPU options of the EC2NodeClass,
+// where the vCPUs of the node are the configured cores multiplied by the configured threads per core.
+func withCPUOptions(nodeClass *v1beta1.EC2NodeClass, info *ec2.InstanceTypeInfo) *ec2.InstanceTypeInfo {
+	options, ok := nodeClass.CPUOptions()
+	if !ok {
+		return info
+	}
+	cores, ok := cpuCores(info)
+	if !ok {
+		return info
+	}
+	threads := aws.Int64Value(info.VCpuInfo.DefaultVCpus) / lo.Max([]int64{cores, 1})
+	if options.CoreCount != nil {
+		cores = *options.CoreCount
+	}
+	if options.ThreadsPerCore != nil {
+		threads = *options.ThreadsPerCore
+	}
+	copied := *info
+	copied.VCpuInfo = &ec2.VCpuInfo{
+		DefaultCores:          aws.Int64(cores),
+		DefaultVCpus:          aws.Int64(cores * threads),
+		DefaultThreadsPerCore: aws.Int64(threads),
+		ValidCores:            info.VCpuInfo.ValidCores,
+		ValidThreadsPerCore:   info.VCpuInfo.ValidThreadsPerCore,
+	}
+	return &copied
+}
+
+// addExtendedResources adds the static extended resources that nodes of the EC2NodeClass advertise to the capacity of
+// the instance type
+func addExtendedResources(nodeClass *v1beta1.EC2NodeClass, it *cloudprovider.InstanceType) {
+	for name, quantity := range nodeClass.Spec.ExtendedResources {
+		it.Capacity[name] = *resource.NewQuantity(quantity, resource.DecimalSI)
+	}
+}
+
+// Overhead computes the overhead and allocatable resources of an instance type for the passed kubelet configuration and
+// EC2NodeClass. The values are computed in the same way as the instance types that are used for scheduling.
+func (p *DefaultProvider) Overhead(ctx context.Context, instanceType string, kc *corev1beta1.KubeletConfiguration, nodeClass *v1beta1.EC2NodeClass) (*NodeOverhead, error) {
+	p.muInstanceTypeInfo.RLock()
+	defer p.muInstanceTypeInfo.RUnlock()
+
+	// Kubelet settings on the EC2NodeClass take precedence, matching the configuration used for scheduling
+	kc = nodeClass.KubeletConfiguration(kc)
+	if kc == nil {
+		kc = &corev1beta1.KubeletConfiguration{}
+	}
+	info, ok := lo.Find(p.instanceTypesInfo, func(i *ec2.InstanceTypeInfo) bool {
+		return aws.StringValue(i.InstanceType) == instanceType
+	})
+	if !ok {
+		return nil, fmt.Errorf("instance type %q not found", instanceType)
+	}
+	if options, ok := nodeClass.CPUOptions(); ok && !supportsCPUOptions(info, options) {
+		return nil, fmt.Errorf("instance type %q doesn't support the cpu options of the nodeclass", instanceType)
+	}
+	it := NewInstanceType(ctx, withCPUOptions(nodeClass, info), p.region,
+		nodeClass.Spec.BlockDeviceMappings, nodeClass.Spec.InstanceStorePolicy,
+		kc.MaxPods, kc.PodsPerCore, kc.KubeReserved, kc.SystemReserved, kc.EvictionHard, kc.EvictionSoft,
+		amifamily.GetAMIFamily(nodeClass.Spec.AMIFamily, &amifamily.Options{}), nil)
+	addExtendedResources(nodeClass, it)
+	return &NodeOverhead{
+		Capacity:          it.Capacity,
+		KubeReserved:      it.Overhead.KubeReserved,
+		SystemReserved:    it.Overhead.SystemReserved,
+		EvictionThreshold: it.Overhead.EvictionThreshold,
+		Allocatable:       it.Allocatable(),
+	}, nil
 }
 
 func (p *DefaultProvider) LivenessProbe(req *http.Request) error {
@@ -225,9 +385,47 @@ func (p *DefaultProvider) UpdateInstanceTypeOfferings(ctx context.Context) error
 	p.muInstanceTypeOfferings.Lock()
 	defer p.muInstanceTypeOfferings.Unlock()
 
-	// Get offerings from EC2
+	// Offerings only depend on the region and the instance families that they're scoped to, so they're cached by those.
+	// Each account's provider has its own offerings cache, since zone names map to different zones in each account.
+	families := options.FromContext(ctx).InstanceTypeFamilyList()
+	ttl := options.FromContext(ctx).InstanceTypeOfferingsCacheTTL
+	key := fmt.Sprintf("%s/%s", p.region, strings.Join(sets.List(sets.New(families...)), ","))
+	var instanceTypeOfferings map[string]sets.Set[string]
+	if cached, ok := p.instanceTypeOfferingsCache.Get(key); ok && ttl > 0 {
+		instanceTypeOfferings = cached.(map[string]sets.Set[string])
+	} else {
+		var err error
+		if instanceTypeOfferings, err = p.describeInstanceTypeOfferings(ctx, families); err != nil {
+			return err
+		}
+		if ttl > 0 {
+			p.instanceTypeOfferingsCache.Set(key, instanceTypeOfferings, ttl)
+		}
+	}
+	if p.cm.HasChanged("instance-type-offering", instanceTypeOfferings) {
+		// Only update instanceTypesSeqNun with the instance type offerings  have been changed
+		// This is to not create new keys with duplicate instance type offerings option
+		atomic.AddUint64(&p.instanceTypeOfferingsSeqNum, 1)
+		log.FromContext(ctx).WithValues("instance-type-count", len(instanceTypeOfferings)).V(1).Info("discovered offerings for instance types")
+	}
+	p.instanceTypeOfferings = instanceTypeOfferings
+	return nil
+}
+
+// describeInstanceTypeOfferings gets the zones that each instance type is offered in from EC2, scoped to the passed
+// instance families when they're specified
+func (p *DefaultProvider) describeInstanceTypeOfferings(ctx context.Context, families []string) (map[string]sets.Set[string], error) {
+	input := &ec2.DescribeInstanceTypeOfferingsInput{LocationType: aws.String("availability-zone")}
+	if len(families) > 0 {
+		input.Filters = []*ec2.Filter{
+			{
+				Name:   aws.String("instance-type"),
+				Values: aws.StringSlice(lo.Map(families, func(f string, _ int) string { return fmt.Sprintf("%s.*", f) })),
+			},
+		}
+	}
 	instanceTypeOfferings := map[string]sets.Set[string]{}
-	if err := p.ec2api.DescribeInstanceTypeOfferingsPagesWithContext(ctx, &ec2.DescribeInstanceTypeOfferingsInput{LocationType: aws.String("availability-zone")},
+	if err := p.ec2api.DescribeInstanceTypeOfferingsPagesWithContext(ctx, input,
 		func(output *ec2.DescribeInstanceTypeOfferingsOutput, lastPage bool) bool {
 			for _, offering := range output.InstanceTypeOfferings {
 				if _, ok := instanceTypeOfferings[aws.StringValue(offering.InstanceType)]; !ok {
@@ -237,37 +435,22 @@ func (p *DefaultProvider) UpdateInstanceTypeOfferings(ctx context.Context) error
 			}
 			return true
 		}); err != nil {
-		return fmt.Errorf("describing instance type zone offerings, %w", err)
-	}
-	if p.cm.HasChanged("instance-type-offering", instanceTypeOfferings) {
-		// Only update instanceTypesSeqNun with the instance type offerings  have been changed
-		// This is to not create new keys with duplicate instance type offerings option
-		atomic.AddUint64(&p.instanceTypeOfferingsSeqNum, 1)
-		log.FromContext(ctx).WithValues("instance-type-count", len(instanceTypeOfferings)).V(1).Info("discovered offerings for instance types")
+		return nil, fmt.Errorf("describing instance type zone offerings, %w", err)
 	}
-	p.instanceTypeOfferings = instanceTypeOfferings
-	return nil
+	return instanceTypeOfferings, nil
 }
 
-func (p *DefaultProvider) createOfferings(ctx context.Context, instanceType *ec2.InstanceTypeInfo, instanceTypeZones, zones, subnetZones sets.Set[string]) []cloudprovider.Offering {
+// createOfferings returns an offering for each zone and capacity type of the instance type. Offerings that are priced
+// above maxPrice are unavailable when hasMaxPrice is set.
+func (p *DefaultProvider) createOfferings(ctx context.Context, instanceType *ec2.InstanceTypeInfo, instanceTypeZones, zones, subnetZones sets.Set[string], maxPrice float64, hasMaxPrice bool) []cloudprovider.Offering {
 	var offerings []cloudprovider.Offering
 	for zone := range zones {
 		// while usage classes should be a distinct set, there's no guarantee of that
 		for capacityType := range sets.NewString(aws.StringValueSlice(instanceType.SupportedUsageClasses)...) {
 			// exclude any offerings that have recently seen an insufficient capacity error from EC2
 			isUnavailable := p.unavailableOfferings.IsUnavailable(*instanceType.InstanceType, zone, capacityType)
-			var price float64
-			var ok bool
-			switch capacityType {
-			case ec2.UsageClassTypeSpot:
-				price, ok = p.pricingProvider.SpotPrice(*instanceType.InstanceType, zone)
-			case ec2.UsageClassTypeOnDemand:
-				price, ok = p.pricingProvider.OnDemandPrice(*instanceType.InstanceType)
-			case "capacity-block":
-				// ignore since karpenter doesn't support it yet, but do not log an unknown capacity type error
-				continue
-			default:
-				log.FromContext(ctx).WithValues("capacity-type", capacityType, "instance-type", *instanceType.InstanceType).Error(fmt.Errorf("received unknown capacity type"), "failed parsing offering")
+			price, ok, supported := p.offeringPrice(ctx, instanceType, zone, capacityType)
+			if !supported {
 				continue
 			}
 			available := !isUnavailable && ok && instanceTypeZones.Has(zone) && subnetZones.Has(zone)
@@ -275,7 +458,8 @@ func (p *DefaultProvider) createOfferings(ctx context.Context, instanceType *ec2
 				Zone:         zone,
 				CapacityType: capacityType,
 				Price:        price,
-				Available:    available,
+				// The offering metrics aren't specific to a nodeclass, so the max price only affects the offering itself
+				Available: available && (!hasMaxPrice || price <= maxPrice),
 			})
 			instanceTypeOfferingAvailable.With(prometheus.Labels{
 				instanceTypeLabel: *instanceType.InstanceType,
@@ -292,8 +476,163 @@ func (p *DefaultProvider) createOfferings(ctx context.Context, instanceType *ec2
 	return offerings
 }
 
+// ListOfferings returns the offerings of every instance type in the zones that it's offered in, keyed by instance type name.
+// Unlike List, the offerings aren't filtered by the subnets, max price or any other setting of an EC2NodeClass so that
+// offerings can be compared across EC2NodeClasses. An offering is only unavailable if it has no price or has recently
+// seen an insufficient capacity error.
+func (p *DefaultProvider) ListOfferings(ctx context.Context) (map[string]cloudprovider.Offerings, error) {
+	p.muInstanceTypeInfo.RLock()
+	p.muInstanceTypeOfferings.RLock()
+	defer p.muInstanceTypeInfo.RUnlock()
+	defer p.muInstanceTypeOfferings.RUnlock()
+
+	if len(p.instanceTypesInfo) == 0 {
+		return nil, fmt.Errorf("no instance types found")
+	}
+	if len(p.instanceTypeOfferings) == 0 {
+		return nil, fmt.Errorf("no instance types offerings found")
+	}
+	result := map[string]cloudprovider.Offerings{}
+	for _, info := range p.instanceTypesInfo {
+		var offerings cloudprovider.Offerings
+		for zone := range p.instanceTypeOfferings[aws.StringValue(info.InstanceType)] {
+			for capacityType := range sets.NewString(aws.StringValueSlice(info.SupportedUsageClasses)...) {
+				price, ok, supported := p.offeringPrice(ctx, info, zone, capacityType)
+				if !supported {
+					continue
+				}
+				offerings = append(offerings, cloudprovider.Offering{
+					Zone:         zone,
+					CapacityType: capacityType,
+					Price:        price,
+					Available:    ok && !p.unavailableOfferings.IsUnavailable(aws.StringValue(info.InstanceType), zone, capacityType),
+				})
+			}
+		}
+		result[aws.StringValue(info.InstanceType)] = offerings
+	}
+	return result, nil
+}
+
+// OfferingScoreWeights are the weights of the resources of an instance type when scoring the efficiency of its offerings
+type OfferingScoreWeights struct {
+	// CPU is the weight of a single vCPU
+	CPU float64
+	// Memory is the weight of a single GiB of memory
+	Memory float64
+}
+
+// DefaultOfferingScoreWeights weighs a vCPU the same as 4GiB of memory, the ratio of general purpose instance types
+var DefaultOfferingScoreWeights = OfferingScoreWeights{CPU: 1, Memory: 0.25}
+
+// OfferingScore is the efficiency score of an offering, which is the price of the offering divided by the weighted
+// resources of its instance type. Lower scores are more efficient.
+type OfferingScore struct {
+	cloudprovider.Offering
+	Score float64
+}
+
+// ListOfferingScores returns the efficiency scores of the offerings from ListOfferings, keyed by instance type name.
+// Offerings without a known price aren't scored.
+func (p *DefaultProvider) ListOfferingScores(ctx context.Context, weights OfferingScoreWeights) (map[string][]OfferingScore, error) {
+	if weights.CPU < 0 || weights.Memory < 0 || weights.CPU+weights.Memory == 0 {
+		return nil, fmt.Errorf("invalid offering score weights, weights must be non-negative and at least one must be positive")
+	}
+	offerings, err := p.ListOfferings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.muInstanceTypeInfo.RLock()
+	infos := lo.SliceToMap(p.instanceTypesInfo, func(info *ec2.InstanceTypeInfo) (string, *ec2.InstanceTypeInfo) {
+		return aws.StringValue(info.InstanceType), info
+	})
+	p.muInstanceTypeInfo.RUnlock()
+
+	result := map[string][]OfferingScore{}
+	for name, its := range offerings {
+		info, ok := infos[name]
+		if !ok {
+			continue
+		}
+		resources := weights.CPU*float64(aws.Int64Value(info.VCpuInfo.DefaultVCpus)) +
+			weights.Memory*float64(aws.Int64Value(info.MemoryInfo.SizeInMiB))/1024
+		if resources == 0 {
+			continue
+		}
+		for _, offering := range its {
+			if offering.Price == 0 {
+				continue
+			}
+			result[name] = append(result[name], OfferingScore{Offering: offering, Score: offering.Price / resources})
+		}
+	}
+	return result, nil
+}
+
+// OfferingSnapshot is a single offering from SnapshotOfferings, flattened so that it can be serialized for consumers
+// outside of the cluster, e.g. capacity dashboards
+type OfferingSnapshot struct {
+	InstanceType string  `json:"instanceType"`
+	Zone         string  `json:"zone"`
+	CapacityType string  `json:"capacityType"`
+	Price        float64 `json:"price"`
+	Available    bool    `json:"available"`
+}
+
+// SnapshotOfferings returns the offerings from ListOfferings as a flat slice, sorted by instance type, zone and
+// capacity type. The snapshot is computed from the cached instance types, pricing and unavailable offerings without
+// calling any AWS APIs, and isn't affected by later changes to the caches.
+func (p *DefaultProvider) SnapshotOfferings(ctx context.Context) ([]OfferingSnapshot, error) {
+	offerings, err := p.ListOfferings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot []OfferingSnapshot
+	for name, its := range offerings {
+		for _, offering := range its {
+			snapshot = append(snapshot, OfferingSnapshot{
+				InstanceType: name,
+				Zone:         offering.Zone,
+				CapacityType: offering.CapacityType,
+				Price:        offering.Price,
+				Available:    offering.Available,
+			})
+		}
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		if snapshot[i].InstanceType != snapshot[j].InstanceType {
+			return snapshot[i].InstanceType < snapshot[j].InstanceType
+		}
+		if snapshot[i].Zone != snapshot[j].Zone {
+			return snapshot[i].Zone < snapshot[j].Zone
+		}
+		return snapshot[i].CapacityType < snapshot[j].CapacityType
+	})
+	return snapshot, nil
+}
+
+// offeringPrice returns the price of the instance type for the zone and capacity type, whether the price is known and
+// whether Karpenter supports launching the capacity type at all
+func (p *DefaultProvider) offeringPrice(ctx context.Context, instanceType *ec2.InstanceTypeInfo, zone, capacityType string) (float64, bool, bool) {
+	switch capacityType {
+	case ec2.UsageClassTypeSpot:
+		price, ok := p.pricingProvider.SpotPrice(*instanceType.InstanceType, zone)
+		return price, ok, true
+	case ec2.UsageClassTypeOnDemand:
+		price, ok := p.pricingProvider.OnDemandPrice(*instanceType.InstanceType)
+		return price, ok, true
+	case "capacity-block":
+		// ignore since karpenter doesn't support it yet, but do not log an unknown capacity type error
+		return 0, false, false
+	default:
+		log.FromContext(ctx).WithValues("capacity-type", capacityType, "instance-type", *instanceType.InstanceType).Error(fmt.Errorf("received unknown capacity type"), "failed parsing offering")
+		return 0, false, false
+	}
+}
+
 func (p *DefaultProvider) Reset() {
 	p.instanceTypesInfo = []*ec2.InstanceTypeInfo{}
 	p.instanceTypeOfferings = map[string]sets.Set[string]{}
 	p.instanceTypesCache.Flush()
+	p.instanceTypeOfferingsCache.Flush()
 }
@@ -0,0 +1,157 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancetype
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// gravitonCoresPerSocket is the largest number of cores on a single Graviton processor. Graviton processors don't
+// support simultaneous multithreading, so each vCPU maps to a single core.
+const gravitonCoresPerSocket = 96
+
+// InstanceTypeNUMANodes contains the number of NUMA nodes for instance types whose processors span multiple sockets.
+// Each entry is the socket count that the instance type reports to the operating system (e.g. "Socket(s)" in lscpu),
+// since DescribeInstanceTypes doesn't expose it. Instance types that aren't Graviton and aren't listed here have a
+// single socket and so a single NUMA node.
+// TODO: remove once DescribeInstanceTypes exposes the socket or NUMA node count of an instance type
+var InstanceTypeNUMANodes = map[string]int64{
+	"c5.24xlarge":       2,
+	"c5.metal":          2,
+	"c5d.24xlarge":      2,
+	"c5d.metal":         2,
+	"c5n.18xlarge":      2,
+	"c5n.metal":         2,
+	"c6a.48xlarge":      2,
+	"c6a.metal":         2,
+	"c6i.32xlarge":      2,
+	"c6i.metal":         2,
+	"c6id.32xlarge":     2,
+	"c6id.metal":        2,
+	"c6in.32xlarge":     2,
+	"c6in.metal":        2,
+	"c7a.48xlarge":      2,
+	"c7a.metal-48xl":    2,
+	"c7i.48xlarge":      2,
+	"c7i.metal-48xl":    2,
+	"dl1.24xlarge":      2,
+	"g4dn.metal":        2,
+	"g5.48xlarge":       2,
+	"hpc6a.48xlarge":    2,
+	"hpc6id.32xlarge":   2,
+	"i3.16xlarge":       2,
+	"i3.metal":          2,
+	"i3en.24xlarge":     2,
+	"i3en.metal":        2,
+	"i4i.32xlarge":      2,
+	"i4i.metal":         2,
+	"inf2.48xlarge":     2,
+	"m5.24xlarge":       2,
+	"m5.metal":          2,
+	"m5d.24xlarge":      2,
+	"m5d.metal":         2,
+	"m5dn.24xlarge":     2,
+	"m5dn.metal":        2,
+	"m5n.24xlarge":      2,
+	"m5n.metal":         2,
+	"m5zn.12xlarge":     2,
+	"m5zn.metal":        2,
+	"m6a.48xlarge":      2,
+	"m6a.metal":         2,
+	"m6i.32xlarge":      2,
+	"m6i.metal":         2,
+	"m6id.32xlarge":     2,
+	"m6id.metal":        2,
+	"m6idn.32xlarge":    2,
+	"m6idn.metal":       2,
+	"m6in.32xlarge":     2,
+	"m6in.metal":        2,
+	"m7a.48xlarge":      2,
+	"m7a.metal-48xl":    2,
+	"m7i.48xlarge":      2,
+	"m7i.metal-48xl":    2,
+	"p3dn.24xlarge":     2,
+	"p4d.24xlarge":      2,
+	"p4de.24xlarge":     2,
+	"p5.48xlarge":       2,
+	"r5.24xlarge":       2,
+	"r5.metal":          2,
+	"r5b.24xlarge":      2,
+	"r5b.metal":         2,
+	"r5d.24xlarge":      2,
+	"r5d.metal":         2,
+	"r5dn.24xlarge":     2,
+	"r5dn.metal":        2,
+	"r5n.24xlarge":      2,
+	"r5n.metal":         2,
+	"r6a.48xlarge":      2,
+	"r6a.metal":         2,
+	"r6i.32xlarge":      2,
+	"r6i.metal":         2,
+	"r6id.32xlarge":     2,
+	"r6id.metal":        2,
+	"r6idn.32xlarge":    2,
+	"r6idn.metal":       2,
+	"r6in.32xlarge":     2,
+	"r6in.metal":        2,
+	"r7a.48xlarge":      2,
+	"r7a.metal-48xl":    2,
+	"r7i.48xlarge":      2,
+	"r7i.metal-48xl":    2,
+	"r7iz.32xlarge":     2,
+	"r7iz.metal-32xl":   2,
+	"trn1.32xlarge":     2,
+	"trn1n.32xlarge":    2,
+	"u-12tb1.112xlarge": 8,
+	"u-12tb1.metal":     8,
+	"u-18tb1.metal":     8,
+	"u-24tb1.metal":     8,
+	"u-3tb1.56xlarge":   4,
+	"u-6tb1.112xlarge":  8,
+	"u-6tb1.56xlarge":   4,
+	"u-6tb1.metal":      8,
+	"u-9tb1.112xlarge":  8,
+	"u-9tb1.metal":      8,
+	"x1.16xlarge":       2,
+	"x1.32xlarge":       4,
+	"x1e.16xlarge":      2,
+	"x1e.32xlarge":      4,
+	"x2idn.32xlarge":    2,
+	"x2idn.metal":       2,
+	"x2iedn.32xlarge":   2,
+	"x2iedn.metal":      2,
+	"x2iezn.12xlarge":   2,
+	"x2iezn.metal":      2,
+	"z1d.12xlarge":      2,
+	"z1d.metal":         2,
+}
+
+// numaNodes returns the number of NUMA nodes of the instance type. The count is taken from InstanceTypeNUMANodes,
+// derived from the vCPU count for Graviton processors, which have a fixed number of cores per socket, and is
+// otherwise a single NUMA node.
+func numaNodes(info *ec2.InstanceTypeInfo) int64 {
+	if nodes, ok := InstanceTypeNUMANodes[aws.StringValue(info.InstanceType)]; ok {
+		return nodes
+	}
+	if info.ProcessorInfo == nil || aws.StringValue(info.ProcessorInfo.Manufacturer) != "AWS" || info.VCpuInfo == nil {
+		return 1
+	}
+	vcpus := aws.Int64Value(info.VCpuInfo.DefaultVCpus)
+	if vcpus <= 0 {
+		return 1
+	}
+	return (vcpus + gravitonCoresPerSocket - 1) / gravitonCoresPerSocket
+}
@@ -21,6 +21,7 @@ import (
 	"net"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -32,6 +33,7 @@ import (
 	"github.com/imdario/mergo"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -56,6 +58,7 @@ import (
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
@@ -84,7 +87,7 @@ var _ = BeforeSuite(func() {
 	ctx = options.ToContext(ctx, test.Options())
 	awsEnv = test.NewEnvironment(ctx, env)
 	fakeClock = &clock.FakeClock{}
-	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.AccountProvider, events.NewRecorder(&record.FakeRecorder{}),
 		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider, awsEnv.SubnetProvider)
 	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
 	prov = provisioning.NewProvisioner(env.Client, events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster)
@@ -128,16 +131,19 @@ var _ = Describe("InstanceTypeProvider", func() {
 					},
 					Subnets: []v1beta1.Subnet{
 						{
-							ID:   "subnet-test1",
-							Zone: "test-zone-1a",
+							ID:     "subnet-test1",
+							Zone:   "test-zone-1a",
+							ZoneID: "testzone1a",
 						},
 						{
-							ID:   "subnet-test2",
-							Zone: "test-zone-1b",
+							ID:     "subnet-test2",
+							Zone:   "test-zone-1b",
+							ZoneID: "testzone1b",
 						},
 						{
-							ID:   "subnet-test3",
-							Zone: "test-zone-1c",
+							ID:     "subnet-test3",
+							Zone:   "test-zone-1c",
+							ZoneID: "testzone1c",
 						},
 					},
 				},
@@ -220,6 +226,7 @@ var _ = Describe("InstanceTypeProvider", func() {
 			corev1beta1.NodePoolLabelKey:     nodePool.Name,
 			v1.LabelTopologyRegion:           fake.DefaultRegion,
 			v1.LabelTopologyZone:             "test-zone-1a",
+			v1beta1.LabelTopologyZoneID:      "testzone1a",
 			v1.LabelInstanceTypeStable:       "g4dn.8xlarge",
 			v1.LabelOSStable:                 "linux",
 			v1.LabelArchStable:               "amd64",
@@ -227,23 +234,32 @@ var _ = Describe("InstanceTypeProvider", func() {
 			// Well Known to AWS
 			v1beta1.LabelInstanceHypervisor:                   "nitro",
 			v1beta1.LabelInstanceEncryptionInTransitSupported: "true",
+			v1beta1.LabelInstanceBurstable:                    "false",
+			v1beta1.LabelInstanceEnclavesSupported:            "true",
 			v1beta1.LabelInstanceCategory:                     "g",
 			v1beta1.LabelInstanceGeneration:                   "4",
 			v1beta1.LabelInstanceFamily:                       "g4dn",
 			v1beta1.LabelInstanceSize:                         "8xlarge",
 			v1beta1.LabelInstanceCPU:                          "32",
+			v1beta1.LabelInstanceCPUCores:                     "16",
 			v1beta1.LabelInstanceCPUManufacturer:              "intel",
 			v1beta1.LabelInstanceMemory:                       "131072",
 			v1beta1.LabelInstanceEBSBandwidth:                 "9500",
 			v1beta1.LabelInstanceNetworkBandwidth:             "50000",
+			v1beta1.LabelInstanceMaxIPs:                       "60",
+			v1beta1.LabelInstanceNetworkCards:                 "1",
+			v1beta1.LabelInstanceMemoryPerVCPU:                "4",
+			v1beta1.LabelInstanceSpotSupported:                "true",
 			v1beta1.LabelInstanceGPUName:                      "t4",
 			v1beta1.LabelInstanceGPUManufacturer:              "nvidia",
 			v1beta1.LabelInstanceGPUCount:                     "1",
 			v1beta1.LabelInstanceGPUMemory:                    "16384",
+			v1beta1.LabelInstanceGPUMemoryTotal:               "16384",
 			v1beta1.LabelInstanceLocalNVME:                    "900",
 			v1beta1.LabelInstanceAcceleratorName:              "inferentia",
 			v1beta1.LabelInstanceAcceleratorManufacturer:      "aws",
 			v1beta1.LabelInstanceAcceleratorCount:             "1",
+			v1beta1.LabelInstanceNUMANodes:                    "2",
 			// Deprecated Labels
 			v1.LabelFailureDomainBetaRegion: fake.DefaultRegion,
 			v1.LabelFailureDomainBetaZone:   "test-zone-1a",
@@ -274,6 +290,7 @@ var _ = Describe("InstanceTypeProvider", func() {
 			corev1beta1.NodePoolLabelKey:     nodePool.Name,
 			v1.LabelTopologyRegion:           fake.DefaultRegion,
 			v1.LabelTopologyZone:             "test-zone-1a",
+			v1beta1.LabelTopologyZoneID:      "testzone1a",
 			v1.LabelInstanceTypeStable:       "g4dn.8xlarge",
 			v1.LabelOSStable:                 "linux",
 			v1.LabelArchStable:               "amd64",
@@ -281,20 +298,29 @@ var _ = Describe("InstanceTypeProvider", func() {
 			// Well Known to AWS
 			v1beta1.LabelInstanceHypervisor:                   "nitro",
 			v1beta1.LabelInstanceEncryptionInTransitSupported: "true",
+			v1beta1.LabelInstanceBurstable:                    "false",
+			v1beta1.LabelInstanceEnclavesSupported:            "true",
 			v1beta1.LabelInstanceCategory:                     "g",
 			v1beta1.LabelInstanceGeneration:                   "4",
 			v1beta1.LabelInstanceFamily:                       "g4dn",
 			v1beta1.LabelInstanceSize:                         "8xlarge",
 			v1beta1.LabelInstanceCPU:                          "32",
+			v1beta1.LabelInstanceCPUCores:                     "16",
 			v1beta1.LabelInstanceCPUManufacturer:              "intel",
 			v1beta1.LabelInstanceMemory:                       "131072",
 			v1beta1.LabelInstanceEBSBandwidth:                 "9500",
 			v1beta1.LabelInstanceNetworkBandwidth:             "50000",
+			v1beta1.LabelInstanceMaxIPs:                       "60",
+			v1beta1.LabelInstanceNetworkCards:                 "1",
+			v1beta1.LabelInstanceMemoryPerVCPU:                "4",
+			v1beta1.LabelInstanceSpotSupported:                "true",
 			v1beta1.LabelInstanceGPUName:                      "t4",
 			v1beta1.LabelInstanceGPUManufacturer:              "nvidia",
 			v1beta1.LabelInstanceGPUCount:                     "1",
 			v1beta1.LabelInstanceGPUMemory:                    "16384",
+			v1beta1.LabelInstanceGPUMemoryTotal:               "16384",
 			v1beta1.LabelInstanceLocalNVME:                    "900",
+			v1beta1.LabelInstanceNUMANodes:                    "1",
 			// Deprecated Labels
 			v1.LabelFailureDomainBetaRegion: fake.DefaultRegion,
 			v1.LabelFailureDomainBetaZone:   "test-zone-1a",
@@ -326,6 +352,7 @@ var _ = Describe("InstanceTypeProvider", func() {
 			corev1beta1.NodePoolLabelKey:     nodePool.Name,
 			v1.LabelTopologyRegion:           fake.DefaultRegion,
 			v1.LabelTopologyZone:             "test-zone-1a",
+			v1beta1.LabelTopologyZoneID:      "testzone1a",
 			v1.LabelInstanceTypeStable:       "inf1.2xlarge",
 			v1.LabelOSStable:                 "linux",
 			v1.LabelArchStable:               "amd64",
@@ -333,18 +360,26 @@ var _ = Describe("InstanceTypeProvider", func() {
 			// Well Known to AWS
 			v1beta1.LabelInstanceHypervisor:                   "nitro",
 			v1beta1.LabelInstanceEncryptionInTransitSupported: "true",
+			v1beta1.LabelInstanceBurstable:                    "false",
+			v1beta1.LabelInstanceEnclavesSupported:            "true",
 			v1beta1.LabelInstanceCategory:                     "inf",
 			v1beta1.LabelInstanceGeneration:                   "1",
 			v1beta1.LabelInstanceFamily:                       "inf1",
 			v1beta1.LabelInstanceSize:                         "2xlarge",
 			v1beta1.LabelInstanceCPU:                          "8",
+			v1beta1.LabelInstanceCPUCores:                     "4",
 			v1beta1.LabelInstanceCPUManufacturer:              "intel",
 			v1beta1.LabelInstanceMemory:                       "16384",
 			v1beta1.LabelInstanceEBSBandwidth:                 "4750",
 			v1beta1.LabelInstanceNetworkBandwidth:             "5000",
+			v1beta1.LabelInstanceMaxIPs:                       "40",
+			v1beta1.LabelInstanceNetworkCards:                 "1",
+			v1beta1.LabelInstanceMemoryPerVCPU:                "2",
+			v1beta1.LabelInstanceSpotSupported:                "true",
 			v1beta1.LabelInstanceAcceleratorName:              "inferentia",
 			v1beta1.LabelInstanceAcceleratorManufacturer:      "aws",
 			v1beta1.LabelInstanceAcceleratorCount:             "1",
+			v1beta1.LabelInstanceNUMANodes:                    "1",
 			// Deprecated Labels
 			v1.LabelFailureDomainBetaRegion: fake.DefaultRegion,
 			v1.LabelFailureDomainBetaZone:   "test-zone-1a",
@@ -360,6 +395,7 @@ var _ = Describe("InstanceTypeProvider", func() {
 			v1beta1.LabelInstanceGPUName,
 			v1beta1.LabelInstanceGPUManufacturer,
 			v1beta1.LabelInstanceGPUMemory,
+			v1beta1.LabelInstanceGPUMemoryTotal,
 			v1beta1.LabelInstanceLocalNVME,
 			v1.LabelWindowsBuild,
 		)).UnsortedList(), lo.Keys(corev1beta1.NormalizedLabels)...)
@@ -369,6 +405,502 @@ var _ = Describe("InstanceTypeProvider", func() {
 		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 		ExpectScheduled(ctx, env.Client, pod)
 	})
+	It("should label burstable instance types", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		burstable := lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, *scheduling.Requirement) {
+			return it.Name, it.Requirements.Get(v1beta1.LabelInstanceBurstable)
+		})
+		for _, name := range []string{"t3.large", "t4g.small", "t4g.medium", "t4g.xlarge"} {
+			Expect(burstable[name].Values()).To(ConsistOf("true"), name)
+		}
+		for _, name := range []string{"m5.large", "c6g.large", "g4dn.8xlarge"} {
+			Expect(burstable[name].Values()).To(ConsistOf("false"), name)
+		}
+	})
+	It("should label instance types that support nitro enclaves", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		enclaves := lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, *scheduling.Requirement) {
+			return it.Name, it.Requirements.Get(v1beta1.LabelInstanceEnclavesSupported)
+		})
+		for _, name := range []string{"c6g.large", "m5.xlarge", "g4dn.8xlarge"} {
+			Expect(enclaves[name].Values()).To(ConsistOf("true"), name)
+		}
+		for _, name := range []string{"m5.large", "t3.large", "t4g.small", "trn1.2xlarge"} {
+			Expect(enclaves[name].Values()).To(ConsistOf("false"), name)
+		}
+	})
+	It("should only return instance types that support nitro enclaves when they're enabled", func() {
+		nodeClass.Spec.NitroEnclaves = aws.Bool(true)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).ToNot(BeEmpty())
+		names := lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })
+		Expect(names).To(ContainElements("c6g.large", "m5.xlarge"))
+		Expect(names).ToNot(ContainElement("m5.large"))
+		Expect(names).ToNot(ContainElement("t3.large"))
+		for _, it := range instanceTypes {
+			Expect(it.Requirements.Get(v1beta1.LabelInstanceEnclavesSupported).Has("true")).To(BeTrue(), it.Name)
+		}
+	})
+	It("should label instance types with their network cards", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		networkCards := lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, *scheduling.Requirement) {
+			return it.Name, it.Requirements.Get(v1beta1.LabelInstanceNetworkCards)
+		})
+		Expect(networkCards["dl1.24xlarge"].Values()).To(ConsistOf("4"))
+		Expect(networkCards["m6idn.32xlarge"].Values()).To(ConsistOf("2"))
+		Expect(networkCards["m5.large"].Values()).To(ConsistOf("1"))
+	})
+	DescribeTable("should label instance types with their memory per vCPU",
+		func(instanceType, memoryPerVCPU string) {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == instanceType })
+			Expect(ok).To(BeTrue())
+			Expect(it.Requirements.Get(v1beta1.LabelInstanceMemoryPerVCPU).Values()).To(ConsistOf(memoryPerVCPU))
+		},
+		Entry("compute optimized", "c6g.large", "2"),
+		Entry("general purpose", "m5.large", "4"),
+		Entry("burstable", "t4g.small", "1"),
+		Entry("rounded down", "p3.8xlarge", "7"),
+		Entry("high memory", "dl1.24xlarge", "8"),
+	)
+	It("should only launch instance types within the bounds of a memory per vCPU requirement", func() {
+		nodePool.Spec.Template.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1beta1.LabelInstanceMemoryPerVCPU, Operator: v1.NodeSelectorOpLt, Values: []string{"3"}}},
+		}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels[v1beta1.LabelInstanceMemoryPerVCPU]).To(BeElementOf("1", "2"))
+	})
+	Context("Cluster Placement Support", func() {
+		BeforeEach(func() {
+			// m7i-flex instance types can't be launched into cluster placement groups
+			output, err := awsEnv.EC2API.DescribeInstanceTypesWithContext(ctx, &ec2.DescribeInstanceTypesInput{})
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes := lo.Map(output.InstanceTypes, func(info *ec2.InstanceTypeInfo, _ int) *ec2.InstanceTypeInfo {
+				clusterSupported := *info
+				clusterSupported.PlacementGroupInfo = &ec2.PlacementGroupInfo{
+					SupportedStrategies: aws.StringSlice([]string{ec2.PlacementGroupStrategyCluster, ec2.PlacementGroupStrategyPartition, ec2.PlacementGroupStrategySpread}),
+				}
+				return &clusterSupported
+			})
+			m5Large, ok := lo.Find(output.InstanceTypes, func(info *ec2.InstanceTypeInfo) bool { return aws.StringValue(info.InstanceType) == "m5.large" })
+			Expect(ok).To(BeTrue())
+			clusterUnsupported := *m5Large
+			clusterUnsupported.InstanceType = aws.String("m7i-flex.large")
+			clusterUnsupported.PlacementGroupInfo = &ec2.PlacementGroupInfo{
+				SupportedStrategies: aws.StringSlice([]string{ec2.PlacementGroupStrategyPartition, ec2.PlacementGroupStrategySpread}),
+			}
+			awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{
+				InstanceTypes: append(instanceTypes, &clusterUnsupported),
+			})
+			offerings, err := awsEnv.EC2API.DescribeInstanceTypeOfferingsWithContext(ctx, &ec2.DescribeInstanceTypeOfferingsInput{})
+			Expect(err).ToNot(HaveOccurred())
+			awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{
+				InstanceTypeOfferings: append(offerings.InstanceTypeOfferings, lo.Map([]string{"test-zone-1a", "test-zone-1b", "test-zone-1c"}, func(zone string, _ int) *ec2.InstanceTypeOffering {
+					return &ec2.InstanceTypeOffering{InstanceType: aws.String("m7i-flex.large"), Location: aws.String(zone)}
+				})...),
+			})
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+		})
+		DescribeTable("should label instance types with whether they support cluster placement groups",
+			func(instanceType, supported string) {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+				Expect(err).ToNot(HaveOccurred())
+				it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == instanceType })
+				Expect(ok).To(BeTrue())
+				Expect(it.Requirements.Get(v1beta1.LabelInstanceClusterPlacementSupported).Values()).To(ConsistOf(supported))
+			},
+			Entry("general purpose", "m5.large", "true"),
+			Entry("accelerated", "p3.8xlarge", "true"),
+			Entry("flex", "m7i-flex.large", "false"),
+		)
+		It("should launch instance types that don't support cluster placement groups when cluster placement isn't required", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1.LabelInstanceTypeStable: "m7i-flex.large"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1beta1.LabelInstanceClusterPlacementSupported]).To(Equal("false"))
+		})
+		It("should not launch instance types that don't support cluster placement groups when cluster placement is required", func() {
+			nodePool.Spec.Template.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1beta1.LabelInstanceClusterPlacementSupported, Operator: v1.NodeSelectorOpIn, Values: []string{"true"}}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1.LabelInstanceTypeStable: "m7i-flex.large"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should only request instance types that support cluster placement groups when cluster placement is required", func() {
+			nodePool.Spec.Template.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1beta1.LabelInstanceClusterPlacementSupported, Operator: v1.NodeSelectorOpIn, Values: []string{"true"}}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1beta1.LabelInstanceClusterPlacementSupported]).To(Equal("true"))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			for _, config := range createFleetInput.LaunchTemplateConfigs {
+				for _, override := range config.Overrides {
+					Expect(aws.StringValue(override.InstanceType)).ToNot(Equal("m7i-flex.large"))
+				}
+			}
+		})
+	})
+	It("should not label instance types with cluster placement support when EC2 doesn't report their placement group strategies", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		for _, it := range instanceTypes {
+			Expect(it.Requirements.Get(v1beta1.LabelInstanceClusterPlacementSupported).Operator()).To(Equal(v1.NodeSelectorOpDoesNotExist))
+		}
+	})
+	Context("Spot Support", func() {
+		BeforeEach(func() {
+			// m5.large is only offered as on-demand
+			output, err := awsEnv.EC2API.DescribeInstanceTypesWithContext(ctx, &ec2.DescribeInstanceTypesInput{})
+			Expect(err).ToNot(HaveOccurred())
+			awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{
+				InstanceTypes: lo.Map(output.InstanceTypes, func(info *ec2.InstanceTypeInfo, _ int) *ec2.InstanceTypeInfo {
+					if aws.StringValue(info.InstanceType) != "m5.large" {
+						return info
+					}
+					onDemandOnly := *info
+					onDemandOnly.SupportedUsageClasses = aws.StringSlice([]string{ec2.UsageClassTypeOnDemand})
+					return &onDemandOnly
+				}),
+			})
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+		})
+		It("should label instance types with whether they're offered as spot", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			onDemandOnly, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+			Expect(ok).To(BeTrue())
+			Expect(onDemandOnly.Requirements.Get(v1beta1.LabelInstanceSpotSupported).Values()).To(ConsistOf("false"))
+			spotCapable, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.xlarge" })
+			Expect(ok).To(BeTrue())
+			Expect(spotCapable.Requirements.Get(v1beta1.LabelInstanceSpotSupported).Values()).To(ConsistOf("true"))
+		})
+		It("should not launch instance types that aren't offered as spot when spot support is required", func() {
+			nodePool.Spec.Template.Spec.Requirements = append(nodePool.Spec.Template.Spec.Requirements, corev1beta1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1beta1.LabelInstanceSpotSupported, Operator: v1.NodeSelectorOpIn, Values: []string{"true"}},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1.LabelInstanceTypeStable: "m5.large"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should not launch instance types that aren't offered as spot when the spot capacity type is required", func() {
+			nodePool.Spec.Template.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.CapacityTypeSpot}}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1.LabelInstanceTypeStable: "m5.large"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should launch instance types that aren't offered as spot as on-demand", func() {
+			nodePool.Spec.Template.Spec.Requirements = append(nodePool.Spec.Template.Spec.Requirements, corev1beta1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1beta1.LabelInstanceSpotSupported, Operator: v1.NodeSelectorOpIn, Values: []string{"false"}},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "m5.large"))
+			Expect(node.Labels).To(HaveKeyWithValue(v1beta1.LabelInstanceSpotSupported, "false"))
+			Expect(node.Labels).To(HaveKeyWithValue(corev1beta1.CapacityTypeLabelKey, corev1beta1.CapacityTypeOnDemand))
+		})
+	})
+	DescribeTable("should label instance types with their physical cpu cores",
+		func(instanceType, cores string) {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == instanceType })
+			Expect(ok).To(BeTrue())
+			Expect(it.Requirements.Get(v1beta1.LabelInstanceCPUCores).Values()).To(ConsistOf(cores))
+		},
+		Entry("hyperthreaded", "m5.large", "1"),
+		Entry("hyperthreaded metal", "m5.metal", "48"),
+		Entry("single thread per core", "c6g.large", "2"),
+		Entry("burstable", "t4g.xlarge", "4"),
+	)
+	It("should only launch instance types within the bounds of a physical cpu cores requirement", func() {
+		nodePool.Spec.Template.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1beta1.LabelInstanceCPUCores, Operator: v1.NodeSelectorOpGt, Values: []string{"40"}}},
+		}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels[v1beta1.LabelInstanceCPUCores]).To(BeElementOf("48", "64"))
+	})
+	It("should only return instance types that support a single thread per core when hyperthreading is disabled", func() {
+		nodeClass.Spec.DisableHyperthreading = aws.Bool(true)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		names := lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })
+		Expect(names).To(ContainElements("c6g.large", "m5.large", "t4g.small"))
+		Expect(names).ToNot(ContainElement("m5.metal"))
+	})
+	It("should only expose a single vCPU per physical core when hyperthreading is disabled", func() {
+		nodeClass.Spec.DisableHyperthreading = aws.Bool(true)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		byName := lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, *corecloudprovider.InstanceType) {
+			return it.Name, it
+		})
+		Expect(byName["m5.xlarge"].Capacity.Cpu().Value()).To(BeNumerically("==", 2))
+		Expect(byName["m5.xlarge"].Requirements.Get(v1beta1.LabelInstanceCPU).Values()).To(ConsistOf("2"))
+		Expect(byName["m5.xlarge"].Requirements.Get(v1beta1.LabelInstanceCPUCores).Values()).To(ConsistOf("2"))
+		// Instance types that already run a single thread per core are unaffected
+		Expect(byName["c6g.large"].Capacity.Cpu().Value()).To(BeNumerically("==", 2))
+	})
+	It("should only return instance types that support the cpu options", func() {
+		nodeClass.Spec.CPUOptions = &v1beta1.CPUOptions{CoreCount: aws.Int64(4), ThreadsPerCore: aws.Int64(1)}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		names := lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })
+		Expect(names).To(ContainElements("g4dn.8xlarge", "p3.8xlarge", "inf1.2xlarge", "inf1.6xlarge", "t4g.xlarge"))
+		for _, name := range []string{"m5.large", "m5.xlarge", "c6g.large", "m5.metal"} {
+			Expect(names).ToNot(ContainElement(name))
+		}
+	})
+	It("should expose the vCPUs of the customized cores and threads per core", func() {
+		nodeClass.Spec.CPUOptions = &v1beta1.CPUOptions{CoreCount: aws.Int64(4), ThreadsPerCore: aws.Int64(2)}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "g4dn.8xlarge" })
+		Expect(ok).To(BeTrue())
+		Expect(it.Capacity.Cpu().Value()).To(BeNumerically("==", 8))
+		Expect(it.Requirements.Get(v1beta1.LabelInstanceCPU).Values()).To(ConsistOf("8"))
+		Expect(it.Requirements.Get(v1beta1.LabelInstanceCPUCores).Values()).To(ConsistOf("4"))
+	})
+	It("should return no instance types when none support the cpu options", func() {
+		nodeClass.Spec.CPUOptions = &v1beta1.CPUOptions{CoreCount: aws.Int64(3)}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(BeEmpty())
+	})
+	It("should add the extended resources of the nodeClass to the capacity of every instance type", func() {
+		nodeClass.Spec.ExtendedResources = map[v1.ResourceName]int64{"example.com/fpga": 2}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).ToNot(BeEmpty())
+		for _, it := range instanceTypes {
+			Expect(it.Capacity).To(HaveKeyWithValue(v1.ResourceName("example.com/fpga"), resource.MustParse("2")), it.Name)
+			Expect(it.Allocatable()).To(HaveKeyWithValue(v1.ResourceName("example.com/fpga"), resource.MustParse("2")), it.Name)
+		}
+	})
+	It("should launch nodes that advertise the extended resources of the nodeClass for pods that request them", func() {
+		nodeClass.Spec.ExtendedResources = map[v1.ResourceName]int64{"example.com/fpga": 2}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod(coretest.PodOptions{
+			ResourceRequirements: v1.ResourceRequirements{
+				Requests: v1.ResourceList{"example.com/fpga": resource.MustParse("2")},
+				Limits:   v1.ResourceList{"example.com/fpga": resource.MustParse("2")},
+			},
+		})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Status.Capacity).To(HaveKeyWithValue(v1.ResourceName("example.com/fpga"), resource.MustParse("2")))
+	})
+	It("should not schedule pods that request more of an extended resource than the nodeClass advertises", func() {
+		nodeClass.Spec.ExtendedResources = map[v1.ResourceName]int64{"example.com/fpga": 1}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod(coretest.PodOptions{
+			ResourceRequirements: v1.ResourceRequirements{
+				Requests: v1.ResourceList{"example.com/fpga": resource.MustParse("2")},
+				Limits:   v1.ResourceList{"example.com/fpga": resource.MustParse("2")},
+			},
+		})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+	})
+	It("should only return instance types with more network cards than the network card index", func() {
+		nodeClass.Spec.NetworkCardIndex = aws.Int64(1)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		names := lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })
+		Expect(names).To(ConsistOf("dl1.24xlarge", "m6idn.32xlarge"))
+
+		nodeClass.Spec.NetworkCardIndex = aws.Int64(2)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		instanceTypes, err = cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		names = lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })
+		Expect(names).To(ConsistOf("dl1.24xlarge"))
+	})
+	It("should not launch burstable instance types when they're excluded", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod(coretest.PodOptions{
+			NodeSelector: map[string]string{v1beta1.LabelInstanceBurstable: "false"},
+		})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1beta1.LabelInstanceBurstable, "false"))
+		Expect(node.Labels[v1.LabelInstanceTypeStable]).ToNot(HavePrefix("t"))
+	})
+	It("should label instance types with the IPv4 addresses that their network interfaces can hold", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		maxIPs := lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, []string) {
+			return it.Name, it.Requirements.Get(v1beta1.LabelInstanceMaxIPs).Values()
+		})
+		// MaximumNetworkInterfaces * Ipv4AddressesPerInterface
+		Expect(maxIPs["m5.large"]).To(ConsistOf("30"))
+		Expect(maxIPs["inf1.2xlarge"]).To(ConsistOf("40"))
+		Expect(maxIPs["g4dn.8xlarge"]).To(ConsistOf("60"))
+	})
+	It("should launch instance types with at least the required IPv4 addresses", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod(coretest.PodOptions{
+			NodeRequirements: []v1.NodeSelectorRequirement{
+				{Key: v1beta1.LabelInstanceMaxIPs, Operator: v1.NodeSelectorOpGt, Values: []string{"50"}},
+			},
+		})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(lo.Must(strconv.Atoi(node.Labels[v1beta1.LabelInstanceMaxIPs]))).To(BeNumerically(">", 50))
+	})
+	It("should map the zones of instance type offerings to zone IDs", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).ToNot(BeEmpty())
+		for _, it := range instanceTypes {
+			zoneIDs := lo.Map(it.Requirements.Get(v1.LabelTopologyZone).Values(), func(zone string, _ int) string {
+				return strings.ReplaceAll(zone, "-", "")
+			})
+			Expect(it.Requirements.Get(v1beta1.LabelTopologyZoneID).Values()).To(ConsistOf(zoneIDs), it.Name)
+		}
+	})
+	It("should launch into the zone of a zone ID", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod(coretest.PodOptions{
+			NodeSelector: map[string]string{v1beta1.LabelTopologyZoneID: "testzone1b"},
+		})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1beta1.LabelTopologyZoneID, "testzone1b"))
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelTopologyZone, "test-zone-1b"))
+	})
+	It("should label dual socket instance types with their numa node count", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		numaNodes := lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, *scheduling.Requirement) {
+			return it.Name, it.Requirements.Get(v1beta1.LabelInstanceNUMANodes)
+		})
+		for _, name := range []string{"m5.metal", "m6idn.32xlarge", "dl1.24xlarge"} {
+			Expect(numaNodes[name].Operator()).To(Equal(v1.NodeSelectorOpIn), name)
+			Expect(numaNodes[name].Values()).To(ConsistOf("2"), name)
+		}
+	})
+	It("should know the numa node count of multi-socket instance types", func() {
+		for name, nodes := range map[string]int64{"x1e.32xlarge": 4, "u-6tb1.56xlarge": 4, "u-12tb1.metal": 8, "m7i.48xlarge": 2, "c5n.18xlarge": 2} {
+			Expect(instancetype.InstanceTypeNUMANodes).To(HaveKeyWithValue(name, nodes))
+		}
+	})
+	It("should label graviton instance types as having a single numa node", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		numaNodes := lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, *scheduling.Requirement) {
+			return it.Name, it.Requirements.Get(v1beta1.LabelInstanceNUMANodes)
+		})
+		for _, name := range []string{"c6g.large", "t4g.small", "t4g.medium", "t4g.xlarge"} {
+			Expect(numaNodes[name].Operator()).To(Equal(v1.NodeSelectorOpIn), name)
+			Expect(numaNodes[name].Values()).To(ConsistOf("1"), name)
+		}
+	})
+	It("should label single socket x86 instance types as having a single numa node", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		numaNodes := lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, *scheduling.Requirement) {
+			return it.Name, it.Requirements.Get(v1beta1.LabelInstanceNUMANodes)
+		})
+		for _, name := range []string{"m5.large", "m5.xlarge", "t3.large", "g4dn.8xlarge"} {
+			Expect(numaNodes[name].Operator()).To(Equal(v1.NodeSelectorOpIn), name)
+			Expect(numaNodes[name].Values()).To(ConsistOf("1"), name)
+		}
+	})
+	It("should schedule pods requiring a single numa node on single socket instance types", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod(coretest.PodOptions{
+			NodeSelector: map[string]string{v1beta1.LabelInstanceNUMANodes: "1"},
+		})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1beta1.LabelInstanceNUMANodes, "1"))
+	})
+	It("should schedule pods requiring a single numa node on single socket x86 instance types", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod(coretest.PodOptions{
+			NodeRequirements: []v1.NodeSelectorRequirement{
+				{Key: v1beta1.LabelInstanceNUMANodes, Operator: v1.NodeSelectorOpIn, Values: []string{"1"}},
+				{Key: v1.LabelArchStable, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.ArchitectureAmd64}},
+			},
+		})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1beta1.LabelInstanceNUMANodes, "1"))
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelArchStable, corev1beta1.ArchitectureAmd64))
+	})
+	Context("Instance Type Filter", func() {
+		families := func(instanceTypes []*corecloudprovider.InstanceType) sets.Set[string] {
+			return sets.New(lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string {
+				return strings.Split(it.Name, ".")[0]
+			})...)
+		}
+		It("should not filter instance types by default", func() {
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, &corev1beta1.KubeletConfiguration{}, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(families(instanceTypes).Has("m5")).To(BeTrue())
+		})
+		It("should apply the filter as the final stage of listing instance types", func() {
+			provider := instancetype.NewDefaultProvider(fake.DefaultRegion, cache.New(awscache.InstanceTypesAndZonesTTL, awscache.DefaultCleanupInterval),
+				cache.New(awscache.InstanceTypesAndZonesTTL, awscache.DefaultCleanupInterval), awsEnv.EC2API, awsEnv.SubnetProvider, awsEnv.UnavailableOfferingsCache, awsEnv.PricingProvider, familyFilter("m5"))
+			Expect(provider.UpdateInstanceTypes(ctx)).To(Succeed())
+			Expect(provider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+			// The second call is served from the cache, which must be filtered as well
+			for i := 0; i < 2; i++ {
+				instanceTypes, err := provider.List(ctx, &corev1beta1.KubeletConfiguration{}, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(instanceTypes).ToNot(BeEmpty())
+				Expect(families(instanceTypes).Has("m5")).To(BeFalse())
+				Expect(families(instanceTypes).Has("c6g")).To(BeTrue())
+			}
+		})
+	})
 	It("should not launch AWS Pod ENI on a t3", func() {
 		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 		pod := coretest.UnschedulablePod(coretest.PodOptions{
@@ -646,6 +1178,18 @@ var _ = Describe("InstanceTypeProvider", func() {
 		}
 		Expect(nodeNames.Len()).To(Equal(2))
 	})
+	It("should launch instances for the total GPU memory across all GPUs", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod(coretest.PodOptions{
+			NodeSelector: map[string]string{v1beta1.LabelInstanceGPUMemoryTotal: "65536"},
+		})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		// p3.8xlarge has 4 GPUs with 16384 MiB of memory each
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "p3.8xlarge"))
+		Expect(node.Labels).To(HaveKeyWithValue(v1beta1.LabelInstanceGPUMemory, "16384"))
+		Expect(node.Labels).To(HaveKeyWithValue(v1beta1.LabelInstanceGPUMemoryTotal, "65536"))
+	})
 	It("should launch instances for Habana GPU resource requests", func() {
 		nodeNames := sets.NewString()
 		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
@@ -795,6 +1339,26 @@ var _ = Describe("InstanceTypeProvider", func() {
 		Expect(node.Labels[v1.LabelInstanceTypeStable]).To(Equal("m6idn.32xlarge"))
 		Expect(*node.Status.Capacity.StorageEphemeral()).To(Equal(resource.MustParse("7600G")))
 	})
+	It("should compute ephemeral storage allocatable from the instance storage when disks are mounted for ephemeral-storage", func() {
+		nodeClass.Spec.InstanceStorePolicy = lo.ToPtr(v1beta1.InstanceStorePolicyRAID0)
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodePool.Spec.Template.Spec.Kubelet, nodeClass)
+		Expect(err).To(BeNil())
+		it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m6idn.32xlarge" })
+		Expect(ok).To(BeTrue())
+		Expect(it.Capacity.StorageEphemeral().Value()).To(Equal(resource.NewScaledQuantity(7600, resource.Giga).Value()))
+		// The default nodefs.available eviction threshold is 10% of the local NVMe rather than of the root volume
+		Expect(it.Overhead.EvictionThreshold.StorageEphemeral().Value()).To(Equal(resource.NewScaledQuantity(760, resource.Giga).Value()))
+		allocatable := it.Allocatable()
+		Expect(allocatable.StorageEphemeral().Value()).To(BeNumerically(">", resource.NewScaledQuantity(6000, resource.Giga).Value()))
+	})
+	It("should compute ephemeral storage from the root volume for instance types without instance storage when disks are mounted for ephemeral-storage", func() {
+		nodeClass.Spec.InstanceStorePolicy = lo.ToPtr(v1beta1.InstanceStorePolicyRAID0)
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodePool.Spec.Template.Spec.Kubelet, nodeClass)
+		Expect(err).To(BeNil())
+		it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+		Expect(ok).To(BeTrue())
+		Expect(it.Capacity.StorageEphemeral().Value()).To(Equal(amifamily.DefaultEBS.VolumeSize.Value()))
+	})
 	It("should not set pods to 110 if using ENI-based pod density", func() {
 		instanceInfo, err := awsEnv.EC2API.DescribeInstanceTypesWithContext(ctx, &ec2.DescribeInstanceTypesInput{})
 		Expect(err).To(BeNil())
@@ -906,6 +1470,307 @@ var _ = Describe("InstanceTypeProvider", func() {
 			}
 		})
 	})
+	Context("Instance Type Offerings", func() {
+		It("should not filter the offerings call when instance type families aren't configured", func() {
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+			input := awsEnv.EC2API.DescribeInstanceTypeOfferingsInput.Clone()
+			Expect(aws.StringValue(input.LocationType)).To(Equal("availability-zone"))
+			Expect(input.Filters).To(BeEmpty())
+		})
+		It("should scope the offerings call to the configured instance type families", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				InstanceTypeFamilies: lo.ToPtr("m5, c6g"),
+			}))
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+			input := awsEnv.EC2API.DescribeInstanceTypeOfferingsInput.Clone()
+			Expect(input.Filters).To(ConsistOf(&ec2.Filter{
+				Name:   aws.String("instance-type"),
+				Values: aws.StringSlice([]string{"m5.*", "c6g.*"}),
+			}))
+		})
+	})
+	Context("Instance Type Offerings Cache", func() {
+		instanceTypeNames := func(provider *instancetype.DefaultProvider) []string {
+			instanceTypes, err := provider.List(ctx, &corev1beta1.KubeletConfiguration{}, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			return lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })
+		}
+		newProvider := func(region string) *instancetype.DefaultProvider {
+			provider := instancetype.NewDefaultProvider(region, cache.New(awscache.InstanceTypesAndZonesTTL, awscache.DefaultCleanupInterval),
+				awsEnv.InstanceTypeOfferingsCache, awsEnv.EC2API, awsEnv.SubnetProvider, awsEnv.UnavailableOfferingsCache, awsEnv.PricingProvider, instancetype.NoopInstanceTypeFilter{})
+			Expect(provider.UpdateInstanceTypes(ctx)).To(Succeed())
+			return provider
+		}
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				InstanceTypeOfferingsCacheTTL: lo.ToPtr(5 * time.Minute),
+			}))
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+			Expect(awsEnv.EC2API.DescribeInstanceTypeOfferingsInput.IsNil()).To(BeFalse())
+			awsEnv.EC2API.DescribeInstanceTypeOfferingsInput.Reset()
+		})
+		It("should reuse cached offerings across updates", func() {
+			expected := instanceTypeNames(awsEnv.InstanceTypesProvider)
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+			Expect(awsEnv.EC2API.DescribeInstanceTypeOfferingsInput.IsNil()).To(BeTrue())
+			Expect(instanceTypeNames(awsEnv.InstanceTypesProvider)).To(ConsistOf(expected))
+		})
+		It("should share cached offerings between providers in the same region", func() {
+			provider := newProvider(fake.DefaultRegion)
+			Expect(provider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+			Expect(awsEnv.EC2API.DescribeInstanceTypeOfferingsInput.IsNil()).To(BeTrue())
+			Expect(instanceTypeNames(provider)).To(ConsistOf(instanceTypeNames(awsEnv.InstanceTypesProvider)))
+		})
+		It("should not share cached offerings between regions", func() {
+			provider := newProvider("us-east-1")
+			Expect(provider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+			Expect(awsEnv.EC2API.DescribeInstanceTypeOfferingsInput.IsNil()).To(BeFalse())
+		})
+		It("should not share cached offerings that are scoped to different instance type families", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				InstanceTypeOfferingsCacheTTL: lo.ToPtr(5 * time.Minute),
+				InstanceTypeFamilies:          lo.ToPtr("m5"),
+			}))
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+			Expect(awsEnv.EC2API.DescribeInstanceTypeOfferingsInput.IsNil()).To(BeFalse())
+		})
+		It("should get offerings from EC2 on every update when the cache is disabled", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				InstanceTypeOfferingsCacheTTL: lo.ToPtr(time.Duration(0)),
+			}))
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+			Expect(awsEnv.EC2API.DescribeInstanceTypeOfferingsInput.IsNil()).To(BeFalse())
+		})
+	})
+	Context("NodeClass Agnostic Offerings", func() {
+		onDemandOffering := func(offerings corecloudprovider.Offerings, zone string) corecloudprovider.Offering {
+			offering, ok := lo.Find(offerings, func(o corecloudprovider.Offering) bool {
+				return o.Zone == zone && o.CapacityType == corev1beta1.CapacityTypeOnDemand
+			})
+			Expect(ok).To(BeTrue(), zone)
+			return offering
+		}
+		It("should return offerings in zones without a subnet of the nodeClass", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			m5, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+			Expect(ok).To(BeTrue())
+			Expect(onDemandOffering(m5.Offerings, "test-zone-1a-local").Available).To(BeFalse())
+
+			offerings, err := awsEnv.InstanceTypesProvider.ListOfferings(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.Uniq(lo.Map(offerings["m5.large"], func(o corecloudprovider.Offering, _ int) string { return o.Zone }))).To(
+				ConsistOf("test-zone-1a", "test-zone-1b", "test-zone-1c", "test-zone-1a-local"))
+			Expect(onDemandOffering(offerings["m5.large"], "test-zone-1a-local").Available).To(BeTrue())
+		})
+		It("should not apply the max hourly price of a nodeClass", func() {
+			nodeClass.Spec.MaxHourlyPrice = aws.String("0.01")
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			m5, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+			Expect(ok).To(BeTrue())
+			Expect(onDemandOffering(m5.Offerings, "test-zone-1a").Available).To(BeFalse())
+
+			offerings, err := awsEnv.InstanceTypesProvider.ListOfferings(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(onDemandOffering(offerings["m5.large"], "test-zone-1a").Available).To(BeTrue())
+			Expect(onDemandOffering(offerings["m5.large"], "test-zone-1a").Price).To(BeNumerically(">", 0.01))
+		})
+		It("should mark offerings with a recent insufficient capacity error as unavailable", func() {
+			awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "test", "m5.large", "test-zone-1b", corev1beta1.CapacityTypeOnDemand)
+			offerings, err := awsEnv.InstanceTypesProvider.ListOfferings(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(onDemandOffering(offerings["m5.large"], "test-zone-1a").Available).To(BeTrue())
+			Expect(onDemandOffering(offerings["m5.large"], "test-zone-1b").Available).To(BeFalse())
+		})
+	})
+	Context("Offering Scores", func() {
+		onDemandScore := func(scores []instancetype.OfferingScore, zone string) instancetype.OfferingScore {
+			score, ok := lo.Find(scores, func(s instancetype.OfferingScore) bool {
+				return s.Zone == zone && s.CapacityType == corev1beta1.CapacityTypeOnDemand
+			})
+			Expect(ok).To(BeTrue(), zone)
+			return score
+		}
+		It("should divide the price of an offering by the weighted resources of its instance type", func() {
+			scores, err := awsEnv.InstanceTypesProvider.ListOfferingScores(ctx, instancetype.DefaultOfferingScoreWeights)
+			Expect(err).ToNot(HaveOccurred())
+			// m5.large has 2 vCPUs and 8GiB of memory, m5.xlarge has 4 vCPUs and 16GiB of memory
+			large, ok := awsEnv.PricingProvider.OnDemandPrice("m5.large")
+			Expect(ok).To(BeTrue())
+			xlarge, ok := awsEnv.PricingProvider.OnDemandPrice("m5.xlarge")
+			Expect(ok).To(BeTrue())
+			Expect(onDemandScore(scores["m5.large"], "test-zone-1a").Score).To(BeNumerically("~", large/4))
+			Expect(onDemandScore(scores["m5.xlarge"], "test-zone-1a").Score).To(BeNumerically("~", xlarge/8))
+			Expect(onDemandScore(scores["m5.large"], "test-zone-1a").Price).To(Equal(large))
+		})
+		It("should apply the configured weights", func() {
+			scores, err := awsEnv.InstanceTypesProvider.ListOfferingScores(ctx, instancetype.OfferingScoreWeights{CPU: 1})
+			Expect(err).ToNot(HaveOccurred())
+			large, ok := awsEnv.PricingProvider.OnDemandPrice("m5.large")
+			Expect(ok).To(BeTrue())
+			Expect(onDemandScore(scores["m5.large"], "test-zone-1a").Score).To(BeNumerically("~", large/2))
+
+			scores, err = awsEnv.InstanceTypesProvider.ListOfferingScores(ctx, instancetype.OfferingScoreWeights{Memory: 1})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(onDemandScore(scores["m5.large"], "test-zone-1a").Score).To(BeNumerically("~", large/8))
+		})
+		It("should keep the availability of the scored offerings", func() {
+			awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "test", "m5.large", "test-zone-1b", corev1beta1.CapacityTypeOnDemand)
+			scores, err := awsEnv.InstanceTypesProvider.ListOfferingScores(ctx, instancetype.DefaultOfferingScoreWeights)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(onDemandScore(scores["m5.large"], "test-zone-1a").Available).To(BeTrue())
+			Expect(onDemandScore(scores["m5.large"], "test-zone-1b").Available).To(BeFalse())
+		})
+		DescribeTable("should fail with invalid weights",
+			func(weights instancetype.OfferingScoreWeights) {
+				_, err := awsEnv.InstanceTypesProvider.ListOfferingScores(ctx, weights)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("no weights", instancetype.OfferingScoreWeights{}),
+			Entry("negative cpu weight", instancetype.OfferingScoreWeights{CPU: -1, Memory: 1}),
+			Entry("negative memory weight", instancetype.OfferingScoreWeights{CPU: 1, Memory: -1}),
+		)
+	})
+	Context("Offering Snapshot", func() {
+		snapshotOffering := func(snapshot []instancetype.OfferingSnapshot, instanceType, zone, capacityType string) instancetype.OfferingSnapshot {
+			offering, ok := lo.Find(snapshot, func(o instancetype.OfferingSnapshot) bool {
+				return o.InstanceType == instanceType && o.Zone == zone && o.CapacityType == capacityType
+			})
+			Expect(ok).To(BeTrue(), fmt.Sprintf("%s/%s/%s", instanceType, zone, capacityType))
+			return offering
+		}
+		It("should reflect the cached prices of the offerings", func() {
+			snapshot, err := awsEnv.InstanceTypesProvider.SnapshotOfferings(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("m5.large")
+			Expect(ok).To(BeTrue())
+			Expect(snapshotOffering(snapshot, "m5.large", "test-zone-1a", corev1beta1.CapacityTypeOnDemand).Price).To(Equal(price))
+			spotPrice, ok := awsEnv.PricingProvider.SpotPrice("m5.large", "test-zone-1b")
+			Expect(ok).To(BeTrue())
+			Expect(snapshotOffering(snapshot, "m5.large", "test-zone-1b", corev1beta1.CapacityTypeSpot).Price).To(Equal(spotPrice))
+		})
+		It("should reflect offerings marked unavailable", func() {
+			awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "test", "m5.large", "test-zone-1b", corev1beta1.CapacityTypeSpot)
+			snapshot, err := awsEnv.InstanceTypesProvider.SnapshotOfferings(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshotOffering(snapshot, "m5.large", "test-zone-1b", corev1beta1.CapacityTypeSpot).Available).To(BeFalse())
+			Expect(snapshotOffering(snapshot, "m5.large", "test-zone-1b", corev1beta1.CapacityTypeOnDemand).Available).To(BeTrue())
+			Expect(snapshotOffering(snapshot, "m5.large", "test-zone-1a", corev1beta1.CapacityTypeSpot).Available).To(BeTrue())
+		})
+		It("should be sorted by instance type, zone and capacity type", func() {
+			snapshot, err := awsEnv.InstanceTypesProvider.SnapshotOfferings(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshot).ToNot(BeEmpty())
+			Expect(sort.SliceIsSorted(snapshot, func(i, j int) bool {
+				a, b := snapshot[i], snapshot[j]
+				if a.InstanceType != b.InstanceType {
+					return a.InstanceType < b.InstanceType
+				}
+				if a.Zone != b.Zone {
+					return a.Zone < b.Zone
+				}
+				return a.CapacityType < b.CapacityType
+			})).To(BeTrue())
+		})
+	})
+	Context("MaxHourlyPrice", func() {
+		It("should mark on-demand offerings priced above the max hourly price as unavailable", func() {
+			maxPrice, ok := awsEnv.PricingProvider.OnDemandPrice("m5.large")
+			Expect(ok).To(BeTrue())
+			nodeClass.Spec.MaxHourlyPrice = aws.String(fmt.Sprint(maxPrice))
+			its, err := awsEnv.InstanceTypesProvider.List(ctx, nil, nodeClass)
+			Expect(err).To(BeNil())
+			for _, it := range its {
+				for _, of := range it.Offerings {
+					if of.CapacityType == corev1beta1.CapacityTypeOnDemand && of.Price > maxPrice {
+						Expect(of.Available).To(BeFalse(), fmt.Sprintf("expected %s in %s to be unavailable", it.Name, of.Zone))
+					}
+				}
+			}
+			m5Large, ok := lo.Find(its, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+			Expect(ok).To(BeTrue())
+			Expect(lo.ContainsBy(m5Large.Offerings.Available(), func(of corecloudprovider.Offering) bool {
+				return of.CapacityType == corev1beta1.CapacityTypeOnDemand
+			})).To(BeTrue())
+			m5XLarge, ok := lo.Find(its, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.xlarge" })
+			Expect(ok).To(BeTrue())
+			Expect(lo.ContainsBy(m5XLarge.Offerings.Available(), func(of corecloudprovider.Offering) bool {
+				return of.CapacityType == corev1beta1.CapacityTypeOnDemand
+			})).To(BeFalse())
+		})
+		It("should mark spot offerings priced above the max hourly price as unavailable", func() {
+			now := time.Now()
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []*ec2.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     aws.String("m5.large"),
+						SpotPrice:        aws.String("0.004"),
+						Timestamp:        &now,
+					},
+					{
+						AvailabilityZone: aws.String("test-zone-1b"),
+						InstanceType:     aws.String("m5.large"),
+						SpotPrice:        aws.String("0.05"),
+						Timestamp:        &now,
+					},
+				},
+			})
+			Expect(awsEnv.PricingProvider.UpdateSpotPricing(ctx)).To(Succeed())
+			nodeClass.Spec.MaxHourlyPrice = aws.String("0.01")
+			its, err := awsEnv.InstanceTypesProvider.List(ctx, nil, nodeClass)
+			Expect(err).To(BeNil())
+			m5Large, ok := lo.Find(its, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+			Expect(ok).To(BeTrue())
+			spot := lo.Filter(m5Large.Offerings, func(of corecloudprovider.Offering, _ int) bool {
+				return of.CapacityType == corev1beta1.CapacityTypeSpot
+			})
+			Expect(lo.Filter(spot, func(of corecloudprovider.Offering, _ int) bool { return of.Available })).To(ConsistOf(
+				corecloudprovider.Offering{Zone: "test-zone-1a", CapacityType: corev1beta1.CapacityTypeSpot, Price: 0.004, Available: true},
+			))
+		})
+		It("should not mark offerings priced below the max hourly price as unavailable", func() {
+			its, err := awsEnv.InstanceTypesProvider.List(ctx, nil, nodeClass)
+			Expect(err).To(BeNil())
+			nodeClass.Spec.MaxHourlyPrice = aws.String("1000")
+			capped, err := awsEnv.InstanceTypesProvider.List(ctx, nil, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(lo.Map(capped, func(it *corecloudprovider.InstanceType, _ int) int { return len(it.Offerings.Available()) })).To(Equal(
+				lo.Map(its, func(it *corecloudprovider.InstanceType, _ int) int { return len(it.Offerings.Available()) }),
+			))
+		})
+		It("should only launch instance types priced at or below the max hourly price", func() {
+			maxPrice, ok := awsEnv.PricingProvider.OnDemandPrice("m5.large")
+			Expect(ok).To(BeTrue())
+			nodeClass.Spec.MaxHourlyPrice = aws.String(fmt.Sprint(maxPrice))
+			nodePool.Spec.Template.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.CapacityTypeOnDemand}}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			call := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			for _, ltc := range call.LaunchTemplateConfigs {
+				for _, override := range ltc.Overrides {
+					price, ok := awsEnv.PricingProvider.OnDemandPrice(aws.StringValue(override.InstanceType))
+					Expect(ok).To(BeTrue())
+					Expect(price).To(BeNumerically("<=", maxPrice))
+				}
+			}
+		})
+		It("should not launch instances when every offering is priced above the max hourly price", func() {
+			nodeClass.Spec.MaxHourlyPrice = aws.String("0.0001")
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+	})
 	It("should launch instances in local zones", func() {
 		nodeClass.Status.Subnets = []v1beta1.Subnet{
 			{
@@ -924,6 +1789,57 @@ var _ = Describe("InstanceTypeProvider", func() {
 		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 		ExpectScheduled(ctx, env.Client, pod)
 	})
+	Context("Computed Overhead", func() {
+		DescribeTable("should match the overhead and allocatable resources of the instance types used for scheduling",
+			func(kc *corev1beta1.KubeletConfiguration) {
+				instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, kc, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				for _, name := range []string{"m5.xlarge", "c6g.large", "t3.large", "g4dn.8xlarge"} {
+					it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == name })
+					Expect(ok).To(BeTrue())
+					overhead, err := awsEnv.InstanceTypesProvider.Overhead(ctx, name, kc, nodeClass)
+					Expect(err).ToNot(HaveOccurred())
+					expectResourcesEqual(overhead.Capacity, it.Capacity)
+					expectResourcesEqual(overhead.KubeReserved, it.Overhead.KubeReserved)
+					expectResourcesEqual(overhead.SystemReserved, it.Overhead.SystemReserved)
+					expectResourcesEqual(overhead.EvictionThreshold, it.Overhead.EvictionThreshold)
+					expectResourcesEqual(overhead.Allocatable, it.Allocatable())
+				}
+			},
+			Entry("with the default kubelet configuration", nil),
+			Entry("with kubelet overrides", &corev1beta1.KubeletConfiguration{
+				MaxPods:        lo.ToPtr[int32](20),
+				KubeReserved:   map[string]string{string(v1.ResourceCPU): "500m"},
+				SystemReserved: map[string]string{string(v1.ResourceMemory): "1Gi"},
+				EvictionHard:   map[string]string{"memory.available": "5%"},
+			}),
+		)
+		It("should return an error for an unknown instance type", func() {
+			_, err := awsEnv.InstanceTypesProvider.Overhead(ctx, "unknown.large", nil, nodeClass)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should include kubelet settings that are only set on the nodeClass", func() {
+			nodeClass.Spec.Kubelet = &v1beta1.KubeletConfiguration{
+				KubeReserved: map[string]string{string(v1.ResourceCPU): "1"},
+			}
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass.KubeletConfiguration(nil), nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.xlarge" })
+			Expect(ok).To(BeTrue())
+			overhead, err := awsEnv.InstanceTypesProvider.Overhead(ctx, "m5.xlarge", nil, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overhead.KubeReserved).To(HaveKeyWithValue(v1.ResourceCPU, resource.MustParse("1")))
+			expectResourcesEqual(overhead.KubeReserved, it.Overhead.KubeReserved)
+			expectResourcesEqual(overhead.Allocatable, it.Allocatable())
+		})
+		It("should include the extended resources of the nodeClass", func() {
+			nodeClass.Spec.ExtendedResources = map[v1.ResourceName]int64{"example.com/fpga": 2}
+			overhead, err := awsEnv.InstanceTypesProvider.Overhead(ctx, "m5.xlarge", nil, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(overhead.Capacity).To(HaveKeyWithValue(v1.ResourceName("example.com/fpga"), resource.MustParse("2")))
+			Expect(overhead.Allocatable).To(HaveKeyWithValue(v1.ResourceName("example.com/fpga"), resource.MustParse("2")))
+		})
+	})
 	Context("Overhead", func() {
 		var info *ec2.InstanceTypeInfo
 		BeforeEach(func() {
@@ -1042,6 +1958,78 @@ var _ = Describe("InstanceTypeProvider", func() {
 				Expect(it.Overhead.KubeReserved.Memory().String()).To(Equal("10Gi"))
 				Expect(it.Overhead.KubeReserved.StorageEphemeral().String()).To(Equal("2Gi"))
 			})
+			It("should add the resources reserved for networking components", func() {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+					NetworkingReserved: lo.ToPtr("cpu=100m,memory=200Mi"),
+				}))
+				nodePool.Spec.Template.Spec.Kubelet = &corev1beta1.KubeletConfiguration{}
+				amiFamily := amifamily.GetAMIFamily(nodeClass.Spec.AMIFamily, &amifamily.Options{})
+				it := instancetype.NewInstanceType(ctx,
+					info,
+					fake.DefaultRegion,
+					nodeClass.Spec.BlockDeviceMappings,
+					nodeClass.Spec.InstanceStorePolicy,
+					nodePool.Spec.Template.Spec.Kubelet.MaxPods,
+					nodePool.Spec.Template.Spec.Kubelet.PodsPerCore,
+					nodePool.Spec.Template.Spec.Kubelet.KubeReserved,
+					nodePool.Spec.Template.Spec.Kubelet.SystemReserved,
+					nodePool.Spec.Template.Spec.Kubelet.EvictionHard,
+					nodePool.Spec.Template.Spec.Kubelet.EvictionSoft,
+					amiFamily,
+					nil,
+				)
+				Expect(it.Overhead.KubeReserved.Cpu().String()).To(Equal("180m"))
+				Expect(it.Overhead.KubeReserved.Memory().String()).To(Equal("1093Mi"))
+				Expect(it.Overhead.KubeReserved.StorageEphemeral().String()).To(Equal("1Gi"))
+			})
+			It("should add the resources reserved for networking components to kube reserved when specified", func() {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+					NetworkingReserved: lo.ToPtr("cpu=100m,memory=200Mi"),
+				}))
+				nodePool.Spec.Template.Spec.Kubelet = &corev1beta1.KubeletConfiguration{
+					KubeReserved: map[string]string{
+						string(v1.ResourceCPU):    "2",
+						string(v1.ResourceMemory): "10Gi",
+					},
+				}
+				amiFamily := amifamily.GetAMIFamily(nodeClass.Spec.AMIFamily, &amifamily.Options{})
+				it := instancetype.NewInstanceType(ctx,
+					info,
+					fake.DefaultRegion,
+					nodeClass.Spec.BlockDeviceMappings,
+					nodeClass.Spec.InstanceStorePolicy,
+					nodePool.Spec.Template.Spec.Kubelet.MaxPods,
+					nodePool.Spec.Template.Spec.Kubelet.PodsPerCore,
+					nodePool.Spec.Template.Spec.Kubelet.KubeReserved,
+					nodePool.Spec.Template.Spec.Kubelet.SystemReserved,
+					nodePool.Spec.Template.Spec.Kubelet.EvictionHard,
+					nodePool.Spec.Template.Spec.Kubelet.EvictionSoft,
+					amiFamily,
+					nil,
+				)
+				Expect(it.Overhead.KubeReserved.Cpu().String()).To(Equal("2100m"))
+				Expect(it.Overhead.KubeReserved.Memory().String()).To(Equal("10440Mi"))
+			})
+			It("should reduce the allocatable of instance types by the resources reserved for networking components", func() {
+				nodePool.Spec.Template.Spec.Kubelet = &corev1beta1.KubeletConfiguration{}
+				amiFamily := amifamily.GetAMIFamily(nodeClass.Spec.AMIFamily, &amifamily.Options{})
+				newInstanceType := func() *corecloudprovider.InstanceType {
+					return instancetype.NewInstanceType(ctx, info, fake.DefaultRegion,
+						nodeClass.Spec.BlockDeviceMappings, nodeClass.Spec.InstanceStorePolicy,
+						nil, nil, nil, nil, nil, nil, amiFamily, nil)
+				}
+				allocatable := newInstanceType().Allocatable()
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+					NetworkingReserved: lo.ToPtr("cpu=100m,memory=200Mi"),
+				}))
+				reducedAllocatable := newInstanceType().Allocatable()
+				cpu, memory := allocatable.Cpu().DeepCopy(), allocatable.Memory().DeepCopy()
+				cpu.Sub(resource.MustParse("100m"))
+				memory.Sub(resource.MustParse("200Mi"))
+				Expect(reducedAllocatable.Cpu().Cmp(cpu)).To(BeZero())
+				Expect(reducedAllocatable.Memory().Cmp(memory)).To(BeZero())
+				Expect(reducedAllocatable.Pods().Cmp(*allocatable.Pods())).To(BeZero())
+			})
 		})
 		Context("Eviction Thresholds", func() {
 			BeforeEach(func() {
@@ -1843,6 +2831,30 @@ var _ = Describe("InstanceTypeProvider", func() {
 				HaveKeyWithValue(v1.LabelInstanceTypeStable, "p3.8xlarge"),
 				HaveKeyWithValue(v1.LabelTopologyZone, "test-zone-1b")))
 		})
+		It("should launch instances in a different zone when offerings are marked unavailable ahead of time", func() {
+			awsEnv.MarkOfferingsUnavailable(ctx, test.Offering{InstanceType: "p3.8xlarge", Zone: "test-zone-1a", CapacityType: corev1beta1.CapacityTypeOnDemand})
+			pod := coretest.UnschedulablePod(coretest.PodOptions{
+				NodeSelector: map[string]string{v1.LabelInstanceTypeStable: "p3.8xlarge"},
+				ResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1beta1.ResourceNVIDIAGPU: resource.MustParse("1")},
+					Limits:   v1.ResourceList{v1beta1.ResourceNVIDIAGPU: resource.MustParse("1")},
+				},
+			})
+			pod.Spec.Affinity = &v1.Affinity{NodeAffinity: &v1.NodeAffinity{PreferredDuringSchedulingIgnoredDuringExecution: []v1.PreferredSchedulingTerm{
+				{
+					Weight: 1, Preference: v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{
+						{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1a"}},
+					}},
+				},
+			}}}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			// the offering is unavailable before the first launch, so no insufficient capacity error has to be hit first
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(SatisfyAll(
+				HaveKeyWithValue(v1.LabelInstanceTypeStable, "p3.8xlarge"),
+				Not(HaveKeyWithValue(v1.LabelTopologyZone, "test-zone-1a"))))
+		})
 		It("should launch smaller instances than optimal if larger instance launch results in Insufficient Capacity Error", func() {
 			awsEnv.EC2API.InsufficientCapacityPools.Set([]fake.CapacityPool{
 				{CapacityType: corev1beta1.CapacityTypeOnDemand, InstanceType: "m5.xlarge", Zone: "test-zone-1a"},
@@ -1997,6 +3009,76 @@ var _ = Describe("InstanceTypeProvider", func() {
 			}
 			Expect(instanceTypeNames.Has("m5.xlarge"))
 		})
+		Context("Zone Cooldown", func() {
+			insufficientCapacity := func(instanceType, zone, capacityType string) {
+				awsEnv.UnavailableOfferingsCache.MarkUnavailableForFleetErr(ctx, &ec2.CreateFleetError{
+					ErrorCode: aws.String("InsufficientInstanceCapacity"),
+					LaunchTemplateAndOverrides: &ec2.LaunchTemplateAndOverridesResponse{
+						Overrides: &ec2.FleetLaunchTemplateOverrides{
+							InstanceType:     aws.String(instanceType),
+							AvailabilityZone: aws.String(zone),
+						},
+					},
+				}, capacityType)
+			}
+			availableZones := func(instanceTypeName, capacityType string) []string {
+				GinkgoHelper()
+				instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, &corev1beta1.KubeletConfiguration{}, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				instanceType, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == instanceTypeName })
+				Expect(ok).To(BeTrue())
+				return lo.FilterMap(instanceType.Offerings.Available(), func(o corecloudprovider.Offering, _ int) (string, bool) {
+					return o.Zone, o.CapacityType == capacityType
+				})
+			}
+			BeforeEach(func() {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+					ZoneCapacityCooldownThreshold: lo.ToPtr(3),
+				}))
+				ExpectApplied(ctx, env.Client, nodeClass)
+			})
+			It("should mark the zone as unavailable when enough instance types fail in the zone", func() {
+				for _, instanceType := range []string{"m5.xlarge", "c5.large", "p3.8xlarge"} {
+					insufficientCapacity(instanceType, "test-zone-1a", corev1beta1.CapacityTypeOnDemand)
+				}
+				zones := availableZones("m5.large", corev1beta1.CapacityTypeOnDemand)
+				Expect(zones).ToNot(ContainElement("test-zone-1a"))
+				Expect(zones).To(ContainElements("test-zone-1b", "test-zone-1c"))
+			})
+			It("should only mark the failed offerings as unavailable when fewer instance types fail than the threshold", func() {
+				for _, instanceType := range []string{"m5.xlarge", "c5.large"} {
+					insufficientCapacity(instanceType, "test-zone-1a", corev1beta1.CapacityTypeOnDemand)
+				}
+				Expect(availableZones("m5.large", corev1beta1.CapacityTypeOnDemand)).To(ContainElement("test-zone-1a"))
+				Expect(availableZones("m5.xlarge", corev1beta1.CapacityTypeOnDemand)).ToNot(ContainElement("test-zone-1a"))
+			})
+			It("should not count repeated failures of the same instance type towards the threshold", func() {
+				for i := 0; i < 3; i++ {
+					insufficientCapacity("m5.xlarge", "test-zone-1a", corev1beta1.CapacityTypeOnDemand)
+				}
+				Expect(availableZones("m5.large", corev1beta1.CapacityTypeOnDemand)).To(ContainElement("test-zone-1a"))
+			})
+			It("should not count failures across different zones towards the threshold", func() {
+				insufficientCapacity("m5.xlarge", "test-zone-1a", corev1beta1.CapacityTypeOnDemand)
+				insufficientCapacity("c5.large", "test-zone-1b", corev1beta1.CapacityTypeOnDemand)
+				insufficientCapacity("p3.8xlarge", "test-zone-1c", corev1beta1.CapacityTypeOnDemand)
+				Expect(availableZones("m5.large", corev1beta1.CapacityTypeOnDemand)).To(ContainElements("test-zone-1a", "test-zone-1b", "test-zone-1c"))
+			})
+			It("should only mark the zone as unavailable for the capacity type that failed", func() {
+				for _, instanceType := range []string{"m5.xlarge", "c5.large", "p3.8xlarge"} {
+					insufficientCapacity(instanceType, "test-zone-1a", corev1beta1.CapacityTypeSpot)
+				}
+				Expect(availableZones("m5.large", corev1beta1.CapacityTypeSpot)).ToNot(ContainElement("test-zone-1a"))
+				Expect(availableZones("m5.large", corev1beta1.CapacityTypeOnDemand)).To(ContainElement("test-zone-1a"))
+			})
+			It("should not mark zones as unavailable when the threshold is 0", func() {
+				ctx = options.ToContext(ctx, test.Options())
+				for _, instanceType := range []string{"m5.xlarge", "c5.large", "p3.8xlarge"} {
+					insufficientCapacity(instanceType, "test-zone-1a", corev1beta1.CapacityTypeOnDemand)
+				}
+				Expect(availableZones("m5.large", corev1beta1.CapacityTypeOnDemand)).To(ContainElement("test-zone-1a"))
+			})
+		})
 	})
 	Context("CapacityType", func() {
 		It("should default to on-demand", func() {
@@ -2374,6 +3456,16 @@ func uniqueInstanceTypeList(instanceTypesLists [][]*corecloudprovider.InstanceTy
 
 // generateSpotPricing creates a spot price history output for use in a mock that has all spot offerings discounted by 50%
 // vs the on-demand offering.
+// expectResourcesEqual asserts that both resource lists contain the same resources with equal quantities
+func expectResourcesEqual(actual, expected v1.ResourceList) {
+	GinkgoHelper()
+	Expect(actual).To(HaveLen(len(expected)))
+	for name, quantity := range expected {
+		Expect(actual).To(HaveKey(name))
+		Expect(quantity.Cmp(actual[name])).To(BeZero(), "resource %s", name)
+	}
+}
+
 func generateSpotPricing(cp *cloudprovider.CloudProvider, nodePool *corev1beta1.NodePool) *ec2.DescribeSpotPriceHistoryOutput {
 	rsp := &ec2.DescribeSpotPriceHistoryOutput{}
 	instanceTypes, err := cp.GetInstanceTypes(ctx, nodePool)
@@ -2405,3 +3497,12 @@ func generateSpotPricing(cp *cloudprovider.CloudProvider, nodePool *corev1beta1.
 	}
 	return rsp
 }
+
+// familyFilter is an InstanceTypeFilter that removes the instance types of a family
+type familyFilter string
+
+func (f familyFilter) Filter(_ context.Context, _ *v1beta1.EC2NodeClass, instanceTypes []*corecloudprovider.InstanceType) []*corecloudprovider.InstanceType {
+	return lo.Reject(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) bool {
+		return strings.Split(it.Name, ".")[0] == string(f)
+	})
+}
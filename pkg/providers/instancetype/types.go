@@ -59,7 +59,7 @@ func NewInstanceType(ctx context.Context, info *ec2.InstanceTypeInfo, region str
 		Offerings:    offerings,
 		Capacity:     computeCapacity(ctx, info, amiFamily, blockDeviceMappings, instanceStorePolicy, maxPods, podsPerCore),
 		Overhead: &cloudprovider.InstanceTypeOverhead{
-			KubeReserved:      kubeReservedResources(cpu(info), pods(ctx, info, amiFamily, maxPods, podsPerCore), ENILimitedPods(ctx, info), amiFamily, kubeReserved),
+			KubeReserved:      withNetworkingReserved(ctx, kubeReservedResources(cpu(info), pods(ctx, info, amiFamily, maxPods, podsPerCore), ENILimitedPods(ctx, info), amiFamily, kubeReserved)),
 			SystemReserved:    systemReservedResources(systemReserved),
 			EvictionThreshold: evictionThreshold(memory(ctx, info), ephemeralStorage(info, amiFamily, blockDeviceMappings, instanceStorePolicy), amiFamily, evictionHard, evictionSoft),
 		},
@@ -84,6 +84,7 @@ func computeRequirements(info *ec2.InstanceTypeInfo, offerings cloudprovider.Off
 		scheduling.NewRequirement(corev1beta1.CapacityTypeLabelKey, v1.NodeSelectorOpIn, lo.Map(offerings.Available(), func(o cloudprovider.Offering, _ int) string { return o.CapacityType })...),
 		// Well Known to AWS
 		scheduling.NewRequirement(v1beta1.LabelInstanceCPU, v1.NodeSelectorOpIn, fmt.Sprint(aws.Int64Value(info.VCpuInfo.DefaultVCpus))),
+		scheduling.NewRequirement(v1beta1.LabelInstanceCPUCores, v1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1beta1.LabelInstanceCPUManufacturer, v1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1beta1.LabelInstanceMemory, v1.NodeSelectorOpIn, fmt.Sprint(aws.Int64Value(info.MemoryInfo.SizeInMiB))),
 		scheduling.NewRequirement(v1beta1.LabelInstanceEBSBandwidth, v1.NodeSelectorOpDoesNotExist),
@@ -97,11 +98,20 @@ func computeRequirements(info *ec2.InstanceTypeInfo, offerings cloudprovider.Off
 		scheduling.NewRequirement(v1beta1.LabelInstanceGPUManufacturer, v1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1beta1.LabelInstanceGPUCount, v1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1beta1.LabelInstanceGPUMemory, v1.NodeSelectorOpDoesNotExist),
+		scheduling.NewRequirement(v1beta1.LabelInstanceGPUMemoryTotal, v1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1beta1.LabelInstanceAcceleratorName, v1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1beta1.LabelInstanceAcceleratorManufacturer, v1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1beta1.LabelInstanceAcceleratorCount, v1.NodeSelectorOpDoesNotExist),
+		scheduling.NewRequirement(v1beta1.LabelInstanceNUMANodes, v1.NodeSelectorOpDoesNotExist),
+		scheduling.NewRequirement(v1beta1.LabelInstanceMaxIPs, v1.NodeSelectorOpDoesNotExist),
+		scheduling.NewRequirement(v1beta1.LabelInstanceNetworkCards, v1.NodeSelectorOpDoesNotExist),
+		scheduling.NewRequirement(v1beta1.LabelInstanceMemoryPerVCPU, v1.NodeSelectorOpDoesNotExist),
+		scheduling.NewRequirement(v1beta1.LabelInstanceClusterPlacementSupported, v1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1beta1.LabelInstanceHypervisor, v1.NodeSelectorOpIn, aws.StringValue(info.Hypervisor)),
 		scheduling.NewRequirement(v1beta1.LabelInstanceEncryptionInTransitSupported, v1.NodeSelectorOpIn, fmt.Sprint(aws.BoolValue(info.NetworkInfo.EncryptionInTransitSupported))),
+		scheduling.NewRequirement(v1beta1.LabelInstanceBurstable, v1.NodeSelectorOpIn, fmt.Sprint(aws.BoolValue(info.BurstablePerformanceSupported))),
+		scheduling.NewRequirement(v1beta1.LabelInstanceEnclavesSupported, v1.NodeSelectorOpIn, fmt.Sprint(aws.StringValue(info.NitroEnclavesSupport) == ec2.NitroEnclavesSupportSupported)),
+		scheduling.NewRequirement(v1beta1.LabelInstanceSpotSupported, v1.NodeSelectorOpIn, fmt.Sprint(lo.Contains(aws.StringValueSlice(info.SupportedUsageClasses), ec2.UsageClassTypeSpot))),
 	)
 	// Instance Type Labels
 	instanceFamilyParts := instanceTypeScheme.FindStringSubmatch(aws.StringValue(info.InstanceType))
@@ -121,6 +131,23 @@ func computeRequirements(info *ec2.InstanceTypeInfo, offerings cloudprovider.Off
 	if bandwidth, ok := InstanceTypeBandwidthMegabits[aws.StringValue(info.InstanceType)]; ok {
 		requirements[v1beta1.LabelInstanceNetworkBandwidth].Insert(fmt.Sprint(bandwidth))
 	}
+	// The IPv4 addresses that all of the network interfaces of the instance type can hold
+	if info.NetworkInfo != nil && info.NetworkInfo.MaximumNetworkInterfaces != nil && info.NetworkInfo.Ipv4AddressesPerInterface != nil {
+		requirements.Get(v1beta1.LabelInstanceMaxIPs).Insert(fmt.Sprint(aws.Int64Value(info.NetworkInfo.MaximumNetworkInterfaces) * aws.Int64Value(info.NetworkInfo.Ipv4AddressesPerInterface)))
+	}
+	// The GiB of memory per vCPU, rounded down so that it can be bounded with Gt and Lt
+	if vcpus := aws.Int64Value(info.VCpuInfo.DefaultVCpus); vcpus != 0 {
+		requirements.Get(v1beta1.LabelInstanceMemoryPerVCPU).Insert(fmt.Sprint(aws.Int64Value(info.MemoryInfo.SizeInMiB) / 1024 / vcpus))
+	}
+	// The network cards that network interfaces of the instance type can be attached to
+	if info.NetworkInfo != nil && len(info.NetworkInfo.NetworkCards) != 0 {
+		requirements.Get(v1beta1.LabelInstanceNetworkCards).Insert(fmt.Sprint(len(info.NetworkInfo.NetworkCards)))
+	}
+	// Whether the instance type can be launched into a cluster placement group, which is left unset when EC2 doesn't
+	// report the placement group strategies the instance type supports
+	if info.PlacementGroupInfo != nil {
+		requirements.Get(v1beta1.LabelInstanceClusterPlacementSupported).Insert(fmt.Sprint(lo.Contains(aws.StringValueSlice(info.PlacementGroupInfo.SupportedStrategies), ec2.PlacementGroupStrategyCluster)))
+	}
 	// GPU Labels
 	if info.GpuInfo != nil && len(info.GpuInfo.Gpus) == 1 {
 		gpu := info.GpuInfo.Gpus[0]
@@ -129,6 +156,16 @@ func computeRequirements(info *ec2.InstanceTypeInfo, offerings cloudprovider.Off
 		requirements.Get(v1beta1.LabelInstanceGPUCount).Insert(fmt.Sprint(aws.Int64Value(gpu.Count)))
 		requirements.Get(v1beta1.LabelInstanceGPUMemory).Insert(fmt.Sprint(aws.Int64Value(gpu.MemoryInfo.SizeInMiB)))
 	}
+	// The MiB of memory across all of the GPUs of the instance type, which is also set for instance types with GPUs of
+	// different kinds
+	if info.GpuInfo != nil && len(info.GpuInfo.Gpus) != 0 {
+		requirements.Get(v1beta1.LabelInstanceGPUMemoryTotal).Insert(fmt.Sprint(lo.SumBy(info.GpuInfo.Gpus, func(gpu *ec2.GpuDeviceInfo) int64 {
+			if gpu.MemoryInfo == nil {
+				return 0
+			}
+			return aws.Int64Value(gpu.Count) * aws.Int64Value(gpu.MemoryInfo.SizeInMiB)
+		})))
+	}
 	// Accelerators
 	if info.InferenceAcceleratorInfo != nil && len(info.InferenceAcceleratorInfo.Accelerators) == 1 {
 		accelerator := info.InferenceAcceleratorInfo.Accelerators[0]
@@ -148,10 +185,16 @@ func computeRequirements(info *ec2.InstanceTypeInfo, offerings cloudprovider.Off
 		requirements.Get(v1beta1.LabelInstanceAcceleratorManufacturer).Insert(lowerKabobCase("AWS"))
 		requirements.Get(v1beta1.LabelInstanceAcceleratorCount).Insert(fmt.Sprint(awsNeurons(info)))
 	}
+	// Physical CPU Cores, omitted when the core count of the instance type isn't known
+	if cores, ok := cpuCores(info); ok {
+		requirements.Get(v1beta1.LabelInstanceCPUCores).Insert(fmt.Sprint(cores))
+	}
 	// CPU Manufacturer, valid options: aws, intel, amd
 	if info.ProcessorInfo != nil {
 		requirements.Get(v1beta1.LabelInstanceCPUManufacturer).Insert(lowerKabobCase(aws.StringValue(info.ProcessorInfo.Manufacturer)))
 	}
+	// NUMA Nodes
+	requirements.Get(v1beta1.LabelInstanceNUMANodes).Insert(fmt.Sprint(numaNodes(info)))
 	// EBS Max Bandwidth
 	if info.EbsInfo != nil && aws.StringValue(info.EbsInfo.EbsOptimizedSupport) == ec2.EbsOptimizedSupportDefault {
 		requirements.Get(v1beta1.LabelInstanceEBSBandwidth).Insert(fmt.Sprint(aws.Int64Value(info.EbsInfo.EbsOptimizedInfo.MaximumBandwidthInMbps)))
@@ -201,6 +244,21 @@ func cpu(info *ec2.InstanceTypeInfo) *resource.Quantity {
 	return resources.Quantity(fmt.Sprint(*info.VCpuInfo.DefaultVCpus))
 }
 
+// cpuCores returns the number of physical cores of the instance type, derived from the thread count per core
+// when EC2 doesn't report the core count directly
+func cpuCores(info *ec2.InstanceTypeInfo) (int64, bool) {
+	if info.VCpuInfo == nil {
+		return 0, false
+	}
+	if info.VCpuInfo.DefaultCores != nil {
+		return aws.Int64Value(info.VCpuInfo.DefaultCores), true
+	}
+	if threads := aws.Int64Value(info.VCpuInfo.DefaultThreadsPerCore); threads > 0 {
+		return aws.Int64Value(info.VCpuInfo.DefaultVCpus) / threads, true
+	}
+	return 0, false
+}
+
 func memory(ctx context.Context, info *ec2.InstanceTypeInfo) *resource.Quantity {
 	sizeInMib := *info.MemoryInfo.SizeInMiB
 	// Gravitons have an extra 64 MiB of cma reserved memory that we can't use
@@ -385,6 +443,18 @@ func kubeReservedResources(cpus, pods, eniLimitedPods *resource.Quantity, amiFam
 	}))
 }
 
+// withNetworkingReserved adds the resources that are reserved for networking components to the kube-reserved
+// resources. The option is validated at startup, so it's parsed without checking for errors.
+func withNetworkingReserved(ctx context.Context, kubeReserved v1.ResourceList) v1.ResourceList {
+	networkingReserved, _ := options.FromContext(ctx).NetworkingReservedResources()
+	for name, quantity := range networkingReserved {
+		reserved := kubeReserved[name]
+		reserved.Add(quantity)
+		kubeReserved[name] = reserved
+	}
+	return kubeReserved
+}
+
 func evictionThreshold(memory *resource.Quantity, storage *resource.Quantity, amiFamily amifamily.AMIFamily, evictionHard map[string]string, evictionSoft map[string]string) v1.ResourceList {
 	overhead := v1.ResourceList{
 		v1.ResourceMemory:           resource.MustParse("100Mi"),
@@ -16,10 +16,12 @@ package launchtemplate
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"math"
 	"net"
+	"regexp"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -41,20 +43,32 @@ import (
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/tracing"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 )
 
+const (
+	maxLaunchTemplateNameLength        = 128
+	maxLaunchTemplateDescriptionLength = 255
+)
+
+// invalidLaunchTemplateNameCharacters matches the characters that EC2 doesn't allow in launch template names
+var invalidLaunchTemplateNameCharacters = regexp.MustCompile(`[^a-zA-Z0-9().\-/_]`)
+
 type Provider interface {
 	EnsureAll(context.Context, *v1beta1.EC2NodeClass, *corev1beta1.NodeClaim,
-		[]*cloudprovider.InstanceType, string, map[string]string) ([]*LaunchTemplate, error)
+		[]*cloudprovider.InstanceType, string, string, map[string]string) ([]*LaunchTemplate, error)
+	RenderAll(context.Context, *v1beta1.EC2NodeClass, *corev1beta1.NodeClaim,
+		[]*cloudprovider.InstanceType, string, string, map[string]string) ([]*RenderedLaunchTemplate, error)
 	DeleteAll(context.Context, *v1beta1.EC2NodeClass) error
 	InvalidateCache(context.Context, string, string)
 	ResolveClusterCIDR(context.Context) error
@@ -66,6 +80,16 @@ type LaunchTemplate struct {
 	ImageID       string
 }
 
+// RenderedLaunchTemplate is the request that Karpenter makes to create a launch template, along with the instance types
+// that are launched with it and its decoded user data
+type RenderedLaunchTemplate struct {
+	Name               string                         `json:"name"`
+	InstanceTypes      []string                       `json:"instanceTypes"`
+	UserData           string                         `json:"userData"`
+	LaunchTemplateData *ec2.RequestLaunchTemplateData `json:"launchTemplateData"`
+	TagSpecifications  []*ec2.TagSpecification        `json:"tagSpecifications"`
+}
+
 type DefaultProvider struct {
 	sync.Mutex
 	ec2api                ec2iface.EC2API
@@ -74,6 +98,7 @@ type DefaultProvider struct {
 	securityGroupProvider securitygroup.Provider
 	subnetProvider        subnet.Provider
 	cache                 *cache.Cache
+	clusterCache          *cache.Cache
 	cm                    *pretty.ChangeMonitor
 	KubeDNSIP             net.IP
 	CABundle              *string
@@ -81,7 +106,7 @@ type DefaultProvider struct {
 	ClusterCIDR           atomic.Pointer[string]
 }
 
-func NewDefaultProvider(ctx context.Context, cache *cache.Cache, ec2api ec2iface.EC2API, eksapi eksiface.EKSAPI, amiFamily *amifamily.Resolver,
+func NewDefaultProvider(ctx context.Context, cache *cache.Cache, clusterCache *cache.Cache, ec2api ec2iface.EC2API, eksapi eksiface.EKSAPI, amiFamily *amifamily.Resolver,
 	securityGroupProvider securitygroup.Provider, subnetProvider subnet.Provider,
 	caBundle *string, startAsync <-chan struct{}, kubeDNSIP net.IP, clusterEndpoint string) *DefaultProvider {
 	l := &DefaultProvider{
@@ -91,6 +116,7 @@ func NewDefaultProvider(ctx context.Context, cache *cache.Cache, ec2api ec2iface
 		securityGroupProvider: securityGroupProvider,
 		subnetProvider:        subnetProvider,
 		cache:                 cache,
+		clusterCache:          clusterCache,
 		CABundle:              caBundle,
 		cm:                    pretty.NewChangeMonitor(),
 		KubeDNSIP:             kubeDNSIP,
@@ -110,16 +136,14 @@ func NewDefaultProvider(ctx context.Context, cache *cache.Cache, ec2api ec2iface
 }
 
 func (p *DefaultProvider) EnsureAll(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim,
-	instanceTypes []*cloudprovider.InstanceType, capacityType string, tags map[string]string) ([]*LaunchTemplate, error) {
+	instanceTypes []*cloudprovider.InstanceType, capacityType string, capacityReservationID string, tags map[string]string) ([]*LaunchTemplate, error) {
+	ctx, span := tracing.Start(ctx, "launchtemplate.EnsureAll", append(tracing.NodeClaim(nodeClaim), tracing.NodeClass(nodeClass))...)
+	defer span.End()
 
 	p.Lock()
 	defer p.Unlock()
 
-	options, err := p.createAMIOptions(ctx, nodeClass, lo.Assign(nodeClaim.Labels, map[string]string{corev1beta1.CapacityTypeLabelKey: capacityType}), tags)
-	if err != nil {
-		return nil, err
-	}
-	resolvedLaunchTemplates, err := p.amiFamily.Resolve(nodeClass, nodeClaim, instanceTypes, capacityType, options)
+	resolvedLaunchTemplates, err := p.resolveLaunchTemplates(ctx, nodeClass, nodeClaim, instanceTypes, capacityType, capacityReservationID, tags)
 	if err != nil {
 		return nil, err
 	}
@@ -135,6 +159,53 @@ func (p *DefaultProvider) EnsureAll(ctx context.Context, nodeClass *v1beta1.EC2N
 	return launchTemplates, nil
 }
 
+// resolveLaunchTemplates resolves the launch templates for the instance types, which EnsureAll ensures exist and
+// RenderAll renders without creating them
+func (p *DefaultProvider) resolveLaunchTemplates(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim,
+	instanceTypes []*cloudprovider.InstanceType, capacityType string, capacityReservationID string, tags map[string]string) ([]*amifamily.LaunchTemplate, error) {
+	// Labels from the NodeClaim take precedence over the registration labels defined on the EC2NodeClass
+	options, err := p.createAMIOptions(ctx, nodeClass, lo.Assign(nodeClass.Spec.NodeLabels, nodeClaim.Labels, capacityTypeLabel(capacityType)), tags)
+	if err != nil {
+		return nil, err
+	}
+	resolvedLaunchTemplates, err := p.amiFamily.Resolve(nodeClass, nodeClaim, instanceTypes, capacityType, options)
+	if err != nil {
+		return nil, err
+	}
+	for _, resolvedLaunchTemplate := range resolvedLaunchTemplates {
+		resolvedLaunchTemplate.CapacityReservationID = capacityReservationID
+	}
+	return resolvedLaunchTemplates, nil
+}
+
+// RenderAll returns the launch templates that EnsureAll would create for the instance types, without creating them
+func (p *DefaultProvider) RenderAll(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim,
+	instanceTypes []*cloudprovider.InstanceType, capacityType string, capacityReservationID string, tags map[string]string) ([]*RenderedLaunchTemplate, error) {
+	resolvedLaunchTemplates, err := p.resolveLaunchTemplates(ctx, nodeClass, nodeClaim, instanceTypes, capacityType, capacityReservationID, tags)
+	if err != nil {
+		return nil, err
+	}
+	var launchTemplates []*RenderedLaunchTemplate
+	for _, resolvedLaunchTemplate := range resolvedLaunchTemplates {
+		input, err := p.createLaunchTemplateInput(ctx, resolvedLaunchTemplate)
+		if err != nil {
+			return nil, err
+		}
+		userData, err := base64.StdEncoding.DecodeString(aws.StringValue(input.LaunchTemplateData.UserData))
+		if err != nil {
+			return nil, fmt.Errorf("decoding user data, %w", err)
+		}
+		launchTemplates = append(launchTemplates, &RenderedLaunchTemplate{
+			Name:               aws.StringValue(input.LaunchTemplateName),
+			InstanceTypes:      lo.Map(resolvedLaunchTemplate.InstanceTypes, func(it *cloudprovider.InstanceType, _ int) string { return it.Name }),
+			UserData:           string(userData),
+			LaunchTemplateData: input.LaunchTemplateData,
+			TagSpecifications:  input.TagSpecifications,
+		})
+	}
+	return launchTemplates, nil
+}
+
 // InvalidateCache deletes a launch template from cache if it exists
 func (p *DefaultProvider) InvalidateCache(ctx context.Context, ltName string, ltID string) {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("launch-template-name", ltName, "launch-template-id", ltID))
@@ -150,6 +221,43 @@ func LaunchTemplateName(options *amifamily.LaunchTemplate) string {
 	return fmt.Sprintf("%s/%d", v1beta1.Group, lo.Must(hashstructure.Hash(options, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})))
 }
 
+// ReadableLaunchTemplateName returns the name of the launch template with the cluster and EC2NodeClass names between
+// the group and the hash. The names are truncated so that the name fits in EC2's limit, with each name keeping at least
+// half of the remaining length when both are long, while the hash is kept whole so that names stay unique.
+func ReadableLaunchTemplateName(options *amifamily.LaunchTemplate) string {
+	hash := strings.TrimPrefix(LaunchTemplateName(options), v1beta1.Group+"/")
+	clusterName := invalidLaunchTemplateNameCharacters.ReplaceAllString(options.ClusterName, "-")
+	nodeClassName := invalidLaunchTemplateNameCharacters.ReplaceAllString(options.NodeClassName, "-")
+	remaining := maxLaunchTemplateNameLength - len(v1beta1.Group) - len(hash) - 3
+	clusterName = lo.Substring(clusterName, 0, uint(lo.Max([]int{remaining / 2, remaining - len(nodeClassName)})))
+	nodeClassName = lo.Substring(nodeClassName, 0, uint(remaining-len(clusterName)))
+	return fmt.Sprintf("%s/%s/%s/%s", v1beta1.Group, clusterName, nodeClassName, hash)
+}
+
+func launchTemplateName(ctx context.Context, lt *amifamily.LaunchTemplate) string {
+	if options.FromContext(ctx).ReadableLaunchTemplateNames {
+		return ReadableLaunchTemplateName(lt)
+	}
+	return LaunchTemplateName(lt)
+}
+
+func versionDescription(ctx context.Context, lt *amifamily.LaunchTemplate) *string {
+	if !options.FromContext(ctx).ReadableLaunchTemplateNames {
+		return nil
+	}
+	description := fmt.Sprintf("Karpenter launch template of EC2NodeClass %s in cluster %s", lt.NodeClassName, lt.ClusterName)
+	return aws.String(lo.Substring(description, 0, maxLaunchTemplateDescriptionLength))
+}
+
+// capacityTypeLabel returns the capacity type label that nodes register with, which is empty when the capacity type
+// isn't known until the instance is launched, e.g. for fleet requests that mix spot and on-demand
+func capacityTypeLabel(capacityType string) map[string]string {
+	if capacityType == "" {
+		return nil
+	}
+	return map[string]string{corev1beta1.CapacityTypeLabelKey: capacityType}
+}
+
 func (p *DefaultProvider) createAMIOptions(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, labels, tags map[string]string) (*amifamily.Options, error) {
 	// Remove any labels passed into userData that are prefixed with "node-restriction.kubernetes.io" or "kops.k8s.io" since the kubelet can't
 	// register the node with any labels from this domain: https://kubernetes.io/docs/reference/access-authn-authz/admission-controllers/#noderestriction
@@ -173,16 +281,19 @@ func (p *DefaultProvider) createAMIOptions(ctx context.Context, nodeClass *v1bet
 	if len(nodeClass.Status.SecurityGroups) == 0 {
 		return nil, fmt.Errorf("no security groups are present in the status")
 	}
+	clusterEndpoint, caBundle := p.clusterEndpointAndCABundle(ctx)
 	options := &amifamily.Options{
 		ClusterName:         options.FromContext(ctx).ClusterName,
-		ClusterEndpoint:     p.ClusterEndpoint,
+		ClusterEndpoint:     clusterEndpoint,
 		ClusterCIDR:         p.ClusterCIDR.Load(),
 		InstanceProfile:     instanceProfile,
 		InstanceStorePolicy: nodeClass.Spec.InstanceStorePolicy,
+		TrustedCABundle:     nodeClass.Spec.TrustedCABundle,
+		RegistryMirrors:     nodeClass.Spec.RegistryMirrors,
 		SecurityGroups:      nodeClass.Status.SecurityGroups,
 		Tags:                tags,
 		Labels:              labels,
-		CABundle:            p.CABundle,
+		CABundle:            caBundle,
 		KubeDNSIP:           p.KubeDNSIP,
 		NodeClassName:       nodeClass.Name,
 	}
@@ -201,7 +312,7 @@ func (p *DefaultProvider) createAMIOptions(ctx context.Context, nodeClass *v1bet
 
 func (p *DefaultProvider) ensureLaunchTemplate(ctx context.Context, options *amifamily.LaunchTemplate) (*ec2.LaunchTemplate, error) {
 	var launchTemplate *ec2.LaunchTemplate
-	name := LaunchTemplateName(options)
+	name := launchTemplateName(ctx, options)
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("launch-template-name", name))
 	// Read from cache
 	if launchTemplate, ok := p.cache.Get(name); ok {
@@ -233,6 +344,19 @@ func (p *DefaultProvider) ensureLaunchTemplate(ctx context.Context, options *ami
 }
 
 func (p *DefaultProvider) createLaunchTemplate(ctx context.Context, options *amifamily.LaunchTemplate) (*ec2.LaunchTemplate, error) {
+	input, err := p.createLaunchTemplateInput(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	output, err := p.ec2api.CreateLaunchTemplateWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	log.FromContext(ctx).WithValues("id", aws.StringValue(output.LaunchTemplate.LaunchTemplateId)).V(1).Info("created launch template")
+	return output.LaunchTemplate, nil
+}
+
+func (p *DefaultProvider) createLaunchTemplateInput(ctx context.Context, options *amifamily.LaunchTemplate) (*ec2.CreateLaunchTemplateInput, error) {
 	userData, err := options.UserData.Script()
 	if err != nil {
 		return nil, err
@@ -245,10 +369,36 @@ func (p *DefaultProvider) createLaunchTemplate(ctx context.Context, options *ami
 		launchTemplateDataTags = append(launchTemplateDataTags, &ec2.LaunchTemplateTagSpecificationRequest{ResourceType: aws.String(ec2.ResourceTypeSpotInstancesRequest), Tags: utils.MergeTags(options.Tags)})
 	}
 	networkInterfaces := p.generateNetworkInterfaces(options)
-	output, err := p.ec2api.CreateLaunchTemplateWithContext(ctx, &ec2.CreateLaunchTemplateInput{
-		LaunchTemplateName: aws.String(LaunchTemplateName(options)),
+	var creditSpecification *ec2.CreditSpecificationRequest
+	if options.CPUCredits != "" {
+		creditSpecification = &ec2.CreditSpecificationRequest{CpuCredits: aws.String(options.CPUCredits)}
+	}
+	var enclaveOptions *ec2.LaunchTemplateEnclaveOptionsRequest
+	if options.NitroEnclaves {
+		enclaveOptions = &ec2.LaunchTemplateEnclaveOptionsRequest{Enabled: aws.Bool(true)}
+	}
+	var cpuOptions *ec2.LaunchTemplateCpuOptionsRequest
+	if options.CPUOptions != nil {
+		cpuOptions = &ec2.LaunchTemplateCpuOptionsRequest{
+			CoreCount:      options.CPUOptions.CoreCount,
+			ThreadsPerCore: options.CPUOptions.ThreadsPerCore,
+		}
+	}
+	var capacityReservationSpecification *ec2.LaunchTemplateCapacityReservationSpecificationRequest
+	if options.CapacityReservationID != "" {
+		capacityReservationSpecification = &ec2.LaunchTemplateCapacityReservationSpecificationRequest{
+			CapacityReservationTarget: &ec2.CapacityReservationTarget{CapacityReservationId: aws.String(options.CapacityReservationID)},
+		}
+	}
+	return &ec2.CreateLaunchTemplateInput{
+		LaunchTemplateName: aws.String(launchTemplateName(ctx, options)),
+		VersionDescription: versionDescription(ctx, options),
 		LaunchTemplateData: &ec2.RequestLaunchTemplateData{
-			BlockDeviceMappings: p.blockDeviceMappings(options.BlockDeviceMappings),
+			BlockDeviceMappings:              p.blockDeviceMappings(options.BlockDeviceMappings),
+			CapacityReservationSpecification: capacityReservationSpecification,
+			CpuOptions:                       cpuOptions,
+			CreditSpecification:              creditSpecification,
+			EnclaveOptions:                   enclaveOptions,
 			IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileSpecificationRequest{
 				Name: aws.String(options.InstanceProfile),
 			},
@@ -264,6 +414,7 @@ func (p *DefaultProvider) createLaunchTemplate(ctx context.Context, options *ami
 				HttpProtocolIpv6:        options.MetadataOptions.HTTPProtocolIPv6,
 				HttpPutResponseHopLimit: options.MetadataOptions.HTTPPutResponseHopLimit,
 				HttpTokens:              options.MetadataOptions.HTTPTokens,
+				InstanceMetadataTags:    lo.Ternary(options.Hardened, aws.String(ec2.LaunchTemplateInstanceMetadataTagsStateDisabled), nil),
 			},
 			NetworkInterfaces: networkInterfaces,
 			TagSpecifications: launchTemplateDataTags,
@@ -274,18 +425,13 @@ func (p *DefaultProvider) createLaunchTemplate(ctx context.Context, options *ami
 				Tags:         utils.MergeTags(options.Tags, map[string]string{v1beta1.TagManagedLaunchTemplate: options.ClusterName, v1beta1.LabelNodeClass: options.NodeClassName}),
 			},
 		},
-	})
-	if err != nil {
-		return nil, err
-	}
-	log.FromContext(ctx).WithValues("id", aws.StringValue(output.LaunchTemplate.LaunchTemplateId)).V(1).Info("created launch template")
-	return output.LaunchTemplate, nil
+	}, nil
 }
 
 // generateNetworkInterfaces generates network interfaces for the launch template.
 func (p *DefaultProvider) generateNetworkInterfaces(options *amifamily.LaunchTemplate) []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest {
 	if options.EFACount != 0 {
-		return lo.Times(options.EFACount, func(i int) *ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest {
+		networkInterfaces := lo.Times(options.EFACount, func(i int) *ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest {
 			return &ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
 				NetworkCardIndex: lo.ToPtr(int64(i)),
 				// Some networking magic to ensure that one network card has higher priority than all the others (important if an instance needs a public IP w/o adding an EIP to every network card)
@@ -297,8 +443,23 @@ func (p *DefaultProvider) generateNetworkInterfaces(options *amifamily.LaunchTem
 				AssociatePublicIpAddress: options.AssociatePublicIPAddress,
 			}
 		})
+		// The EFA interfaces already cover the first EFACount network cards
+		if options.NetworkCardIndex >= int64(options.EFACount) {
+			networkInterfaces = append(networkInterfaces, p.networkCardInterface(options))
+		}
+		return networkInterfaces
+	}
+	if options.NetworkCardIndex != 0 {
+		return []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
+			{
+				AssociatePublicIpAddress: options.AssociatePublicIPAddress,
+				DeviceIndex:              aws.Int64(0),
+				NetworkCardIndex:         aws.Int64(0),
+				Groups:                   lo.Map(options.SecurityGroups, func(s v1beta1.SecurityGroup, _ int) *string { return aws.String(s.ID) }),
+			},
+			p.networkCardInterface(options),
+		}
 	}
-
 	if options.AssociatePublicIPAddress != nil {
 		return []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
 			{
@@ -311,6 +472,16 @@ func (p *DefaultProvider) generateNetworkInterfaces(options *amifamily.LaunchTem
 	return nil
 }
 
+// networkCardInterface generates the additional network interface that is attached to the network card of the launch
+// template. The primary network interface stays on network card 0, so the additional interface is never the primary one.
+func (p *DefaultProvider) networkCardInterface(options *amifamily.LaunchTemplate) *ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest {
+	return &ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
+		NetworkCardIndex: aws.Int64(options.NetworkCardIndex),
+		DeviceIndex:      aws.Int64(1),
+		Groups:           lo.Map(options.SecurityGroups, func(s v1beta1.SecurityGroup, _ int) *string { return aws.String(s.ID) }),
+	}
+}
+
 func (p *DefaultProvider) blockDeviceMappings(blockDeviceMappings []*v1beta1.BlockDeviceMapping) []*ec2.LaunchTemplateBlockDeviceMappingRequest {
 	if len(blockDeviceMappings) == 0 {
 		// The EC2 API fails with empty slices and expects nil.
@@ -426,6 +597,41 @@ func (p *DefaultProvider) DeleteAll(ctx context.Context, nodeClass *v1beta1.EC2N
 	return nil
 }
 
+// clusterEndpointAndCABundle returns the cluster endpoint and CA bundle that nodes bootstrap with. When cluster
+// discovery is enabled, they're discovered with DescribeCluster and cached, and the configured values are only used
+// when discovery fails. Failures are cached for a short time so that launches don't each retry the discovery.
+func (p *DefaultProvider) clusterEndpointAndCABundle(ctx context.Context) (string, *string) {
+	if !options.FromContext(ctx).ClusterDiscovery {
+		return p.ClusterEndpoint, p.CABundle
+	}
+	clusterName := options.FromContext(ctx).ClusterName
+	if cached, ok := p.clusterCache.Get(clusterName); ok {
+		if cluster, ok := cached.(*eks.Cluster); ok {
+			return aws.StringValue(cluster.Endpoint), cluster.CertificateAuthority.Data
+		}
+		return p.ClusterEndpoint, p.CABundle
+	}
+	out, err := p.eksapi.DescribeClusterWithContext(ctx, &eks.DescribeClusterInput{
+		Name: aws.String(clusterName),
+	})
+	if err == nil && (aws.StringValue(out.Cluster.Endpoint) == "" || out.Cluster.CertificateAuthority == nil || aws.StringValue(out.Cluster.CertificateAuthority.Data) == "") {
+		err = fmt.Errorf("no endpoint or certificate authority found in DescribeCluster response")
+	}
+	if err != nil {
+		p.clusterCache.Set(clusterName, err, awscache.ClusterDiscoveryFailureTTL)
+		if p.cm.HasChanged("cluster-discovery-error", err.Error()) {
+			log.FromContext(ctx).Error(err, "failed discovering cluster endpoint and CA bundle, falling back to the configured values")
+		}
+		return p.ClusterEndpoint, p.CABundle
+	}
+	p.cm.HasChanged("cluster-discovery-error", nil)
+	p.clusterCache.SetDefault(clusterName, out.Cluster)
+	if endpoint := aws.StringValue(out.Cluster.Endpoint); endpoint != p.ClusterEndpoint {
+		log.FromContext(ctx).WithValues("cluster-endpoint", endpoint).V(1).Info("discovered cluster endpoint")
+	}
+	return aws.StringValue(out.Cluster.Endpoint), out.Cluster.CertificateAuthority.Data
+}
+
 func (p *DefaultProvider) ResolveClusterCIDR(ctx context.Context) error {
 	if p.ClusterCIDR.Load() != nil {
 		return nil
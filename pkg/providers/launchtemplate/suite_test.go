@@ -20,7 +20,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"testing"
@@ -29,10 +32,12 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
 	admv1alpha1 "github.com/awslabs/amazon-eks-ami/nodeadm/api/v1alpha1"
 	opstatus "github.com/awslabs/operatorpkg/status"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/pelletier/go-toml/v2"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -93,7 +98,7 @@ var _ = BeforeSuite(func() {
 	awsEnv = test.NewEnvironment(ctx, env)
 
 	fakeClock = &clock.FakeClock{}
-	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.AccountProvider, events.NewRecorder(&record.FakeRecorder{}),
 		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider, awsEnv.SubnetProvider)
 	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
 	prov = provisioning.NewProvisioner(env.Client, events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster)
@@ -618,6 +623,109 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				Expect(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.Iops).To(BeNil())
 			})
 		})
+		It("should raise the root volume size to the size of the AMI's root snapshot", func() {
+			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2
+			nodeClass.Spec.BlockDeviceMappings = []*v1beta1.BlockDeviceMapping{
+				{
+					DeviceName: aws.String("/dev/xvda"),
+					EBS: &v1beta1.BlockDevice{
+						VolumeType: aws.String("gp3"),
+						VolumeSize: lo.ToPtr(resource.MustParse("20Gi")),
+					},
+				},
+				{
+					DeviceName: aws.String("/dev/xvdb"),
+					EBS: &v1beta1.BlockDevice{
+						VolumeType: aws.String("gp3"),
+						VolumeSize: lo.ToPtr(resource.MustParse("10Gi")),
+					},
+				},
+			}
+			for i := range nodeClass.Status.AMIs {
+				nodeClass.Status.AMIs[i].RootDeviceName = "/dev/xvda"
+				nodeClass.Status.AMIs[i].RootSnapshotSize = lo.ToPtr(resource.MustParse("50Gi"))
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.BlockDeviceMappings).To(HaveLen(2))
+				Expect(aws.Int64Value(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeSize)).To(Equal(int64(50)))
+				Expect(aws.Int64Value(ltInput.LaunchTemplateData.BlockDeviceMappings[1].Ebs.VolumeSize)).To(Equal(int64(10)))
+			})
+			// The block device mappings of the EC2NodeClass are unchanged
+			Expect(nodeClass.Spec.BlockDeviceMappings[0].EBS.VolumeSize.String()).To(Equal("20Gi"))
+		})
+		It("should use the volume type of the EC2NodeClass for the root volume when raising it to the size of the AMI's root snapshot", func() {
+			// The root snapshot of the AMI defaults to gp2, which the volume type of the EC2NodeClass overrides
+			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2
+			nodeClass.Spec.BlockDeviceMappings = []*v1beta1.BlockDeviceMapping{
+				{
+					DeviceName: aws.String("/dev/xvda"),
+					EBS: &v1beta1.BlockDevice{
+						VolumeType: aws.String("gp3"),
+						VolumeSize: lo.ToPtr(resource.MustParse("20Gi")),
+					},
+				},
+			}
+			for i := range nodeClass.Status.AMIs {
+				nodeClass.Status.AMIs[i].RootDeviceName = "/dev/xvda"
+				nodeClass.Status.AMIs[i].RootSnapshotSize = lo.ToPtr(resource.MustParse("50Gi"))
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.BlockDeviceMappings).To(HaveLen(1))
+				Expect(aws.StringValue(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeType)).To(Equal("gp3"))
+				Expect(aws.Int64Value(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeSize)).To(Equal(int64(50)))
+			})
+		})
+		It("should override the volume type of the AMI's root snapshot without overriding its size", func() {
+			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2
+			nodeClass.Spec.BlockDeviceMappings = []*v1beta1.BlockDeviceMapping{
+				{
+					DeviceName: aws.String("/dev/xvda"),
+					EBS: &v1beta1.BlockDevice{
+						VolumeType: aws.String("gp3"),
+					},
+				},
+			}
+			for i := range nodeClass.Status.AMIs {
+				nodeClass.Status.AMIs[i].RootDeviceName = "/dev/xvda"
+				nodeClass.Status.AMIs[i].RootSnapshotSize = lo.ToPtr(resource.MustParse("50Gi"))
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.BlockDeviceMappings).To(HaveLen(1))
+				Expect(aws.StringValue(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeType)).To(Equal("gp3"))
+				// EC2 uses the size of the root snapshot when the launch template doesn't specify one
+				Expect(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeSize).To(BeNil())
+			})
+		})
+		It("should not change a root volume that is larger than the AMI's root snapshot", func() {
+			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2
+			for i := range nodeClass.Status.AMIs {
+				nodeClass.Status.AMIs[i].RootDeviceName = "/dev/xvda"
+				nodeClass.Status.AMIs[i].RootSnapshotSize = lo.ToPtr(resource.MustParse("8Gi"))
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.Int64Value(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeSize)).To(Equal(int64(20)))
+			})
+		})
 		It("should use custom block device mapping", func() {
 			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2
 			nodeClass.Spec.BlockDeviceMappings = []*v1beta1.BlockDeviceMapping{
@@ -1361,6 +1469,92 @@ var _ = Describe("LaunchTemplate Provider", func() {
 			ExpectScheduled(ctx, env.Client, pod)
 			ExpectLaunchTemplatesCreatedWithUserDataContaining("--local-disks raid0")
 		})
+		Context("UserDataFragments", func() {
+			BeforeEach(func() {
+				nodePool.Spec.Template.Spec.Requirements = append(nodePool.Spec.Template.Spec.Requirements, corev1beta1.NodeSelectorRequirementWithMinValues{
+					NodeSelectorRequirement: v1.NodeSelectorRequirement{
+						Key:      v1.LabelInstanceTypeStable,
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{"m5.large"},
+					},
+				})
+			})
+			It("should merge in user data fragments that match the instance family", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2
+				nodeClass.Spec.UserData = aws.String("echo custom")
+				nodeClass.Spec.UserDataFragments = []v1beta1.UserDataFragment{
+					{InstanceFamilies: []string{"m5"}, UserData: "echo m5-fragment"},
+				}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("echo custom", "echo m5-fragment", "/etc/eks/bootstrap.sh")
+				userData := ExpectUserDataExistsFromCreatedLaunchTemplates()
+				for _, ud := range userData {
+					Expect(strings.Index(ud, "echo custom")).To(BeNumerically("<", strings.Index(ud, "echo m5-fragment")))
+					Expect(strings.Index(ud, "echo m5-fragment")).To(BeNumerically("<", strings.Index(ud, "/etc/eks/bootstrap.sh")))
+				}
+			})
+			It("should merge in user data fragments that match the instance type", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2
+				nodeClass.Spec.UserDataFragments = []v1beta1.UserDataFragment{
+					{InstanceTypes: []string{"m5.large"}, UserData: "echo m5-large-fragment"},
+				}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("echo m5-large-fragment")
+			})
+			It("should not merge in user data fragments that don't match the instance type", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2
+				nodeClass.Spec.UserDataFragments = []v1beta1.UserDataFragment{
+					{InstanceFamilies: []string{"p3", "g4dn"}, UserData: "echo gpu-fragment"},
+					{InstanceTypes: []string{"m5.xlarge"}, UserData: "echo m5-xlarge-fragment"},
+				}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("echo gpu-fragment", "echo m5-xlarge-fragment")
+			})
+			It("should create separate launch templates for instance types with different user data fragments", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2
+				nodeClass.Spec.UserDataFragments = []v1beta1.UserDataFragment{
+					{InstanceTypes: []string{"m5.xlarge"}, UserData: "echo m5-xlarge-fragment"},
+				}
+				nodePool.Spec.Template.Spec.Requirements[len(nodePool.Spec.Template.Spec.Requirements)-1].Values = []string{"m5.large", "m5.xlarge"}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				userData := ExpectUserDataExistsFromCreatedLaunchTemplates()
+				Expect(userData).To(HaveLen(2))
+				Expect(lo.CountBy(userData, func(ud string) bool { return strings.Contains(ud, "echo m5-xlarge-fragment") })).To(Equal(1))
+			})
+			It("should merge Bottlerocket user data fragments into the TOML settings", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyBottlerocket
+				nodeClass.Spec.UserData = aws.String("[settings.kernel.sysctl]\n\"net.core.somaxconn\" = \"1024\"")
+				nodeClass.Spec.UserDataFragments = []v1beta1.UserDataFragment{
+					{InstanceFamilies: []string{"m5"}, UserData: "[settings.kernel.sysctl]\n\"vm.max_map_count\" = \"262144\""},
+				}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					config := &bootstrap.BottlerocketConfig{}
+					Expect(config.UnmarshalTOML(userData)).To(Succeed())
+					Expect(config.SettingsRaw).To(HaveKey("kernel"))
+					Expect(config.SettingsRaw["kernel"]).To(HaveKeyWithValue("sysctl", HaveKeyWithValue("net.core.somaxconn", "1024")))
+					Expect(config.SettingsRaw["kernel"]).To(HaveKeyWithValue("sysctl", HaveKeyWithValue("vm.max_map_count", "262144")))
+				})
+			})
+		})
 		Context("Bottlerocket", func() {
 			BeforeEach(func() {
 				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyBottlerocket
@@ -1935,6 +2129,59 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				})
 				Expect(expectedImageIds.Equal(actualImageIds)).To(BeTrue())
 			})
+			It("should reference the AMI matching each instance type's architecture in CreateFleet overrides", func() {
+				nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}}}
+				nodeClass.Status.AMIs = []v1beta1.AMI{
+					{
+						ID: "ami-amd64",
+						Requirements: []v1.NodeSelectorRequirement{
+							{Key: v1.LabelArchStable, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.ArchitectureAmd64}},
+						},
+					},
+					{
+						ID: "ami-arm64",
+						Requirements: []v1.NodeSelectorRequirement{
+							{Key: v1.LabelArchStable, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.ArchitectureArm64}},
+						},
+					},
+				}
+				nodePool.Spec.Template.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+					{
+						NodeSelectorRequirement: v1.NodeSelectorRequirement{
+							Key:      v1.LabelInstanceTypeStable,
+							Operator: v1.NodeSelectorOpIn,
+							Values:   []string{"m5.large", "m5.xlarge", "t4g.medium", "t4g.xlarge"},
+						},
+					},
+				}
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+
+				imageForTemplate := map[string]string{}
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					imageForTemplate[*ltInput.LaunchTemplateName] = *ltInput.LaunchTemplateData.ImageId
+				})
+				Expect(sets.New(lo.Values(imageForTemplate)...)).To(Equal(sets.New("ami-amd64", "ami-arm64")))
+
+				expectedImage := map[string]string{
+					"m5.large":   "ami-amd64",
+					"m5.xlarge":  "ami-amd64",
+					"t4g.medium": "ami-arm64",
+					"t4g.xlarge": "ami-arm64",
+				}
+				Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+				createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+				Expect(createFleetInput.LaunchTemplateConfigs).To(HaveLen(2))
+				for _, ltc := range createFleetInput.LaunchTemplateConfigs {
+					templateImage := imageForTemplate[*ltc.LaunchTemplateSpecification.LaunchTemplateName]
+					for _, override := range ltc.Overrides {
+						Expect(*override.ImageId).To(Equal(expectedImage[*override.InstanceType]))
+						Expect(*override.ImageId).To(Equal(templateImage))
+					}
+				}
+			})
 			It("should create a launch template with the newest compatible AMI when multiple amis are discovered", func() {
 				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []*ec2.Image{
 					{
@@ -1959,7 +2206,7 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				}})
 				nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}}}
 				ExpectApplied(ctx, env.Client, nodeClass)
-				controller := status.NewController(env.Client, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+				controller := status.NewController(env.Client, awsEnv.AccountProvider, awsEnv.LaunchTemplateProvider)
 				ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
 				nodePool.Spec.Template.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
 					{
@@ -2037,7 +2284,7 @@ var _ = Describe("LaunchTemplate Provider", func() {
 					{Tags: map[string]string{"Name": "test-subnet-3"}},
 				}
 				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
-				controller := status.NewController(env.Client, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+				controller := status.NewController(env.Client, awsEnv.AccountProvider, awsEnv.LaunchTemplateProvider)
 				ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
 				pod := coretest.UnschedulablePod()
 				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
@@ -2050,7 +2297,7 @@ var _ = Describe("LaunchTemplate Provider", func() {
 					{Tags: map[string]string{"Name": "test-subnet-2"}},
 				}
 				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
-				controller := status.NewController(env.Client, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+				controller := status.NewController(env.Client, awsEnv.AccountProvider, awsEnv.LaunchTemplateProvider)
 				ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
 				pod := coretest.UnschedulablePod()
 				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
@@ -2058,6 +2305,26 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				input := awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Pop()
 				Expect(len(input.LaunchTemplateData.NetworkInterfaces)).To(BeNumerically("==", 0))
 			})
+			DescribeTable(
+				"should override the value inferred from the subnets when 'AssociatePublicIPAddress' is set",
+				func(subnetName string, setValue bool) {
+					nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{
+						{Tags: map[string]string{"Name": subnetName}},
+					}
+					nodeClass.Spec.AssociatePublicIPAddress = lo.ToPtr(setValue)
+					ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+					controller := status.NewController(env.Client, awsEnv.AccountProvider, awsEnv.LaunchTemplateProvider)
+					ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+					pod := coretest.UnschedulablePod()
+					ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+					ExpectScheduled(ctx, env.Client, pod)
+					input := awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Pop()
+					Expect(input.LaunchTemplateData.NetworkInterfaces).To(HaveLen(1))
+					Expect(*input.LaunchTemplateData.NetworkInterfaces[0].AssociatePublicIpAddress).To(Equal(setValue))
+				},
+				Entry("false on a subnet that assigns public IPv4 addresses", "test-subnet-2", false),
+				Entry("true on a subnet that doesn't assign public IPv4 addresses", "test-subnet-1", true),
+			)
 			DescribeTable(
 				"should set 'AssociatePublicIPAddress' based on EC2NodeClass",
 				func(setValue, expectedValue, isEFA bool) {
@@ -2090,18 +2357,61 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				ExpectLaunchTemplatesCreatedWithUserDataContaining("--dns-cluster-ip '10.0.10.100'")
 			})
 		})
-		Context("Windows Custom UserData", func() {
+		Context("EC2NodeClass Cluster DNS", func() {
 			BeforeEach(func() {
-				nodePool.Spec.Template.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelOSStable, Operator: v1.NodeSelectorOpIn, Values: []string{string(v1.Windows)}}}}
-				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyWindows2022
-				nodePool.Spec.Template.Spec.Kubelet = &corev1beta1.KubeletConfiguration{MaxPods: lo.ToPtr[int32](110)}
+				nodeClass.Spec.Kubelet = &v1beta1.KubeletConfiguration{ClusterDNS: []string{"10.0.10.200"}}
 			})
-			It("should merge and bootstrap with custom user data", func() {
-				content, err := os.ReadFile("testdata/windows_userdata_input.golden")
-				Expect(err).To(BeNil())
-				nodeClass.Spec.UserData = aws.String(string(content))
-				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
-				Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(nodePool), nodePool)).To(Succeed())
+			It("should override the discovered cluster DNS IP for AL2", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("--dns-cluster-ip '10.0.10.200'")
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining(awsEnv.LaunchTemplateProvider.KubeDNSIP.String())
+			})
+			It("should override the cluster DNS IP set on the NodePool", func() {
+				nodePool.Spec.Template.Spec.Kubelet = &corev1beta1.KubeletConfiguration{ClusterDNS: []string{"10.0.10.100"}}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("--dns-cluster-ip '10.0.10.200'")
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("10.0.10.100")
+			})
+			It("should override the discovered cluster DNS IP in the nodeadm config for AL2023", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2023
+				nodeClass.Spec.Kubelet.ClusterDNS = []string{"10.0.10.200", "10.0.10.201"}
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					configs := ExpectUserDataCreatedWithNodeConfigs(userData)
+					Expect(len(configs)).To(Equal(1))
+					Expect(string(configs[0].Spec.Kubelet.Config["clusterDNS"].Raw)).To(Equal(`["10.0.10.200","10.0.10.201"]`))
+				}
+			})
+			It("should override the discovered cluster DNS IP in the settings for Bottlerocket", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyBottlerocket
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					config := &bootstrap.BottlerocketConfig{}
+					Expect(config.UnmarshalTOML(userData)).To(Succeed())
+					Expect(lo.FromPtr(config.Settings.Kubernetes.ClusterDNSIP)).To(Equal("10.0.10.200"))
+				})
+			})
+			It("should override the discovered cluster DNS IP for Windows", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyWindows2022
+				nodePool.Spec.Template.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelOSStable, Operator: v1.NodeSelectorOpIn, Values: []string{string(v1.Windows)}}}}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 				pod := coretest.UnschedulablePod(coretest.PodOptions{
 					NodeSelector: map[string]string{
 						v1.LabelOSStable:     string(v1.Windows),
@@ -2110,13 +2420,68 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				})
 				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 				ExpectScheduled(ctx, env.Client, pod)
-				content, err = os.ReadFile("testdata/windows_userdata_merged.golden")
-				Expect(err).To(BeNil())
-				ExpectLaunchTemplatesCreatedWithUserData(fmt.Sprintf(string(content), corev1beta1.NodePoolLabelKey, nodePool.Name))
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("-DNSClusterIP '10.0.10.200'")
 			})
-			It("should bootstrap when custom user data is empty", func() {
-				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
-				Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(nodePool), nodePool)).To(Succeed())
+		})
+		Context("EC2NodeClass Node Labels", func() {
+			BeforeEach(func() {
+				nodeClass.Spec.NodeLabels = map[string]string{"example.com/team": "team-a", "example.com/tier": "backend"}
+			})
+			It("should pass the EC2NodeClass node labels as kubelet args for AL2", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("example.com/team=team-a", "example.com/tier=backend")
+			})
+			It("should prefer the NodePool labels when the same label is set on the EC2NodeClass", func() {
+				nodePool.Spec.Template.Labels = map[string]string{"example.com/team": "team-b"}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("example.com/team=team-b", "example.com/tier=backend")
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("example.com/team=team-a")
+			})
+			It("should pass the EC2NodeClass node labels in the kubelet flags for AL2023", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2023
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					configs := ExpectUserDataCreatedWithNodeConfigs(userData)
+					Expect(len(configs)).To(Equal(1))
+					labelFlag, ok := lo.Find(configs[0].Spec.Kubelet.Flags, func(flag string) bool {
+						return strings.HasPrefix(flag, "--node-labels")
+					})
+					Expect(ok).To(BeTrue())
+					Expect(labelFlag).To(ContainSubstring("example.com/team=team-a"))
+					Expect(labelFlag).To(ContainSubstring("example.com/tier=backend"))
+				}
+			})
+			It("should pass the EC2NodeClass node labels in the settings for Bottlerocket", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyBottlerocket
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					config := &bootstrap.BottlerocketConfig{}
+					Expect(config.UnmarshalTOML(userData)).To(Succeed())
+					Expect(config.Settings.Kubernetes.NodeLabels).To(HaveKeyWithValue("example.com/team", "team-a"))
+					Expect(config.Settings.Kubernetes.NodeLabels).To(HaveKeyWithValue("example.com/tier", "backend"))
+				})
+			})
+			It("should pass the EC2NodeClass node labels as kubelet args for Windows", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyWindows2022
+				nodePool.Spec.Template.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelOSStable, Operator: v1.NodeSelectorOpIn, Values: []string{string(v1.Windows)}}}}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 				pod := coretest.UnschedulablePod(coretest.PodOptions{
 					NodeSelector: map[string]string{
 						v1.LabelOSStable:     string(v1.Windows),
@@ -2125,14 +2490,517 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				})
 				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 				ExpectScheduled(ctx, env.Client, pod)
-				content, err := os.ReadFile("testdata/windows_userdata_unmerged.golden")
-				Expect(err).To(BeNil())
-				ExpectLaunchTemplatesCreatedWithUserData(fmt.Sprintf(string(content), corev1beta1.NodePoolLabelKey, nodePool.Name))
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("example.com/team=team-a", "example.com/tier=backend")
 			})
 		})
-	})
-	Context("Detailed Monitoring", func() {
-		It("should default detailed monitoring to off", func() {
+		Context("EC2NodeClass Node Taints", func() {
+			BeforeEach(func() {
+				nodeClass.Spec.NodeTaints = []v1.Taint{
+					{Key: "example.com/not-ready", Effect: v1.TaintEffectNoSchedule},
+					{Key: "example.com/dedicated", Value: "team-a", Effect: v1.TaintEffectNoExecute},
+				}
+			})
+			It("should pass the EC2NodeClass taints as kubelet args for AL2", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining(`--register-with-taints="example.com/not-ready=:NoSchedule,example.com/dedicated=team-a:NoExecute"`)
+			})
+			It("should prefer the NodePool taints when a taint with the same key and effect is set on the EC2NodeClass", func() {
+				nodePool.Spec.Template.Spec.Taints = []v1.Taint{{Key: "example.com/dedicated", Value: "team-b", Effect: v1.TaintEffectNoExecute}}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod(coretest.PodOptions{Tolerations: []v1.Toleration{{Operator: v1.TolerationOpExists}}})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("example.com/dedicated=team-b:NoExecute", "example.com/not-ready=:NoSchedule")
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("example.com/dedicated=team-a:NoExecute")
+			})
+			It("should pass the EC2NodeClass taints in the nodeadm config for AL2023", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2023
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					configs := ExpectUserDataCreatedWithNodeConfigs(userData)
+					Expect(len(configs)).To(Equal(1))
+					taintsRaw, ok := configs[0].Spec.Kubelet.Config["registerWithTaints"]
+					Expect(ok).To(BeTrue())
+					taints := []v1.Taint{}
+					Expect(yaml.Unmarshal(taintsRaw.Raw, &taints)).To(Succeed())
+					Expect(taints).To(ConsistOf(nodeClass.Spec.NodeTaints))
+				}
+			})
+			It("should pass the EC2NodeClass taints in the settings for Bottlerocket", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyBottlerocket
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					config := &bootstrap.BottlerocketConfig{}
+					Expect(config.UnmarshalTOML(userData)).To(Succeed())
+					Expect(config.Settings.Kubernetes.NodeTaints).To(HaveKeyWithValue("example.com/not-ready", []string{":NoSchedule"}))
+					Expect(config.Settings.Kubernetes.NodeTaints).To(HaveKeyWithValue("example.com/dedicated", []string{"team-a:NoExecute"}))
+				})
+			})
+			It("should pass the EC2NodeClass taints as kubelet args for Windows", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyWindows2022
+				nodePool.Spec.Template.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelOSStable, Operator: v1.NodeSelectorOpIn, Values: []string{string(v1.Windows)}}}}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod(coretest.PodOptions{
+					NodeSelector: map[string]string{
+						v1.LabelOSStable:     string(v1.Windows),
+						v1.LabelWindowsBuild: "10.0.20348",
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("example.com/not-ready=:NoSchedule", "example.com/dedicated=team-a:NoExecute")
+			})
+		})
+		Context("EC2NodeClass Trusted CA Bundle", func() {
+			var certificates []string
+			BeforeEach(func() {
+				certificates = []string{test.CACertificate("internal-ca-1"), test.CACertificate("internal-ca-2")}
+				nodeClass.Spec.TrustedCABundle = lo.ToPtr(strings.Join(certificates, ""))
+			})
+			It("should not update the trust store when the trusted CA bundle isn't set", func() {
+				nodeClass.Spec.TrustedCABundle = nil
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("karpenter-trusted-ca-bundle", "update-ca-trust")
+			})
+			DescribeTable("should write the trusted CA bundle to the trust store and restart containerd",
+				func(amiFamily string) {
+					nodeClass.Spec.AMIFamily = lo.ToPtr(amiFamily)
+					ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+					pod := coretest.UnschedulablePod()
+					ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+					ExpectScheduled(ctx, env.Client, pod)
+					ExpectLaunchTemplatesCreatedWithUserDataContaining(append(certificates,
+						"/etc/pki/ca-trust/source/anchors/karpenter-trusted-ca-bundle.pem",
+						"update-ca-trust extract",
+						"/usr/local/share/ca-certificates/karpenter-trusted-ca-bundle.crt",
+						"update-ca-certificates",
+						"systemctl restart containerd",
+					)...)
+				},
+				Entry("AL2", v1beta1.AMIFamilyAL2),
+				Entry("Ubuntu", v1beta1.AMIFamilyUbuntu),
+			)
+			It("should write the trusted CA bundle to the trust store in a shell script for AL2023", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2023
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					archive, err := mime.NewArchive(userData)
+					Expect(err).ToNot(HaveOccurred())
+					scripts := lo.Filter([]mime.Entry(archive), func(entry mime.Entry, _ int) bool { return entry.ContentType == mime.ContentTypeShellScript })
+					Expect(scripts).To(HaveLen(1))
+					for _, certificate := range certificates {
+						Expect(scripts[0].Content).To(ContainSubstring(certificate))
+					}
+					Expect(scripts[0].Content).To(ContainSubstring("update-ca-trust extract"))
+					Expect(scripts[0].Content).To(ContainSubstring("systemctl restart containerd"))
+				}
+			})
+			It("should add the trusted CA bundle to the PKI settings for Bottlerocket", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyBottlerocket
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					config := &bootstrap.BottlerocketConfig{}
+					Expect(config.UnmarshalTOML(userData)).To(Succeed())
+					pki, ok := config.Settings.PKI["karpenter-trusted-ca-bundle"]
+					Expect(ok).To(BeTrue())
+					Expect(lo.FromPtr(pki.Trusted)).To(BeTrue())
+					Expect(base64.StdEncoding.DecodeString(lo.FromPtr(pki.Data))).To(BeEquivalentTo(*nodeClass.Spec.TrustedCABundle))
+				})
+			})
+			It("should import each certificate of the trusted CA bundle into the root store for Windows", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyWindows2022
+				nodePool.Spec.Template.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelOSStable, Operator: v1.NodeSelectorOpIn, Values: []string{string(v1.Windows)}}}}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod(coretest.PodOptions{
+					NodeSelector: map[string]string{
+						v1.LabelOSStable:     string(v1.Windows),
+						v1.LabelWindowsBuild: "10.0.20348",
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					for _, certificate := range certificates {
+						Expect(userData).To(ContainSubstring(strings.TrimSpace(certificate)))
+					}
+					Expect(strings.Count(userData, `Import-Certificate -FilePath $KarpenterTrustedCAFile -CertStoreLocation Cert:\LocalMachine\Root`)).To(Equal(2))
+					Expect(userData).To(ContainSubstring("Restart-Service containerd"))
+				}
+			})
+			It("should not change the UserData when AMIFamily is Custom", func() {
+				nodeClass.Spec.UserData = aws.String("special user data")
+				nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}}}
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyCustom
+				nodeClass.Status.AMIs = []v1beta1.AMI{
+					{
+						ID: "ami-123",
+						Requirements: []v1.NodeSelectorRequirement{
+							{Key: v1.LabelArchStable, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.ArchitectureAmd64}},
+						},
+					},
+				}
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserData("special user data")
+			})
+		})
+		Context("EC2NodeClass Registry Mirrors", func() {
+			BeforeEach(func() {
+				nodeClass.Spec.RegistryMirrors = []v1beta1.RegistryMirror{
+					{Registry: "docker.io", Endpoints: []string{"https://mirror.example.com", "http://10.0.0.10:5000"}},
+					{Registry: "*", Endpoints: []string{"https://default-mirror.example.com"}},
+				}
+			})
+			It("should write a containerd hosts.toml for each registry for AL2023", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2023
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					archive, err := mime.NewArchive(userData)
+					Expect(err).ToNot(HaveOccurred())
+					scripts := lo.Filter([]mime.Entry(archive), func(entry mime.Entry, _ int) bool { return entry.ContentType == mime.ContentTypeShellScript })
+					Expect(scripts).To(HaveLen(1))
+					Expect(scripts[0].Content).To(ContainSubstring("/etc/containerd/certs.d/docker.io/hosts.toml"))
+					Expect(scripts[0].Content).To(ContainSubstring(`[host."https://mirror.example.com"]`))
+					Expect(scripts[0].Content).To(ContainSubstring(`[host."http://10.0.0.10:5000"]`))
+					Expect(scripts[0].Content).To(ContainSubstring("/etc/containerd/certs.d/_default/hosts.toml"))
+					Expect(scripts[0].Content).To(ContainSubstring(`[host."https://default-mirror.example.com"]`))
+				}
+			})
+			It("should add the registry mirrors to the container-registry settings for Bottlerocket", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyBottlerocket
+				nodeClass.Spec.UserData = aws.String(`
+[settings.container-registry.mirrors]
+"docker.io" = ["https://user-mirror.example.com"]
+"public.ecr.aws" = ["https://ecr-mirror.example.com"]
+`)
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					type mirror struct {
+						Registry string   `toml:"registry"`
+						Endpoint []string `toml:"endpoint"`
+					}
+					config := struct {
+						Settings struct {
+							ContainerRegistry struct {
+								Mirrors []mirror `toml:"mirrors"`
+							} `toml:"container-registry"`
+						} `toml:"settings"`
+					}{}
+					Expect(toml.Unmarshal(userData, &config)).To(Succeed())
+					mirrors := lo.SliceToMap(config.Settings.ContainerRegistry.Mirrors, func(m mirror) (string, []string) {
+						return m.Registry, m.Endpoint
+					})
+					Expect(mirrors).To(Equal(map[string][]string{
+						"docker.io":      {"https://mirror.example.com", "http://10.0.0.10:5000"},
+						"*":              {"https://default-mirror.example.com"},
+						"public.ecr.aws": {"https://ecr-mirror.example.com"},
+					}))
+				})
+			})
+			It("should not configure registry mirrors for AL2", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("/etc/containerd/certs.d", "mirror.example.com")
+			})
+		})
+		Context("EC2NodeClass Kubelet", func() {
+			BeforeEach(func() {
+				nodeClass.Spec.Kubelet = &v1beta1.KubeletConfiguration{
+					MaxPods:                     lo.ToPtr[int32](20),
+					SystemReserved:              map[string]string{string(v1.ResourceCPU): "100m"},
+					KubeReserved:                map[string]string{string(v1.ResourceMemory): "500Mi"},
+					EvictionHard:                map[string]string{"memory.available": "5%"},
+					EvictionSoft:                map[string]string{"nodefs.available": "15%"},
+					EvictionSoftGracePeriod:     map[string]metav1.Duration{"nodefs.available": {Duration: time.Minute}},
+					ImageGCHighThresholdPercent: lo.ToPtr[int32](80),
+					ImageGCLowThresholdPercent:  lo.ToPtr[int32](60),
+				}
+			})
+			It("should pass the EC2NodeClass kubelet configuration as kubelet args for AL2", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining(
+					"--max-pods=20",
+					"--system-reserved=cpu=100m",
+					"--kube-reserved=memory=500Mi",
+					"--eviction-hard=memory.available<5%",
+					"--eviction-soft=nodefs.available<15%",
+					"--eviction-soft-grace-period=nodefs.available=1m0s",
+					"--image-gc-high-threshold=80",
+					"--image-gc-low-threshold=60",
+				)
+			})
+			It("should prefer the EC2NodeClass kubelet configuration over the NodePool kubelet configuration", func() {
+				nodePool.Spec.Template.Spec.Kubelet = &corev1beta1.KubeletConfiguration{
+					MaxPods:      lo.ToPtr[int32](10),
+					EvictionHard: map[string]string{"memory.available": "10%"},
+					PodsPerCore:  lo.ToPtr[int32](2),
+				}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("--max-pods=20", "--eviction-hard=memory.available<5%", "--pods-per-core=2")
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("--max-pods=10", "memory.available<10%")
+			})
+			It("should override the NodePool image GC thresholds together when the EC2NodeClass sets either one", func() {
+				nodeClass.Spec.Kubelet = &v1beta1.KubeletConfiguration{ImageGCHighThresholdPercent: lo.ToPtr[int32](60)}
+				nodePool.Spec.Template.Spec.Kubelet = &corev1beta1.KubeletConfiguration{
+					ImageGCHighThresholdPercent: lo.ToPtr[int32](90),
+					ImageGCLowThresholdPercent:  lo.ToPtr[int32](70),
+				}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("--image-gc-high-threshold=60")
+				ExpectLaunchTemplatesCreatedWithUserDataNotContaining("--image-gc-high-threshold=90", "--image-gc-low-threshold")
+			})
+			It("should pass the EC2NodeClass kubelet configuration in the nodeadm config for AL2023", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2023
+				awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
+				awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(lo.ToPtr("10.100.0.0/16"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				for _, userData := range ExpectUserDataExistsFromCreatedLaunchTemplates() {
+					configs := ExpectUserDataCreatedWithNodeConfigs(userData)
+					Expect(len(configs)).To(Equal(1))
+					config := configs[0].Spec.Kubelet.Config
+					Expect(string(config["maxPods"].Raw)).To(Equal("20"))
+					Expect(string(config["systemReserved"].Raw)).To(Equal(`{"cpu":"100m"}`))
+					Expect(string(config["kubeReserved"].Raw)).To(Equal(`{"memory":"500Mi"}`))
+					Expect(string(config["evictionHard"].Raw)).To(Equal(`{"memory.available":"5%"}`))
+					Expect(string(config["evictionSoft"].Raw)).To(Equal(`{"nodefs.available":"15%"}`))
+					Expect(string(config["evictionSoftGracePeriod"].Raw)).To(Equal(`{"nodefs.available":"1m0s"}`))
+					Expect(string(config["imageGCHighThresholdPercent"].Raw)).To(Equal("80"))
+					Expect(string(config["imageGCLowThresholdPercent"].Raw)).To(Equal("60"))
+				}
+			})
+			It("should pass the EC2NodeClass kubelet configuration in the settings for Bottlerocket", func() {
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyBottlerocket
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					userData, err := base64.StdEncoding.DecodeString(*ltInput.LaunchTemplateData.UserData)
+					Expect(err).To(BeNil())
+					config := &bootstrap.BottlerocketConfig{}
+					Expect(config.UnmarshalTOML(userData)).To(Succeed())
+					Expect(lo.FromPtr(config.Settings.Kubernetes.MaxPods)).To(Equal(20))
+					Expect(config.Settings.Kubernetes.SystemReserved).To(Equal(map[string]string{string(v1.ResourceCPU): "100m"}))
+					Expect(config.Settings.Kubernetes.KubeReserved).To(Equal(map[string]string{string(v1.ResourceMemory): "500Mi"}))
+					Expect(config.Settings.Kubernetes.EvictionHard).To(Equal(map[string]string{"memory.available": "5%"}))
+					Expect(lo.FromPtr(config.Settings.Kubernetes.ImageGCHighThresholdPercent)).To(Equal("80"))
+					Expect(lo.FromPtr(config.Settings.Kubernetes.ImageGCLowThresholdPercent)).To(Equal("60"))
+				})
+			})
+		})
+		Context("Windows Custom UserData", func() {
+			BeforeEach(func() {
+				nodePool.Spec.Template.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelOSStable, Operator: v1.NodeSelectorOpIn, Values: []string{string(v1.Windows)}}}}
+				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyWindows2022
+				nodePool.Spec.Template.Spec.Kubelet = &corev1beta1.KubeletConfiguration{MaxPods: lo.ToPtr[int32](110)}
+			})
+			It("should merge and bootstrap with custom user data", func() {
+				content, err := os.ReadFile("testdata/windows_userdata_input.golden")
+				Expect(err).To(BeNil())
+				nodeClass.Spec.UserData = aws.String(string(content))
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(nodePool), nodePool)).To(Succeed())
+				pod := coretest.UnschedulablePod(coretest.PodOptions{
+					NodeSelector: map[string]string{
+						v1.LabelOSStable:     string(v1.Windows),
+						v1.LabelWindowsBuild: "10.0.20348",
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				content, err = os.ReadFile("testdata/windows_userdata_merged.golden")
+				Expect(err).To(BeNil())
+				ExpectLaunchTemplatesCreatedWithUserData(fmt.Sprintf(string(content), corev1beta1.NodePoolLabelKey, nodePool.Name))
+			})
+			It("should bootstrap when custom user data is empty", func() {
+				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+				Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(nodePool), nodePool)).To(Succeed())
+				pod := coretest.UnschedulablePod(coretest.PodOptions{
+					NodeSelector: map[string]string{
+						v1.LabelOSStable:     string(v1.Windows),
+						v1.LabelWindowsBuild: "10.0.20348",
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				content, err := os.ReadFile("testdata/windows_userdata_unmerged.golden")
+				Expect(err).To(BeNil())
+				ExpectLaunchTemplatesCreatedWithUserData(fmt.Sprintf(string(content), corev1beta1.NodePoolLabelKey, nodePool.Name))
+			})
+		})
+	})
+	Context("Cluster Discovery", func() {
+		BeforeEach(func() {
+			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2
+			awsEnv.EKSAPI.DescribeClusterBehavior.Output.Set(&eks.DescribeClusterOutput{
+				Cluster: &eks.Cluster{
+					Endpoint:             aws.String("https://discovered-cluster"),
+					CertificateAuthority: &eks.Certificate{Data: aws.String("discovered-ca-bundle")},
+				},
+			})
+		})
+		It("should use the configured cluster endpoint and CA bundle when it's disabled", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			ExpectLaunchTemplatesCreatedWithUserDataContaining("--apiserver-endpoint 'https://test-cluster'", "--b64-cluster-ca 'ca-bundle'")
+			Expect(awsEnv.EKSAPI.DescribeClusterBehavior.Calls()).To(BeZero())
+		})
+		Context("Enabled", func() {
+			BeforeEach(func() {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ClusterDiscovery: lo.ToPtr(true)}))
+			})
+			It("should bootstrap with the discovered cluster endpoint and CA bundle", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("--apiserver-endpoint 'https://discovered-cluster'", "--b64-cluster-ca 'discovered-ca-bundle'")
+				Expect(awsEnv.EKSAPI.DescribeClusterBehavior.CalledWithInput.Pop().Name).To(Equal(lo.ToPtr(options.FromContext(ctx).ClusterName)))
+			})
+			It("should cache the discovered cluster endpoint and CA bundle", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				pod = coretest.UnschedulablePod(coretest.PodOptions{ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100")}}})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				Expect(awsEnv.EKSAPI.DescribeClusterBehavior.Calls()).To(Equal(1))
+			})
+			It("should fall back to the configured cluster endpoint and CA bundle when discovery fails", func() {
+				awsEnv.EKSAPI.DescribeClusterBehavior.Error.Set(fmt.Errorf("failed"))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("--apiserver-endpoint 'https://test-cluster'", "--b64-cluster-ca 'ca-bundle'")
+			})
+			It("should not retry discovery for every launch while discovery is failing", func() {
+				awsEnv.EKSAPI.DescribeClusterBehavior.Error.Set(fmt.Errorf("failed"), fake.MaxCalls(1))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				pod = coretest.UnschedulablePod(coretest.PodOptions{ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100")}}})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				Expect(awsEnv.EKSAPI.DescribeClusterBehavior.Calls()).To(Equal(1))
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("--apiserver-endpoint 'https://test-cluster'", "--b64-cluster-ca 'ca-bundle'")
+			})
+			It("should fall back to the configured cluster endpoint and CA bundle when DescribeCluster doesn't return them", func() {
+				awsEnv.EKSAPI.DescribeClusterBehavior.Output.Set(&eks.DescribeClusterOutput{Cluster: &eks.Cluster{}})
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				ExpectLaunchTemplatesCreatedWithUserDataContaining("--apiserver-endpoint 'https://test-cluster'", "--b64-cluster-ca 'ca-bundle'")
+			})
+		})
+	})
+	Context("Readable Names", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ReadableLaunchTemplateNames: lo.ToPtr(true)}))
+		})
+		It("should include the cluster and nodeclass names before the hash", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			prefix := fmt.Sprintf("%s/%s/%s/", v1beta1.Group, options.FromContext(ctx).ClusterName, nodeClass.Name)
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.StringValue(ltInput.LaunchTemplateName)).To(MatchRegexp(`^%s[0-9]+$`, regexp.QuoteMeta(prefix)))
+				Expect(aws.StringValue(ltInput.VersionDescription)).To(ContainSubstring(nodeClass.Name))
+			})
+		})
+		It("should truncate long names to EC2's limit and keep the hash", func() {
+			lt := &amifamily.LaunchTemplate{Options: &amifamily.Options{
+				ClusterName:   strings.Repeat("c", 100),
+				NodeClassName: strings.Repeat("n", 253),
+			}}
+			name := launchtemplate.ReadableLaunchTemplateName(lt)
+			Expect(len(name)).To(BeNumerically("<=", 128))
+			Expect(name).To(HaveSuffix(strings.TrimPrefix(launchtemplate.LaunchTemplateName(lt), v1beta1.Group)))
+			Expect(name).To(ContainSubstring("/ccc"))
+			Expect(name).To(ContainSubstring("/nnn"))
+		})
+		It("should replace characters that aren't allowed in launch template names", func() {
+			lt := &amifamily.LaunchTemplate{Options: &amifamily.Options{ClusterName: "my cluster", NodeClassName: "default"}}
+			Expect(launchtemplate.ReadableLaunchTemplateName(lt)).To(HavePrefix(fmt.Sprintf("%s/my-cluster/default/", v1beta1.Group)))
+		})
+		It("should delete launch templates with readable names by their tags", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			count := 0
+			awsEnv.EC2API.LaunchTemplates.Range(func(_, _ any) bool { count++; return true })
+			Expect(count).To(BeNumerically(">=", 1))
+
+			Expect(awsEnv.LaunchTemplateProvider.DeleteAll(ctx, nodeClass)).To(Succeed())
+			count = 0
+			awsEnv.EC2API.LaunchTemplates.Range(func(_, _ any) bool { count++; return true })
+			Expect(count).To(BeZero())
+		})
+	})
+	Context("Detailed Monitoring", func() {
+		It("should default detailed monitoring to off", func() {
 			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 			pod := coretest.UnschedulablePod()
@@ -2156,6 +3024,273 @@ var _ = Describe("LaunchTemplate Provider", func() {
 			})
 		})
 	})
+	Context("Hardened", func() {
+		It("should use the default metadata options when it isn't set", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.StringValue(ltInput.LaunchTemplateData.MetadataOptions.HttpTokens)).To(Equal(ec2.LaunchTemplateHttpTokensStateRequired))
+				Expect(aws.Int64Value(ltInput.LaunchTemplateData.MetadataOptions.HttpPutResponseHopLimit)).To(BeNumerically("==", 2))
+				Expect(ltInput.LaunchTemplateData.MetadataOptions.InstanceMetadataTags).To(BeNil())
+			})
+		})
+		It("should harden the launch template when it's enabled", func() {
+			nodeClass.Spec.Hardened = aws.Bool(true)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.KeyName).To(BeNil())
+				Expect(aws.StringValue(ltInput.LaunchTemplateData.MetadataOptions.HttpEndpoint)).To(Equal(ec2.LaunchTemplateInstanceMetadataEndpointStateEnabled))
+				Expect(aws.StringValue(ltInput.LaunchTemplateData.MetadataOptions.HttpTokens)).To(Equal(ec2.LaunchTemplateHttpTokensStateRequired))
+				Expect(aws.Int64Value(ltInput.LaunchTemplateData.MetadataOptions.HttpPutResponseHopLimit)).To(BeNumerically("==", 1))
+				Expect(aws.StringValue(ltInput.LaunchTemplateData.MetadataOptions.InstanceMetadataTags)).To(Equal(ec2.LaunchTemplateInstanceMetadataTagsStateDisabled))
+			})
+		})
+		It("should take precedence over the metadata options of the EC2NodeClass", func() {
+			nodeClass.Spec.Hardened = aws.Bool(true)
+			nodeClass.Spec.MetadataOptions = &v1beta1.MetadataOptions{
+				HTTPEndpoint:            aws.String(ec2.LaunchTemplateInstanceMetadataEndpointStateEnabled),
+				HTTPProtocolIPv6:        aws.String(ec2.LaunchTemplateInstanceMetadataProtocolIpv6Enabled),
+				HTTPPutResponseHopLimit: aws.Int64(3),
+				HTTPTokens:              aws.String(ec2.LaunchTemplateHttpTokensStateOptional),
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.StringValue(ltInput.LaunchTemplateData.MetadataOptions.HttpProtocolIpv6)).To(Equal(ec2.LaunchTemplateInstanceMetadataProtocolIpv6Enabled))
+				Expect(aws.StringValue(ltInput.LaunchTemplateData.MetadataOptions.HttpTokens)).To(Equal(ec2.LaunchTemplateHttpTokensStateRequired))
+				Expect(aws.Int64Value(ltInput.LaunchTemplateData.MetadataOptions.HttpPutResponseHopLimit)).To(BeNumerically("==", 1))
+			})
+			Expect(nodeClass.Spec.MetadataOptions.HTTPPutResponseHopLimit).To(Equal(aws.Int64(3)))
+		})
+	})
+	Context("Nitro Enclaves", func() {
+		It("should not enable enclaves by default", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.EnclaveOptions).To(BeNil())
+			})
+		})
+		It("should enable enclaves in the launch template when they're enabled", func() {
+			nodeClass.Spec.NitroEnclaves = aws.Bool(true)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1beta1.LabelInstanceEnclavesSupported, "true"))
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.BoolValue(ltInput.LaunchTemplateData.EnclaveOptions.Enabled)).To(BeTrue())
+			})
+		})
+		It("should not launch instance types that don't support enclaves when they're enabled", func() {
+			nodeClass.Spec.NitroEnclaves = aws.Bool(true)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1.LabelInstanceTypeStable: "m5.large"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+	})
+	Context("CPU Options", func() {
+		It("should not set cpu options in the launch template by default", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.CpuOptions).To(BeNil())
+			})
+		})
+		It("should launch a single thread per core when hyperthreading is disabled", func() {
+			nodeClass.Spec.DisableHyperthreading = aws.Bool(true)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.Int64Value(ltInput.LaunchTemplateData.CpuOptions.ThreadsPerCore)).To(BeNumerically("==", 1))
+				Expect(ltInput.LaunchTemplateData.CpuOptions.CoreCount).To(BeNil())
+			})
+		})
+		It("should set the core count and threads per core of the cpu options", func() {
+			nodeClass.Spec.CPUOptions = &v1beta1.CPUOptions{CoreCount: aws.Int64(4), ThreadsPerCore: aws.Int64(1)}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1beta1.LabelInstanceCPUCores, "4"))
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.Int64Value(ltInput.LaunchTemplateData.CpuOptions.CoreCount)).To(BeNumerically("==", 4))
+				Expect(aws.Int64Value(ltInput.LaunchTemplateData.CpuOptions.ThreadsPerCore)).To(BeNumerically("==", 1))
+			})
+		})
+		It("should not launch instances when no instance types support the cpu options", func() {
+			nodeClass.Spec.CPUOptions = &v1beta1.CPUOptions{CoreCount: aws.Int64(3)}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeZero())
+		})
+	})
+	Context("Network Card Index", func() {
+		It("should not attach additional network interfaces by default", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.NetworkInterfaces).To(BeNil())
+			})
+		})
+		It("should attach a network interface to the network card of a multi-card instance type", func() {
+			nodeClass.Spec.NetworkCardIndex = aws.Int64(1)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1.LabelInstanceTypeStable: "m6idn.32xlarge"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1beta1.LabelInstanceNetworkCards, "2"))
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(Equal(1))
+			input := awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Pop()
+			Expect(input.LaunchTemplateData.SecurityGroupIds).To(BeNil())
+			networkInterfaces := input.LaunchTemplateData.NetworkInterfaces
+			Expect(networkInterfaces).To(HaveLen(2))
+			Expect(aws.Int64Value(networkInterfaces[0].NetworkCardIndex)).To(BeNumerically("==", 0))
+			Expect(aws.Int64Value(networkInterfaces[0].DeviceIndex)).To(BeNumerically("==", 0))
+			Expect(aws.Int64Value(networkInterfaces[1].NetworkCardIndex)).To(BeNumerically("==", 1))
+			Expect(aws.Int64Value(networkInterfaces[1].DeviceIndex)).To(BeNumerically("==", 1))
+			for _, networkInterface := range networkInterfaces {
+				Expect(aws.StringValueSlice(networkInterface.Groups)).To(ConsistOf("sg-test1", "sg-test2", "sg-test3"))
+			}
+		})
+		It("should not attach a second network interface to a network card that an EFA interface is attached to", func() {
+			nodeClass.Spec.NetworkCardIndex = aws.Int64(1)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{
+				NodeSelector: map[string]string{v1.LabelInstanceTypeStable: "m6idn.32xlarge"},
+				ResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1beta1.ResourceEFA: resource.MustParse("1")},
+					Limits:   v1.ResourceList{v1beta1.ResourceEFA: resource.MustParse("1")},
+				},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			input := awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Pop()
+			networkInterfaces := input.LaunchTemplateData.NetworkInterfaces
+			Expect(lo.Map(networkInterfaces, func(ni *ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest, _ int) int64 {
+				return aws.Int64Value(ni.NetworkCardIndex)
+			})).To(ConsistOf(int64(0), int64(1)))
+			for _, networkInterface := range networkInterfaces {
+				Expect(aws.StringValue(networkInterface.InterfaceType)).To(Equal(ec2.NetworkInterfaceTypeEfa))
+			}
+		})
+		It("should not launch instance types without the network card", func() {
+			nodeClass.Spec.NetworkCardIndex = aws.Int64(1)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1.LabelInstanceTypeStable: "m5.large"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+	})
+	Context("CPU Credits", func() {
+		It("should not specify a credit option by default", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1beta1.LabelInstanceBurstable: "true"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.CreditSpecification).To(BeNil())
+			})
+		})
+		It("should pass the credit option to the launch templates of burstable instance types", func() {
+			nodeClass.Spec.CPUCredits = aws.String("unlimited")
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1beta1.LabelInstanceBurstable: "true"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.StringValue(ltInput.LaunchTemplateData.CreditSpecification.CpuCredits)).To(Equal("unlimited"))
+			})
+		})
+		It("should not pass the credit option to the launch templates of instance types that aren't burstable", func() {
+			nodeClass.Spec.CPUCredits = aws.String("standard")
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1beta1.LabelInstanceBurstable: "false"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.CreditSpecification).To(BeNil())
+			})
+		})
+		It("should resolve separate launch templates for burstable instance types", func() {
+			nodeClass.Spec.CPUCredits = aws.String("standard")
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			creditSpecifications := sets.New[string]()
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				creditSpecifications.Insert(lo.FromPtr(lo.FromPtr(ltInput.LaunchTemplateData.CreditSpecification).CpuCredits))
+			})
+			Expect(creditSpecifications.UnsortedList()).To(ConsistOf("standard", ""))
+		})
+	})
+	Context("Debug Endpoint", func() {
+		It("should serve the rendered launch templates without creating them", func() {
+			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2
+			nodeClass.Spec.Tags = map[string]string{"team": "platform"}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			recorder := httptest.NewRecorder()
+			cloudProvider.LaunchTemplatesHandler(ctx).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet,
+				fmt.Sprintf("/debug/launchtemplates?nodeclass=%s&nodepool=%s", nodeClass.Name, nodePool.Name), nil))
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			var launchTemplates []launchtemplate.RenderedLaunchTemplate
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &launchTemplates)).To(Succeed())
+			Expect(launchTemplates).ToNot(BeEmpty())
+			for _, lt := range launchTemplates {
+				Expect(lt.Name).To(HavePrefix(v1beta1.Group + "/"))
+				Expect(lt.InstanceTypes).ToNot(BeEmpty())
+				Expect(lt.UserData).To(ContainSubstring("/etc/eks/bootstrap.sh"))
+				Expect(lt.UserData).To(ContainSubstring(fmt.Sprintf("%s=%s", corev1beta1.NodePoolLabelKey, nodePool.Name)))
+				Expect(lt.LaunchTemplateData.BlockDeviceMappings).ToNot(BeEmpty())
+				Expect(lt.LaunchTemplateData.SecurityGroupIds).To(ConsistOf(aws.String("sg-test1"), aws.String("sg-test2"), aws.String("sg-test3")))
+				Expect(lt.TagSpecifications).To(HaveLen(1))
+				ExpectTags(lt.TagSpecifications[0].Tags, map[string]string{"team": "platform", v1beta1.LabelNodeClass: nodeClass.Name})
+			}
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(Equal(0))
+		})
+		It("should require the nodeclass query parameter", func() {
+			recorder := httptest.NewRecorder()
+			cloudProvider.LaunchTemplatesHandler(ctx).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/launchtemplates", nil))
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+		It("should return not found for an unknown nodeclass", func() {
+			recorder := httptest.NewRecorder()
+			cloudProvider.LaunchTemplatesHandler(ctx).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/launchtemplates?nodeclass=unknown", nil))
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
 })
 
 // ExpectTags verifies that the expected tags are a subset of the tags found
@@ -0,0 +1,116 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placementscore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+)
+
+// ScoredInstanceTypes are the instance types that spot placement scores are requested for, by architecture. EC2 limits
+// the number of distinct configurations that an account can request scores for in a day, so scores are requested for
+// a fixed set of widely available instance types rather than for the instance types of each launch.
+var ScoredInstanceTypes = map[string][]string{
+	corev1beta1.ArchitectureAmd64: {"c5.large", "c6i.large", "m5.large", "m6i.large", "r5.large", "r6i.large"},
+	corev1beta1.ArchitectureArm64: {"c6g.large", "c7g.large", "m6g.large", "m7g.large", "r6g.large", "r7g.large"},
+}
+
+type Provider interface {
+	// Scores returns the spot placement score of each zone for the architecture from the last refresh, keyed by zone
+	// id. Zones without a score are omitted.
+	Scores(context.Context, string) map[string]int64
+	// UpdateScores refreshes the spot placement scores of each architecture
+	UpdateScores(context.Context) error
+}
+
+type DefaultProvider struct {
+	sync.RWMutex
+	region string
+	ec2api ec2iface.EC2API
+	scores map[string]map[string]int64
+	cm     *pretty.ChangeMonitor
+}
+
+func NewDefaultProvider(region string, ec2api ec2iface.EC2API) *DefaultProvider {
+	return &DefaultProvider{
+		region: region,
+		ec2api: ec2api,
+		scores: map[string]map[string]int64{},
+		cm:     pretty.NewChangeMonitor(),
+	}
+}
+
+func (p *DefaultProvider) Scores(_ context.Context, architecture string) map[string]int64 {
+	p.RLock()
+	defer p.RUnlock()
+	return p.scores[architecture]
+}
+
+// UpdateScores refreshes the spot placement scores of each architecture. The scores of an architecture that fails to
+// refresh are kept, so that launches keep using the last known scores.
+func (p *DefaultProvider) UpdateScores(ctx context.Context) error {
+	var errs error
+	for _, architecture := range lo.Keys(ScoredInstanceTypes) {
+		scores, err := p.getScores(ctx, ScoredInstanceTypes[architecture])
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("getting spot placement scores for %s, %w", architecture, err))
+			continue
+		}
+		if p.cm.HasChanged(fmt.Sprintf("spot-placement-scores/%s", architecture), scores) {
+			log.FromContext(ctx).WithValues("architecture", architecture, "scores", scores).V(1).Info("discovered spot placement scores")
+		}
+		p.Lock()
+		p.scores[architecture] = scores
+		p.Unlock()
+	}
+	return errs
+}
+
+func (p *DefaultProvider) getScores(ctx context.Context, instanceTypes []string) (map[string]int64, error) {
+	scores := map[string]int64{}
+	if err := p.ec2api.GetSpotPlacementScoresPagesWithContext(ctx, &ec2.GetSpotPlacementScoresInput{
+		InstanceTypes:          aws.StringSlice(instanceTypes),
+		TargetCapacity:         aws.Int64(1),
+		TargetCapacityUnitType: aws.String(ec2.TargetCapacityUnitTypeUnits),
+		SingleAvailabilityZone: aws.Bool(true),
+		RegionNames:            aws.StringSlice([]string{p.region}),
+	}, func(output *ec2.GetSpotPlacementScoresOutput, _ bool) bool {
+		// Scores are reported by zone id, which is consistent across accounts, while zone names aren't
+		for _, score := range output.SpotPlacementScores {
+			scores[aws.StringValue(score.AvailabilityZoneId)] = aws.Int64Value(score.Score)
+		}
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+func (p *DefaultProvider) Reset() {
+	p.Lock()
+	defer p.Unlock()
+	p.scores = map[string]map[string]int64{}
+}
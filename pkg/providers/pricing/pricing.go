@@ -56,7 +56,8 @@ type Provider interface {
 // support running in locations where pricing data is unavailable.  In those cases the static pricing data provides a
 // relative ordering that is still more accurate than our previous pricing model.  In the event that a pricing update
 // fails, the previous pricing information is retained and used which may be the static initial pricing data if pricing
-// updates never succeed.
+// updates never succeed. Updates fetch pricing data without holding a lock and then swap in new price maps, so reads
+// are served the last known prices while an update is in progress.
 type DefaultProvider struct {
 	ec2     ec2iface.EC2API
 	pricing pricingiface.PricingAPI
@@ -88,8 +89,9 @@ func newZonalPricing(defaultPrice float64) zonal {
 	return z
 }
 
-// NewPricingAPI returns a pricing API configured based on a particular region
-func NewAPI(sess *session.Session, region string) pricingiface.PricingAPI {
+// NewAPI returns a client for the pricing API endpoint that serves the region. The configs are applied to the client
+// after the region of the endpoint.
+func NewAPI(sess *session.Session, region string, configs ...*aws.Config) pricingiface.PricingAPI {
 	if sess == nil {
 		return nil
 	}
@@ -102,7 +104,7 @@ func NewAPI(sess *session.Session, region string) pricingiface.PricingAPI {
 	} else if strings.HasPrefix(region, "eu-") {
 		pricingAPIRegion = "eu-central-1"
 	}
-	return pricing.New(sess, &aws.Config{Region: aws.String(pricingAPIRegion)})
+	return pricing.New(sess, append([]*aws.Config{{Region: aws.String(pricingAPIRegion)}}, configs...)...)
 }
 
 func NewDefaultProvider(_ context.Context, pricing pricingiface.PricingAPI, ec2Api ec2iface.EC2API, region string) *DefaultProvider {
@@ -171,9 +173,6 @@ func (p *DefaultProvider) UpdateOnDemandPricing(ctx context.Context) error {
 		return nil
 	}
 
-	p.muOnDemand.Lock()
-	defer p.muOnDemand.Unlock()
-
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -218,9 +217,12 @@ func (p *DefaultProvider) UpdateOnDemandPricing(ctx context.Context) error {
 		return fmt.Errorf("no on-demand pricing found")
 	}
 
-	p.onDemandPrices = lo.Assign(onDemandPrices, onDemandMetalPrices)
-	if p.cm.HasChanged("on-demand-prices", p.onDemandPrices) {
-		log.FromContext(ctx).WithValues("instance-type-count", len(p.onDemandPrices)).V(1).Info("updated on-demand pricing")
+	prices := lo.Assign(onDemandPrices, onDemandMetalPrices)
+	p.muOnDemand.Lock()
+	p.onDemandPrices = prices
+	p.muOnDemand.Unlock()
+	if p.cm.HasChanged("on-demand-prices", prices) {
+		log.FromContext(ctx).WithValues("instance-type-count", len(prices)).V(1).Info("updated on-demand pricing")
 	}
 	return nil
 }
@@ -357,8 +359,6 @@ func (p *DefaultProvider) onDemandPage(ctx context.Context, prices map[string]fl
 func (p *DefaultProvider) UpdateSpotPricing(ctx context.Context) error {
 	prices := map[string]map[string]float64{}
 
-	p.muSpot.Lock()
-	defer p.muSpot.Unlock()
 	err := p.ec2.DescribeSpotPriceHistoryPagesWithContext(
 		ctx,
 		&ec2.DescribeSpotPriceHistoryInput{
@@ -379,21 +379,28 @@ func (p *DefaultProvider) UpdateSpotPricing(ctx context.Context) error {
 		return fmt.Errorf("no spot pricing found")
 	}
 
+	p.muSpot.Lock()
+	// The price maps that readers may hold are never modified, so the prices are merged into copies and swapped in
+	spotPrices := lo.MapValues(p.spotPrices, func(z zonal, _ string) zonal {
+		return zonal{defaultPrice: z.defaultPrice, prices: lo.Assign(z.prices)}
+	})
 	totalOfferings := 0
 	for it, zoneData := range prices {
-		if _, ok := p.spotPrices[it]; !ok {
-			p.spotPrices[it] = newZonalPricing(0)
+		if _, ok := spotPrices[it]; !ok {
+			spotPrices[it] = newZonalPricing(0)
 		}
 		for zone, price := range zoneData {
-			p.spotPrices[it].prices[zone] = price
+			spotPrices[it].prices[zone] = price
 		}
 		totalOfferings += len(zoneData)
 	}
-
+	p.spotPrices = spotPrices
 	p.spotPricingUpdated = true
-	if p.cm.HasChanged("spot-prices", p.spotPrices) {
+	p.muSpot.Unlock()
+
+	if p.cm.HasChanged("spot-prices", spotPrices) {
 		log.FromContext(ctx).WithValues(
-			"instance-type-count", len(p.onDemandPrices),
+			"instance-type-count", len(spotPrices),
 			"offering-count", totalOfferings).V(1).Info("updated spot pricing with instance types and offerings")
 	}
 	return nil
@@ -22,30 +22,41 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/metrics"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/tracing"
 )
 
 type Provider interface {
 	List(context.Context, *v1beta1.EC2NodeClass) ([]*ec2.SecurityGroup, error)
 }
 
+// clusterSecurityGroupCacheKey is the cache key of the ID of the cluster security group that EKS created for the cluster
+const clusterSecurityGroupCacheKey = "cluster-security-group"
+
 type DefaultProvider struct {
 	sync.Mutex
 	ec2api ec2iface.EC2API
+	eksapi eksiface.EKSAPI
 	cache  *cache.Cache
 	cm     *pretty.ChangeMonitor
 }
 
-func NewDefaultProvider(ec2api ec2iface.EC2API, cache *cache.Cache) *DefaultProvider {
+func NewDefaultProvider(ec2api ec2iface.EC2API, eksapi eksiface.EKSAPI, cache *cache.Cache) *DefaultProvider {
 	return &DefaultProvider{
 		ec2api: ec2api,
+		eksapi: eksapi,
 		cm:     pretty.NewChangeMonitor(),
 		// TODO: Remove cache cache when we utilize the security groups from the EC2NodeClass.status
 		cache: cache,
@@ -53,12 +64,24 @@ func NewDefaultProvider(ec2api ec2iface.EC2API, cache *cache.Cache) *DefaultProv
 }
 
 func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) ([]*ec2.SecurityGroup, error) {
+	ctx, span := tracing.Start(ctx, "securitygroup.List", tracing.NodeClass(nodeClass))
+	defer span.End()
+	var cacheHit bool
+	defer metrics.MeasureResolution(metrics.ProviderTypeSecurityGroup)(&cacheHit)
 	p.Lock()
 	defer p.Unlock()
 
+	terms, err := p.resolveClusterSecurityGroup(ctx, nodeClass.Spec.SecurityGroupSelectorTerms)
+	if err != nil {
+		return nil, err
+	}
 	// Get SecurityGroups
-	filterSets := getFilterSets(nodeClass.Spec.SecurityGroupSelectorTerms)
-	securityGroups, err := p.getSecurityGroups(ctx, filterSets)
+	var securityGroups []*ec2.SecurityGroup
+	if lo.FromPtr(nodeClass.Spec.SecurityGroupSelectorStrategy) == v1beta1.SecurityGroupSelectorStrategyIntersection {
+		securityGroups, cacheHit, err = p.getSecurityGroupIntersection(ctx, terms)
+	} else {
+		securityGroups, cacheHit, err = p.getSecurityGroups(ctx, getFilterSets(terms))
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -72,28 +95,94 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1beta1.EC2NodeCl
 	return securityGroups, nil
 }
 
-func (p *DefaultProvider) getSecurityGroups(ctx context.Context, filterSets [][]*ec2.Filter) ([]*ec2.SecurityGroup, error) {
-	hash, err := hashstructure.Hash(filterSets, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+// resolveClusterSecurityGroup replaces the terms that select the cluster's primary security group with terms that select
+// the security group by its ID
+func (p *DefaultProvider) resolveClusterSecurityGroup(ctx context.Context, terms []v1beta1.SecurityGroupSelectorTerm) ([]v1beta1.SecurityGroupSelectorTerm, error) {
+	if !lo.ContainsBy(terms, func(t v1beta1.SecurityGroupSelectorTerm) bool { return t.ClusterPrimarySecurityGroup }) {
+		return terms, nil
+	}
+	id, err := p.getClusterSecurityGroupID(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return lo.Map(terms, func(t v1beta1.SecurityGroupSelectorTerm, _ int) v1beta1.SecurityGroupSelectorTerm {
+		if t.ClusterPrimarySecurityGroup {
+			return v1beta1.SecurityGroupSelectorTerm{ID: id}
+		}
+		return t
+	}), nil
+}
+
+// getClusterSecurityGroupID discovers the ID of the cluster security group that EKS created for the cluster
+func (p *DefaultProvider) getClusterSecurityGroupID(ctx context.Context) (string, error) {
+	if id, ok := p.cache.Get(clusterSecurityGroupCacheKey); ok {
+		return id.(string), nil
+	}
+	clusterName := options.FromContext(ctx).ClusterName
+	if clusterName == "" {
+		return "", fmt.Errorf("resolving cluster security group, cluster name is not configured")
+	}
+	out, err := p.eksapi.DescribeClusterWithContext(ctx, &eks.DescribeClusterInput{Name: aws.String(clusterName)})
+	if err != nil {
+		return "", fmt.Errorf("describing cluster %q, %w", clusterName, err)
+	}
+	var id string
+	if out.Cluster != nil && out.Cluster.ResourcesVpcConfig != nil {
+		id = aws.StringValue(out.Cluster.ResourcesVpcConfig.ClusterSecurityGroupId)
+	}
+	if id == "" {
+		return "", fmt.Errorf("cluster %q has no cluster security group", clusterName)
+	}
+	p.cache.SetDefault(clusterSecurityGroupCacheKey, id)
+	return id, nil
+}
+
+// getSecurityGroups returns the security groups that match any of the filter sets, and whether they were served from
+// the cache
+func (p *DefaultProvider) getSecurityGroups(ctx context.Context, filterSets [][]*ec2.Filter) ([]*ec2.SecurityGroup, bool, error) {
+	hash, err := hashstructure.Hash(filterSets, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	if err != nil {
+		return nil, false, err
+	}
 	if sg, ok := p.cache.Get(fmt.Sprint(hash)); ok {
 		// Ensure what's returned from this function is a shallow-copy of the slice (not a deep-copy of the data itself)
 		// so that modifications to the ordering of the data don't affect the original
-		return append([]*ec2.SecurityGroup{}, sg.([]*ec2.SecurityGroup)...), nil
+		return append([]*ec2.SecurityGroup{}, sg.([]*ec2.SecurityGroup)...), true, nil
 	}
 	securityGroups := map[string]*ec2.SecurityGroup{}
 	for _, filters := range filterSets {
 		output, err := p.ec2api.DescribeSecurityGroupsWithContext(ctx, &ec2.DescribeSecurityGroupsInput{Filters: filters})
 		if err != nil {
-			return nil, fmt.Errorf("describing security groups %+v, %w", filterSets, err)
+			return nil, false, fmt.Errorf("describing security groups %+v, %w", filterSets, err)
 		}
 		for i := range output.SecurityGroups {
 			securityGroups[lo.FromPtr(output.SecurityGroups[i].GroupId)] = output.SecurityGroups[i]
 		}
 	}
 	p.cache.SetDefault(fmt.Sprint(hash), lo.Values(securityGroups))
-	return lo.Values(securityGroups), nil
+	return lo.Values(securityGroups), false, nil
+}
+
+// getSecurityGroupIntersection returns the security groups that match every one of the terms, and whether all the terms
+// were served from the cache. Each term is resolved separately so that results are cached per term and shared with
+// nodeclasses that select the same term.
+func (p *DefaultProvider) getSecurityGroupIntersection(ctx context.Context, terms []v1beta1.SecurityGroupSelectorTerm) ([]*ec2.SecurityGroup, bool, error) {
+	var securityGroups []*ec2.SecurityGroup
+	cacheHit := true
+	for i, term := range terms {
+		matches, cached, err := p.getSecurityGroups(ctx, [][]*ec2.Filter{getFilters(term)})
+		if err != nil {
+			return nil, false, err
+		}
+		cacheHit = cacheHit && cached
+		if i == 0 {
+			securityGroups = matches
+			continue
+		}
+		ids := sets.New(lo.Map(matches, func(s *ec2.SecurityGroup, _ int) string { return aws.StringValue(s.GroupId) })...)
+		securityGroups = lo.Filter(securityGroups, func(s *ec2.SecurityGroup, _ int) bool { return ids.Has(aws.StringValue(s.GroupId)) })
+	}
+	return securityGroups, cacheHit, nil
 }
 
 func getFilterSets(terms []v1beta1.SecurityGroupSelectorTerm) (res [][]*ec2.Filter) {
@@ -106,21 +195,7 @@ func getFilterSets(terms []v1beta1.SecurityGroupSelectorTerm) (res [][]*ec2.Filt
 		case term.Name != "":
 			nameFilter.Values = append(nameFilter.Values, aws.String(term.Name))
 		default:
-			var filters []*ec2.Filter
-			for k, v := range term.Tags {
-				if v == "*" {
-					filters = append(filters, &ec2.Filter{
-						Name:   aws.String("tag-key"),
-						Values: []*string{aws.String(k)},
-					})
-				} else {
-					filters = append(filters, &ec2.Filter{
-						Name:   aws.String(fmt.Sprintf("tag:%s", k)),
-						Values: []*string{aws.String(v)},
-					})
-				}
-			}
-			res = append(res, filters)
+			res = append(res, getFilters(term))
 		}
 	}
 	if len(idFilter.Values) > 0 {
@@ -131,3 +206,27 @@ func getFilterSets(terms []v1beta1.SecurityGroupSelectorTerm) (res [][]*ec2.Filt
 	}
 	return res
 }
+
+// getFilters returns the filters that select the security groups matching a single term
+func getFilters(term v1beta1.SecurityGroupSelectorTerm) (filters []*ec2.Filter) {
+	switch {
+	case term.ID != "":
+		return []*ec2.Filter{{Name: aws.String("group-id"), Values: []*string{aws.String(term.ID)}}}
+	case term.Name != "":
+		return []*ec2.Filter{{Name: aws.String("group-name"), Values: []*string{aws.String(term.Name)}}}
+	}
+	for k, v := range term.Tags {
+		if v == "*" {
+			filters = append(filters, &ec2.Filter{
+				Name:   aws.String("tag-key"),
+				Values: []*string{aws.String(k)},
+			})
+		} else {
+			filters = append(filters, &ec2.Filter{
+				Name:   aws.String(fmt.Sprintf("tag:%s", k)),
+				Values: []*string{aws.String(v)},
+			})
+		}
+	}
+	return filters
+}
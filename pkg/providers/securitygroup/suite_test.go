@@ -16,12 +16,14 @@ package securitygroup_test
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"sync"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/samber/lo"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
@@ -267,6 +269,165 @@ var _ = Describe("SecurityGroupProvider", func() {
 			},
 		}, securityGroups)
 	})
+	Context("Selector Strategy", func() {
+		BeforeEach(func() {
+			nodeClass.Spec.SecurityGroupSelectorTerms = []v1beta1.SecurityGroupSelectorTerm{
+				{
+					Tags: map[string]string{"foo": "bar"},
+				},
+				{
+					Tags: map[string]string{"TestTag": "*"},
+				},
+				{
+					ID: "sg-test3",
+				},
+			}
+		})
+		It("should union the results of the terms by default", func() {
+			securityGroups, err := awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			ExpectConsistsOfSecurityGroups([]*ec2.SecurityGroup{
+				{
+					GroupId:   aws.String("sg-test1"),
+					GroupName: aws.String("securityGroup-test1"),
+				},
+				{
+					GroupId:   aws.String("sg-test2"),
+					GroupName: aws.String("securityGroup-test2"),
+				},
+				{
+					GroupId:   aws.String("sg-test3"),
+					GroupName: aws.String("securityGroup-test3"),
+				},
+			}, securityGroups)
+		})
+		It("should union the results of the terms when the strategy is Union", func() {
+			nodeClass.Spec.SecurityGroupSelectorStrategy = lo.ToPtr(v1beta1.SecurityGroupSelectorStrategyUnion)
+			securityGroups, err := awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(securityGroups).To(HaveLen(3))
+		})
+		It("should intersect the results of the terms when the strategy is Intersection", func() {
+			nodeClass.Spec.SecurityGroupSelectorStrategy = lo.ToPtr(v1beta1.SecurityGroupSelectorStrategyIntersection)
+			securityGroups, err := awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			ExpectConsistsOfSecurityGroups([]*ec2.SecurityGroup{
+				{
+					GroupId:   aws.String("sg-test3"),
+					GroupName: aws.String("securityGroup-test3"),
+				},
+			}, securityGroups)
+		})
+		It("should return no security groups when the terms don't intersect", func() {
+			nodeClass.Spec.SecurityGroupSelectorStrategy = lo.ToPtr(v1beta1.SecurityGroupSelectorStrategyIntersection)
+			nodeClass.Spec.SecurityGroupSelectorTerms = []v1beta1.SecurityGroupSelectorTerm{
+				{
+					ID: "sg-test1",
+				},
+				{
+					ID: "sg-test2",
+				},
+			}
+			securityGroups, err := awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(securityGroups).To(BeEmpty())
+		})
+		It("should not share cached results between strategies", func() {
+			securityGroups, err := awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(securityGroups).To(HaveLen(3))
+
+			nodeClass.Spec.SecurityGroupSelectorStrategy = lo.ToPtr(v1beta1.SecurityGroupSelectorStrategyIntersection)
+			securityGroups, err = awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(securityGroups).To(HaveLen(1))
+		})
+	})
+	Context("Cluster Primary Security Group", func() {
+		BeforeEach(func() {
+			awsEnv.EKSAPI.DescribeClusterBehavior.Output.Set(&eks.DescribeClusterOutput{
+				Cluster: &eks.Cluster{
+					ResourcesVpcConfig: &eks.VpcConfigResponse{
+						ClusterSecurityGroupId: aws.String("sg-test2"),
+						SecurityGroupIds:       []*string{aws.String("sg-test3")},
+					},
+				},
+			})
+			nodeClass.Spec.SecurityGroupSelectorTerms = []v1beta1.SecurityGroupSelectorTerm{
+				{
+					ClusterPrimarySecurityGroup: true,
+				},
+			}
+		})
+		It("should discover the cluster security group through DescribeCluster", func() {
+			securityGroups, err := awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			ExpectConsistsOfSecurityGroups([]*ec2.SecurityGroup{
+				{
+					GroupId:   aws.String("sg-test2"),
+					GroupName: aws.String("securityGroup-test2"),
+				},
+			}, securityGroups)
+			Expect(awsEnv.EKSAPI.DescribeClusterBehavior.CalledWithInput.Len()).To(Equal(1))
+			Expect(aws.StringValue(awsEnv.EKSAPI.DescribeClusterBehavior.CalledWithInput.Pop().Name)).To(Equal("test-cluster"))
+		})
+		It("should union the cluster security group with the other terms", func() {
+			nodeClass.Spec.SecurityGroupSelectorTerms = append(nodeClass.Spec.SecurityGroupSelectorTerms, v1beta1.SecurityGroupSelectorTerm{ID: "sg-test1"})
+			securityGroups, err := awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			ExpectConsistsOfSecurityGroups([]*ec2.SecurityGroup{
+				{
+					GroupId:   aws.String("sg-test1"),
+					GroupName: aws.String("securityGroup-test1"),
+				},
+				{
+					GroupId:   aws.String("sg-test2"),
+					GroupName: aws.String("securityGroup-test2"),
+				},
+			}, securityGroups)
+		})
+		It("should intersect the cluster security group with the other terms", func() {
+			nodeClass.Spec.SecurityGroupSelectorStrategy = lo.ToPtr(v1beta1.SecurityGroupSelectorStrategyIntersection)
+			nodeClass.Spec.SecurityGroupSelectorTerms = append(nodeClass.Spec.SecurityGroupSelectorTerms, v1beta1.SecurityGroupSelectorTerm{Tags: map[string]string{"foo": "bar"}})
+			securityGroups, err := awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			ExpectConsistsOfSecurityGroups([]*ec2.SecurityGroup{
+				{
+					GroupId:   aws.String("sg-test2"),
+					GroupName: aws.String("securityGroup-test2"),
+				},
+			}, securityGroups)
+		})
+		It("should only describe the cluster once", func() {
+			_, err := awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			_, err = awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(awsEnv.EKSAPI.DescribeClusterBehavior.CalledWithInput.Len()).To(Equal(1))
+		})
+		It("should not describe the cluster when no term selects the cluster security group", func() {
+			nodeClass.Spec.SecurityGroupSelectorTerms = []v1beta1.SecurityGroupSelectorTerm{{ID: "sg-test1"}}
+			_, err := awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(awsEnv.EKSAPI.DescribeClusterBehavior.CalledWithInput.Len()).To(Equal(0))
+		})
+		It("should return an error when the cluster name isn't configured", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ClusterName: lo.ToPtr("")}))
+			_, err := awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+			Expect(err).To(HaveOccurred())
+			Expect(awsEnv.EKSAPI.DescribeClusterBehavior.CalledWithInput.Len()).To(Equal(0))
+		})
+		It("should return an error when the cluster has no cluster security group", func() {
+			awsEnv.EKSAPI.DescribeClusterBehavior.Output.Set(&eks.DescribeClusterOutput{Cluster: &eks.Cluster{}})
+			_, err := awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should return an error when DescribeCluster fails", func() {
+			awsEnv.EKSAPI.DescribeClusterBehavior.Error.Set(fmt.Errorf("failed"))
+			_, err := awsEnv.SecurityGroupProvider.List(ctx, nodeClass)
+			Expect(err).To(HaveOccurred())
+		})
+	})
 	Context("Provider Cache", func() {
 		It("should resolve security groups from cache that are filtered by id", func() {
 			expectedSecurityGroups := awsEnv.EC2API.DescribeSecurityGroupsOutput.Clone().SecurityGroups
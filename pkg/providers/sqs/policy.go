@@ -0,0 +1,120 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqs
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// queuePolicy is the subset of an IAM policy document needed to determine whether a
+// service principal is allowed to send messages to the queue
+type queuePolicy struct {
+	Statement statements `json:"Statement"`
+}
+
+type statements []statement
+
+type statement struct {
+	Effect       string          `json:"Effect"`
+	Principal    principal       `json:"Principal"`
+	NotPrincipal json.RawMessage `json:"NotPrincipal"`
+	Action       stringOrSet     `json:"Action"`
+	NotAction    stringOrSet     `json:"NotAction"`
+	Resource     stringOrSet     `json:"Resource"`
+	NotResource  stringOrSet     `json:"NotResource"`
+	Condition    json.RawMessage `json:"Condition"`
+}
+
+type principal struct {
+	Wildcard bool
+	Service  stringOrSet
+}
+
+// stringOrSet handles policy elements that may either be a single string or a list of strings
+type stringOrSet []string
+
+// UnmarshalJSON accepts a single statement object as well as a list of statements
+func (s *statements) UnmarshalJSON(data []byte) error {
+	var single statement
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = statements{single}
+		return nil
+	}
+	var list []statement
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// UnmarshalJSON accepts either the "*" wildcard principal or a principal object
+func (p *principal) UnmarshalJSON(data []byte) error {
+	var wildcard string
+	if err := json.Unmarshal(data, &wildcard); err == nil {
+		p.Wildcard = wildcard == "*"
+		return nil
+	}
+	var obj struct {
+		AWS     stringOrSet `json:"AWS"`
+		Service stringOrSet `json:"Service"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	p.Wildcard = lo.Contains(obj.AWS, "*")
+	p.Service = obj.Service
+	return nil
+}
+
+func (s *stringOrSet) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = stringOrSet{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// allowsSendMessage returns true if the policy allows the service principal to call sqs:SendMessage
+// against the queue and no statement explicitly denies it. Statements that use Not* elements can't be
+// evaluated without the full request context, so they're ignored. Conditions are assumed to hold for Allow
+// statements (e.g. scoping aws:SourceArn to the interruption rules), but Deny statements with conditions are
+// ignored since they commonly target requests that EventBridge never makes (e.g. aws:SecureTransport=false).
+func (q queuePolicy) allowsSendMessage(service, queueARN string) bool {
+	matching := lo.Filter(q.Statement, func(s statement, _ int) bool { return s.evaluable() && s.matches(service, queueARN) })
+	return lo.ContainsBy(matching, func(s statement) bool { return strings.EqualFold(s.Effect, "Allow") }) &&
+		!lo.ContainsBy(matching, func(s statement) bool { return strings.EqualFold(s.Effect, "Deny") && len(s.Condition) == 0 })
+}
+
+func (s statement) evaluable() bool {
+	return len(s.NotPrincipal) == 0 && len(s.NotAction) == 0 && len(s.NotResource) == 0
+}
+
+func (s statement) matches(service, queueARN string) bool {
+	return (s.Principal.Wildcard || lo.Contains(s.Principal.Service, service)) &&
+		lo.ContainsBy(s.Action, func(a string) bool {
+			return lo.Contains([]string{"*", "sqs:*", "sqs:sendmessage"}, strings.ToLower(a))
+		}) &&
+		(len(s.Resource) == 0 || lo.ContainsBy(s.Resource, func(r string) bool { return r == "*" || r == queueARN }))
+}
@@ -18,18 +18,32 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 )
 
+// eventBridgeServicePrincipal is the service principal that EventBridge rules use to deliver events to the queue
+const eventBridgeServicePrincipal = "events.amazonaws.com"
+
+// maxBatchSize is the maximum number of entries SQS accepts in a single ReceiveMessage or DeleteMessageBatch call
+const maxBatchSize = 10
+
 type Provider interface {
 	Name() string
 	GetSQSMessages(context.Context) ([]*sqs.Message, error)
 	SendMessage(context.Context, interface{}) (string, error)
 	DeleteSQSMessage(context.Context, *sqs.Message) error
+	DeleteSQSMessages(context.Context, []*sqs.Message) ([]*sqs.Message, error)
+	ValidateQueuePolicy(context.Context) error
+	TagQueue(context.Context, map[string]string) error
 }
 
 type DefaultProvider struct {
@@ -50,11 +64,16 @@ func (p *DefaultProvider) Name() string {
 	return ss[len(ss)-1]
 }
 
+// GetSQSMessages long polls the queue for messages. A poll that is interrupted because the passed context was cancelled,
+// e.g. on shutdown, returns no messages rather than an error.
 func (p *DefaultProvider) GetSQSMessages(ctx context.Context) ([]*sqs.Message, error) {
+	receiveCtx, cancel := context.WithTimeout(ctx, options.FromContext(ctx).InterruptionReceiveTimeout)
+	defer cancel()
+
 	input := &sqs.ReceiveMessageInput{
-		MaxNumberOfMessages: aws.Int64(10),
-		VisibilityTimeout:   aws.Int64(20), // Seconds
-		WaitTimeSeconds:     aws.Int64(20), // Seconds, maximum for long polling
+		MaxNumberOfMessages: aws.Int64(int64(options.FromContext(ctx).InterruptionBatchSize)),
+		VisibilityTimeout:   aws.Int64(int64(options.FromContext(ctx).InterruptionVisibilityTimeout.Seconds())),
+		WaitTimeSeconds:     aws.Int64(int64(options.FromContext(ctx).InterruptionWaitTime.Seconds())),
 		AttributeNames: []*string{
 			aws.String(sqs.MessageSystemAttributeNameSentTimestamp),
 		},
@@ -64,8 +83,11 @@ func (p *DefaultProvider) GetSQSMessages(ctx context.Context) ([]*sqs.Message, e
 		QueueUrl: aws.String(p.queueURL),
 	}
 
-	result, err := p.client.ReceiveMessageWithContext(ctx, input)
+	result, err := p.client.ReceiveMessageWithContext(receiveCtx, input)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, nil
+		}
 		return nil, fmt.Errorf("receiving sqs messages, %w", err)
 	}
 
@@ -99,3 +121,71 @@ func (p *DefaultProvider) DeleteSQSMessage(ctx context.Context, msg *sqs.Message
 	}
 	return nil
 }
+
+// DeleteSQSMessages removes the passed messages from the queue using batched deletes. It returns the messages that
+// were successfully deleted alongside an error for any messages that failed to be deleted. Messages that fail to be
+// deleted become visible on the queue again once their visibility timeout expires.
+func (p *DefaultProvider) DeleteSQSMessages(ctx context.Context, msgs []*sqs.Message) ([]*sqs.Message, error) {
+	var deleted []*sqs.Message
+	var errs error
+	for _, batch := range lo.Chunk(msgs, maxBatchSize) {
+		input := &sqs.DeleteMessageBatchInput{
+			QueueUrl: aws.String(p.queueURL),
+			Entries: lo.Map(batch, func(msg *sqs.Message, i int) *sqs.DeleteMessageBatchRequestEntry {
+				return &sqs.DeleteMessageBatchRequestEntry{
+					Id:            aws.String(strconv.Itoa(i)),
+					ReceiptHandle: msg.ReceiptHandle,
+				}
+			}),
+		}
+		out, err := p.client.DeleteMessageBatchWithContext(ctx, input)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("deleting messages from sqs queue, %w", err))
+			continue
+		}
+		for _, entry := range out.Successful {
+			if i, err := strconv.Atoi(aws.StringValue(entry.Id)); err == nil && i < len(batch) {
+				deleted = append(deleted, batch[i])
+			}
+		}
+		for _, entry := range out.Failed {
+			errs = multierr.Append(errs, fmt.Errorf("deleting message from sqs queue, %s, %s", aws.StringValue(entry.Code), aws.StringValue(entry.Message)))
+		}
+	}
+	return deleted, errs
+}
+
+// ValidateQueuePolicy checks that the queue's access policy allows EventBridge to deliver interruption events to the queue.
+// A queue without a valid policy silently drops every event that the EventBridge rules attempt to send to it.
+func (p *DefaultProvider) ValidateQueuePolicy(ctx context.Context) error {
+	out, err := p.client.GetQueueAttributesWithContext(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(p.queueURL),
+		AttributeNames: aws.StringSlice([]string{sqs.QueueAttributeNamePolicy, sqs.QueueAttributeNameQueueArn}),
+	})
+	if err != nil {
+		return fmt.Errorf("getting sqs queue attributes, %w", err)
+	}
+	raw := aws.StringValue(out.Attributes[sqs.QueueAttributeNamePolicy])
+	if raw == "" {
+		return fmt.Errorf("sqs queue %q has no access policy, %s is not allowed to sqs:SendMessage to the queue", p.Name(), eventBridgeServicePrincipal)
+	}
+	policy := queuePolicy{}
+	if err = json.Unmarshal([]byte(raw), &policy); err != nil {
+		return fmt.Errorf("parsing sqs queue policy, %w", err)
+	}
+	if !policy.allowsSendMessage(eventBridgeServicePrincipal, aws.StringValue(out.Attributes[sqs.QueueAttributeNameQueueArn])) {
+		return fmt.Errorf("sqs queue %q access policy does not allow %s to sqs:SendMessage to the queue", p.Name(), eventBridgeServicePrincipal)
+	}
+	return nil
+}
+
+// TagQueue applies the passed tags to the queue
+func (p *DefaultProvider) TagQueue(ctx context.Context, tags map[string]string) error {
+	if _, err := p.client.TagQueueWithContext(ctx, &sqs.TagQueueInput{
+		QueueUrl: aws.String(p.queueURL),
+		Tags:     aws.StringMap(tags),
+	}); err != nil {
+		return fmt.Errorf("tagging sqs queue, %w", err)
+	}
+	return nil
+}
@@ -17,23 +17,35 @@ package subnet
 import (
 	"context"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/metrics"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/tracing"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 )
 
+// maxFilterValues is the maximum number of values in a single EC2 filter
+const maxFilterValues = 200
+
 type Provider interface {
 	LivenessProbe(*http.Request) error
 	List(context.Context, *v1beta1.EC2NodeClass) ([]*ec2.Subnet, error)
@@ -45,11 +57,13 @@ type Provider interface {
 type DefaultProvider struct {
 	sync.Mutex
 	ec2api                        ec2iface.EC2API
+	taggingapi                    resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI
 	cache                         *cache.Cache
 	availableIPAddressCache       *cache.Cache
 	associatePublicIPAddressCache *cache.Cache
 	cm                            *pretty.ChangeMonitor
 	inflightIPs                   map[string]int64
+	priorities                    map[string]float64
 }
 
 type Subnet struct {
@@ -58,10 +72,11 @@ type Subnet struct {
 	AvailableIPAddressCount int64
 }
 
-func NewDefaultProvider(ec2api ec2iface.EC2API, cache *cache.Cache, availableIPAddressCache *cache.Cache, associatePublicIPAddressCache *cache.Cache) *DefaultProvider {
+func NewDefaultProvider(ec2api ec2iface.EC2API, taggingapi resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI, cache *cache.Cache, availableIPAddressCache *cache.Cache, associatePublicIPAddressCache *cache.Cache) *DefaultProvider {
 	return &DefaultProvider{
-		ec2api: ec2api,
-		cm:     pretty.NewChangeMonitor(),
+		ec2api:     ec2api,
+		taggingapi: taggingapi,
+		cm:         pretty.NewChangeMonitor(),
 		// TODO: Remove cache when we utilize the resolved subnets from the EC2NodeClass.status
 		// Subnets are sorted on AvailableIpAddressCount, descending order
 		cache:                         cache,
@@ -69,10 +84,16 @@ func NewDefaultProvider(ec2api ec2iface.EC2API, cache *cache.Cache, availableIPA
 		associatePublicIPAddressCache: associatePublicIPAddressCache,
 		// inflightIPs is used to track IPs from known launched instances
 		inflightIPs: map[string]int64{},
+		// priorities is used to track the priorities of subnets from their priority tags
+		priorities: map[string]float64{},
 	}
 }
 
 func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) ([]*ec2.Subnet, error) {
+	ctx, span := tracing.Start(ctx, "subnet.List", tracing.NodeClass(nodeClass))
+	defer span.End()
+	var cacheHit bool
+	defer metrics.MeasureResolution(metrics.ProviderTypeSubnet)(&cacheHit)
 	p.Lock()
 	defer p.Unlock()
 	filterSets := getFilterSets(nodeClass.Spec.SubnetSelectorTerms)
@@ -84,6 +105,7 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1beta1.EC2NodeCl
 		return nil, err
 	}
 	if subnets, ok := p.cache.Get(fmt.Sprint(hash)); ok {
+		cacheHit = true
 		// Ensure what's returned from this function is a shallow-copy of the slice (not a deep-copy of the data itself)
 		// so that modifications to the ordering of the data don't affect the original
 		return append([]*ec2.Subnet{}, subnets.([]*ec2.Subnet)...), nil
@@ -92,17 +114,20 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1beta1.EC2NodeCl
 	// Ensure that all the subnets that are returned here are unique
 	subnets := map[string]*ec2.Subnet{}
 	for _, filters := range filterSets {
-		output, err := p.ec2api.DescribeSubnetsWithContext(ctx, &ec2.DescribeSubnetsInput{Filters: filters})
+		output, err := p.describeSubnets(ctx, filters)
 		if err != nil {
 			return nil, fmt.Errorf("describing subnets %s, %w", pretty.Concise(filters), err)
 		}
-		for i := range output.Subnets {
-			subnets[lo.FromPtr(output.Subnets[i].SubnetId)] = output.Subnets[i]
-			p.availableIPAddressCache.SetDefault(lo.FromPtr(output.Subnets[i].SubnetId), lo.FromPtr(output.Subnets[i].AvailableIpAddressCount))
-			p.associatePublicIPAddressCache.SetDefault(lo.FromPtr(output.Subnets[i].SubnetId), lo.FromPtr(output.Subnets[i].MapPublicIpOnLaunch))
+		for i := range output {
+			subnets[lo.FromPtr(output[i].SubnetId)] = output[i]
+			p.availableIPAddressCache.SetDefault(lo.FromPtr(output[i].SubnetId), lo.FromPtr(output[i].AvailableIpAddressCount))
+			p.associatePublicIPAddressCache.SetDefault(lo.FromPtr(output[i].SubnetId), lo.FromPtr(output[i].MapPublicIpOnLaunch))
 			// subnets can be leaked here, if a subnets is never called received from ec2
 			// we are accepting it for now, as this will be an insignificant amount of memory
-			delete(p.inflightIPs, lo.FromPtr(output.Subnets[i].SubnetId)) // remove any previously tracked IP addresses since we just refreshed from EC2
+			delete(p.inflightIPs, lo.FromPtr(output[i].SubnetId)) // remove any previously tracked IP addresses since we just refreshed from EC2
+			if tagKey := options.FromContext(ctx).SubnetPriorityTagKey; tagKey != "" {
+				p.priorities[lo.FromPtr(output[i].SubnetId)] = subnetPriority(output[i], tagKey)
+			}
 		}
 	}
 	p.cache.SetDefault(fmt.Sprint(hash), lo.Values(subnets))
@@ -116,6 +141,59 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1beta1.EC2NodeCl
 	return lo.Values(subnets), nil
 }
 
+// describeSubnets returns the subnets matching the filters. When discovery through the Resource Groups Tagging API is
+// enabled, subnets selected by tags are resolved to subnet IDs with GetResources and only the matching subnets are
+// described. Discovery falls back to describing the subnets by their tags if the Tagging API returns an error.
+func (p *DefaultProvider) describeSubnets(ctx context.Context, filters []*ec2.Filter) ([]*ec2.Subnet, error) {
+	if tagFilters, ok := getTagFilters(filters); ok && options.FromContext(ctx).SubnetDiscoveryTaggingAPI {
+		ids, err := p.subnetIDsFromTags(ctx, tagFilters)
+		// The tagging api is eventually consistent, so subnets that were just tagged may not be returned yet. An empty
+		// result falls back to describing subnets rather than resolving no subnets for the selector.
+		if err == nil && len(ids) > 0 {
+			var subnets []*ec2.Subnet
+			for _, chunk := range lo.Chunk(ids, maxFilterValues) {
+				output, err := p.ec2api.DescribeSubnetsWithContext(ctx, &ec2.DescribeSubnetsInput{Filters: []*ec2.Filter{{Name: aws.String("subnet-id"), Values: aws.StringSlice(chunk)}}})
+				if err != nil {
+					return nil, err
+				}
+				subnets = append(subnets, output.Subnets...)
+			}
+			return subnets, nil
+		}
+		if err != nil {
+			log.FromContext(ctx).Error(err, "failed discovering subnets with the resource groups tagging api, falling back to describing subnets")
+		}
+	}
+	output, err := p.ec2api.DescribeSubnetsWithContext(ctx, &ec2.DescribeSubnetsInput{Filters: filters})
+	if err != nil {
+		return nil, err
+	}
+	return output.Subnets, nil
+}
+
+// subnetIDsFromTags returns the IDs of the subnets that match all the tag filters
+func (p *DefaultProvider) subnetIDsFromTags(ctx context.Context, tagFilters []*resourcegroupstaggingapi.TagFilter) ([]string, error) {
+	var ids []string
+	var parseErr error
+	if err := p.taggingapi.GetResourcesPagesWithContext(ctx, &resourcegroupstaggingapi.GetResourcesInput{
+		ResourceTypeFilters: aws.StringSlice([]string{"ec2:subnet"}),
+		TagFilters:          tagFilters,
+	}, func(output *resourcegroupstaggingapi.GetResourcesOutput, _ bool) bool {
+		for _, resource := range output.ResourceTagMappingList {
+			parsed, err := arn.Parse(aws.StringValue(resource.ResourceARN))
+			if err != nil {
+				parseErr = fmt.Errorf("parsing subnet arn, %w", err)
+				return false
+			}
+			ids = append(ids, strings.TrimPrefix(parsed.Resource, "subnet/"))
+		}
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("getting resources %s, %w", pretty.Concise(tagFilters), err)
+	}
+	return ids, parseErr
+}
+
 // associatePublicIPAddressValue validates whether we know the association value for all subnets AND
 // that all subnets don't have associatePublicIP set. If both of these are true, we set the value explicitly to false
 // For more detail see: https://github.com/aws/karpenter-provider-aws/pull/3814
@@ -131,6 +209,8 @@ func (p *DefaultProvider) AssociatePublicIPAddressValue(nodeClass *v1beta1.EC2No
 
 // ZonalSubnetsForLaunch returns a mapping of zone to the subnet with the most available IP addresses and deducts the passed ips from the available count
 func (p *DefaultProvider) ZonalSubnetsForLaunch(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, instanceTypes []*cloudprovider.InstanceType, capacityType string) (map[string]*Subnet, error) {
+	_, span := tracing.Start(ctx, "subnet.ZonalSubnetsForLaunch", tracing.NodeClass(nodeClass))
+	defer span.End()
 	if len(nodeClass.Status.Subnets) == 0 {
 		return nil, fmt.Errorf("no subnets matched selector %v", nodeClass.Spec.SubnetSelectorTerms)
 	}
@@ -146,6 +226,7 @@ func (p *DefaultProvider) ZonalSubnetsForLaunch(ctx context.Context, nodeClass *
 		}
 	}
 
+	prioritized := options.FromContext(ctx).SubnetPriorityTagKey != ""
 	for _, subnet := range nodeClass.Status.Subnets {
 		if v, ok := zonalSubnets[subnet.Zone]; ok {
 			currentZonalSubnetIPAddressCount := v.AvailableIPAddressCount
@@ -157,7 +238,11 @@ func (p *DefaultProvider) ZonalSubnetsForLaunch(ctx context.Context, nodeClass *
 				newZonalSubnetIPAddressCount = ips
 			}
 
-			if currentZonalSubnetIPAddressCount >= newZonalSubnetIPAddressCount {
+			if prioritized {
+				if !p.prefersSubnet(subnet.ID, newZonalSubnetIPAddressCount, v.ID, currentZonalSubnetIPAddressCount, p.minPods(instanceTypes, subnet.Zone, capacityType)) {
+					continue
+				}
+			} else if currentZonalSubnetIPAddressCount >= newZonalSubnetIPAddressCount {
 				continue
 			}
 		}
@@ -241,6 +326,41 @@ func (p *DefaultProvider) LivenessProbe(_ *http.Request) error {
 	return nil
 }
 
+// prefersSubnet returns true if the candidate subnet is preferred over the current subnet of its zone for a launch that
+// is predicted to use predictedIPs. Subnets with enough available IPs for the launch are preferred, then subnets with a
+// higher priority, then subnets with more available IPs.
+func (p *DefaultProvider) prefersSubnet(candidateID string, candidateIPs int64, currentID string, currentIPs int64, predictedIPs int64) bool {
+	if candidateFits, currentFits := candidateIPs >= predictedIPs, currentIPs >= predictedIPs; candidateFits != currentFits {
+		return candidateFits
+	}
+	if candidatePriority, currentPriority := p.priority(candidateID), p.priority(currentID); candidatePriority != currentPriority {
+		return candidatePriority > currentPriority
+	}
+	return candidateIPs > currentIPs
+}
+
+// priority returns the priority of the subnet, which is the lowest priority if it's unknown
+func (p *DefaultProvider) priority(id string) float64 {
+	if priority, ok := p.priorities[id]; ok {
+		return priority
+	}
+	return math.Inf(-1)
+}
+
+// subnetPriority returns the numeric priority from the priority tag of the subnet. Subnets with a missing or invalid
+// priority tag have the lowest priority.
+func subnetPriority(subnet *ec2.Subnet, tagKey string) float64 {
+	tag, ok := lo.Find(subnet.Tags, func(t *ec2.Tag) bool { return aws.StringValue(t.Key) == tagKey })
+	if !ok {
+		return math.Inf(-1)
+	}
+	priority, err := strconv.ParseFloat(strings.TrimSpace(aws.StringValue(tag.Value)), 64)
+	if err != nil || math.IsNaN(priority) {
+		return math.Inf(-1)
+	}
+	return priority
+}
+
 func (p *DefaultProvider) minPods(instanceTypes []*cloudprovider.InstanceType, zone string, capacityType string) int64 {
 	// filter for instance types available in the zone and capacity type being requested
 	filteredInstanceTypes := lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
@@ -260,6 +380,28 @@ func (p *DefaultProvider) minPods(instanceTypes []*cloudprovider.InstanceType, z
 	return pods
 }
 
+// getTagFilters converts EC2 tag filters to Resource Groups Tagging API tag filters. It returns false if any of the
+// filters doesn't select on tags or uses wildcards, which the Tagging API doesn't support.
+func getTagFilters(filters []*ec2.Filter) ([]*resourcegroupstaggingapi.TagFilter, bool) {
+	var tagFilters []*resourcegroupstaggingapi.TagFilter
+	for _, filter := range filters {
+		if lo.ContainsBy(append([]*string{filter.Name}, filter.Values...), func(s *string) bool { return strings.ContainsAny(aws.StringValue(s), "*?") }) {
+			return nil, false
+		}
+		switch name := aws.StringValue(filter.Name); {
+		case name == "tag-key":
+			tagFilters = append(tagFilters, lo.Map(filter.Values, func(key *string, _ int) *resourcegroupstaggingapi.TagFilter {
+				return &resourcegroupstaggingapi.TagFilter{Key: key}
+			})...)
+		case strings.HasPrefix(name, "tag:"):
+			tagFilters = append(tagFilters, &resourcegroupstaggingapi.TagFilter{Key: aws.String(strings.TrimPrefix(name, "tag:")), Values: filter.Values})
+		default:
+			return nil, false
+		}
+	}
+	return tagFilters, len(tagFilters) > 0
+}
+
 func getFilterSets(terms []v1beta1.SubnetSelectorTerm) (res [][]*ec2.Filter) {
 	idFilter := &ec2.Filter{Name: aws.String("subnet-id")}
 	for _, term := range terms {
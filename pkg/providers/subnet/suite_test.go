@@ -16,19 +16,27 @@ package subnet_test
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"sync"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
 	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
 
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/operator/scheme"
 	coretest "sigs.k8s.io/karpenter/pkg/test"
@@ -218,6 +226,187 @@ var _ = Describe("SubnetProvider", func() {
 				},
 			}, subnets)
 		})
+		It("should discover subnets across vpcs", func() {
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
+				{SubnetId: lo.ToPtr("subnet-test1"), AvailabilityZone: lo.ToPtr("test-zone-1a"), AvailableIpAddressCount: lo.ToPtr[int64](100), VpcId: lo.ToPtr("vpc-test1"),
+					Tags: []*ec2.Tag{{Key: lo.ToPtr("foo"), Value: lo.ToPtr("bar")}}},
+				{SubnetId: lo.ToPtr("subnet-test2"), AvailabilityZone: lo.ToPtr("test-zone-1a"), AvailableIpAddressCount: lo.ToPtr[int64](100), VpcId: lo.ToPtr("vpc-test2"),
+					Tags: []*ec2.Tag{{Key: lo.ToPtr("foo"), Value: lo.ToPtr("bar")}}},
+				{SubnetId: lo.ToPtr("subnet-test3"), AvailabilityZone: lo.ToPtr("test-zone-1b"), AvailableIpAddressCount: lo.ToPtr[int64](100), VpcId: lo.ToPtr("vpc-test3")},
+			}})
+			nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{
+				{
+					Tags: map[string]string{"foo": "bar"},
+				},
+				{
+					ID: "subnet-test3",
+				},
+			}
+			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			ExpectConsistsOfSubnets([]*ec2.Subnet{
+				{
+					SubnetId:                lo.ToPtr("subnet-test1"),
+					AvailabilityZone:        lo.ToPtr("test-zone-1a"),
+					AvailableIpAddressCount: lo.ToPtr[int64](100),
+					VpcId:                   lo.ToPtr("vpc-test1"),
+				},
+				{
+					SubnetId:                lo.ToPtr("subnet-test2"),
+					AvailabilityZone:        lo.ToPtr("test-zone-1a"),
+					AvailableIpAddressCount: lo.ToPtr[int64](100),
+					VpcId:                   lo.ToPtr("vpc-test2"),
+				},
+				{
+					SubnetId:                lo.ToPtr("subnet-test3"),
+					AvailabilityZone:        lo.ToPtr("test-zone-1b"),
+					AvailableIpAddressCount: lo.ToPtr[int64](100),
+					VpcId:                   lo.ToPtr("vpc-test3"),
+				},
+			}, subnets)
+		})
+	})
+	Context("Resolution Duration Metric", func() {
+		sampleCount := func(cacheHit string) uint64 {
+			GinkgoHelper()
+			m, ok := FindMetricWithLabelValues("karpenter_provider_resolution_duration_seconds", map[string]string{
+				"provider":  "subnet",
+				"cache_hit": cacheHit,
+			})
+			if !ok {
+				return 0
+			}
+			return m.GetHistogram().GetSampleCount()
+		}
+		It("should record the duration of resolving subnets with whether they were served from the cache", func() {
+			misses, hits := sampleCount("false"), sampleCount("true")
+			_, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(sampleCount("false")).To(Equal(misses + 1))
+			Expect(sampleCount("true")).To(Equal(hits))
+
+			_, err = awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(sampleCount("false")).To(Equal(misses + 1))
+			Expect(sampleCount("true")).To(Equal(hits + 1))
+		})
+	})
+	Context("Resource Groups Tagging API", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				SubnetDiscoveryTaggingAPI: lo.ToPtr(true),
+			}))
+		})
+		It("should discover subnets by tags through the tagging api", func() {
+			nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{
+				{
+					Tags: map[string]string{"foo": "bar", "Name": "test-subnet-2"},
+				},
+			}
+			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			ExpectConsistsOfSubnets([]*ec2.Subnet{
+				{
+					SubnetId:                lo.ToPtr("subnet-test2"),
+					AvailabilityZone:        lo.ToPtr("test-zone-1b"),
+					AvailableIpAddressCount: lo.ToPtr[int64](100),
+				},
+			}, subnets)
+			Expect(awsEnv.TaggingAPI.GetResourcesBehavior.SuccessfulCalls()).To(Equal(1))
+			input := awsEnv.TaggingAPI.GetResourcesBehavior.CalledWithInput.Pop()
+			Expect(aws.StringValueSlice(input.ResourceTypeFilters)).To(ConsistOf("ec2:subnet"))
+			Expect(input.TagFilters).To(HaveLen(2))
+		})
+		It("should discover subnets by tag keys through the tagging api", func() {
+			nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{
+				{
+					Tags: map[string]string{"TestTag": "*"},
+				},
+			}
+			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			ExpectConsistsOfSubnets([]*ec2.Subnet{
+				{
+					SubnetId:                lo.ToPtr("subnet-test3"),
+					AvailabilityZone:        lo.ToPtr("test-zone-1c"),
+					AvailableIpAddressCount: lo.ToPtr[int64](100),
+				},
+			}, subnets)
+			Expect(awsEnv.TaggingAPI.GetResourcesBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+		It("should return no subnets when no subnets match the tags", func() {
+			nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{
+				{
+					Tags: map[string]string{"Name": "test-subnet-5"},
+				},
+			}
+			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(subnets).To(BeEmpty())
+			Expect(awsEnv.TaggingAPI.GetResourcesBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+		It("should fall back to describing subnets when the tagging api doesn't return any subnets", func() {
+			awsEnv.TaggingAPI.GetResourcesBehavior.Output.Set(&resourcegroupstaggingapi.GetResourcesOutput{})
+			nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{
+				{
+					Tags: map[string]string{"Name": "test-subnet-1"},
+				},
+			}
+			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			ExpectConsistsOfSubnets([]*ec2.Subnet{
+				{
+					SubnetId:                lo.ToPtr("subnet-test1"),
+					AvailabilityZone:        lo.ToPtr("test-zone-1a"),
+					AvailableIpAddressCount: lo.ToPtr[int64](100),
+				},
+			}, subnets)
+			Expect(awsEnv.TaggingAPI.GetResourcesBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+		It("should fall back to describing subnets when the tagging api returns an error", func() {
+			awsEnv.TaggingAPI.GetResourcesBehavior.Error.Set(fmt.Errorf("AccessDeniedException"))
+			nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{
+				{
+					Tags: map[string]string{"Name": "test-subnet-1"},
+				},
+			}
+			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			ExpectConsistsOfSubnets([]*ec2.Subnet{
+				{
+					SubnetId:                lo.ToPtr("subnet-test1"),
+					AvailabilityZone:        lo.ToPtr("test-zone-1a"),
+					AvailableIpAddressCount: lo.ToPtr[int64](100),
+				},
+			}, subnets)
+			Expect(awsEnv.TaggingAPI.GetResourcesBehavior.FailedCalls()).To(Equal(1))
+		})
+		It("should not use the tagging api for subnets selected by ID or wildcard tags", func() {
+			nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{
+				{
+					ID: "subnet-test1",
+				},
+				{
+					Tags: map[string]string{"*": "*"},
+				},
+			}
+			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(subnets).To(HaveLen(4))
+			Expect(awsEnv.TaggingAPI.GetResourcesBehavior.Calls()).To(Equal(0))
+		})
+		It("should not use the tagging api when it isn't enabled", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{
+				{
+					Tags: map[string]string{"Name": "test-subnet-1"},
+				},
+			}
+			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			Expect(subnets).To(HaveLen(1))
+			Expect(awsEnv.TaggingAPI.GetResourcesBehavior.Calls()).To(Equal(0))
+		})
 	})
 	Context("AssociatePublicIPAddress", func() {
 		It("should be false when no subnets assign a public IPv4 address to EC2 instances on launch", func() {
@@ -266,6 +455,87 @@ var _ = Describe("SubnetProvider", func() {
 			Expect(associatePublicIP).To(BeNil())
 		})
 	})
+	Context("Priority", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		makeSubnet := func(id string, zone string, availableIPs int64, priority string) *ec2.Subnet {
+			tags := []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String(id)}}
+			if priority != "" {
+				tags = append(tags, &ec2.Tag{Key: aws.String("example.com/priority"), Value: aws.String(priority)})
+			}
+			return &ec2.Subnet{SubnetId: aws.String(id), AvailabilityZone: aws.String(zone), AvailableIpAddressCount: aws.Int64(availableIPs), Tags: tags}
+		}
+		// zonalSubnetsForLaunch discovers the subnets of the nodeclass and returns the subnet selected in each zone
+		zonalSubnetsForLaunch := func() map[string]string {
+			GinkgoHelper()
+			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			nodeClass.Status.Subnets = lo.Map(subnets, func(s *ec2.Subnet, _ int) v1beta1.Subnet {
+				return v1beta1.Subnet{ID: aws.StringValue(s.SubnetId), Zone: aws.StringValue(s.AvailabilityZone)}
+			})
+			zonalSubnets, err := awsEnv.SubnetProvider.ZonalSubnetsForLaunch(ctx, nodeClass, instanceTypes, corev1beta1.CapacityTypeOnDemand)
+			Expect(err).ToNot(HaveOccurred())
+			return lo.MapValues(zonalSubnets, func(s *subnet.Subnet, _ string) string { return s.ID })
+		}
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				SubnetPriorityTagKey: lo.ToPtr("example.com/priority"),
+			}))
+			instanceTypes = nil
+		})
+		It("should prefer the subnet with the highest priority in a zone", func() {
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
+				makeSubnet("subnet-low", "test-zone-1a", 100, "1"),
+				makeSubnet("subnet-high", "test-zone-1a", 50, "10"),
+				makeSubnet("subnet-none", "test-zone-1a", 200, ""),
+				makeSubnet("subnet-other", "test-zone-1b", 10, "1"),
+			}})
+			Expect(zonalSubnetsForLaunch()).To(Equal(map[string]string{
+				"test-zone-1a": "subnet-high",
+				"test-zone-1b": "subnet-other",
+			}))
+		})
+		It("should prefer subnets with enough available IPs for the launch over subnets with a higher priority", func() {
+			instanceTypes = []*corecloudprovider.InstanceType{fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "test-instance-type",
+				Offerings: []corecloudprovider.Offering{
+					{CapacityType: corev1beta1.CapacityTypeOnDemand, Zone: "test-zone-1a", Price: 1, Available: true},
+				},
+				Resources: v1.ResourceList{v1.ResourcePods: resource.MustParse("60")},
+			})}
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
+				makeSubnet("subnet-low", "test-zone-1a", 100, "1"),
+				makeSubnet("subnet-high", "test-zone-1a", 50, "10"),
+				makeSubnet("subnet-none", "test-zone-1a", 200, ""),
+			}})
+			Expect(zonalSubnetsForLaunch()).To(Equal(map[string]string{"test-zone-1a": "subnet-low"}))
+		})
+		It("should treat missing and invalid priorities as the lowest priority", func() {
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
+				makeSubnet("subnet-invalid", "test-zone-1a", 300, "high"),
+				makeSubnet("subnet-none", "test-zone-1a", 200, ""),
+				makeSubnet("subnet-negative", "test-zone-1a", 10, "-5"),
+			}})
+			Expect(zonalSubnetsForLaunch()).To(Equal(map[string]string{"test-zone-1a": "subnet-negative"}))
+		})
+		It("should prefer the subnet with the most available IPs when priorities are equal", func() {
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
+				makeSubnet("subnet-small", "test-zone-1a", 50, "5"),
+				makeSubnet("subnet-large", "test-zone-1a", 150, "5.0"),
+				makeSubnet("subnet-invalid", "test-zone-1a", 100, "high"),
+				makeSubnet("subnet-none", "test-zone-1a", 200, ""),
+			}})
+			Expect(zonalSubnetsForLaunch()).To(Equal(map[string]string{"test-zone-1a": "subnet-large"}))
+		})
+		It("should ignore priority tags when the priority tag key isn't set", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
+				makeSubnet("subnet-low", "test-zone-1a", 100, "1"),
+				makeSubnet("subnet-high", "test-zone-1a", 50, "10"),
+				makeSubnet("subnet-none", "test-zone-1a", 200, ""),
+			}})
+			Expect(zonalSubnetsForLaunch()).To(Equal(map[string]string{"test-zone-1a": "subnet-none"}))
+		})
+	})
 	Context("Provider Cache", func() {
 		It("should resolve subnets from cache that are filtered by id", func() {
 			expectedSubnets := awsEnv.EC2API.DescribeSubnetsOutput.Clone().Subnets
@@ -408,8 +678,9 @@ func ExpectConsistsOfSubnets(expected, actual []*ec2.Subnet) {
 		_, ok := lo.Find(actual, func(s *ec2.Subnet) bool {
 			return lo.FromPtr(s.SubnetId) == lo.FromPtr(elem.SubnetId) &&
 				lo.FromPtr(s.AvailabilityZone) == lo.FromPtr(elem.AvailabilityZone) &&
-				lo.FromPtr(s.AvailableIpAddressCount) == lo.FromPtr(elem.AvailableIpAddressCount)
+				lo.FromPtr(s.AvailableIpAddressCount) == lo.FromPtr(elem.AvailableIpAddressCount) &&
+				lo.FromPtr(s.VpcId) == lo.FromPtr(elem.VpcId)
 		})
-		Expect(ok).To(BeTrue(), `Expected subnet with {"SubnetId": %q, "AvailabilityZone": %q, "AvailableIpAddressCount": %q} to exist`, lo.FromPtr(elem.SubnetId), lo.FromPtr(elem.AvailabilityZone), lo.FromPtr(elem.AvailableIpAddressCount))
+		Expect(ok).To(BeTrue(), `Expected subnet with {"SubnetId": %q, "AvailabilityZone": %q, "AvailableIpAddressCount": %q, "VpcId": %q} to exist`, lo.FromPtr(elem.SubnetId), lo.FromPtr(elem.AvailabilityZone), lo.FromPtr(elem.AvailableIpAddressCount), lo.FromPtr(elem.VpcId))
 	}
 }
@@ -25,6 +25,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 )
 
 const (
@@ -57,6 +59,10 @@ func NewDefaultProvider(kubernetesInterface kubernetes.Interface, cache *cache.C
 }
 
 func (p *DefaultProvider) Get(ctx context.Context) (string, error) {
+	// A configured version pins the version rather than following the version of the API server
+	if version := options.FromContext(ctx).KubernetesVersion; version != "" {
+		return version, nil
+	}
 	if version, ok := p.cache.Get(kubernetesVersionCacheKey); ok {
 		return version.(string), nil
 	}
@@ -68,14 +74,15 @@ func (p *DefaultProvider) Get(ctx context.Context) (string, error) {
 	p.cache.SetDefault(kubernetesVersionCacheKey, version)
 	if p.cm.HasChanged("kubernetes-version", version) {
 		log.FromContext(ctx).WithValues("version", version).V(1).Info("discovered kubernetes version")
-		if err := validateK8sVersion(version); err != nil {
+		if err := ValidateK8sVersion(version); err != nil {
 			log.FromContext(ctx).Error(err, "failed validating kubernetes version")
 		}
 	}
 	return version, nil
 }
 
-func validateK8sVersion(v string) error {
+// ValidateK8sVersion returns an error when the minor version is outside of the versions that Karpenter supports
+func ValidateK8sVersion(v string) error {
 	k8sVersion := version.MustParseGeneric(v)
 
 	// We will only error if the user is running karpenter on a k8s version,
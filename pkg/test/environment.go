@@ -18,8 +18,10 @@ import (
 	"context"
 	"net"
 
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
+	"golang.org/x/sync/semaphore"
 	corev1 "k8s.io/api/core/v1"
 
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
@@ -28,11 +30,14 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityreservation"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/placementscore"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
@@ -50,35 +55,47 @@ func init() {
 
 type Environment struct {
 	// API
-	EC2API     *fake.EC2API
-	EKSAPI     *fake.EKSAPI
-	SSMAPI     *fake.SSMAPI
-	IAMAPI     *fake.IAMAPI
-	PricingAPI *fake.PricingAPI
+	EC2API          *fake.EC2API
+	EKSAPI          *fake.EKSAPI
+	SSMAPI          *fake.SSMAPI
+	ImagebuilderAPI *fake.ImagebuilderAPI
+	IAMAPI          *fake.IAMAPI
+	TaggingAPI      *fake.TaggingAPI
+	PricingAPI      *fake.PricingAPI
+	STSAPI          *fake.STSAPI
+	// AccountEC2API is the EC2 API of the account of roles that EC2NodeClasses assume
+	AccountEC2API *fake.EC2API
 
 	// Cache
 	EC2Cache                      *cache.Cache
 	KubernetesVersionCache        *cache.Cache
 	InstanceTypeCache             *cache.Cache
+	InstanceTypeOfferingsCache    *cache.Cache
 	UnavailableOfferingsCache     *awscache.UnavailableOfferings
 	LaunchTemplateCache           *cache.Cache
 	SubnetCache                   *cache.Cache
 	AvailableIPAdressCache        *cache.Cache
 	AssociatePublicIPAddressCache *cache.Cache
 	SecurityGroupCache            *cache.Cache
+	CapacityReservationCache      *cache.Cache
 	InstanceProfileCache          *cache.Cache
+	InstanceProfileRoleCache      *cache.Cache
+	ClusterCache                  *cache.Cache
 
 	// Providers
-	InstanceTypesProvider   *instancetype.DefaultProvider
-	InstanceProvider        *instance.DefaultProvider
-	SubnetProvider          *subnet.DefaultProvider
-	SecurityGroupProvider   *securitygroup.DefaultProvider
-	InstanceProfileProvider *instanceprofile.DefaultProvider
-	PricingProvider         *pricing.DefaultProvider
-	AMIProvider             *amifamily.DefaultProvider
-	AMIResolver             *amifamily.Resolver
-	VersionProvider         *version.DefaultProvider
-	LaunchTemplateProvider  *launchtemplate.DefaultProvider
+	InstanceTypesProvider       *instancetype.DefaultProvider
+	InstanceProvider            *instance.DefaultProvider
+	SubnetProvider              *subnet.DefaultProvider
+	SecurityGroupProvider       *securitygroup.DefaultProvider
+	CapacityReservationProvider *capacityreservation.DefaultProvider
+	InstanceProfileProvider     *instanceprofile.DefaultProvider
+	PricingProvider             *pricing.DefaultProvider
+	AMIProvider                 *amifamily.DefaultProvider
+	AMIResolver                 *amifamily.Resolver
+	VersionProvider             *version.DefaultProvider
+	LaunchTemplateProvider      *launchtemplate.DefaultProvider
+	PlacementScoreProvider      *placementscore.DefaultProvider
+	AccountProvider             *account.DefaultProvider
 }
 
 func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment {
@@ -86,34 +103,45 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 	ec2api := fake.NewEC2API()
 	eksapi := fake.NewEKSAPI()
 	ssmapi := fake.NewSSMAPI()
+	imagebuilderapi := fake.NewImagebuilderAPI()
 	iamapi := fake.NewIAMAPI()
+	taggingapi := fake.NewTaggingAPI()
+	stsapi := fake.NewSTSAPI()
+	accountEC2API := fake.NewEC2API()
 
 	// cache
 	ec2Cache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	kubernetesVersionCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	instanceTypeCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	instanceTypeOfferingsCache := cache.New(awscache.InstanceTypesAndZonesTTL, awscache.DefaultCleanupInterval)
 	unavailableOfferingsCache := awscache.NewUnavailableOfferings()
 	launchTemplateCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	subnetCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	availableIPAdressCache := cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval)
 	associatePublicIPAddressCache := cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval)
 	securityGroupCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	capacityReservationCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	instanceProfileCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	instanceProfileRoleCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	clusterCache := cache.New(awscache.ClusterTTL, awscache.DefaultCleanupInterval)
 	fakePricingAPI := &fake.PricingAPI{}
 
 	// Providers
 	pricingProvider := pricing.NewDefaultProvider(ctx, fakePricingAPI, ec2api, fake.DefaultRegion)
-	subnetProvider := subnet.NewDefaultProvider(ec2api, subnetCache, availableIPAdressCache, associatePublicIPAddressCache)
-	securityGroupProvider := securitygroup.NewDefaultProvider(ec2api, securityGroupCache)
+	subnetProvider := subnet.NewDefaultProvider(ec2api, taggingapi, subnetCache, availableIPAdressCache, associatePublicIPAddressCache)
+	securityGroupProvider := securitygroup.NewDefaultProvider(ec2api, eksapi, securityGroupCache)
+	capacityReservationProvider := capacityreservation.NewDefaultProvider(ec2api, capacityReservationCache)
 	versionProvider := version.NewDefaultProvider(env.KubernetesInterface, kubernetesVersionCache)
-	instanceProfileProvider := instanceprofile.NewDefaultProvider(fake.DefaultRegion, iamapi, instanceProfileCache)
-	amiProvider := amifamily.NewDefaultProvider(versionProvider, ssmapi, ec2api, ec2Cache)
+	instanceProfileProvider := instanceprofile.NewDefaultProvider(fake.DefaultRegion, iamapi, instanceProfileCache, instanceProfileRoleCache)
+	amiResolutionLimiter := semaphore.NewWeighted(int64(Options().AMIResolutionConcurrency))
+	amiProvider := amifamily.NewDefaultProvider(fake.DefaultRegion, versionProvider, ssmapi, ec2api, imagebuilderapi, ec2Cache, amiResolutionLimiter)
 	amiResolver := amifamily.NewResolver(amiProvider)
-	instanceTypesProvider := instancetype.NewDefaultProvider(fake.DefaultRegion, instanceTypeCache, ec2api, subnetProvider, unavailableOfferingsCache, pricingProvider)
+	instanceTypesProvider := instancetype.NewDefaultProvider(fake.DefaultRegion, instanceTypeCache, instanceTypeOfferingsCache, ec2api, subnetProvider, unavailableOfferingsCache, pricingProvider, instancetype.NoopInstanceTypeFilter{})
 	launchTemplateProvider :=
 		launchtemplate.NewDefaultProvider(
 			ctx,
 			launchTemplateCache,
+			clusterCache,
 			ec2api,
 			eksapi,
 			amiResolver,
@@ -124,6 +152,7 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 			net.ParseIP("10.0.100.10"),
 			"https://test-cluster",
 		)
+	placementScoreProvider := placementscore.NewDefaultProvider(fake.DefaultRegion, ec2api)
 	instanceProvider :=
 		instance.NewDefaultProvider(ctx,
 			"",
@@ -132,14 +161,81 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 			instanceTypesProvider,
 			subnetProvider,
 			launchTemplateProvider,
+			placementScoreProvider,
+			instance.NoopTagMutator{},
 		)
+	accountProvider := account.NewDefaultProvider(
+		env.Client,
+		stsapi,
+		&account.Providers{
+			EC2API:                      ec2api,
+			SubnetProvider:              subnetProvider,
+			SecurityGroupProvider:       securityGroupProvider,
+			CapacityReservationProvider: capacityReservationProvider,
+			InstanceProfileProvider:     instanceProfileProvider,
+			AMIProvider:                 amiProvider,
+			LaunchTemplateProvider:      launchTemplateProvider,
+			InstanceTypeProvider:        instanceTypesProvider,
+			InstanceProvider:            instanceProvider,
+			UnavailableOfferings:        unavailableOfferingsCache,
+		},
+		func(string, *credentials.Credentials) *account.Providers {
+			accountSubnetProvider := subnet.NewDefaultProvider(accountEC2API, taggingapi, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval))
+			accountSecurityGroupProvider := securitygroup.NewDefaultProvider(accountEC2API, eksapi, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+			accountAMIProvider := amifamily.NewDefaultProvider(fake.DefaultRegion, versionProvider, ssmapi, accountEC2API, imagebuilderapi, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), amiResolutionLimiter)
+			accountLaunchTemplateProvider := launchtemplate.NewDefaultProvider(
+				ctx,
+				cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval),
+				clusterCache,
+				accountEC2API,
+				eksapi,
+				amifamily.NewResolver(accountAMIProvider),
+				accountSecurityGroupProvider,
+				accountSubnetProvider,
+				lo.ToPtr("ca-bundle"),
+				make(chan struct{}),
+				net.ParseIP("10.0.100.10"),
+				"https://test-cluster",
+			)
+			accountUnavailableOfferings := awscache.NewUnavailableOfferings()
+			accountInstanceTypesProvider := instancetype.NewDefaultProvider(fake.DefaultRegion, cache.New(awscache.InstanceTypesAndZonesTTL, awscache.DefaultCleanupInterval),
+				cache.New(awscache.InstanceTypesAndZonesTTL, awscache.DefaultCleanupInterval), accountEC2API, accountSubnetProvider, accountUnavailableOfferings, pricingProvider, instancetype.NoopInstanceTypeFilter{})
+			lo.Must0(accountInstanceTypesProvider.UpdateInstanceTypes(ctx))
+			lo.Must0(accountInstanceTypesProvider.UpdateInstanceTypeOfferings(ctx))
+			return &account.Providers{
+				EC2API:                      accountEC2API,
+				SubnetProvider:              accountSubnetProvider,
+				SecurityGroupProvider:       accountSecurityGroupProvider,
+				CapacityReservationProvider: capacityreservation.NewDefaultProvider(accountEC2API, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)),
+				InstanceProfileProvider:     instanceprofile.NewDefaultProvider(fake.DefaultRegion, iamapi, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)),
+				AMIProvider:                 accountAMIProvider,
+				LaunchTemplateProvider:      accountLaunchTemplateProvider,
+				InstanceTypeProvider:        accountInstanceTypesProvider,
+				InstanceProvider: instance.NewDefaultProvider(ctx,
+					"",
+					accountEC2API,
+					accountUnavailableOfferings,
+					accountInstanceTypesProvider,
+					accountSubnetProvider,
+					accountLaunchTemplateProvider,
+					placementScoreProvider,
+					instance.NoopTagMutator{},
+				),
+				UnavailableOfferings: accountUnavailableOfferings,
+			}
+		},
+	)
 
 	return &Environment{
-		EC2API:     ec2api,
-		EKSAPI:     eksapi,
-		SSMAPI:     ssmapi,
-		IAMAPI:     iamapi,
-		PricingAPI: fakePricingAPI,
+		EC2API:          ec2api,
+		EKSAPI:          eksapi,
+		SSMAPI:          ssmapi,
+		ImagebuilderAPI: imagebuilderapi,
+		IAMAPI:          iamapi,
+		TaggingAPI:      taggingapi,
+		PricingAPI:      fakePricingAPI,
+		STSAPI:          stsapi,
+		AccountEC2API:   accountEC2API,
 
 		EC2Cache:                      ec2Cache,
 		KubernetesVersionCache:        kubernetesVersionCache,
@@ -148,19 +244,26 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 		AvailableIPAdressCache:        availableIPAdressCache,
 		AssociatePublicIPAddressCache: associatePublicIPAddressCache,
 		SecurityGroupCache:            securityGroupCache,
+		CapacityReservationCache:      capacityReservationCache,
 		InstanceProfileCache:          instanceProfileCache,
+		InstanceProfileRoleCache:      instanceProfileRoleCache,
+		InstanceTypeOfferingsCache:    instanceTypeOfferingsCache,
+		ClusterCache:                  clusterCache,
 		UnavailableOfferingsCache:     unavailableOfferingsCache,
 
-		InstanceTypesProvider:   instanceTypesProvider,
-		InstanceProvider:        instanceProvider,
-		SubnetProvider:          subnetProvider,
-		SecurityGroupProvider:   securityGroupProvider,
-		LaunchTemplateProvider:  launchTemplateProvider,
-		InstanceProfileProvider: instanceProfileProvider,
-		PricingProvider:         pricingProvider,
-		AMIProvider:             amiProvider,
-		AMIResolver:             amiResolver,
-		VersionProvider:         versionProvider,
+		InstanceTypesProvider:       instanceTypesProvider,
+		InstanceProvider:            instanceProvider,
+		SubnetProvider:              subnetProvider,
+		SecurityGroupProvider:       securityGroupProvider,
+		CapacityReservationProvider: capacityReservationProvider,
+		LaunchTemplateProvider:      launchTemplateProvider,
+		PlacementScoreProvider:      placementScoreProvider,
+		InstanceProfileProvider:     instanceProfileProvider,
+		PricingProvider:             pricingProvider,
+		AMIProvider:                 amiProvider,
+		AMIResolver:                 amiResolver,
+		VersionProvider:             versionProvider,
+		AccountProvider:             accountProvider,
 	}
 }
 
@@ -168,10 +271,16 @@ func (env *Environment) Reset() {
 	env.EC2API.Reset()
 	env.EKSAPI.Reset()
 	env.SSMAPI.Reset()
+	env.ImagebuilderAPI.Reset()
 	env.IAMAPI.Reset()
+	env.TaggingAPI.Reset()
 	env.PricingAPI.Reset()
+	env.STSAPI.Reset()
+	env.AccountEC2API.Reset()
 	env.PricingProvider.Reset()
 	env.InstanceTypesProvider.Reset()
+	env.InstanceProvider.Reset()
+	env.AccountProvider.Reset()
 
 	env.EC2Cache.Flush()
 	env.KubernetesVersionCache.Flush()
@@ -181,7 +290,11 @@ func (env *Environment) Reset() {
 	env.AssociatePublicIPAddressCache.Flush()
 	env.AvailableIPAdressCache.Flush()
 	env.SecurityGroupCache.Flush()
+	env.CapacityReservationCache.Flush()
 	env.InstanceProfileCache.Flush()
+	env.InstanceProfileRoleCache.Flush()
+	env.PlacementScoreProvider.Reset()
+	env.ClusterCache.Flush()
 
 	mfs, err := crmetrics.Registry.Gather()
 	if err != nil {
@@ -0,0 +1,46 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// Offering identifies an instance type offering by its instance type, zone and capacity type
+type Offering struct {
+	InstanceType string
+	Zone         string
+	CapacityType string
+}
+
+// MarkOfferingsUnavailable marks the offerings as unavailable the same way that the instance provider does when a
+// launch fails with an insufficient capacity error, so that tests can exercise the fallback to other offerings
+// deterministically. The offerings stay unavailable until the environment is reset.
+func (env *Environment) MarkOfferingsUnavailable(ctx context.Context, offerings ...Offering) {
+	for _, offering := range offerings {
+		env.UnavailableOfferingsCache.MarkUnavailableForFleetErr(ctx, &ec2.CreateFleetError{
+			ErrorCode: aws.String("InsufficientInstanceCapacity"),
+			LaunchTemplateAndOverrides: &ec2.LaunchTemplateAndOverridesResponse{
+				Overrides: &ec2.FleetLaunchTemplateOverrides{
+					InstanceType:     aws.String(offering.InstanceType),
+					AvailabilityZone: aws.String(offering.Zone),
+				},
+			},
+		}, offering.CapacityType)
+	}
+}
@@ -25,15 +25,59 @@ import (
 )
 
 type OptionsFields struct {
-	AssumeRoleARN           *string
-	AssumeRoleDuration      *time.Duration
-	ClusterCABundle         *string
-	ClusterName             *string
-	ClusterEndpoint         *string
-	IsolatedVPC             *bool
-	VMMemoryOverheadPercent *float64
-	InterruptionQueue       *string
-	ReservedENIs            *int
+	AssumeRoleARN                 *string
+	AssumeRoleDuration            *time.Duration
+	ClusterCABundle               *string
+	ClusterName                   *string
+	ClusterEndpoint               *string
+	IsolatedVPC                   *bool
+	VMMemoryOverheadPercent       *float64
+	InterruptionQueue             *string
+	InterruptionQueueTagging      *bool
+	InterruptionScheduledChanges  *bool
+	InterruptionBatchSize         *int
+	InterruptionVisibilityTimeout *time.Duration
+	InterruptionWorkers           *int
+	InterruptionDisruptionLimit   *int
+	InterruptionEvictionGrace     *time.Duration
+	InterruptionRebalanceReplace  *bool
+	InterruptionWaitTime          *time.Duration
+	InterruptionReceiveTimeout    *time.Duration
+	ReservedENIs                  *int
+	InstanceTypeFamilies          *string
+	InstanceTypeOfferingsCacheTTL *time.Duration
+	InstanceTypesRefreshInterval  *time.Duration
+	EC2CreateFleetQPS             *float64
+	EC2DescribeInstancesQPS       *float64
+	EC2TerminateInstancesQPS      *float64
+	EC2CreateTagsQPS              *float64
+	NewerGenerationPriceThreshold *float64
+	SubnetDiscoveryTaggingAPI     *bool
+	CreateFleetClientToken        *bool
+	SpotPlacementScore            *bool
+	ZoneCapacityCooldownThreshold *int
+	LaunchTemplateDebugEndpoint   *bool
+	ReadableLaunchTemplateNames   *bool
+	MaxNodeClassLaunchBatchSize   *int
+	EC2Endpoint                   *string
+	SSMEndpoint                   *string
+	PricingEndpoint               *string
+	FIPSEndpoints                 *bool
+	AnnotateInstanceCapabilities  *bool
+	NotReadyTimeout               *time.Duration
+	MaxStoppedInstanceAge         *time.Duration
+	OnDemandAllocationStrategy    *string
+	OnDemandFamilyPriority        *string
+	Tracing                       *bool
+	TracingEndpoint               *string
+	SubnetPriorityTagKey          *string
+	KubernetesVersion             *string
+	PricingRefreshInterval        *time.Duration
+	PricingRefreshJitter          *time.Duration
+	AMISelectorFallback           *bool
+	ClusterDiscovery              *bool
+	NetworkingReserved            *string
+	AMIResolutionConcurrency      *int
 }
 
 func Options(overrides ...OptionsFields) *options.Options {
@@ -44,14 +88,58 @@ func Options(overrides ...OptionsFields) *options.Options {
 		}
 	}
 	return &options.Options{
-		AssumeRoleARN:           lo.FromPtrOr(opts.AssumeRoleARN, ""),
-		AssumeRoleDuration:      lo.FromPtrOr(opts.AssumeRoleDuration, 15*time.Minute),
-		ClusterCABundle:         lo.FromPtrOr(opts.ClusterCABundle, ""),
-		ClusterName:             lo.FromPtrOr(opts.ClusterName, "test-cluster"),
-		ClusterEndpoint:         lo.FromPtrOr(opts.ClusterEndpoint, "https://test-cluster"),
-		IsolatedVPC:             lo.FromPtrOr(opts.IsolatedVPC, false),
-		VMMemoryOverheadPercent: lo.FromPtrOr(opts.VMMemoryOverheadPercent, 0.075),
-		InterruptionQueue:       lo.FromPtrOr(opts.InterruptionQueue, ""),
-		ReservedENIs:            lo.FromPtrOr(opts.ReservedENIs, 0),
+		AssumeRoleARN:                 lo.FromPtrOr(opts.AssumeRoleARN, ""),
+		AssumeRoleDuration:            lo.FromPtrOr(opts.AssumeRoleDuration, 15*time.Minute),
+		ClusterCABundle:               lo.FromPtrOr(opts.ClusterCABundle, ""),
+		ClusterName:                   lo.FromPtrOr(opts.ClusterName, "test-cluster"),
+		ClusterEndpoint:               lo.FromPtrOr(opts.ClusterEndpoint, "https://test-cluster"),
+		IsolatedVPC:                   lo.FromPtrOr(opts.IsolatedVPC, false),
+		VMMemoryOverheadPercent:       lo.FromPtrOr(opts.VMMemoryOverheadPercent, 0.075),
+		InterruptionQueue:             lo.FromPtrOr(opts.InterruptionQueue, ""),
+		InterruptionQueueTagging:      lo.FromPtrOr(opts.InterruptionQueueTagging, false),
+		InterruptionScheduledChanges:  lo.FromPtrOr(opts.InterruptionScheduledChanges, true),
+		InterruptionBatchSize:         lo.FromPtrOr(opts.InterruptionBatchSize, 10),
+		InterruptionVisibilityTimeout: lo.FromPtrOr(opts.InterruptionVisibilityTimeout, 20*time.Second),
+		InterruptionWorkers:           lo.FromPtrOr(opts.InterruptionWorkers, 10),
+		InterruptionDisruptionLimit:   lo.FromPtrOr(opts.InterruptionDisruptionLimit, 0),
+		InterruptionEvictionGrace:     lo.FromPtrOr(opts.InterruptionEvictionGrace, 0),
+		InterruptionRebalanceReplace:  lo.FromPtrOr(opts.InterruptionRebalanceReplace, false),
+		InterruptionWaitTime:          lo.FromPtrOr(opts.InterruptionWaitTime, 20*time.Second),
+		InterruptionReceiveTimeout:    lo.FromPtrOr(opts.InterruptionReceiveTimeout, 30*time.Second),
+		ReservedENIs:                  lo.FromPtrOr(opts.ReservedENIs, 0),
+		InstanceTypeFamilies:          lo.FromPtrOr(opts.InstanceTypeFamilies, ""),
+		InstanceTypeOfferingsCacheTTL: lo.FromPtrOr(opts.InstanceTypeOfferingsCacheTTL, 0),
+		InstanceTypesRefreshInterval:  lo.FromPtrOr(opts.InstanceTypesRefreshInterval, 12*time.Hour),
+		EC2CreateFleetQPS:             lo.FromPtrOr(opts.EC2CreateFleetQPS, 0),
+		EC2DescribeInstancesQPS:       lo.FromPtrOr(opts.EC2DescribeInstancesQPS, 0),
+		EC2TerminateInstancesQPS:      lo.FromPtrOr(opts.EC2TerminateInstancesQPS, 0),
+		EC2CreateTagsQPS:              lo.FromPtrOr(opts.EC2CreateTagsQPS, 0),
+		NewerGenerationPriceThreshold: lo.FromPtrOr(opts.NewerGenerationPriceThreshold, 0),
+		SubnetDiscoveryTaggingAPI:     lo.FromPtrOr(opts.SubnetDiscoveryTaggingAPI, false),
+		CreateFleetClientToken:        lo.FromPtrOr(opts.CreateFleetClientToken, false),
+		SpotPlacementScore:            lo.FromPtrOr(opts.SpotPlacementScore, false),
+		ZoneCapacityCooldownThreshold: lo.FromPtrOr(opts.ZoneCapacityCooldownThreshold, 0),
+		LaunchTemplateDebugEndpoint:   lo.FromPtrOr(opts.LaunchTemplateDebugEndpoint, false),
+		ReadableLaunchTemplateNames:   lo.FromPtrOr(opts.ReadableLaunchTemplateNames, false),
+		MaxNodeClassLaunchBatchSize:   lo.FromPtrOr(opts.MaxNodeClassLaunchBatchSize, 0),
+		EC2Endpoint:                   lo.FromPtrOr(opts.EC2Endpoint, ""),
+		SSMEndpoint:                   lo.FromPtrOr(opts.SSMEndpoint, ""),
+		PricingEndpoint:               lo.FromPtrOr(opts.PricingEndpoint, ""),
+		FIPSEndpoints:                 lo.FromPtrOr(opts.FIPSEndpoints, false),
+		AnnotateInstanceCapabilities:  lo.FromPtrOr(opts.AnnotateInstanceCapabilities, false),
+		NotReadyTimeout:               lo.FromPtrOr(opts.NotReadyTimeout, 0),
+		MaxStoppedInstanceAge:         lo.FromPtrOr(opts.MaxStoppedInstanceAge, 24*time.Hour),
+		OnDemandAllocationStrategy:    lo.FromPtrOr(opts.OnDemandAllocationStrategy, "lowest-price"),
+		OnDemandFamilyPriority:        lo.FromPtrOr(opts.OnDemandFamilyPriority, ""),
+		Tracing:                       lo.FromPtrOr(opts.Tracing, false),
+		TracingEndpoint:               lo.FromPtrOr(opts.TracingEndpoint, ""),
+		SubnetPriorityTagKey:          lo.FromPtrOr(opts.SubnetPriorityTagKey, ""),
+		KubernetesVersion:             lo.FromPtrOr(opts.KubernetesVersion, ""),
+		PricingRefreshInterval:        lo.FromPtrOr(opts.PricingRefreshInterval, 12*time.Hour),
+		PricingRefreshJitter:          lo.FromPtrOr(opts.PricingRefreshJitter, 0),
+		AMISelectorFallback:           lo.FromPtrOr(opts.AMISelectorFallback, false),
+		ClusterDiscovery:              lo.FromPtrOr(opts.ClusterDiscovery, false),
+		NetworkingReserved:            lo.FromPtrOr(opts.NetworkingReserved, ""),
+		AMIResolutionConcurrency:      lo.FromPtrOr(opts.AMIResolutionConcurrency, 10),
 	}
 }
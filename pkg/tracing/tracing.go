@@ -0,0 +1,99 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+const (
+	TracerName  = "github.com/aws/karpenter-provider-aws"
+	ServiceName = "karpenter"
+)
+
+// Start starts a span with Karpenter's tracer. Spans are dropped unless a tracer provider has been registered, which
+// NewTracerProvider does when tracing is enabled.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records the error, if any, on the span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// NodeClass returns the attribute that identifies the EC2NodeClass of a span
+func NodeClass(nodeClass *v1beta1.EC2NodeClass) attribute.KeyValue {
+	return attribute.String("karpenter.k8s.aws/ec2nodeclass", nodeClass.Name)
+}
+
+// NodeClaim returns the attributes that identify the NodeClaim of a span
+func NodeClaim(nodeClaim *corev1beta1.NodeClaim) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("karpenter.sh/nodeclaim", nodeClaim.Name),
+		attribute.String("karpenter.sh/nodepool", nodeClaim.Labels[corev1beta1.NodePoolLabelKey]),
+	}
+}
+
+// NewTracerProvider returns a tracer provider that exports spans with OTLP over HTTP to the tracing endpoint. The
+// endpoint of the exporter defaults to the OTEL_EXPORTER_OTLP_ENDPOINT environment variable, or to http://localhost:4318,
+// when the tracing endpoint isn't set.
+func NewTracerProvider(ctx context.Context) (*sdktrace.TracerProvider, error) {
+	var opts []otlptracehttp.Option
+	if endpoint := options.FromContext(ctx).TracingEndpoint; endpoint != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("parsing tracing endpoint, %w", err)
+		}
+		opts = append(opts, otlptracehttp.WithEndpoint(u.Host))
+		if u.Path != "" && u.Path != "/" {
+			opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+		}
+		if u.Scheme == "http" {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating trace exporter, %w", err)
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(ServiceName),
+			attribute.String("karpenter.sh/cluster", options.FromContext(ctx).ClusterName),
+		)),
+	), nil
+}
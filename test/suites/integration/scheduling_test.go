@@ -83,16 +83,24 @@ var _ = Describe("Scheduling", Ordered, ContinueOnFailure, func() {
 				corev1beta1.NodePoolLabelKey: nodePool.Name,
 				v1.LabelInstanceTypeStable:   "c5.large",
 				// Well Known to AWS
-				v1beta1.LabelInstanceHypervisor:       "nitro",
-				v1beta1.LabelInstanceCategory:         "c",
-				v1beta1.LabelInstanceGeneration:       "5",
-				v1beta1.LabelInstanceFamily:           "c5",
-				v1beta1.LabelInstanceSize:             "large",
-				v1beta1.LabelInstanceCPU:              "2",
-				v1beta1.LabelInstanceCPUManufacturer:  "intel",
-				v1beta1.LabelInstanceMemory:           "4096",
-				v1beta1.LabelInstanceEBSBandwidth:     "4750",
-				v1beta1.LabelInstanceNetworkBandwidth: "750",
+				v1beta1.LabelInstanceHypervisor:                "nitro",
+				v1beta1.LabelInstanceCategory:                  "c",
+				v1beta1.LabelInstanceGeneration:                "5",
+				v1beta1.LabelInstanceFamily:                    "c5",
+				v1beta1.LabelInstanceSize:                      "large",
+				v1beta1.LabelInstanceCPU:                       "2",
+				v1beta1.LabelInstanceCPUCores:                  "1",
+				v1beta1.LabelInstanceCPUManufacturer:           "intel",
+				v1beta1.LabelInstanceMemory:                    "4096",
+				v1beta1.LabelInstanceEBSBandwidth:              "4750",
+				v1beta1.LabelInstanceNetworkBandwidth:          "750",
+				v1beta1.LabelInstanceBurstable:                 "false",
+				v1beta1.LabelInstanceEnclavesSupported:         "false",
+				v1beta1.LabelInstanceMaxIPs:                    "30",
+				v1beta1.LabelInstanceNetworkCards:              "1",
+				v1beta1.LabelInstanceMemoryPerVCPU:             "2",
+				v1beta1.LabelInstanceClusterPlacementSupported: "true",
+				v1beta1.LabelInstanceSpotSupported:             "true",
 			}
 			selectors.Insert(lo.Keys(nodeSelector)...) // Add node selector keys to selectors used in testing to ensure we test all labels
 			requirements := lo.MapToSlice(nodeSelector, func(key string, value string) v1.NodeSelectorRequirement {
@@ -129,6 +137,35 @@ var _ = Describe("Scheduling", Ordered, ContinueOnFailure, func() {
 			env.EventuallyExpectHealthyPodCount(labels.SelectorFromSet(deployment.Spec.Selector.MatchLabels), int(*deployment.Spec.Replicas))
 			env.ExpectCreatedNodeCount("==", 1)
 		})
+		It("should support well-known labels for numa nodes", func() {
+			selectors.Insert(v1beta1.LabelInstanceNUMANodes) // Add node selector keys to selectors used in testing to ensure we test all labels
+			deployment := test.Deployment(test.DeploymentOptions{Replicas: 1, PodOptions: test.PodOptions{
+				NodeRequirements: []v1.NodeSelectorRequirement{
+					{
+						Key:      v1beta1.LabelInstanceNUMANodes,
+						Operator: v1.NodeSelectorOpGt,
+						Values:   []string{"0"},
+					},
+				},
+			}})
+			env.ExpectCreated(nodeClass, nodePool, deployment)
+			env.EventuallyExpectHealthyPodCount(labels.SelectorFromSet(deployment.Spec.Selector.MatchLabels), int(*deployment.Spec.Replicas))
+			env.ExpectCreatedNodeCount("==", 1)
+		})
+		It("should support well-known labels for zone id", func() {
+			selectors.Insert(v1beta1.LabelTopologyZoneID) // Add node selector keys to selectors used in testing to ensure we test all labels
+			deployment := test.Deployment(test.DeploymentOptions{Replicas: 1, PodOptions: test.PodOptions{
+				NodeRequirements: []v1.NodeSelectorRequirement{
+					{
+						Key:      v1beta1.LabelTopologyZoneID,
+						Operator: v1.NodeSelectorOpExists,
+					},
+				},
+			}})
+			env.ExpectCreated(nodeClass, nodePool, deployment)
+			env.EventuallyExpectHealthyPodCount(labels.SelectorFromSet(deployment.Spec.Selector.MatchLabels), int(*deployment.Spec.Replicas))
+			env.ExpectCreatedNodeCount("==", 1)
+		})
 		It("should support well-known labels for encryption in transit", func() {
 			selectors.Insert(v1beta1.LabelInstanceEncryptionInTransitSupported) // Add node selector keys to selectors used in testing to ensure we test all labels
 			deployment := test.Deployment(test.DeploymentOptions{Replicas: 1, PodOptions: test.PodOptions{
@@ -73,7 +73,7 @@ func main() {
 	})
 	cloudProvider := cloudprovider.New(
 		op.InstanceTypesProvider,
-		op.InstanceProvider,
+		op.AccountProvider,
 		op.EventRecorder,
 		op.GetClient(),
 		op.AMIProvider,